@@ -0,0 +1,179 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Pooled allocation of the syntax trees.
+
+package build
+
+import "sync"
+
+// PooledAllocation makes the parser allocate the most common syntax tree nodes (strings,
+// identifiers, calls, ...) in chunks shared by the nodes of a file, make the tokens substrings of
+// a single copy of the file, and reuse its temporary buffers across parses. It cuts the number of
+// allocations, and therefore the garbage collection work, of tools that parse many files in one
+// process. The trees are the same and can be modified as usual, but a node or a token kept after
+// the rest of its tree is dropped keeps its chunk, or the text of the file, in memory.
+var PooledAllocation = false
+
+// maxChunk is the maximum number of nodes of a type allocated at once.
+const maxChunk = 256
+
+// A slab allocates values of a type in chunks of increasing size.
+type slab[T any] struct {
+	free []T
+	size int // size of the last chunk
+}
+
+func (s *slab[T]) new(x T) *T {
+	if len(s.free) == 0 {
+		s.size *= 2
+		if s.size < 16 {
+			s.size = 16
+		} else if s.size > maxChunk {
+			s.size = maxChunk
+		}
+		s.free = make([]T, s.size)
+	}
+	p := &s.free[0]
+	*p = x
+	s.free = s.free[1:]
+	return p
+}
+
+// newNode allocates a single node, without moving its argument to the heap when a slab is used.
+func newNode[T any](x T) *T {
+	p := new(T)
+	*p = x
+	return p
+}
+
+// An arena holds the nodes and the text of a file parsed with PooledAllocation. The methods of a
+// nil arena allocate the nodes individually.
+type arena struct {
+	text string // the input, the tokens are substrings of it
+
+	strings     slab[StringExpr]
+	idents      slab[Ident]
+	literals    slab[LiteralExpr]
+	calls       slab[CallExpr]
+	lists       slab[ListExpr]
+	keyValues   slab[KeyValueExpr]
+	dots        slab[DotExpr]
+	assignments slab[AssignExpr]
+	binaryExprs slab[BinaryExpr]
+}
+
+func (a *arena) stringExpr(x StringExpr) *StringExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.strings.new(x)
+}
+
+func (a *arena) ident(x Ident) *Ident {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.idents.new(x)
+}
+
+func (a *arena) literalExpr(x LiteralExpr) *LiteralExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.literals.new(x)
+}
+
+func (a *arena) callExpr(x CallExpr) *CallExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.calls.new(x)
+}
+
+func (a *arena) listExpr(x ListExpr) *ListExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.lists.new(x)
+}
+
+func (a *arena) keyValueExpr(x KeyValueExpr) *KeyValueExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.keyValues.new(x)
+}
+
+func (a *arena) dotExpr(x DotExpr) *DotExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.dots.new(x)
+}
+
+func (a *arena) assignExpr(x AssignExpr) *AssignExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.assignments.new(x)
+}
+
+func (a *arena) binaryExpr(x BinaryExpr) *BinaryExpr {
+	if a == nil {
+		return newNode(x)
+	}
+	return a.binaryExprs.new(x)
+}
+
+// token returns the text of a token starting at the given offset of the input.
+func (a *arena) token(offset int, tok []byte) string {
+	if a == nil {
+		return string(tok)
+	}
+	return a.text[offset : offset+len(tok)]
+}
+
+// commentBuffers are the preorder and postorder lists of the comment assignment, which only live
+// during a parse.
+type commentBuffers struct {
+	pre, post []Expr
+}
+
+var commentBufferPool = sync.Pool{
+	New: func() interface{} { return new(commentBuffers) },
+}
+
+// getCommentBuffers sets the lists of the comment assignment to empty pooled buffers.
+func (in *input) getCommentBuffers() *commentBuffers {
+	b := commentBufferPool.Get().(*commentBuffers)
+	in.pre, in.post = b.pre[:0], b.post[:0]
+	return b
+}
+
+// putCommentBuffers returns the lists of the comment assignment to the pool, without keeping the
+// nodes they reference alive.
+func (in *input) putCommentBuffers(b *commentBuffers) {
+	for i := range in.pre {
+		in.pre[i] = nil
+	}
+	for i := range in.post {
+		in.post[i] = nil
+	}
+	b.pre, b.post = in.pre[:0], in.post[:0]
+	in.pre, in.post = nil, nil
+	commentBufferPool.Put(b)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// benchmarkBuildFile returns a BUILD file with the given number of rules.
+func benchmarkBuildFile(rules int) []byte {
+	var b strings.Builder
+	b.WriteString("load(\"@rules_cc//cc:defs.bzl\", \"cc_library\")\n\n")
+	for i := 0; i < rules; i++ {
+		fmt.Fprintf(&b, `# Library %d.
+cc_library(
+    name = "lib%d",
+    srcs = ["lib%d.cc"] + select({
+        "//conditions:default": [],
+        ":linux": ["lib%d_linux.cc"],
+    }),
+    hdrs = glob(["lib%d/*.h"]),
+    copts = ["-O2", "-DLIB=%d"],
+    visibility = ["//visibility:public"],
+    deps = [
+        ":lib%d",
+        "//base",  # the base library
+    ],
+)
+
+`, i, i, i, i, i, i, i/2)
+	}
+	return []byte(b.String())
+}
+
+func setPooledAllocation(pooled bool) func() {
+	orig := PooledAllocation
+	PooledAllocation = pooled
+	return func() { PooledAllocation = orig }
+}
+
+func TestPooledAllocation(t *testing.T) {
+	inputs := []string{string(benchmarkBuildFile(50))}
+	for _, tt := range parseTests {
+		inputs = append(inputs, tt.in)
+	}
+	for _, input := range inputs {
+		restore := setPooledAllocation(false)
+		want, err := Parse("BUILD", []byte(input))
+		restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restore = setPooledAllocation(true)
+		got, err := Parse("BUILD", []byte(input))
+		restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(%q) with PooledAllocation differs", input)
+			compare(t, got, want)
+		}
+		if formatted := string(Format(got)); formatted != string(Format(want)) {
+			t.Errorf("Format(Parse(%q)) with PooledAllocation = %q, want %q", input, formatted, Format(want))
+		}
+	}
+}
+
+func benchmarkParse(b *testing.B, pooled bool) {
+	defer setPooledAllocation(pooled)()
+	data := benchmarkBuildFile(500)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseBuild("BUILD", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	benchmarkParse(b, false)
+}
+
+func BenchmarkParsePooled(b *testing.B) {
+	benchmarkParse(b, true)
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Parsing, formatting and editing of .bazelrc files.
+
+package build
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IsBazelrcFile returns true if the file at the given path is a .bazelrc file, e.g.
+// ".bazelrc", "ci.bazelrc" or "tools/bazel.rc".
+func IsBazelrcFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasSuffix(base, "bazelrc") || base == "bazel.rc"
+}
+
+// A BazelrcFile is a parsed .bazelrc file.
+type BazelrcFile struct {
+	Path  string
+	Lines []*BazelrcLine
+}
+
+// A BazelrcLine is a line of a .bazelrc file: a blank line, a comment line, an import or a
+// list of flags for a command, e.g. `build:ci --config=remote --jobs=50`. The lines of an
+// entry continued with backslashes are a single BazelrcLine.
+type BazelrcLine struct {
+	// Pos is the start of the line in the file.
+	Pos Position
+	// Command is the command the flags apply to ("build", "test", "common", ...) or
+	// "import" or "try-import" for imports, empty for blank and comment lines.
+	Command string
+	// Config is the name of the config, e.g. "ci" for `build:ci`, empty if there's none.
+	Config string
+	// Flags are the flags, or the path of an import, as written in the file (including
+	// quotes).
+	Flags []string
+	// Comment is the text of a comment line, or the comment at the end of an entry,
+	// including the "#".
+	Comment string
+	// Continued is true if the entry was split over several lines with backslashes, it's
+	// then formatted with one flag per line.
+	Continued bool
+}
+
+// IsImport returns true if the line is an import or try-import statement.
+func (l *BazelrcLine) IsImport() bool {
+	return l.Command == "import" || l.Command == "try-import"
+}
+
+// IsBlank returns true if the line is empty.
+func (l *BazelrcLine) IsBlank() bool {
+	return l.Command == "" && l.Comment == ""
+}
+
+// Name returns the command of the line followed by its config, e.g. "build:ci".
+func (l *BazelrcLine) Name() string {
+	if l.Config == "" {
+		return l.Command
+	}
+	return l.Command + ":" + l.Config
+}
+
+// ParseBazelrc parses a .bazelrc file. Errors are reported as ParseError values.
+func ParseBazelrc(filename string, data []byte) (*BazelrcFile, error) {
+	f := &BazelrcFile{Path: filename}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i := 0; i < len(lines); i++ {
+		pos := Position{Line: i + 1, LineRune: 1}
+		text := lines[i]
+		continued := false
+		for strings.HasSuffix(text, "\\") && i+1 < len(lines) {
+			i++
+			text = strings.TrimSuffix(text, "\\") + " " + lines[i]
+			continued = true
+		}
+		text = strings.TrimSpace(text)
+		line := &BazelrcLine{Pos: pos, Continued: continued}
+		f.Lines = append(f.Lines, line)
+		if text == "" {
+			continue
+		}
+		if strings.HasPrefix(text, "#") {
+			line.Comment = text
+			continue
+		}
+		tokens, comment, err := splitBazelrcLine(text)
+		if err != nil {
+			return nil, ParseError{Message: err.Error(), Filename: filename, Pos: pos}
+		}
+		line.Comment = comment
+		line.Command, line.Config, _ = strings.Cut(tokens[0], ":")
+		line.Flags = tokens[1:]
+		if line.IsImport() && len(line.Flags) != 1 {
+			return nil, ParseError{Message: line.Command + " expects exactly one path", Filename: filename, Pos: pos}
+		}
+	}
+	return f, nil
+}
+
+// splitBazelrcLine splits a line into tokens separated by unquoted whitespace, and returns the
+// comment at the end of the line if there's one. The tokens keep their quotes.
+func splitBazelrcLine(text string) (tokens []string, comment string, err error) {
+	var token strings.Builder
+	inToken := false
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(text) {
+				token.WriteByte(c)
+				i++
+				c = text[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == ' ' || c == '\t':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+			continue
+		case c == '#' && !inToken:
+			return tokens, strings.TrimSpace(text[i:]), nil
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\' && i+1 < len(text):
+			token.WriteByte(c)
+			i++
+			c = text[i]
+		}
+		token.WriteByte(c)
+		inToken = true
+	}
+	if quote != 0 {
+		return nil, "", errors.New("unterminated quote")
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, "", nil
+}
+
+// FormatBazelrc returns the formatted contents of a .bazelrc file: the tokens of each entry
+// are separated by single spaces, the flags of the entries continued with backslashes are
+// aligned one per line, consecutive blank lines are merged, and the imports within blocks of
+// consecutive imports are sorted.
+func FormatBazelrc(f *BazelrcFile) []byte {
+	var buf bytes.Buffer
+	lines := sortBazelrcImports(f.Lines)
+	blank := false
+	for _, line := range lines {
+		if line.IsBlank() {
+			blank = true
+			continue
+		}
+		// Blank lines at the start and at the end of the file are removed.
+		if blank && buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		blank = false
+		if line.Command == "" {
+			buf.WriteString(line.Comment)
+			buf.WriteByte('\n')
+			continue
+		}
+		head := line.Name()
+		buf.WriteString(head)
+		for j, flag := range line.Flags {
+			if j > 0 && line.Continued {
+				buf.WriteString(" \\\n")
+				buf.WriteString(strings.Repeat(" ", len(head)))
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(flag)
+		}
+		if line.Comment != "" {
+			buf.WriteString("  ")
+			buf.WriteString(line.Comment)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// sortBazelrcImports returns the lines with the imports within blocks of consecutive imports
+// sorted by path.
+func sortBazelrcImports(lines []*BazelrcLine) []*BazelrcLine {
+	sorted := append([]*BazelrcLine{}, lines...)
+	for start := 0; start < len(sorted); start++ {
+		end := start
+		for end < len(sorted) && sorted[end].IsImport() {
+			end++
+		}
+		block := sorted[start:end]
+		sort.SliceStable(block, func(i, j int) bool {
+			return block[i].Flags[0] < block[j].Flags[0]
+		})
+		start = end
+	}
+	return sorted
+}
+
+// splitBazelrcName splits a command name such as "build:ci" into the command and the config.
+func splitBazelrcName(name string) (command, config string) {
+	command, config, _ = strings.Cut(name, ":")
+	return command, config
+}
+
+// matchesBazelrcFlag returns true if the token sets the given flag. A flag without a value
+// also matches the tokens that set a value for it, e.g. "--jobs" matches "--jobs=50".
+func matchesBazelrcFlag(token, flag string) bool {
+	return token == flag || !strings.Contains(flag, "=") && strings.HasPrefix(token, flag+"=")
+}
+
+// Flags returns the flags of the entries for a command, e.g. "build" or "build:ci", in the
+// order of the file.
+func (f *BazelrcFile) Flags(name string) []string {
+	command, config := splitBazelrcName(name)
+	var flags []string
+	for _, line := range f.Lines {
+		if line.Command == command && line.Config == config {
+			flags = append(flags, line.Flags...)
+		}
+	}
+	return flags
+}
+
+// AddFlags adds flags to the entries for a command, e.g. "build:ci". Flags that are already
+// set for the command are skipped. The flags are appended to the last entry for the command,
+// or to a new entry after the last one for the same command with another config (or at the
+// end of the file). It returns true if the file was modified.
+func (f *BazelrcFile) AddFlags(name string, flags ...string) bool {
+	command, config := splitBazelrcName(name)
+	existing := make(map[string]bool)
+	for _, flag := range f.Flags(name) {
+		existing[flag] = true
+	}
+	var added []string
+	for _, flag := range flags {
+		if !existing[flag] {
+			existing[flag] = true
+			added = append(added, flag)
+		}
+	}
+	if len(added) == 0 {
+		return false
+	}
+
+	last, lastCommand := -1, -1
+	for i, line := range f.Lines {
+		if line.Command == command {
+			lastCommand = i
+			if line.Config == config {
+				last = i
+			}
+		}
+	}
+	if last >= 0 {
+		f.Lines[last].Flags = append(f.Lines[last].Flags, added...)
+		return true
+	}
+	line := &BazelrcLine{Command: command, Config: config, Flags: added}
+	if lastCommand < 0 {
+		f.Lines = append(f.Lines, line)
+		return true
+	}
+	f.Lines = append(f.Lines[:lastCommand+1], append([]*BazelrcLine{line}, f.Lines[lastCommand+1:]...)...)
+	return true
+}
+
+// RemoveFlags removes flags from the entries for a command, e.g. "build:ci". A flag without
+// a value also removes the tokens that set a value for it. Entries without flags left are
+// removed. It returns true if the file was modified.
+func (f *BazelrcFile) RemoveFlags(name string, flags ...string) bool {
+	command, config := splitBazelrcName(name)
+	modified := false
+	var lines []*BazelrcLine
+	for _, line := range f.Lines {
+		if line.Command != command || line.Config != config {
+			lines = append(lines, line)
+			continue
+		}
+		var kept []string
+		for _, token := range line.Flags {
+			removed := false
+			for _, flag := range flags {
+				if matchesBazelrcFlag(token, flag) {
+					removed = true
+					break
+				}
+			}
+			if !removed {
+				kept = append(kept, token)
+			}
+		}
+		if len(kept) == len(line.Flags) {
+			lines = append(lines, line)
+			continue
+		}
+		modified = true
+		if len(kept) > 0 {
+			line.Flags = kept
+			lines = append(lines, line)
+		}
+	}
+	f.Lines = lines
+	return modified
+}
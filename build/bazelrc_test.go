@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatBazelrc(t *testing.T) {
+	for _, tc := range []struct {
+		input, want string
+	}{
+		{
+			input: "build   --jobs=50    --keep_going\n",
+			want:  "build --jobs=50 --keep_going\n",
+		},
+		{
+			input: "\n\n# Remote builds.\nbuild:ci --config=remote \\\n  --jobs=50 \\\n --remote_timeout=3600  # seconds\n\n\n\ntest --test_output=errors\n\n",
+			want:  "# Remote builds.\nbuild:ci --config=remote \\\n         --jobs=50 \\\n         --remote_timeout=3600  # seconds\n\ntest --test_output=errors\n",
+		},
+		{
+			input: "try-import %workspace%/user.bazelrc\nimport %workspace%/tools/ci.bazelrc\n\nimport %workspace%/b.bazelrc\nimport %workspace%/a.bazelrc\n",
+			want:  "import %workspace%/tools/ci.bazelrc\ntry-import %workspace%/user.bazelrc\n\nimport %workspace%/a.bazelrc\nimport %workspace%/b.bazelrc\n",
+		},
+		{
+			input: "build --copt=\"-DNAME=a b\" --define='x=#y' --host_copt=-Wall#x\n",
+			want:  "build --copt=\"-DNAME=a b\" --define='x=#y' --host_copt=-Wall#x\n",
+		},
+	} {
+		f, err := ParseBazelrc(".bazelrc", []byte(tc.input))
+		if err != nil {
+			t.Errorf("ParseBazelrc(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if got := string(FormatBazelrc(f)); got != tc.want {
+			t.Errorf("FormatBazelrc(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestParseBazelrc(t *testing.T) {
+	f, err := ParseBazelrc(".bazelrc", []byte("# Comment\nbuild:ci --jobs=50 \"--copt=a b\"  # CI\n\nimport foo.bazelrc\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*BazelrcLine{
+		{Pos: Position{Line: 1, LineRune: 1}, Comment: "# Comment"},
+		{Pos: Position{Line: 2, LineRune: 1}, Command: "build", Config: "ci", Flags: []string{"--jobs=50", "\"--copt=a b\""}, Comment: "# CI"},
+		{Pos: Position{Line: 3, LineRune: 1}},
+		{Pos: Position{Line: 4, LineRune: 1}, Command: "import", Flags: []string{"foo.bazelrc"}},
+	}
+	if !reflect.DeepEqual(f.Lines, want) {
+		t.Errorf("ParseBazelrc() = %+v, want %+v", f.Lines, want)
+	}
+
+	for input, wantErr := range map[string]string{
+		"build --copt='-DX\n":  ".bazelrc:1:1: unterminated quote",
+		"\nimport a.rc b.rc\n": ".bazelrc:2:1: import expects exactly one path",
+	} {
+		if _, err := ParseBazelrc(".bazelrc", []byte(input)); err == nil || err.Error() != wantErr {
+			t.Errorf("ParseBazelrc(%q) error = %v, want %q", input, err, wantErr)
+		}
+	}
+}
+
+func TestBazelrcFlags(t *testing.T) {
+	f, err := ParseBazelrc(".bazelrc", []byte(`build --jobs=50
+build:ci --config=remote
+build:ci --remote_timeout=3600 --keep_going
+
+test --test_output=errors
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.AddFlags("build:ci", "--keep_going", "--jobs=100") {
+		t.Errorf("AddFlags(build:ci) didn't modify the file")
+	}
+	if f.AddFlags("build:ci", "--config=remote") {
+		t.Errorf("AddFlags(build:ci) with an existing flag modified the file")
+	}
+	f.AddFlags("build:opt", "--compilation_mode=opt")
+	f.AddFlags("run", "--run_under=//tools:wrapper")
+	if !f.RemoveFlags("build:ci", "--config", "--remote_timeout") {
+		t.Errorf("RemoveFlags(build:ci) didn't modify the file")
+	}
+	if f.RemoveFlags("test", "--test_output=all") {
+		t.Errorf("RemoveFlags(test) with a missing flag modified the file")
+	}
+
+	want := `build --jobs=50
+build:ci --keep_going --jobs=100
+build:opt --compilation_mode=opt
+
+test --test_output=errors
+run --run_under=//tools:wrapper
+`
+	if got := string(FormatBazelrc(f)); got != want {
+		t.Errorf("FormatBazelrc() =\n%s\nwant:\n%s", got, want)
+	}
+	if got, want := f.Flags("build:ci"), []string{"--keep_going", "--jobs=100"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Flags(build:ci) = %v, want %v", got, want)
+	}
+}
+
+func TestIsBazelrcFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		".bazelrc":           true,
+		"tools/ci.bazelrc":   true,
+		"tools/bazel.rc":     true,
+		"BUILD":              false,
+		"tools/bazelrc.bzl":  false,
+		"tools/settings.txt": false,
+	} {
+		if got := IsBazelrcFile(path); got != want {
+			t.Errorf("IsBazelrcFile(%q) = %t, want %t", path, got, want)
+		}
+	}
+}
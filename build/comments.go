@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+// DetachComments prepares a sequence of statements (the statements of a file or of a block)
+// to be reordered or moved to another file, so that each statement only carries the comments
+// that describe it:
+//
+//   - the comments directly below a statement, which the parser attaches to it as After
+//     comments when they're followed by a blank line or by the end of the block, are moved
+//     to a standalone CommentBlock statement following it;
+//   - the suffix comments of the last subexpression of a statement (e.g. of the value of
+//     `x = y  # comment`) are moved to the statement itself;
+//   - nil statements are removed.
+//
+// The Before comments of a statement stay with it, and the comment blocks are standalone
+// statements that callers can keep in place. After the statements have been reordered,
+// ReattachComments should be called on the result.
+func DetachComments(stmts []Expr) []Expr {
+	var result []Expr
+	for _, stmt := range stmts {
+		if stmt == nil {
+			continue
+		}
+		result = append(result, stmt)
+		if _, ok := stmt.(*CommentBlock); ok {
+			continue
+		}
+		hoistSuffixComments(stmt)
+		com := stmt.Comment()
+		if len(com.After) == 0 {
+			continue
+		}
+		result = append(result, &CommentBlock{
+			Start:    com.After[0].Start,
+			Comments: Comments{After: com.After},
+		})
+		com.After = nil
+	}
+	return result
+}
+
+// ReattachComments fixes the comments of a sequence of statements after they have been
+// reordered or moved, see DetachComments. Nil statements and comment blocks without comments
+// are removed, and a comment block that still directly follows the statement it was detached
+// from (i.e. it starts on the line after the end of the statement) is attached to it again,
+// so that the statements that haven't been moved are printed as they were.
+func ReattachComments(stmts []Expr) []Expr {
+	var result []Expr
+	for _, stmt := range stmts {
+		if stmt == nil {
+			continue
+		}
+		cb, ok := stmt.(*CommentBlock)
+		if !ok {
+			result = append(result, stmt)
+			continue
+		}
+		com := cb.Comment()
+		if len(com.Before) == 0 && len(com.Suffix) == 0 && len(com.After) == 0 {
+			continue
+		}
+		if n := len(result); n > 0 && len(com.Before) == 0 && len(com.Suffix) == 0 && len(com.After) > 0 {
+			prev := result[n-1]
+			if _, isComment := prev.(*CommentBlock); !isComment {
+				_, end := prev.Span()
+				if start := com.After[0].Start; start.Line > 0 && end.Line > 0 && start.Line == end.Line+1 {
+					prevCom := prev.Comment()
+					prevCom.After = append(prevCom.After, com.After...)
+					continue
+				}
+			}
+		}
+		result = append(result, cb)
+	}
+	return result
+}
+
+// hoistSuffixComments moves the suffix comments of the last subexpression of a statement,
+// which are printed at the end of the statement anyway, to the statement itself.
+func hoistSuffixComments(stmt Expr) {
+	var suffix []Comment
+	for x := lastSubexpression(stmt); x != nil; x = lastSubexpression(x) {
+		com := x.Comment()
+		// The inner expressions are printed first, so are their suffix comments.
+		suffix = append(append([]Comment{}, com.Suffix...), suffix...)
+		com.Suffix = nil
+	}
+	if len(suffix) > 0 {
+		com := stmt.Comment()
+		com.Suffix = append(suffix, com.Suffix...)
+	}
+}
+
+// lastSubexpression returns the subexpression of x that ends where x ends, or nil if x ends
+// with a token, such as a closing bracket.
+func lastSubexpression(x Expr) Expr {
+	switch x := x.(type) {
+	case *AssignExpr:
+		return x.RHS
+	case *BinaryExpr:
+		return x.Y
+	case *UnaryExpr:
+		return x.X
+	case *ConditionalExpr:
+		return x.Else
+	case *ReturnStmt:
+		return x.Result
+	case *TypedIdent:
+		return x.Type
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"testing"
+)
+
+const commentsInput = `# Header.
+
+# About a.
+a()
+
+b = c + d  # b
+# Below b.
+
+# Footer.
+`
+
+func TestDetachReattachComments(t *testing.T) {
+	f, err := ParseBzl("test.bzl", []byte(commentsInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Stmt = ReattachComments(DetachComments(f.Stmt))
+	if got := string(FormatWithoutRewriting(f)); got != commentsInput {
+		t.Errorf("detaching and reattaching the comments changed the file:\n%s\nwant:\n%s", got, commentsInput)
+	}
+
+	f, err = ParseBzl("test.bzl", []byte(commentsInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmts := DetachComments(f.Stmt)
+	if len(stmts) != 5 {
+		t.Fatalf("DetachComments() returned %d statements, want 5", len(stmts))
+	}
+	// Swap the statements, keeping the comment blocks where they are.
+	stmts[1], stmts[2] = stmts[2], stmts[1]
+	f.Stmt = ReattachComments(stmts)
+	want := `# Header.
+
+b = c + d  # b
+
+# About a.
+a()
+
+# Below b.
+
+# Footer.
+`
+	if got := string(FormatWithoutRewriting(f)); got != want {
+		t.Errorf("reordered statements:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDetachCommentsSuffix(t *testing.T) {
+	f, err := ParseBzl("test.bzl", []byte("x = y + z  # comment\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a tool that attached the comment to the value.
+	assign := f.Stmt[0].(*AssignExpr)
+	assign.RHS.(*BinaryExpr).Y.Comment().Suffix, assign.Suffix = assign.Suffix, nil
+
+	DetachComments(f.Stmt)
+	if len(assign.Suffix) != 1 || len(assign.RHS.(*BinaryExpr).Y.Comment().Suffix) != 0 {
+		t.Errorf("DetachComments() didn't move the suffix comment to the statement")
+	}
+	assign.RHS = &Ident{Name: "w"}
+	if got, want := string(FormatWithoutRewriting(f)), "x = w  # comment\n"; got != want {
+		t.Errorf("replaced value: got %q, want %q", got, want)
+	}
+}
+
+func TestMoveLoadOnTopComments(t *testing.T) {
+	f, err := ParseBzl("test.bzl", []byte(`foo()
+
+# The bar macro.
+load(":foo.bzl", "bar")
+# The end of the file.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# The bar macro.
+load(":foo.bzl", "bar")
+
+foo()
+
+# The end of the file.
+`
+	if got := string(Format(f)); got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
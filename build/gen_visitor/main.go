@@ -0,0 +1,122 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Generator of the Visitor interface of the build package, see build/visitor.go.
+//
+// Usage (from the build directory, see the go:generate directive of syntax.go):
+//
+//	go run ./gen_visitor -src syntax.go -out visitor.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+)
+
+var (
+	src = flag.String("src", "syntax.go", "Go file defining the syntax tree node types")
+	out = flag.String("out", "visitor.go", "Go file to write the visitor to")
+)
+
+// nodeTypes returns the names of the types of a Go file implementing the Expr interface, in
+// the order of their declarations. The node types are recognized by their Copy() Expr method
+// with a pointer receiver.
+func nodeTypes(filename string) ([]string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var types []string
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || fn.Name.Name != "Copy" || len(fn.Recv.List) != 1 {
+			continue
+		}
+		star, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if ident, ok := star.X.(*ast.Ident); ok {
+			types = append(types, ident.Name)
+		}
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("%s: no node types found", filename)
+	}
+	return types, nil
+}
+
+// generate returns the source code of the visitor of the given node types.
+func generate(types []string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by build/gen_visitor from %s. DO NOT EDIT.\n\n", *src)
+	buf.WriteString("package build\n\n")
+	buf.WriteString("import \"fmt\"\n\n")
+	buf.WriteString(`// Visitor is implemented by the analyses that handle each type of node of the syntax tree. It
+// has one method per node type, so that adding a node type breaks the compilation of the
+// implementations instead of letting them silently skip the new nodes. Implementations should
+// therefore not embed another Visitor to inherit its methods.
+//
+// The methods are called by Visit, they decide whether and in which order the children of the
+// nodes are visited.
+type Visitor interface {
+`)
+	for _, t := range types {
+		fmt.Fprintf(&buf, "\tVisit%s(x *%s)\n", t, t)
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString(`// Visit calls the method of the visitor for the type of the node, it does nothing if the node
+// is nil. It panics if the type of the node isn't a node type of this package.
+func Visit(v Visitor, x Expr) {
+	switch x := x.(type) {
+	case nil:
+		return
+`)
+	for _, t := range types {
+		fmt.Fprintf(&buf, "\tcase *%s:\n\t\tv.Visit%s(x)\n", t, t)
+	}
+	buf.WriteString(`	default:
+		panic(fmt.Sprintf("build.Visit: unexpected node type %T", x))
+	}
+}
+`)
+	return format.Source(buf.Bytes())
+}
+
+func main() {
+	flag.Parse()
+	types, err := nodeTypes(*src)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen_visitor: %v\n", err)
+		os.Exit(1)
+	}
+	code, err := generate(types)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gen_visitor: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, code, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "gen_visitor: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// The comments that delimit the regions of a file maintained by a code generator, e.g. a block
+// of rules between hand-written ones. The regions are formatted like the rest of the file, but
+// the lint findings within them are marked as generated and their rules aren't modified by
+// buildozer unless it's forced to.
+const (
+	BeginGeneratedDirective = "buildifier: begin-generated"
+	EndGeneratedDirective   = "buildifier: end-generated"
+)
+
+// A GeneratedRegion is a range of lines delimited by the generated-region directives, including
+// the lines of the directives themselves.
+type GeneratedRegion struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether a line is within the region.
+func (r GeneratedRegion) Contains(line int) bool {
+	return line >= r.Start && line <= r.End
+}
+
+// isDirective reports whether a comment starts with a directive, ignoring the case.
+func isDirective(com Comment, directive string) bool {
+	text := strings.TrimSpace(strings.TrimPrefix(com.Token, "#"))
+	return strings.HasPrefix(strings.ToLower(text), directive)
+}
+
+// GeneratedRegions returns the generated regions of a file, in order. A region without an end
+// directive extends to the end of the file, and the end directives outside of regions are
+// ignored.
+func GeneratedRegions(f *File) []GeneratedRegion {
+	var comments []Comment
+	Walk(f, func(x Expr, stk []Expr) {
+		com := x.Comment()
+		comments = append(comments, com.Before...)
+		comments = append(comments, com.Suffix...)
+		comments = append(comments, com.After...)
+	})
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].Start.Line < comments[j].Start.Line
+	})
+
+	var regions []GeneratedRegion
+	inRegion := false
+	for _, com := range comments {
+		switch {
+		case !inRegion && isDirective(com, BeginGeneratedDirective):
+			regions = append(regions, GeneratedRegion{Start: com.Start.Line, End: math.MaxInt})
+			inRegion = true
+		case inRegion && isDirective(com, EndGeneratedDirective):
+			regions[len(regions)-1].End = com.Start.Line
+			inRegion = false
+		}
+	}
+	return regions
+}
+
+// InGeneratedRegion reports whether a line is within one of the given regions.
+func InGeneratedRegion(regions []GeneratedRegion, line int) bool {
+	for _, r := range regions {
+		if r.Contains(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestGeneratedRegions(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  []GeneratedRegion
+	}{
+		{
+			input: `cc_library(name = "a")
+`,
+			want: nil,
+		},
+		{
+			input: `cc_library(name = "a")
+
+# buildifier: begin-generated
+cc_library(name = "b")
+
+cc_library(
+    name = "c",
+    deps = [":b"],  # buildifier: end-generated
+)
+
+cc_library(name = "d")
+
+# Buildifier: Begin-Generated by tool
+cc_library(name = "e")
+
+# buildifier: end-generated
+`,
+			want: []GeneratedRegion{{3, 8}, {13, 16}},
+		},
+		{
+			input: `# buildifier: end-generated
+cc_library(name = "a")
+
+def f():
+    # buildifier: begin-generated
+    native.cc_library(name = "b")
+`,
+			want: []GeneratedRegion{{5, math.MaxInt}},
+		},
+	} {
+		f, err := Parse("BUILD", []byte(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := GeneratedRegions(f); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("GeneratedRegions(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+
+	regions := []GeneratedRegion{{3, 8}, {13, 16}}
+	for line, want := range map[int]bool{1: false, 3: true, 5: true, 8: true, 9: false, 16: true, 17: false} {
+		if got := InGeneratedRegion(regions, line); got != want {
+			t.Errorf("InGeneratedRegion(%d) = %v, want %v", line, got, want)
+		}
+	}
+}
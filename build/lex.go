@@ -44,6 +44,10 @@ const (
 	TypeBzl
 	// TypeModule represents MODULE.bazel and *.MODULE.bazel files
 	TypeModule
+	// TypeRepo represents REPO.bazel files
+	TypeRepo
+	// TypeVendor represents VENDOR.bazel files
+	TypeVendor
 )
 
 func (t FileType) String() string {
@@ -58,6 +62,10 @@ func (t FileType) String() string {
 		return ".bzl"
 	case TypeModule:
 		return "MODULE.bazel"
+	case TypeRepo:
+		return "REPO.bazel"
+	case TypeVendor:
+		return "VENDOR.bazel"
 	}
 	return "unknown"
 }
@@ -98,6 +106,30 @@ func ParseModule(filename string, data []byte) (*File, error) {
 	return f, err
 }
 
+// ParseRepo parses a file, marks it as a REPO.bazel file and returns the corresponding parse tree.
+//
+// The filename is used only for generating error messages.
+func ParseRepo(filename string, data []byte) (*File, error) {
+	in := newInput(filename, data)
+	f, err := in.parse()
+	if f != nil {
+		f.Type = TypeRepo
+	}
+	return f, err
+}
+
+// ParseVendor parses a file, marks it as a VENDOR.bazel file and returns the corresponding parse tree.
+//
+// The filename is used only for generating error messages.
+func ParseVendor(filename string, data []byte) (*File, error) {
+	in := newInput(filename, data)
+	f, err := in.parse()
+	if f != nil {
+		f.Type = TypeVendor
+	}
+	return f, err
+}
+
 // ParseBzl parses a file, marks it as a .bzl file and returns the corresponding parse tree.
 //
 // The filename is used only for generating error messages.
@@ -133,6 +165,12 @@ func getFileType(filename string) FileType {
 	if basename == "module.bazel" || strings.HasSuffix(basename, ".module.bazel") {
 		return TypeModule
 	}
+	switch basename {
+	case "repo.bazel":
+		return TypeRepo
+	case "vendor.bazel":
+		return TypeVendor
+	}
 	ext := filepath.Ext(basename)
 	switch ext {
 	case ".bzl":
@@ -162,6 +200,10 @@ func Parse(filename string, data []byte) (*File, error) {
 		return ParseWorkspace(filename, data)
 	case TypeModule:
 		return ParseModule(filename, data)
+	case TypeRepo:
+		return ParseRepo(filename, data)
+	case TypeVendor:
+		return ParseVendor(filename, data)
 	case TypeBzl:
 		return ParseBzl(filename, data)
 	}
@@ -173,6 +215,9 @@ type ParseError struct {
 	Message  string
 	Filename string
 	Pos      Position
+	// PrintStatement is true if the error is caused by a Python 2 print statement
+	// (e.g. print "hello"), Pos is the position right after the print keyword.
+	PrintStatement bool
 }
 
 // Error returns a string representation of the parse error.
@@ -189,6 +234,8 @@ type input struct {
 	// Lexing state.
 	filename       string    // name of input file, for errors
 	complete       []byte    // entire input
+	bom            bool      // the input starts with a UTF-8 byte order mark
+	crlf           bool      // the input uses CRLF line endings
 	remaining      []byte    // remaining input
 	token          []byte    // token being scanned
 	lastToken      string    // most recently returned token, for error messages
@@ -196,26 +243,59 @@ type input struct {
 	lineComments   []Comment // accumulated line comments
 	suffixComments []Comment // accumulated suffix comments
 	depth          int       // nesting of [ ] { } ( )
+	brackets       []int     // stack of currently open brackets, indexBracket for index expressions
+	prevTokens     [2]int    // the two most recently returned tokens, the last one second
 	cleanLine      bool      // true if the current line only contains whitespace before the current position
 	indent         int       // current line indentation in spaces
 	indents        []int     // stack of indentation levels in spaces
+	tabIndented    bool      // true if a line was indented with tabs, see AllowTabIndentation
+
+	// Python 2 print statements, kept for the diagnostics and the fixes.
+	afterPrint    bool     // true if the most recently returned token is a print at the beginning of a statement
+	printArgument bool     // true if the most recently returned token directly follows such a print
+	printEnd      Position // position right after the most recent print at the beginning of a statement
+
+	// Missing commas between string literals in lists, see AllowMissingCommas.
+	pendingString *yySymType        // string literal to return after the inserted comma
+	missingCommas map[Position]bool // start positions of the list items following missing commas
 
 	// Parser state.
-	file       *File // returned top-level syntax tree
-	parseError error // error encountered during parsing
+	file       *File  // returned top-level syntax tree
+	parseError error  // error encountered during parsing
+	arena      *arena // allocator of the nodes with PooledAllocation, nil otherwise
 
 	// Comment assignment state.
 	pre  []Expr // all expressions, in preorder traversal
 	post []Expr // all expressions, in postorder traversal
 }
 
+// AllowTabIndentation makes the parser accept files indented with tabs, e.g. by code
+// generators. A tab advances the indentation to the next multiple of 8 columns, and the
+// formatted files are indented with spaces. By default tabs aren't part of the indentation,
+// so tab-indented blocks are syntax errors.
+var AllowTabIndentation = false
+
+// AllowMissingCommas makes the parser insert the commas that are most likely missing between
+// adjacent string literals in list literals, e.g. ["//a" "//b"]. The strings are parsed as
+// separate items, the ones following a missing comma are marked with StringExpr.MissingComma
+// and printed as they are until the missing-comma warning fixes them. By default adjacent
+// string literals are syntax errors, Starlark doesn't concatenate them implicitly.
+var AllowMissingCommas = false
+
+// indexBracket is pushed on the stack of open brackets for the opening bracket of an index
+// expression or a slice, e.g. x[0], to distinguish it from the bracket of a list literal.
+const indexBracket = -'['
+
+// utf8BOM is the UTF-8 encoded byte order mark, some editors on Windows prepend it to the files.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
 func newInput(filename string, data []byte) *input {
 	// The syntax requires that each simple statement ends with '\n', however it's optional at EOF.
 	// If `data` doesn't end with '\n' we add it here to keep parser simple.
 	// It shouldn't affect neither the parsed tree nor its formatting.
 	data = append(data, '\n')
 
-	return &input{
+	in := &input{
 		filename:  filename,
 		complete:  data,
 		remaining: data,
@@ -223,12 +303,47 @@ func newInput(filename string, data []byte) *input {
 		cleanLine: true,
 		indents:   []int{0},
 	}
+	if PooledAllocation {
+		in.arena = &arena{text: string(data)}
+	}
+
+	// Skip the byte order mark, the byte offsets of the tokens remain relative to the complete input.
+	if bytes.HasPrefix(data, utf8BOM) {
+		in.bom = true
+		in.remaining = data[len(utf8BOM):]
+		in.pos.Byte = len(utf8BOM)
+	}
+	// The line ending convention is determined by the first line. The lexer treats '\r' as
+	// whitespace, so CRLF line endings don't need any other special handling.
+	if i := bytes.IndexByte(data, '\n'); i > 0 && data[i-1] == '\r' {
+		in.crlf = true
+	}
+	return in
 }
 
 func (in *input) currentIndent() int {
 	return in.indents[len(in.indents)-1]
 }
 
+// markMissingCommas marks the string literals of the lists that follow the previous items without
+// a comma, see StringExpr.MissingComma.
+func (in *input) markMissingCommas() {
+	if len(in.missingCommas) == 0 {
+		return
+	}
+	Walk(in.file, func(x Expr, stk []Expr) {
+		list, ok := x.(*ListExpr)
+		if !ok {
+			return
+		}
+		for _, item := range list.List {
+			if str, ok := item.(*StringExpr); ok && in.missingCommas[str.Start] {
+				str.MissingComma = true
+			}
+		}
+	})
+}
+
 // parse parses the input file.
 func (in *input) parse() (f *File, err error) {
 	// The parser panics for both routine errors like syntax errors
@@ -251,6 +366,10 @@ func (in *input) parse() (f *File, err error) {
 		return nil, in.parseError
 	}
 	in.file.Path = in.filename
+	in.file.BOM = in.bom
+	in.file.CRLF = in.crlf
+	in.file.TabIndented = in.tabIndented
+	in.markMissingCommas()
 
 	// Assign comments to nearby syntax.
 	in.assignComments()
@@ -262,9 +381,15 @@ func (in *input) parse() (f *File, err error) {
 // When called by the generated code s is always "syntax error".
 // Error does not return: it panics.
 func (in *input) Error(s string) {
-	if s == "syntax error" && in.lastToken != "" {
+	syntaxError := s == "syntax error"
+	if syntaxError && in.lastToken != "" {
 		s += " near " + in.lastToken
 	}
+	if syntaxError && in.printArgument {
+		// A print followed by an argument without parentheses, Python 2 style.
+		in.parseError = ParseError{Message: s, Filename: in.filename, Pos: in.printEnd, PrintStatement: true}
+		panic(in.parseError)
+	}
 	in.parseError = ParseError{Message: s, Filename: in.filename, Pos: in.pos}
 	panic(in.parseError)
 }
@@ -318,7 +443,7 @@ func (in *input) startToken(val *yySymType) {
 // has not done that already.
 func (in *input) endToken(val *yySymType) {
 	if val.tok == "" {
-		tok := string(in.peekToken())
+		tok := in.arena.token(len(in.complete)-len(in.token), in.peekToken())
 		val.tok = tok
 		in.lastToken = val.tok
 	}
@@ -337,6 +462,67 @@ func (in *input) peekToken() []byte {
 // val.Pos (the position where the token begins)
 // and val.Token (the input string corresponding to the token).
 func (in *input) Lex(val *yySymType) int {
+	tok := _STRING
+	if in.pendingString != nil {
+		// The string literal following an inserted comma, see below.
+		*val = *in.pendingString
+		in.pendingString = nil
+	} else {
+		tok = in.lex(val)
+	}
+	if tok == _STRING && in.missingComma() {
+		if !AllowMissingCommas {
+			in.pos = val.pos
+			in.Error("implicit string concatenation is not allowed, a comma is probably missing between the list items")
+		}
+		// The comma is returned first, the string literal is returned by the next call.
+		pending := *val
+		in.pendingString = &pending
+		if in.missingCommas == nil {
+			in.missingCommas = make(map[Position]bool)
+		}
+		in.missingCommas[val.pos] = true
+		val.tok = ","
+		tok = ','
+	}
+	in.prevTokens[0], in.prevTokens[1] = in.prevTokens[1], tok
+	return tok
+}
+
+// missingComma reports whether a comma is most likely missing before the string literal that has
+// just been scanned: it directly follows another string literal in a list literal, and both are
+// complete list items, e.g. "//b" in ["//a" "//b"] but not in x["//a" "//b"] or ["//a" "//b" + c].
+func (in *input) missingComma() bool {
+	if in.prevTokens[1] != _STRING || in.prevTokens[0] != '[' && in.prevTokens[0] != ',' {
+		return false
+	}
+	if len(in.brackets) == 0 || in.brackets[len(in.brackets)-1] != '[' {
+		return false
+	}
+	// The string literal must be followed by the end of the item, skipping the whitespace and
+	// the comments in between.
+	rest := in.remaining
+	for len(rest) > 0 {
+		switch rest[0] {
+		case ' ', '\t', '\r', '\n':
+			rest = rest[1:]
+			continue
+		case '#':
+			if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+				rest = rest[i:]
+				continue
+			}
+			return false
+		case ',', ']', '"', '\'':
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+// lex scans the next input token, see Lex.
+func (in *input) lex(val *yySymType) int {
 	// Skip past spaces, stopping at non-space or EOF.
 	countNL := 0 // number of newlines we've skipped past
 	for !in.eof() {
@@ -358,6 +544,10 @@ func (in *input) Lex(val *yySymType) int {
 				countNL++
 			} else if c == ' ' && in.cleanLine {
 				in.indent++
+			} else if c == '\t' && in.cleanLine && in.depth == 0 && AllowTabIndentation {
+				// Like in Python, a tab advances the indentation to the next multiple of 8.
+				in.indent += 8 - in.indent%8
+				in.tabIndented = true
 			}
 			in.readRune()
 			continue
@@ -460,6 +650,7 @@ func (in *input) Lex(val *yySymType) int {
 		}
 	}
 
+	startOfStatement := in.depth == 0 && (in.cleanLine || in.lastToken == "indent" || in.lastToken == ";")
 	in.cleanLine = false
 
 	// If the file ends with an indented block, return the corresponding amounts of unindents.
@@ -472,6 +663,8 @@ func (in *input) Lex(val *yySymType) int {
 	// Found the beginning of the next token.
 	in.startToken(val)
 	defer in.endToken(val)
+	in.printArgument = in.afterPrint
+	in.afterPrint = false
 
 	// End of file.
 	if in.eof() {
@@ -483,11 +676,19 @@ func (in *input) Lex(val *yySymType) int {
 	switch c := in.peekRune(); c {
 	case '[', '(', '{':
 		in.depth++
+		if c == '[' && in.afterOperand() {
+			in.brackets = append(in.brackets, indexBracket)
+		} else {
+			in.brackets = append(in.brackets, c)
+		}
 		in.readRune()
 		return c
 
 	case ']', ')', '}':
 		in.depth--
+		if len(in.brackets) > 0 {
+			in.brackets = in.brackets[:len(in.brackets)-1]
+		}
 		in.readRune()
 		return c
 
@@ -625,9 +826,14 @@ func (in *input) Lex(val *yySymType) int {
 		c := in.peekRune()
 		if !isIdent(c) {
 			if c == '+' || c == '-' {
-				t := in.peekToken()
-				// Parse 12.3e-4 and 12.3E+4 as a single token.
-				if !(len(t) > 0 && t[0] >= '0' && t[0] <= '9' && (t[len(t)-1] == 'e' || t[len(t)-1] == 'E')) {
+				// Parse 12.3e-4, 12.3E+4 and the hexadecimal float 0x1p-4 as a single token.
+				if !endsWithExponent(in.peekToken()) {
+					break
+				}
+			} else if c == '.' {
+				// Parse 1.e5 and the hexadecimal float 0x1.8p3 as a single token, the
+				// other floats are parsed as numbers separated by a dot.
+				if !continuesFloat(in.peekToken(), in.remaining) {
 					break
 				}
 			} else {
@@ -643,6 +849,10 @@ func (in *input) Lex(val *yySymType) int {
 	if k := keywordToken[val.tok]; k != 0 {
 		return k
 	}
+	if val.tok == "print" && startOfStatement {
+		in.afterPrint = true
+		in.printEnd = in.pos
+	}
 	switch val.tok {
 	case "pass":
 		return _PASS
@@ -657,6 +867,79 @@ func (in *input) Lex(val *yySymType) int {
 	return _IDENT
 }
 
+// afterOperand reports whether the most recently returned token ends an operand, so that an
+// opening bracket following it starts an index expression rather than a list literal.
+func (in *input) afterOperand() bool {
+	switch in.prevTokens[1] {
+	case _IDENT, _STRING, _INT, ')', ']', '}':
+		return true
+	}
+	return false
+}
+
+// isHexNumber reports whether a number token is hexadecimal.
+func isHexNumber(t []byte) bool {
+	return len(t) > 1 && t[0] == '0' && (t[1] == 'x' || t[1] == 'X')
+}
+
+// endsWithExponent reports whether a number token ends with the exponent marker of a float,
+// i.e. 'e' or 'E' for decimal numbers and 'p' or 'P' for hexadecimal ones, so that it can
+// be followed by the sign of the exponent.
+func endsWithExponent(t []byte) bool {
+	if len(t) == 0 || t[0] < '0' || t[0] > '9' {
+		return false
+	}
+	last := t[len(t)-1]
+	if isHexNumber(t) {
+		return last == 'p' || last == 'P'
+	}
+	if len(t) > 1 && t[0] == '0' && strings.ContainsRune("oObB", rune(t[1])) {
+		return false
+	}
+	return last == 'e' || last == 'E'
+}
+
+// isFloatToken reports whether a number token is a float literal rather than an integer.
+func isFloatToken(token string) bool {
+	if isHexNumber([]byte(token)) {
+		return strings.ContainsAny(token, "pP")
+	}
+	return strings.ContainsAny(token, ".eE")
+}
+
+// continuesFloat reports whether the dot at the beginning of rest continues the number token
+// t as a float that the parser wouldn't recognize otherwise: a decimal number followed by a
+// dot and an exponent (1.e5), or a hexadecimal number followed by a fraction and a binary
+// exponent (0x1.8p3).
+func continuesFloat(t, rest []byte) bool {
+	if len(t) == 0 || t[0] < '0' || t[0] > '9' || bytes.IndexByte(t, '.') >= 0 {
+		return false
+	}
+	i := 1 // skip the dot
+	if isHexNumber(t) {
+		for i < len(rest) && strings.IndexByte("0123456789abcdefABCDEF", rest[i]) >= 0 {
+			i++
+		}
+		if i == len(rest) || (rest[i] != 'p' && rest[i] != 'P') {
+			return false
+		}
+	} else {
+		for _, c := range t {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+		if i == len(rest) || (rest[i] != 'e' && rest[i] != 'E') {
+			return false
+		}
+	}
+	i++
+	if i < len(rest) && (rest[i] == '+' || rest[i] == '-') {
+		i++
+	}
+	return i < len(rest) && rest[i] >= '0' && rest[i] <= '9'
+}
+
 // isIdent reports whether c is an identifier rune.
 // We treat all non-ASCII runes as identifier runes.
 func isIdent(c int) bool {
@@ -840,6 +1123,9 @@ func (in *input) order(v Expr) {
 // assignComments attaches comments to nearby syntax.
 func (in *input) assignComments() {
 	// Generate preorder and postorder lists.
+	if in.arena != nil {
+		defer in.putCommentBuffers(in.getCommentBuffers())
+	}
 	in.order(in.file)
 	in.assignSuffixComments()
 	in.assignLineComments()
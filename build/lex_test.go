@@ -17,7 +17,11 @@ limitations under the License.
 package build
 
 import (
+	"math/big"
+	"strings"
 	"testing"
+
+	"go.starlark.net/syntax"
 )
 
 func TestIsBuildFilename(t *testing.T) {
@@ -52,6 +56,11 @@ func TestIsBuildFilename(t *testing.T) {
 		"module.bazel":        TypeModule,
 		"module.bzl":          TypeBzl,
 		"MODULE":              TypeDefault,
+		"REPO.bazel":          TypeRepo,
+		"REPO.bazel.oss":      TypeRepo,
+		"repo.bzl":            TypeBzl,
+		"VENDOR.bazel":        TypeVendor,
+		"vendor.bzl":          TypeBzl,
 	}
 	for name, fileType := range cases {
 		res := getFileType(name)
@@ -60,3 +69,100 @@ func TestIsBuildFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestFloatLiterals(t *testing.T) {
+	for _, tc := range []struct {
+		input, want string
+	}{
+		{"1.5", "1.5"},
+		{".5", "0.5"},
+		{"1.", "1."},
+		{"1e10", "1e10"},
+		{"1.5E+3", "1.5e3"},
+		{".5e-03", "0.5e-3"},
+		{"1.e5", "1.e5"},
+		{"1.E-05", "1.e-5"},
+		{"1.5e+0", "1.5e0"},
+		{"1.5e-00", "1.5e0"},
+		{"09.5", "09.5"},
+		{"0x1p-2", "0x1p-2"},
+		{"0x1.8P+3", "0x1.8P+3"},
+		{"0x1e-2", "0x1e - 2"},
+		{"0X1E+2", "0X1E + 2"},
+		{"0o17", "0o17"},
+		{"017", "0o17"},
+		{"2e-1-1", "2e-1 - 1"},
+		{"1.0.hex()", "1.0.hex()"},
+	} {
+		f, err := ParseDefault("test.bzl", []byte("x = "+tc.input+"\n"))
+		if err != nil {
+			t.Errorf("ParseDefault(%q) failed: %v", tc.input, err)
+			continue
+		}
+		if got, want := string(Format(f)), "x = "+tc.want+"\n"; got != want {
+			t.Errorf("Format(%q) = %q, want %q", tc.input, got, want)
+		}
+	}
+}
+
+// FuzzFloatLiteral checks that the numbers accepted by the Starlark scanner keep their values
+// after being formatted.
+func FuzzFloatLiteral(f *testing.F) {
+	for _, s := range []string{"1.5", ".5", "1.", "1e10", "1.5E+3", ".5e-03", "1.e5", "0x1e-2", "0o17", "2e-1-1", "0.0"} {
+		f.Add(s)
+	}
+	value := func(src string) (interface{}, bool) {
+		expr, err := syntax.ParseExpr("test.bzl", src, 0)
+		if err != nil {
+			return nil, false
+		}
+		literal, ok := expr.(*syntax.Literal)
+		if !ok || literal.Raw != src || (literal.Token != syntax.INT && literal.Token != syntax.FLOAT) {
+			return nil, false
+		}
+		if n, ok := literal.Value.(*big.Int); ok {
+			return n.String(), true
+		}
+		return literal.Value, true
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		want, ok := value(src)
+		if !ok {
+			return
+		}
+		file, err := ParseDefault("test.bzl", []byte("x = "+src+"\n"))
+		if err != nil {
+			t.Fatalf("ParseDefault(%q) failed: %v", src, err)
+		}
+		formatted := strings.TrimSuffix(strings.TrimPrefix(string(Format(file)), "x = "), "\n")
+		if got, ok := value(formatted); !ok || got != want {
+			t.Errorf("Format(%q) = %q with the value %v, want %v", src, formatted, got, want)
+		}
+	})
+}
+
+func TestTabIndentation(t *testing.T) {
+	input := "def f(x):\n\tif x:\n\t\treturn [\n\t\t\t1,\n\t\t]\n  \treturn 2\n"
+	if _, err := ParseDefault("test.bzl", []byte(input)); err == nil {
+		t.Errorf("ParseDefault() of a tab-indented file succeeded without AllowTabIndentation")
+	}
+
+	defer func(allow bool) { AllowTabIndentation = allow }(AllowTabIndentation)
+	AllowTabIndentation = true
+	f, err := ParseDefault("test.bzl", []byte(input))
+	if err != nil {
+		t.Fatalf("ParseDefault() failed: %v", err)
+	}
+	if !f.TabIndented {
+		t.Errorf("TabIndented = false, want true")
+	}
+	want := "def f(x):\n    if x:\n        return [\n            1,\n        ]\n    return 2\n"
+	if got := string(Format(f)); got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	f, err = ParseDefault("test.bzl", []byte("x = [\n\t1,\n]\n"))
+	if err != nil || f.TabIndented {
+		t.Errorf("ParseDefault() of a file with tabs only within brackets = %v, TabIndented: %v, want false", err, f != nil && f.TabIndented)
+	}
+}
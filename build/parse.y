@@ -471,8 +471,8 @@ small_stmt:
 			Return: $1,
 		}
 	}
-|	expr '=' expr      { $$ = binary($1, $2, $<tok>2, $3) }
-|	expr _AUGM expr    { $$ = binary($1, $2, $<tok>2, $3) }
+|	expr '=' expr      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	expr _AUGM expr    { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
 |	_PASS
 	{
 		$$ = &BranchStmt{
@@ -507,12 +507,12 @@ primary_expr:
 	}
 |	primary_expr '.' _IDENT
 	{
-		$$ = &DotExpr{
+		$$ = yylex.(*input).arena.dotExpr(DotExpr{
 			X: $1,
 			Dot: $2,
 			NamePos: $3,
 			Name: $<tok>3,
-		}
+		})
 	}
 |	_LOAD '(' commas_opt string commas load_arguments commas_opt ')'
 	{
@@ -530,14 +530,14 @@ primary_expr:
 	}
 |	primary_expr '(' arguments_opt ')'
 	{
-		$$ = &CallExpr{
+		$$ = yylex.(*input).arena.callExpr(CallExpr{
 			X: $1,
 			ListStart: $2,
 			List: $3,
 			End: End{Pos: $4},
 			ForceCompact: forceCompact($2, $3, $4),
 			ForceMultiLine: forceMultiLine($2, $3, $4),
-		}
+		})
 	}
 |	primary_expr '[' expr ']'
 	{
@@ -574,12 +574,12 @@ primary_expr:
 	}
 |	'[' tests_opt ']'
 	{
-		$$ = &ListExpr{
+		$$ = yylex.(*input).arena.listExpr(ListExpr{
 			Start: $1,
 			List: $2,
 			End: End{Pos: $3},
 			ForceMultiLine: forceMultiLine($1, $2, $3),
-		}
+		})
 	}
 |	'[' test for_clauses_with_if_clauses_opt ']'
 	{
@@ -669,7 +669,7 @@ argument:
 	test
 |	ident '=' test
 	{
-		$$ = binary($1, $2, $<tok>2, $3)
+		$$ = yylex.(*input).binary($1, $2, $<tok>2, $3)
 	}
 |	'*' test
 	{
@@ -765,7 +765,7 @@ parameter:
 	ident
 |	ident '=' test
 	{
-		$$ = binary($1, $2, $<tok>2, $3)
+		$$ = yylex.(*input).binary($1, $2, $<tok>2, $3)
 	}
 |	'*' ident
 	{
@@ -790,7 +790,7 @@ parameter_type:
 	}
 |	ident ':' test '=' test
 	{
-		$$ = binary(typed($1, $3), $4, $<tok>4, $5)
+		$$ = yylex.(*input).binary(typed($1, $3), $4, $<tok>4, $5)
 	}
 |	'*' ident ':' test
 	{
@@ -840,33 +840,33 @@ test:
 |	'-' test  %prec _UNARY { $$ = unary($1, $<tok>1, $2) }
 |	'+' test  %prec _UNARY { $$ = unary($1, $<tok>1, $2) }
 |	'~' test  %prec _UNARY { $$ = unary($1, $<tok>1, $2) }
-|	test '*' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '%' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '/' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _INT_DIV test { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '+' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '-' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '<' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '>' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _EQ test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _LE test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _NE test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _GE test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _IN test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _NOT _IN test { $$ = binary($1, $2, "not in", $4) }
-|	test _OR test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _AND test     { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '|' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '&' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test '^' test      { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _BIT_LSH test { $$ = binary($1, $2, $<tok>2, $3) }
-|	test _BIT_RSH test { $$ = binary($1, $2, $<tok>2, $3) }
+|	test '*' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '%' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '/' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _INT_DIV test { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '+' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '-' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '<' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '>' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _EQ test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _LE test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _NE test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _GE test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _IN test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _NOT _IN test { $$ = yylex.(*input).binary($1, $2, "not in", $4) }
+|	test _OR test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _AND test     { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '|' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '&' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test '^' test      { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _BIT_LSH test { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
+|	test _BIT_RSH test { $$ = yylex.(*input).binary($1, $2, $<tok>2, $3) }
 |	test _IS test
 	{
 		if b, ok := $3.(*UnaryExpr); ok && b.Op == "not" {
-			$$ = binary($1, $2, "is not", b.X)
+			$$ = yylex.(*input).binary($1, $2, "is not", b.X)
 		} else {
-			$$ = binary($1, $2, $<tok>2, $3)
+			$$ = yylex.(*input).binary($1, $2, $<tok>2, $3)
 		}
 	}
 |	test _IF test _ELSE test
@@ -938,11 +938,11 @@ commas_opt:
 
 keyvalue:
 	test ':' test  {
-		$$ = &KeyValueExpr{
+		$$ = yylex.(*input).arena.keyValueExpr(KeyValueExpr{
 			Key: $1,
 			Colon: $2,
 			Value: $3,
-		}
+		})
 	}
 
 keyvalues_no_comma:
@@ -988,37 +988,37 @@ loop_vars:
 string:
 	_STRING
 	{
-		$$ = &StringExpr{
+		$$ = yylex.(*input).arena.stringExpr(StringExpr{
 			Start: $1,
 			Value: $<str>1,
 			TripleQuote: $<triple>1,
 			End: $1.add($<tok>1),
 			Token: $<tok>1,
-		}
+		})
 	}
 
 ident:
 	_IDENT
 	{
-		$$ = &Ident{NamePos: $1, Name: $<tok>1}
+		$$ = yylex.(*input).arena.ident(Ident{NamePos: $1, Name: $<tok>1})
 	}
 
 number:
 	_INT '.' _INT
 	{
-		$$ = &LiteralExpr{Start: $1, Token: $<tok>1 + "." + $<tok>3}
+		$$ = yylex.(*input).arena.literalExpr(LiteralExpr{Start: $1, Token: $<tok>1 + "." + $<tok>3})
 	}
 |	_INT '.'
 	{
-		$$ = &LiteralExpr{Start: $1, Token: $<tok>1 + "."}
+		$$ = yylex.(*input).arena.literalExpr(LiteralExpr{Start: $1, Token: $<tok>1 + "."})
 	}
 |	'.' _INT
 	{
-		$$ = &LiteralExpr{Start: $1, Token: "." + $<tok>2}
+		$$ = yylex.(*input).arena.literalExpr(LiteralExpr{Start: $1, Token: "." + $<tok>2})
 	}
 |	_INT %prec ShiftInstead
 	{
-		$$ = &LiteralExpr{Start: $1, Token: $<tok>1}
+		$$ = yylex.(*input).arena.literalExpr(LiteralExpr{Start: $1, Token: $<tok>1})
 	}
 
 for_clause:
@@ -1071,28 +1071,28 @@ func unary(pos Position, op string, x Expr) Expr {
 
 // binary returns a binary expression with the given
 // operands, position, and operator.
-func binary(x Expr, pos Position, op string, y Expr) Expr {
+func (in *input) binary(x Expr, pos Position, op string, y Expr) Expr {
 	_, xend := x.Span()
 	ystart, _ := y.Span()
 
 	switch op {
 	case "=", "+=", "-=", "*=", "/=", "//=", "%=", "&=", "|=", "^=", "<<=", ">>=":
-		return &AssignExpr{
+		return in.arena.assignExpr(AssignExpr{
 			LHS:       x,
 			OpPos:     pos,
 			Op:        op,
 			LineBreak: xend.Line < ystart.Line,
 			RHS:       y,
-		}
+		})
 	}
 
-	return &BinaryExpr{
+	return in.arena.binaryExpr(BinaryExpr{
 		X:         x,
 		OpStart:   pos,
 		Op:        op,
 		LineBreak: xend.Line < ystart.Line,
 		Y:         y,
-	}
+	})
 }
 
 // typed returns a TypedIdent expression
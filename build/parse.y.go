@@ -160,28 +160,28 @@ func unary(pos Position, op string, x Expr) Expr {
 
 // binary returns a binary expression with the given
 // operands, position, and operator.
-func binary(x Expr, pos Position, op string, y Expr) Expr {
+func (in *input) binary(x Expr, pos Position, op string, y Expr) Expr {
 	_, xend := x.Span()
 	ystart, _ := y.Span()
 
 	switch op {
 	case "=", "+=", "-=", "*=", "/=", "//=", "%=", "&=", "|=", "^=", "<<=", ">>=":
-		return &AssignExpr{
+		return in.arena.assignExpr(AssignExpr{
 			LHS:       x,
 			OpPos:     pos,
 			Op:        op,
 			LineBreak: xend.Line < ystart.Line,
 			RHS:       y,
-		}
+		})
 	}
 
-	return &BinaryExpr{
+	return in.arena.binaryExpr(BinaryExpr{
 		X:         x,
 		OpStart:   pos,
 		Op:        op,
 		LineBreak: xend.Line < ystart.Line,
 		Y:         y,
-	}
+	})
 }
 
 // typed returns a TypedIdent expression
@@ -1296,13 +1296,13 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:474
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 34:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:475
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 35:
 		yyDollar = yyS[yypt-1 : yypt+1]
@@ -1341,12 +1341,12 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:509
 		{
-			yyVAL.expr = &DotExpr{
+			yyVAL.expr = yylex.(*input).arena.dotExpr(DotExpr{
 				X:       yyDollar[1].expr,
 				Dot:     yyDollar[2].pos,
 				NamePos: yyDollar[3].pos,
 				Name:    yyDollar[3].tok,
-			}
+			})
 		}
 	case 44:
 		yyDollar = yyS[yypt-8 : yypt+1]
@@ -1368,14 +1368,14 @@ yydefault:
 		yyDollar = yyS[yypt-4 : yypt+1]
 //line build/parse.y:532
 		{
-			yyVAL.expr = &CallExpr{
+			yyVAL.expr = yylex.(*input).arena.callExpr(CallExpr{
 				X:              yyDollar[1].expr,
 				ListStart:      yyDollar[2].pos,
 				List:           yyDollar[3].exprs,
 				End:            End{Pos: yyDollar[4].pos},
 				ForceCompact:   forceCompact(yyDollar[2].pos, yyDollar[3].exprs, yyDollar[4].pos),
 				ForceMultiLine: forceMultiLine(yyDollar[2].pos, yyDollar[3].exprs, yyDollar[4].pos),
-			}
+			})
 		}
 	case 46:
 		yyDollar = yyS[yypt-4 : yypt+1]
@@ -1420,12 +1420,12 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:576
 		{
-			yyVAL.expr = &ListExpr{
+			yyVAL.expr = yylex.(*input).arena.listExpr(ListExpr{
 				Start:          yyDollar[1].pos,
 				List:           yyDollar[2].exprs,
 				End:            End{Pos: yyDollar[3].pos},
 				ForceMultiLine: forceMultiLine(yyDollar[1].pos, yyDollar[2].exprs, yyDollar[3].pos),
-			}
+			})
 		}
 	case 50:
 		yyDollar = yyS[yypt-4 : yypt+1]
@@ -1529,7 +1529,7 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:671
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 61:
 		yyDollar = yyS[yypt-2 : yypt+1]
@@ -1652,7 +1652,7 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:767
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 77:
 		yyDollar = yyS[yypt-2 : yypt+1]
@@ -1682,7 +1682,7 @@ yydefault:
 		yyDollar = yyS[yypt-5 : yypt+1]
 //line build/parse.y:792
 		{
-			yyVAL.expr = binary(typed(yyDollar[1].expr, yyDollar[3].expr), yyDollar[4].pos, yyDollar[4].tok, yyDollar[5].expr)
+			yyVAL.expr = yylex.(*input).binary(typed(yyDollar[1].expr, yyDollar[3].expr), yyDollar[4].pos, yyDollar[4].tok, yyDollar[5].expr)
 		}
 	case 83:
 		yyDollar = yyS[yypt-4 : yypt+1]
@@ -1758,136 +1758,136 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:843
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 96:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:844
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 97:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:845
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 98:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:846
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 99:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:847
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 100:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:848
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 101:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:849
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 102:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:850
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 103:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:851
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 104:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:852
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 105:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:853
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 106:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:854
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 107:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:855
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 108:
 		yyDollar = yyS[yypt-4 : yypt+1]
 //line build/parse.y:856
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, "not in", yyDollar[4].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, "not in", yyDollar[4].expr)
 		}
 	case 109:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:857
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 110:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:858
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 111:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:859
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 112:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:860
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 113:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:861
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 114:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:862
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 115:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:863
 		{
-			yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+			yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 		}
 	case 116:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:865
 		{
 			if b, ok := yyDollar[3].expr.(*UnaryExpr); ok && b.Op == "not" {
-				yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, "is not", b.X)
+				yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, "is not", b.X)
 			} else {
-				yyVAL.expr = binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
+				yyVAL.expr = yylex.(*input).binary(yyDollar[1].expr, yyDollar[2].pos, yyDollar[2].tok, yyDollar[3].expr)
 			}
 		}
 	case 117:
@@ -1954,11 +1954,11 @@ yydefault:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:940
 		{
-			yyVAL.kv = &KeyValueExpr{
+			yyVAL.kv = yylex.(*input).arena.keyValueExpr(KeyValueExpr{
 				Key:   yyDollar[1].expr,
 				Colon: yyDollar[2].pos,
 				Value: yyDollar[3].expr,
-			}
+			})
 		}
 	case 131:
 		yyDollar = yyS[yypt-1 : yypt+1]
@@ -2010,43 +2010,43 @@ yydefault:
 		yyDollar = yyS[yypt-1 : yypt+1]
 //line build/parse.y:990
 		{
-			yyVAL.string = &StringExpr{
+			yyVAL.string = yylex.(*input).arena.stringExpr(StringExpr{
 				Start:       yyDollar[1].pos,
 				Value:       yyDollar[1].str,
 				TripleQuote: yyDollar[1].triple,
 				End:         yyDollar[1].pos.add(yyDollar[1].tok),
 				Token:       yyDollar[1].tok,
-			}
+			})
 		}
 	case 139:
 		yyDollar = yyS[yypt-1 : yypt+1]
 //line build/parse.y:1002
 		{
-			yyVAL.expr = &Ident{NamePos: yyDollar[1].pos, Name: yyDollar[1].tok}
+			yyVAL.expr = yylex.(*input).arena.ident(Ident{NamePos: yyDollar[1].pos, Name: yyDollar[1].tok})
 		}
 	case 140:
 		yyDollar = yyS[yypt-3 : yypt+1]
 //line build/parse.y:1008
 		{
-			yyVAL.expr = &LiteralExpr{Start: yyDollar[1].pos, Token: yyDollar[1].tok + "." + yyDollar[3].tok}
+			yyVAL.expr = yylex.(*input).arena.literalExpr(LiteralExpr{Start: yyDollar[1].pos, Token: yyDollar[1].tok + "." + yyDollar[3].tok})
 		}
 	case 141:
 		yyDollar = yyS[yypt-2 : yypt+1]
 //line build/parse.y:1012
 		{
-			yyVAL.expr = &LiteralExpr{Start: yyDollar[1].pos, Token: yyDollar[1].tok + "."}
+			yyVAL.expr = yylex.(*input).arena.literalExpr(LiteralExpr{Start: yyDollar[1].pos, Token: yyDollar[1].tok + "."})
 		}
 	case 142:
 		yyDollar = yyS[yypt-2 : yypt+1]
 //line build/parse.y:1016
 		{
-			yyVAL.expr = &LiteralExpr{Start: yyDollar[1].pos, Token: "." + yyDollar[2].tok}
+			yyVAL.expr = yylex.(*input).arena.literalExpr(LiteralExpr{Start: yyDollar[1].pos, Token: "." + yyDollar[2].tok})
 		}
 	case 143:
 		yyDollar = yyS[yypt-1 : yypt+1]
 //line build/parse.y:1020
 		{
-			yyVAL.expr = &LiteralExpr{Start: yyDollar[1].pos, Token: yyDollar[1].tok}
+			yyVAL.expr = yylex.(*input).arena.literalExpr(LiteralExpr{Start: yyDollar[1].pos, Token: yyDollar[1].tok})
 		}
 	case 144:
 		yyDollar = yyS[yypt-4 : yypt+1]
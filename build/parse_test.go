@@ -44,6 +44,85 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseMissingComma(t *testing.T) {
+	src := "x = [\n    \"//a\"\n    \"//b\",\n    \"//c\" \"//d\",\n]\n\ny = [\"e\" \"f\"]\n"
+	_, err := ParseBuild("BUILD", []byte(src))
+	if parseError, ok := err.(ParseError); !ok || parseError.Pos != (Position{Line: 3, LineRune: 5, Byte: 20}) {
+		t.Errorf("ParseBuild() error = %v, want a ParseError at 3:5", err)
+	}
+
+	AllowMissingCommas = true
+	defer func() { AllowMissingCommas = false }()
+	f, err := ParseBuild("BUILD", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBuild() with AllowMissingCommas error = %v", err)
+	}
+	list := f.Stmt[0].(*AssignExpr).RHS.(*ListExpr)
+	var missing []bool
+	for _, item := range list.List {
+		missing = append(missing, item.(*StringExpr).MissingComma)
+	}
+	if want := []bool{false, true, false, true}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("StringExpr.MissingComma of the list items = %v, want %v", missing, want)
+	}
+	// The printer keeps the commas missing.
+	want := "x = [\n    \"//a\"\n    \"//b\",\n    \"//c\"\n    \"//d\",\n]\n\ny = [\n    \"e\"\n    \"f\",\n]\n"
+	if got := string(Format(f)); got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+
+	bzl, err := ParseBzl("a.bzl", []byte("x = [\"a\" \"b\", \"c\"]\n"))
+	if err != nil {
+		t.Fatalf("ParseBzl() error = %v", err)
+	}
+	if got, want := string(Format(bzl)), "x = [\"a\" \"b\", \"c\"]\n"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+
+	// Adjacent strings that aren't both complete list items remain syntax errors.
+	for _, src := range []string{
+		"f(\"a\" \"b\")\n",
+		"y = z[\"a\" \"b\"]\n",
+		"y = [z[\"a\" \"b\"]]\n",
+		"y = [\"a\", \"b\" \"c\"[0]]\n",
+		"y = [\"a\", \"b\" \"c\".upper()]\n",
+		"y = [\"a\" + \"b\" \"c\"]\n",
+		"y = [\"a\" \"b\" for c in d]\n",
+	} {
+		if _, err := ParseBzl("a.bzl", []byte(src)); err == nil {
+			t.Errorf("ParseBzl(%q) with AllowMissingCommas returned no error", src)
+		}
+	}
+}
+
+func TestParsePrintStatement(t *testing.T) {
+	_, err := ParseBzl("a.bzl", []byte("def f(x):\n    print \"x =\", x\n"))
+	parseError, ok := err.(ParseError)
+	if !ok {
+		t.Fatalf("ParseBzl() error = %v, want ParseError", err)
+	}
+	if !parseError.PrintStatement {
+		t.Errorf("ParseError.PrintStatement = false, want true")
+	}
+	if want := (Position{Line: 2, LineRune: 10, Byte: 19}); parseError.Pos != want {
+		t.Errorf("ParseError.Pos = %v, want %v", parseError.Pos, want)
+	}
+
+	// A print following a semicolon also starts a statement.
+	_, err = ParseBzl("a.bzl", []byte("x = 1; print \"x\"\n"))
+	if parseError, ok := err.(ParseError); !ok || !parseError.PrintStatement || parseError.Pos.Byte != 12 {
+		t.Errorf("ParseBzl() error = %v, want a ParseError with PrintStatement at byte 12", err)
+	}
+
+	// Other uses of print are regular syntax errors.
+	for _, input := range []string{"x = print \"x\"\n", "f(print \"x\")\n", "print(\"x\") \"y\"\n"} {
+		_, err = ParseBzl("a.bzl", []byte(input))
+		if parseError, ok := err.(ParseError); !ok || parseError.PrintStatement {
+			t.Errorf("ParseBzl(%q) error = %v, want a ParseError without PrintStatement", input, err)
+		}
+	}
+}
+
 func TestParseTestdata(t *testing.T) {
 	// Test that files in the testdata directory can all be parsed.
 	// For this test we don't bother checking what the tree looks like.
@@ -21,8 +21,10 @@ package build
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/bazelbuild/buildtools/build/quote"
 	"github.com/bazelbuild/buildtools/tables"
 )
 
@@ -37,9 +39,52 @@ const (
 func FormatWithoutRewriting(f *File) []byte {
 	pr := &printer{fileType: f.Type}
 	pr.file(f)
+	if PreserveLineEndings {
+		return restoreLineEndings(f, pr.Bytes())
+	}
 	return pr.Bytes()
 }
 
+// PreserveLineEndings makes the printer keep the byte order mark and the CRLF line endings
+// of the parsed files. By default the output is always normalized to LF line endings without
+// a byte order mark.
+var PreserveLineEndings = false
+
+// A BlankLinePolicy is the number of blank lines the printer puts between the top-level
+// statements that aren't kept together, e.g. consecutive load statements or the bazel_deps of
+// MODULE.bazel files.
+type BlankLinePolicy struct {
+	BetweenRules int // between the statements of BUILD, WORKSPACE and MODULE.bazel files
+	AfterLoads   int // between the last load statement and the next statement
+	BetweenDefs  int // before and after the function definitions of .bzl files
+}
+
+// DefaultBlankLines is the blank line policy of the printer, with one blank line everywhere.
+var DefaultBlankLines = BlankLinePolicy{BetweenRules: 1, AfterLoads: 1, BetweenDefs: 1}
+
+// BlankLines is the blank line policy used by the printer.
+var BlankLines = DefaultBlankLines
+
+// restoreLineEndings adds the byte order mark and converts the line endings of the formatted
+// output back to the convention of the original file.
+func restoreLineEndings(f *File, data []byte) []byte {
+	if !f.BOM && !f.CRLF {
+		return data
+	}
+	out := make([]byte, 0, len(data)+len(utf8BOM)+bytes.Count(data, []byte("\n")))
+	if f.BOM {
+		out = append(out, utf8BOM...)
+	}
+	for i, c := range data {
+		// Multiline strings keep their line endings verbatim, don't double them.
+		if c == '\n' && f.CRLF && (i == 0 || data[i-1] != '\r') {
+			out = append(out, '\r')
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
 // Format rewrites the file and returns the formatted form of it.
 func Format(f *File) []byte {
 	Rewrite(f)
@@ -52,6 +97,28 @@ func FormatWithRewriter(w *Rewriter, f *File) []byte {
 	return FormatWithoutRewriting(f)
 }
 
+// A SourceMapping links a byte range of the formatted output to the syntax tree node
+// it has been printed from.
+type SourceMapping struct {
+	Node  Expr
+	Start int // offset of the first byte of the node in the output
+	End   int // offset of the byte following the node in the output
+	// InputStart and InputEnd are the position of the node in the original input,
+	// they're zero values for nodes that have been created programmatically.
+	InputStart Position
+	InputEnd   Position
+}
+
+// FormatWithSourceMap rewrites the file and returns the formatted form of it together
+// with source mappings for all printed nodes, ordered by their position in the output.
+// Enclosing nodes precede the nodes they contain.
+func FormatWithSourceMap(f *File) ([]byte, []SourceMapping) {
+	Rewrite(f)
+	pr := &printer{fileType: f.Type, recordMappings: true}
+	pr.file(f)
+	return pr.Bytes(), pr.sourceMap(f)
+}
+
 // FormatString returns the string form of the given expression.
 func FormatString(x Expr) string {
 	// Expr is an interface and can be nil
@@ -83,13 +150,40 @@ type printer struct {
 	depth        int       // nesting depth inside ( ) [ ] { }
 	level        int       // nesting level of def-, if-else- and for-blocks
 	needsNewLine bool      // true if the next statement needs a new line before it
+
+	recordMappings bool            // true if source mappings should be collected
+	mappings       []SourceMapping // source mappings collected so far
+}
+
+// sourceMap returns the collected source mappings including a mapping for the whole file,
+// sorted by their start offsets.
+func (p *printer) sourceMap(f *File) []SourceMapping {
+	n := p.Len()
+	fileStart, fileEnd := f.Span()
+	mappings := append([]SourceMapping{{f, 0, n, fileStart, fileEnd}}, p.mappings...)
+	for i := range mappings {
+		// Trailing spaces may have been trimmed after a node has been printed.
+		if mappings[i].End > n {
+			mappings[i].End = n
+		}
+		if mappings[i].Start > mappings[i].End {
+			mappings[i].Start = mappings[i].End
+		}
+	}
+	sort.SliceStable(mappings, func(i, j int) bool {
+		if mappings[i].Start != mappings[j].Start {
+			return mappings[i].Start < mappings[j].Start
+		}
+		return mappings[i].End > mappings[j].End
+	})
+	return mappings
 }
 
 // formattingMode returns the current file formatting mode.
 // Can be only TypeBuild or TypeDefault.
 func (p *printer) formattingMode() FileType {
 	switch p.fileType {
-	case TypeBuild, TypeWorkspace, TypeModule:
+	case TypeBuild, TypeWorkspace, TypeModule, TypeRepo, TypeVendor:
 		return TypeBuild
 	default: // TypeDefault, TypeBzl
 		return TypeDefault
@@ -240,12 +334,32 @@ func (p *printer) statements(rawStmts []Expr) {
 			p.newline()
 		}
 
-		if i+1 < len(stmts) && !p.compactStmt(stmt, stmts[i+1]) {
-			p.newline()
+		if i+1 < len(stmts) {
+			for n := p.blankLines(stmt, stmts[i+1]); n > 0; n-- {
+				p.newline()
+			}
 		}
 	}
 }
 
+// blankLines returns the number of blank lines to print between the statements s1 and s2,
+// according to compactStmt and to the blank line policy for the top-level statements.
+func (p *printer) blankLines(s1, s2 Expr) int {
+	switch {
+	case p.compactStmt(s1, s2):
+		return 0
+	case p.level > 0:
+		return 1
+	case isLoad(s1) && !isLoad(s2):
+		return BlankLines.AfterLoads
+	case p.formattingMode() == TypeBuild:
+		return BlankLines.BetweenRules
+	case isFunctionDefinition(s1) || isFunctionDefinition(s2):
+		return BlankLines.BetweenDefs
+	}
+	return 1
+}
+
 // compactStmt reports whether the pair of statements s1, s2
 // should be printed without an intervening blank line.
 // We omit the blank line when both are subinclude statements
@@ -327,12 +441,18 @@ func isUseRepoOrUseExtension(x Expr) bool {
 	if !ok {
 		return false
 	}
-	if ident, ok := call.X.(*Ident); ok && (ident.Name == "use_repo" || ident.Name == "use_extension") {
+	if ident, ok := call.X.(*Ident); ok && (ident.Name == "use_repo" || ident.Name == "use_extension" || isRepoMappingFunction(ident.Name)) {
 		return true
 	}
 	return false
 }
 
+// isRepoMappingFunction reports whether the function maps repositories of the module to the ones
+// of a module extension, like use_repo does the other way around.
+func isRepoMappingFunction(name string) bool {
+	return name == "inject_repo" || name == "override_repo"
+}
+
 func isModuleOverride(x Expr) bool {
 	call, ok := x.(*CallExpr)
 	if !ok {
@@ -409,9 +529,10 @@ func useSameModuleExtensionProxy(x, y Expr) bool {
 
 func usedModuleExtensionProxy(x Expr) (name string, isUseRepo bool) {
 	if call, ok := x.(*CallExpr); ok {
-		if callee, isIdent := call.X.(*Ident); isIdent && callee.Name == "use_repo" {
+		if callee, isIdent := call.X.(*Ident); isIdent && (callee.Name == "use_repo" || isRepoMappingFunction(callee.Name)) {
 			// Handles:
 			//   use_repo(foo_deps, "com_github_foo_bar")
+			//   inject_repo(foo_deps, "bar")
 			if len(call.List) < 1 {
 				return "", true
 			}
@@ -586,6 +707,8 @@ func (p *printer) expr(v Expr, outerPrec int) {
 		}
 	}
 
+	start := p.Len()
+
 	// Do we introduce parentheses?
 	// The result depends on the kind of expression.
 	// Each expression type that might need parentheses
@@ -655,7 +778,7 @@ func (p *printer) expr(v Expr, outerPrec int) {
 			}
 		}
 
-		p.printf("%s", quote(v.Value, v.TripleQuote))
+		p.printf("%s", quote.Quote(v.Value, v.TripleQuote))
 
 	case *DotExpr:
 		addParen(precSuffix)
@@ -891,7 +1014,7 @@ func (p *printer) expr(v Expr, outerPrec int) {
 			p.nestedStatements(block.True)
 
 			isFirst = false
-			_, end := block.True[len(block.True)-1].Span()
+			end := stmtsEnd(block.True)
 			needsEmptyLine = block.ElsePos.Pos.Line-end.Line > 1
 
 			// If the else-block contains just one statement which is an IfStmt, flatten it as a part
@@ -937,6 +1060,11 @@ func (p *printer) expr(v Expr, outerPrec int) {
 		p.printf(")")
 	}
 
+	if p.recordMappings {
+		inputStart, inputEnd := v.Span()
+		p.mappings = append(p.mappings, SourceMapping{v, start, p.Len(), inputStart, inputEnd})
+	}
+
 	// Queue end-of-line comments for printing when we
 	// reach the end of the line.
 	p.comment = append(p.comment, v.Comment().Suffix...)
@@ -1025,6 +1153,13 @@ func (p *printer) useCompactMode(start *Position, list *[]Expr, end *End, mode s
 	return len(*list) <= 1
 }
 
+// missingComma reports whether x is a list item that follows the previous one without a comma,
+// see StringExpr.MissingComma.
+func missingComma(mode seqMode, x Expr) bool {
+	str, ok := x.(*StringExpr)
+	return ok && mode == modeList && str.MissingComma
+}
+
 // seq formats a list of values inside a given bracket pair (brack = "()", "[]", "{}").
 // The end node holds any trailing comments to be printed just before the
 // closing bracket.
@@ -1054,7 +1189,9 @@ func (p *printer) seq(brack string, start *Position, list *[]Expr, end *End, mod
 
 	if p.useCompactMode(start, args, end, mode, forceCompact, forceMultiLine) {
 		for i, x := range *args {
-			if i > 0 {
+			if i > 0 && missingComma(mode, x) {
+				p.printf(" ")
+			} else if i > 0 {
 				p.printf(", ")
 			}
 			p.expr(x, precLow)
@@ -1087,7 +1224,7 @@ func (p *printer) seq(brack string, start *Position, list *[]Expr, end *End, mod
 		p.newline()
 		p.expr(x, precLow)
 
-		if i+1 < len(*args) || needsTrailingComma(mode, x) {
+		if i+1 < len(*args) && !missingComma(mode, (*args)[i+1]) || i+1 == len(*args) && needsTrailingComma(mode, x) {
 			p.printf(",")
 		}
 	}
@@ -508,3 +508,167 @@ func (eq *eqchecker) checkValue(v, w reflect.Value) error {
 	}
 	return nil
 }
+
+func TestFormatWithSourceMap(t *testing.T) {
+	input := `cc_library(name="foo",
+  srcs=["b.cc","a.cc"])
+`
+	f, err := ParseBuild("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, mappings := FormatWithSourceMap(f)
+	want := `cc_library(
+    name = "foo",
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],
+)
+`
+	if string(out) != want {
+		t.Fatalf("FormatWithSourceMap() output:\n%s\nwant:\n%s", out, want)
+	}
+
+	if len(mappings) == 0 || mappings[0].Node != f || mappings[0].Start != 0 || mappings[0].End != len(out) {
+		t.Fatalf("FormatWithSourceMap() first mapping should cover the whole file, got %+v", mappings[0])
+	}
+	for i := 1; i < len(mappings); i++ {
+		if mappings[i].Start < mappings[i-1].Start {
+			t.Errorf("FormatWithSourceMap() mappings are not sorted: %d follows %d", mappings[i].Start, mappings[i-1].Start)
+		}
+	}
+
+	found := 0
+	for _, m := range mappings {
+		str, ok := m.Node.(*StringExpr)
+		if !ok {
+			continue
+		}
+		if got := string(out[m.Start:m.End]); got != fmt.Sprintf("%q", str.Value) {
+			t.Errorf("FormatWithSourceMap() mapping for %q covers %q", str.Value, got)
+		}
+		if got := input[m.InputStart.Byte:m.InputEnd.Byte]; got != fmt.Sprintf("%q", str.Value) {
+			t.Errorf("FormatWithSourceMap() input range for %q covers %q", str.Value, got)
+		}
+		found++
+	}
+	if found != 3 {
+		t.Errorf("FormatWithSourceMap() found %d string mappings, want 3", found)
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	input := "\xef\xbb\xbf# Comment\r\ncc_library(name=\"foo\",  # foo\r\n  srcs=[\"b.cc\",\"a.cc\"])\r\n"
+	f, err := ParseBuild("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.BOM || !f.CRLF {
+		t.Errorf("ParseBuild() BOM = %t, CRLF = %t, want true, true", f.BOM, f.CRLF)
+	}
+	if len(f.Stmt) != 1 {
+		t.Fatalf("ParseBuild() got %d statements, want 1", len(f.Stmt))
+	}
+	if start, _ := f.Stmt[0].Span(); start.Line != 2 || start.LineRune != 1 || input[start.Byte:start.Byte+len("cc_library")] != "cc_library" {
+		t.Errorf("ParseBuild() statement starts at %+v", start)
+	}
+
+	want := `# Comment
+cc_library(
+    name = "foo",  # foo
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],
+)
+`
+	if out := string(Format(f)); out != want {
+		t.Errorf("Format() output:\n%q\nwant:\n%q", out, want)
+	}
+
+	defer func(preserve bool) { PreserveLineEndings = preserve }(PreserveLineEndings)
+	PreserveLineEndings = true
+	want = "\xef\xbb\xbf" + strings.ReplaceAll(want, "\n", "\r\n")
+	if out := string(Format(f)); out != want {
+		t.Errorf("Format() output with preserved line endings:\n%q\nwant:\n%q", out, want)
+	}
+
+	f, err = ParseBuild("BUILD", []byte("x = \"\"\"a\r\nb\"\"\"\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, want := string(Format(f)), "x = \"\"\"a\r\nb\"\"\"\r\n"; out != want {
+		t.Errorf("Format() output with preserved line endings:\n%q\nwant:\n%q", out, want)
+	}
+}
+
+func TestBlankLines(t *testing.T) {
+	defer func(policy BlankLinePolicy) { BlankLines = policy }(BlankLines)
+	BlankLines = BlankLinePolicy{BetweenRules: 2, AfterLoads: 0, BetweenDefs: 2}
+
+	tests := []struct {
+		name, input, want string
+	}{
+		{
+			name: "BUILD",
+			input: `load(":a.bzl", "a")
+load(":b.bzl", "b")
+
+a(name = "a")
+b(name = "b")
+# Comment
+
+b(name = "c")
+`,
+			want: `load(":a.bzl", "a")
+load(":b.bzl", "b")
+a(name = "a")
+
+
+b(name = "b")
+# Comment
+
+
+b(name = "c")
+`,
+		},
+		{
+			name: "x.bzl",
+			input: `load(":a.bzl", "a")
+
+X = 1
+Y = 2
+def f():
+    x = 1
+
+    return x
+def g():
+    pass
+`,
+			want: `load(":a.bzl", "a")
+X = 1
+Y = 2
+
+
+def f():
+    x = 1
+
+    return x
+
+
+def g():
+    pass
+`,
+		},
+	}
+	for _, tt := range tests {
+		f, err := Parse(tt.name, []byte(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := string(FormatWithoutRewriting(f)); out != tt.want {
+			t.Errorf("FormatWithoutRewriting(%s) output:\n%s\nwant:\n%s", tt.name, out, tt.want)
+		}
+	}
+}
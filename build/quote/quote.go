@@ -0,0 +1,350 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package quote implements quoting and unquoting of Starlark string literals: raw strings,
+// byte strings, triple-quoted strings and octal, hexadecimal and unicode escapes.
+//
+// Tools that construct build.StringExpr values should set their Value to the actual string
+// and let the printer quote it, or use Quote to produce the literal of a value.
+package quote
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Form describes how a string literal is written.
+type Form struct {
+	Raw    bool // the literal has an "r" prefix, backslashes don't start escape sequences
+	Bytes  bool // the literal has a "b" prefix
+	Triple bool // the literal is triple-quoted
+	Single bool // the literal uses single quotes instead of double quotes
+}
+
+// unesc maps single-letter chars following \ to their actual values.
+var unesc = [256]byte{
+	'a':  '\a',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'v':  '\v',
+	'\\': '\\',
+	'\'': '\'',
+	'"':  '"',
+}
+
+// esc maps escape-worthy bytes to the char that should follow \.
+var esc = [256]byte{
+	'\a': 'a',
+	'\b': 'b',
+	'\f': 'f',
+	'\n': 'n',
+	'\r': 'r',
+	'\t': 't',
+	'\v': 'v',
+	'\\': '\\',
+	'\'': '\'',
+	'"':  '"',
+}
+
+// escapable is a set of all character that may follow an unescaped backslash
+// in a string literal
+var escapable = [256]bool{
+	'\n': true,
+	'a':  true,
+	'b':  true,
+	'f':  true,
+	'n':  true,
+	'r':  true,
+	't':  true,
+	'u':  true,
+	'U':  true,
+	'v':  true,
+	'x':  true,
+	'\'': true,
+	'\\': true,
+	'"':  true,
+	'0':  true,
+	'1':  true,
+	'2':  true,
+	'3':  true,
+	'4':  true,
+	'5':  true,
+	'6':  true,
+	'7':  true,
+	'8':  true,
+	'9':  true,
+}
+
+// hex is a list of the hexadecimal digits, for use in quoting.
+// We always print lower-case hexadecimal.
+const hex = "0123456789abcdef"
+
+// Unquote unquotes a string literal, returning the actual string value, the form of the
+// literal, and an error describing invalid input.
+func Unquote(literal string) (value string, form Form, err error) {
+	quoted := literal
+	// Check for the prefixes: "r" means don't interpret the inner \, "b" is a byte string.
+	for i := 0; i < 2 && len(quoted) > 0; i++ {
+		if quoted[0] == 'r' && !form.Raw {
+			form.Raw = true
+		} else if quoted[0] == 'b' && !form.Bytes {
+			form.Bytes = true
+		} else {
+			break
+		}
+		quoted = quoted[1:]
+	}
+
+	if len(quoted) < 2 {
+		return "", form, fmt.Errorf("string literal too short")
+	}
+	if quoted[0] != '"' && quoted[0] != '\'' || quoted[0] != quoted[len(quoted)-1] {
+		return "", form, fmt.Errorf("string literal has invalid quotes")
+	}
+	form.Single = quoted[0] == '\''
+
+	// Check for triple quoted string.
+	q := quoted[0]
+	if len(quoted) >= 6 && quoted[1] == q && quoted[2] == q && quoted[:3] == quoted[len(quoted)-3:] {
+		form.Triple = true
+		quoted = quoted[3 : len(quoted)-3]
+	} else {
+		quoted = quoted[1 : len(quoted)-1]
+	}
+
+	// Now quoted is the quoted data, but no quotes.
+	// If we're in raw mode or there are no escapes, we're done.
+	if form.Raw || !strings.Contains(quoted, `\`) {
+		return quoted, form, nil
+	}
+	value, err = unescape(quoted)
+	return value, form, err
+}
+
+// unescape processes the escape sequences of the contents of a string literal.
+func unescape(quoted string) (string, error) {
+	// Each iteration processes one escape sequence along with the
+	// plain text leading up to it.
+	var buf strings.Builder
+	for {
+		// Remove prefix before escape sequence.
+		i := strings.Index(quoted, `\`)
+		if i < 0 {
+			i = len(quoted)
+		}
+		buf.WriteString(quoted[:i])
+		quoted = quoted[i:]
+
+		if len(quoted) == 0 {
+			break
+		}
+
+		// Process escape sequence.
+		if len(quoted) == 1 {
+			return "", fmt.Errorf(`truncated escape sequence \`)
+		}
+
+		switch quoted[1] {
+		default:
+			// In Python, if \z (for some byte z) is not a known escape sequence
+			// then it appears as literal text in the string.
+			buf.WriteString(quoted[:2])
+			quoted = quoted[2:]
+
+		case '\n':
+			// Ignore the escape and the line break.
+			quoted = quoted[2:]
+
+		case 'a', 'b', 'f', 'n', 'r', 't', 'v', '\\', '\'', '"':
+			// One-char escape
+			buf.WriteByte(unesc[quoted[1]])
+			quoted = quoted[2:]
+
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			// Octal escape, up to 3 digits.
+			n := int(quoted[1] - '0')
+			quoted = quoted[2:]
+			for i := 1; i < 3; i++ {
+				if len(quoted) == 0 || quoted[0] < '0' || '7' < quoted[0] {
+					break
+				}
+				n = n*8 + int(quoted[0]-'0')
+				quoted = quoted[1:]
+			}
+			if n >= 256 {
+				// NOTE: Python silently discards the high bit,
+				// so that '\541' == '\141' == 'a'.
+				// Let's see if we can avoid doing that in BUILD files.
+				return "", fmt.Errorf(`invalid escape sequence \%03o`, n)
+			}
+			buf.WriteByte(byte(n))
+
+		case 'u', 'U':
+			// Unicode escape, exactly 4 (\u) or 8 (\U) hexadecimal digits.
+			size := 6
+			if quoted[1] == 'U' {
+				size = 10
+			}
+			if len(quoted) < size {
+				return "", fmt.Errorf(`truncated escape sequence %s`, quoted)
+			}
+			n, err := strconv.ParseUint(quoted[2:size], 16, 32)
+			if err != nil || strings.ContainsAny(quoted[2:size], "+-") {
+				return "", fmt.Errorf(`invalid escape sequence %s`, quoted[:size])
+			}
+			if n >= 0xD800 && n <= 0xDFFF {
+				return "", fmt.Errorf(`invalid dangling surrogate %s`, quoted[:size])
+			}
+			if n > 0x10FFFF {
+				return "", fmt.Errorf(`Unicode value out of range %s`, quoted[:size])
+			}
+			buf.WriteRune(rune(n))
+			quoted = quoted[size:]
+
+		case 'x':
+			// Hexadecimal escape, exactly 2 digits.
+			if len(quoted) < 4 {
+				return "", fmt.Errorf(`truncated escape sequence %s`, quoted)
+			}
+			n, err := strconv.ParseUint(quoted[2:4], 16, 8)
+			if err != nil || strings.ContainsAny(quoted[2:4], "+-") {
+				return "", fmt.Errorf(`invalid escape sequence %s`, quoted[:4])
+			}
+			buf.WriteByte(byte(n))
+			quoted = quoted[4:]
+		}
+	}
+	return buf.String(), nil
+}
+
+// IsCorrectEscaping reports whether a string literal doesn't contain any incorrectly
+// escaped sequences such as "\a".
+func IsCorrectEscaping(literal string) bool {
+	escaped := false
+	// This for-loop doesn't correctly check for a backlash at the end of the string literal, but
+	// such string can't be parsed anyway, neither by Bazel nor by Buildifier.
+	for _, ch := range literal {
+		if !escaped {
+			if ch == '\\' {
+				escaped = true
+			}
+			continue
+		}
+
+		if ch >= 256 || !escapable[ch] {
+			return false
+		}
+		escaped = false
+	}
+	return true
+}
+
+// Quote returns the double-quoted literal of a string value, the form buildifier prints.
+// If triple is true, Quote uses the triple-quoted form """x""".
+func Quote(value string, triple bool) string {
+	quotes := tripleQuote('"', triple)
+	return quotes + escape(value, '"', triple) + quotes
+}
+
+// Escape returns the contents of the double-quoted literal of a string value, without the
+// quotes, e.g. to insert the value into an existing literal.
+func Escape(value string) string {
+	return escape(value, '"', false)
+}
+
+// Format returns the literal of a string value in the given form. Raw literals can't
+// represent all values, e.g. the ones containing the quote character or ending with a
+// backslash, Format returns an error for them.
+func Format(value string, form Form) (string, error) {
+	var q byte = '"'
+	if form.Single {
+		q = '\''
+	}
+	var prefix string
+	if form.Raw {
+		prefix += "r"
+	}
+	if form.Bytes {
+		prefix += "b"
+	}
+	quotes := tripleQuote(q, form.Triple)
+	if !form.Raw {
+		return prefix + quotes + escape(value, q, form.Triple) + quotes, nil
+	}
+	if strings.IndexByte(value, q) >= 0 || !form.Triple && strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("%q can't be written as a raw string literal with %s", value, quotes)
+	}
+	if trailing := len(value) - len(strings.TrimRight(value, `\`)); trailing%2 == 1 {
+		return "", fmt.Errorf("%q can't be written as a raw string literal, it ends with a backslash", value)
+	}
+	return prefix + quotes + value + quotes, nil
+}
+
+// tripleQuote returns the delimiter of a literal with the given quote character.
+func tripleQuote(q byte, triple bool) string {
+	if triple {
+		return strings.Repeat(string(q), 3)
+	}
+	return string(q)
+}
+
+// escape returns the contents of a literal delimited by the given quote character.
+func escape(value string, q byte, triple bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == q && triple && (i+1 < len(value) && value[i+1] != q || i+2 < len(value) && value[i+2] != q) {
+			// Can pass up to two quotes through, because they are followed by a non-quote byte.
+			buf.WriteByte(c)
+			if i+1 < len(value) && value[i+1] == q {
+				buf.WriteByte(c)
+				i++
+			}
+			continue
+		}
+		if triple && c == '\n' {
+			// Can allow newline in triple-quoted string.
+			buf.WriteByte(c)
+			continue
+		}
+		if (c == '\'' || c == '"') && c != q {
+			// Can allow the other quote character.
+			buf.WriteByte(c)
+			continue
+		}
+		if esc[c] != 0 {
+			buf.WriteByte('\\')
+			buf.WriteByte(esc[c])
+			continue
+		}
+		if c < 0x20 || c >= 0x80 {
+			// BUILD files are supposed to be Latin-1, so escape all control and high bytes.
+			// I'd prefer to use \x here, but Blaze does not implement
+			// \x in quoted strings (b/7272572).
+			buf.WriteByte('\\')
+			buf.WriteByte(hex[c>>6]) // actually octal but reusing hex digits 0-7.
+			buf.WriteByte(hex[(c>>3)&7])
+			buf.WriteByte(hex[c&7])
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
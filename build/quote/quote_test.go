@@ -0,0 +1,184 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package quote
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnquoteForms(t *testing.T) {
+	for _, tt := range []struct {
+		literal string
+		value   string
+		form    Form
+	}{
+		{`"a"`, "a", Form{}},
+		{`'a'`, "a", Form{Single: true}},
+		{`"""a"b"""`, `a"b`, Form{Triple: true}},
+		{`'''a'''`, "a", Form{Triple: true, Single: true}},
+		{`r"a\n"`, `a\n`, Form{Raw: true}},
+		{`b"a\n"`, "a\n", Form{Bytes: true}},
+		{`rb'a\n'`, `a\n`, Form{Raw: true, Bytes: true, Single: true}},
+		{`br"""a\n"""`, `a\n`, Form{Raw: true, Bytes: true, Triple: true}},
+		{`"é\U0001F600\x41\101"`, "é\U0001F600AA", Form{}},
+		{`"a\
+b"`, "ab", Form{}},
+	} {
+		value, form, err := Unquote(tt.literal)
+		if err != nil || value != tt.value || form != tt.form {
+			t.Errorf("Unquote(%s) = %#q, %+v, %v, want %#q, %+v, nil", tt.literal, value, form, err, tt.value, tt.form)
+		}
+	}
+}
+
+func TestUnquoteErrors(t *testing.T) {
+	for _, literal := range []string{
+		`"`,
+		`r"`,
+		`"a'`,
+		`x"a"`,
+		`rr"a"`,
+		`"\`,
+		`"\400"`,
+		`"\x4"`,
+		`"\x-1"`,
+		`"\u12"`,
+		`"\ud800"`,
+		`"\U00110000"`,
+	} {
+		if value, form, err := Unquote(literal); err == nil {
+			t.Errorf("Unquote(%s) = %#q, %+v, nil, want error", literal, value, form)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, tt := range []struct {
+		value  string
+		triple bool
+		want   string
+	}{
+		{"", false, `""`},
+		{`a"b'c\d`, false, `"a\"b'c\\d"`},
+		{"a\nb\tc", false, `"a\nb\tc"`},
+		{"a\nb\tc", true, "\"\"\"a\nb\\tc\"\"\""},
+		{`a"b""c"""`, true, `"""a"b""c\"\"\""""`},
+		{"\x00\xffé", false, `"\000\377\303\251"`},
+	} {
+		if got := Quote(tt.value, tt.triple); got != tt.want {
+			t.Errorf("Quote(%#q, %v) = %s, want %s", tt.value, tt.triple, got, tt.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got, want := Escape("a\"b\n"), `a\"b\n`; got != want {
+		t.Errorf("Escape() = %s, want %s", got, want)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, tt := range []struct {
+		value   string
+		form    Form
+		want    string
+		wantErr bool
+	}{
+		{value: `a"b'c`, form: Form{Single: true}, want: `'a"b\'c'`},
+		{value: "a\n", form: Form{Bytes: true}, want: `b"a\n"`},
+		{value: `a\d`, form: Form{Raw: true}, want: `r"a\d"`},
+		{value: `a\d`, form: Form{Raw: true, Bytes: true, Single: true}, want: `rb'a\d'`},
+		{value: "a\nb", form: Form{Raw: true, Triple: true}, want: "r\"\"\"a\nb\"\"\""},
+		{value: `a"b`, form: Form{Raw: true}, wantErr: true},
+		{value: "a\nb", form: Form{Raw: true}, wantErr: true},
+		{value: `a\`, form: Form{Raw: true}, wantErr: true},
+		{value: `a\\`, form: Form{Raw: true}, want: `r"a\\"`},
+	} {
+		got, err := Format(tt.value, tt.form)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Format(%#q, %+v) = %s, %v, want %s, error: %v", tt.value, tt.form, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIsCorrectEscaping(t *testing.T) {
+	for _, tt := range []struct {
+		literal string
+		want    bool
+	}{
+		{`"a\n\\b"`, true},
+		{`"é\x41\0"`, true},
+		{`"\d"`, false},
+		{`"\é"`, false},
+	} {
+		if got := IsCorrectEscaping(tt.literal); got != tt.want {
+			t.Errorf("IsCorrectEscaping(%s) = %v, want %v", tt.literal, got, tt.want)
+		}
+	}
+}
+
+func FuzzQuote(f *testing.F) {
+	for _, s := range []string{"", "a", `"'\`, "a\nb", `""""`, "\x00\xff", "é"} {
+		f.Add(s, false)
+		f.Add(s, true)
+	}
+	f.Fuzz(func(t *testing.T, value string, triple bool) {
+		literal := Quote(value, triple)
+		got, form, err := Unquote(literal)
+		if err != nil || got != value || form.Triple != triple {
+			t.Errorf("Unquote(Quote(%#q, %v)) = %#q, %+v, %v", value, triple, got, form, err)
+		}
+		if !IsCorrectEscaping(literal) {
+			t.Errorf("IsCorrectEscaping(Quote(%#q, %v)) = false", value, triple)
+		}
+		for _, form := range []Form{{Single: true}, {Single: true, Triple: true}, {Raw: true}, {Raw: true, Triple: true, Single: true}} {
+			literal, err := Format(value, form)
+			if err != nil {
+				if !form.Raw {
+					t.Errorf("Format(%#q, %+v) returned error %v", value, form, err)
+				}
+				continue
+			}
+			got, gotForm, err := Unquote(literal)
+			if err != nil || got != value || gotForm != form {
+				t.Errorf("Unquote(Format(%#q, %+v)) = %#q, %+v, %v", value, form, got, gotForm, err)
+			}
+		}
+	})
+}
+
+func FuzzUnquote(f *testing.F) {
+	for _, s := range []string{`""`, `'a'`, `r"\d"`, `b"\x00"`, `"""a"b"""`, `"é\U0001F600"`, `"\777"`, `"\`} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, literal string) {
+		value, form, err := Unquote(literal)
+		if err != nil {
+			return
+		}
+		// Quoting the value back must give an equivalent literal.
+		requoted := Quote(value, form.Triple)
+		got, _, err := Unquote(requoted)
+		if err != nil || got != value {
+			t.Errorf("Unquote(Quote(%#q)) = %#q, %v, the original literal is %s", value, got, err, literal)
+		}
+		if !form.Triple && strings.Contains(requoted, "\n") {
+			t.Errorf("Quote(%#q, false) contains a line break", value)
+		}
+	})
+}
@@ -19,6 +19,8 @@ package build
 import (
 	"strings"
 	"testing"
+
+	"github.com/bazelbuild/buildtools/build/quote"
 )
 
 var quoteTests = []struct {
@@ -113,9 +115,9 @@ func TestQuote(t *testing.T) {
 		if !tt.std {
 			continue
 		}
-		q := quote(tt.s, strings.HasPrefix(tt.q, `"""`))
+		q := quote.Quote(tt.s, strings.HasPrefix(tt.q, `"""`))
 		if q != tt.q {
-			t.Errorf("quote(%#q) = %s, want %s", tt.s, q, tt.q)
+			t.Errorf("quote.Quote(%#q) = %s, want %s", tt.s, q, tt.q)
 		}
 	}
 }
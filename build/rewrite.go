@@ -19,6 +19,7 @@ limitations under the License.
 package build
 
 import (
+	"bytes"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -42,6 +43,20 @@ func disabled(name string) bool {
 	return false
 }
 
+// EnableRewrites enables certain rewrites for all file types, including the ones
+// they don't apply to by default.
+var EnableRewrites []string
+
+// forceEnabled reports whether the named rewrite is enabled for all file types.
+func forceEnabled(name string) bool {
+	for _, x := range EnableRewrites {
+		if name == x {
+			return true
+		}
+	}
+	return false
+}
+
 // AllowSort allows sorting of these lists even with sorting otherwise disabled (for debugging).
 var AllowSort []string
 
@@ -74,7 +89,19 @@ type Rewriter struct {
 
 // Rewrite applies rewrites to a file
 func Rewrite(f *File) {
-	var rewriter = &Rewriter{
+	defaultRewriter().Rewrite(f)
+}
+
+// NewRewriter returns a Rewriter configured with the current tables that applies the
+// default rewrites for each file type. Tools that need a different list of rewrites can
+// set its RewriteSet to the names of the passes returned by RewritePasses.
+func NewRewriter() *Rewriter {
+	return defaultRewriter()
+}
+
+// defaultRewriter returns a Rewriter configured with the current tables.
+func defaultRewriter() *Rewriter {
+	return &Rewriter{
 		IsLabelArg:                      tables.IsLabelArg,
 		LabelDenyList:                   tables.LabelDenylist,
 		IsSortableListArg:               tables.IsSortableListArg,
@@ -84,21 +111,86 @@ func Rewrite(f *File) {
 		StripLabelLeadingSlashes:        tables.StripLabelLeadingSlashes,
 		ShortenAbsoluteLabelsToRelative: tables.ShortenAbsoluteLabelsToRelative,
 	}
-	rewriter.Rewrite(f)
 }
 
 // Rewrite applies the rewrites to a file
 func (w *Rewriter) Rewrite(f *File) {
 	for _, r := range rewrites {
-		// f.Type&r.scope is a bitwise comparison. Because starlark files result in a scope that will
-		// not be changed by rewrites, we have included another check looking on the right side.
-		// If we have an empty rewrite set, we do not want any rewrites to happen.
-		if (!disabled(r.name) && (f.Type&r.scope != 0) && w.RewriteSet == nil) || (w.RewriteSet != nil && rewriteSetContains(w, r.name)) {
+		if w.enabled(f, r.name, r.scope) {
 			r.fn(f, w)
 		}
 	}
 }
 
+// enabled reports whether the named rewrite should be applied to the file.
+func (w *Rewriter) enabled(f *File, name string, scope FileType) bool {
+	// f.Type&r.scope is a bitwise comparison. Because starlark files result in a scope that will
+	// not be changed by rewrites, we have included another check looking on the right side.
+	// If we have an empty rewrite set, we do not want any rewrites to happen.
+	return (!disabled(name) && (f.Type&scope != 0 || forceEnabled(name)) && w.RewriteSet == nil) || (w.RewriteSet != nil && rewriteSetContains(w, name))
+}
+
+// A RewritePass describes one of the rewrites applied by Format.
+type RewritePass struct {
+	Name        string
+	Description string
+	Scope       FileType // the file types the rewrite applies to by default
+}
+
+// RewritePasses returns all rewrites in the order in which they're applied. Their names
+// can be used in Rewriter.RewriteSet, DisableRewrites and EnableRewrites.
+func RewritePasses() []RewritePass {
+	var passes []RewritePass
+	for _, r := range rewrites {
+		passes = append(passes, RewritePass{Name: r.name, Description: r.desc, Scope: r.scope})
+	}
+	return passes
+}
+
+// FileTypes returns the file types the rewrite applies to by default.
+func (p RewritePass) FileTypes() []FileType {
+	var types []FileType
+	for _, t := range []FileType{TypeDefault, TypeBuild, TypeWorkspace, TypeBzl, TypeModule, TypeRepo, TypeVendor} {
+		if p.Scope&t != 0 {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// A FormatStep is a stage of formatting a file, see ExplainFormat.
+type FormatStep struct {
+	Name        string // name of the rewrite, or "print" for the layout done by the printer
+	Description string
+	Output      []byte // the formatted file after the step
+}
+
+// ExplainFormat rewrites the file like Format, but returns the formatted file after each step
+// that changed it. The first step is always "print", the layout of the file by the printer
+// without any rewrites (indentation, line breaks, multiline lists with trailing commas, etc.),
+// it's followed by the rewrites in the order they're applied. The layout decisions of the
+// printer aren't split into separate steps. The output of the last step is the same as the
+// result of Format.
+func ExplainFormat(f *File) []FormatStep {
+	w := defaultRewriter()
+	steps := []FormatStep{{
+		Name:        "print",
+		Description: "laid out the file: indentation, spacing, line breaks and trailing commas",
+		Output:      FormatWithoutRewriting(f),
+	}}
+	for _, r := range rewrites {
+		if !w.enabled(f, r.name, r.scope) {
+			continue
+		}
+		r.fn(f, w)
+		output := FormatWithoutRewriting(f)
+		if !bytes.Equal(output, steps[len(steps)-1].Output) {
+			steps = append(steps, FormatStep{Name: r.name, Description: r.desc, Output: output})
+		}
+	}
+	return steps
+}
+
 func rewriteSetContains(w *Rewriter, name string) bool {
 	for _, value := range w.RewriteSet {
 		if value == name {
@@ -111,8 +203,8 @@ func rewriteSetContains(w *Rewriter, name string) bool {
 // Each rewrite function can be either applied for BUILD files, other files (such as .bzl),
 // or all files.
 const (
-	scopeDefault = TypeDefault | TypeBzl                  // .bzl and generic Starlark files
-	scopeBuild   = TypeBuild | TypeWorkspace | TypeModule // BUILD, WORKSPACE, and MODULE files
+	scopeDefault = TypeDefault | TypeBzl                                          // .bzl and generic Starlark files
+	scopeBuild   = TypeBuild | TypeWorkspace | TypeModule | TypeRepo | TypeVendor // BUILD, WORKSPACE, MODULE, REPO, and VENDOR files
 	scopeBoth    = scopeDefault | scopeBuild
 )
 
@@ -123,22 +215,24 @@ var rewrites = []struct {
 	name  string
 	fn    func(*File, *Rewriter)
 	scope FileType
+	desc  string // what the rewrite does, see ExplainFormat
 }{
-	{"removeParens", removeParens, scopeBuild},
-	{"callsort", sortCallArgs, scopeBuild},
-	{"label", fixLabels, scopeBuild},
-	{"listsort", sortStringLists, scopeBoth},
-	{"multiplus", fixMultilinePlus, scopeBuild},
-	{"loadTop", moveLoadOnTop, scopeBoth},
-	{"sameOriginLoad", compressSameOriginLoads, scopeBoth},
-	{"sortLoadStatements", sortLoadStatements, scopeBoth},
-	{"loadsort", sortAllLoadArgs, scopeBoth},
-	{"useRepoPositionalsSort", sortUseRepoPositionals, TypeModule},
-	{"formatdocstrings", formatDocstrings, scopeBoth},
-	{"reorderarguments", reorderArguments, scopeBoth},
-	{"editoctal", editOctals, scopeBoth},
-	{"editfloat", editFloats, scopeBoth},
-	{"collapseEmpty", collapseEmpty, scopeBoth},
+	{"removeParens", removeParens, scopeBuild, "removed redundant parentheses"},
+	{"callsort", sortCallArgs, scopeBuild, "sorted call arguments by the NamePriority table"},
+	{"label", fixLabels, scopeBuild, "shortened labels per the IsLabelArg and LabelDenylist tables"},
+	{"listsort", sortStringLists, scopeBoth, "sorted string lists per the IsSortableListArg, SortableAllowlist and SortableDenylist tables"},
+	{"multiplus", fixMultilinePlus, scopeBuild, "reformatted a multiline concatenation of lists"},
+	{"loadTop", moveLoadOnTop, scopeBoth, "moved load statements to the top of the file"},
+	{"sameOriginLoad", compressSameOriginLoads, scopeBoth, "merged load statements of the same module"},
+	{"sortLoadStatements", sortLoadStatements, scopeBoth, "sorted load statements by module"},
+	{"loadsort", sortAllLoadArgs, scopeBoth, "sorted the symbols of load statements"},
+	{"useRepoPositionalsSort", sortUseRepoPositionals, TypeModule, "sorted the positional arguments of use_repo"},
+	{"formatdocstrings", formatDocstrings, scopeBoth, "fixed the indentation of docstrings"},
+	{"reorderarguments", reorderArguments, scopeBoth, "reordered arguments as positional, keyword, *args, **kwargs"},
+	{"editoctal", editOctals, scopeBoth, "added the 0o prefix to octal numbers"},
+	{"editfloat", editFloats, scopeBoth, "normalized float literals"},
+	{"collapseEmpty", collapseEmpty, scopeBoth, "collapsed an empty call to a single line"},
+	{"flaglists", normalizeFlagLists, 0, "put one flag per element and removed the repeated flags of the IsFlagListArg lists, and sorted the SortableFlagListArg ones"},
 }
 
 // leaveAlone reports whether any of the nodes on the stack are marked
@@ -211,6 +305,33 @@ func keepSorted(x Expr) bool {
 	return hasComment(x, "keep sorted")
 }
 
+// BlockDirective returns the sorting directive, "keep sorted" or "do not sort", found in the
+// comments above x, or "" if there's none. Inside a list the directive applies to the block
+// of elements it precedes, up to the next comment line.
+func BlockDirective(x Expr) string {
+	for _, com := range x.Comment().Before {
+		token := strings.ToLower(com.Token)
+		if strings.Contains(token, "keep sorted") {
+			return "keep sorted"
+		}
+		if strings.Contains(token, "do not sort") {
+			return "do not sort"
+		}
+	}
+	return ""
+}
+
+// hasBlockDirective reports whether an element of the list other than the first one starts
+// a block with the given sorting directive, or with any directive if directive is "".
+func hasBlockDirective(list *ListExpr, directive string) bool {
+	for i := 1; i < len(list.List); i++ {
+		if d := BlockDirective(list.List[i]); d != "" && (directive == "" || d == directive) {
+			return true
+		}
+	}
+	return false
+}
+
 // labelRE matches label strings, e.g. @r//x/y/z:abc
 // where $1 is @r//x/y/z, $2 is @r//, $3 is r, $4 is z, $5 is abc.
 func makeLabelRe(labelPrefix string) *regexp.Regexp {
@@ -360,7 +481,7 @@ func fixLabels(f *File, w *Rewriter) {
 // If the call is not to a literal rule name or a dot expression, callName
 // returns "".
 func callName(call *CallExpr) string {
-	return (&Rule{call, ""}).Kind()
+	return CallKind(call)
 }
 
 // sortCallArgs sorts lists of named arguments to a call.
@@ -374,33 +495,38 @@ func sortCallArgs(f *File, w *Rewriter) {
 		if leaveAlone(stk, call) {
 			return
 		}
-		rule := callName(call)
-		if rule == "" {
-			rule = "<complex rule kind>"
-		}
+		sortNamedArgs(w, call)
+	})
+}
 
-		// Find the tail of the argument list with named arguments.
-		start := len(call.List)
-		for start > 0 && argName(call.List[start-1]) != "" {
-			start--
-		}
+// sortNamedArgs sorts the tail of named arguments of a call.
+func sortNamedArgs(w *Rewriter, call *CallExpr) {
+	rule := callName(call)
+	if rule == "" {
+		rule = "<complex rule kind>"
+	}
 
-		// Record information about each arg into a sortable list.
-		var args namedArgs
-		for i, x := range call.List[start:] {
-			name := argName(x)
-			args = append(args, namedArg{ruleNamePriority(w, rule, name), name, i, x})
-		}
+	// Find the tail of the argument list with named arguments.
+	start := len(call.List)
+	for start > 0 && argName(call.List[start-1]) != "" {
+		start--
+	}
 
-		// Sort the list and put the args back in the new order.
-		if sort.IsSorted(args) {
-			return
-		}
-		sort.Sort(args)
-		for i, x := range args {
-			call.List[start+i] = x.expr
-		}
-	})
+	// Record information about each arg into a sortable list.
+	var args namedArgs
+	for i, x := range call.List[start:] {
+		name := argName(x)
+		args = append(args, namedArg{ruleNamePriority(w, rule, name), name, i, x})
+	}
+
+	// Sort the list and put the args back in the new order.
+	if sort.IsSorted(args) {
+		return
+	}
+	sort.Sort(args)
+	for i, x := range args {
+		call.List[start+i] = x.expr
+	}
 }
 
 // ruleNamePriority maps a rule argument name to its sorting priority.
@@ -531,6 +657,9 @@ func sortStringLists(f *File, w *Rewriter) {
 			// "keep sorted" comment above first list element also forces sorting of list.
 			if len(v.List) > 0 && (keepSorted(v) || keepSorted(v.List[0])) {
 				findAndModifyStrings(&e, sortStringList)
+			} else if hasBlockDirective(v, "keep sorted") {
+				// "keep sorted" comment above another element forces sorting of its block only.
+				v.List = sortStringExprBlocks(v.List, false)
 			}
 		}
 	})
@@ -580,6 +709,198 @@ func deduplicateStringExprs(list []Expr) []Expr {
 	return deduplicated
 }
 
+// normalizeFlagLists normalizes the lists of compiler and linker flags of the arguments listed
+// in tables.IsFlagListArg, see normalizeFlagList.
+func normalizeFlagLists(f *File, w *Rewriter) {
+	if f.Type == TypeDefault || f.Type == TypeBzl {
+		// Rule parameters, not applicable to .bzl or default file types
+		return
+	}
+	Walk(f, func(e Expr, stk []Expr) {
+		call, ok := e.(*CallExpr)
+		if !ok || leaveAlone(stk, call) {
+			return
+		}
+		for _, arg := range call.List {
+			as, ok := arg.(*AssignExpr)
+			if !ok || leaveAlone1(as) {
+				continue
+			}
+			key, ok := as.LHS.(*Ident)
+			if !ok || !tables.IsFlagListArg[key.Name] {
+				continue
+			}
+			sortable := tables.SortableFlagListArg[key.Name] && !doNotSort(as)
+			linker := key.Name == "linkopts"
+			findAndModifyStrings(&as.RHS, func(x *Expr) {
+				if list, ok := (*x).(*ListExpr); ok {
+					normalizeFlagList(list, sortable, linker)
+				}
+			})
+		}
+	})
+}
+
+// A flagGroup is a flag of a flag list together with the elements that follow it and don't
+// start with "-" or "$", i.e. its values, e.g. "-isystem", "third_party/foo". A flag of the
+// PassThroughFlags also owns the option it passes through, e.g. "-Xclang", "-fno-rtti".
+type flagGroup struct {
+	elems []Expr
+	key   string // the values of the elements separated by spaces, empty if one isn't a string
+	// The pass-through flag of the group if its option may take a value passed through
+	// separately in the next group, e.g. "-Xlinker" for "-Xlinker", "-rpath".
+	open string
+}
+
+// passThrough returns the flag of the PassThroughFlags passing an option through to another
+// tool at the beginning of elems, the option, and the number of elements they span. Returns an
+// empty flag if elems don't start with such a flag.
+func passThrough(elems []Expr) (flag, option string, n int) {
+	str := elems[0].(*StringExpr)
+	if tables.PassThroughFlags[str.Value] {
+		if len(elems) > 1 {
+			if next, ok := elems[1].(*StringExpr); ok && len(next.Comments.Before) == 0 {
+				return str.Value, next.Value, 2
+			}
+		}
+		return "", "", 0
+	}
+	if i := strings.Index(str.Value, ","); i > 0 && tables.PassThroughFlags[str.Value[:i+1]] {
+		return str.Value[:i+1], str.Value[i+1:], 1
+	}
+	return "", "", 0
+}
+
+// normalizeFlagList puts one flag per element of a list of flags: the elements consisting of
+// several flags separated by spaces are split, unless they contain quotes, backslashes or make
+// variables, and the values of the JoinableFlags are attached to them ("-D FOO" becomes
+// "-DFOO"). Then it removes the repeated flags, keeping the first one, and sorts the flags if the
+// list is sortable. A flag stays together with its values, and the libraries of a list of linker
+// flags ("-lfoo") aren't removed since the linker may need them several times.
+func normalizeFlagList(list *ListExpr, sortable, linker bool) {
+	var elems []Expr
+	for _, x := range list.List {
+		str, ok := x.(*StringExpr)
+		if !ok || strings.ContainsAny(str.Value, `"'\$`) {
+			elems = append(elems, x)
+			continue
+		}
+		fields := strings.Fields(str.Value)
+		if len(fields) == 2 && tables.JoinableFlags[fields[0]] {
+			joined := *str
+			joined.Value = fields[0] + fields[1]
+			joined.Token = ""
+			elems = append(elems, &joined)
+			continue
+		}
+		if len(fields) < 2 {
+			elems = append(elems, x)
+			continue
+		}
+		for i, field := range fields {
+			elem := &StringExpr{Value: field}
+			if i == 0 {
+				elem.Comments = str.Comments
+			}
+			elems = append(elems, elem)
+		}
+	}
+
+	var groups []*flagGroup
+	for i := 0; i < len(elems); i++ {
+		str, ok := elems[i].(*StringExpr)
+		if !ok {
+			groups = append(groups, &flagGroup{elems: elems[i : i+1]})
+			continue
+		}
+		if flag, option, n := passThrough(elems[i:]); flag != "" {
+			g := &flagGroup{elems: elems[i : i+n], key: str.Value}
+			if n > 1 {
+				g.key += " " + option
+			}
+			i += n - 1
+			if last := len(groups) - 1; last >= 0 && groups[last].open == flag &&
+				!strings.HasPrefix(option, "-") && len(str.Comments.Before) == 0 {
+				// The value of the option passed through by the previous group.
+				groups[last].elems = append(groups[last].elems, g.elems...)
+				groups[last].key += " " + g.key
+				groups[last].open = ""
+				continue
+			}
+			if strings.HasPrefix(option, "-") && !strings.ContainsAny(option, ",=") {
+				g.open = flag
+			}
+			groups = append(groups, g)
+			continue
+		}
+		if tables.JoinableFlags[str.Value] && i+1 < len(elems) {
+			if next, ok := elems[i+1].(*StringExpr); ok && !strings.HasPrefix(next.Value, "-") && len(next.Comments.Before) == 0 {
+				joined := *str
+				joined.Value += next.Value
+				joined.Token = ""
+				joined.Comments.Suffix = append(joined.Comments.Suffix, next.Comments.Suffix...)
+				elems[i+1] = &joined
+				continue
+			}
+		}
+		if !strings.HasPrefix(str.Value, "-") && !strings.HasPrefix(str.Value, "$") && len(groups) > 0 && len(str.Comments.Before) == 0 {
+			// A value of the previous flag, make variables are flags on their own.
+			if last := groups[len(groups)-1]; last.key != "" {
+				last.elems = append(last.elems, str)
+				last.key += " " + str.Value
+				continue
+			}
+		}
+		groups = append(groups, &flagGroup{elems: []Expr{str}, key: str.Value})
+	}
+
+	// Remove the repeated flags, keeping the comments of the removed ones.
+	var comments []Comment
+	seen := make(map[string]bool)
+	var deduplicated []*flagGroup
+	for _, g := range groups {
+		if g.key != "" && seen[g.key] && !(linker && strings.HasPrefix(g.key, "-l")) {
+			for _, x := range g.elems {
+				comments = append(comments, x.Comment().Before...)
+				comments = append(comments, x.Comment().After...)
+			}
+			continue
+		}
+		seen[g.key] = true
+		if len(comments) > 0 {
+			first := g.elems[0].Comment()
+			first.Before = append(comments, first.Before...)
+			comments = nil
+		}
+		deduplicated = append(deduplicated, g)
+	}
+	groups = deduplicated
+
+	if sortable {
+		// Sort the chunks of flags with no intervening non-strings, blank lines or comments.
+		for i := 0; i < len(groups); {
+			if groups[i].key == "" {
+				i++
+				continue
+			}
+			j := i + 1
+			for ; j < len(groups); j++ {
+				if groups[j].key == "" || len(groups[j].elems[0].Comment().Before) > 0 {
+					break
+				}
+			}
+			chunk := groups[i:j]
+			sort.SliceStable(chunk, func(a, b int) bool { return chunk[a].key < chunk[b].key })
+			i = j
+		}
+	}
+
+	list.List = nil
+	for _, g := range groups {
+		list.List = append(list.List, g.elems...)
+	}
+}
+
 // SortStringList sorts x, a list of strings.
 // The list is broken by non-strings and by blank lines and comments into chunks.
 // Each chunk is sorted in place.
@@ -588,6 +909,20 @@ func SortStringList(x Expr) {
 	if !ok || len(list.List) < 2 {
 		return
 	}
+	for _, x := range list.List {
+		if str, ok := x.(*StringExpr); ok && str.MissingComma {
+			// The list is left alone until the missing-comma warning inserts the commas, the
+			// strings are probably meant to be separate items but it's not certain.
+			return
+		}
+	}
+
+	if hasBlockDirective(list, "") {
+		// The "keep sorted" and "do not sort" comments inside the list only apply to the
+		// blocks they start, the other blocks are sorted unless the list has other comments.
+		list.List = sortStringExprBlocks(list.List, keepSorted(list) || !hasUndirectedComments(list))
+		return
+	}
 
 	if doNotSort(list.List[0]) {
 		list.List = deduplicateStringExprs(list.List)
@@ -611,6 +946,48 @@ func SortStringList(x Expr) {
 	list.List = sortStringExprs(list.List)
 }
 
+// hasUndirectedComments reports whether the list has line comments other than the sorting
+// directives of its blocks.
+func hasUndirectedComments(list *ListExpr) bool {
+	com := list.Comment()
+	if len(com.Before) > 0 || len(com.After) > 0 || len(list.End.Before) > 0 {
+		return true
+	}
+	for _, elem := range list.List {
+		if len(elem.Comment().Before) > 0 && BlockDirective(elem) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortStringExprBlocks sorts the blocks of a list separated by comment lines. The blocks
+// starting with a "keep sorted" comment are always sorted, the ones starting with a
+// "do not sort" comment are only deduplicated, the other ones are sorted if sortUnmarked is set.
+func sortStringExprBlocks(list []Expr, sortUnmarked bool) []Expr {
+	var result []Expr
+	for start := 0; start < len(list); {
+		end := start + 1
+		for end < len(list) && len(list[end].Comment().Before) == 0 {
+			end++
+		}
+		block := list[start:end:end]
+		switch BlockDirective(list[start]) {
+		case "keep sorted":
+			block = sortStringExprs(block)
+		case "do not sort":
+			block = deduplicateStringExprs(block)
+		default:
+			if sortUnmarked {
+				block = sortStringExprs(block)
+			}
+		}
+		result = append(result, block...)
+		start = end
+	}
+	return result
+}
+
 // findAndModifyStrings finds and modifies string lists with a callback
 // function recursively within  the given expression. It doesn't touch all
 // string lists it can find, but only top-level lists, lists that are parts of
@@ -941,7 +1318,7 @@ func isFunctionCall(expr Expr, name string) bool {
 }
 
 // moveLoadOnTop moves all load statements to the top of the file
-func moveLoadOnTop(f *File, _ *Rewriter) {
+func moveLoadOnTop(f *File, r *Rewriter) {
 	if f.Type == TypeWorkspace {
 		// Moving load statements in Workspace files can break the semantics
 		return
@@ -987,6 +1364,15 @@ func moveLoadOnTop(f *File, _ *Rewriter) {
 	if offset == 0 {
 		return
 	}
+	for _, load := range misplacedLoads {
+		if len(load.After) > 0 {
+			// The comments below a misplaced load statement should stay where they are.
+			f.Stmt = DetachComments(f.Stmt)
+			moveLoadOnTop(f, r)
+			f.Stmt = ReattachComments(f.Stmt)
+			return
+		}
+	}
 	stmtCopy := append([]Expr{}, f.Stmt...)
 	for i := range f.Stmt {
 		if i < firstStmtIndex {
@@ -1176,24 +1562,45 @@ func sortAllLoadArgs(f *File, _ *Rewriter) {
 func sortUseRepoPositionals(f *File, _ *Rewriter) {
 	Walk(f, func(v Expr, stk []Expr) {
 		if call, ok := v.(*CallExpr); ok {
-			// The first argument of a valid use_repo call is always a module extension proxy, so we
-			// do not need to sort calls with less than three arguments.
-			if ident, ok := call.X.(*Ident); !ok || ident.Name != "use_repo" || len(call.List) < 3 {
-				return
-			}
-			// Respect the "do not sort" comment on both the first argument and the first repository
-			// name.
-			if doNotSort(call) || doNotSort(call.List[0]) || doNotSort(call.List[1]) {
-				call.List = deduplicateStringExprs(call.List)
-			} else {
+			if ident, ok := call.X.(*Ident); ok && ident.Name == "use_repo" {
 				// Keyword arguments do not have to be sorted here as this has already been done by
 				// the generic callsort rewriter pass.
-				call.List = sortStringExprs(call.List)
+				sortUseRepoPositionalArgs(call)
 			}
 		}
 	})
 }
 
+// sortUseRepoPositionalArgs sorts and deduplicates the repository names passed to a use_repo
+// call as positional arguments.
+func sortUseRepoPositionalArgs(call *CallExpr) {
+	// The first argument of a valid use_repo call is always a module extension proxy, so we
+	// do not need to sort calls with less than three arguments.
+	if len(call.List) < 3 {
+		return
+	}
+	// Respect the "do not sort" comment on both the first argument and the first repository
+	// name.
+	if doNotSort(call) || doNotSort(call.List[0]) || doNotSort(call.List[1]) {
+		call.List = deduplicateStringExprs(call.List)
+	} else {
+		call.List = sortStringExprs(call.List)
+	}
+}
+
+// SortUseRepoArgs puts the arguments of a use_repo call in the order buildifier would: the
+// module extension proxy first, then the positional repository names sorted and deduplicated,
+// then the keyword arguments sorted by key. It can be used by tools that edit MODULE.bazel
+// files without formatting them. Calls marked with a "buildifier: leave-alone" comment are
+// left unchanged.
+func SortUseRepoArgs(call *CallExpr) {
+	if leaveAlone(nil, call) {
+		return
+	}
+	sortNamedArgs(defaultRewriter(), call)
+	sortUseRepoPositionalArgs(call)
+}
+
 // hasComments reports whether any comments are associated with
 // the list or its elements.
 func hasComments(list *ListExpr) (line, suffix bool) {
@@ -1381,7 +1788,7 @@ func editOctals(f *File, _ *Rewriter) {
 		if !ok {
 			return
 		}
-		if len(l.Token) > 1 && l.Token[0] == '0' && l.Token[1] >= '0' && l.Token[1] <= '9' {
+		if len(l.Token) > 1 && l.Token[0] == '0' && strings.Trim(l.Token, "0123456789") == "" {
 			l.Token = "0o" + l.Token[1:]
 		}
 	})
@@ -1395,7 +1802,7 @@ func editFloats(f *File, _ *Rewriter) {
 		if !ok {
 			return
 		}
-		if !strings.ContainsRune(l.Token, '.') {
+		if !strings.ContainsRune(l.Token, '.') || isHexNumber([]byte(l.Token)) {
 			return
 		}
 		if strings.HasPrefix(l.Token, ".") {
@@ -1412,10 +1819,23 @@ func editFloats(f *File, _ *Rewriter) {
 			// Invalid float, skip rewriting.
 			return
 		}
-		l.Token = parts[0] + "e" + strings.TrimLeft(parts[1], "0+")
+		l.Token = parts[0] + "e" + normalizeExponent(parts[1])
 	})
 }
 
+// normalizeExponent removes the plus sign and the leading zeros of the exponent of a float.
+func normalizeExponent(exponent string) string {
+	sign := ""
+	if strings.HasPrefix(exponent, "-") {
+		sign = "-"
+	}
+	digits := strings.TrimLeft(strings.TrimLeft(exponent, "+-"), "0")
+	if digits == "" {
+		return "0"
+	}
+	return sign + digits
+}
+
 // removeParens removes trivial parens
 func removeParens(f *File, _ *Rewriter) {
 	var simplify func(expr Expr, stack []Expr) Expr
@@ -20,7 +20,10 @@ import (
 	"bytes"
 	"os"
 	"path"
+	"strings"
 	"testing"
+
+	"github.com/bazelbuild/buildtools/tables"
 )
 
 var workingDir string = path.Join(os.Getenv("TEST_SRCDIR"), os.Getenv("TEST_WORKSPACE"), "build")
@@ -94,3 +97,378 @@ func TestRewriterWithRewriter(t *testing.T) {
 		t.Error("Original Printer should not equal Modified Printer")
 	}
 }
+
+func TestExplainFormat(t *testing.T) {
+	input := `cc_library(name="x", deps=["//b:b", ":a"])
+`
+	f, err := ParseBuild("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseBuild("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := ExplainFormat(f)
+	var names []string
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	if got, want := strings.Join(names, ","), "print,label,listsort"; got != want {
+		t.Errorf("ExplainFormat() steps = %s, want %s", got, want)
+	}
+	if got, want := string(steps[len(steps)-1].Output), string(Format(want)); got != want {
+		t.Errorf("ExplainFormat() output = %q, want %q", got, want)
+	}
+	if got := string(steps[0].Output); !strings.Contains(got, `"//b:b",`) {
+		t.Errorf("the print step should not rewrite labels, got %q", got)
+	}
+}
+
+func TestEnableRewrites(t *testing.T) {
+	input := `cc_library(deps = ["//b/c:c"], name = "x")
+`
+	for _, tc := range []struct {
+		enable []string
+		want   string
+	}{
+		{nil, input},
+		{[]string{"label", "callsort"}, `cc_library(name = "x", deps = ["//b/c"])
+`},
+	} {
+		func() {
+			defer func(enable []string) { EnableRewrites = enable }(EnableRewrites)
+			EnableRewrites = tc.enable
+			f, err := ParseBzl("defs.bzl", []byte(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(Format(f)); got != tc.want {
+				t.Errorf("Format() with EnableRewrites = %v: got %q, want %q", tc.enable, got, tc.want)
+			}
+		}()
+	}
+}
+
+func TestRewritePasses(t *testing.T) {
+	var names []string
+	for _, pass := range RewritePasses() {
+		if pass.Description == "" {
+			t.Errorf("rewrite %q has no description", pass.Name)
+		}
+		names = append(names, pass.Name)
+	}
+	if got := strings.Join(names[:3], ","); got != "removeParens,callsort,label" {
+		t.Errorf("RewritePasses() starts with %s, want removeParens,callsort,label", got)
+	}
+
+	f, err := ParseBuild("BUILD", []byte(`cc_library(deps = ["//b/c:c"], name = "x")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewRewriter()
+	w.RewriteSet = []string{"label"}
+	w.Rewrite(f)
+	if got, want := string(FormatWithoutRewriting(f)), `cc_library(
+    deps = ["//b/c"],
+    name = "x",
+)
+`; got != want {
+		t.Errorf("Rewrite() with RewriteSet = %v: got %q, want %q", w.RewriteSet, got, want)
+	}
+}
+
+func TestSortStringListBlocks(t *testing.T) {
+	for _, tc := range []struct{ input, want string }{
+		{
+			// Only the "do not sort" block is left alone.
+			`x(deps = [
+    ":b",
+    ":a",
+    # do not sort
+    ":z",
+    ":y",
+    ":z",
+])
+`, `x(deps = [
+    ":a",
+    ":b",
+    # do not sort
+    ":z",
+    ":y",
+])
+`,
+		},
+		{
+			// Other comments prevent sorting the unmarked blocks.
+			`x(deps = [
+    ":b",
+    ":a",
+    # Order matters here.
+    ":d",
+    ":c",
+    # Keep sorted.
+    ":n",
+    ":m",
+])
+`, `x(deps = [
+    ":b",
+    ":a",
+    # Order matters here.
+    ":d",
+    ":c",
+    # Keep sorted.
+    ":m",
+    ":n",
+])
+`,
+		},
+		{
+			// A "keep sorted" block is sorted even in attributes that aren't sortable.
+			`x(args = [
+    "b",
+    "a",
+    # keep sorted
+    "d",
+    "c",
+])
+`, `x(args = [
+    "b",
+    "a",
+    # keep sorted
+    "c",
+    "d",
+])
+`,
+		},
+	} {
+		f, err := ParseBuild("BUILD", []byte(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(Format(f)); got != tc.want {
+			t.Errorf("Format(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestFlagLists(t *testing.T) {
+	input := `cc_library(
+    name = "x",
+    copts = [
+        "-Wall",
+        "-D FOO",
+        "-Wextra -Werror",
+        "-I",
+        "include",
+        "-Wall",
+        "-isystem",
+        "a",
+        "-isystem",
+        "b",
+        "-isystem",
+        "a",
+        "$(STACK_FRAME_UNLIMITED)",
+        "-DBAR='a b'",
+    ] + select({
+        "//conditions:default": ["-O2", "-O2"],
+    }),
+    linkopts = ["-lpthread", "-ldl", "-l", "dl"],
+    deps = [":a", ":a"],
+)
+`
+	for _, tc := range []struct {
+		name     string
+		enable   []string
+		sortable map[string]bool
+		want     string
+	}{
+		{
+			name: "disabled",
+			want: `cc_library(
+    name = "x",
+    copts = [
+        "-Wall",
+        "-D FOO",
+        "-Wextra -Werror",
+        "-I",
+        "include",
+        "-Wall",
+        "-isystem",
+        "a",
+        "-isystem",
+        "b",
+        "-isystem",
+        "a",
+        "$(STACK_FRAME_UNLIMITED)",
+        "-DBAR='a b'",
+    ] + select({
+        "//conditions:default": [
+            "-O2",
+            "-O2",
+        ],
+    }),
+    linkopts = [
+        "-lpthread",
+        "-ldl",
+        "-l",
+        "dl",
+    ],
+    deps = [":a"],
+)
+`,
+		},
+		{
+			name:   "enabled",
+			enable: []string{"flaglists"},
+			want: `cc_library(
+    name = "x",
+    copts = [
+        "-Wall",
+        "-DFOO",
+        "-Wextra",
+        "-Werror",
+        "-Iinclude",
+        "-isystem",
+        "a",
+        "-isystem",
+        "b",
+        "$(STACK_FRAME_UNLIMITED)",
+        "-DBAR='a b'",
+    ] + select({
+        "//conditions:default": ["-O2"],
+    }),
+    linkopts = [
+        "-lpthread",
+        "-ldl",
+        "-ldl",
+    ],
+    deps = [":a"],
+)
+`,
+		},
+		{
+			name:     "sorted",
+			enable:   []string{"flaglists"},
+			sortable: map[string]bool{"linkopts": true},
+			want: `cc_library(
+    name = "x",
+    copts = [
+        "-Wall",
+        "-DFOO",
+        "-Wextra",
+        "-Werror",
+        "-Iinclude",
+        "-isystem",
+        "a",
+        "-isystem",
+        "b",
+        "$(STACK_FRAME_UNLIMITED)",
+        "-DBAR='a b'",
+    ] + select({
+        "//conditions:default": ["-O2"],
+    }),
+    linkopts = [
+        "-ldl",
+        "-ldl",
+        "-lpthread",
+    ],
+    deps = [":a"],
+)
+`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func(enable []string, sortable map[string]bool) {
+				EnableRewrites, tables.SortableFlagListArg = enable, sortable
+			}(EnableRewrites, tables.SortableFlagListArg)
+			EnableRewrites = tc.enable
+			if tc.sortable != nil {
+				tables.SortableFlagListArg = tc.sortable
+			}
+			f, err := ParseBuild("BUILD", []byte(input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(Format(f)); got != tc.want {
+				t.Errorf("Format() =\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFlagListsPassThrough(t *testing.T) {
+	defer func(enable []string) { EnableRewrites = enable }(EnableRewrites)
+	EnableRewrites = []string{"flaglists"}
+	input := `cc_library(
+    name = "x",
+    copts = [
+        "-Xclang",
+        "-foo",
+        "-Xclang",
+        "-bar",
+        "-Xclang",
+        "-foo",
+        "-mllvm -inline-threshold=100",
+    ],
+    linkopts = [
+        "-lfoo",
+        "-lbar",
+        "-lfoo",
+        "-Xlinker",
+        "-rpath",
+        "-Xlinker",
+        "/a",
+        "-Xlinker",
+        "-rpath",
+        "-Xlinker",
+        "/b",
+        "-Wl,-rpath",
+        "-Wl,/a",
+        "-Wl,-rpath",
+        "-Wl,/b",
+        "-Wl,--as-needed",
+        "-Wl,--as-needed",
+    ],
+)
+`
+	want := `cc_library(
+    name = "x",
+    copts = [
+        "-Xclang",
+        "-foo",
+        "-Xclang",
+        "-bar",
+        "-mllvm",
+        "-inline-threshold=100",
+    ],
+    linkopts = [
+        "-lfoo",
+        "-lbar",
+        "-lfoo",
+        "-Xlinker",
+        "-rpath",
+        "-Xlinker",
+        "/a",
+        "-Xlinker",
+        "-rpath",
+        "-Xlinker",
+        "/b",
+        "-Wl,-rpath",
+        "-Wl,/a",
+        "-Wl,-rpath",
+        "-Wl,/b",
+        "-Wl,--as-needed",
+    ],
+)
+`
+	f, err := ParseBuild("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(Format(f)); got != want {
+		t.Errorf("Format() =\n%s\nwant:\n%s", got, want)
+	}
+}
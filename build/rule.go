@@ -19,8 +19,13 @@ limitations under the License.
 package build
 
 import (
+	"fmt"
+	"math"
 	"path/filepath"
+	"strconv"
 	"strings"
+
+	"github.com/bazelbuild/buildtools/labels"
 )
 
 // A Rule represents a single BUILD rule.
@@ -129,6 +134,11 @@ func (f *File) DelRules(kind, name string) int {
 // had the name of the directory containing the BUILD file.
 // This is following a convention used in the Pants build system to cut down on boilerplate.
 func (f *File) implicitRuleName() string {
+	switch f.Type {
+	case TypeWorkspace, TypeModule, TypeRepo, TypeVendor:
+		// Repository-level files don't define targets, the convention doesn't apply to them.
+		return ""
+	}
 	// We disallow empty names in the top-level BUILD files.
 	dir := filepath.Dir(f.Path)
 	if dir == "." {
@@ -160,13 +170,28 @@ func (f *File) implicitRuleName() string {
 	return ""
 }
 
-// Kind returns the rule's kind (such as "go_library").
-// The kind of the rule may be given by a literal or it may be a sequence of dot expressions that
-// begins with a literal, if the call expression does not conform to either of these forms, an
-// empty string will be returned
+// Kind returns the rule's kind (such as "go_library"), see CallKind.
 func (r *Rule) Kind() string {
+	return CallKind(r.Call)
+}
+
+// SetKind changes rule's kind (such as "go_library"), see KindExpr.
+func (r *Rule) SetKind(kind string) {
+	var startPos Position
+	if x := r.Call.X; x != nil {
+		startPos, _ = x.Span()
+	}
+	r.Call.X = KindExpr(kind, startPos)
+}
+
+// CallKind returns the kind of a call (such as "go_library").
+// The kind of the call may be given by a literal or it may be a sequence of dot expressions that
+// begins with a literal, e.g. "maven.install" for a tag of a module extension or
+// "native.cc_library" in a macro. If the call expression does not conform to either of these
+// forms, an empty string will be returned.
+func CallKind(call *CallExpr) string {
 	var names []string
-	expr := r.Call.X
+	expr := call.X
 	for {
 		x, ok := expr.(*DotExpr)
 		if !ok {
@@ -187,19 +212,17 @@ func (r *Rule) Kind() string {
 	return strings.Join(names, ".")
 }
 
-// SetKind changes rule's kind (such as "go_library").
-func (r *Rule) SetKind(kind string) {
+// KindExpr returns the expression called by the calls of a kind, the inverse of CallKind: an
+// identifier, or a sequence of dot expressions for a kind containing dots. The nodes are
+// positioned at pos.
+func KindExpr(kind string, pos Position) Expr {
 	names := strings.Split(kind, ".")
-	var startPos Position
-	if x := r.Call.X; x != nil {
-		startPos, _ = x.Span()
-	}
 	var expr Expr
-	expr = &Ident{Name: names[0], NamePos: startPos}
+	expr = &Ident{Name: names[0], NamePos: pos}
 	for _, name := range names[1:] {
-		expr = &DotExpr{X: expr, Name: name, NamePos: startPos}
+		expr = &DotExpr{X: expr, Name: name, NamePos: pos}
 	}
-	r.Call.X = expr
+	return expr
 }
 
 // ExplicitName returns the rule's target name if it's explicitly provided as a string value, "" otherwise.
@@ -354,3 +377,194 @@ func Strings(expr Expr) []string {
 	}
 	return all
 }
+
+// An AttrTypeError is returned by the typed attribute getters (AttrBool, AttrInt,
+// AttrStringList and AttrLabel) if the value of the attribute doesn't have the
+// expected type, or isn't a literal that can be evaluated without running Starlark.
+type AttrTypeError struct {
+	Key   string // name of the attribute
+	Type  string // expected type, e.g. "bool"
+	Value Expr   // actual value of the attribute
+}
+
+func (e *AttrTypeError) Error() string {
+	return fmt.Sprintf("attribute %q is not a %s: %s", e.Key, e.Type, FormatString(e.Value))
+}
+
+// AttrBool returns the value of the rule's boolean attribute with the given key
+// (such as "testonly"). Both True/False and the legacy 1/0 forms are accepted.
+// If the rule has no such attribute, AttrBool returns false; use Attr to tell
+// a missing attribute from False.
+func (r *Rule) AttrBool(key string) (bool, error) {
+	switch value := r.Attr(key).(type) {
+	case nil:
+		return false, nil
+	case *Ident:
+		switch value.Name {
+		case "True":
+			return true, nil
+		case "False":
+			return false, nil
+		}
+	case *LiteralExpr:
+		switch value.Token {
+		case "1":
+			return true, nil
+		case "0":
+			return false, nil
+		}
+	}
+	return false, &AttrTypeError{key, "bool", r.Attr(key)}
+}
+
+// SetAttrBool sets the rule's attribute with the given key to True or False.
+func (r *Rule) SetAttrBool(key string, value bool) {
+	name := "False"
+	if value {
+		name = "True"
+	}
+	r.SetAttr(key, &Ident{Name: name})
+}
+
+// AttrInt returns the value of the rule's integer attribute with the given key
+// (such as "shard_count"). Decimal, hexadecimal, octal and binary literals
+// are accepted, optionally negated.
+// If the rule has no such attribute, AttrInt returns 0; use Attr to tell
+// a missing attribute from 0.
+func (r *Rule) AttrInt(key string) (int, error) {
+	value := r.Attr(key)
+	if value == nil {
+		return 0, nil
+	}
+	expr, sign := value, 1
+	if unary, ok := expr.(*UnaryExpr); ok && (unary.Op == "-" || unary.Op == "+") {
+		expr = unary.X
+		if unary.Op == "-" {
+			sign = -1
+		}
+	}
+	if literal, ok := expr.(*LiteralExpr); ok {
+		token := literal.Token
+		if len(token) > 1 && token[0] == '0' && token[1] >= '0' && token[1] <= '9' {
+			// Starlark doesn't allow leading zeros, unlike strconv.
+			return 0, &AttrTypeError{key, "int", value}
+		}
+		if n, err := strconv.ParseInt(token, 0, strconv.IntSize); err == nil {
+			return sign * int(n), nil
+		}
+	}
+	return 0, &AttrTypeError{key, "int", value}
+}
+
+// SetAttrInt sets the rule's attribute with the given key to an integer literal.
+func (r *Rule) SetAttrInt(key string, value int) {
+	if value < 0 {
+		r.SetAttr(key, &UnaryExpr{Op: "-", X: &LiteralExpr{Token: strconv.Itoa(-value)}})
+		return
+	}
+	r.SetAttr(key, &LiteralExpr{Token: strconv.Itoa(value)})
+}
+
+// AttrFloat returns the value of the rule's numeric attribute with the given key
+// (such as "timeout_multiplier") as a float. Float literals, including hexadecimal
+// floats such as 0x1.8p3, and integer literals are accepted, optionally negated.
+// If the rule has no such attribute, AttrFloat returns 0; use Attr to tell
+// a missing attribute from 0.
+func (r *Rule) AttrFloat(key string) (float64, error) {
+	value := r.Attr(key)
+	if value == nil {
+		return 0, nil
+	}
+	expr, sign := value, 1.0
+	if unary, ok := expr.(*UnaryExpr); ok && (unary.Op == "-" || unary.Op == "+") {
+		expr = unary.X
+		if unary.Op == "-" {
+			sign = -1
+		}
+	}
+	if n, err := r.AttrInt(key); err == nil {
+		return float64(n), nil
+	}
+	if literal, ok := expr.(*LiteralExpr); ok && isFloatToken(literal.Token) {
+		if f, err := strconv.ParseFloat(literal.Token, 64); err == nil {
+			return sign * f, nil
+		}
+	}
+	return 0, &AttrTypeError{key, "float", value}
+}
+
+// SetAttrFloat sets the rule's attribute with the given key to a float literal, e.g.
+// 1.0, 0.25 or 1e-9. Infinities and NaN are written as calls to float, e.g. float("inf").
+func (r *Rule) SetAttrFloat(key string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		r.SetAttr(key, &CallExpr{
+			X:    &Ident{Name: "float"},
+			List: []Expr{&StringExpr{Value: strings.ToLower(strconv.FormatFloat(value, 'g', -1, 64))}},
+		})
+		return
+	}
+	var expr Expr = &LiteralExpr{Token: FormatFloat(math.Abs(value))}
+	if math.Signbit(value) {
+		expr = &UnaryExpr{Op: "-", X: expr}
+	}
+	r.SetAttr(key, expr)
+}
+
+// FormatFloat returns the shortest float literal that represents a value, in the form
+// produced by the formatter: 1.0, 0.25 or 1e-9. The value must be finite.
+func FormatFloat(value float64) string {
+	token := strconv.FormatFloat(value, 'g', -1, 64)
+	if i := strings.IndexByte(token, 'e'); i >= 0 {
+		return token[:i] + "e" + normalizeExponent(token[i+1:])
+	}
+	if !strings.ContainsRune(token, '.') {
+		token += ".0"
+	}
+	return token
+}
+
+// AttrStringList returns the value of the rule's attribute with the given key
+// (such as "srcs"), which must be a list of string literals.
+// Unlike AttrStrings, it reports values such as glob() calls or concatenations
+// as errors. If the rule has no such attribute, AttrStringList returns a nil slice.
+func (r *Rule) AttrStringList(key string) ([]string, error) {
+	value := r.Attr(key)
+	if value == nil {
+		return nil, nil
+	}
+	if values := Strings(value); values != nil {
+		return values, nil
+	}
+	return nil, &AttrTypeError{key, "list of strings", value}
+}
+
+// SetAttrStringList sets the rule's attribute with the given key to a list of
+// string literals.
+func (r *Rule) SetAttrStringList(key string, values []string) {
+	list := &ListExpr{}
+	for _, value := range values {
+		list.List = append(list.List, &StringExpr{Value: value})
+	}
+	r.SetAttr(key, list)
+}
+
+// AttrLabel returns the value of the rule's label attribute with the given key
+// (such as "actual"), resolved relatively to the package pkg.
+// If the rule has no such attribute, AttrLabel returns the zero Label.
+func (r *Rule) AttrLabel(key, pkg string) (labels.Label, error) {
+	value := r.Attr(key)
+	if value == nil {
+		return labels.Label{}, nil
+	}
+	str, ok := value.(*StringExpr)
+	if !ok || str.Value == "" {
+		return labels.Label{}, &AttrTypeError{key, "label", value}
+	}
+	return labels.ParseRelative(str.Value, pkg), nil
+}
+
+// SetAttrLabel sets the rule's attribute with the given key to a label,
+// written relatively to the package pkg if it belongs to it.
+func (r *Rule) SetAttrLabel(key string, label labels.Label, pkg string) {
+	r.SetAttr(key, &StringExpr{Value: label.FormatRelative(pkg)})
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package build
 
 import (
+	"math"
+	"reflect"
 	"testing"
+
+	"github.com/bazelbuild/buildtools/labels"
 )
 
 var simpleCall *CallExpr = &CallExpr{
@@ -109,6 +113,39 @@ func TestSetKind(t *testing.T) {
 	})
 }
 
+func TestCallKind(t *testing.T) {
+	for _, tt := range []struct {
+		input, kind string
+	}{
+		{`java_library(name = "x")`, "java_library"},
+		{`maven.install(artifacts = [])`, "maven.install"},
+		{`foo.bar.baz()`, "foo.bar.baz"},
+		{`foo().bar()`, ""},
+		{`rules[0]()`, ""},
+	} {
+		f, err := ParseBuild("BUILD", []byte(tt.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		call := f.Stmt[0].(*CallExpr)
+		kind := CallKind(call)
+		if kind != tt.kind {
+			t.Errorf("CallKind(%s) = %q, want %q", tt.input, kind, tt.kind)
+		}
+		if kind == "" {
+			continue
+		}
+		// KindExpr is the inverse of CallKind.
+		start, _ := call.X.Span()
+		if got := CallKind(&CallExpr{X: KindExpr(kind, start)}); got != kind {
+			t.Errorf("CallKind(KindExpr(%q)) = %q", kind, got)
+		}
+		if got, want := FormatString(KindExpr(kind, start)), FormatString(call.X); got != want {
+			t.Errorf("KindExpr(%q) = %s, want %s", kind, got, want)
+		}
+	}
+}
+
 func TestRules(t *testing.T) {
 	f := &File{
 		Stmt: []Expr{
@@ -214,6 +251,8 @@ rule(name="b")`, "", `No implicit name for load`},
 		{"foo/BUILD", `load(":foo.bzl", "bar")
 rule()`, "foo", `Use an implicit name for one unnamed rule with load`},
 		{"BUILD", `rule()`, "", `No implicit name for root package`},
+		{"foo/WORKSPACE", `http_archive()`, "", `No implicit name in WORKSPACE files`},
+		{"foo/MODULE.bazel", `maven.install()`, "", `No implicit name in MODULE.bazel files`},
 	}
 
 	for _, tst := range tests {
@@ -228,3 +267,178 @@ rule()`, "foo", `Use an implicit name for one unnamed rule with load`},
 		}
 	}
 }
+
+func TestTypedAttrs(t *testing.T) {
+	f, err := ParseBuild("BUILD", []byte(`cc_test(
+    name = "x",
+    testonly = True,
+    linkstatic = 0,
+    flaky = FLAKY,
+    shard_count = 0x10,
+    size_limit = -3,
+    octal = 010,
+    srcs = ["a.cc", "b.cc"],
+    hdrs = glob(["*.h"]),
+    data = [],
+    actual = ":y",
+    malloc = "//base:malloc",
+)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := f.Rules("")[0]
+
+	for _, tc := range []struct {
+		key     string
+		want    bool
+		wantErr bool
+	}{
+		{"testonly", true, false},
+		{"linkstatic", false, false},
+		{"missing", false, false},
+		{"flaky", false, true},
+		{"name", false, true},
+	} {
+		got, err := r.AttrBool(tc.key)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("AttrBool(%q) = %v, %v; want %v, error: %v", tc.key, got, err, tc.want, tc.wantErr)
+		}
+	}
+
+	for _, tc := range []struct {
+		key     string
+		want    int
+		wantErr bool
+	}{
+		{"shard_count", 16, false},
+		{"size_limit", -3, false},
+		{"linkstatic", 0, false},
+		{"missing", 0, false},
+		{"octal", 0, true},
+		{"testonly", 0, true},
+	} {
+		got, err := r.AttrInt(tc.key)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("AttrInt(%q) = %v, %v; want %v, error: %v", tc.key, got, err, tc.want, tc.wantErr)
+		}
+	}
+
+	for _, tc := range []struct {
+		key     string
+		want    []string
+		wantErr bool
+	}{
+		{"srcs", []string{"a.cc", "b.cc"}, false},
+		{"data", []string{}, false},
+		{"missing", nil, false},
+		{"hdrs", nil, true},
+	} {
+		got, err := r.AttrStringList(tc.key)
+		if !reflect.DeepEqual(got, tc.want) || (err != nil) != tc.wantErr {
+			t.Errorf("AttrStringList(%q) = %q, %v; want %q, error: %v", tc.key, got, err, tc.want, tc.wantErr)
+		}
+	}
+
+	if got, err := r.AttrLabel("actual", "pkg"); err != nil || got != (labels.Label{Package: "pkg", Target: "y"}) {
+		t.Errorf("AttrLabel(actual) = %v, %v", got, err)
+	}
+	if got, err := r.AttrLabel("malloc", "pkg"); err != nil || got != (labels.Label{Package: "base", Target: "malloc"}) {
+		t.Errorf("AttrLabel(malloc) = %v, %v", got, err)
+	}
+	if _, err := r.AttrLabel("hdrs", "pkg"); err == nil {
+		t.Errorf("AttrLabel(hdrs) succeeded, want an error")
+	} else if want := `attribute "hdrs" is not a label: glob(["*.h"])`; err.Error() != want {
+		t.Errorf("AttrLabel(hdrs) error = %q, want %q", err, want)
+	}
+
+	r.SetAttrBool("testonly", false)
+	r.SetAttrInt("shard_count", 4)
+	r.SetAttrInt("size_limit", -5)
+	r.SetAttrStringList("srcs", []string{"c.cc"})
+	r.SetAttrLabel("actual", labels.Label{Package: "pkg", Target: "z"}, "pkg")
+	r.SetAttrLabel("malloc", labels.Label{Package: "third_party", Target: "tcmalloc"}, "pkg")
+	if got, _ := r.AttrBool("testonly"); got {
+		t.Errorf("AttrBool(testonly) = true after SetAttrBool(false)")
+	}
+	if got, _ := r.AttrInt("size_limit"); got != -5 {
+		t.Errorf("AttrInt(size_limit) = %d after SetAttrInt(-5)", got)
+	}
+	want := `cc_test(
+    name = "x",
+    testonly = False,
+    linkstatic = 0,
+    flaky = FLAKY,
+    shard_count = 4,
+    size_limit = -5,
+    octal = 010,
+    srcs = ["c.cc"],
+    hdrs = glob(["*.h"]),
+    data = [],
+    actual = ":z",
+    malloc = "//third_party:tcmalloc",
+)
+`
+	if got := string(FormatWithoutRewriting(f)); got != want {
+		t.Errorf("Format() after setting attributes =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFloatAttrs(t *testing.T) {
+	f, err := ParseBuild("BUILD", []byte(`cc_test(
+    name = "x",
+    ratio = 0.25,
+    large = 1.e5,
+    small = -1.5E-3,
+    hex = 0x1.8p1,
+    count = 0x10,
+    octal = 010,
+    flaky = FLAKY,
+)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := f.Rules("")[0]
+
+	for _, tc := range []struct {
+		key     string
+		want    float64
+		wantErr bool
+	}{
+		{"ratio", 0.25, false},
+		{"large", 1e5, false},
+		{"small", -1.5e-3, false},
+		{"hex", 3, false},
+		{"count", 16, false},
+		{"missing", 0, false},
+		{"octal", 0, true},
+		{"flaky", 0, true},
+		{"name", 0, true},
+	} {
+		got, err := r.AttrFloat(tc.key)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("AttrFloat(%q) = %v, %v; want %v, error: %v", tc.key, got, err, tc.want, tc.wantErr)
+		}
+	}
+
+	r.SetAttrFloat("ratio", 2)
+	r.SetAttrFloat("large", 1e21)
+	r.SetAttrFloat("small", -1e-9)
+	r.SetAttrFloat("hex", math.Inf(-1))
+	if got, _ := r.AttrFloat("small"); got != -1e-9 {
+		t.Errorf("AttrFloat(small) = %v after SetAttrFloat(-1e-9)", got)
+	}
+	want := `cc_test(
+    name = "x",
+    ratio = 2.0,
+    large = 1e21,
+    small = -1e-9,
+    hex = float("-inf"),
+    count = 0x10,
+    octal = 010,
+    flaky = FLAKY,
+)
+`
+	if got := string(FormatWithoutRewriting(f)); got != want {
+		t.Errorf("Format() after setting attributes =\n%s\nwant:\n%s", got, want)
+	}
+}
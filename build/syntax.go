@@ -44,6 +44,8 @@ func (p Position) add(s string) Position {
 	return p
 }
 
+//go:generate go run ./gen_visitor -src syntax.go -out visitor.go
+
 // An Expr represents an input element.
 type Expr interface {
 	// Span returns the start and end position of the expression,
@@ -108,6 +110,12 @@ type File struct {
 	Label         string // optional; file path relative to the package name (always forward slashes)
 	WorkspaceRoot string // optional; path to the directory containing the WORKSPACE file
 	Type          FileType
+	// BOM is true if the file started with a UTF-8 byte order mark
+	BOM bool
+	// CRLF is true if the file used Windows (CRLF) line endings
+	CRLF bool
+	// TabIndented is true if the file was indented with tabs, see AllowTabIndentation
+	TabIndented bool
 	Comments
 	Stmt []Expr
 }
@@ -260,6 +268,11 @@ type StringExpr struct {
 	// preferred form of Value. This field is a hint:
 	// it is only used if it is a valid quoted form for Value.
 	Token string
+
+	// MissingComma is true if the string is a list item that follows the previous one
+	// without a comma, e.g. "//b" in ["//a" "//b"]. The printer keeps the comma missing,
+	// the missing-comma warning inserts it.
+	MissingComma bool
 }
 
 // Span returns the start and end positions of the node
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ValidationError is a statement that Bazel rejects in a file of the given type, even though
+// it's syntactically valid Starlark.
+type ValidationError struct {
+	Start   Position
+	End     Position
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Start.Line, e.Start.LineRune, e.Message)
+}
+
+// fileRestrictions describes the top-level statements allowed in a type of files.
+type fileRestrictions struct {
+	load, def, controlFlow bool
+	// calls lists the only functions that can be called, all functions can be called if it's
+	// nil. The functions that are only allowed once are marked with a "!" suffix.
+	calls []string
+	// proxies allows calling the values assigned to top-level variables and their methods,
+	// e.g. the extension proxies returned by use_extension() in MODULE.bazel files.
+	proxies bool
+}
+
+// restrictions lists the statements allowed in the file types that Bazel restricts.
+var restrictions = map[FileType]fileRestrictions{
+	TypeBuild: {load: true},
+	TypeModule: {
+		calls: []string{
+			"archive_override",
+			"bazel_dep",
+			"flag_alias",
+			"git_override",
+			"include",
+			"inject_repo",
+			"local_path_override",
+			"module!",
+			"multiple_version_override",
+			"override_repo",
+			"register_execution_platforms",
+			"register_toolchains",
+			"single_version_override",
+			"use_extension",
+			"use_repo",
+			"use_repo_rule",
+		},
+		proxies: true,
+	},
+	TypeRepo:   {calls: []string{"ignore_directories", "repo!"}},
+	TypeVendor: {calls: []string{"ignore", "pin"}},
+}
+
+// Validate checks the kinds of the top-level statements of a file against the ones Bazel allows
+// in files of its type: BUILD files can't define functions nor contain control flow statements,
+// MODULE.bazel, REPO.bazel and VENDOR.bazel files can't load .bzl files either and can only call
+// the functions specific to them. The parser accepts all of them, so that the files can be
+// formatted, and Bazel only reports them when it evaluates the file. Files of other types are
+// not restricted.
+func Validate(f *File) []*ValidationError {
+	r, ok := restrictions[f.Type]
+	if !ok {
+		return nil
+	}
+	var errs []*ValidationError
+	report := func(x Expr, format string, args ...interface{}) {
+		start, end := x.Span()
+		errs = append(errs, &ValidationError{Start: start, End: end, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var allowed []string
+	once := make(map[string]bool)
+	for _, name := range r.calls {
+		if strings.HasSuffix(name, "!") {
+			name = strings.TrimSuffix(name, "!")
+			once[name] = true
+		}
+		allowed = append(allowed, name)
+	}
+	proxies := make(map[string]bool)
+	called := make(map[string]bool)
+	checkCall := func(call *CallExpr) {
+		if r.calls == nil {
+			return
+		}
+		kind := (&Rule{Call: call}).Kind()
+		if r.proxies {
+			// `ext.tag(...)` or `http_archive(...)` with `http_archive = use_repo_rule(...)`
+			base, _, _ := strings.Cut(kind, ".")
+			if proxies[base] {
+				return
+			}
+		}
+		isAllowed := false
+		for _, name := range allowed {
+			isAllowed = isAllowed || name == kind
+		}
+		if !isAllowed {
+			report(call, "%q can't be called in %s files, only %s() are allowed.", kind, f.Type, strings.Join(allowed, "(), "))
+			return
+		}
+		if once[kind] && called[kind] {
+			report(call, "%s() can be called only once per %s file.", kind, f.Type)
+		}
+		called[kind] = true
+	}
+
+	for _, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *LoadStmt:
+			if !r.load {
+				report(stmt, "Load statements are not allowed in %s files.", f.Type)
+			}
+		case *DefStmt:
+			if !r.def {
+				report(stmt, "Functions can't be defined in %s files.", f.Type)
+			}
+		case *ForStmt, *IfStmt:
+			if !r.controlFlow {
+				report(stmt, "Control flow statements are not allowed in %s files.", f.Type)
+			}
+		case *CallExpr:
+			checkCall(stmt)
+		case *AssignExpr:
+			if call, ok := stmt.RHS.(*CallExpr); ok {
+				checkCall(call)
+			}
+			if lhs, ok := stmt.LHS.(*Ident); ok {
+				proxies[lhs.Name] = true
+			}
+		}
+	}
+	return errs
+}
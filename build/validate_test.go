@@ -0,0 +1,89 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		filename string
+		input    string
+		want     []string
+	}{
+		{
+			filename: "BUILD",
+			input: `load(":defs.bzl", "my_macro")
+
+def foo():
+    pass
+
+my_macro(name = "a")
+`,
+			want: []string{"3:1: Functions can't be defined in BUILD files."},
+		},
+		{
+			filename: "MODULE.bazel",
+			input: `module(name = "foo")
+
+ext = use_extension("//:ext.bzl", "ext")
+ext.tag(name = "a")
+use_repo(ext, "a")
+
+for x in []:
+    pass
+`,
+			want: []string{"7:1: Control flow statements are not allowed in MODULE.bazel files."},
+		},
+		{
+			filename: "REPO.bazel",
+			input: `load("//:defs.bzl", "X")
+
+repo()
+`,
+			want: []string{"1:1: Load statements are not allowed in REPO.bazel files."},
+		},
+		{
+			filename: "defs.bzl",
+			input: `load(":a.bzl", "a")
+
+def foo():
+    pass
+`,
+		},
+	} {
+		f, err := Parse(tc.filename, []byte(tc.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, err := range Validate(f) {
+			got = append(got, err.Error())
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("Validate(%s) = %q, want %q", tc.filename, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("Validate(%s) = %q, want %q", tc.filename, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package build
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+// visitRecorder records the type of the last node it visited.
+type visitRecorder struct {
+	visited string
+}
+
+func (r *visitRecorder) VisitFile(x *File)                       { r.visited = "File" }
+func (r *visitRecorder) VisitCommentBlock(x *CommentBlock)       { r.visited = "CommentBlock" }
+func (r *visitRecorder) VisitIdent(x *Ident)                     { r.visited = "Ident" }
+func (r *visitRecorder) VisitTypedIdent(x *TypedIdent)           { r.visited = "TypedIdent" }
+func (r *visitRecorder) VisitBranchStmt(x *BranchStmt)           { r.visited = "BranchStmt" }
+func (r *visitRecorder) VisitLiteralExpr(x *LiteralExpr)         { r.visited = "LiteralExpr" }
+func (r *visitRecorder) VisitStringExpr(x *StringExpr)           { r.visited = "StringExpr" }
+func (r *visitRecorder) VisitEnd(x *End)                         { r.visited = "End" }
+func (r *visitRecorder) VisitCallExpr(x *CallExpr)               { r.visited = "CallExpr" }
+func (r *visitRecorder) VisitDotExpr(x *DotExpr)                 { r.visited = "DotExpr" }
+func (r *visitRecorder) VisitComprehension(x *Comprehension)     { r.visited = "Comprehension" }
+func (r *visitRecorder) VisitForClause(x *ForClause)             { r.visited = "ForClause" }
+func (r *visitRecorder) VisitIfClause(x *IfClause)               { r.visited = "IfClause" }
+func (r *visitRecorder) VisitKeyValueExpr(x *KeyValueExpr)       { r.visited = "KeyValueExpr" }
+func (r *visitRecorder) VisitDictExpr(x *DictExpr)               { r.visited = "DictExpr" }
+func (r *visitRecorder) VisitListExpr(x *ListExpr)               { r.visited = "ListExpr" }
+func (r *visitRecorder) VisitSetExpr(x *SetExpr)                 { r.visited = "SetExpr" }
+func (r *visitRecorder) VisitTupleExpr(x *TupleExpr)             { r.visited = "TupleExpr" }
+func (r *visitRecorder) VisitUnaryExpr(x *UnaryExpr)             { r.visited = "UnaryExpr" }
+func (r *visitRecorder) VisitBinaryExpr(x *BinaryExpr)           { r.visited = "BinaryExpr" }
+func (r *visitRecorder) VisitAssignExpr(x *AssignExpr)           { r.visited = "AssignExpr" }
+func (r *visitRecorder) VisitParenExpr(x *ParenExpr)             { r.visited = "ParenExpr" }
+func (r *visitRecorder) VisitSliceExpr(x *SliceExpr)             { r.visited = "SliceExpr" }
+func (r *visitRecorder) VisitIndexExpr(x *IndexExpr)             { r.visited = "IndexExpr" }
+func (r *visitRecorder) VisitFunction(x *Function)               { r.visited = "Function" }
+func (r *visitRecorder) VisitLambdaExpr(x *LambdaExpr)           { r.visited = "LambdaExpr" }
+func (r *visitRecorder) VisitConditionalExpr(x *ConditionalExpr) { r.visited = "ConditionalExpr" }
+func (r *visitRecorder) VisitLoadStmt(x *LoadStmt)               { r.visited = "LoadStmt" }
+func (r *visitRecorder) VisitDefStmt(x *DefStmt)                 { r.visited = "DefStmt" }
+func (r *visitRecorder) VisitReturnStmt(x *ReturnStmt)           { r.visited = "ReturnStmt" }
+func (r *visitRecorder) VisitForStmt(x *ForStmt)                 { r.visited = "ForStmt" }
+func (r *visitRecorder) VisitIfStmt(x *IfStmt)                   { r.visited = "IfStmt" }
+
+func TestVisit(t *testing.T) {
+	visitor := reflect.TypeOf((*Visitor)(nil)).Elem()
+	for i := 0; i < visitor.NumMethod(); i++ {
+		method := visitor.Method(i)
+		node := reflect.New(method.Type.In(0).Elem()).Interface().(Expr)
+		r := &visitRecorder{}
+		Visit(r, node)
+		if want := "Visit" + r.visited; want != method.Name {
+			t.Errorf("Visit(%T) called Visit%s, want %s", node, r.visited, method.Name)
+		}
+	}
+
+	r := &visitRecorder{}
+	Visit(r, nil)
+	if r.visited != "" {
+		t.Errorf("Visit(nil) called Visit%s, want no call", r.visited)
+	}
+}
+
+// TestVisitorUpToDate checks that the visitor has a method for each node type of syntax.go, run
+// `go generate` in the build directory to update it.
+func TestVisitorUpToDate(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "syntax.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodeTypes := make(map[string]bool)
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && fn.Name.Name == "Copy" {
+			if star, ok := fn.Recv.List[0].Type.(*ast.StarExpr); ok {
+				nodeTypes[star.X.(*ast.Ident).Name] = true
+			}
+		}
+	}
+
+	visitor := reflect.TypeOf((*Visitor)(nil)).Elem()
+	methods := make(map[string]bool)
+	for i := 0; i < visitor.NumMethod(); i++ {
+		methods[visitor.Method(i).Type.In(0).Elem().Name()] = true
+	}
+	for name := range nodeTypes {
+		if !methods[name] {
+			t.Errorf("Visitor has no method for the node type %s, run go generate", name)
+		}
+	}
+	for name := range methods {
+		if !nodeTypes[name] {
+			t.Errorf("Visitor has a method for %s, which isn't a node type anymore, run go generate", name)
+		}
+	}
+}
@@ -19,17 +19,23 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"unicode"
 
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/buildifier/config"
+	"github.com/bazelbuild/buildtools/buildifier/merge"
 	"github.com/bazelbuild/buildtools/buildifier/utils"
 	"github.com/bazelbuild/buildtools/differ"
+	"github.com/bazelbuild/buildtools/profile"
+	"github.com/bazelbuild/buildtools/warn"
 	"github.com/bazelbuild/buildtools/wspace"
 )
 
@@ -37,7 +43,7 @@ var buildVersion = "redacted"
 var buildScmRevision = "redacted"
 
 func usage() {
-	fmt.Fprintf(flag.CommandLine.Output(), `usage: buildifier [-d] [-v] [-r] [-config=path.json] [-diff_command=command] [-help] [-multi_diff] [-mode=mode] [-lint=lint_mode] [-path=path] [files...]
+	fmt.Fprintf(flag.CommandLine.Output(), `usage: buildifier [-d] [-v] [-r] [-config=path.json] [-diff_command=command] [-diff_format=format] [-help] [-multi_diff] [-mode=mode] [-lint=lint_mode] [-path=path] [files...]
 
 Buildifier applies standard formatting to the named Starlark files.  The mode
 flag selects the processing: check, diff, fix, or print_if_changed.  In check
@@ -45,7 +51,10 @@ mode, buildifier prints a list of files that need reformatting.  In diff mode,
 buildifier shows the diffs that it would make.  It creates the diffs by running
 a diff command, which can be specified using the -diff_command flag. You can
 indicate that the diff command can show differences between more than two files
-in the manner of tkdiff by specifying the -multi_diff flag.  In fix mode,
+in the manner of tkdiff by specifying the -multi_diff flag.  With the
+-diff_format flag, buildifier computes the diffs itself instead and prints them
+in the unified, color (with the changed words highlighted) or json format.  In
+fix mode,
 buildifier updates the files that need reformatting and, if the -v flag is
 given, prints their names to standard error.  In print_if_changed mode,
 buildifier shows the file contents it would write.  The default mode is fix. -d
@@ -69,6 +78,11 @@ file names given, but the path can be given explicitly with the -path
 argument. This is especially useful when reformatting standard input,
 or in scripts that reformat a temporary copy of a file.
 
+With -server=socket, buildifier keeps running and processes the files sent to
+the unix socket by 'buildifier client [-socket=socket] [-files=list] [files...]'
+with its own configuration, which avoids the startup costs of repeated
+invocations, e.g. by pre-commit hooks.
+
 Return codes used by buildifier:
 
   0: success, everything went well
@@ -94,7 +108,133 @@ with -config=off.
 `)
 }
 
+// listRewrites prints the rewrites applied when formatting files.
+func listRewrites() {
+	for _, pass := range build.RewritePasses() {
+		var types []string
+		for _, t := range pass.FileTypes() {
+			types = append(types, t.String())
+		}
+		if len(types) == 0 {
+			types = []string{"opt-in"}
+		}
+		fmt.Printf("%s (%s): %s\n", pass.Name, strings.Join(types, ", "), pass.Description)
+	}
+}
+
+// loadAliases reports the symbols loaded under different aliases by the BUILD files in args,
+// directories are searched recursively. It returns the exit code.
+func loadAliases(c *config.Config, args []string) int {
+	paths, err := utils.ExpandDirectoriesWithExclusions(&args, c.Exclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+		return 3
+	}
+	parser := utils.GetParser(c.InputType)
+	var files []*build.File
+	exitCode := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			exitCode = 3
+			continue
+		}
+		f, err := parser(path, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			exitCode = 1
+			continue
+		}
+		if absolutePath, err := filepath.Abs(path); err == nil {
+			f.WorkspaceRoot, f.Pkg, f.Label = wspace.SplitFilePath(absolutePath)
+		}
+		files = append(files, f)
+	}
+	reports := warn.FindLoadAliases(files)
+	warn.PrintLoadAliases(os.Stdout, reports)
+	if exitCode == 0 && len(reports) > 0 {
+		exitCode = 4
+	}
+	return exitCode
+}
+
+// migrateConfig upgrades the configuration file to the current schema, reporting the changes
+// and the unknown keys on stderr. It returns the exit code.
+func migrateConfig(configPath string) int {
+	if configPath == "" || configPath == "off" || configPath == "example" {
+		fmt.Fprintf(os.Stderr, "buildifier: no %s configuration file found, use -config to specify it\n", ".buildifier.json")
+		return 2
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+		return 3
+	}
+	m, err := config.Migrate(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %s: %v\n", configPath, err)
+		return 2
+	}
+	for _, change := range m.Changes {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", configPath, change)
+	}
+	if !bytes.Equal(data, m.Data) {
+		if err := os.WriteFile(configPath, m.Data, 0666); err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			return 3
+		}
+	}
+	for _, key := range m.UnknownKeys {
+		fmt.Fprintf(os.Stderr, "%s: unknown key %q\n", configPath, key)
+	}
+	if len(m.UnknownKeys) > 0 {
+		return 4
+	}
+	return 0
+}
+
+// mergeFiles merges the changes from the base file to the ours and theirs files, given in this
+// order in args, into the ours file. It returns the exit code, 4 if there are conflicts.
+func mergeFiles(c *config.Config, args []string) int {
+	basePath, oursPath, theirsPath := args[0], args[1], args[2]
+	// Git passes temporary files, -path gives the name of the merged file.
+	name := oursPath
+	if c.WorkspaceRelativePath != "" {
+		name = c.WorkspaceRelativePath
+	}
+	parser := utils.GetParser(c.InputType)
+	var files []*build.File
+	for _, path := range []string{basePath, oursPath, theirsPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			return 3
+		}
+		f, err := parser(name, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %s: %v\n", path, err)
+			return 1
+		}
+		files = append(files, f)
+	}
+	result := merge.Merge(files[0], files[1], files[2])
+	if err := os.WriteFile(oursPath, result.Output, 0666); err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+		return 3
+	}
+	if result.Conflicts > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d merge conflict(s)\n", name, result.Conflicts)
+		return 4
+	}
+	return 0
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "client" {
+		os.Exit(runClient(os.Args[2:]))
+	}
+
 	c := config.New()
 
 	flags := c.FlagSet("buildifier", flag.ExitOnError)
@@ -119,6 +259,9 @@ func main() {
 	if c.ConfigPath == "" {
 		c.ConfigPath = config.FindConfigPath("")
 	}
+	if c.MigrateConfig {
+		os.Exit(migrateConfig(c.ConfigPath))
+	}
 	if c.ConfigPath != "" {
 		if c.ConfigPath == "example" {
 			fmt.Println(config.Example().String())
@@ -141,13 +284,82 @@ func main() {
 		fmt.Fprintf(os.Stderr, "buildifier: %s\n", err)
 		os.Exit(2)
 	}
+	for _, conflict := range c.TablesConflicts {
+		fmt.Fprintf(os.Stderr, "buildifier: conflicting table entry: %s\n", conflict)
+	}
+
+	if c.ListRewrites {
+		listRewrites()
+		os.Exit(0)
+	}
+
+	if c.LoadAliases {
+		os.Exit(loadAliases(c, args))
+	}
 
 	// Pass down debug flags into build package
-	build.DisableRewrites = c.DisableRewrites
+	build.DisableRewrites = append(c.DisableRewrites, c.DisabledRewrites...)
+	build.EnableRewrites = c.EnabledRewrites
 	build.AllowSort = c.AllowSort
+	build.PreserveLineEndings = c.PreserveLineEndings
+	build.AllowTabIndentation = c.TabIndentation
+	build.BlankLines = c.BlankLinePolicy
+	// Adjacent string literals in lists are only parsed as separate items if the missing-comma
+	// warning inserts the commas, otherwise they're syntax errors.
+	for _, warning := range c.LintWarnings {
+		if warning == "missing-comma" && c.Lint == "fix" {
+			build.AllowMissingCommas = true
+		}
+	}
+
+	if c.Merge {
+		os.Exit(mergeFiles(c, args))
+	}
+
+	// Pass down the policy flags into warn package
+	warn.PackageMetadataRoots = c.PackageMetadataRoots
+	warn.ExportsVisibilityRoots = c.ExportsVisibilityRoots
+	warn.SourceRoots = c.SourceRoots
+	warn.ToolchainModules = c.ToolchainModules
+	warn.AllowedDefines = c.AllowedDefines
+	warn.VendorDir = c.VendorDir
+	if len(c.PlatformConstraints) > 0 {
+		warn.PlatformConstraints = c.PlatformConstraints
+	}
+	warn.RemovePrints = c.RemovePrints
+	if c.SelectDefaultFix != "" {
+		warn.SelectDefaultFix = c.SelectDefaultFix
+	}
+	warn.BazelVersion = c.BazelVersion
+	if c.DocCoverage != "" {
+		warn.DocCoverage = c.DocCoverage
+	}
+	if c.MaxFunctionStatements > 0 {
+		warn.MaxFunctionStatements = c.MaxFunctionStatements
+	}
+	if c.MaxExportedSymbols > 0 {
+		warn.MaxExportedSymbols = c.MaxExportedSymbols
+	}
+	if c.MaxMacroDepth > 0 {
+		warn.MaxMacroDepth = c.MaxMacroDepth
+	}
+	if c.MaxAliasDepth > 0 {
+		warn.MaxAliasDepth = c.MaxAliasDepth
+	}
+	if c.MaxBuildTargets > 0 {
+		warn.MaxBuildTargets = c.MaxBuildTargets
+	}
+	if c.MaxBuildFileBytes > 0 {
+		warn.MaxBuildFileBytes = c.MaxBuildFileBytes
+	}
+	if c.MaxSelectBranches > 0 {
+		warn.MaxSelectBranches = c.MaxSelectBranches
+	}
 
 	differ, deprecationWarning := differ.Find()
-	if c.DiffCommand != "" {
+	if c.DiffFormat != "" {
+		differ.Format = c.DiffFormat
+	} else if c.DiffCommand != "" {
 		differ.Cmd = c.DiffCommand
 		differ.MultiDiff = c.MultiDiff
 	} else {
@@ -156,15 +368,109 @@ func main() {
 		}
 	}
 
-	b := buildifier{c, differ}
+	if c.Server != "" {
+		os.Exit(serve(c, differ))
+	}
+
+	profileOutput := c.ProfileOutput
+	if profileOutput == "" {
+		profileOutput = profile.DefaultOutput("buildifier", c.Profile)
+	}
+	stopProfile, err := profile.Start(c.Profile, profileOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %s\n", err)
+		os.Exit(3)
+	}
+	var stats *profile.Stats
+	if c.Stats {
+		stats = profile.NewStats()
+	}
+
+	changedLines, err := readChangedLines(c)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %s\n", err)
+		os.Exit(2)
+	}
+
+	b := buildifier{config: c, differ: differ, stats: stats, changedLines: changedLines}
 	exitCode := b.run(args)
 
+	if err := stopProfile(); err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: writing profile: %s\n", err)
+		exitCode = 3
+	}
+	stats.Write(os.Stderr, "buildifier")
+
 	os.Exit(exitCode)
 }
 
 type buildifier struct {
 	config *config.Config
 	differ *differ.Differ
+	// stats collects the time spent in the processing phases, nil unless -stats is given.
+	stats *profile.Stats
+	// changedLines restricts the reported lint findings to the changed lines, nil unless
+	// -diff_base or -diff_file is given.
+	changedLines utils.ChangedLines
+	// outcomes counts the results of the run that the exit code policy applies to.
+	outcomes outcomes
+	// tabIndented lists the files whose tab indentation was converted, see -tab_indentation.
+	tabIndented []string
+}
+
+// outcomes counts the results of a run that are mapped to exit codes by config.ExitCodePolicy.
+type outcomes struct {
+	parseErrors  int // files with syntax errors
+	lintWarnings int // reported lint findings
+	reformat     int // files that need reformatting in the check and diff modes
+	fixed        int // files reformatted in the fix mode
+}
+
+// exitCode returns the exit code for the outcomes of a run.
+func (b *buildifier) exitCode() int {
+	policy := b.config.ExitCodePolicy
+	lintCode := policy.Lint
+	if budget := b.config.MaxWarnings; budget > 0 {
+		lintCode = 0
+		if b.outcomes.lintWarnings > budget {
+			lintCode = policy.MaxWarnings
+		}
+	}
+	for _, outcome := range []struct{ count, code int }{
+		{b.outcomes.parseErrors, policy.Parse},
+		{b.outcomes.lintWarnings, lintCode},
+		{b.outcomes.reformat, policy.Reformat},
+		{b.outcomes.fixed, policy.Fixed},
+		{len(b.config.TablesConflicts), policy.Tables},
+	} {
+		if outcome.count > 0 && outcome.code != 0 {
+			return outcome.code
+		}
+	}
+	return 0
+}
+
+// readChangedLines returns the changed lines for differential linting, or nil if the whole
+// files are linted.
+func readChangedLines(c *config.Config) (utils.ChangedLines, error) {
+	switch {
+	case c.DiffBase != "":
+		return utils.GitChangedLines(c.DiffBase)
+	case c.DiffFile == "-":
+		return utils.ParseUnifiedDiff(os.Stdin)
+	case c.DiffFile != "":
+		file, err := os.Open(c.DiffFile)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		changed, err := utils.ParseUnifiedDiff(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", c.DiffFile, err)
+		}
+		return changed, nil
+	}
+	return nil, nil
 }
 
 func (b *buildifier) run(args []string) int {
@@ -190,7 +496,7 @@ func (b *buildifier) run(args []string) int {
 		files := args
 		if b.config.Recursive {
 			var err error
-			files, err = utils.ExpandDirectories(&args)
+			files, err = utils.ExpandDirectoriesWithExclusions(&args, b.config.Exclude)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
 				return 3
@@ -198,8 +504,14 @@ func (b *buildifier) run(args []string) int {
 		}
 		diagnostics, exitCode = b.processFiles(files, tf)
 	}
+	if exitCode == 0 {
+		exitCode = b.exitCode()
+	}
 
 	diagnosticsOutput := diagnostics.Format(b.config.Format, b.config.Verbose)
+	if b.config.Report == "summary" {
+		diagnosticsOutput = diagnostics.Summarize().Format(b.config.Format, b.config.Verbose)
+	}
 	if b.config.Format != "" {
 		// Explicitly provided --format means the diagnostics are printed to stdout
 		fmt.Print(diagnosticsOutput)
@@ -209,6 +521,20 @@ func (b *buildifier) run(args []string) int {
 		// --format is not provided, stdout is reserved for file contents
 		fmt.Fprint(os.Stderr, diagnosticsOutput)
 	}
+	if budget := b.config.MaxWarnings; budget > 0 && b.outcomes.lintWarnings > budget {
+		fmt.Fprintf(os.Stderr, "buildifier: %d lint warnings, more than the maximum of %d\n", b.outcomes.lintWarnings, budget)
+	}
+	if len(b.tabIndented) > 0 {
+		// Only the fix mode modifies the files, the other modes report what it would do.
+		verb := "would convert"
+		if b.config.Mode == "fix" || b.config.Mode == "pipe" {
+			verb = "converted"
+		}
+		fmt.Fprintf(os.Stderr, "buildifier: %s the tab indentation of the following files:\n", verb)
+		for _, name := range b.tabIndented {
+			fmt.Fprintf(os.Stderr, "  %s\n", name)
+		}
+	}
 
 	if err := b.differ.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
@@ -280,52 +606,124 @@ func (b *buildifier) processFiles(files []string, tf *utils.TempFile) (*utils.Di
 
 // processFile processes a single file containing data.
 // It has been read from filename and should be written back if fixing.
+// The returned exit code is only non-zero for runtime errors, the other
+// outcomes are counted in b.outcomes.
 func (b *buildifier) processFile(filename string, data []byte, displayFileNames bool, tf *utils.TempFile) (*utils.FileDiagnostics, int) {
-	var exitCode int
-
 	displayFilename := filename
 	if b.config.WorkspaceRelativePath != "" {
 		displayFilename = b.config.WorkspaceRelativePath
 	}
 
+	if b.config.InputType == "bazelrc" || b.config.InputType == "auto" && build.IsBazelrcFile(displayFilename) {
+		return b.processBazelrc(filename, displayFilename, data, displayFileNames, tf)
+	}
+
 	parser := utils.GetParser(b.config.InputType)
+	b.stats.AddFile()
 
+	stopTimer := b.stats.Time("parse")
 	f, err := parser(displayFilename, data)
+	if b.config.Lint == "fix" && b.config.PyCleanup {
+		// Python 2 print statements are converted to calls.
+		for fixed := data; err != nil; {
+			parseError, ok := err.(build.ParseError)
+			if !ok || !parseError.PrintStatement {
+				break
+			}
+			fixed = fixPrintStatement(fixed, parseError.Pos.Byte)
+			f, err = parser(displayFilename, fixed)
+		}
+	}
+	stopTimer()
 	if err != nil {
 		// Do not use buildifier: prefix on this error.
 		// Since it is a parse error, it begins with file:line:
 		// and we want that to be the first thing in the error.
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		if exitCode < 1 {
-			exitCode = 1
-		}
-		return utils.InvalidFileDiagnostics(displayFilename), exitCode
+		b.outcomes.parseErrors++
+		return utils.InvalidFileDiagnostics(displayFilename), 0
 	}
 
 	if absoluteFilename, err := filepath.Abs(displayFilename); err == nil {
 		f.WorkspaceRoot, f.Pkg, f.Label = wspace.SplitFilePath(absoluteFilename)
 	}
+	if f.TabIndented {
+		b.tabIndented = append(b.tabIndented, f.DisplayPath())
+	}
 
+	stopTimer = b.stats.Time("lint")
 	warnings := utils.Lint(f, b.config.Lint, &b.config.LintWarnings, b.config.Verbose)
-	if len(warnings) > 0 {
-		exitCode = 4
+	stopTimer()
+	if b.changedLines != nil {
+		absoluteFilename, _ := filepath.Abs(displayFilename)
+		warnings = b.changedLines.Filter(absoluteFilename, warnings)
 	}
+	if b.config.SkipGenerated {
+		warnings = utils.WithoutGenerated(warnings)
+	}
+	b.outcomes.lintWarnings += len(warnings)
 	fileDiagnostics := utils.NewFileDiagnostics(f.DisplayPath(), warnings)
 
+	if b.config.Explain {
+		if b.explain(f, data, parser) {
+			fileDiagnostics.Formatted = false
+			b.outcomes.reformat++
+		}
+		return fileDiagnostics, 0
+	}
+
+	stopTimer = b.stats.Time("print")
 	ndata := build.Format(f)
+	stopTimer()
+
+	return b.output(filename, f.DisplayPath(), data, ndata, fileDiagnostics, displayFileNames, tf)
+}
 
+// processBazelrc formats a .bazelrc file, which isn't linted.
+func (b *buildifier) processBazelrc(filename, displayFilename string, data []byte, displayFileNames bool, tf *utils.TempFile) (*utils.FileDiagnostics, int) {
+	b.stats.AddFile()
+	stopTimer := b.stats.Time("parse")
+	f, err := build.ParseBazelrc(displayFilename, data)
+	stopTimer()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		b.outcomes.parseErrors++
+		return utils.InvalidFileDiagnostics(displayFilename), 0
+	}
+
+	stopTimer = b.stats.Time("print")
+	ndata := build.FormatBazelrc(f)
+	stopTimer()
+	displayPath := displayFilename
+	if displayPath == "" {
+		displayPath = "<stdin>"
+	}
+	return b.output(filename, displayPath, data, ndata, utils.NewFileDiagnostics(displayPath, nil), displayFileNames, tf)
+}
+
+// output reports, shows or writes the formatted contents of a file according to the mode.
+func (b *buildifier) output(filename, displayPath string, data, ndata []byte, fileDiagnostics *utils.FileDiagnostics, displayFileNames bool, tf *utils.TempFile) (*utils.FileDiagnostics, int) {
 	switch b.config.Mode {
 	case "check":
 		// check mode: print names of files that need formatting.
 		if !bytes.Equal(data, ndata) {
 			fileDiagnostics.Formatted = false
-			return fileDiagnostics, 4
+			b.outcomes.reformat++
 		}
 
 	case "diff":
 		// diff mode: run diff on old and new.
 		if bytes.Equal(data, ndata) {
-			return fileDiagnostics, exitCode
+			return fileDiagnostics, 0
+		}
+		b.outcomes.reformat++
+		if b.differ.Format != "" {
+			// The built-in formats include the file names.
+			if err := b.differ.ShowContents(displayPath, data, ndata); err != nil {
+				fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+				return fileDiagnostics, 3
+			}
+			return fileDiagnostics, 0
 		}
 		outfile, err := tf.WriteTemp(ndata)
 		if err != nil {
@@ -343,11 +741,10 @@ func (b *buildifier) processFile(filename string, data []byte, displayFileNames
 			}
 		}
 		if displayFileNames {
-			fmt.Fprintf(os.Stderr, "%v:\n", f.DisplayPath())
+			fmt.Fprintf(os.Stderr, "%v:\n", displayPath)
 		}
 		if err := b.differ.Show(infile, outfile); err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
-			return fileDiagnostics, 4
 		}
 
 	case "pipe":
@@ -356,10 +753,26 @@ func (b *buildifier) processFile(filename string, data []byte, displayFileNames
 		os.Stdout.Write(ndata)
 
 	case "fix":
+		if b.config.OutputRoot != "" {
+			// fix mode with an output root: write all the files to the mirror tree.
+			if !bytes.Equal(data, ndata) {
+				b.outcomes.fixed++
+				if b.config.Verbose {
+					fmt.Fprintf(os.Stderr, "fixed %s\n", displayPath)
+				}
+			}
+			if err := writeToOutputRoot(b.config.OutputRoot, filename, ndata); err != nil {
+				fmt.Fprintf(os.Stderr, "buildifier: %s\n", err)
+				return fileDiagnostics, 3
+			}
+			return fileDiagnostics, 0
+		}
+
 		// fix mode: update files in place as needed.
 		if bytes.Equal(data, ndata) {
-			return fileDiagnostics, exitCode
+			return fileDiagnostics, 0
 		}
+		b.outcomes.fixed++
 
 		err := os.WriteFile(filename, ndata, 0666)
 		if err != nil {
@@ -368,11 +781,11 @@ func (b *buildifier) processFile(filename string, data []byte, displayFileNames
 		}
 
 		if b.config.Verbose {
-			fmt.Fprintf(os.Stderr, "fixed %s\n", f.DisplayPath())
+			fmt.Fprintf(os.Stderr, "fixed %s\n", displayPath)
 		}
 	case "print_if_changed":
 		if bytes.Equal(data, ndata) {
-			return fileDiagnostics, exitCode
+			return fileDiagnostics, 0
 		}
 
 		if _, err := os.Stdout.Write(ndata); err != nil {
@@ -380,5 +793,117 @@ func (b *buildifier) processFile(filename string, data []byte, displayFileNames
 			return fileDiagnostics, 3
 		}
 	}
-	return fileDiagnostics, exitCode
+	return fileDiagnostics, 0
+}
+
+// writeToOutputRoot writes the formatted contents of a file to the output root directory, at the
+// path of the file relative to the working directory.
+func writeToOutputRoot(root, filename string, data []byte) error {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside of the working directory, it can't be written to -output_root", filename)
+	}
+	output := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(output), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(output, data, 0666)
+}
+
+// explanation is printed for each changed hunk in the explain mode.
+type explanation struct {
+	File        string `json:"file"`
+	Step        string `json:"step"`
+	Description string `json:"description"`
+	differ.Hunk
+}
+
+// fixPrintStatement converts a Python 2 print statement to a call by wrapping its arguments,
+// starting at the given offset right after the print keyword, in parentheses. The arguments end
+// at the end of the line or at the first comment or semicolon outside of strings and brackets,
+// they continue on the next lines inside brackets, triple-quoted strings and after backslashes.
+func fixPrintStatement(data []byte, offset int) []byte {
+	end := offset
+	depth := 0
+	var quote []byte
+scan:
+	for ; end < len(data); end++ {
+		switch c := data[end]; {
+		case quote != nil && c == '\\':
+			end++
+		case quote != nil && bytes.HasPrefix(data[end:], quote):
+			end += len(quote) - 1
+			quote = nil
+		case quote != nil && c == '\n' && len(quote) == 1:
+			// An unterminated string, left for the parser to report.
+			break scan
+		case quote != nil:
+		case c == '"' || c == '\'':
+			quote = []byte{c}
+			if bytes.HasPrefix(data[end:], []byte{c, c, c}) {
+				quote = []byte{c, c, c}
+				end += 2
+			}
+		case c == '\\':
+			// A line continuation, skip the line ending.
+			end++
+			if end+1 < len(data) && data[end] == '\r' {
+				end++
+			}
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case (c == ')' || c == ']' || c == '}') && depth > 0:
+			depth--
+		case c == '#' && depth > 0:
+			// A comment inside brackets, the arguments continue on the next line.
+			for end+1 < len(data) && data[end+1] != '\n' {
+				end++
+			}
+		case depth == 0 && (c == '\n' || c == '#' || c == ';'):
+			break scan
+		}
+	}
+	args := bytes.TrimRightFunc(data[offset:end], unicode.IsSpace)
+	var fixed []byte
+	fixed = append(fixed, data[:offset]...)
+	fixed = append(fixed, '(')
+	fixed = append(fixed, bytes.TrimSpace(args)...)
+	fixed = append(fixed, ')')
+	// Keep the spaces before a comment and the line ending, including a trailing \r.
+	return append(fixed, data[offset+len(args):]...)
+}
+
+// explain prints the formatting changes of a file as JSON, one line per hunk, together with the
+// formatting step responsible for them. Line numbers refer to the file before and after the step.
+// Returns whether the file is changed.
+func (b *buildifier) explain(f *build.File, data []byte, parser func(string, []byte) (*build.File, error)) bool {
+	steps := build.ExplainFormat(f)
+	if b.config.Lint == "fix" {
+		// Separate the lint fixes from the layout of the original file.
+		if orig, err := parser(f.Path, data); err == nil {
+			if layout := build.FormatWithoutRewriting(orig); !bytes.Equal(layout, steps[0].Output) {
+				lint := build.FormatStep{Name: "lint", Description: "applied lint fixes", Output: steps[0].Output}
+				steps[0].Output = layout
+				steps = append(steps[:1], append([]build.FormatStep{lint}, steps[1:]...)...)
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	prev := data
+	for _, step := range steps {
+		for _, hunk := range differ.Hunks(string(prev), string(step.Output)) {
+			encoder.Encode(explanation{f.DisplayPath(), step.Name, step.Description, hunk})
+		}
+		prev = step.Output
+	}
+	return !bytes.Equal(data, prev)
 }
@@ -25,8 +25,12 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/lang"
+	"github.com/bazelbuild/buildtools/profile"
 	"github.com/bazelbuild/buildtools/tables"
 	"github.com/bazelbuild/buildtools/warn"
 	"github.com/bazelbuild/buildtools/wspace"
@@ -34,6 +38,9 @@ import (
 
 const buildifierJSONFilename = ".buildifier.json"
 
+// bazelVersionPattern matches the Bazel versions, e.g. 7, 7.4.0 or 8.0.0rc1.
+var bazelVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+){0,2}[-a-z0-9]*$`)
+
 // New constructs a Config with default values.
 func New() *Config {
 	return &Config{
@@ -89,8 +96,33 @@ func findTablesPath(file string) (string, error) {
 	return filepath.Join(dirname, file), nil
 }
 
+// loadTables loads the comma-separated table files given to a flag, the later files are merged
+// into the earlier ones, see tables.ParseAndUpdateJSONDefinitionFiles. It returns the entries
+// replaced with different values by the later files.
+func loadTables(flag, paths string, merge bool) ([]tables.Conflict, error) {
+	var files []string
+	for _, path := range strings.Split(paths, ",") {
+		if path == "" {
+			continue
+		}
+		found, err := findTablesPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find %s for -%s: %w", path, flag, err)
+		}
+		files = append(files, found)
+	}
+	conflicts, err := tables.ParseAndUpdateJSONDefinitionFiles(files, merge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s for -%s: %w", paths, flag, err)
+	}
+	return conflicts, nil
+}
+
 // Config is used to configure buildifier
 type Config struct {
+	// SchemaVersion is the version of the schema of the configuration file,
+	// see CurrentVersion
+	SchemaVersion int `json:"version,omitempty"`
 	// InputType determines the input file type: build (for BUILD files), bzl
 	// (for .bzl files), workspace (for WORKSPACE files), default (for generic
 	// Starlark files), module (for MODULE.bazel files)
@@ -98,20 +130,35 @@ type Config struct {
 	InputType string `json:"type,omitempty"`
 	// Format sets the diagnostics format: text or json (default text)
 	Format string `json:"format,omitempty"`
+	// Report sets the diagnostics report: full (one entry per finding, the default) or
+	// summary (the number of findings per warning category and per top-level directory)
+	Report string `json:"report,omitempty"`
 	// Mode determines the formatting mode: check, diff, or fix (default fix)
 	Mode string `json:"mode,omitempty"`
 	// DiffMode is an alias for
 	DiffMode bool `json:"diffMode,omitempty"`
 	// Lint determines the lint mode: off, warn, or fix (default off)
 	Lint string `json:"lint,omitempty"`
-	// Warnings is a comma-separated list of warning identifiers used in the lint mode or "all"
+	// Warnings is a comma-separated list of warning identifiers or warning groups used in the
+	// lint mode or "all"
 	Warnings string `json:"warnings,omitempty"`
 	// WarningsList is a list of warnings (alternative to comma-separated warnings string)
 	WarningsList []string `json:"warningsList,omitempty"`
+	// PyCleanup enables the warnings about Python 2 remnants and the fix of
+	// Python 2 print statements, the lint mode defaults to fix with it
+	PyCleanup bool `json:"pyCleanup,omitempty"`
 	// Recursive instructs buildifier to find starlark files recursively
 	Recursive bool `json:"recursive,omitempty"`
+	// Exclude lists patterns (in .gitignore syntax) of paths to skip when
+	// searching for starlark files recursively, in addition to the paths listed
+	// in .buildifierignore files
+	Exclude ArrayFlags `json:"exclude,omitempty"`
 	// Verbose instructs buildifier to output verbose diagnostics
 	Verbose bool `json:"verbose,omitempty"`
+	// Explain instructs buildifier to print a JSON description of each
+	// formatting change and the rewrite responsible for it instead of applying
+	// the changes
+	Explain bool `json:"explain,omitempty"`
 	// DiffCommand is the command to run when the formatting mode is diff
 	// (default uses the BUILDIFIER_DIFF, BUILDIFIER_MULTIDIFF, and DISPLAY
 	// environment variables to create the diff command)
@@ -119,17 +166,117 @@ type Config struct {
 	// MultiDiff means the command specified by the -diff_command flag can diff
 	// multiple files in the style of tkdiff (default false)
 	MultiDiff bool `json:"multiDiff,omitempty"`
-	// TablesPath is the path to JSON file with custom table definitions that
-	// will replace the built-in tables
+	// DiffFormat is the format of the diffs computed by buildifier itself
+	// instead of running DiffCommand: unified, color or json
+	DiffFormat string `json:"diffFormat,omitempty"`
+	// TablesPath is the comma-separated paths to JSON files with custom table
+	// definitions that will replace the built-in tables, the later files are
+	// merged into the earlier ones
 	TablesPath string `json:"tables,omitempty"`
-	// AddTablesPath path to JSON file with custom table definitions which will be merged with the built-in tables
+	// AddTablesPath is the comma-separated paths to JSON files with custom table definitions which will be merged with the built-in tables, in order
 	AddTablesPath string `json:"addTables,omitempty"`
 	// WorkspaceRelativePath - assume BUILD file has this path relative to the workspace directory
 	WorkspaceRelativePath string `json:"path,omitempty"`
 	// DisableRewrites configures the list of buildifier rewrites to disable
 	DisableRewrites ArrayFlags `json:"buildifier_disable,omitempty"`
+	// Rewrites is a comma-separated list of the buildifier rewrites to apply,
+	// or of modifiers of the default rewrites ("+foo" applies foo to all file
+	// types, "-foo" disables it)
+	Rewrites string `json:"rewrites,omitempty"`
 	// AllowSort specifies additional sort contexts to treat as safe
 	AllowSort ArrayFlags `json:"allowsort,omitempty"`
+	// PreserveLineEndings keeps the byte order mark and the CRLF line endings
+	// of the input files instead of normalizing them to LF
+	PreserveLineEndings bool `json:"preserveLineEndings,omitempty"`
+	// BlankLines is a comma-separated list of context=count pairs setting the
+	// number of blank lines between the top-level statements: rules (BUILD,
+	// WORKSPACE and MODULE.bazel files), loads (after the load statements) and
+	// defs (around the function definitions of .bzl files)
+	BlankLines string `json:"blankLines,omitempty"`
+	// TabIndentation accepts files indented with tabs and converts their
+	// indentation to spaces, the converted files are listed on stderr
+	TabIndentation bool `json:"tabIndentation,omitempty"`
+	// PackageMetadataRoots lists the package roots (e.g. third_party) where the
+	// package-metadata warning requires license declarations
+	PackageMetadataRoots ArrayFlags `json:"packageMetadataRoots,omitempty"`
+	// ExportsVisibilityRoots lists the package roots (e.g. third_party) where the
+	// broad-exports warning requires exports_files() to set a visibility
+	ExportsVisibilityRoots ArrayFlags `json:"exportsVisibilityRoots,omitempty"`
+	// SourceRoots lists the source roots (e.g. src/main/java), optionally
+	// followed by =<import path>, against which the source-root-layout
+	// warning checks the Go import paths, Java packages and proto import paths
+	SourceRoots ArrayFlags `json:"sourceRoots,omitempty"`
+	// ToolchainModules lists the modules that are only bazel_deps for the
+	// toolchains they register, which the unused-bazel-dep warning ignores
+	ToolchainModules ArrayFlags `json:"toolchainModules,omitempty"`
+	// VendorDir is the directory of the vendored external repositories,
+	// relative to the workspace root, in which the unresolved-load warning
+	// looks for the files loaded from other repositories
+	VendorDir string `json:"vendorDir,omitempty"`
+	// AllowedDefines lists the --define names that config_setting rules may
+	// match, the config-setting warning reports the other ones
+	AllowedDefines ArrayFlags `json:"allowedDefines,omitempty"`
+	// PlatformConstraints lists the constraint settings (e.g. @platforms//os)
+	// that the platform-constraints warning requires every platform to set
+	PlatformConstraints ArrayFlags `json:"platformConstraints,omitempty"`
+	// RemovePrints makes the autofix of the print warning remove the print()
+	// calls used as statements
+	RemovePrints bool `json:"removePrints,omitempty"`
+	// SelectDefaultFix is the autofix of the select-default warning: empty
+	// (add a default branch with an empty value) or error (add a
+	// no_match_error argument)
+	SelectDefaultFix string `json:"selectDefaultFix,omitempty"`
+	// BazelVersion is the version of Bazel the files are used with (e.g.
+	// 7.4.0), which decides the functions of the native module the
+	// missing-native warning knows about, empty for the latest one
+	BazelVersion string `json:"bazelVersion,omitempty"`
+	// DocCoverage is the set of definitions the rule-doc and attr-doc warnings
+	// require a documentation on: public (the default) or all
+	DocCoverage string `json:"docCoverage,omitempty"`
+	// MaxFunctionStatements is the number of statements a function may contain
+	// before the function-length warning reports it (default 50)
+	MaxFunctionStatements int `json:"maxFunctionStatements,omitempty"`
+	// MaxExportedSymbols is the number of public symbols a .bzl file may export
+	// before the exported-symbols warning reports it (default 40)
+	MaxExportedSymbols int `json:"maxExportedSymbols,omitempty"`
+	// MaxMacroDepth is the number of nested macro layers a macro may consist of
+	// before the macro-depth warning reports it (default 3)
+	MaxMacroDepth int `json:"maxMacroDepth,omitempty"`
+	// MaxAliasDepth is the number of aliases a chain of aliases may consist of
+	// before the alias-chain warning reports it (default 2)
+	MaxAliasDepth int `json:"maxAliasDepth,omitempty"`
+	// MaxBuildTargets is the number of targets a BUILD file may define before
+	// the oversized-build-file warning reports it (default 500)
+	MaxBuildTargets int `json:"maxBuildTargets,omitempty"`
+	// MaxBuildFileBytes is the size of a BUILD file before the
+	// oversized-build-file warning reports it (default 200000)
+	MaxBuildFileBytes int `json:"maxBuildFileBytes,omitempty"`
+	// MaxSelectBranches is the number of select() branches a BUILD file may
+	// contain before the oversized-build-file warning reports it (default 100)
+	MaxSelectBranches int `json:"maxSelectBranches,omitempty"`
+	// MaxWarnings is the number of lint warnings allowed before the run fails,
+	// lint warnings within the budget don't fail it (default 0, no budget)
+	MaxWarnings int `json:"maxWarnings,omitempty"`
+	// ExitCodes is a comma-separated list of outcome=code pairs overriding
+	// the exit codes for syntax errors, exceeded warning budgets, lint
+	// warnings and reformatted files (parse, max_warnings, lint, reformat)
+	ExitCodes string `json:"exitCodes,omitempty"`
+	// BuildLanguagePath is the output of `bazel info build-language`, used by the
+	// unknown-attribute warning instead of the bundled schema of native rules
+	BuildLanguagePath string `json:"buildLanguage,omitempty"`
+	// UsedDepsPath is a JSON file mapping target labels to the labels of the
+	// dependencies they use, used by the stale-keep warning
+	UsedDepsPath string `json:"usedDeps,omitempty"`
+
+	// DiffBase is a git revision, if set only the lint findings on the lines
+	// added or modified since that revision are reported
+	DiffBase string `json:"diffBase,omitempty"`
+	// DiffFile is the path to a unified diff ("-" for stdin), if set only the
+	// lint findings on the lines added or modified by the diff are reported
+	DiffFile string `json:"-"`
+	// SkipGenerated drops the lint findings within the regions delimited by
+	// "# buildifier: begin-generated" and "# buildifier: end-generated" comments
+	SkipGenerated bool `json:"skipGenerated,omitempty"`
 
 	// Help is true if the -h flag is set
 	Help bool `json:"-"`
@@ -137,8 +284,42 @@ type Config struct {
 	Version bool `json:"-"`
 	// ConfigPath is the path to this config
 	ConfigPath string `json:"-"`
+	// Profile is the kind of profile to collect: cpu, mem, or trace (default none)
+	Profile string `json:"-"`
+	// ProfileOutput is the file the profile is written to
+	ProfileOutput string `json:"-"`
+	// ListRewrites instructs buildifier to print the available rewrites and exit
+	ListRewrites bool `json:"-"`
+	// MigrateConfig instructs buildifier to upgrade the configuration file to
+	// the current schema and exit
+	MigrateConfig bool `json:"-"`
+	// LoadAliases instructs buildifier to report the symbols loaded under different
+	// aliases by the BUILD files under the given directories and exit
+	LoadAliases bool `json:"-"`
+	// Merge instructs buildifier to merge three versions of a file (base, ours
+	// and theirs), write the result to ours and exit
+	Merge bool `json:"-"`
+	// Server is the path of a unix socket to listen on, buildifier then processes the files
+	// sent by `buildifier client` with this configuration until it's interrupted
+	Server string `json:"-"`
+	// OutputRoot is a directory the fix mode writes all the formatted files to, at their
+	// paths relative to the working directory, instead of modifying them in place
+	OutputRoot string `json:"-"`
+	// Stats instructs buildifier to print the time spent parsing, linting and printing files
+	Stats bool `json:"-"`
 	// LintWarnings is the final validated list of Lint/Fix warnings
 	LintWarnings []string `json:"-"`
+	// TablesConflicts are the table entries set to different values by several
+	// of the files of TablesPath or AddTablesPath
+	TablesConflicts []tables.Conflict `json:"-"`
+	// EnabledRewrites is the validated list of rewrites to apply to all file types
+	EnabledRewrites []string `json:"-"`
+	// DisabledRewrites is the validated list of rewrites disabled by -rewrites
+	DisabledRewrites []string `json:"-"`
+	// ExitCodePolicy is the validated exit code policy
+	ExitCodePolicy ExitCodePolicy `json:"-"`
+	// BlankLinePolicy is the validated blank line policy of the printer
+	BlankLinePolicy build.BlankLinePolicy `json:"-"`
 }
 
 // LoadFile unmarshals JSON file from the ConfigPath field.
@@ -157,7 +338,12 @@ func (c *Config) LoadReader(in io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("reading config: %w", err)
 	}
-	if err := json.Unmarshal(data, c); err != nil {
+	// Older configuration files are upgraded in memory, see Migrate.
+	m, err := Migrate(data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(m.Data, c); err != nil {
 		return err
 	}
 	return nil
@@ -172,19 +358,62 @@ func (c *Config) FlagSet(name string, errorHandling flag.ErrorHandling) *flag.Fl
 	flags.BoolVar(&c.Verbose, "v", c.Verbose, "print verbose information to standard error")
 	flags.BoolVar(&c.DiffMode, "d", c.DiffMode, "alias for -mode=diff")
 	flags.BoolVar(&c.Recursive, "r", c.Recursive, "find starlark files recursively")
+	flags.BoolVar(&c.Stats, "stats", false, "print a summary of the time spent parsing, linting and printing files to standard error")
+	flags.BoolVar(&c.Explain, "explain", c.Explain, "print a JSON description of each formatting change and the rewrite responsible for it instead of applying the changes")
+	flags.BoolVar(&c.ListRewrites, "list_rewrites", false, "print the rewrites applied when formatting files, the file types they apply to by default and their descriptions")
+	flags.BoolVar(&c.MigrateConfig, "migrate_config", false, "upgrade the configuration file to the current schema, replacing the renamed warnings, report its unknown keys and exit")
+	flags.BoolVar(&c.LoadAliases, "load_aliases", false, "report the symbols that the BUILD files under the given directories load under different aliases, grouped by symbol, and exit")
+	flags.BoolVar(&c.Merge, "merge", false, "merge the changes from the base file to the ours and theirs files, given in this order, into the ours file, e.g. as a git merge driver, and exit")
+	flags.StringVar(&c.OutputRoot, "output_root", "", "write the formatted files (all of them, even if unchanged) to a mirror tree under the given directory, at their paths relative to the working directory, instead of modifying them in place; requires -mode=fix")
+	flags.StringVar(&c.Server, "server", "", "listen on the given unix socket and process the files sent by 'buildifier client' with this configuration, until interrupted")
+	flags.BoolVar(&c.PyCleanup, "py_cleanup", c.PyCleanup, "find and fix Python 2 remnants: print statements, octal literals, backslash line continuations, chained comparisons and Python 2 dict methods (implies -lint=fix, or -lint=warn if the mode isn't fix)")
+	flags.BoolVar(&c.PreserveLineEndings, "preserve_line_endings", c.PreserveLineEndings, "keep the byte order mark and the CRLF line endings of the input files instead of normalizing them to LF")
+	flags.StringVar(&c.BlankLines, "blank_lines", c.BlankLines, "comma-separated context=count pairs setting the number of blank lines (0 to 2, default 1) between the top-level statements: rules (of BUILD, WORKSPACE and MODULE.bazel files), loads (after the load statements) and defs (around the function definitions of .bzl files)")
+	flags.BoolVar(&c.TabIndentation, "tab_indentation", c.TabIndentation, "accept files indented with tabs, e.g. by code generators, and indent them with spaces; the converted files are listed on standard error")
 	flags.BoolVar(&c.MultiDiff, "multi_diff", c.MultiDiff, "the command specified by the -diff_command flag can diff multiple files in the style of tkdiff (default false)")
 	flags.StringVar(&c.Mode, "mode", c.Mode, "formatting mode: check, diff, or fix (default fix)")
 	flags.StringVar(&c.Format, "format", c.Format, "diagnostics format: text or json (default text)")
+	flags.StringVar(&c.Report, "report", c.Report, "diagnostics report: full (one line or JSON object per finding) or summary (the number of findings per warning category and per top-level directory, and the files with the most findings) (default full)")
+	flags.StringVar(&c.DiffFormat, "diff_format", c.DiffFormat, "compute the diffs of the diff mode without running -diff_command and print them in the given format: unified, color (unified, colored like git diff --color, with the changed words highlighted), or json (one JSON object per hunk)")
 	flags.StringVar(&c.DiffCommand, "diff_command", c.DiffCommand, "command to run when the formatting mode is diff (default uses the BUILDIFIER_DIFF, BUILDIFIER_MULTIDIFF, and DISPLAY environment variables to create the diff command)")
+	flags.StringVar(&c.DiffBase, "diff_base", c.DiffBase, "git revision, report only the lint findings on the lines added or modified since it")
+	flags.StringVar(&c.DiffFile, "diff_file", "", "unified diff file ('-' for stdin), report only the lint findings on the lines added or modified by it")
+	flags.BoolVar(&c.SkipGenerated, "skip_generated", c.SkipGenerated, "don't report the lint findings within the regions delimited by '# buildifier: begin-generated' and '# buildifier: end-generated' comments (they're reported with a generated tag otherwise)")
 	flags.StringVar(&c.Lint, "lint", c.Lint, "lint mode: off, warn, or fix (default off)")
-	flags.StringVar(&c.Warnings, "warnings", c.Warnings, "comma-separated warnings used in the lint mode or \"all\"")
+	flags.StringVar(&c.Warnings, "warnings", c.Warnings, "comma-separated warnings or warning groups (bzlmod, correctness, migration, performance, style) used in the lint mode or \"all\"")
+	flags.StringVar(&c.Rewrites, "rewrites", c.Rewrites, "comma-separated rewrites to apply when formatting, or modifiers of the default rewrites: +foo applies foo to all file types, -foo disables it (see -list_rewrites)")
 	flags.StringVar(&c.WorkspaceRelativePath, "path", c.WorkspaceRelativePath, "assume BUILD file has this path relative to the workspace directory")
-	flags.StringVar(&c.TablesPath, "tables", c.TablesPath, "path to JSON file with custom table definitions which will replace the built-in tables")
-	flags.StringVar(&c.AddTablesPath, "add_tables", c.AddTablesPath, "path to JSON file with custom table definitions which will be merged with the built-in tables")
-	flags.StringVar(&c.InputType, "type", c.InputType, "Input file type: build (for BUILD files), bzl (for .bzl files), workspace (for WORKSPACE files), module (for MODULE.bazel files), default (for generic Starlark files) or auto (default, based on the filename)")
+	flags.StringVar(&c.TablesPath, "tables", c.TablesPath, "comma-separated paths to JSON files with custom table definitions which will replace the built-in tables, the later files are merged into the earlier ones")
+	flags.StringVar(&c.BuildLanguagePath, "build_language", c.BuildLanguagePath, "path to the output of 'bazel info build-language' (binary, or JSON if the name ends with .json) used by the unknown-attribute warning")
+	flags.StringVar(&c.UsedDepsPath, "used_deps", c.UsedDepsPath, "path to a JSON file mapping target labels to the labels of the dependencies they use, used by the stale-keep warning")
+	flags.StringVar(&c.AddTablesPath, "add_tables", c.AddTablesPath, "comma-separated paths to JSON files with custom table definitions which will be merged with the built-in tables, in order")
+	flags.StringVar(&c.InputType, "type", c.InputType, "Input file type: build (for BUILD files), bzl (for .bzl files), workspace (for WORKSPACE files), module (for MODULE.bazel files), repo (for REPO.bazel files), vendor (for VENDOR.bazel files), bazelrc (for .bazelrc files, formatted only), default (for generic Starlark files) or auto (default, based on the filename)")
 	flags.StringVar(&c.ConfigPath, "config", "", "path to .buildifier.json config file")
+	flags.StringVar(&c.Profile, "profile", "", "collect a profile of the run: cpu, mem, or trace")
+	flags.StringVar(&c.ProfileOutput, "profile_output", "", "file to write the profile to (default buildifier.<kind>.pprof, or buildifier.trace)")
 	flags.Var(&c.AllowSort, "allowsort", "additional sort contexts to treat as safe")
+	flags.Var(&c.Exclude, "exclude", "patterns (in .gitignore syntax) of paths to skip when searching for starlark files recursively")
 	flags.Var(&c.DisableRewrites, "buildifier_disable", "list of buildifier rewrites to disable")
+	flags.IntVar(&c.MaxFunctionStatements, "max_function_statements", c.MaxFunctionStatements, "number of statements a function may contain before the function-length warning reports it (default 50)")
+	flags.IntVar(&c.MaxExportedSymbols, "max_exported_symbols", c.MaxExportedSymbols, "number of public symbols a .bzl file may export before the exported-symbols warning reports it (default 40)")
+	flags.IntVar(&c.MaxBuildTargets, "max_build_targets", c.MaxBuildTargets, "number of targets a BUILD file may define before the oversized-build-file warning reports it (default 500)")
+	flags.IntVar(&c.MaxBuildFileBytes, "max_build_file_bytes", c.MaxBuildFileBytes, "size in bytes of a BUILD file before the oversized-build-file warning reports it (default 200000)")
+	flags.IntVar(&c.MaxSelectBranches, "max_select_branches", c.MaxSelectBranches, "number of select() branches a BUILD file may contain before the oversized-build-file warning reports it (default 100)")
+	flags.IntVar(&c.MaxMacroDepth, "max_macro_depth", c.MaxMacroDepth, "number of nested macro layers a macro may consist of before the macro-depth warning reports it (default 3)")
+	flags.IntVar(&c.MaxAliasDepth, "max_alias_depth", c.MaxAliasDepth, "number of aliases a chain of aliases may consist of before the alias-chain warning reports it (default 2)")
+	flags.IntVar(&c.MaxWarnings, "max_warnings", c.MaxWarnings, "number of lint warnings allowed before the run fails with the max_warnings exit code, lint warnings within the budget don't fail the run (default 0, no budget)")
+	flags.StringVar(&c.ExitCodes, "exit_codes", c.ExitCodes, "comma-separated outcome=code pairs overriding the exit codes: parse (default 1), max_warnings (default 4), lint (default 4), reformat (default 4 in the check and diff modes, 0 in the fix mode), tables (default 4); 0 doesn't fail the run")
+	flags.Var(&c.PackageMetadataRoots, "package_metadata_roots", "package roots where the package-metadata warning requires license declarations")
+	flags.BoolVar(&c.RemovePrints, "remove_prints", c.RemovePrints, "make the autofix of the print warning remove the print() calls used as statements")
+	flags.StringVar(&c.SelectDefaultFix, "select_default_fix", c.SelectDefaultFix, "autofix of the select-default warning: empty (add a default branch with an empty value, the default) or error (add a no_match_error argument)")
+	flags.StringVar(&c.BazelVersion, "bazel_version", c.BazelVersion, "version of Bazel the files are used with (e.g. 7.4.0), which decides the functions of the native module the missing-native warning knows about (default the latest one)")
+	flags.StringVar(&c.DocCoverage, "doc_coverage", c.DocCoverage, "definitions the rule-doc and attr-doc warnings require a doc parameter on: public (the rules, repository rules, module extensions and attributes with public names and the tag classes of the public module extensions, the default) or all")
+	flags.Var(&c.ExportsVisibilityRoots, "exports_visibility_roots", "package roots where the broad-exports warning requires exports_files() to set a visibility")
+	flags.Var(&c.SourceRoots, "source_roots", "source roots, optionally followed by =<import path> (e.g. go=example.com/repo), against which the source-root-layout warning checks the Go import paths, Java packages and proto import paths")
+	flags.Var(&c.ToolchainModules, "toolchain_modules", "modules that are only bazel_deps for the toolchains they register, which the unused-bazel-dep warning ignores")
+	flags.StringVar(&c.VendorDir, "vendor_dir", c.VendorDir, "directory of the vendored external repositories relative to the workspace root (e.g. created by 'bazel vendor --vendor_dir'), in which the unresolved-load warning looks for the files loaded from other repositories")
+	flags.Var(&c.AllowedDefines, "allowed_defines", "--define names that config_setting rules may match, the config-setting warning reports the other ones")
+	flags.Var(&c.PlatformConstraints, "platform_constraints", "constraint settings (e.g. @platforms//os) that the platform-constraints warning requires every platform to set (default @platforms//os and @platforms//cpu)")
 
 	return flags
 }
@@ -205,32 +434,139 @@ func (c *Config) Validate(args []string) error {
 		return err
 	}
 
+	if err := ValidateDiffFormat(c.DiffFormat, c.DiffCommand); err != nil {
+		return err
+	}
+
+	switch c.Report {
+	case "", "full", "summary":
+	default:
+		return fmt.Errorf("unrecognized report %s; valid reports are full, summary", c.Report)
+	}
+	if c.PyCleanup && c.Lint == "off" {
+		c.Lint = "warn"
+		if c.Mode == "fix" {
+			c.Lint = "fix"
+		}
+	}
+
+	switch c.SelectDefaultFix {
+	case "", "empty", "error":
+	default:
+		return fmt.Errorf("unrecognized select_default_fix %s; valid values are empty, error", c.SelectDefaultFix)
+	}
+
+	if c.BazelVersion != "" && !bazelVersionPattern.MatchString(c.BazelVersion) {
+		return fmt.Errorf("invalid bazel_version %s; expected a version such as 7.4.0", c.BazelVersion)
+	}
+
+	switch c.DocCoverage {
+	case "", "public", "all":
+	default:
+		return fmt.Errorf("unrecognized doc_coverage %s; valid values are public, all", c.DocCoverage)
+	}
+
+	if c.MaxWarnings < 0 {
+		return fmt.Errorf("-max_warnings must not be negative")
+	}
+	exitCodePolicy, err := ValidateExitCodes(c.ExitCodes)
+	if err != nil {
+		return err
+	}
+	c.ExitCodePolicy = exitCodePolicy
+	blankLinePolicy, err := ValidateBlankLines(c.BlankLines)
+	if err != nil {
+		return err
+	}
+	c.BlankLinePolicy = blankLinePolicy
+
+	if err := profile.Validate(c.Profile); err != nil {
+		return err
+	}
+
+	if c.Explain && c.Format != "" {
+		return fmt.Errorf("cannot specify both -explain and -format flags")
+	}
+
+	if c.DiffBase != "" && c.DiffFile != "" {
+		return fmt.Errorf("cannot specify both -diff_base and -diff_file flags")
+	}
+	if c.DiffFile == "-" && (len(args) == 0 || (len(args) == 1 && args[0] == "-")) {
+		return fmt.Errorf("cannot read both the diff and the input file from stdin")
+	}
+
+	if c.Server != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("-server doesn't accept files, they are sent by 'buildifier client'")
+		}
+		if c.DiffBase != "" || c.DiffFile != "" {
+			return fmt.Errorf("cannot specify -diff_base or -diff_file with -server")
+		}
+	}
+
+	if c.OutputRoot != "" {
+		if c.Mode != "fix" {
+			return fmt.Errorf("-output_root requires -mode=fix")
+		}
+		if len(args) == 0 || (len(args) == 1 && args[0] == "-") {
+			return fmt.Errorf("-output_root requires files, it doesn't apply to the standard input")
+		}
+	}
+
+	if c.Merge && len(args) != 3 {
+		return fmt.Errorf("-merge requires three files: base, ours and theirs")
+	}
+
 	// If the path flag is set, must only be formatting a single file.
 	// It doesn't make sense for multiple files to have the same path.
-	if (c.WorkspaceRelativePath != "" || c.Mode == "print_if_changed") && len(args) > 1 {
+	// The three files of -merge are versions of the same file.
+	if (c.WorkspaceRelativePath != "" || c.Mode == "print_if_changed") && len(args) > 1 && !c.Merge {
 		return fmt.Errorf("can only format one file when using -path flag or -mode=print_if_changed")
 	}
 
 	if c.TablesPath != "" {
-		foundTablesPath, err := findTablesPath(c.TablesPath)
+		conflicts, err := loadTables("tables", c.TablesPath, false)
 		if err != nil {
-			return fmt.Errorf("failed to find %s for -tables: %w", c.TablesPath, err)
-		}
-		if err := tables.ParseAndUpdateJSONDefinitions(foundTablesPath, false); err != nil {
-			return fmt.Errorf("failed to parse %s for -tables: %w", foundTablesPath, err)
+			return err
 		}
+		c.TablesConflicts = append(c.TablesConflicts, conflicts...)
 	}
 
 	if c.AddTablesPath != "" {
-		foundTablesPath, err := findTablesPath(c.AddTablesPath)
+		conflicts, err := loadTables("add_tables", c.AddTablesPath, true)
 		if err != nil {
-			return fmt.Errorf("failed to find %s for -add_tables: %w", c.AddTablesPath, err)
+			return err
 		}
-		if err := tables.ParseAndUpdateJSONDefinitions(foundTablesPath, true); err != nil {
-			return fmt.Errorf("failed to parse %s for -add_tables: %w", foundTablesPath, err)
+		c.TablesConflicts = append(c.TablesConflicts, conflicts...)
+	}
+
+	if c.BuildLanguagePath != "" {
+		attributes, err := lang.ReadAttributes(c.BuildLanguagePath)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s for -build_language: %w", c.BuildLanguagePath, err)
+		}
+		warn.RuleAttributes = attributes
+	}
+
+	if c.UsedDepsPath != "" {
+		usedDeps, err := warn.ReadUsedDeps(c.UsedDepsPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s for -used_deps: %w", c.UsedDepsPath, err)
 		}
+		warn.UsedDeps = usedDeps
 	}
 
+	var rewriteNames []string
+	for _, pass := range build.RewritePasses() {
+		rewriteNames = append(rewriteNames, pass.Name)
+	}
+	enabledRewrites, disabledRewrites, err := ValidateRewrites(c.Rewrites, rewriteNames)
+	if err != nil {
+		return err
+	}
+	c.EnabledRewrites = enabledRewrites
+	c.DisabledRewrites = disabledRewrites
+
 	warningsList := c.WarningsList
 	if c.Warnings != "" {
 		warningsList = append(warningsList, c.Warnings)
@@ -241,6 +577,19 @@ func (c *Config) Validate(args []string) error {
 		return err // TODO(pcj) return nil?
 	}
 	c.LintWarnings = lintWarnings
+	if c.PyCleanup {
+		// The list may be shared with warn.DefaultWarnings, don't append to it in place.
+		c.LintWarnings = append([]string{}, c.LintWarnings...)
+		enabled := make(map[string]bool)
+		for _, warning := range c.LintWarnings {
+			enabled[warning] = true
+		}
+		for _, warning := range warn.PythonCleanupWarnings {
+			if !enabled[warning] {
+				c.LintWarnings = append(c.LintWarnings, warning)
+			}
+		}
+	}
 
 	return nil
 }
@@ -272,6 +621,7 @@ func (i *ArrayFlags) Set(value string) error {
 // Example creates a sample configuration file for the -config=example flag.
 func Example() *Config {
 	c := New()
+	c.SchemaVersion = CurrentVersion
 	c.InputType = "auto"
 	c.Mode = "fix"
 	c.Lint = "fix"
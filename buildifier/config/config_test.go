@@ -21,8 +21,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
 )
 
 func ExampleNew() {
@@ -39,19 +42,26 @@ func ExampleExample() {
 	fmt.Print(c.String())
 	// Output:
 	// {
+	//   "version": 1,
 	//   "type": "auto",
 	//   "mode": "fix",
 	//   "lint": "fix",
 	//   "warningsList": [
+	//     "alias-chain",
 	//     "attr-applicable_licenses",
 	//     "attr-cfg",
+	//     "attr-doc",
 	//     "attr-license",
 	//     "attr-licenses",
 	//     "attr-non-empty",
 	//     "attr-output-default",
 	//     "attr-single-file",
+	//     "broad-exports",
 	//     "build-args-kwargs",
 	//     "bzl-visibility",
+	//     "canonical-load-label",
+	//     "chained-comparison",
+	//     "config-setting",
 	//     "confusing-name",
 	//     "constant-glob",
 	//     "ctx-actions",
@@ -61,20 +71,41 @@ func ExampleExample() {
 	//     "depset-iteration",
 	//     "depset-union",
 	//     "dict-concatenation",
+	//     "dict-iteration-order",
 	//     "dict-method-named-arg",
+	//     "disallowed-statement",
 	//     "duplicated-name",
+	//     "exported-symbols",
+	//     "fail-message",
 	//     "filetype",
 	//     "function-docstring",
 	//     "function-docstring-args",
 	//     "function-docstring-header",
 	//     "function-docstring-return",
+	//     "function-length",
 	//     "git-repository",
+	//     "glob-context",
+	//     "glob-patterns",
 	//     "http-archive",
 	//     "integer-division",
 	//     "keyword-positional-params",
+	//     "label-string-comparison",
+	//     "legacy-dict-method",
+	//     "legacy-octal",
+	//     "line-continuation",
 	//     "list-append",
 	//     "load",
+	//     "macro-depth",
+	//     "macro-kwargs-forwarding",
+	//     "misplaced-attribute",
+	//     "missing-comma",
+	//     "missing-native",
+	//     "missing-source-file",
+	//     "mixed-type-comparison",
 	//     "module-docstring",
+	//     "module-extension-tag",
+	//     "module-order",
+	//     "module-version",
 	//     "name-conventions",
 	//     "native-android",
 	//     "native-build",
@@ -120,25 +151,40 @@ func ExampleExample() {
 	//     "native-sh-binary",
 	//     "native-sh-library",
 	//     "native-sh-test",
+	//     "negative-repetition",
 	//     "no-effect",
 	//     "output-group",
 	//     "overly-nested-depset",
+	//     "oversized-build-file",
+	//     "package-metadata",
 	//     "package-name",
 	//     "package-on-top",
+	//     "platform-constraints",
 	//     "positional-args",
 	//     "print",
 	//     "provider-params",
 	//     "redefined-variable",
+	//     "redundant-attr-label",
+	//     "reexported-load",
 	//     "repository-name",
 	//     "return-value",
+	//     "rule-doc",
 	//     "rule-impl-return",
+	//     "select-default",
+	//     "select-order",
 	//     "skylark-comment",
 	//     "skylark-docstring",
+	//     "source-root-layout",
+	//     "stale-keep",
 	//     "string-iteration",
+	//     "test-suite-membership",
 	//     "uninitialized",
+	//     "unknown-attribute",
 	//     "unnamed-macro",
 	//     "unreachable",
+	//     "unresolved-load",
 	//     "unsorted-dict-items",
+	//     "unused-bazel-dep",
 	//     "unused-variable"
 	//   ]
 	// }
@@ -151,24 +197,67 @@ func ExampleFlagSet() {
 		fmt.Printf("%s: %s (%q)\n", f.Name, f.Usage, f.DefValue)
 	})
 	// Output:
-	// add_tables: path to JSON file with custom table definitions which will be merged with the built-in tables ("")
+	// add_tables: comma-separated paths to JSON files with custom table definitions which will be merged with the built-in tables, in order ("")
+	// allowed_defines: --define names that config_setting rules may match, the config-setting warning reports the other ones ("")
 	// allowsort: additional sort contexts to treat as safe ("")
+	// bazel_version: version of Bazel the files are used with (e.g. 7.4.0), which decides the functions of the native module the missing-native warning knows about (default the latest one) ("")
+	// blank_lines: comma-separated context=count pairs setting the number of blank lines (0 to 2, default 1) between the top-level statements: rules (of BUILD, WORKSPACE and MODULE.bazel files), loads (after the load statements) and defs (around the function definitions of .bzl files) ("")
+	// build_language: path to the output of 'bazel info build-language' (binary, or JSON if the name ends with .json) used by the unknown-attribute warning ("")
 	// buildifier_disable: list of buildifier rewrites to disable ("")
 	// config: path to .buildifier.json config file ("")
 	// d: alias for -mode=diff ("false")
+	// diff_base: git revision, report only the lint findings on the lines added or modified since it ("")
 	// diff_command: command to run when the formatting mode is diff (default uses the BUILDIFIER_DIFF, BUILDIFIER_MULTIDIFF, and DISPLAY environment variables to create the diff command) ("")
+	// diff_file: unified diff file ('-' for stdin), report only the lint findings on the lines added or modified by it ("")
+	// diff_format: compute the diffs of the diff mode without running -diff_command and print them in the given format: unified, color (unified, colored like git diff --color, with the changed words highlighted), or json (one JSON object per hunk) ("")
+	// doc_coverage: definitions the rule-doc and attr-doc warnings require a doc parameter on: public (the rules, repository rules, module extensions and attributes with public names and the tag classes of the public module extensions, the default) or all ("")
+	// exclude: patterns (in .gitignore syntax) of paths to skip when searching for starlark files recursively ("")
+	// exit_codes: comma-separated outcome=code pairs overriding the exit codes: parse (default 1), max_warnings (default 4), lint (default 4), reformat (default 4 in the check and diff modes, 0 in the fix mode), tables (default 4); 0 doesn't fail the run ("")
+	// explain: print a JSON description of each formatting change and the rewrite responsible for it instead of applying the changes ("false")
+	// exports_visibility_roots: package roots where the broad-exports warning requires exports_files() to set a visibility ("")
 	// format: diagnostics format: text or json (default text) ("")
 	// help: print usage information ("false")
 	// lint: lint mode: off, warn, or fix (default off) ("")
+	// list_rewrites: print the rewrites applied when formatting files, the file types they apply to by default and their descriptions ("false")
+	// load_aliases: report the symbols that the BUILD files under the given directories load under different aliases, grouped by symbol, and exit ("false")
+	// max_alias_depth: number of aliases a chain of aliases may consist of before the alias-chain warning reports it (default 2) ("0")
+	// max_build_file_bytes: size in bytes of a BUILD file before the oversized-build-file warning reports it (default 200000) ("0")
+	// max_build_targets: number of targets a BUILD file may define before the oversized-build-file warning reports it (default 500) ("0")
+	// max_exported_symbols: number of public symbols a .bzl file may export before the exported-symbols warning reports it (default 40) ("0")
+	// max_function_statements: number of statements a function may contain before the function-length warning reports it (default 50) ("0")
+	// max_macro_depth: number of nested macro layers a macro may consist of before the macro-depth warning reports it (default 3) ("0")
+	// max_select_branches: number of select() branches a BUILD file may contain before the oversized-build-file warning reports it (default 100) ("0")
+	// max_warnings: number of lint warnings allowed before the run fails with the max_warnings exit code, lint warnings within the budget don't fail the run (default 0, no budget) ("0")
+	// merge: merge the changes from the base file to the ours and theirs files, given in this order, into the ours file, e.g. as a git merge driver, and exit ("false")
+	// migrate_config: upgrade the configuration file to the current schema, replacing the renamed warnings, report its unknown keys and exit ("false")
 	// mode: formatting mode: check, diff, or fix (default fix) ("")
 	// multi_diff: the command specified by the -diff_command flag can diff multiple files in the style of tkdiff (default false) ("false")
+	// output_root: write the formatted files (all of them, even if unchanged) to a mirror tree under the given directory, at their paths relative to the working directory, instead of modifying them in place; requires -mode=fix ("")
+	// package_metadata_roots: package roots where the package-metadata warning requires license declarations ("")
 	// path: assume BUILD file has this path relative to the workspace directory ("")
+	// platform_constraints: constraint settings (e.g. @platforms//os) that the platform-constraints warning requires every platform to set (default @platforms//os and @platforms//cpu) ("")
+	// preserve_line_endings: keep the byte order mark and the CRLF line endings of the input files instead of normalizing them to LF ("false")
+	// profile: collect a profile of the run: cpu, mem, or trace ("")
+	// profile_output: file to write the profile to (default buildifier.<kind>.pprof, or buildifier.trace) ("")
+	// py_cleanup: find and fix Python 2 remnants: print statements, octal literals, backslash line continuations, chained comparisons and Python 2 dict methods (implies -lint=fix, or -lint=warn if the mode isn't fix) ("false")
 	// r: find starlark files recursively ("false")
-	// tables: path to JSON file with custom table definitions which will replace the built-in tables ("")
-	// type: Input file type: build (for BUILD files), bzl (for .bzl files), workspace (for WORKSPACE files), module (for MODULE.bazel files), default (for generic Starlark files) or auto (default, based on the filename) ("auto")
+	// remove_prints: make the autofix of the print warning remove the print() calls used as statements ("false")
+	// report: diagnostics report: full (one line or JSON object per finding) or summary (the number of findings per warning category and per top-level directory, and the files with the most findings) (default full) ("")
+	// rewrites: comma-separated rewrites to apply when formatting, or modifiers of the default rewrites: +foo applies foo to all file types, -foo disables it (see -list_rewrites) ("")
+	// select_default_fix: autofix of the select-default warning: empty (add a default branch with an empty value, the default) or error (add a no_match_error argument) ("")
+	// server: listen on the given unix socket and process the files sent by 'buildifier client' with this configuration, until interrupted ("")
+	// skip_generated: don't report the lint findings within the regions delimited by '# buildifier: begin-generated' and '# buildifier: end-generated' comments (they're reported with a generated tag otherwise) ("false")
+	// source_roots: source roots, optionally followed by =<import path> (e.g. go=example.com/repo), against which the source-root-layout warning checks the Go import paths, Java packages and proto import paths ("")
+	// stats: print a summary of the time spent parsing, linting and printing files to standard error ("false")
+	// tab_indentation: accept files indented with tabs, e.g. by code generators, and indent them with spaces; the converted files are listed on standard error ("false")
+	// tables: comma-separated paths to JSON files with custom table definitions which will replace the built-in tables, the later files are merged into the earlier ones ("")
+	// toolchain_modules: modules that are only bazel_deps for the toolchains they register, which the unused-bazel-dep warning ignores ("")
+	// type: Input file type: build (for BUILD files), bzl (for .bzl files), workspace (for WORKSPACE files), module (for MODULE.bazel files), repo (for REPO.bazel files), vendor (for VENDOR.bazel files), bazelrc (for .bazelrc files, formatted only), default (for generic Starlark files) or auto (default, based on the filename) ("auto")
+	// used_deps: path to a JSON file mapping target labels to the labels of the dependencies they use, used by the stale-keep warning ("")
 	// v: print verbose information to standard error ("false")
+	// vendor_dir: directory of the vendored external repositories relative to the workspace root (e.g. created by 'bazel vendor --vendor_dir'), in which the unresolved-load warning looks for the files loaded from other repositories ("")
 	// version: print the version of buildifier ("false")
-	// warnings: comma-separated warnings used in the lint mode or "all" ("")
+	// warnings: comma-separated warnings or warning groups (bzlmod, correctness, migration, performance, style) used in the lint mode or "all" ("")
 }
 
 func ExampleFlagSet_parse() {
@@ -253,23 +342,40 @@ func TestValidate(t *testing.T) {
 		"format text":           {options: "--mode=check --format=text"},
 		"format json":           {options: "--mode=check --format=json"},
 		"format error":          {options: "--mode=check --format=foo", wantErr: fmt.Errorf("unrecognized format foo; valid types are text, json")},
+		"diff format color":     {options: "-d --diff_format=color", wantMode: "diff"},
+		"diff format command":   {options: "-d --diff_format=json --diff_command=diff", wantErr: fmt.Errorf("cannot specify both --diff_format and --diff_command")},
+		"diff format error":     {options: "-d --diff_format=foo", wantErr: fmt.Errorf("unrecognized diff format foo; valid formats are unified, color, json")},
+		"report summary":        {options: "--report=summary"},
+		"report error":          {options: "--report=foo", wantErr: fmt.Errorf("unrecognized report foo; valid reports are full, summary")},
+		"select fix":            {options: "--select_default_fix=error"},
+		"select fix error":      {options: "--select_default_fix=fail", wantErr: fmt.Errorf("unrecognized select_default_fix fail; valid values are empty, error")},
+		"bazel version":         {options: "--bazel_version=7.4.0"},
+		"bazel version error":   {options: "--bazel_version=latest", wantErr: fmt.Errorf("invalid bazel_version latest; expected a version such as 7.4.0")},
+		"doc coverage":          {options: "--doc_coverage=all"},
+		"doc coverage error":    {options: "--doc_coverage=some", wantErr: fmt.Errorf("unrecognized doc_coverage some; valid values are public, all")},
 		"type build":            {options: "--type=build"},
 		"type bzl":              {options: "--type=bzl"},
 		"type workspace":        {options: "--type=workspace"},
 		"type default":          {options: "--type=default"},
 		"type module":           {options: "--type=module"},
 		"type auto":             {options: "--type=auto"},
-		"type error":            {options: "--type=foo", wantErr: fmt.Errorf("unrecognized input type foo; valid types are build, bzl, workspace, default, module, auto")},
+		"type error":            {options: "--type=foo", wantErr: fmt.Errorf("unrecognized input type foo; valid types are build, bzl, workspace, default, module, repo, vendor, bazelrc, auto")},
 		"warnings all": {options: "--warnings=all", wantWarnings: []string{
+			"alias-chain",
 			"attr-applicable_licenses",
 			"attr-cfg",
+			"attr-doc",
 			"attr-license",
 			"attr-licenses",
 			"attr-non-empty",
 			"attr-output-default",
 			"attr-single-file",
+			"broad-exports",
 			"build-args-kwargs",
 			"bzl-visibility",
+			"canonical-load-label",
+			"chained-comparison",
+			"config-setting",
 			"confusing-name",
 			"constant-glob",
 			"ctx-actions",
@@ -279,20 +385,41 @@ func TestValidate(t *testing.T) {
 			"depset-iteration",
 			"depset-union",
 			"dict-concatenation",
+			"dict-iteration-order",
 			"dict-method-named-arg",
+			"disallowed-statement",
 			"duplicated-name",
+			"exported-symbols",
+			"fail-message",
 			"filetype",
 			"function-docstring",
 			"function-docstring-args",
 			"function-docstring-header",
 			"function-docstring-return",
+			"function-length",
 			"git-repository",
+			"glob-context",
+			"glob-patterns",
 			"http-archive",
 			"integer-division",
 			"keyword-positional-params",
+			"label-string-comparison",
+			"legacy-dict-method",
+			"legacy-octal",
+			"line-continuation",
 			"list-append",
 			"load",
+			"macro-depth",
+			"macro-kwargs-forwarding",
+			"misplaced-attribute",
+			"missing-comma",
+			"missing-native",
+			"missing-source-file",
+			"mixed-type-comparison",
 			"module-docstring",
+			"module-extension-tag",
+			"module-order",
+			"module-version",
 			"name-conventions",
 			"native-android",
 			"native-build",
@@ -338,37 +465,58 @@ func TestValidate(t *testing.T) {
 			"native-sh-binary",
 			"native-sh-library",
 			"native-sh-test",
+			"negative-repetition",
 			"no-effect",
 			"output-group",
 			"overly-nested-depset",
+			"oversized-build-file",
+			"package-metadata",
 			"package-name",
 			"package-on-top",
+			"platform-constraints",
 			"positional-args",
 			"print",
 			"provider-params",
 			"redefined-variable",
+			"redundant-attr-label",
+			"reexported-load",
 			"repository-name",
 			"return-value",
+			"rule-doc",
 			"rule-impl-return",
+			"select-default",
+			"select-order",
 			"skylark-comment",
 			"skylark-docstring",
+			"source-root-layout",
+			"stale-keep",
 			"string-iteration",
+			"test-suite-membership",
 			"uninitialized",
+			"unknown-attribute",
 			"unnamed-macro",
 			"unreachable",
+			"unresolved-load",
 			"unsorted-dict-items",
+			"unused-bazel-dep",
 			"unused-variable",
 		}},
 		"warnings default": {options: "--warnings=default", wantWarnings: []string{
+			// "alias-chain",
 			"attr-applicable_licenses",
 			"attr-cfg",
+			// "attr-doc",
 			"attr-license",
 			"attr-licenses",
 			"attr-non-empty",
 			"attr-output-default",
 			"attr-single-file",
+			// "broad-exports",
 			"build-args-kwargs",
 			"bzl-visibility",
+			// "canonical-load-label",
+			// "chained-comparison",
+			"config-setting",
 			"confusing-name",
 			"constant-glob",
 			"ctx-actions",
@@ -378,20 +526,41 @@ func TestValidate(t *testing.T) {
 			"depset-iteration",
 			"depset-union",
 			"dict-concatenation",
+			// "dict-iteration-order",
 			"dict-method-named-arg",
+			"disallowed-statement",
 			"duplicated-name",
+			// "exported-symbols",
+			"fail-message",
 			"filetype",
 			"function-docstring",
 			"function-docstring-args",
 			"function-docstring-header",
 			"function-docstring-return",
+			// "function-length",
 			"git-repository",
+			// "glob-context",
+			// "glob-patterns",
 			"http-archive",
 			"integer-division",
 			"keyword-positional-params",
+			// "label-string-comparison",
+			// "legacy-dict-method",
+			// "legacy-octal",
+			// "line-continuation",
 			"list-append",
 			"load",
+			// "macro-depth",
+			// "macro-kwargs-forwarding",
+			// "misplaced-attribute",
+			"missing-comma",
+			"missing-native",
+			// "missing-source-file",
+			"mixed-type-comparison",
 			"module-docstring",
+			"module-extension-tag",
+			// "module-order",
+			"module-version",
 			"name-conventions",
 			"native-android",
 			"native-build",
@@ -437,37 +606,58 @@ func TestValidate(t *testing.T) {
 			"native-sh-binary",
 			"native-sh-library",
 			"native-sh-test",
+			"negative-repetition",
 			"no-effect",
 			"output-group",
 			"overly-nested-depset",
+			// "oversized-build-file",
+			// "package-metadata",
 			"package-name",
 			"package-on-top",
+			// "platform-constraints",
 			"positional-args",
 			"print",
 			"provider-params",
 			"redefined-variable",
+			// "redundant-attr-label",
+			// "reexported-load",
 			"repository-name",
 			"return-value",
+			// "rule-doc",
 			"rule-impl-return",
+			// "select-default",
+			// "select-order",
 			"skylark-comment",
 			"skylark-docstring",
+			// "source-root-layout",
+			// "stale-keep",
 			"string-iteration",
+			// "test-suite-membership",
 			"uninitialized",
+			// "unknown-attribute",
 			"unnamed-macro",
 			"unreachable",
+			// "unresolved-load",
 			// "unsorted-dict-items",
+			// "unused-bazel-dep",
 			"unused-variable",
 		}},
 		"warnings plus/minus": {options: "--warnings=+unsorted-dict-items,-print,-deprecated-function", wantWarnings: []string{
+			// "alias-chain",
 			"attr-applicable_licenses",
 			"attr-cfg",
+			// "attr-doc",
 			"attr-license",
 			"attr-licenses",
 			"attr-non-empty",
 			"attr-output-default",
 			"attr-single-file",
+			// "broad-exports",
 			"build-args-kwargs",
 			"bzl-visibility",
+			// "canonical-load-label",
+			// "chained-comparison",
+			"config-setting",
 			"confusing-name",
 			"constant-glob",
 			"ctx-actions",
@@ -477,20 +667,41 @@ func TestValidate(t *testing.T) {
 			"depset-iteration",
 			"depset-union",
 			"dict-concatenation",
+			// "dict-iteration-order",
 			"dict-method-named-arg",
+			"disallowed-statement",
 			"duplicated-name",
+			// "exported-symbols",
+			"fail-message",
 			"filetype",
 			"function-docstring",
 			"function-docstring-args",
 			"function-docstring-header",
 			"function-docstring-return",
+			// "function-length",
 			"git-repository",
+			// "glob-context",
+			// "glob-patterns",
 			"http-archive",
 			"integer-division",
 			"keyword-positional-params",
+			// "label-string-comparison",
+			// "legacy-dict-method",
+			// "legacy-octal",
+			// "line-continuation",
 			"list-append",
 			"load",
+			// "macro-depth",
+			// "macro-kwargs-forwarding",
+			// "misplaced-attribute",
+			"missing-comma",
+			"missing-native",
+			// "missing-source-file",
+			"mixed-type-comparison",
 			"module-docstring",
+			"module-extension-tag",
+			// "module-order",
+			"module-version",
 			"name-conventions",
 			"native-android",
 			"native-build",
@@ -536,18 +747,33 @@ func TestValidate(t *testing.T) {
 			"native-sh-binary",
 			"native-sh-library",
 			"native-sh-test",
+			"negative-repetition",
 			"no-effect",
 			"output-group",
 			"overly-nested-depset",
+			// "oversized-build-file",
+			// "package-metadata",
 			"package-name",
 			"package-on-top",
+			// "platform-constraints",
 			"positional-args",
 			// "print",
 			"provider-params",
 			"redefined-variable",
+			// "redundant-attr-label",
+			// "reexported-load",
 			"repository-name",
 			"return-value",
+			// "rule-doc",
 			"rule-impl-return",
+			// "select-default",
+			// "select-order",
+			// "source-root-layout",
+			// "stale-keep",
+			// "test-suite-membership",
+			// "unknown-attribute",
+			// "unresolved-load",
+			// "unused-bazel-dep",
 
 			"skylark-comment",
 			"skylark-docstring",
@@ -558,6 +784,16 @@ func TestValidate(t *testing.T) {
 			"unused-variable",
 			"unsorted-dict-items",
 		}},
+		"py_cleanup check mode": {options: "--py_cleanup --mode=check", wantLint: "warn"},
+		"py_cleanup lint warn":  {options: "--py_cleanup --lint=warn", wantLint: "warn"},
+		"py_cleanup": {options: "--py_cleanup --warnings=load,legacy-octal", wantLint: "fix", wantWarnings: []string{
+			"load",
+			"legacy-octal",
+			"chained-comparison",
+			"legacy-dict-method",
+			"line-continuation",
+		}},
+		"rewrites error": {options: "--rewrites=listsort,-label", wantErr: fmt.Errorf(`rewrites with modifiers ("+" or "-") can't be mixed with raw rewrites`)},
 		"warnings error": {options: "--warnings=native-py,-print,-deprecated-function", wantErr: fmt.Errorf(`warning categories with modifiers ("+" or "-") can't be mixed with raw warning categories`)},
 	} {
 		t.Run(name, func(t *testing.T) {
@@ -754,3 +990,198 @@ func TestFindTablePath(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateExitCodes(t *testing.T) {
+	for _, tc := range []struct {
+		exitCodes string
+		want      ExitCodePolicy
+		wantErr   string
+	}{
+		{exitCodes: "", want: DefaultExitCodes},
+		{exitCodes: "lint=0,max_warnings=5", want: ExitCodePolicy{Parse: 1, MaxWarnings: 5, Lint: 0, Reformat: 4, Fixed: 0, Tables: 4}},
+		{exitCodes: "reformat=6,parse=7", want: ExitCodePolicy{Parse: 7, MaxWarnings: 4, Lint: 4, Reformat: 6, Fixed: 6, Tables: 4}},
+		{exitCodes: "tables=0", want: ExitCodePolicy{Parse: 1, MaxWarnings: 4, Lint: 4, Reformat: 4, Fixed: 0, Tables: 0}},
+		{exitCodes: "lint", wantErr: `invalid exit code "lint"; expected outcome=code`},
+		{exitCodes: "lint=x", wantErr: `invalid exit code "x" for lint; expected a number between 0 and 125`},
+		{exitCodes: "lint=200", wantErr: `invalid exit code "200" for lint; expected a number between 0 and 125`},
+		{exitCodes: "parse=3", wantErr: `exit code 3 for parse is reserved for usage and runtime errors`},
+		{exitCodes: "format=5", wantErr: `unrecognized outcome format; valid outcomes are parse, max_warnings, lint, reformat, tables`},
+	} {
+		got, err := ValidateExitCodes(tc.exitCodes)
+		if err != nil || tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("ValidateExitCodes(%q) error = %v, want %q", tc.exitCodes, err, tc.wantErr)
+			}
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ValidateExitCodes(%q) = %+v, want %+v", tc.exitCodes, got, tc.want)
+		}
+	}
+}
+
+func TestValidateBlankLines(t *testing.T) {
+	for _, tc := range []struct {
+		blankLines string
+		want       build.BlankLinePolicy
+		wantErr    string
+	}{
+		{blankLines: "", want: build.DefaultBlankLines},
+		{blankLines: "rules=2,defs=2", want: build.BlankLinePolicy{BetweenRules: 2, AfterLoads: 1, BetweenDefs: 2}},
+		{blankLines: "loads=0", want: build.BlankLinePolicy{BetweenRules: 1, AfterLoads: 0, BetweenDefs: 1}},
+		{blankLines: "rules", wantErr: `invalid blank lines "rules"; expected context=count`},
+		{blankLines: "rules=x", wantErr: `invalid number of blank lines "x" for rules; expected a number between 0 and 2`},
+		{blankLines: "defs=3", wantErr: `invalid number of blank lines "3" for defs; expected a number between 0 and 2`},
+		{blankLines: "macros=2", wantErr: `unrecognized blank lines context macros; valid contexts are rules, loads, defs`},
+	} {
+		got, err := ValidateBlankLines(tc.blankLines)
+		if err != nil || tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("ValidateBlankLines(%q) error = %v, want %q", tc.blankLines, err, tc.wantErr)
+			}
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ValidateBlankLines(%q) = %+v, want %+v", tc.blankLines, got, tc.want)
+		}
+	}
+}
+
+func TestValidateRewrites(t *testing.T) {
+	all := []string{"callsort", "label", "listsort"}
+	for _, tc := range []struct {
+		rewrites    string
+		wantEnable  []string
+		wantDisable []string
+		wantErr     string
+	}{
+		{rewrites: ""},
+		{rewrites: "label", wantDisable: []string{"callsort", "listsort"}},
+		{rewrites: "+label,-listsort", wantEnable: []string{"label"}, wantDisable: []string{"listsort"}},
+		{rewrites: "-callsort", wantDisable: []string{"callsort"}},
+		{rewrites: "+foo", wantErr: `unrecognized rewrite "foo"; valid rewrites are callsort, label, listsort`},
+		{rewrites: "label,-listsort", wantErr: `rewrites with modifiers ("+" or "-") can't be mixed with raw rewrites`},
+	} {
+		enable, disable, err := ValidateRewrites(tc.rewrites, all)
+		if err != nil || tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("ValidateRewrites(%q) error = %v, want %q", tc.rewrites, err, tc.wantErr)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(enable, tc.wantEnable) || !reflect.DeepEqual(disable, tc.wantDisable) {
+			t.Errorf("ValidateRewrites(%q) = %v, %v, want %v, %v", tc.rewrites, enable, disable, tc.wantEnable, tc.wantDisable)
+		}
+	}
+}
+
+func TestValidateWarningGroups(t *testing.T) {
+	all := []string{"depset-union", "list-append", "load", "native-py", "print", "unknown-attribute"}
+	defaults := []string{"depset-union", "load", "native-py", "print"}
+	for _, tc := range []struct {
+		warnings string
+		want     []string
+		wantErr  string
+	}{
+		{warnings: "performance,load", want: []string{"depset-iteration", "depset-union", "dict-concatenation", "list-append", "overly-nested-depset", "load"}},
+		{warnings: "-style,+unknown-attribute", want: []string{"depset-union", "native-py", "unknown-attribute"}},
+		{warnings: "+performance,-list-append,-migration", want: []string{"depset-union", "load", "print", "depset-iteration", "dict-concatenation", "overly-nested-depset"}},
+		{warnings: "-style,+print", want: []string{"depset-union", "native-py", "print"}},
+		{warnings: "all,-correctness,-performance", want: []string{"load", "native-py", "print"}},
+		{warnings: "default,-load", want: []string{"depset-union", "native-py", "print"}},
+		{warnings: "native-java,load", want: []string{"native-java-binary", "native-java-import", "native-java-library", "native-java-plugin", "native-java-test", "native-java-package-config", "native-java-runtime", "native-java-toolchain", "load"}},
+		{warnings: "style,-print", wantErr: `warning categories with modifiers ("+" or "-") can't be mixed with raw warning categories`},
+	} {
+		got, err := ValidateWarnings(&tc.warnings, &all, &defaults)
+		if err != nil || tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("ValidateWarnings(%q) error = %v, want %q", tc.warnings, err, tc.wantErr)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ValidateWarnings(%q) = %v, want %v", tc.warnings, got, tc.want)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	m, err := Migrate([]byte(`{
+  "mode": "check",
+  "warnings": "+native-java,-load",
+  "warningsList": ["native-cc", "print,native-java"],
+  "lnt": "warn",
+  "diffCommand": "diff <a>",
+  "maxWarnings": 10
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	javaWarnings := "native-java-binary,native-java-import,native-java-library,native-java-plugin,native-java-test,native-java-package-config,native-java-runtime,native-java-toolchain"
+	want := `{
+  "diffCommand": "diff <a>",
+  "lnt": "warn",
+  "maxWarnings": 10,
+  "mode": "check",
+  "version": 1,
+  "warnings": "+` + strings.ReplaceAll(javaWarnings, ",", ",+") + `,-load",
+  "warningsList": [
+    "native-cc-binary",
+    "native-cc-import",
+    "native-cc-library",
+    "native-cc-objc-import",
+    "native-cc-objc-library",
+    "native-cc-shared-library",
+    "native-cc-test",
+    "native-cc-toolchain",
+    "native-cc-toolchain-suite",
+    "native-cc-fdo-prefetch-hints",
+    "native-cc-fdo-profile",
+    "native-cc-memprof-profile",
+    "native-cc-propeller-optimize",
+    "print,` + javaWarnings + `"
+  ]
+}
+`
+	if got := string(m.Data); got != want {
+		t.Errorf("Migrate() =\n%s\nwant:\n%s", got, want)
+	}
+	if len(m.Changes) != 4 || m.Changes[3] != "set the config version to 1" {
+		t.Errorf("Migrate() changes = %q, want 3 renamed warnings and the new version", m.Changes)
+	}
+	if want := []string{"lnt"}; !reflect.DeepEqual(m.UnknownKeys, want) {
+		t.Errorf("Migrate() unknown keys = %q, want %q", m.UnknownKeys, want)
+	}
+
+	// A migrated file doesn't change anymore.
+	m2, err := Migrate(m.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(m2.Data) != string(m.Data) || len(m2.Changes) != 0 {
+		t.Errorf("Migrate() of a migrated file = %s with changes %q, want no changes", m2.Data, m2.Changes)
+	}
+}
+
+func TestLoadReaderVersion(t *testing.T) {
+	c := New()
+	if err := c.LoadReader(strings.NewReader(`{"warnings": "native-java", "lint": "warn"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if c.SchemaVersion != CurrentVersion || c.Lint != "warn" || !strings.HasPrefix(c.Warnings, "native-java-binary,") {
+		t.Errorf("LoadReader() of an old config = version %d, lint %q, warnings %q, want it upgraded", c.SchemaVersion, c.Lint, c.Warnings)
+	}
+
+	for _, tc := range []struct {
+		config  string
+		wantErr string
+	}{
+		{`{"version": 2}`, "the config version 2 is newer than the versions supported by this buildifier (up to 1), please upgrade buildifier"},
+		{`{"version": "1"}`, "the config version must be an integer, got 1"},
+		{`{"version": 1.5}`, "the config version must be a non-negative integer, got 1.5"},
+	} {
+		if err := New().LoadReader(strings.NewReader(tc.config)); err == nil || err.Error() != tc.wantErr {
+			t.Errorf("LoadReader(%s) error = %v, want %q", tc.config, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/warn"
+)
+
+// CurrentVersion is the version of the schema of the configuration files understood by this
+// version of buildifier. Configuration files without a version have the version 0, files with
+// a newer version are rejected.
+const CurrentVersion = 1
+
+// migrations upgrade the decoded contents of a configuration file from the version of their
+// index to the next one, and return the descriptions of the changes they made.
+var migrations = []func(map[string]interface{}) []string{
+	// 0 -> 1: the warnings that were split into several ones are replaced.
+	migrateRenamedWarnings,
+}
+
+// migrateRenamedWarnings replaces the former names of the renamed warnings in the warnings
+// and warningsList keys, see warn.RenamedWarnings.
+func migrateRenamedWarnings(config map[string]interface{}) []string {
+	var changes []string
+	rename := func(warnings []string) []string {
+		var result []string
+		for _, warning := range warnings {
+			modifier, name := "", strings.TrimSpace(warning)
+			if strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-") {
+				modifier, name = name[:1], name[1:]
+			}
+			renamed, ok := warn.RenamedWarnings[name]
+			if !ok {
+				result = append(result, warning)
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("replaced the warning %q with %s", name, strings.Join(renamed, ", ")))
+			for _, r := range renamed {
+				result = append(result, modifier+r)
+			}
+		}
+		return result
+	}
+
+	if warnings, ok := config["warnings"].(string); ok && warnings != "" {
+		config["warnings"] = strings.Join(rename(strings.Split(warnings, ",")), ",")
+	}
+	if list, ok := config["warningsList"].([]interface{}); ok {
+		var warnings []string
+		valid := true
+		for _, w := range list {
+			s, ok := w.(string)
+			if !ok {
+				valid = false
+				break
+			}
+			// Each element may be a comma-separated list too.
+			if names := strings.Split(s, ","); len(names) > 1 {
+				warnings = append(warnings, strings.Join(rename(names), ","))
+			} else {
+				warnings = append(warnings, rename(names)...)
+			}
+		}
+		if valid {
+			config["warningsList"] = warnings
+		}
+	}
+	return changes
+}
+
+// configKeys returns the keys of the configuration files, i.e. the JSON names of the fields of
+// Config.
+func configKeys() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// configVersion returns the schema version of the decoded contents of a configuration file.
+func configVersion(config map[string]interface{}) (int, error) {
+	v, ok := config["version"]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("the config version must be an integer, got %v", v)
+	}
+	version, err := n.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("the config version must be a non-negative integer, got %v", n)
+	}
+	if version > CurrentVersion {
+		return 0, fmt.Errorf("the config version %d is newer than the versions supported by this buildifier (up to %d), please upgrade buildifier", version, CurrentVersion)
+	}
+	return int(version), nil
+}
+
+// decodeConfig decodes the contents of a configuration file, keeping the numbers as they are.
+func decodeConfig(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var config map[string]interface{}
+	if err := decoder.Decode(&config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// A Migration is the result of Migrate.
+type Migration struct {
+	// Data is the upgraded contents of the configuration file.
+	Data []byte
+	// Changes are the descriptions of the changes made to upgrade the file.
+	Changes []string
+	// UnknownKeys are the sorted keys of the file that buildifier doesn't recognize, e.g.
+	// because of a typo. They're kept as they are.
+	UnknownKeys []string
+}
+
+// Migrate upgrades the JSON contents of a configuration file to the current version of the
+// schema. The upgraded contents are indented, with the keys sorted.
+func Migrate(data []byte) (*Migration, error) {
+	config, err := decodeConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	version, err := configVersion(config)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Migration{}
+	for ; version < CurrentVersion; version++ {
+		m.Changes = append(m.Changes, migrations[version](config)...)
+	}
+	if v, _ := config["version"].(json.Number); v.String() != fmt.Sprint(CurrentVersion) {
+		m.Changes = append(m.Changes, fmt.Sprintf("set the config version to %d", CurrentVersion))
+		config["version"] = CurrentVersion
+	}
+
+	keys := configKeys()
+	for key := range config {
+		if !keys[key] {
+			m.UnknownKeys = append(m.UnknownKeys, key)
+		}
+	}
+	sort.Strings(m.UnknownKeys)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(config); err != nil {
+		return nil, err
+	}
+	m.Data = buf.Bytes()
+	return m, nil
+}
@@ -18,17 +18,22 @@ package config
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/differ"
+	"github.com/bazelbuild/buildtools/warn"
 )
 
 // ValidateInputType validates the value of --type
 func ValidateInputType(inputType *string) error {
 	switch *inputType {
-	case "build", "bzl", "workspace", "default", "module", "auto":
+	case "build", "bzl", "workspace", "default", "module", "repo", "vendor", "bazelrc", "auto":
 		return nil
 
 	default:
-		return fmt.Errorf("unrecognized input type %s; valid types are build, bzl, workspace, default, module, auto", *inputType)
+		return fmt.Errorf("unrecognized input type %s; valid types are build, bzl, workspace, default, module, repo, vendor, bazelrc, auto", *inputType)
 	}
 }
 
@@ -49,6 +54,20 @@ func ValidateFormat(format, mode *string) error {
 	return nil
 }
 
+// ValidateDiffFormat validates the value of --diff_format
+func ValidateDiffFormat(diffFormat, diffCommand string) error {
+	if diffFormat == "" {
+		return nil
+	}
+	if !differ.IsFormat(diffFormat) {
+		return fmt.Errorf("unrecognized diff format %s; valid formats are %s, %s, %s", diffFormat, differ.FormatUnified, differ.FormatColor, differ.FormatJSON)
+	}
+	if diffCommand != "" {
+		return fmt.Errorf("cannot specify both --diff_format and --diff_command")
+	}
+	return nil
+}
+
 // isRecognizedMode checks whether the given mode is one of the valid modes.
 func isRecognizedMode(validModes []string, mode string) bool {
 	for _, m := range validModes {
@@ -98,7 +117,12 @@ func ValidateModes(mode, lint *string, dflag *bool, additionalModes ...string) e
 	return nil
 }
 
-// ValidateWarnings validates the value of the --warnings flag
+// ValidateWarnings validates the value of the --warnings flag. Besides warning categories, the
+// value may contain the names of the warning groups (see warn.WarningGroups), which stand for
+// all of their warnings. With "+" and "-" modifiers the warnings are added to or removed from
+// the default ones, or from all warnings if the value starts with "all", and later modifiers
+// take precedence, e.g. "+performance,-list-append" enables the performance warnings except
+// list-append.
 func ValidateWarnings(warnings *string, allWarnings, defaultWarnings *[]string) ([]string, error) {
 
 	// Check lint warnings
@@ -112,30 +136,205 @@ func ValidateWarnings(warnings *string, allWarnings, defaultWarnings *[]string)
 		// Either all or no warning categories should start with "+" or "-".
 		// If all of them start with "+" or "-", the semantics is
 		// "default set of warnings + something - something".
-		plus := map[string]bool{}
-		minus := map[string]bool{}
+		var modifiers []string
 		for _, warning := range strings.Split(*warnings, ",") {
-			if strings.HasPrefix(warning, "+") {
-				plus[warning[1:]] = true
-			} else if strings.HasPrefix(warning, "-") {
-				minus[warning[1:]] = true
+			if strings.HasPrefix(warning, "+") || strings.HasPrefix(warning, "-") {
+				modifiers = append(modifiers, warning)
 			} else {
 				warningsList = append(warningsList, warning)
 			}
 		}
-		if len(warningsList) > 0 && (len(plus) > 0 || len(minus) > 0) {
+		if len(modifiers) == 0 {
+			return expandWarningGroups(warningsList), nil
+		}
+		base := *defaultWarnings
+		if len(warningsList) == 1 && (warningsList[0] == "all" || warningsList[0] == "default") {
+			if warningsList[0] == "all" {
+				base = *allWarnings
+			}
+		} else if len(warningsList) > 0 {
 			return []string{}, fmt.Errorf("warning categories with modifiers (\"+\" or \"-\") can't be mixed with raw warning categories")
 		}
-		if len(warningsList) == 0 {
-			for _, warning := range *defaultWarnings {
-				if !minus[warning] {
-					warningsList = append(warningsList, warning)
+
+		enabled := make(map[string]bool)
+		listed := make(map[string]bool)
+		for _, warning := range base {
+			enabled[warning] = true
+			listed[warning] = true
+		}
+		var added []string
+		for _, modifier := range modifiers {
+			for _, warning := range expandWarningGroups([]string{modifier[1:]}) {
+				enabled[warning] = modifier[0] == '+'
+				if enabled[warning] && !listed[warning] {
+					listed[warning] = true
+					added = append(added, warning)
 				}
 			}
-			for warning := range plus {
+		}
+		warningsList = nil
+		for _, warning := range append(append([]string{}, base...), added...) {
+			if enabled[warning] {
 				warningsList = append(warningsList, warning)
 			}
 		}
 	}
 	return warningsList, nil
 }
+
+// expandWarningGroups replaces the names of warning groups with their warnings, and the former
+// names of renamed warnings with the warnings replacing them, removing duplicates.
+func expandWarningGroups(names []string) []string {
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		group, ok := warn.WarningGroups[name]
+		if !ok {
+			group, ok = warn.RenamedWarnings[name]
+		}
+		if !ok {
+			group = []string{name}
+		}
+		for _, warning := range group {
+			if !seen[warning] {
+				seen[warning] = true
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	return warnings
+}
+
+// ValidateRewrites validates the value of the --rewrites flag and returns the rewrites to
+// enable for all file types and the rewrites to disable. A plain list of rewrites selects
+// exactly the rewrites to apply, "+foo" applies foo to all file types in addition to the
+// default ones, and "-foo" disables foo.
+func ValidateRewrites(rewrites string, allRewrites []string) (enable, disable []string, err error) {
+	if rewrites == "" {
+		return nil, nil, nil
+	}
+	known := make(map[string]bool)
+	for _, name := range allRewrites {
+		known[name] = true
+	}
+	var plain []string
+	selected := make(map[string]bool)
+	for _, rewrite := range strings.Split(rewrites, ",") {
+		name := strings.TrimLeft(rewrite, "+-")
+		if !known[name] {
+			return nil, nil, fmt.Errorf("unrecognized rewrite %q; valid rewrites are %s", name, strings.Join(allRewrites, ", "))
+		}
+		switch {
+		case strings.HasPrefix(rewrite, "+"):
+			enable = append(enable, name)
+		case strings.HasPrefix(rewrite, "-"):
+			disable = append(disable, name)
+		default:
+			plain = append(plain, name)
+			selected[name] = true
+		}
+	}
+	if len(plain) > 0 && (len(enable) > 0 || len(disable) > 0) {
+		return nil, nil, fmt.Errorf("rewrites with modifiers (\"+\" or \"-\") can't be mixed with raw rewrites")
+	}
+	if len(plain) > 0 {
+		for _, name := range allRewrites {
+			if !selected[name] {
+				disable = append(disable, name)
+			}
+		}
+	}
+	return enable, disable, nil
+}
+
+// ExitCodePolicy maps the outcomes of a run to the exit codes of buildifier. An exit code of 0
+// means the outcome doesn't fail the run. If several outcomes occur, the exit code of the
+// first one in the order of the fields is used.
+type ExitCodePolicy struct {
+	Parse       int // some files have syntax errors
+	MaxWarnings int // there are more lint warnings than allowed by -max_warnings
+	Lint        int // there are lint warnings
+	Reformat    int // some files need reformatting (check and diff modes)
+	Fixed       int // some files were reformatted (fix mode)
+	Tables      int // several -tables or -add_tables files set the same entries to different values
+}
+
+// DefaultExitCodes is the exit code policy used unless -exit_codes overrides it.
+var DefaultExitCodes = ExitCodePolicy{
+	Parse:       1,
+	MaxWarnings: 4,
+	Lint:        4,
+	Reformat:    4,
+	Fixed:       0,
+	Tables:      4,
+}
+
+// ValidateExitCodes validates the value of the --exit_codes flag, a comma-separated list of
+// outcome=code pairs, and returns the default policy with these codes replaced. The reformat
+// code also applies to the files reformatted in the fix mode.
+func ValidateExitCodes(exitCodes string) (ExitCodePolicy, error) {
+	policy := DefaultExitCodes
+	if exitCodes == "" {
+		return policy, nil
+	}
+	for _, pair := range strings.Split(exitCodes, ",") {
+		outcome, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return policy, fmt.Errorf("invalid exit code %q; expected outcome=code", pair)
+		}
+		code, err := strconv.Atoi(value)
+		if err != nil || code < 0 || code > 125 {
+			return policy, fmt.Errorf("invalid exit code %q for %s; expected a number between 0 and 125", value, outcome)
+		}
+		if code == 2 || code == 3 {
+			return policy, fmt.Errorf("exit code %d for %s is reserved for usage and runtime errors", code, outcome)
+		}
+		switch outcome {
+		case "parse":
+			policy.Parse = code
+		case "max_warnings":
+			policy.MaxWarnings = code
+		case "lint":
+			policy.Lint = code
+		case "reformat":
+			policy.Reformat = code
+			policy.Fixed = code
+		case "tables":
+			policy.Tables = code
+		default:
+			return policy, fmt.Errorf("unrecognized outcome %s; valid outcomes are parse, max_warnings, lint, reformat, tables", outcome)
+		}
+	}
+	return policy, nil
+}
+
+// ValidateBlankLines validates the value of the --blank_lines flag, a comma-separated list of
+// context=count pairs, and returns the default blank line policy of the printer with these
+// counts replaced.
+func ValidateBlankLines(blankLines string) (build.BlankLinePolicy, error) {
+	policy := build.DefaultBlankLines
+	if blankLines == "" {
+		return policy, nil
+	}
+	for _, pair := range strings.Split(blankLines, ",") {
+		context, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return policy, fmt.Errorf("invalid blank lines %q; expected context=count", pair)
+		}
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 || count > 2 {
+			return policy, fmt.Errorf("invalid number of blank lines %q for %s; expected a number between 0 and 2", value, context)
+		}
+		switch context {
+		case "rules":
+			policy.BetweenRules = count
+		case "loads":
+			policy.AfterLoads = count
+		case "defs":
+			policy.BetweenDefs = count
+		default:
+			return policy, fmt.Errorf("unrecognized blank lines context %s; valid contexts are rules, loads, defs", context)
+		}
+	}
+	return policy, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package format is a Go API to format and lint Starlark files like the buildifier binary,
+// for tools that embed buildifier (pre-commit hooks, code review servers, etc.) instead of
+// running it as a subprocess.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/buildifier/utils"
+	"github.com/bazelbuild/buildtools/warn"
+	"github.com/bazelbuild/buildtools/wspace"
+)
+
+// Options control how Process formats and lints a file. The zero value formats a file of a
+// type detected from its name without linting it, like `buildifier` without flags.
+type Options struct {
+	// Type is the type of the file, as the -type flag of buildifier: auto (the default if
+	// empty), build, bzl, workspace, module, repo, vendor or default.
+	Type string
+	// Lint is the lint mode, as the -lint flag of buildifier: off (the default if empty),
+	// warn or fix. Missing commas between list items are syntax errors unless
+	// build.AllowMissingCommas is set, as buildifier does in the fix mode.
+	Lint string
+	// Warnings is the list of warnings checked or fixed in the lint modes, the default
+	// warnings are used if it's nil.
+	Warnings []string
+	// WorkspaceRoot is the root of the workspace containing the file, the name of the file
+	// is relative to it. If it's empty, the workspace root is looked up from the name.
+	WorkspaceRoot string
+	// FileReader reads the other files of the workspace used by some warnings. If it's nil,
+	// they are read from the local filesystem if the workspace root is known.
+	FileReader *warn.FileReader
+}
+
+// A Rewrite is a formatting rewrite that changed the file, such as sorting a list.
+type Rewrite struct {
+	// Name is the name of the rewrite, as used by the -buildifier_disable flag.
+	Name string
+	// Description is a human-readable description of the rewrite.
+	Description string
+}
+
+// A Result is the outcome of Process.
+type Result struct {
+	// Output is the formatted (and fixed in the fix lint mode) contents of the file.
+	Output []byte
+	// Changed is true if Output differs from the original contents.
+	Changed bool
+	// Type is the type the file was parsed as.
+	Type build.FileType
+	// Findings are the lint warnings of the file. In the fix mode they are the warnings
+	// which couldn't be fixed automatically.
+	Findings []*warn.Finding
+	// Fixes are the automatically fixable warnings found in the file before fixing them in
+	// the fix lint mode.
+	Fixes []*warn.Finding
+	// Rewrites are the formatting rewrites that changed the file, in the order they were
+	// applied.
+	Rewrites []Rewrite
+}
+
+// Process formats and lints the contents of a file like the buildifier binary would, name is
+// the path of the file used to detect its type and to report the findings. Parse errors are
+// returned as errors, the lint findings are returned in the result.
+func Process(name string, src []byte, opts Options) (Result, error) {
+	inputType := opts.Type
+	if inputType == "" {
+		inputType = "auto"
+	}
+	switch inputType {
+	case "auto", "build", "bzl", "workspace", "module", "repo", "vendor", "default":
+	default:
+		return Result{}, fmt.Errorf("unrecognized input type %q", opts.Type)
+	}
+	switch opts.Lint {
+	case "", "off", "warn", "fix":
+	default:
+		return Result{}, fmt.Errorf("unrecognized lint mode %q", opts.Lint)
+	}
+	warnings := opts.Warnings
+	if warnings == nil {
+		warnings = warn.DefaultWarnings
+	}
+	for _, w := range warnings {
+		if !isWarning(w) {
+			return Result{}, fmt.Errorf("unrecognized warning %q", w)
+		}
+	}
+
+	parser := utils.GetParser(inputType)
+	f, err := parser(name, src)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if opts.WorkspaceRoot != "" {
+		f.WorkspaceRoot = opts.WorkspaceRoot
+		f.Pkg, f.Label = filepath.ToSlash(filepath.Dir(name)), filepath.Base(name)
+		if f.Pkg == "." {
+			f.Pkg = ""
+		}
+	} else if absoluteFilename, err := filepath.Abs(name); err == nil {
+		f.WorkspaceRoot, f.Pkg, f.Label = wspace.SplitFilePath(absoluteFilename)
+	}
+	fileReader := opts.FileReader
+	if fileReader == nil {
+		fileReader = utils.GetFileReader(f.WorkspaceRoot)
+	}
+
+	var findings, fixes []*warn.Finding
+	switch opts.Lint {
+	case "warn":
+		findings = warn.FileWarnings(f, warnings, nil, warn.ModeWarn, fileReader)
+	case "fix":
+		for _, finding := range warn.FileWarnings(f, warnings, nil, warn.ModeWarn, fileReader) {
+			if finding.AutoFixable {
+				fixes = append(fixes, finding)
+			}
+		}
+		findings = warn.FileWarnings(f, warnings, nil, warn.ModeFix, fileReader)
+	}
+
+	steps := build.ExplainFormat(f)
+	result := Result{
+		Output:   steps[len(steps)-1].Output,
+		Type:     f.Type,
+		Findings: findings,
+		Fixes:    fixes,
+	}
+	result.Changed = !bytes.Equal(src, result.Output)
+	for _, step := range steps[1:] {
+		result.Rewrites = append(result.Rewrites, Rewrite{Name: step.Name, Description: step.Description})
+	}
+	return result, nil
+}
+
+// isWarning returns true if the name is the name of a warning.
+func isWarning(name string) bool {
+	for _, w := range warn.AllWarnings {
+		if w == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package format
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/warn"
+)
+
+func categories(findings []*warn.Finding) []string {
+	var result []string
+	for _, f := range findings {
+		result = append(result, f.Category)
+	}
+	return result
+}
+
+func TestProcess(t *testing.T) {
+	src := []byte(`cc_library(name="a", deps=[":c", ":b"])
+`)
+	want := `cc_library(
+    name = "a",
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+`
+	result, err := Process("pkg/BUILD", src, Options{WorkspaceRoot: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result.Output) != want {
+		t.Errorf("Process() output =\n%s\nwant:\n%s", result.Output, want)
+	}
+	if !result.Changed {
+		t.Errorf("Process() changed = false, want true")
+	}
+	if result.Type != build.TypeBuild {
+		t.Errorf("Process() type = %s, want %s", result.Type, build.TypeBuild)
+	}
+	var rewrites []string
+	for _, r := range result.Rewrites {
+		rewrites = append(rewrites, r.Name)
+	}
+	if want := []string{"listsort"}; !reflect.DeepEqual(rewrites, want) {
+		t.Errorf("Process() rewrites = %q, want %q", rewrites, want)
+	}
+	if len(result.Findings) != 0 || len(result.Fixes) != 0 {
+		t.Errorf("Process() without linting returned findings %q and fixes %q", categories(result.Findings), categories(result.Fixes))
+	}
+
+	result, err = Process("pkg/BUILD", []byte(want), Options{WorkspaceRoot: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Changed || len(result.Rewrites) != 0 {
+		t.Errorf("Process() of a formatted file: changed = %t, rewrites = %v, want no changes", result.Changed, result.Rewrites)
+	}
+
+	if _, err := Process("pkg/BUILD", []byte("cc_library(\n"), Options{}); err == nil {
+		t.Errorf("Process() of an invalid file returned no error")
+	}
+	if _, err := Process("pkg/BUILD", src, Options{Lint: "error"}); err == nil {
+		t.Errorf("Process() with an invalid lint mode returned no error")
+	}
+	if _, err := Process("pkg/BUILD", src, Options{Lint: "warn", Warnings: []string{"native"}}); err == nil {
+		t.Errorf("Process() with an invalid warning returned no error")
+	}
+}
+
+func TestProcessLint(t *testing.T) {
+	src := []byte(`load(":a.bzl", "x", "y")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc" "b.cc"],
+)
+
+x("a")
+`)
+	opts := Options{
+		Type:          "build",
+		Lint:          "warn",
+		Warnings:      []string{"load", "missing-comma", "positional-args"},
+		WorkspaceRoot: t.TempDir(),
+	}
+	if _, err := Process("BUILD", src, opts); err == nil {
+		t.Errorf("Process() of a file with a missing comma returned no error")
+	}
+
+	build.AllowMissingCommas = true
+	defer func() { build.AllowMissingCommas = false }()
+	opts.Lint = "fix"
+	result, err := Process("BUILD", src, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `load(":a.bzl", "x")
+
+cc_library(
+    name = "a",
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],
+)
+
+x("a")
+`
+	if string(result.Output) != want {
+		t.Errorf("Process() output =\n%s\nwant:\n%s", result.Output, want)
+	}
+	if got, want := categories(result.Fixes), []string{"load", "missing-comma"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Process() fixes = %q, want %q", got, want)
+	}
+	if got, want := categories(result.Findings), []string{"positional-args"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Process() findings = %q, want %q", got, want)
+	}
+	if result.Fixes[1].File == nil || result.Fixes[1].Start.Line != 5 {
+		t.Errorf("Process() missing comma fix at %v, want line 5", result.Fixes[1].Start)
+	}
+}
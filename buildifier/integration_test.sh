@@ -254,19 +254,26 @@ EOF
 
 cat > golden/.buildifier.example.json <<EOF
 {
+  "version": 1,
   "type": "auto",
   "mode": "fix",
   "lint": "fix",
   "warningsList": [
+    "alias-chain",
     "attr-applicable_licenses",
     "attr-cfg",
+    "attr-doc",
     "attr-license",
     "attr-licenses",
     "attr-non-empty",
     "attr-output-default",
     "attr-single-file",
+    "broad-exports",
     "build-args-kwargs",
     "bzl-visibility",
+    "canonical-load-label",
+    "chained-comparison",
+    "config-setting",
     "confusing-name",
     "constant-glob",
     "ctx-actions",
@@ -276,20 +283,41 @@ cat > golden/.buildifier.example.json <<EOF
     "depset-iteration",
     "depset-union",
     "dict-concatenation",
+    "dict-iteration-order",
     "dict-method-named-arg",
+    "disallowed-statement",
     "duplicated-name",
+    "exported-symbols",
+    "fail-message",
     "filetype",
     "function-docstring",
     "function-docstring-args",
     "function-docstring-header",
     "function-docstring-return",
+    "function-length",
     "git-repository",
+    "glob-context",
+    "glob-patterns",
     "http-archive",
     "integer-division",
     "keyword-positional-params",
+    "label-string-comparison",
+    "legacy-dict-method",
+    "legacy-octal",
+    "line-continuation",
     "list-append",
     "load",
+    "macro-depth",
+    "macro-kwargs-forwarding",
+    "misplaced-attribute",
+    "missing-comma",
+    "missing-native",
+    "missing-source-file",
+    "mixed-type-comparison",
     "module-docstring",
+    "module-extension-tag",
+    "module-order",
+    "module-version",
     "name-conventions",
     "native-android",
     "native-build",
@@ -335,25 +363,40 @@ cat > golden/.buildifier.example.json <<EOF
     "native-sh-binary",
     "native-sh-library",
     "native-sh-test",
+    "negative-repetition",
     "no-effect",
     "output-group",
     "overly-nested-depset",
+    "oversized-build-file",
+    "package-metadata",
     "package-name",
     "package-on-top",
+    "platform-constraints",
     "positional-args",
     "print",
     "provider-params",
     "redefined-variable",
+    "redundant-attr-label",
+    "reexported-load",
     "repository-name",
     "return-value",
+    "rule-doc",
     "rule-impl-return",
+    "select-default",
+    "select-order",
     "skylark-comment",
     "skylark-docstring",
+    "source-root-layout",
+    "stale-keep",
     "string-iteration",
+    "test-suite-membership",
     "uninitialized",
+    "unknown-attribute",
     "unnamed-macro",
     "unreachable",
+    "unresolved-load",
     "unsorted-dict-items",
+    "unused-bazel-dep",
     "unused-variable"
   ]
 }
@@ -432,6 +475,7 @@ EOF
 
 error_bzl="test_dir/to_fix_tmp.bzl:1: bzl-visibility: Module \"//foo/bar/internal/baz:module.bzl\" can only be loaded from files located inside \"//foo/bar\", not from \"//to_fix_tmp.bzl\". (https://github.com/bazelbuild/buildtools/blob/main/WARNINGS.md#bzl-visibility)"
 error_docstring="test_dir/to_fix_tmp.bzl:1: module-docstring: The file has no module docstring."$'\n'"A module docstring is a string literal (not a comment) which should be the first statement of a file (it may follow comment lines). (https://github.com/bazelbuild/buildtools/blob/main/WARNINGS.md#module-docstring)"
+error_load="test_dir/to_fix_tmp.bzl:1: unresolved-load: The loaded file \"//foo/bar/internal/baz:module.bzl\" doesn't exist. (https://github.com/bazelbuild/buildtools/blob/main/WARNINGS.md#unresolved-load)"
 error_integer="test_dir/to_fix_tmp.bzl:4: integer-division: The \"/\" operator for integer division is deprecated in favor of \"//\". (https://github.com/bazelbuild/buildtools/blob/main/WARNINGS.md#integer-division)"
 error_dict="test_dir/to_fix_tmp.bzl:5: unsorted-dict-items: Dictionary items are out of their lexicographical order. (https://github.com/bazelbuild/buildtools/blob/main/WARNINGS.md#unsorted-dict-items)"
 error_cfg="test_dir/to_fix_tmp.bzl:6: attr-cfg: cfg = \"data\" for attr definitions has no effect and should be removed. (https://github.com/bazelbuild/buildtools/blob/main/WARNINGS.md#attr-cfg)"
@@ -480,10 +524,107 @@ EOF
 }
 
 test_lint "default" "" "test_dir/fixed_golden.bzl" "$error_bzl"$'\n'"$error_docstring"$'\n'"$error_integer"$'\n'"$error_cfg" 2
-test_lint "all" "--warnings=all" "test_dir/fixed_golden_all.bzl" "$error_bzl"$'\n'"$error_docstring"$'\n'"$error_integer"$'\n'"$error_dict"$'\n'"$error_cfg" 2
+test_lint "all" "--warnings=all" "test_dir/fixed_golden_all.bzl" "$error_bzl"$'\n'"$error_docstring"$'\n'"$error_load"$'\n'"$error_integer"$'\n'"$error_dict"$'\n'"$error_cfg" 3
 test_lint "cfg" "--warnings=attr-cfg" "test_dir/fixed_golden_cfg.bzl" "$error_cfg" 0
 test_lint "custom" "--warnings=-bzl-visibility,-integer-division,+unsorted-dict-items" "test_dir/fixed_golden_dict_cfg.bzl" "$error_docstring"$'\n'"$error_dict"$'\n'"$error_cfg" 1
 
+# Test --max_warnings and --exit_codes
+
+cp test_dir/to_fix.bzl test_dir/to_fix_tmp.bzl
+ret=0
+$buildifier --mode=check --lint=warn --max_warnings=4 test_dir/to_fix_tmp.bzl 2> /dev/null || ret=$?
+if [[ $ret -ne 0 ]]; then
+  die "max_warnings: Expected buildifier to exit with 0 within the budget, actual: $ret"
+fi
+$buildifier --mode=check --lint=warn --max_warnings=3 --exit_codes=max_warnings=5 test_dir/to_fix_tmp.bzl 2> test_dir/error || ret=$?
+if [[ $ret -ne 5 ]]; then
+  die "max_warnings: Expected buildifier to exit with 5 above the budget, actual: $ret"
+fi
+tail -n 1 test_dir/error | grep -q "buildifier: 4 lint warnings, more than the maximum of 3" || die "max_warnings: missing message about the exceeded budget"
+
+echo "a=b" > test_dir/to_fix_tmp.bzl
+ret=0
+$buildifier --exit_codes=reformat=6 test_dir/to_fix_tmp.bzl || ret=$?
+if [[ $ret -ne 6 ]]; then
+  die "exit_codes: Expected buildifier to exit with 6 after reformatting a file, actual: $ret"
+fi
+rm test_dir/to_fix_tmp.bzl
+
+# Test --py_cleanup
+
+cat > test_dir/BUILD.py_cleanup <<'EOF'
+print "a # b", x  # comment
+print "c"; y = 0755
+print 'd;\'e'
+print "f"; print "g"
+print "h" + str(
+    z,  # comment
+)
+print "i", \
+    y
+print """j
+k"""
+EOF
+
+cat > golden/BUILD.py_cleanup <<'EOF'
+print("a # b", x)  # comment
+
+print("c")
+
+y = 0o755
+
+print("d;'e")
+
+print("f")
+
+print("g")
+
+print("h" + str(
+    z,  # comment
+))
+
+print(
+    "i",
+    y,
+)
+
+print("""j
+k""")
+EOF
+
+$buildifier --py_cleanup test_dir/BUILD.py_cleanup || die "py_cleanup: the Python 2 remnants should be fixed"
+diff -u golden/BUILD.py_cleanup test_dir/BUILD.py_cleanup || die "py_cleanup: wrong fixed file"
+
+# Test the conflicts of several --add_tables files
+
+echo '{"NamePriority": {"name": -1, "srcs": 1}}' > tables_org.json
+echo '{"NamePriority": {"srcs": 2}}' > tables_repo.json
+echo 'cc_library(name = "x")' > test_dir/BUILD.tables
+
+ret=0
+$buildifier --add_tables=tables_org.json,tables_repo.json test_dir/BUILD.tables 2> report || ret=$?
+[[ $ret -eq 4 ]] || die "tables: expected the conflicting tables to exit with 4, actual: $ret"
+grep -q 'conflicting table entry: tables_repo.json: NamePriority\["srcs"\] = 2 overrides 1 from tables_org.json' report || die "tables: the conflicts should be reported"
+$buildifier --add_tables=tables_org.json,tables_repo.json --exit_codes=tables=0 test_dir/BUILD.tables 2> /dev/null || die "tables: the conflicts shouldn't fail the run with tables=0"
+rm tables_org.json tables_repo.json test_dir/BUILD.tables report
+
+# Test --tab_indentation
+
+printf 'def f():\n\treturn 1\n' > test_dir/tabs.bzl
+cp test_dir/tabs.bzl golden/tabs.bzl.orig
+printf 'def f():\n    return 1\n' > golden/tabs.bzl
+
+ret=0
+$buildifier --tab_indentation --mode=check test_dir/tabs.bzl 2> report || ret=$?
+[[ $ret -eq 4 ]] || die "tab_indentation: expected the check mode to exit with 4, actual: $ret"
+grep -q "would convert the tab indentation" report || die "tab_indentation: the check mode should report the files it would convert"
+cmp -s golden/tabs.bzl.orig test_dir/tabs.bzl || die "tab_indentation: the check mode shouldn't modify the file"
+
+$buildifier --tab_indentation test_dir/tabs.bzl 2> report || die "tab_indentation: the file should be converted"
+grep -q "buildifier: converted the tab indentation" report || die "tab_indentation: the converted files should be reported"
+diff -u golden/tabs.bzl test_dir/tabs.bzl || die "tab_indentation: wrong converted file"
+rm test_dir/tabs.bzl report
+
 # Test --format=json
 
 mkdir test_dir/json
@@ -689,3 +830,49 @@ EOF
 
 $buildifier --lint=warn --warnings=deprecated-function BUILD 2> report || ret=$?
 diff -u report_golden report || die "$1: wrong console output for multifile warnings (WORKSPACE exists)"
+
+# Test the output root
+
+mkdir -p output_root_src/pkg
+cat > output_root_src/pkg/BUILD <<EOF
+cc_library(name="a",srcs=["b.cc","a.cc"])
+EOF
+cat > output_root_src/BUILD <<EOF
+cc_library(name = "b")
+EOF
+cat > output_root_golden <<EOF
+cc_library(
+    name = "a",
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],
+)
+EOF
+
+(cd output_root_src && $buildifier --output_root=../output_root pkg/BUILD BUILD) || die "$1: the output root mode failed"
+diff -u output_root_golden output_root/pkg/BUILD || die "$1: wrong formatted file in the output root"
+diff -u output_root_src/BUILD output_root/BUILD || die "$1: the unchanged files should be copied to the output root"
+grep -q 'srcs=\["b.cc"' output_root_src/pkg/BUILD || die "$1: the output root mode should not modify the original files"
+
+# Test the server mode
+
+$buildifier --lint=warn --warnings=deprecated-function --server="$PWD/buildifier.sock" 2> server_log &
+server_pid=$!
+for i in $(seq 50); do
+  [[ -S buildifier.sock ]] && break
+  sleep 0.1
+done
+
+ret=0
+$buildifier client --socket="$PWD/buildifier.sock" BUILD 2> report || ret=$?
+diff -u report_golden report || die "$1: wrong console output for the server mode"
+[[ $ret -eq 4 ]] || die "$1: the client should return the exit code of the server run, got $ret"
+
+ret=0
+echo BUILD | BUILDIFIER_SOCKET="$PWD/buildifier.sock" $buildifier client --files=- 2> report || ret=$?
+diff -u report_golden report || die "$1: wrong console output for the server mode with a file list"
+
+kill -INT $server_pid
+wait $server_pid || die "$1: the server should exit cleanly when interrupted"
+[[ ! -e buildifier.sock ]] || die "$1: the server should remove its socket"
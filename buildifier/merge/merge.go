@@ -0,0 +1,530 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package merge implements a three-way merge of Starlark files that works on their syntax
+// trees instead of their lines: the top-level statements are matched by their identity (the
+// name of a rule, the module of a load, the variable of an assignment, ...), the rules are
+// merged attribute by attribute, and the lists modified on both sides are merged element by
+// element, so that e.g. two dependencies added to the same deps list don't conflict.
+package merge
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// The markers of the conflicts in the merged file, as written by git.
+const (
+	oursMarker   = "<<<<<<< ours"
+	middleMarker = "======="
+	theirsMarker = ">>>>>>> theirs"
+)
+
+// A Result is the outcome of Merge.
+type Result struct {
+	// Output is the formatted merged file. The parts changed differently on both sides are
+	// surrounded with conflict markers.
+	Output []byte
+	// Conflicts is the number of conflicts in Output.
+	Conflicts int
+}
+
+// conflict is a statement or an attribute changed differently on both sides. It's replaced with
+// a placeholder in the merged syntax tree, and with conflict markers once the tree is printed.
+type conflict struct {
+	placeholder  string
+	ours, theirs build.Expr // nil if the side deleted it
+	attr         bool       // true for the attributes of a rule, false for top-level statements
+}
+
+type merger struct {
+	fileType  build.FileType
+	conflicts []*conflict
+}
+
+// Merge merges the changes from base to ours and from base to theirs, and returns the merged
+// file formatted like buildifier would. The statements and attributes changed on only one side
+// are taken from it, the lists changed on both sides are merged (keeping the elements added
+// by either side, dropping the ones removed by either side), and the other changes made on both
+// sides are conflicts.
+func Merge(base, ours, theirs *build.File) *Result {
+	m := &merger{fileType: ours.Type}
+	merged := *ours
+	merged.Stmt = m.mergeStmts(base.Stmt, ours.Stmt, theirs.Stmt)
+	output := build.Format(&merged)
+	return &Result{
+		Output:    m.writeConflicts(output),
+		Conflicts: len(m.conflicts),
+	}
+}
+
+// A keyedStmt is a top-level statement together with the key it's matched by in the other
+// versions of the file.
+type keyedStmt struct {
+	key  string
+	stmt build.Expr
+}
+
+// stmtKey returns the identity of a top-level statement, which is the same in all versions of
+// the file even if the statement has been modified.
+func stmtKey(stmt build.Expr) string {
+	switch stmt := stmt.(type) {
+	case *build.LoadStmt:
+		return "load:" + stmt.Module.Value
+	case *build.CallExpr:
+		if name := (&build.Rule{Call: stmt}).Name(); name != "" {
+			return "rule:" + name
+		}
+		// Calls without a name, e.g. package(), are matched by their function.
+		return "call:" + build.FormatString(stmt.X)
+	case *build.AssignExpr:
+		return "assign:" + build.FormatString(stmt.LHS)
+	case *build.DefStmt:
+		return "def:" + stmt.Name
+	}
+	// Other statements, e.g. comment blocks, are only matched if they're identical.
+	return "stmt:" + text(stmt)
+}
+
+// keyedStmts returns the statements of a file with their keys. The statements with the same
+// key, e.g. several exports_files() calls, are matched in their order.
+func keyedStmts(stmts []build.Expr) []keyedStmt {
+	var result []keyedStmt
+	seen := make(map[string]int)
+	for _, stmt := range stmts {
+		if stmt == nil {
+			continue
+		}
+		key := stmtKey(stmt)
+		if n := seen[key]; n > 0 {
+			seen[key]++
+			key = fmt.Sprintf("%s#%d", key, n)
+		} else {
+			seen[key] = 1
+		}
+		result = append(result, keyedStmt{key, stmt})
+	}
+	return result
+}
+
+// text returns the printed form of an expression, including its comments, used to check
+// whether it's been modified.
+func text(x build.Expr) string {
+	if x == nil {
+		return ""
+	}
+	s := build.FormatString(x)
+	for _, c := range x.Comment().Suffix {
+		s += "  " + c.Token
+	}
+	return s
+}
+
+// mergeStmts merges the top-level statements. The merged statements are in the order of ours,
+// the statements added by theirs are inserted after the statement preceding them in theirs.
+func (m *merger) mergeStmts(base, ours, theirs []build.Expr) []build.Expr {
+	baseStmts := make(map[string]build.Expr)
+	for _, s := range keyedStmts(base) {
+		baseStmts[s.key] = s.stmt
+	}
+	theirsStmts := make(map[string]build.Expr)
+	for _, s := range keyedStmts(theirs) {
+		theirsStmts[s.key] = s.stmt
+	}
+
+	var result []keyedStmt
+	oursKeys := make(map[string]bool)
+	for _, s := range keyedStmts(ours) {
+		oursKeys[s.key] = true
+		b := baseStmts[s.key]
+		t, ok := theirsStmts[s.key]
+		switch {
+		case ok:
+			result = append(result, keyedStmt{s.key, m.mergeStmt(b, s.stmt, t)})
+		case b == nil:
+			// Added by ours.
+			result = append(result, s)
+		case text(b) != text(s.stmt):
+			// Deleted by theirs, modified by ours.
+			result = append(result, keyedStmt{s.key, m.conflict(s.stmt, nil, false)})
+		}
+	}
+
+	previous := ""
+	for _, s := range keyedStmts(theirs) {
+		key := previous
+		previous = s.key
+		if oursKeys[s.key] {
+			continue
+		}
+		stmt := s.stmt
+		if b, ok := baseStmts[s.key]; ok {
+			if text(b) == text(s.stmt) {
+				// Deleted by ours.
+				continue
+			}
+			// Deleted by ours, modified by theirs.
+			stmt = m.conflict(nil, s.stmt, false)
+		}
+		// Insert it after the statement preceding it in theirs, or at the beginning.
+		i := 0
+		for j := range result {
+			if result[j].key == key {
+				i = j + 1
+				break
+			}
+		}
+		result = append(result[:i], append([]keyedStmt{{s.key, stmt}}, result[i:]...)...)
+	}
+
+	stmts := make([]build.Expr, len(result))
+	for i, s := range result {
+		stmts[i] = s.stmt
+	}
+	return stmts
+}
+
+// mergeStrings returns the value changed from b on one side, and false if both sides changed it
+// differently.
+func mergeStrings(b, o, t string) (string, bool) {
+	switch {
+	case o == t || t == b:
+		return o, true
+	case o == b:
+		return t, true
+	}
+	return "", false
+}
+
+// pick returns the version of an expression changed from b on one side, and nil and false if
+// both sides changed it differently.
+func pick(b, o, t build.Expr) (build.Expr, bool) {
+	tb, to, tt := text(b), text(o), text(t)
+	switch {
+	case to == tt || tt == tb:
+		return o, true
+	case to == tb:
+		return t, true
+	}
+	return nil, false
+}
+
+// mergeStmt merges a top-level statement present on both sides, b is nil if both sides added it.
+func (m *merger) mergeStmt(b, o, t build.Expr) build.Expr {
+	if stmt, ok := pick(b, o, t); ok {
+		return stmt
+	}
+	switch o := o.(type) {
+	case *build.CallExpr:
+		if t, ok := t.(*build.CallExpr); ok {
+			bc, _ := b.(*build.CallExpr)
+			if call := m.mergeCall(bc, o, t); call != nil {
+				return call
+			}
+		}
+	case *build.AssignExpr:
+		if t, ok := t.(*build.AssignExpr); ok && o.Op == t.Op && text(o.LHS) == text(t.LHS) {
+			var bv build.Expr
+			if ba, ok := b.(*build.AssignExpr); ok {
+				bv = ba.RHS
+			}
+			if v, ok := mergeValue(bv, o.RHS, t.RHS); ok {
+				assign := *o
+				assign.RHS = v
+				return &assign
+			}
+		}
+	case *build.LoadStmt:
+		if t, ok := t.(*build.LoadStmt); ok {
+			bl, _ := b.(*build.LoadStmt)
+			return mergeLoad(bl, o, t)
+		}
+	}
+	return m.conflict(o, t, false)
+}
+
+// callArgs splits the arguments of a call into the positional ones and the keyword ones.
+func callArgs(call *build.CallExpr) (positional []build.Expr, keywords map[string]*build.AssignExpr, names []string) {
+	keywords = make(map[string]*build.AssignExpr)
+	if call == nil {
+		return nil, keywords, nil
+	}
+	for _, arg := range call.List {
+		if assign, ok := arg.(*build.AssignExpr); ok {
+			if key, ok := assign.LHS.(*build.Ident); ok {
+				keywords[key.Name] = assign
+				names = append(names, key.Name)
+				continue
+			}
+		}
+		positional = append(positional, arg)
+	}
+	return positional, keywords, names
+}
+
+// mergeCall merges a rule or a call changed on both sides attribute by attribute, and returns
+// nil if the conflict can't be limited to attributes, e.g. because both sides renamed the rule.
+func (m *merger) mergeCall(b, o, t *build.CallExpr) *build.CallExpr {
+	var bx build.Expr
+	if b != nil {
+		bx = b.X
+	}
+	x, ok := pick(bx, o.X, t.X)
+	if !ok {
+		return nil
+	}
+
+	bPos, bKw, _ := callArgs(b)
+	oPos, oKw, oNames := callArgs(o)
+	tPos, tKw, tNames := callArgs(t)
+
+	// The positional arguments are merged one by one if there's the same number of them.
+	positional := oPos
+	if len(oPos) != len(tPos) || (b != nil && len(bPos) != len(oPos)) {
+		if _, ok := mergeStrings(argsText(bPos), argsText(oPos), argsText(tPos)); !ok {
+			return nil
+		}
+		if argsText(oPos) == argsText(bPos) {
+			positional = tPos
+		}
+	} else {
+		positional = nil
+		for i := range oPos {
+			var bv build.Expr
+			if bPos != nil {
+				bv = bPos[i]
+			}
+			v, ok := mergeValue(bv, oPos[i], tPos[i])
+			if !ok {
+				return nil
+			}
+			positional = append(positional, v)
+		}
+	}
+
+	names := oNames
+	for _, name := range tNames {
+		if oKw[name] == nil {
+			names = append(names, name)
+		}
+	}
+	call := *o
+	call.X = x
+	call.List = positional
+	for _, name := range names {
+		bAttr, oAttr, tAttr := bKw[name], oKw[name], tKw[name]
+		var attr build.Expr
+		if a, ok := pick(nilIfNone(bAttr), nilIfNone(oAttr), nilIfNone(tAttr)); ok {
+			if a == nil {
+				// Deleted.
+				continue
+			}
+			attr = a
+		} else if oAttr != nil && tAttr != nil {
+			var bv build.Expr
+			if bAttr != nil {
+				bv = bAttr.RHS
+			}
+			if v, ok := mergeValue(bv, oAttr.RHS, tAttr.RHS); ok {
+				merged := *oAttr
+				merged.RHS = v
+				attr = &merged
+			}
+		}
+		if attr == nil {
+			placeholder := m.conflict(nilIfNone(oAttr), nilIfNone(tAttr), true)
+			attr = &build.AssignExpr{LHS: &build.Ident{Name: name}, Op: "=", RHS: placeholder}
+			call.ForceMultiLine = true
+			call.ForceCompact = false
+		}
+		call.List = append(call.List, attr)
+	}
+	return &call
+}
+
+// nilIfNone converts a nil attribute to a nil interface.
+func nilIfNone(attr *build.AssignExpr) build.Expr {
+	if attr == nil {
+		return nil
+	}
+	return attr
+}
+
+// argsText returns the printed form of a list of arguments.
+func argsText(args []build.Expr) string {
+	var texts []string
+	for _, arg := range args {
+		texts = append(texts, text(arg))
+	}
+	return strings.Join(texts, ", ")
+}
+
+// mergeValue merges the values of an attribute or a variable, b is nil if both sides added it.
+// Only lists changed on both sides can be merged, their elements are merged as sets.
+func mergeValue(b, o, t build.Expr) (build.Expr, bool) {
+	if v, ok := pick(b, o, t); ok {
+		return v, true
+	}
+	ol, ok1 := o.(*build.ListExpr)
+	tl, ok2 := t.(*build.ListExpr)
+	bl, ok3 := b.(*build.ListExpr)
+	if !ok1 || !ok2 || (b != nil && !ok3) {
+		return nil, false
+	}
+	var bList []build.Expr
+	if bl != nil {
+		bList = bl.List
+	}
+	list := *ol
+	list.List = mergeElements(bList, ol.List, tl.List, elementKey)
+	return &list, true
+}
+
+// elementKey returns the identity of a list element, regardless of its comments.
+func elementKey(x build.Expr) string {
+	if str, ok := x.(*build.StringExpr); ok {
+		return "str:" + str.Value
+	}
+	return build.FormatString(x)
+}
+
+// mergeElements merges the elements of a list: the ones of ours which theirs didn't remove, then
+// the ones added by theirs.
+func mergeElements[T any](b, o, t []T, key func(T) string) []T {
+	keys := func(list []T) map[string]bool {
+		m := make(map[string]bool)
+		for _, x := range list {
+			m[key(x)] = true
+		}
+		return m
+	}
+	inBase, inOurs, inTheirs := keys(b), keys(o), keys(t)
+	var result []T
+	seen := make(map[string]bool)
+	for _, x := range o {
+		k := key(x)
+		if seen[k] || (inBase[k] && !inTheirs[k]) {
+			continue
+		}
+		seen[k] = true
+		result = append(result, x)
+	}
+	for _, x := range t {
+		k := key(x)
+		if seen[k] || inBase[k] || inOurs[k] {
+			continue
+		}
+		seen[k] = true
+		result = append(result, x)
+	}
+	return result
+}
+
+// mergeLoad merges the symbols of a load statement changed on both sides.
+func mergeLoad(b, o, t *build.LoadStmt) *build.LoadStmt {
+	type symbol struct{ from, to *build.Ident }
+	symbols := func(load *build.LoadStmt) []symbol {
+		if load == nil {
+			return nil
+		}
+		var result []symbol
+		for i := range load.From {
+			result = append(result, symbol{load.From[i], load.To[i]})
+		}
+		return result
+	}
+	merged := mergeElements(symbols(b), symbols(o), symbols(t), func(s symbol) string {
+		return s.to.Name + "=" + s.from.Name
+	})
+	load := *o
+	load.From, load.To = nil, nil
+	for _, s := range merged {
+		load.From = append(load.From, s.from)
+		load.To = append(load.To, s.to)
+	}
+	return &load
+}
+
+// conflict records a conflict and returns the placeholder that marks its location in the merged
+// syntax tree.
+func (m *merger) conflict(o, t build.Expr, attr bool) build.Expr {
+	c := &conflict{
+		placeholder: fmt.Sprintf("__buildifier_merge_conflict_%d__", len(m.conflicts)),
+		ours:        o,
+		theirs:      t,
+		attr:        attr,
+	}
+	m.conflicts = append(m.conflicts, c)
+	return &build.Ident{Name: c.placeholder}
+}
+
+// side returns the printed lines of a side of a conflict.
+func (m *merger) side(c *conflict, x build.Expr, indent string) string {
+	if x == nil {
+		return ""
+	}
+	var s string
+	if c.attr {
+		s = build.FormatString(x) + ","
+		for _, com := range x.Comment().Suffix {
+			s += "  " + com.Token
+		}
+	} else {
+		s = strings.TrimSuffix(string(build.Format(&build.File{Type: m.fileType, Stmt: []build.Expr{x}})), "\n")
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		if line != "" {
+			b.WriteString(indent)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// writeConflicts replaces the lines of the placeholders of the conflicts with the conflicting
+// versions of the statements or attributes surrounded with conflict markers.
+func (m *merger) writeConflicts(output []byte) []byte {
+	if len(m.conflicts) == 0 {
+		return output
+	}
+	lines := bytes.SplitAfter(output, []byte("\n"))
+	var b bytes.Buffer
+	for _, line := range lines {
+		var c *conflict
+		for _, candidate := range m.conflicts {
+			if bytes.Contains(line, []byte(candidate.placeholder)) {
+				c = candidate
+				break
+			}
+		}
+		if c == nil {
+			b.Write(line)
+			continue
+		}
+		indent := string(line[:len(line)-len(bytes.TrimLeft(line, " "))])
+		b.WriteString(oursMarker + "\n")
+		b.WriteString(m.side(c, c.ours, indent))
+		b.WriteString(middleMarker + "\n")
+		b.WriteString(m.side(c, c.theirs, indent))
+		b.WriteString(theirsMarker + "\n")
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,359 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package merge
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+const mergeBase = `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+`
+
+func TestMerge(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		ours, theirs  string
+		want          string
+		wantConflicts int
+	}{
+		{
+			name: "disjoint list additions",
+			ours: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+        ":d",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+`,
+			theirs: `load("//tools:defs.bzl", "my_library", "my_test")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":c",
+        ":e",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+`,
+			want: `load("//tools:defs.bzl", "my_library", "my_test")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":c",
+        ":d",
+        ":e",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+`,
+		},
+		{
+			name: "different attributes and rules",
+			ours: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    copts = ["-O2"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+
+cc_library(
+    name = "d",
+    srcs = ["d.cc"],
+)
+`,
+			theirs: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    linkstatic = True,
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "e",
+    srcs = ["e.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+`,
+			want: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    copts = ["-O2"],
+    linkstatic = True,
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "e",
+    srcs = ["e.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+
+cc_library(
+    name = "d",
+    srcs = ["d.cc"],
+)
+`,
+		},
+		{
+			name: "attribute conflict",
+			ours: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+    linkstatic = True,
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+)
+`,
+			theirs: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+    linkstatic = False,
+)
+
+cc_library(
+    name = "c",
+    srcs = glob(["*.cc"]),
+)
+`,
+			want: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+<<<<<<< ours
+    linkstatic = True,
+=======
+    linkstatic = False,
+>>>>>>> theirs
+)
+
+cc_library(
+    name = "c",
+    srcs = glob(["*.cc"]),
+)
+`,
+			wantConflicts: 1,
+		},
+		{
+			name: "deleted and modified rule",
+			ours: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+`,
+			theirs: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+cc_library(
+    name = "c",
+    srcs = ["c2.cc"],
+)
+`,
+			want: `load("//tools:defs.bzl", "my_library")
+
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [
+        ":b",
+        ":c",
+    ],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b.cc"],
+)
+
+<<<<<<< ours
+=======
+cc_library(
+    name = "c",
+    srcs = ["c2.cc"],
+)
+>>>>>>> theirs
+`,
+			wantConflicts: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			parse := func(name, src string) *build.File {
+				f, err := build.ParseBuild(name, []byte(src))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return f
+			}
+			result := Merge(parse("base/BUILD", mergeBase), parse("ours/BUILD", tc.ours), parse("theirs/BUILD", tc.theirs))
+			if got := string(result.Output); got != tc.want {
+				t.Errorf("Merge() =\n%s\nwant:\n%s", got, tc.want)
+			}
+			if result.Conflicts != tc.wantConflicts {
+				t.Errorf("Merge() conflicts = %d, want %d", result.Conflicts, tc.wantConflicts)
+			}
+		})
+	}
+}
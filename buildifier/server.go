@@ -0,0 +1,237 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The server mode of buildifier: a long-lived process listening on a unix socket that
+// processes the files sent by `buildifier client`, so that repeated invocations, e.g. by
+// pre-commit hooks, don't pay the process startup and keep the parsed .bzl files warm.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/bazelbuild/buildtools/buildifier/config"
+	"github.com/bazelbuild/buildtools/buildifier/utils"
+	"github.com/bazelbuild/buildtools/differ"
+	"github.com/bazelbuild/buildtools/warn"
+)
+
+// serverRequest is sent by the client, the files are relative to Dir, the working directory of
+// the client.
+type serverRequest struct {
+	Dir   string   `json:"dir"`
+	Files []string `json:"files"`
+}
+
+// serverResponse is the output and the exit code of the run of the server for a request.
+type serverResponse struct {
+	Stdout   []byte `json:"stdout"`
+	Stderr   []byte `json:"stderr"`
+	ExitCode int    `json:"exitCode"`
+}
+
+// serve listens on the unix socket given by -server and processes the requests of the clients
+// one at a time with the configuration of the server, until it's interrupted. It returns the
+// exit code.
+func serve(c *config.Config, d *differ.Differ) int {
+	if conn, err := net.Dial("unix", c.Server); err == nil {
+		conn.Close()
+		fmt.Fprintf(os.Stderr, "buildifier: a server is already listening on %s\n", c.Server)
+		return 2
+	}
+	// Remove the socket left by a server that didn't exit cleanly.
+	os.Remove(c.Server)
+	listener, err := net.Listen("unix", c.Server)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+		return 3
+	}
+	// Closing the listener removes the socket.
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close()
+	}()
+
+	utils.SharedFileCache = warn.NewFileCache()
+	if c.Verbose {
+		fmt.Fprintf(os.Stderr, "buildifier: listening on %s\n", c.Server)
+	}
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			return 3
+		}
+		handleRequest(c, d, conn)
+	}
+}
+
+// handleRequest reads a request from a client connection and writes back the response.
+func handleRequest(c *config.Config, d *differ.Differ, conn net.Conn) {
+	defer conn.Close()
+	var req serverRequest
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		// Connections closed without a request check whether the server is running.
+		if !errors.Is(err, io.EOF) {
+			fmt.Fprintf(os.Stderr, "buildifier: reading request: %v\n", err)
+		}
+		return
+	}
+	resp, err := processRequest(c, d, req)
+	if err != nil {
+		resp = serverResponse{Stderr: []byte(fmt.Sprintf("buildifier: %v\n", err)), ExitCode: 3}
+	}
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: writing response: %v\n", err)
+	}
+}
+
+// processRequest runs buildifier on the files of a request from the working directory of the
+// client, capturing the standard output and error of the run.
+func processRequest(c *config.Config, d *differ.Differ, req serverRequest) (serverResponse, error) {
+	if len(req.Files) == 0 {
+		// The server doesn't read its standard input.
+		return serverResponse{}, nil
+	}
+	stdout, err := os.CreateTemp("", "buildifier-stdout-")
+	if err != nil {
+		return serverResponse{}, err
+	}
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+	stderr, err := os.CreateTemp("", "buildifier-stderr-")
+	if err != nil {
+		return serverResponse{}, err
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return serverResponse{}, err
+	}
+	if err := os.Chdir(req.Dir); err != nil {
+		return serverResponse{}, err
+	}
+	defer os.Chdir(wd)
+
+	// The run may change its configuration and accumulates the arguments of the diff command.
+	runConfig := *c
+	runDiffer := *d
+	runDiffer.Args = nil
+	b := buildifier{config: &runConfig, differ: &runDiffer}
+
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	exitCode := b.run(req.Files)
+	os.Stdout, os.Stderr = origStdout, origStderr
+
+	resp := serverResponse{ExitCode: exitCode}
+	if resp.Stdout, err = os.ReadFile(stdout.Name()); err != nil {
+		return serverResponse{}, err
+	}
+	if resp.Stderr, err = os.ReadFile(stderr.Name()); err != nil {
+		return serverResponse{}, err
+	}
+	return resp, nil
+}
+
+// readFileList returns the non-empty lines of a file, or of the standard input for "-".
+func readFileList(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, scanner.Err()
+}
+
+// runClient sends the files given in args to a buildifier server and prints its output. It
+// returns the exit code of the server run.
+func runClient(args []string) int {
+	flags := flag.NewFlagSet("buildifier client", flag.ContinueOnError)
+	socket := flags.String("socket", os.Getenv("BUILDIFIER_SOCKET"), "path of the unix socket of the server started with -server (default $BUILDIFIER_SOCKET)")
+	fileList := flags.String("files", "", "file listing the files to process, one per line, or - for the standard input, in addition to the files given as arguments")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	files := flags.Args()
+	if *fileList != "" {
+		listed, err := readFileList(*fileList)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			return 2
+		}
+		files = append(files, listed...)
+	}
+	if *socket == "" {
+		fmt.Fprintf(os.Stderr, "buildifier: no server socket, use -socket or BUILDIFIER_SOCKET to specify it\n")
+		return 2
+	}
+	if len(files) == 0 {
+		return 0
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+		return 3
+	}
+	conn, err := net.Dial("unix", *socket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: connecting to the server: %v\n", err)
+		return 3
+	}
+	defer conn.Close()
+	if err := json.NewEncoder(conn).Encode(serverRequest{Dir: dir, Files: files}); err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: sending the request: %v\n", err)
+		return 3
+	}
+	var resp serverResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		fmt.Fprintf(os.Stderr, "buildifier: reading the response: %v\n", err)
+		return 3
+	}
+	os.Stdout.Write(resp.Stdout)
+	os.Stderr.Write(resp.Stderr)
+	return resp.ExitCode
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/warn"
+)
+
+// ChangedLines maps the paths of the files of a unified diff (with forward slashes, as written
+// in the diff) to the line numbers added or modified in their new version.
+type ChangedLines map[string]map[int]bool
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// hunkLength parses the optional line count of a hunk range, it defaults to 1.
+func hunkLength(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// ParseUnifiedDiff reads a unified diff (e.g. the output of `git diff`) and returns the lines
+// it adds or modifies. Removed lines don't have a counterpart in the new file and are ignored.
+func ParseUnifiedDiff(r io.Reader) (ChangedLines, error) {
+	changed := make(ChangedLines)
+	var lines map[int]bool
+	// The position in the new file and the number of lines of the current hunk still to be read.
+	line, oldLeft, newLeft := 0, 0, 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(text, "+"):
+				if lines != nil {
+					lines[line] = true
+				}
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, `\`):
+				// "\ No newline at end of file"
+			default:
+				line++
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			path := strings.TrimPrefix(text, "+++ ")
+			if i := strings.IndexByte(path, '\t'); i >= 0 {
+				// Some diff tools append a timestamp to the file name.
+				path = path[:i]
+			}
+			if path == "/dev/null" {
+				// The file is deleted.
+				lines = nil
+				continue
+			}
+			path = strings.TrimPrefix(path, "b/")
+			lines = make(map[int]bool)
+			changed[path] = lines
+		case strings.HasPrefix(text, "@@ "):
+			m := hunkHeader.FindStringSubmatch(text)
+			if m == nil {
+				return nil, fmt.Errorf("invalid hunk header %q", text)
+			}
+			line, _ = strconv.Atoi(m[2])
+			oldLeft, newLeft = hunkLength(m[1]), hunkLength(m[3])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// GitChangedLines returns the lines added or modified in the working tree relative to the
+// given git revision. Only the files tracked by git are included.
+func GitChangedLines(base string) (ChangedLines, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "diff", "--unified=0", "--no-color", "--no-ext-diff", base, "--")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git diff %s: %v: %s", base, err, strings.TrimSpace(stderr.String()))
+	}
+	return ParseUnifiedDiff(&stdout)
+}
+
+// lookup returns the changed lines of a file. The paths in a diff are usually relative to the
+// repository root, so a path matches if it's a suffix of the file name at a directory boundary.
+func (c ChangedLines) lookup(filename string) map[int]bool {
+	filename = filepath.ToSlash(filepath.Clean(filename))
+	if lines, ok := c[filename]; ok {
+		return lines
+	}
+	var found map[int]bool
+	longest := 0
+	for path, lines := range c {
+		if len(path) > longest && strings.HasSuffix(filename, "/"+path) {
+			found, longest = lines, len(path)
+		}
+	}
+	return found
+}
+
+// Filter returns the findings of a file that overlap with its changed lines. Files not
+// mentioned by the diff have no changed lines.
+func (c ChangedLines) Filter(filename string, findings []*warn.Finding) []*warn.Finding {
+	lines := c.lookup(filename)
+	var result []*warn.Finding
+	for _, f := range findings {
+		end := f.End.Line
+		if end < f.Start.Line {
+			end = f.Start.Line
+		}
+		for l := f.Start.Line; l <= end; l++ {
+			if lines[l] {
+				result = append(result, f)
+				break
+			}
+		}
+	}
+	return result
+}
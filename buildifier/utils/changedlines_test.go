@@ -0,0 +1,88 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/warn"
+)
+
+const testDiff = `diff --git a/foo/BUILD b/foo/BUILD
+index 1111111..2222222 100644
+--- a/foo/BUILD
++++ b/foo/BUILD
+@@ -2,4 +2,4 @@ cc_library(
+     name = "a",
+-    srcs = ["a.cc"],
++    srcs = ["b.cc"],
++    deps = [":c"],
+--- x
+ )
+@@ -20,0 +22 @@ cc_library(
++load(":defs.bzl", "foo")
+diff --git a/bar/defs.bzl b/bar/defs.bzl
+deleted file mode 100644
+--- a/bar/defs.bzl
++++ /dev/null
+@@ -1 +0,0 @@
+-x = 1
+`
+
+func TestParseUnifiedDiff(t *testing.T) {
+	changed, err := ParseUnifiedDiff(strings.NewReader(testDiff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ChangedLines{
+		"foo/BUILD": {3: true, 4: true, 22: true},
+	}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("ParseUnifiedDiff() = %v, want %v", changed, want)
+	}
+
+	if _, err := ParseUnifiedDiff(strings.NewReader("+++ b/BUILD\n@@ invalid @@\n")); err == nil {
+		t.Error("ParseUnifiedDiff() with an invalid hunk header succeeded, want an error")
+	}
+}
+
+func TestChangedLinesFilter(t *testing.T) {
+	changed := ChangedLines{
+		"foo/BUILD": {3: true, 4: true},
+	}
+	finding := func(start, end int) *warn.Finding {
+		return &warn.Finding{Start: build.Position{Line: start}, End: build.Position{Line: end}}
+	}
+	findings := []*warn.Finding{finding(1, 1), finding(2, 3), finding(4, 0), finding(5, 6)}
+
+	for _, tc := range []struct {
+		filename string
+		want     []*warn.Finding
+	}{
+		{"/home/user/repo/foo/BUILD", findings[1:3]},
+		{"foo/BUILD", findings[1:3]},
+		{"/home/user/repo/barfoo/BUILD", nil},
+		{"/home/user/repo/bar/BUILD", nil},
+	} {
+		if got := changed.Filter(tc.filename, findings); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Filter(%q) = %v, want %v", tc.filename, got, tc.want)
+		}
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/warn"
+	"path"
+	"sort"
 	"strings"
 )
 
@@ -41,6 +43,9 @@ func (d *Diagnostics) Format(format string, verbose bool) string {
 				if !w.Actionable {
 					formatString = "%s:%d: %s: %s [%s]\n"
 				}
+				if w.Generated {
+					formatString = strings.TrimSuffix(formatString, "\n") + " [generated]\n"
+				}
 				output.WriteString(fmt.Sprintf(formatString,
 					f.Filename,
 					w.Start.Line,
@@ -65,6 +70,116 @@ func (d *Diagnostics) Format(format string, verbose bool) string {
 	return ""
 }
 
+// summaryTopFiles is the number of files with the most findings listed by a Summary.
+const summaryTopFiles = 10
+
+// Summary contains the findings of Diagnostics aggregated per warning category and per top-level
+// directory, for reports that track the number of findings rather than each of them.
+type Summary struct {
+	Success     bool            `json:"success"`     // same as Diagnostics.Success
+	Files       int             `json:"files"`       // number of files processed
+	Findings    int             `json:"findings"`    // total number of lint findings
+	Reformat    int             `json:"reformat"`    // number of files that need reformatting
+	Categories  []*SummaryCount `json:"categories"`  // findings per warning category
+	Directories []*SummaryCount `json:"directories"` // findings per top-level directory
+	TopFiles    []*SummaryCount `json:"topFiles"`    // files with the most findings
+}
+
+// SummaryCount is the number of findings of a warning category, a directory or a file.
+type SummaryCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// topLevelDirectory returns the first directory of a file path, or "." if the file isn't in
+// a directory.
+func topLevelDirectory(filename string) string {
+	dir, _, ok := strings.Cut(strings.TrimPrefix(path.Clean(filename), "/"), "/")
+	if !ok {
+		return "."
+	}
+	return dir
+}
+
+// sortedCounts returns the counts sorted by decreasing count, then by name.
+func sortedCounts(counts map[string]int) []*SummaryCount {
+	result := []*SummaryCount{}
+	for name, count := range counts {
+		result = append(result, &SummaryCount{Name: name, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Summarize aggregates the findings per warning category and per top-level directory.
+func (d *Diagnostics) Summarize() *Summary {
+	s := &Summary{Success: d.Success, Files: len(d.Files)}
+	categories := make(map[string]int)
+	directories := make(map[string]int)
+	files := make(map[string]int)
+	for _, f := range d.Files {
+		if !f.Formatted {
+			s.Reformat++
+		}
+		if len(f.Warnings) == 0 {
+			continue
+		}
+		s.Findings += len(f.Warnings)
+		for _, w := range f.Warnings {
+			categories[w.Category]++
+		}
+		directories[topLevelDirectory(f.Filename)] += len(f.Warnings)
+		files[f.Filename] += len(f.Warnings)
+	}
+	s.Categories = sortedCounts(categories)
+	s.Directories = sortedCounts(directories)
+	s.TopFiles = sortedCounts(files)
+	if len(s.TopFiles) > summaryTopFiles {
+		s.TopFiles = s.TopFiles[:summaryTopFiles]
+	}
+	return s
+}
+
+// Format formats a Summary object either as plain text or as json
+func (s *Summary) Format(format string, verbose bool) string {
+	switch format {
+	case "text", "":
+		var output strings.Builder
+		output.WriteString(fmt.Sprintf("%d findings in %d files, %d files need reformatting\n", s.Findings, s.Files, s.Reformat))
+		for _, section := range []struct {
+			title  string
+			counts []*SummaryCount
+		}{
+			{"By category", s.Categories},
+			{"By directory", s.Directories},
+			{"Top files", s.TopFiles},
+		} {
+			if len(section.counts) == 0 {
+				continue
+			}
+			output.WriteString(fmt.Sprintf("\n%s:\n", section.title))
+			for _, c := range section.counts {
+				output.WriteString(fmt.Sprintf("%7d  %s\n", c.Count, c.Name))
+			}
+		}
+		return output.String()
+	case "json":
+		var result []byte
+		if verbose {
+			result, _ = json.MarshalIndent(*s, "", "    ")
+		} else {
+			result, _ = json.Marshal(*s)
+		}
+		return string(result) + "\n"
+	}
+	return ""
+}
+
 // FileDiagnostics contains diagnostics information for a file
 type FileDiagnostics struct {
 	Filename  string     `json:"filename"`
@@ -81,6 +196,10 @@ type warning struct {
 	AutoFixable bool     `json:"autoFixable"`
 	Message     string   `json:"message"`
 	URL         string   `json:"url"`
+	Generated   bool     `json:"generated,omitempty"`
+	// Metrics are the measurements of the metrics warnings, e.g. the number of targets of an
+	// oversized BUILD file.
+	Metrics map[string]int `json:"metrics,omitempty"`
 }
 
 type position struct {
@@ -121,6 +240,8 @@ func NewFileDiagnostics(filename string, warnings []*warn.Finding) *FileDiagnost
 			AutoFixable: w.AutoFixable,
 			Message:     w.Message,
 			URL:         w.URL,
+			Generated:   w.Generated,
+			Metrics:     w.Metrics,
 		})
 	}
 
@@ -0,0 +1,119 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/warn"
+)
+
+func findings(categories ...string) []*warn.Finding {
+	var result []*warn.Finding
+	for _, category := range categories {
+		result = append(result, &warn.Finding{Category: category, Message: "message"})
+	}
+	return result
+}
+
+func TestFormatGenerated(t *testing.T) {
+	generated := findings("load", "load")
+	generated[0].Actionable = true
+	generated[1].Start = build.Position{Line: 3}
+	generated[1].Generated = true
+	d := NewDiagnostics(NewFileDiagnostics("BUILD", generated))
+
+	want := `BUILD:0: load: message ()
+BUILD:3: load: message [] [generated]
+`
+	if got := d.Format("text", false); got != want {
+		t.Errorf("Format(text) =\n%s\nwant:\n%s", got, want)
+	}
+	if got := WithoutGenerated(generated); len(got) != 1 || got[0] != generated[0] {
+		t.Errorf("WithoutGenerated() = %v, want %v", got, generated[:1])
+	}
+}
+
+func TestFormatMetrics(t *testing.T) {
+	metrics := findings("oversized-build-file")
+	metrics[0].Metrics = map[string]int{"targets": 612, "bytes": 40960}
+	d := NewDiagnostics(NewFileDiagnostics("BUILD", metrics))
+
+	want := `"metrics":{"bytes":40960,"targets":612}`
+	if got := d.Format("json", false); !strings.Contains(got, want) {
+		t.Errorf("Format(json) =\n%s\nwant it to contain %s", got, want)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	unformatted := NewFileDiagnostics("foo/bar/BUILD", nil)
+	unformatted.Formatted = false
+	d := NewDiagnostics(
+		NewFileDiagnostics("foo/BUILD", findings("load", "unsorted-dict-items", "load")),
+		NewFileDiagnostics("foo/baz/defs.bzl", findings("function-docstring")),
+		NewFileDiagnostics("bar/BUILD", findings("load", "load", "load")),
+		NewFileDiagnostics("BUILD", findings("native-cc")),
+		unformatted,
+	)
+
+	want := `8 findings in 5 files, 1 files need reformatting
+
+By category:
+      5  load
+      1  function-docstring
+      1  native-cc
+      1  unsorted-dict-items
+
+By directory:
+      4  foo
+      3  bar
+      1  .
+
+Top files:
+      3  bar/BUILD
+      3  foo/BUILD
+      1  BUILD
+      1  foo/baz/defs.bzl
+`
+	if got := d.Summarize().Format("text", false); got != want {
+		t.Errorf("Summarize().Format(text) =\n%s\nwant:\n%s", got, want)
+	}
+
+	wantJSON := `{"success":false,"files":5,"findings":8,"reformat":1,` +
+		`"categories":[{"name":"load","count":5},{"name":"function-docstring","count":1},{"name":"native-cc","count":1},{"name":"unsorted-dict-items","count":1}],` +
+		`"directories":[{"name":"foo","count":4},{"name":"bar","count":3},{"name":".","count":1}],` +
+		`"topFiles":[{"name":"bar/BUILD","count":3},{"name":"foo/BUILD","count":3},{"name":"BUILD","count":1},{"name":"foo/baz/defs.bzl","count":1}]}` + "\n"
+	if got := d.Summarize().Format("json", false); got != wantJSON {
+		t.Errorf("Summarize().Format(json) =\n%s\nwant:\n%s", got, wantJSON)
+	}
+}
+
+func TestSummarizeTopFiles(t *testing.T) {
+	var files []*FileDiagnostics
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		files = append(files, NewFileDiagnostics(name+"/BUILD", findings("load")))
+	}
+	s := NewDiagnostics(files...).Summarize()
+	if len(s.TopFiles) != summaryTopFiles {
+		t.Errorf("Summarize() returned %d top files, want %d", len(s.TopFiles), summaryTopFiles)
+	}
+	if len(s.Directories) != 12 {
+		t.Errorf("Summarize() returned %d directories, want 12", len(s.Directories))
+	}
+}
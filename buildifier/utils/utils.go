@@ -37,19 +37,24 @@ func isStarlarkFile(name string) bool {
 	switch ext {
 	case ".bazel", ".oss":
 		// BUILD.bazel or BUILD.foo.bazel should be treated as Starlark files, same for WORSKSPACE and MODULE
-		return strings.HasPrefix(name, "BUILD.") || strings.HasPrefix(name, "WORKSPACE.") || strings.HasPrefix(name, "MODULE.")
+		return strings.HasPrefix(name, "BUILD.") || strings.HasPrefix(name, "WORKSPACE.") || strings.HasPrefix(name, "MODULE.") ||
+			name == "REPO.bazel" || name == "VENDOR.bazel"
 	}
 
 	return name == "BUILD" || name == "WORKSPACE"
 }
 
-func skip(info os.FileInfo) bool {
-	return info.IsDir() && info.Name() == ".git"
-}
-
 // ExpandDirectories takes a list of file/directory names and returns a list with file names
 // by traversing each directory recursively and searching for relevant Starlark files.
+// Paths listed in .buildifierignore files are skipped.
 func ExpandDirectories(args *[]string) ([]string, error) {
+	return ExpandDirectoriesWithExclusions(args, nil)
+}
+
+// ExpandDirectoriesWithExclusions is the same as ExpandDirectories but additionally skips
+// paths matching any of the exclude patterns (in .gitignore syntax, relative to each directory
+// argument). Directories are traversed concurrently.
+func ExpandDirectoriesWithExclusions(args *[]string, exclude []string) ([]string, error) {
 	files := []string{}
 	for _, arg := range *args {
 		info, err := os.Stat(arg)
@@ -60,21 +65,17 @@ func ExpandDirectories(args *[]string) ([]string, error) {
 			files = append(files, arg)
 			continue
 		}
-		err = filepath.Walk(arg, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
+		var patterns []*ignorePattern
+		for _, line := range exclude {
+			if p := parseIgnorePattern(arg, line); p != nil {
+				patterns = append(patterns, p)
 			}
-			if skip(info) {
-				return filepath.SkipDir
-			}
-			if !info.IsDir() && isStarlarkFile(info.Name()) {
-				files = append(files, path)
-			}
-			return nil
-		})
+		}
+		found, err := newWalker().walk(arg, patterns)
 		if err != nil {
 			return []string{}, err
 		}
+		files = append(files, found...)
 	}
 	return files, nil
 }
@@ -92,14 +93,22 @@ func GetParser(inputType string) func(filename string, data []byte) (*build.File
 		return build.ParseWorkspace
 	case "module":
 		return build.ParseModule
+	case "repo":
+		return build.ParseRepo
+	case "vendor":
+		return build.ParseVendor
 	default:
 		return build.ParseDefault
 	}
 }
 
-// getFileReader returns a *FileReader object that reads files from the local
+// SharedFileCache keeps the files parsed by the FileReaders of GetFileReader across runs, it's
+// only set when buildifier runs as a server.
+var SharedFileCache *warn.FileCache
+
+// GetFileReader returns a *FileReader object that reads files from the local
 // filesystem if the workspace root is known.
-func getFileReader(workspaceRoot string) *warn.FileReader {
+func GetFileReader(workspaceRoot string) *warn.FileReader {
 	if workspaceRoot == "" {
 		return nil
 	}
@@ -112,12 +121,15 @@ func getFileReader(workspaceRoot string) *warn.FileReader {
 		return os.ReadFile(path)
 	}
 
-	return warn.NewFileReader(readFile)
+	if SharedFileCache != nil {
+		return warn.NewFileReaderWithCache(readFile, os.DirFS(workspaceRoot), SharedFileCache, workspaceRoot)
+	}
+	return warn.NewFileReaderWithFS(readFile, os.DirFS(workspaceRoot))
 }
 
 // Lint calls the linter and returns a list of unresolved findings
 func Lint(f *build.File, lint string, warningsList *[]string, verbose bool) []*warn.Finding {
-	fileReader := getFileReader(f.WorkspaceRoot)
+	fileReader := GetFileReader(f.WorkspaceRoot)
 
 	switch lint {
 	case "warn":
@@ -127,3 +139,15 @@ func Lint(f *build.File, lint string, warningsList *[]string, verbose bool) []*w
 	}
 	return nil
 }
+
+// WithoutGenerated returns the findings that aren't within the generated regions of their files,
+// see build.GeneratedRegions.
+func WithoutGenerated(findings []*warn.Finding) []*warn.Finding {
+	var result []*warn.Finding
+	for _, f := range findings {
+		if !f.Generated {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -129,6 +129,18 @@ func TestIsStarlarkFile(t *testing.T) {
 			filename: "foo.workspace",
 			ok:       false,
 		},
+		{
+			filename: "REPO.bazel",
+			ok:       true,
+		},
+		{
+			filename: "VENDOR.bazel",
+			ok:       true,
+		},
+		{
+			filename: "foo.REPO.bazel",
+			ok:       false,
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,242 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// IgnoreFileName is the name of the files listing paths that should be skipped
+// when searching for Starlark files recursively. The syntax is the same as for
+// .gitignore files.
+const IgnoreFileName = ".buildifierignore"
+
+// ignorePattern is a single line of an ignore file.
+type ignorePattern struct {
+	base    string // directory the pattern is relative to, slash-separated
+	re      *regexp.Regexp
+	negate  bool // the pattern starts with "!"
+	dirOnly bool // the pattern ends with "/"
+}
+
+// parseIgnorePattern parses a gitignore-style pattern relative to the base directory.
+// Returns nil for empty lines and comments.
+func parseIgnorePattern(base, line string) *ignorePattern {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	p := &ignorePattern{base: filepath.ToSlash(filepath.Clean(base))}
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// Escaped "!" or "#"
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// A pattern without a slash (except for a trailing one) matches at any level,
+	// otherwise it's relative to the base directory.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "/**") && i+3 == len(line):
+			re.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(line[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := line[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(line):
+			i++
+			re.WriteString(regexp.QuoteMeta(string(line[i])))
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil
+	}
+	p.re = compiled
+	return p
+}
+
+// match reports whether the pattern matches the slash-separated path.
+func (p *ignorePattern) match(path string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	rel := path
+	if p.base != "" && p.base != "." {
+		if !strings.HasPrefix(path, p.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(path, p.base+"/")
+	}
+	return p.re.MatchString(rel)
+}
+
+// ignored reports whether the path is ignored by the list of patterns. Later patterns
+// take precedence over earlier ones.
+func ignored(patterns []*ignorePattern, path string, isDir bool) bool {
+	path = filepath.ToSlash(path)
+	result := false
+	for _, p := range patterns {
+		if p.negate == result && p.match(path, isDir) {
+			result = !p.negate
+		}
+	}
+	return result
+}
+
+// readIgnoreFile reads the ignore file in the given directory, if it exists.
+func readIgnoreFile(dir string) []*ignorePattern {
+	data, err := os.ReadFile(filepath.Join(dir, IgnoreFileName))
+	if err != nil {
+		return nil
+	}
+	var patterns []*ignorePattern
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if p := parseIgnorePattern(dir, scanner.Text()); p != nil {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// walker searches for Starlark files in a directory tree using several goroutines.
+type walker struct {
+	sem chan struct{} // limits the number of directories read simultaneously
+	wg  sync.WaitGroup
+
+	mu    sync.Mutex
+	files []string
+	err   error
+}
+
+func newWalker() *walker {
+	return &walker{sem: make(chan struct{}, runtime.NumCPU())}
+}
+
+func (w *walker) walkDir(dir string, patterns []*ignorePattern) {
+	defer w.wg.Done()
+
+	w.sem <- struct{}{}
+	entries, err := os.ReadDir(dir)
+	<-w.sem
+	if err != nil {
+		w.mu.Lock()
+		if w.err == nil {
+			w.err = err
+		}
+		w.mu.Unlock()
+		return
+	}
+
+	if local := readIgnoreFile(dir); len(local) > 0 {
+		patterns = append(append([]*ignorePattern{}, patterns...), local...)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() && entry.Name() == ".git" {
+			continue
+		}
+		if ignored(patterns, path, entry.IsDir()) {
+			continue
+		}
+		if entry.IsDir() {
+			w.wg.Add(1)
+			go w.walkDir(path, patterns)
+		} else if isStarlarkFile(entry.Name()) {
+			files = append(files, path)
+		}
+	}
+
+	w.mu.Lock()
+	w.files = append(w.files, files...)
+	w.mu.Unlock()
+}
+
+// walk returns all Starlark files in the directory tree, in the same order as filepath.Walk.
+func (w *walker) walk(root string, patterns []*ignorePattern) ([]string, error) {
+	w.wg.Add(1)
+	w.walkDir(root, patterns)
+	w.wg.Wait()
+
+	sort.Slice(w.files, func(i, j int) bool {
+		return lessPath(w.files[i], w.files[j])
+	})
+	return w.files, w.err
+}
+
+// lessPath compares two paths component by component.
+func lessPath(a, b string) bool {
+	partsA := strings.Split(filepath.ToSlash(a), "/")
+	partsB := strings.Split(filepath.ToSlash(b), "/")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] != partsB[i] {
+			return partsA[i] < partsB[i]
+		}
+	}
+	return len(partsA) < len(partsB)
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnorePatterns(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		isDir   bool
+		ok      bool
+	}{
+		{"foo", "root/foo", true, true},
+		{"foo", "root/a/b/foo", false, true},
+		{"foo", "root/foobar", false, false},
+		{"foo/", "root/a/foo", true, true},
+		{"foo/", "root/a/foo", false, false},
+		{"/foo", "root/foo", true, true},
+		{"/foo", "root/a/foo", true, false},
+		{"a/foo", "root/a/foo", false, true},
+		{"a/foo", "root/b/a/foo", false, false},
+		{"*.bzl", "root/a/defs.bzl", false, true},
+		{"a/*.bzl", "root/a/b/defs.bzl", false, false},
+		{"**/testdata", "root/a/b/testdata", true, true},
+		{"a/**/BUILD", "root/a/BUILD", false, true},
+		{"a/**/BUILD", "root/a/b/c/BUILD", false, true},
+		{"a/**", "root/a/b/c/BUILD", false, true},
+		{"BUILD.?", "root/BUILD.x", false, true},
+		{"[ab]", "root/b", false, true},
+		{"[!ab]", "root/b", false, false},
+		{"# comment", "root/# comment", false, false},
+		{`\#foo`, "root/#foo", false, true},
+	}
+	for _, tc := range tests {
+		p := parseIgnorePattern("root", tc.pattern)
+		got := p != nil && p.match(tc.path, tc.isDir)
+		if got != tc.ok {
+			t.Errorf("pattern %q on %q (dir: %t): got %t, want %t", tc.pattern, tc.path, tc.isDir, got, tc.ok)
+		}
+	}
+}
+
+func TestIgnoredNegation(t *testing.T) {
+	var patterns []*ignorePattern
+	for _, line := range []string{"*.bzl", "!keep.bzl"} {
+		patterns = append(patterns, parseIgnorePattern(".", line))
+	}
+	if !ignored(patterns, "a/defs.bzl", false) {
+		t.Errorf("a/defs.bzl should be ignored")
+	}
+	if ignored(patterns, "a/keep.bzl", false) {
+		t.Errorf("a/keep.bzl should not be ignored")
+	}
+}
+
+func writeFiles(t testing.TB, root string, files ...string) {
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"BUILD",
+		"a/BUILD.bazel",
+		"a/b/defs.bzl",
+		"a/b.bzl",
+		"a/generated/BUILD",
+		"a/readme.md",
+		"c/BUILD",
+		"c/testdata/BUILD",
+		"vendor/BUILD",
+		".git/BUILD",
+	)
+	if err := os.WriteFile(filepath.Join(root, IgnoreFileName), []byte("# comment\n/vendor\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "a", IgnoreFileName), []byte("generated/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{root}
+	got, err := ExpandDirectoriesWithExclusions(&args, []string{"testdata/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, file := range []string{"BUILD", "a/BUILD.bazel", "a/b/defs.bzl", "a/b.bzl", "c/BUILD"} {
+		want = append(want, filepath.Join(root, filepath.FromSlash(file)))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandDirectoriesWithExclusions() = %q, want %q", got, want)
+	}
+
+	// Files passed explicitly are never ignored
+	args = []string{filepath.Join(root, "vendor", "BUILD")}
+	got, err = ExpandDirectories(&args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, args) {
+		t.Errorf("ExpandDirectories() = %q, want %q", got, args)
+	}
+}
+
+func BenchmarkExpandDirectories(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		root := b.TempDir()
+		for i := 0; i < size; i++ {
+			dir := fmt.Sprintf("pkg%d/sub%d", i%10, i)
+			writeFiles(b, root, dir+"/BUILD", dir+"/defs.bzl", dir+"/source.cc")
+		}
+		b.Run(fmt.Sprintf("dirs=%d", size), func(b *testing.B) {
+			args := []string{root}
+			for i := 0; i < b.N; i++ {
+				if _, err := ExpandDirectories(&args); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
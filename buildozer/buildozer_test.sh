@@ -187,6 +187,34 @@ function test_sorted_deps() {
 )'
 }
 
+function test_sorted_deps_blocks() {
+  in='go_library(
+    name = "edit",
+    deps = [
+      ":c",
+      # do not sort
+      ":x",
+      ":w",
+      # keep sorted
+      ":m",
+    ],
+)'
+  run "$in" 'add deps :a :y :n' 'remove deps :m' '//pkg:edit'
+  assert_equals 'go_library(
+    name = "edit",
+    deps = [
+        ":a",
+        ":c",
+        # do not sort
+        ":x",
+        ":w",
+        # keep sorted
+        ":n",
+        ":y",
+    ],
+)'
+}
+
 function test_noshorten_labels_flag() {
   in='go_library(
     name = "edit",
@@ -983,6 +1011,57 @@ cc_binary(
 )'
 }
 
+function test_union_attr() {
+  in='cc_library(name = "from", deps = [":a", ":c"])
+
+cc_binary(name = "to", deps = [":a", ":b"])'
+
+  run "$in" 'union_attr deps from deps' '//pkg:to'
+  assert_equals 'cc_library(
+    name = "from",
+    deps = [
+        ":a",
+        ":c",
+    ],
+)
+
+cc_binary(
+    name = "to",
+    deps = [
+        ":a",
+        ":b",
+        ":c",
+    ],
+)'
+}
+
+function test_subtract_attr_variable() {
+  in='EXCLUDED = ["//pkg:b"]
+
+cc_binary(name = "to", deps = [":a", ":b"])'
+
+  run "$in" 'subtract_attr deps EXCLUDED' '//pkg:to'
+  assert_equals 'EXCLUDED = ["//pkg:b"]
+
+cc_binary(
+    name = "to",
+    deps = [":a"],
+)'
+}
+
+function test_intersect_attr_list() {
+  in='cc_binary(name = "to", deps = [":a", ":b", ":c"])'
+
+  run "$in" 'intersect_attr deps [":b",":c",":d"]' '//pkg:to'
+  assert_equals 'cc_binary(
+    name = "to",
+    deps = [
+        ":b",
+        ":c",
+    ],
+)'
+}
+
 function test_copy_no_overwrite_no_overwrite() {
   in='proto_library(name = "from", testonly = 1)
 
@@ -1130,6 +1209,24 @@ java_library(name = "c")'
 c java_library'
 }
 
+function test_query_commands() {
+  in='cc_library(
+    name = "a",
+    testonly = True,
+    deps = [":b"],
+)
+
+cc_test(name = "t")'
+  run "$in" 'exists' '//pkg:a' '//pkg:t'
+  ERROR=4 run "$in" 'exists' '//pkg:a' '//pkg:missing'
+  run "$in" 'count' '//pkg:*'
+  assert_output '2'
+  ERROR=4 run "$in" 'count' '//pkg:%java_library'
+  assert_output '0'
+  run "$in" 'attr_equals deps //pkg:b' '//pkg:a'
+  ERROR=4 run "$in" 'attr_equals testonly True' '//pkg:*'
+}
+
 function test_refer_to_rule_by_location() {
   in='cc_test(name = "a")
 java_library(name = "b")
@@ -1306,6 +1403,178 @@ function test_new_already_exists() {
   assert_err "rule 'a' already exists"
 }
 
+function test_new_from_template() {
+  cat > templates.json <<'EOF'
+{
+  "go_binary_tmpl": {
+    "kind": "go_binary",
+    "name": "{name}_{mode}",
+    "attrs": {"srcs": ["{name}.go"], "embed": [":{package_name}_lib"]},
+    "loads": {"@io_bazel_rules_go//go:def.bzl": ["go_binary"]}
+  }
+}
+EOF
+  in='load("@io_bazel_rules_go//go:def.bzl", "go_library")
+
+go_library(name = "pkg_lib")'
+  run --templates=templates.json "$in" 'new_from_template go_binary_tmpl my_tool mode=opt' '//pkg:__pkg__'
+  assert_equals 'load("@io_bazel_rules_go//go:def.bzl", "go_binary", "go_library")
+
+go_library(name = "pkg_lib")
+
+go_binary(
+    name = "my_tool_opt",
+    srcs = ["my_tool.go"],
+    embed = [":pkg_lib"],
+)'
+
+  ERROR=2 run --templates=templates.json "$in" 'new_from_template go_test_tmpl my_test' '//pkg:__pkg__'
+  assert_err "unknown template 'go_test_tmpl'"
+}
+
+function test_make_alias_replaces_rule() {
+  in='# Moved.
+java_library(
+    name = "lib",
+    srcs = ["A.java"],
+    testonly = True,
+    visibility = ["//visibility:public"],  # keep
+)'
+  run "$in" 'make_alias lib //new/pkg:lib' '//pkg:__pkg__'
+  assert_equals '# Moved.
+alias(
+    name = "lib",
+    testonly = True,
+    actual = "//new/pkg:lib",
+    deprecation = "Moved to //new/pkg:lib",
+    visibility = ["//visibility:public"],  # keep
+)'
+}
+
+function test_make_alias_updates_alias() {
+  in='alias(
+    name = "lib",
+    actual = "//new/pkg:lib",
+    deprecation = "Moved to //new/pkg:lib",
+)'
+  run "$in" 'make_alias //pkg:lib :impl Use\ :impl' '//pkg:__pkg__'
+  assert_equals 'alias(
+    name = "lib",
+    actual = ":impl",
+    deprecation = "Use :impl",
+)'
+}
+
+function test_make_alias_adds_alias() {
+  in='cc_test(name = "a")'
+  run "$in" 'make_alias lib //new/pkg:lib' '//pkg:__pkg__'
+  assert_equals 'cc_test(name = "a")
+
+alias(
+    name = "lib",
+    actual = "//new/pkg:lib",
+    deprecation = "Moved to //new/pkg:lib",
+)'
+}
+
+function test_make_alias_other_package() {
+  in='cc_test(name = "a")'
+  ERROR=2 run "$in" 'make_alias //other:a //new/pkg:a' '//pkg:__pkg__'
+  assert_err "'//other:a' is not in the package 'pkg'"
+}
+
+function test_add_package_to_group() {
+  in='package_group(
+    name = "friends",
+    packages = ["//a/..."],
+)'
+  run "$in" 'add_package_to_group //pkg:friends //new/pkg/... //a/... :other //team:group' '//pkg:__pkg__'
+  assert_equals 'package_group(
+    name = "friends",
+    includes = [
+        ":other",
+        "//team:group",
+    ],
+    packages = [
+        "//a/...",
+        "//new/pkg/...",
+    ],
+)'
+}
+
+function test_add_package_to_group_creates_group() {
+  in='cc_test(name = "a")'
+  run "$in" 'add_package_to_group friends //new/pkg' '//pkg:__pkg__'
+  assert_equals 'cc_test(name = "a")
+
+package_group(
+    name = "friends",
+    packages = ["//new/pkg"],
+)'
+}
+
+function test_add_package_to_group_invalid() {
+  in='cc_test(name = "a")'
+  ERROR=2 run "$in" 'add_package_to_group a //new/pkg' '//pkg:__pkg__'
+  assert_err "'a' is a cc_test, not a package_group"
+  ERROR=2 run "$in" 'add_package_to_group friends new/pkg' '//pkg:__pkg__'
+  assert_err 'invalid package specification "new/pkg"'
+  ERROR=2 run "$in" 'add_package_to_group @repo//other:friends //new/pkg' '//pkg:__pkg__'
+  assert_err "'@repo//other:friends' is not in the package 'pkg'"
+}
+
+function test_add_package_to_group_other_package() {
+  mkdir -p tools
+  cat > tools/BUILD <<EOF
+package_group(name = "friends")
+EOF
+  in='cc_test(name = "a")'
+  run "$in" 'add_package_to_group //tools:friends //new/pkg :group //tools:other' '//pkg:__pkg__'
+  assert_equals "$in"
+  assert_equals 'package_group(
+    name = "friends",
+    includes = [
+        ":other",
+        "//pkg:group",
+    ],
+    packages = ["//new/pkg"],
+)' tools
+
+  run "$in" 'remove_package_from_group //tools:friends //new/pkg :group' '//pkg:__pkg__'
+  assert_equals 'package_group(
+    name = "friends",
+    includes = [":other"],
+)' tools
+
+  # The group is created in its package if it's missing.
+  run "$in" 'add_package_to_group //tools:new_group //pkg' '//pkg:__pkg__'
+  assert_equals 'package_group(
+    name = "friends",
+    includes = [":other"],
+)
+
+package_group(
+    name = "new_group",
+    packages = ["//pkg"],
+)' tools
+}
+
+function test_remove_package_from_group() {
+  in='package_group(
+    name = "friends",
+    includes = [":other"],
+    packages = [
+        "//a/...",
+        "//b",
+    ],
+)'
+  run "$in" 'remove_package_from_group friends //b :other' '//pkg:__pkg__'
+  assert_equals 'package_group(
+    name = "friends",
+    packages = ["//a/..."],
+)'
+}
+
 function test_new_before_first() {
   in='cc_test(name = "a")'
   run "$in" 'new java_library foo before a' 'pkg/BUILD'
@@ -1441,6 +1710,43 @@ function test_keep_going() {
 )'
 }
 
+function test_detailed_exit_codes() {
+  ERROR=5 run "$one_dep" --detailed_exit_codes -k 'add deps //dep' '//pkg:doesnt_exist' '//pkg:edit'
+  assert_err "rule 'doesnt_exist' not found"
+  assert_err "targets: 1 changed, 0 unchanged, 1 not found"
+
+  ERROR=3 run "$one_dep" --detailed_exit_codes 'add deps //buildifier:build' '//pkg:edit'
+  assert_err "targets: 0 changed, 1 unchanged, 0 not found"
+
+  ERROR=5 run "$one_dep" --detailed_exit_codes 'add deps //dep' '//pkg:%cc_test'
+  assert_err "targets: 0 changed, 0 unchanged, 1 not found"
+
+  ERROR=5 run "$one_dep" --detailed_exit_codes 'add deps //dep' '//doesnt_exist:edit'
+
+  in='load("//tools:defs.bzl", "go_binary")
+
+go_library(
+    name = "a",
+    deps = [":x"],
+)
+
+go_library(name = "b")'
+  run "$in" --detailed_exit_codes 'add deps :x' '//pkg:a' '//pkg:b'
+  assert_err "targets: 1 changed, 1 unchanged, 0 not found"
+  run "$in" --detailed_exit_codes 'delete' '//pkg:a' '//pkg:b'
+  assert_err "targets: 2 changed, 0 unchanged, 0 not found"
+  run "$in" --detailed_exit_codes 'new_load //tools:defs.bzl go_test' '//pkg:__pkg__'
+  assert_err "targets: 1 changed, 0 unchanged, 0 not found"
+  ERROR=3 run "$in" --detailed_exit_codes 'new_load //tools:defs.bzl go_binary' 'print name' '//pkg:__pkg__' '//pkg:a'
+  assert_err "targets: 0 changed, 2 unchanged, 0 not found"
+
+  ERROR=2 run "$one_dep" --detailed_exit_codes -k 'new cc_library edit' '//pkg:doesnt_exist' '//pkg:edit'
+
+  # Without the flag, a rule that doesn't match is a failure and no match is no change.
+  ERROR=2 run "$one_dep" 'add deps //dep' '//pkg:doesnt_exist'
+  ERROR=3 run "$one_dep" 'add deps //dep' '//pkg:%cc_test'
+}
+
 function test_buildifier_missing() {
   ERROR=2 run "$one_dep" '--buildifier=doesnt_exist' 'add deps //dep' '//pkg:edit'
   assert_err "executable file not found in \$PATH"
@@ -1626,6 +1932,70 @@ function test_value_inside_select_print_comment() {
 world'
 }
 
+function test_set_meta() {
+  in='# A library.
+cc_library(name = "a")
+
+# meta: owner=foo
+cc_library(name = "b")
+
+# meta: owner=foo ticket=BUG-123
+cc_library(name = "c")'
+  run "$in" 'set_meta owner bar' 'set_meta note some\ text' //pkg:a //pkg:b //pkg:c
+  assert_equals '# A library.
+# meta: owner=bar note="some text"
+cc_library(name = "a")
+
+# meta: owner=bar note="some text"
+cc_library(name = "b")
+
+# meta: owner=bar ticket=BUG-123 note="some text"
+cc_library(name = "c")'
+
+  # Newlines and other control characters are quoted, the comment stays on one line.
+  run "$in" $'set_meta note two\\\nlines\a' //pkg:a
+  assert_equals '# A library.
+# meta: note="two\nlines\a"
+cc_library(name = "a")
+
+# meta: owner=foo
+cc_library(name = "b")
+
+# meta: owner=foo ticket=BUG-123
+cc_library(name = "c")'
+  ERROR=2 run "$in" $'set_meta bad\akey value' //pkg:a
+  assert_err 'invalid metadata key "bad\\akey"'
+}
+
+function test_print_meta() {
+  in='# A library.
+# meta: owner=foo note="some text"
+# meta: ticket=BUG-123
+cc_library(name = "a")
+
+cc_library(name = "b")'
+  run "$in" 'print_meta owner' 'print_meta note' 'print_meta ticket' 'print_meta' //pkg:a
+  assert_output 'foo
+some text
+BUG-123
+owner=foo note="some text" ticket=BUG-123'
+  run "$in" 'print_meta owner' //pkg:b
+  assert_output '(missing)'
+}
+
+function test_remove_meta() {
+  in='# meta: owner=foo
+cc_library(name = "a")
+
+# meta: owner=foo ticket=BUG-123
+cc_library(name = "b")'
+  run "$in" 'remove_meta owner' //pkg:a //pkg:b
+  assert_equals 'cc_library(name = "a")
+
+# meta: ticket=BUG-123
+cc_library(name = "b")'
+}
+
 # Test both absolute and relative package names
 function test_path() {
   mkdir -p "java/com/foo/myproject"
@@ -1918,6 +2288,50 @@ some_rule(
 )'
 }
 
+function test_add_existing_value_of_variable() {
+  in='COMMON_DEPS = ["a"]
+
+some_rule(
+    name = "r",
+    deps = COMMON_DEPS + ["b"],
+)'
+
+  ERROR=3 run "$in" --edit-variables 'add deps a' '//pkg:r'
+  assert_equals "$in"
+}
+
+function test_remove_from_variable() {
+in='COMMON_DEPS = ["a", "b", "c"]
+some_rule(name="r", deps=COMMON_DEPS + ["d", "e"])'
+
+  run "$in" --edit-variables 'remove deps a d' '//pkg:r'
+  assert_equals 'COMMON_DEPS = [
+    "b",
+    "c",
+]
+
+some_rule(
+    name = "r",
+    deps = COMMON_DEPS + ["e"],
+)'
+}
+
+function test_remove_from_variable_without_flag() {
+in='COMMON_DEPS = ["a", "b"]
+some_rule(name="r", deps=COMMON_DEPS + ["d"])'
+
+  run "$in" 'remove deps a d' '//pkg:r'
+  assert_equals 'COMMON_DEPS = [
+    "a",
+    "b",
+]
+
+some_rule(
+    name = "r",
+    deps = COMMON_DEPS,
+)'
+}
+
 function test_pkg_recursive_wildcard() {
 mkdir -p foo/bar/baz
 mkdir -p foo/abc
@@ -1999,6 +2413,45 @@ function test_set_config_string() {
 )'
 }
 
+function test_normalize_labels() {
+  run 'cc_library(
+    name = "lib",
+    deps = [
+        "//pkg:a",
+        "//foo:foo",
+        "//pkg:b",  # keep
+    ],
+)' 'normalize_labels' '//pkg:lib'
+  assert_equals 'cc_library(
+    name = "lib",
+    deps = [
+        ":a",
+        "//foo",
+        "//pkg:b",  # keep
+    ],
+)'
+}
+
+function test_normalize_labels_long() {
+  in='cc_library(
+    name = "lib",
+    deps = [
+        ":a",
+        "//foo",
+    ],
+)'
+  ERROR=2 run "$in" 'normalize_labels long' '//pkg:lib'
+  assert_err 'the "long" form requires -shorten_labels=false'
+  run "$in" --shorten_labels=false 'normalize_labels long' '//pkg:lib'
+  assert_equals 'cc_library(
+    name = "lib",
+    deps = [
+        "//foo:foo",
+        "//pkg:a",
+    ],
+)'
+}
+
 function test_fix_unused_load() {
   run 'load(":a.bzl", "a")
 # TODO: refactor
@@ -2046,6 +2499,40 @@ foobar()
 foobar()'
 }
 
+function test_fix_sort_loads_and_lists() {
+  run 'load(":b.bzl", "b")
+load(":a.bzl", "unused")
+load(":a.bzl", "a")
+
+a(name = "x")
+
+b(
+    name = "y",
+    srcs = [
+        "y.cc",
+        "x.cc",
+    ],
+)' 'fix unusedLoads sortLoads sortLists' '//pkg:__pkg__'
+  assert_equals 'load(":a.bzl", "a")
+load(":b.bzl", "b")
+
+a(name = "x")
+
+b(
+    name = "y",
+    srcs = [
+        "x.cc",
+        "y.cc",
+    ],
+)'
+}
+
+function test_fix_unknown() {
+  in='cc_library(name = "a")'
+  ERROR=2 run "$in" 'fix sortList' '//pkg:a'
+  assert_err 'fix: unknown fix "sortList"'
+}
+
 function test_commands_with_targets() {
   mkdir -p pkg1
   mkdir -p pkg2
@@ -2075,6 +2562,36 @@ EOF
 )' pkg2
 }
 
+function test_bazelrc() {
+  cat > .bazelrc <<'EOF'
+# Shared settings.
+build   --jobs=50
+build:ci --config=remote \
+  --remote_timeout=3600
+
+test --test_output=errors
+EOF
+
+  cat > .bazelrc.expected <<'EOF'
+# Shared settings.
+build --jobs=50
+build:ci --config=remote \
+         --keep_going
+build:opt --compilation_mode=opt
+
+test --test_output=errors
+EOF
+
+  $buildozer 'bazelrc_add build:ci --keep_going' 'bazelrc_remove build:ci --remote_timeout' 'bazelrc_add build:opt --compilation_mode=opt' //.bazelrc
+  diff -u .bazelrc.expected .bazelrc || fail "Output didn't match"
+}
+
+function test_bazelrc_unsupported_command() {
+  echo 'build --jobs=50' > .bazelrc
+  ERROR=2 run_with_current_workspace "$buildozer" 'set jobs 50' //.bazelrc
+  assert_err "the command can't be executed on .bazelrc files"
+}
+
 function test_module_bazel() {
   cat > MODULE.bazel <<EOF
 module(
@@ -2186,6 +2703,118 @@ EOF
   diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
 }
 
+function test_inject_and_override_repo() {
+  cat > MODULE.bazel <<EOF
+bazel_dep(name = "rules_foo", version = "1.0.0")
+bazel_dep(name = "my_bar", version = "1.0.0")
+
+foo = use_extension("@rules_foo//:extensions.bzl", "foo")
+foo.toolchain(version = "1.0")
+use_repo(foo, "foo_toolchains")
+override_repo(foo, "baz")
+EOF
+
+  cat > MODULE.bazel.expected <<EOF
+bazel_dep(name = "rules_foo", version = "1.0.0")
+bazel_dep(name = "my_bar", version = "1.0.0")
+
+foo = use_extension("@rules_foo//:extensions.bzl", "foo")
+foo.toolchain(version = "1.0")
+inject_repo(foo, "my_bar", bar = "my_bar")
+use_repo(foo, "foo_toolchains")
+EOF
+
+  $buildozer 'inject_repo_add foo my_bar bar=my_bar my_bar' \
+    'override_repo_remove @rules_foo//:extensions.bzl foo baz' //MODULE.bazel:all
+  diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
+
+  cat > MODULE.bazel.expected <<EOF
+bazel_dep(name = "rules_foo", version = "1.0.0")
+bazel_dep(name = "my_bar", version = "1.0.0")
+
+foo = use_extension("@rules_foo//:extensions.bzl", "foo")
+foo.toolchain(version = "1.0")
+override_repo(foo, "baz")
+inject_repo(foo, bar = "other_bar")
+use_repo(foo, "foo_toolchains")
+EOF
+
+  $buildozer 'inject_repo_remove foo my_bar' 'inject_repo_add foo bar=other_bar' \
+    'override_repo_add foo baz' //MODULE.bazel:all
+  diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
+}
+
+function test_use_repo_sync() {
+  cat > MODULE.bazel <<EOF
+bazel_dep(name = "gazelle", version = "0.30.0")
+
+go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")
+go_deps.from_file(go_mod = "//:go.mod")
+use_repo(go_deps, "com_example_foo", "com_example_unused")
+
+go_dev_deps = use_extension("@gazelle//:extensions.bzl", "go_deps", dev_dependency = True)
+go_dev_deps.from_file(go_mod = "//:go_dev.mod")
+EOF
+
+  cat > metadata.json <<EOF
+{
+  "extension_bzl_file": "@gazelle//:extensions.bzl",
+  "extension_name": "go_deps",
+  "root_module_direct_deps": ["com_example_foo", "org_example_bar"],
+  "root_module_direct_dev_deps": ["org_example_test"]
+}
+EOF
+
+  cat > MODULE.bazel.expected <<EOF
+bazel_dep(name = "gazelle", version = "0.30.0")
+
+go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")
+go_deps.from_file(go_mod = "//:go.mod")
+use_repo(go_deps, "com_example_foo", "org_example_bar")
+
+go_dev_deps = use_extension("@gazelle//:extensions.bzl", "go_deps", dev_dependency = True)
+go_dev_deps.from_file(go_mod = "//:go_dev.mod")
+use_repo(go_dev_deps, "org_example_test")
+EOF
+
+  $buildozer "use_repo_sync $PWD/metadata.json" //MODULE.bazel:all
+  diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
+}
+
+function test_isolate_extension() {
+  cat > MODULE.bazel <<EOF
+bazel_dep(name = "rules_jvm_external", version = "6.0")
+
+maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+use_repo(maven, "foo")
+EOF
+
+  cat > MODULE.bazel.expected <<EOF
+bazel_dep(name = "rules_jvm_external", version = "6.0")
+
+foo_maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", isolate = True)
+foo_maven.install(name = "foo")
+use_repo(foo_maven, "foo")
+EOF
+
+  $buildozer 'isolate_extension maven foo_maven foo' //MODULE.bazel:all
+  diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
+
+  cat > MODULE.bazel.expected <<EOF
+bazel_dep(name = "rules_jvm_external", version = "6.0")
+
+foo_maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+foo_maven.install(name = "foo")
+use_repo(foo_maven, "foo")
+EOF
+
+  $buildozer 'unisolate_extension foo_maven' //MODULE.bazel:all
+  diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
+
+  ERROR=2 run_with_current_workspace "$buildozer" 'unisolate_extension foo_maven' //MODULE.bazel:all
+}
+
 function test_use_repo_add_dev() {
   cat > MODULE.bazel <<EOF
 module(
@@ -2393,4 +3022,70 @@ EOF
   diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
 }
 
+function test_set_default_bzl() {
+  mkdir -p pkg
+  cat > pkg/defs.bzl <<EOF
+TIMEOUT = "short"
+
+def my_macro(name, size = "small", tags = []):
+    native.sh_test(name = name, size = size, tags = tags, timeout = TIMEOUT)
+EOF
+
+  cat > pkg/defs.bzl.expected <<EOF
+TIMEOUT = "long"
+
+def my_macro(name, size = "large", tags = ["manual"]):
+    native.sh_test(name = name, size = size, tags = tags, timeout = TIMEOUT)
+EOF
+
+  $buildozer 'set_default my_macro size large' 'set_default my_macro tags manual' 'set_constant TIMEOUT "long"' pkg/defs.bzl
+  diff -u pkg/defs.bzl.expected pkg/defs.bzl || fail "Output didn't match"
+}
+
+function test_set_default_bzl_no_default() {
+  mkdir -p pkg
+  cat > pkg/defs.bzl <<EOF
+def my_macro(name, size = "small"):
+    pass
+EOF
+
+  ERROR=2 run_with_current_workspace "$buildozer" 'set_default my_macro name foo' //pkg/defs.bzl
+  assert_err "parameter 'name' of function 'my_macro' has no default value"
+}
+
+function test_module_compatibility() {
+  cat > MODULE.bazel <<EOF
+module(
+    name = "foo",
+    version = "1.2.3",
+    compatibility_level = 1,
+)
+
+bazel_dep(name = "rules_go", version = "0.50.0")
+EOF
+
+  cat > MODULE.bazel.expected <<EOF
+module(
+    name = "foo",
+    version = "2.0.0-rc.1",
+    bazel_compatibility = [">=7.0.0"],
+    compatibility_level = 2,
+)
+
+bazel_dep(name = "rules_go", version = "0.50.0", max_compatibility_level = 1)
+EOF
+
+  $buildozer 'set_module_version 2.0.0-rc.1' 'set_compatibility_level 2' 'bazel_compatibility_add >=7.0.0' 'set_max_compatibility_level rules_go 1' //MODULE.bazel:all
+  diff -u MODULE.bazel.expected MODULE.bazel || fail "Output didn't match"
+}
+
+function test_module_invalid_version() {
+  cat > MODULE.bazel <<EOF
+module(name = "foo", version = "1.2.3")
+EOF
+
+  ERROR=2 run_with_current_workspace "$buildozer" 'set_module_version 1..2' //MODULE.bazel:all
+  assert_err 'invalid module version "1..2"'
+}
+
 run_suite "buildozer tests"
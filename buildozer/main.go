@@ -26,6 +26,7 @@ import (
 
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/edit"
+	"github.com/bazelbuild/buildtools/profile"
 	"github.com/bazelbuild/buildtools/tables"
 )
 
@@ -42,6 +43,7 @@ var (
 	buildScmRevision = "redacted"
 
 	commandsFiles flagArray
+	forFiles      flagArray
 
 	version           = flag.Bool("version", false, "Print the version of buildozer")
 	stdout            = flag.Bool("stdout", false, "write changed BUILD file to stdout")
@@ -53,7 +55,7 @@ var (
 	preferEOLComments = flag.Bool("eol-comments", true, "when adding a new comment, put it on the same line if possible")
 	rootDir           = flag.String("root_dir", "", "If present, use this folder rather than $PWD to find the root directory.")
 	quiet             = flag.Bool("quiet", false, "suppress informational messages")
-	editVariables     = flag.Bool("edit-variables", false, "For attributes that simply assign a variable (e.g. hdrs = LIB_HDRS), edit the build variable instead of appending to the attribute.")
+	editVariables     = flag.Bool("edit-variables", false, "For attributes that simply assign a variable (e.g. hdrs = LIB_HDRS), edit the build variable instead of appending to the attribute. Values are also removed from the variables an attribute consists of.")
 	isPrintingProto   = flag.Bool("output_proto", false, "output serialized devtools.buildozer.Output protos instead of human-readable strings.")
 	isPrintingJSON    = flag.Bool("output_json", false, "output serialized devtools.buildozer.Output json instead of human-readable strings.")
 	tablesPath        = flag.String("tables", "", "path to JSON file with custom table definitions which will replace the built-in tables")
@@ -61,7 +63,23 @@ var (
 
 	shortenLabelsFlag  = flag.Bool("shorten_labels", true, "convert added labels to short form, e.g. //foo:bar => :bar")
 	deleteWithComments = flag.Bool("delete_with_comments", true, "If a list attribute should be deleted even if there is a comment attached to it")
+	foldRuleNames      = flag.Bool("fold_names", false, "match rules whose name is a static string expression, e.g. name = PREFIX + \"_lib\"")
 	respectBazelignore = flag.Bool("respect_bazelignore", true, "use .bazelignore file for ignoring paths")
+	newRulePlacement   = flag.String("new_rule_placement", "kind", "where the 'new' command inserts rules: kind (after the last rule of the same kind), group_by_kind, alphabetical, after_loads, or end")
+	profileKind        = flag.String("profile", "", "collect a profile of the run: cpu, mem, or trace")
+	profileOutput      = flag.String("profile_output", "", "file to write the profile to (default buildozer.<kind>.pprof, or buildozer.trace)")
+	preserveEOL        = flag.Bool("preserve_line_endings", false, "keep the byte order mark and the CRLF line endings of the edited files instead of normalizing them")
+	minimalDiff        = flag.Bool("minimal_diff", false, "only rewrite the top-level statements modified by the commands and keep the rest of the file byte for byte, e.g. for very large generated files")
+	shardFlag          = flag.String("shard", "", "only process the BUILD files of the K-th of N shards, in the K/N form (e.g. 2/4), to split the work between several workers. Files are assigned to shards by a hash of their path relative to the workspace root.")
+	overrideFrozen     = flag.Bool("override_frozen", false, "modify the rules and attributes marked with a \"buildozer: frozen\" comment")
+	force              = flag.Bool("force", false, "modify files even if they contain a generated-file marker comment, see -generated_markers, and the rules within '# buildifier: begin-generated' regions")
+	generatedMarkers   = stringList("generated_markers", "comma-separated list of markers in the leading comments of generated files, which aren't modified without -force (default \"DO NOT EDIT,generated by gazelle,@generated\")")
+	deleteReferences   = flag.String("delete_references", "", "look for the references to the rules removed by 'delete' in the BUILD files of the workspace, and fail (leaving all files unchanged), warn, or remove them from the referring attributes: fail, warn, or remove")
+	referencesQuery    = flag.String("references_query", "", "output of 'bazel query --output=proto' with the rules that may reference the deleted rules, e.g. of 'rdeps(//..., <targets>, 1)', used by -delete_references instead of scanning the BUILD files")
+	detailedExitCodes  = flag.Bool("detailed_exit_codes", false, "print how many targets were changed, left unchanged and not found, and return 5 instead of 2 or 3 when a target or its file wasn't found")
+	gitRev             = flag.String("git_rev", "", "read the BUILD files from this revision of the Git repository (e.g. HEAD or a commit hash) instead of the working tree, and print the changes as a patch instead of modifying the files")
+	templatesPath      = flag.String("templates", "", "JSON file with the rule templates of the 'new_from_template' command, an object mapping template names to {kind, name, attrs, loads} entries")
+	planFile           = flag.String("plan", "", "JSON file with a list of {command, args, targets, comment} entries to apply in order, use '-' for stdin. A JSON report is written to stdout.")
 )
 
 func stringList(name, help string) func() []string {
@@ -80,6 +98,7 @@ func stringList(name, help string) func() []string {
 
 func main() {
 	flag.Var(&commandsFiles, "f", "file name(s) to read commands from, use '-' for stdin (format:|-separated command line arguments to buildozer, excluding flags)")
+	flag.Var(&forFiles, "for_file", "source file(s) whose owning rules, i.e. the rules of its package that have it in srcs, hdrs or textual_hdrs, are added to the targets")
 	flag.Parse()
 
 	if *version {
@@ -105,8 +124,33 @@ func main() {
 	if !(*shortenLabelsFlag) {
 		build.DisableRewrites = []string{"label"}
 	}
+	build.PreserveLineEndings = *preserveEOL
 	edit.ShortenLabelsFlag = *shortenLabelsFlag
 	edit.DeleteWithComments = *deleteWithComments
+	edit.FoldRuleNamesFlag = *foldRuleNames
+	placement, err := edit.InsertionStrategyByName(*newRulePlacement)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildozer: -new_rule_placement: %s\n", err)
+		os.Exit(1)
+	}
+	if err := edit.ValidateDeleteReferences(*deleteReferences); err != nil {
+		fmt.Fprintf(os.Stderr, "buildozer: -delete_references: %s\n", err)
+		os.Exit(1)
+	}
+	var shard edit.Shard
+	if *shardFlag != "" {
+		if shard, err = edit.ParseShard(*shardFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "buildozer: -shard: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	var templates map[string]*edit.RuleTemplate
+	if *templatesPath != "" {
+		if templates, err = edit.ReadRuleTemplates(*templatesPath); err != nil {
+			fmt.Fprintf(os.Stderr, "buildozer: -templates: %s\n", err)
+			os.Exit(1)
+		}
+	}
 	opts := &edit.Options{
 		Stdout:             *stdout,
 		Buildifier:         *buildifier,
@@ -122,6 +166,32 @@ func main() {
 		IsPrintingProto:    *isPrintingProto,
 		IsPrintingJSON:     *isPrintingJSON,
 		RespectBazelignore: *respectBazelignore,
+		PlanFile:           *planFile,
+		ForFiles:           forFiles,
+		NewRulePlacement:   placement,
+		Force:              *force,
+		MinimalDiff:        *minimalDiff,
+		OverrideFrozen:     *overrideFrozen,
+		Shard:              shard,
+		DeleteReferences:   *deleteReferences,
+		ReferencesQuery:    *referencesQuery,
+		DetailedExitCodes:  *detailedExitCodes,
+		GitRev:             *gitRev,
+		GeneratedMarkers:   generatedMarkers(),
+		Templates:          templates,
+	}
+
+	if *profileOutput == "" {
+		*profileOutput = profile.DefaultOutput("buildozer", *profileKind)
+	}
+	stopProfile, err := profile.Start(*profileKind, *profileOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "buildozer: -profile: %s\n", err)
+		os.Exit(1)
+	}
+	ret := edit.Buildozer(opts, flag.Args())
+	if err := stopProfile(); err != nil {
+		fmt.Fprintf(os.Stderr, "buildozer: writing profile: %s\n", err)
 	}
-	os.Exit(edit.Buildozer(opts, flag.Args()))
+	os.Exit(ret)
 }
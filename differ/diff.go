@@ -32,6 +32,9 @@ type Differ struct {
 	Cmd       string   // command
 	MultiDiff bool     // diff accepts list of multiple pairs
 	Args      []string // accumulated arguments
+	// Format is one of the built-in formats (FormatUnified, FormatColor or FormatJSON) if the
+	// differences are computed by ShowContents instead of running Cmd.
+	Format string
 }
 
 // run runs the given command with args.
@@ -75,6 +78,18 @@ func (d *Differ) Show(old, new string) error {
 	return nil
 }
 
+// ShowContents prints the differences between the old and the new contents of a file in the
+// built-in Format. The name of the file is only used in the output.
+func (d *Differ) ShowContents(name string, old, new []byte) error {
+	switch d.Format {
+	case FormatUnified, FormatColor:
+		return WriteUnified(os.Stdout, name, string(old), string(new), d.Format == FormatColor)
+	case FormatJSON:
+		return WriteJSON(os.Stdout, name, string(old), string(new))
+	}
+	return fmt.Errorf("buildifier: unknown diff format %q", d.Format)
+}
+
 // Run runs any pending diffs.
 // For a single-pair diff program, Show already ran diff; Run is a no-op.
 // For a multi-pair diff program, Run displays the diffs queued by Show.
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package differ
+
+import (
+	"strings"
+)
+
+// A Hunk is a group of adjacent changed lines.
+type Hunk struct {
+	// OldStart is the 1-based number of the first removed line in the old text. If no lines
+	// are removed, it's the number of the line before which the new lines are inserted.
+	OldStart int      `json:"old_start"`
+	Old      []string `json:"old"`
+	// NewStart is the 1-based number of the first added line in the new text. If no lines
+	// are added, it's the number of the line before which the old lines have been removed.
+	NewStart int      `json:"new_start"`
+	New      []string `json:"new"`
+}
+
+// Hunks computes the line-based differences between two texts.
+func Hunks(old, new string) []Hunk {
+	a := splitLines(old)
+	b := splitLines(new)
+
+	var hunks []Hunk
+	var current *Hunk
+	x, y := 0, 0
+	for _, op := range diffOps(a, b) {
+		if op == opEqual {
+			if current != nil {
+				hunks = append(hunks, *current)
+				current = nil
+			}
+			x++
+			y++
+			continue
+		}
+		if current == nil {
+			current = &Hunk{OldStart: x + 1, NewStart: y + 1, Old: []string{}, New: []string{}}
+		}
+		if op == opDelete {
+			current.Old = append(current.Old, a[x])
+			x++
+		} else {
+			current.New = append(current.New, b[y])
+			y++
+		}
+	}
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+	return hunks
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+type diffOp int
+
+const (
+	opEqual diffOp = iota
+	opDelete
+	opInsert
+)
+
+// diffOps returns the shortest edit script transforming a into b, computed with the Myers
+// algorithm.
+func diffOps(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int{}, v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, offset, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(trace [][]int, offset, n, m int) []diffOp {
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, opEqual)
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, opInsert)
+			} else {
+				ops = append(ops, opDelete)
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
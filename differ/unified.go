@@ -0,0 +1,333 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package differ
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Formats of the diffs computed without running an external program, see Differ.Format.
+const (
+	FormatUnified = "unified"
+	FormatColor   = "color"
+	FormatJSON    = "json"
+)
+
+// IsFormat returns true if the format is one of the built-in diff formats.
+func IsFormat(format string) bool {
+	return format == FormatUnified || format == FormatColor || format == FormatJSON
+}
+
+// The escape sequences of the color format, the same as the defaults of `git diff --color`.
+// The changed words within modified lines are displayed in reverse video.
+const (
+	colorMeta      = "\x1b[1m"
+	colorFrag      = "\x1b[36m"
+	colorOld       = "\x1b[31m"
+	colorNew       = "\x1b[32m"
+	colorReset     = "\x1b[m"
+	colorReverse   = "\x1b[7m"
+	colorNoReverse = "\x1b[27m"
+)
+
+// contextLines is the number of unchanged lines shown around the changes in the unified format.
+const contextLines = 3
+
+// A diffLine is a line of a unified diff, including its line ending.
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// A unifiedHunk is a group of changed lines with the unchanged lines surrounding them.
+type unifiedHunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	lines              []diffLine
+}
+
+// splitLinesKeepEnds splits a text into lines, keeping their line endings so that a missing
+// newline at the end of the file is detected as a change.
+func splitLinesKeepEnds(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n') + 1
+		if i == 0 {
+			i = len(s)
+		}
+		lines = append(lines, s[:i])
+		s = s[i:]
+	}
+	return lines
+}
+
+// unifiedHunks computes the hunks of the differences between two lists of lines, each one with
+// up to `context` unchanged lines before and after the changes. Changes separated by no more
+// than twice that many unchanged lines are merged into a single hunk.
+func unifiedHunks(a, b []string, context int) []unifiedHunk {
+	ops := diffOps(a, b)
+	// The positions in a and b before each operation.
+	xs := make([]int, len(ops)+1)
+	ys := make([]int, len(ops)+1)
+	for i, op := range ops {
+		xs[i+1], ys[i+1] = xs[i], ys[i]
+		if op != opInsert {
+			xs[i+1]++
+		}
+		if op != opDelete {
+			ys[i+1]++
+		}
+	}
+
+	var hunks []unifiedHunk
+	for i := 0; i < len(ops); {
+		if ops[i] == opEqual {
+			i++
+			continue
+		}
+		last := i
+		for j := i; j < len(ops); j++ {
+			if ops[j] != opEqual {
+				last = j
+			} else if j-last > 2*context {
+				break
+			}
+		}
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end := last + 1 + context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hunk := unifiedHunk{
+			oldStart: xs[start] + 1,
+			oldCount: xs[end] - xs[start],
+			newStart: ys[start] + 1,
+			newCount: ys[end] - ys[start],
+		}
+		for k := start; k < end; k++ {
+			if ops[k] == opInsert {
+				hunk.lines = append(hunk.lines, diffLine{opInsert, b[ys[k]]})
+			} else {
+				hunk.lines = append(hunk.lines, diffLine{ops[k], a[xs[k]]})
+			}
+		}
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
+
+// hunkRange formats the range of lines of a hunk header. An empty range refers to the line
+// before which the lines are inserted or after which they were removed.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprintf("%d", start)
+	default:
+		return fmt.Sprintf("%d,%d", start, count)
+	}
+}
+
+// WriteUnified writes the differences between the old and the new contents of a file in the
+// unified format, as produced by `diff --unified`. With color, the output is colored like the
+// output of `git diff --color`, and the changed words within modified lines are highlighted.
+func WriteUnified(w io.Writer, name, old, new string, color bool) error {
+	hunks := unifiedHunks(splitLinesKeepEnds(old), splitLinesKeepEnds(new), contextLines)
+	if len(hunks) == 0 {
+		return nil
+	}
+	paint := func(color string) string { return "" }
+	if color {
+		paint = func(color string) string { return color }
+	}
+
+	bw := bufio.NewWriter(w)
+	name = strings.TrimPrefix(name, "/")
+	fmt.Fprintf(bw, "%s--- a/%s%s\n", paint(colorMeta), name, paint(colorReset))
+	fmt.Fprintf(bw, "%s+++ b/%s%s\n", paint(colorMeta), name, paint(colorReset))
+	for _, hunk := range hunks {
+		fmt.Fprintf(bw, "%s@@ -%s +%s @@%s\n", paint(colorFrag), hunkRange(hunk.oldStart, hunk.oldCount), hunkRange(hunk.newStart, hunk.newCount), paint(colorReset))
+		texts := make([]string, len(hunk.lines))
+		for i, line := range hunk.lines {
+			texts[i] = strings.TrimSuffix(line.text, "\n")
+		}
+		if color {
+			highlightWords(hunk.lines, texts)
+		}
+		for i, line := range hunk.lines {
+			switch line.op {
+			case opEqual:
+				fmt.Fprintf(bw, " %s", texts[i])
+			case opDelete:
+				fmt.Fprintf(bw, "%s-%s%s", paint(colorOld), texts[i], paint(colorReset))
+			case opInsert:
+				fmt.Fprintf(bw, "%s+%s%s", paint(colorNew), texts[i], paint(colorReset))
+			}
+			bw.WriteString("\n")
+			if !strings.HasSuffix(line.text, "\n") {
+				bw.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// highlightWords highlights the changed words of the modified lines of a hunk in their texts. A
+// block of removed lines followed by as many added lines is considered as modified lines, and
+// each removed line is compared with the corresponding added line.
+func highlightWords(lines []diffLine, texts []string) {
+	for i := 0; i < len(lines); {
+		if lines[i].op != opDelete {
+			i++
+			continue
+		}
+		start := i
+		for i < len(lines) && lines[i].op == opDelete {
+			i++
+		}
+		mid := i
+		for i < len(lines) && lines[i].op == opInsert {
+			i++
+		}
+		if i-mid != mid-start {
+			continue
+		}
+		for k := 0; k < mid-start; k++ {
+			texts[start+k], texts[mid+k] = wordDiff(texts[start+k], texts[mid+k])
+		}
+	}
+}
+
+// splitWords splits a line into words (runs of letters, digits and underscores), runs of
+// whitespace and single punctuation characters.
+func splitWords(s string) []string {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	var words []string
+	start := 0
+	prev := -1
+	for i, r := range s {
+		c := class(r)
+		if i > start && (c != prev || c == 0) {
+			words = append(words, s[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// wordDiff returns the old and the new line with the removed and the added words highlighted.
+// The lines are returned unchanged if they have no word in common.
+func wordDiff(old, new string) (string, string) {
+	a, b := splitWords(old), splitWords(new)
+	ops := diffOps(a, b)
+	common := false
+	x, y := 0, 0
+	for _, op := range ops {
+		if op == opEqual {
+			if strings.TrimSpace(a[x]) != "" {
+				common = true
+			}
+			x++
+			y++
+		} else if op == opDelete {
+			x++
+		} else {
+			y++
+		}
+	}
+	if !common {
+		return old, new
+	}
+
+	var oldOut, newOut strings.Builder
+	oldHighlighted, newHighlighted := false, false
+	setHighlight := func(out *strings.Builder, highlighted *bool, on bool) {
+		if *highlighted == on {
+			return
+		}
+		*highlighted = on
+		if on {
+			out.WriteString(colorReverse)
+		} else {
+			out.WriteString(colorNoReverse)
+		}
+	}
+	x, y = 0, 0
+	for _, op := range ops {
+		switch op {
+		case opEqual:
+			setHighlight(&oldOut, &oldHighlighted, false)
+			setHighlight(&newOut, &newHighlighted, false)
+			oldOut.WriteString(a[x])
+			newOut.WriteString(b[y])
+			x++
+			y++
+		case opDelete:
+			setHighlight(&oldOut, &oldHighlighted, true)
+			oldOut.WriteString(a[x])
+			x++
+		case opInsert:
+			setHighlight(&newOut, &newHighlighted, true)
+			newOut.WriteString(b[y])
+			y++
+		}
+	}
+	setHighlight(&oldOut, &oldHighlighted, false)
+	setHighlight(&newOut, &newHighlighted, false)
+	return oldOut.String(), newOut.String()
+}
+
+// jsonHunk is a hunk printed by WriteJSON.
+type jsonHunk struct {
+	File string `json:"file"`
+	Hunk
+}
+
+// WriteJSON writes the differences between the old and the new contents of a file as JSON
+// objects, one line per hunk, with the file name and the fields of Hunk.
+func WriteJSON(w io.Writer, name, old, new string) error {
+	encoder := json.NewEncoder(w)
+	for _, hunk := range Hunks(old, new) {
+		if err := encoder.Encode(jsonHunk{name, hunk}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
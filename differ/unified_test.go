@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package differ
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteUnified(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	new := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\no"
+	want := `--- a/pkg/BUILD
++++ b/pkg/BUILD
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -12,3 +12,4 @@
+ l
+ m
+ n
++o
+\ No newline at end of file
+`
+	var buf bytes.Buffer
+	if err := WriteUnified(&buf, "/pkg/BUILD", old, new, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("WriteUnified() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// Changes separated by up to 6 unchanged lines are in the same hunk, removed lines are
+	// counted from the line before them.
+	buf.Reset()
+	if err := WriteUnified(&buf, "BUILD", "a\nb\nc\nd\ne\nf\ng\nh\n", "a\nc\nd\ne\nf\ng\nh\nH\n", false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "--- a/BUILD\n+++ b/BUILD\n@@ -1,8 +1,8 @@\n a\n-b\n c\n d\n e\n f\n g\n h\n+H\n"; got != want {
+		t.Errorf("WriteUnified() =\n%s\nwant:\n%s", got, want)
+	}
+	buf.Reset()
+	if err := WriteUnified(&buf, "BUILD", "a\nb\n", "b\n", false); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "--- a/BUILD\n+++ b/BUILD\n@@ -1,2 +1 @@\n-a\n b\n"; got != want {
+		t.Errorf("WriteUnified() =\n%s\nwant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := WriteUnified(&buf, "BUILD", "a\n", "a\n", false); err != nil || buf.Len() != 0 {
+		t.Errorf("WriteUnified() with equal contents = %q, %v, want no output", buf.String(), err)
+	}
+}
+
+func TestWriteUnifiedColor(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteUnified(&buf, "BUILD", "x = [1]\n    name=\"a\",\nfoo\n", "x = [1]\n    name = \"b\",\nbar\n", true); err != nil {
+		t.Fatal(err)
+	}
+	replacer := strings.NewReplacer(colorMeta, "<meta>", colorFrag, "<frag>", colorOld, "<old>", colorNew, "<new>", colorReset, "</>", colorReverse, "[", colorNoReverse, "]")
+	want := `<meta>--- a/BUILD</>
+<meta>+++ b/BUILD</>
+<frag>@@ -1,3 +1,3 @@</>
+ x = [1]
+<old>-    name="[a]",</>
+<old>-foo</>
+<new>+    name[ ]=[ ]"[b]",</>
+<new>+bar</>
+`
+	if got := replacer.Replace(buf.String()); got != want {
+		t.Errorf("WriteUnified() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, "BUILD", "a\nb\nc\n", "a\nB\nc\nd\n"); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"file":"BUILD","old_start":2,"old":["b"],"new_start":2,"new":["B"]}
+{"file":"BUILD","old_start":4,"old":[],"new_start":4,"new":["d"]}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteJSON() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Buildozer commands for .bazelrc files.
+
+package edit
+
+import (
+	"fmt"
+	"os"
+
+	apipb "github.com/bazelbuild/buildtools/api_proto"
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// bazelrcCommands implement the commands that can be executed on .bazelrc files. They return
+// true if the file was modified.
+var bazelrcCommands = map[string]func(f *build.BazelrcFile, args []string) (bool, error){
+	"bazelrc_add": func(f *build.BazelrcFile, args []string) (bool, error) {
+		return f.AddFlags(args[0], args[1:]...), nil
+	},
+	"bazelrc_remove": func(f *build.BazelrcFile, args []string) (bool, error) {
+		return f.RemoveFlags(args[0], args[1:]...), nil
+	},
+	"format": func(f *build.BazelrcFile, args []string) (bool, error) {
+		return true, nil
+	},
+}
+
+// cmdBazelrc implements the bazelrc commands for Starlark files, the commands are executed on
+// .bazelrc files by rewriteBazelrc.
+func cmdBazelrc(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return nil, fmt.Errorf("the command can only be executed on .bazelrc files")
+}
+
+// rewriteBazelrc executes the commands on a .bazelrc file, e.g.
+// `buildozer 'bazelrc_add build:ci --jobs=50' //.bazelrc`.
+func rewriteBazelrc(opts *Options, name string, fi os.FileInfo, data []byte, commandsForFile commandsForFile) *rewriteResult {
+	f, err := build.ParseBazelrc(name, data)
+	if err != nil {
+		return &rewriteResult{file: name, errs: []error{fileError(ErrorParse, name, err)}}
+	}
+
+	var errs []error
+	changed := false
+	for _, cft := range commandsForFile.commands {
+		for _, cmd := range cft.commands {
+			fn, ok := bazelrcCommands[cmd.tokens[0]]
+			if !ok {
+				err = fmt.Errorf("the command can't be executed on .bazelrc files")
+			} else {
+				var modified bool
+				modified, err = fn(f, cmd.tokens[1:])
+				changed = changed || modified
+			}
+			if err != nil {
+				cerr := targetError(ErrorCommand, name, []command{cmd}, cft.target, nil, err)
+				if !opts.KeepGoing {
+					return &rewriteResult{file: name, errs: []error{cerr}}
+				}
+				errs = append(errs, cerr)
+			}
+		}
+	}
+	if !changed {
+		return &rewriteResult{file: name, errs: errs}
+	}
+	return writeResult(opts, name, fi, data, build.FormatBazelrc(f), errs, []*apipb.Output_Record{})
+}
@@ -30,14 +30,17 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	apipb "github.com/bazelbuild/buildtools/api_proto"
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/edit/bzlmod"
 	"github.com/bazelbuild/buildtools/file"
 	"github.com/bazelbuild/buildtools/labels"
+	"github.com/bazelbuild/buildtools/tables"
 	"github.com/bazelbuild/buildtools/wspace"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
@@ -55,12 +58,40 @@ type Options struct {
 	PreferEOLComments  bool      // when adding a new comment, put it on the same line if possible
 	RootDir            string    // If present, use this folder rather than $PWD to find the root dir
 	Quiet              bool      // suppress informational messages.
-	EditVariables      bool      // for attributes that simply assign a variable (e.g. hdrs = LIB_HDRS), edit the build variable instead of appending to the attribute.
+	EditVariables      bool      // for attributes that simply assign a variable (e.g. hdrs = LIB_HDRS), edit the build variable instead of appending to the attribute, and remove values from it.
 	IsPrintingProto    bool      // output serialized devtools.buildozer.Output protos instead of human-readable strings
 	IsPrintingJSON     bool      // output serialized devtools.buildozer.Output json instead of human-readable strings
 	OutWriter          io.Writer // where to write normal output (`os.Stdout` will be used if not specified)
 	ErrWriter          io.Writer // where to write error output (`os.Stderr` will be used if not specified)
 	RespectBazelignore bool      // whether to use .bazelignore file for ignoring paths
+	PlanFile           string    // JSON file with a list of commands to apply, see PlanEntry
+	ForFiles           []string  // source files whose owning rules are added to the targets, see FindFileOwners
+	Force              bool      // modify files even if they contain a generated-file marker, and the rules in generated regions
+	MinimalDiff        bool      // only rewrite the top-level statements changed by the commands, see minimalDiff
+	OverrideFrozen     bool      // modify rules and attributes marked with a "buildozer: frozen" comment
+	Shard              Shard     // only process the files of a shard, the zero value means all files
+	DeleteReferences   string    // what to do with the references to the rules removed by delete, see DeleteReferencesFail, DeleteReferencesWarn and DeleteReferencesRemove; empty means they aren't looked for
+	ReferencesQuery    string    // output of `bazel query --output=proto` with the rules that may reference the deleted rules, instead of scanning the BUILD files of the workspace
+	DetailedExitCodes  bool      // count the targets that were changed, left unchanged or not found, and return 5 when a target wasn't found, see Buildozer
+	GitRev             string    // read the files from this revision of the Git repository and print the changes as a patch instead of modifying the files
+
+	// ErrorHandler is called with each error reported by Buildozer, in addition to printing it
+	// to ErrWriter.
+	ErrorHandler func(*Error)
+
+	// NewRulePlacement decides where the "new" command inserts rules, nil means SameKindInsertion.
+	NewRulePlacement InsertionStrategy
+
+	// GeneratedMarkers mark a file as generated when found in its leading comments, nil means
+	// DefaultGeneratedMarkers. Generated files aren't modified unless Force is set.
+	GeneratedMarkers []string
+
+	// Templates are the rule templates the new_from_template command can create targets from,
+	// by name, see RuleTemplate.
+	Templates map[string]*RuleTemplate
+
+	cache *workspaceCache // set by Buildozer for the duration of a single invocation
+	git   *gitRev         // set by Buildozer when GitRev is set
 }
 
 // NewOpts returns a new Options struct with some defaults set.
@@ -210,6 +241,259 @@ func cmdPrintComment(opts *Options, env CmdEnvironment) (*build.File, error) {
 	return nil, nil
 }
 
+// cmdPrintBlame prints the commit, date and author that last changed each element of a list
+// attribute, one element per line, according to `git blame`.
+func cmdPrintBlame(opts *Options, env CmdEnvironment) (*build.File, error) {
+	attr := env.Rule.Attr(env.Args[0])
+	if attr == nil {
+		env.output.Fields = []*apipb.Output_Record_Field{
+			{Value: &apipb.Output_Record_Field_Error{Error: apipb.Output_Record_Field_MISSING}},
+		}
+		return nil, fmt.Errorf("rule \"//%s:%s\" has no attribute \"%s\"", env.Pkg, env.Rule.Name(), env.Args[0])
+	}
+	lists := allListsIncludingSelects(attr)
+	if len(lists) == 0 {
+		return nil, fmt.Errorf("attribute \"%s\" of rule \"//%s:%s\" isn't a list", env.Args[0], env.Pkg, env.Rule.Name())
+	}
+
+	var elems []build.Expr
+	first, last := 0, 0
+	for _, list := range lists {
+		for _, elem := range list.List {
+			elems = append(elems, elem)
+			// Elements added by the previous commands have no position.
+			if start, _ := elem.Span(); start.Line > 0 {
+				if first == 0 || start.Line < first {
+					first = start.Line
+				}
+				if start.Line > last {
+					last = start.Line
+				}
+			}
+		}
+	}
+	var commits map[int]*blameCommit
+	if first > 0 {
+		commit := ""
+		if opts.git != nil {
+			commit = opts.git.commit
+		}
+		var err error
+		if commits, err = gitBlame(env.File.Path, commit, first, last); err != nil {
+			return nil, err
+		}
+	}
+
+	lines := make([]string, len(elems))
+	for i, elem := range elems {
+		value := build.FormatString(elem)
+		if str, ok := elem.(*build.StringExpr); ok {
+			value = str.Value
+		}
+		start, _ := elem.Span()
+		if commit := commits[start.Line]; commit != nil {
+			lines[i] = fmt.Sprintf("%s %s", value, commit)
+		} else {
+			lines[i] = fmt.Sprintf("%s (unknown)", value)
+		}
+	}
+	env.output.Fields = []*apipb.Output_Record_Field{
+		{Value: &apipb.Output_Record_Field_Text{Text: strings.Join(lines, "\n")}},
+	}
+	return nil, nil
+}
+
+// metaPrefix starts the comment lines before a rule that hold its structured metadata, as
+// space-separated key=value pairs, e.g. `# meta: owner=foo ticket=BUG-123`. Values containing
+// spaces, quotes or non-printable characters (e.g. newlines) are quoted.
+const metaPrefix = "meta:"
+
+// A metaPair is a key=value pair of a metadata comment. Words without a value are kept as is.
+type metaPair struct {
+	key, value string
+	bare       bool // the word has no value
+}
+
+func (p metaPair) String() string {
+	if p.bare {
+		return p.key
+	}
+	if p.value == "" || strings.IndexFunc(p.value, needsMetaQuote) >= 0 {
+		return p.key + "=" + strconv.Quote(p.value)
+	}
+	return p.key + "=" + p.value
+}
+
+// needsMetaQuote reports whether a metadata value containing the rune must be quoted, so that
+// the comment stays on one line and the value is parsed back as is.
+func needsMetaQuote(r rune) bool {
+	return r == ' ' || r == '"' || !unicode.IsPrint(r)
+}
+
+// parseMetaComment returns the pairs of a metadata comment, and false if the comment isn't one.
+func parseMetaComment(token string) ([]metaPair, bool) {
+	text := strings.TrimSpace(strings.TrimPrefix(token, "#"))
+	if !strings.HasPrefix(text, metaPrefix) {
+		return nil, false
+	}
+	text = text[len(metaPrefix):]
+	var pairs []metaPair
+	for {
+		text = strings.TrimLeft(text, " \t")
+		if text == "" {
+			return pairs, true
+		}
+		i := strings.IndexAny(text, "= \t")
+		if i < 0 || text[i] != '=' {
+			if i < 0 {
+				i = len(text)
+			}
+			pairs = append(pairs, metaPair{key: text[:i], bare: true})
+			text = text[i:]
+			continue
+		}
+		key := text[:i]
+		text = text[i+1:]
+		if quoted, err := strconv.QuotedPrefix(text); err == nil {
+			value, _ := strconv.Unquote(quoted)
+			pairs = append(pairs, metaPair{key: key, value: value})
+			text = text[len(quoted):]
+			continue
+		}
+		j := strings.IndexAny(text, " \t")
+		if j < 0 {
+			j = len(text)
+		}
+		pairs = append(pairs, metaPair{key: key, value: text[:j]})
+		text = text[j:]
+	}
+}
+
+// formatMetaComment returns the comment holding the given metadata pairs.
+func formatMetaComment(pairs []metaPair) string {
+	words := []string{"#", metaPrefix}
+	for _, p := range pairs {
+		words = append(words, p.String())
+	}
+	return strings.Join(words, " ")
+}
+
+// checkMetaKey returns an error if a metadata key can't be written in a comment.
+func checkMetaKey(key string) error {
+	if key == "" || strings.ContainsRune(key, '=') || strings.IndexFunc(key, needsMetaQuote) >= 0 {
+		return fmt.Errorf("invalid metadata key %q", key)
+	}
+	return nil
+}
+
+func cmdPrintMeta(opts *Options, env CmdEnvironment) (*build.File, error) {
+	var pairs []metaPair
+	for _, com := range env.Rule.Call.Comments.Before {
+		if p, ok := parseMetaComment(com.Token); ok {
+			pairs = append(pairs, p...)
+		}
+	}
+	if len(env.Args) == 0 {
+		var words []string
+		for _, p := range pairs {
+			words = append(words, p.String())
+		}
+		env.output.Fields = []*apipb.Output_Record_Field{
+			{Value: &apipb.Output_Record_Field_Text{Text: strings.Join(words, " ")}},
+		}
+		return nil, nil
+	}
+	key := env.Args[0]
+	for _, p := range pairs {
+		if p.key == key && !p.bare {
+			env.output.Fields = []*apipb.Output_Record_Field{
+				{Value: &apipb.Output_Record_Field_Text{Text: p.value}},
+			}
+			return nil, nil
+		}
+	}
+	fmt.Fprintf(opts.ErrWriter, "rule \"//%s:%s\" has no metadata \"%s\"\n", env.Pkg, env.Rule.Name(), key)
+	env.output.Fields = []*apipb.Output_Record_Field{
+		{Value: &apipb.Output_Record_Field_Error{Error: apipb.Output_Record_Field_MISSING}},
+	}
+	return nil, nil
+}
+
+func cmdSetMeta(opts *Options, env CmdEnvironment) (*build.File, error) {
+	key, value := env.Args[0], env.Args[1]
+	if err := checkMetaKey(key); err != nil {
+		return nil, err
+	}
+	comments := env.Rule.Call.Comments.Before
+	found, changed := false, false
+	last := -1
+	for i, com := range comments {
+		pairs, ok := parseMetaComment(com.Token)
+		if !ok {
+			continue
+		}
+		last = i
+		for j, p := range pairs {
+			if p.key != key {
+				continue
+			}
+			found = true
+			if p.bare || p.value != value {
+				pairs[j] = metaPair{key: key, value: value}
+				comments[i].Token = formatMetaComment(pairs)
+				changed = true
+			}
+		}
+	}
+	switch {
+	case found && !changed:
+		return nil, nil
+	case found:
+	case last >= 0:
+		// Add the key to the last metadata comment.
+		pairs, _ := parseMetaComment(comments[last].Token)
+		comments[last].Token = formatMetaComment(append(pairs, metaPair{key: key, value: value}))
+	default:
+		env.Rule.Call.Comments.Before = append(comments, build.Comment{
+			Token: formatMetaComment([]metaPair{{key: key, value: value}}),
+		})
+	}
+	return env.File, nil
+}
+
+func cmdRemoveMeta(opts *Options, env CmdEnvironment) (*build.File, error) {
+	key := env.Args[0]
+	var comments []build.Comment
+	changed := false
+	for _, com := range env.Rule.Call.Comments.Before {
+		pairs, ok := parseMetaComment(com.Token)
+		if !ok {
+			comments = append(comments, com)
+			continue
+		}
+		var kept []metaPair
+		for _, p := range pairs {
+			if p.key != key {
+				kept = append(kept, p)
+			}
+		}
+		if len(kept) == len(pairs) {
+			comments = append(comments, com)
+			continue
+		}
+		changed = true
+		if len(kept) > 0 {
+			com.Token = formatMetaComment(kept)
+			comments = append(comments, com)
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	env.Rule.Call.Comments.Before = comments
+	return env.File, nil
+}
+
 func cmdDelete(opts *Options, env CmdEnvironment) (*build.File, error) {
 	return DeleteRule(env.File, env.Rule), nil
 }
@@ -239,7 +523,7 @@ func cmdMove(opts *Options, env CmdEnvironment) (*build.File, error) {
 func cmdNew(opts *Options, env CmdEnvironment) (*build.File, error) {
 	kind := env.Args[0]
 	name := env.Args[1]
-	addAtEOF, insertionIndex, err := findInsertionIndex(env)
+	strategy, err := findInsertionStrategy(opts, env)
 	if err != nil {
 		return nil, err
 	}
@@ -252,33 +536,121 @@ func cmdNew(opts *Options, env CmdEnvironment) (*build.File, error) {
 	rule := &build.Rule{Call: call, ImplicitName: ""}
 	rule.SetAttr("name", &build.StringExpr{Value: name})
 
-	if addAtEOF {
-		env.File.Stmt = InsertAfterLastOfSameKind(env.File.Stmt, rule.Call)
-	} else {
-		env.File.Stmt = InsertAfter(insertionIndex, env.File.Stmt, call)
+	InsertRule(env.File, call, strategy)
+	return env.File, nil
+}
+
+// cmdNewFromTemplate creates a target from one of the rule templates of the options, with the
+// load statements it needs.
+func cmdNewFromTemplate(opts *Options, env CmdEnvironment) (*build.File, error) {
+	t, ok := opts.Templates[env.Args[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown template '%s'", env.Args[0])
+	}
+	values, err := templateValues(env.Args[1], env.Pkg, env.Args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := NewRuleFromTemplate(env.File, t, values, opts.NewRulePlacement); err != nil {
+		return nil, err
 	}
 	return env.File, nil
 }
 
-// findInsertionIndex is used by cmdNew to find the place at which to insert the new rule.
-func findInsertionIndex(env CmdEnvironment) (bool, int, error) {
-	if len(env.Args) < 4 {
-		return true, 0, nil
+// aliasAttributes are the attributes of a rule that an alias replacing it keeps.
+var aliasAttributes = []string{"testonly", "tags", "visibility"}
+
+// cmdMakeAlias replaces a rule moved to another package with an alias pointing at its new
+// label, so that its dependents keep working until they're updated. The alias keeps the
+// visibility of the rule and is deprecated. Existing aliases are updated and missing ones are
+// added.
+func cmdMakeAlias(opts *Options, env CmdEnvironment) (*build.File, error) {
+	old := labels.ParseRelative(env.Args[0], env.Pkg)
+	if old.Repository != "" || old.Package != env.Pkg {
+		return nil, fmt.Errorf("'%s' is not in the package '%s'", env.Args[0], env.Pkg)
+	}
+	actual := labels.ParseRelative(env.Args[1], env.Pkg)
+	if actual == old {
+		return nil, fmt.Errorf("'%s' can't be an alias of itself", env.Args[0])
+	}
+	deprecation := "Moved to " + actual.Format()
+	if len(env.Args) > 2 {
+		deprecation = env.Args[2]
+	}
+
+	r := FindRuleByName(env.File, old.Target)
+	if r == nil {
+		call := &build.CallExpr{X: &build.Ident{Name: "alias"}}
+		r = &build.Rule{Call: call}
+		r.SetAttr("name", &build.StringExpr{Value: old.Target})
+		InsertRule(env.File, call, opts.NewRulePlacement)
+	} else if r.Kind() != "alias" {
+		var kept []build.Expr
+		for _, attr := range aliasAttributes {
+			if defn := r.AttrDefn(attr); defn != nil {
+				kept = append(kept, defn)
+			}
+		}
+		r.SetKind("alias")
+		r.Call.List = nil
+		r.ImplicitName = ""
+		r.SetAttr("name", &build.StringExpr{Value: old.Target})
+		r.Call.List = append(r.Call.List, kept...)
+	}
+	r.SetAttr("actual", &build.StringExpr{Value: ShortenLabel(actual.Format(), env.Pkg)})
+	r.SetAttr("deprecation", &build.StringExpr{Value: deprecation})
+	return env.File, nil
+}
+
+// packageGroupName returns the name of the package group given as the first argument of the
+// package_group commands. The commands on the groups of other packages of the main repository
+// run on these packages, see redirectToPackageGroup.
+func packageGroupName(env CmdEnvironment) (string, error) {
+	group := labels.ParseRelative(env.Args[0], env.Pkg)
+	if group.Repository != "" || group.Package != env.Pkg {
+		return "", fmt.Errorf("'%s' is not in the package '%s'", env.Args[0], env.Pkg)
+	}
+	return group.Target, nil
+}
+
+// cmdAddPackageToGroup grants packages access to what a package_group protects, by adding them
+// to its packages, or adding other package groups to its includes. The group is created if it's
+// missing.
+func cmdAddPackageToGroup(opts *Options, env CmdEnvironment) (*build.File, error) {
+	name, err := packageGroupName(env)
+	if err != nil {
+		return nil, err
 	}
+	if err := AddToPackageGroup(env.File, env.Pkg, name, env.Args[1:], opts.NewRulePlacement); err != nil {
+		return nil, err
+	}
+	return env.File, nil
+}
+
+// cmdRemovePackageFromGroup removes packages or included package groups from a package_group.
+func cmdRemovePackageFromGroup(opts *Options, env CmdEnvironment) (*build.File, error) {
+	name, err := packageGroupName(env)
+	if err != nil {
+		return nil, err
+	}
+	removed, err := RemoveFromPackageGroup(env.File, env.Pkg, name, env.Args[1:])
+	if err != nil || !removed {
+		return nil, err
+	}
+	return env.File, nil
+}
 
-	relativeToRuleName := env.Args[3]
-	ruleIdx, _ := IndexOfRuleByName(env.File, relativeToRuleName)
-	if ruleIdx == -1 {
-		return true, 0, nil
+// findInsertionStrategy is used by cmdNew to find the place at which to insert the new rule.
+func findInsertionStrategy(opts *Options, env CmdEnvironment) (InsertionStrategy, error) {
+	if len(env.Args) < 4 {
+		return opts.NewRulePlacement, nil
 	}
 
 	switch env.Args[2] {
-	case "before":
-		return false, ruleIdx - 1, nil
-	case "after":
-		return false, ruleIdx, nil
+	case "before", "after":
+		return RelativeInsertion{Name: env.Args[3], Before: env.Args[2] == "before", Fallback: opts.NewRulePlacement}, nil
 	default:
-		return true, 0, fmt.Errorf("Unknown relative operator '%s'; allowed: 'before', 'after'", env.Args[1])
+		return nil, fmt.Errorf("Unknown relative operator '%s'; allowed: 'before', 'after'", env.Args[1])
 	}
 }
 
@@ -442,7 +814,7 @@ func cmdRemove(opts *Options, env CmdEnvironment) (*build.File, error) {
 		fixed := false
 		for _, key := range attrKeysForPattern(env.Rule, env.Args[0]) {
 			for _, val := range env.Args[1:] {
-				ListAttributeDelete(env.Rule, key, val, env.Pkg)
+				ListAttributeDeleteWithVariables(env.Rule, key, val, env.Pkg, &env.Vars)
 				fixed = true
 			}
 			ResolveAttr(env.Rule, key, env.Pkg)
@@ -480,6 +852,109 @@ func cmdRemoveIfEqual(opts *Options, env CmdEnvironment) (*build.File, error) {
 	return env.File, nil
 }
 
+// cmdQuery is the function of the query commands (exists, count and attr_equals), which don't
+// modify the rules they're run on. They're evaluated by evalQueries instead, which also sees the
+// targets that don't exist.
+func cmdQuery(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return nil, nil
+}
+
+// attrEquals reports whether the attribute of a rule has the given value: a string (compared as a
+// label relative to pkg), an identifier or a literal, or a list of strings with the given elements
+// in order. The kind of the rule can be compared too.
+func attrEquals(r *build.Rule, attr string, values []string, pkg string) bool {
+	if attr == "kind" {
+		return len(values) == 1 && r.Kind() == values[0]
+	}
+	switch value := r.Attr(attr).(type) {
+	case *build.StringExpr:
+		return len(values) == 1 && labels.Equal(value.Value, values[0], pkg)
+	case *build.Ident:
+		return len(values) == 1 && value.Name == values[0]
+	case *build.LiteralExpr:
+		return len(values) == 1 && value.Token == values[0]
+	case *build.ListExpr:
+		if len(value.List) != len(values) {
+			return false
+		}
+		for i, elem := range value.List {
+			str, ok := elem.(*build.StringExpr)
+			if !ok || !labels.Equal(str.Value, values[i], pkg) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// queryResult is the outcome of the query commands run on one or several files.
+type queryResult struct {
+	counted bool // whether a count command was run
+	count   int  // the number of rules matched by the count commands
+	no      bool // whether an exists or attr_equals command answered no
+}
+
+// merge adds the outcome of the query commands run on another file.
+func (q *queryResult) merge(other queryResult) {
+	q.counted = q.counted || other.counted
+	q.count += other.count
+	q.no = q.no || other.no
+}
+
+// answeredNo reports whether a query answered no, including a count of zero rules.
+func (q queryResult) answeredNo() bool {
+	return q.no || (q.counted && q.count == 0)
+}
+
+// evalQueries evaluates the query commands among the given ones on the rules matched by a target
+// of the package pkg, which are empty if the target doesn't exist.
+func evalQueries(commands []command, rules []*build.Rule, pkg string) queryResult {
+	var result queryResult
+	for _, cmd := range commands {
+		switch cmd.tokens[0] {
+		case "exists":
+			result.no = result.no || len(rules) == 0
+		case "count":
+			result.counted = true
+			result.count += len(rules)
+		case "attr_equals":
+			result.no = result.no || len(rules) == 0
+			for _, r := range rules {
+				if !attrEquals(r, cmd.tokens[1], cmd.tokens[2:], pkg) {
+					result.no = true
+				}
+			}
+		}
+	}
+	return result
+}
+
+// missingFileQueries evaluates the commands on the targets of a missing BUILD file, ok is false
+// unless they're all query commands.
+func missingFileQueries(commands []commandsForTarget) (result queryResult, ok bool) {
+	for _, cft := range commands {
+		if !onlyQueries(cft.commands) {
+			return queryResult{}, false
+		}
+		result.merge(evalQueries(cft.commands, nil, ""))
+	}
+	return result, true
+}
+
+// onlyQueries reports whether all the commands are query commands, which answer no rather than
+// fail if their target doesn't exist.
+func onlyQueries(commands []command) bool {
+	for _, cmd := range commands {
+		switch cmd.tokens[0] {
+		case "exists", "count", "attr_equals":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 func cmdRemoveComment(opts *Options, env CmdEnvironment) (*build.File, error) {
 	switch len(env.Args) {
 	case 0: // Remove comment attached to rule
@@ -580,6 +1055,59 @@ func cmdSetIfAbsent(opts *Options, env CmdEnvironment) (*build.File, error) {
 	return env.File, nil
 }
 
+// cmdSetDefault sets the default value of a parameter of a function defined in the file,
+// e.g. a macro in a .bzl file.
+func cmdSetDefault(opts *Options, env CmdEnvironment) (*build.File, error) {
+	function := env.Args[0]
+	param := env.Args[1]
+	var def *build.DefStmt
+	for _, stmt := range env.File.Stmt {
+		if d, ok := stmt.(*build.DefStmt); ok && d.Name == function {
+			def = d
+		}
+	}
+	if def == nil {
+		return nil, fmt.Errorf("function '%s' not found", function)
+	}
+	for _, p := range def.Params {
+		switch p := p.(type) {
+		case *build.AssignExpr:
+			if paramName(p.LHS) == param {
+				p.RHS = getAttrValueExpr(param, env.Args[2:], env)
+				return env.File, nil
+			}
+		default:
+			if paramName(p) == param {
+				return nil, fmt.Errorf("parameter '%s' of function '%s' has no default value", param, function)
+			}
+		}
+	}
+	return nil, fmt.Errorf("parameter '%s' not found in function '%s'", param, function)
+}
+
+// paramName returns the name of a function parameter without a default value,
+// or an empty string if it's not an identifier (e.g. *args).
+func paramName(p build.Expr) string {
+	switch p := p.(type) {
+	case *build.Ident:
+		return p.Name
+	case *build.TypedIdent:
+		return p.Ident.Name
+	}
+	return ""
+}
+
+// cmdSetConstant sets the value of a top-level variable of the file.
+func cmdSetConstant(opts *Options, env CmdEnvironment) (*build.File, error) {
+	name := env.Args[0]
+	as, ok := getGlobalVariables(env.File.Stmt)[name]
+	if !ok {
+		return nil, fmt.Errorf("variable '%s' not found", name)
+	}
+	as.RHS = getAttrValueExpr(name, env.Args[1:], env)
+	return env.File, nil
+}
+
 func getAttrValueExpr(attr string, args []string, env CmdEnvironment) build.Expr {
 	switch {
 	case attr == "kind":
@@ -767,10 +1295,8 @@ func cmdDictReplaceIfEqual(opts *Options, env CmdEnvironment) (*build.File, erro
 		return env.File, nil
 	}
 
+	// A missing key isn't an error, so that the command can be run again after a migration.
 	prev := DictionaryGet(dictAttr, key)
-	if prev == nil {
-		return nil, fmt.Errorf("key '%s' not found in dict", key)
-	}
 	if e, ok := prev.(*build.StringExpr); ok {
 		if labels.Equal(e.Value, oldV, env.Pkg) {
 			DictionarySet(dictAttr, key, getStringExpr(newV, env.Pkg))
@@ -783,6 +1309,38 @@ func cmdDictReplaceIfEqual(opts *Options, env CmdEnvironment) (*build.File, erro
 	return env.File, nil
 }
 
+// cmdDictRenameKey renames a key in a dict, keeping its value, its position and its comments.
+// Nothing is changed if the dict has no such key, e.g. because it has already been renamed.
+func cmdDictRenameKey(opts *Options, env CmdEnvironment) (*build.File, error) {
+	attr := env.Args[0]
+	oldKey, newKey := getStringValue(env.Args[1]), getStringValue(env.Args[2])
+
+	dict, ok := env.Rule.Attr(attr).(*build.DictExpr)
+	if !ok || oldKey == newKey {
+		return env.File, nil
+	}
+	var key *build.StringExpr
+	exists := false
+	for _, kv := range dict.List {
+		if k, ok := kv.Key.(*build.StringExpr); ok {
+			switch k.Value {
+			case oldKey:
+				key = k
+			case newKey:
+				exists = true
+			}
+		}
+	}
+	if key == nil {
+		return env.File, nil
+	}
+	if exists {
+		return nil, fmt.Errorf("key '%s' already exists in dict", newKey)
+	}
+	key.Value = newKey
+	return env.File, nil
+}
+
 // cmdDictListAdd adds an item to a list in a dict.
 func cmdDictListAdd(opts *Options, env CmdEnvironment) (*build.File, error) {
 	attr := env.Args[0]
@@ -829,60 +1387,389 @@ func copyAttributeBetweenRules(env CmdEnvironment, attrName string, from string)
 	return env.File, nil
 }
 
-func cmdUseRepoAdd(opts *Options, env CmdEnvironment) (*build.File, error) {
-	return cmdImplUseRepo(env, "use_repo_add")
-}
-
-func cmdUseRepoRemove(opts *Options, env CmdEnvironment) (*build.File, error) {
-	return cmdImplUseRepo(env, "use_repo_remove")
+func cmdUnionAttr(opts *Options, env CmdEnvironment) (*build.File, error) {
+	attr := env.Args[0]
+	values, err := setOperationSource(opts, env, env.Args[1:])
+	if err != nil {
+		return nil, err
+	}
+	for _, val := range values {
+		AddValueToListAttribute(env.Rule, attr, env.Pkg, getStringExpr(val, env.Pkg), &env.Vars)
+	}
+	ResolveAttr(env.Rule, attr, env.Pkg)
+	return env.File, nil
 }
 
-func cmdImplUseRepo(env CmdEnvironment, mode string) (*build.File, error) {
-	if env.File.Type != build.TypeModule {
-		return nil, fmt.Errorf("%s: only applies to MODULE.bazel files", mode)
+func cmdIntersectAttr(opts *Options, env CmdEnvironment) (*build.File, error) {
+	attr := env.Args[0]
+	values, err := setOperationSource(opts, env, env.Args[1:])
+	if err != nil {
+		return nil, err
 	}
-
-	dev := false
-	args := env.Args
-	if env.Args[0] == "dev" && isExtensionLabel(env.Args[1]) {
-		dev = true
-		args = env.Args[1:]
+	var toRemove []string
+	for _, li := range allListsIncludingSelects(env.Rule.Attr(attr)) {
+		for _, elem := range li.List {
+			str, ok := elem.(*build.StringExpr)
+			if ok && !containsLabel(values, str.Value, env.Pkg) {
+				toRemove = append(toRemove, str.Value)
+			}
+		}
 	}
+	return removeListValues(env, attr, toRemove), nil
+}
 
-	var proxies []string
-	var repos []string
-	if isExtensionLabel(args[0]) {
-		extBzlFile := args[0]
-		extName := args[1]
-
-		proxies = bzlmod.Proxies(env.File, extBzlFile, extName, dev)
-		if len(proxies) == 0 {
-			return nil, fmt.Errorf("%s: no use_extension assignment found for extension %q defined in %q", mode, extName, extBzlFile)
-		}
-		repos = args[2:]
-	} else {
-		proxy := args[0]
+func cmdSubtractAttr(opts *Options, env CmdEnvironment) (*build.File, error) {
+	attr := env.Args[0]
+	values, err := setOperationSource(opts, env, env.Args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return removeListValues(env, attr, values), nil
+}
 
-		proxies = bzlmod.AllProxies(env.File, proxy)
-		if len(proxies) == 0 {
-			return nil, fmt.Errorf("%s: no use_extension assignment to variable %q found", mode, proxy)
+// removeListValues removes the values from the list attribute, and the attribute itself if it
+// ends up empty. It returns nil if nothing was removed.
+func removeListValues(env CmdEnvironment, attr string, values []string) *build.File {
+	removed := false
+	for _, val := range values {
+		if ListAttributeDelete(env.Rule, attr, val, env.Pkg) != nil {
+			removed = true
 		}
-		repos = args[1:]
 	}
-
-	useRepos := bzlmod.UseRepos(env.File, proxies)
-	if len(useRepos) == 0 {
-		var newUseRepo *build.CallExpr
-		env.File, newUseRepo = bzlmod.NewUseRepo(env.File, proxies)
-		useRepos = []*build.CallExpr{newUseRepo}
+	if !removed {
+		return nil
 	}
-
-	if mode == "use_repo_add" {
-		bzlmod.AddRepoUsages(useRepos, repos...)
-	} else {
-		bzlmod.RemoveRepoUsages(useRepos, repos...)
+	ResolveAttr(env.Rule, attr, env.Pkg)
+	if listExpr, ok := env.Rule.Attr(attr).(*build.ListExpr); ok && len(listExpr.List) == 0 {
+		env.Rule.DelAttr(attr)
 	}
+	return env.File
+}
 
+func containsLabel(values []string, item, pkg string) bool {
+	for _, val := range values {
+		if labels.Equal(val, item, pkg) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOperationSource returns the values of the second operand of a set operation, which is
+// either a list literal (e.g. '[":a", ":b"]'), an attribute of the same rule or a top-level
+// variable of the file, or a rule and one of its attributes. Labels from a rule in another
+// package are returned in absolute form.
+func setOperationSource(opts *Options, env CmdEnvironment, args []string) ([]string, error) {
+	var expr build.Expr
+	pkg := env.Pkg
+	switch {
+	case len(args) == 1 && strings.HasPrefix(args[0], "["):
+		f, err := build.ParseBuild("", []byte(args[0]))
+		if err != nil || len(f.Stmt) != 1 {
+			return nil, fmt.Errorf("could not parse list %s", args[0])
+		}
+		expr = f.Stmt[0]
+	case len(args) == 1:
+		expr = env.Rule.Attr(args[0])
+		if expr == nil {
+			if assign, ok := getGlobalVariables(env.File.Stmt)[args[0]]; ok {
+				expr = assign.RHS
+			}
+		}
+		if expr == nil {
+			return nil, fmt.Errorf("no attribute or variable %q found", args[0])
+		}
+	default:
+		rule, rulePkg, err := findSourceRule(opts, env, args[0])
+		if err != nil {
+			return nil, err
+		}
+		expr = rule.Attr(args[1])
+		if expr == nil {
+			return nil, fmt.Errorf("rule '%s' does not have attribute '%s'", args[0], args[1])
+		}
+		pkg = rulePkg
+	}
+
+	if _, ok := expr.(*build.ListExpr); !ok {
+		return nil, fmt.Errorf("%s is not a list", build.FormatString(expr))
+	}
+	var values []string
+	for _, elem := range expr.(*build.ListExpr).List {
+		str, ok := elem.(*build.StringExpr)
+		if !ok {
+			return nil, fmt.Errorf("list element %s is not a string", build.FormatString(elem))
+		}
+		val := str.Value
+		if pkg != env.Pkg && tables.IsLabelArg[args[len(args)-1]] {
+			val = labels.ParseRelative(val, pkg).Format()
+		}
+		values = append(values, val)
+	}
+	return values, nil
+}
+
+// findSourceRule finds the rule with the given label, relative to the package of env. Rules in
+// other packages are read from their BUILD file as it is on disk.
+func findSourceRule(opts *Options, env CmdEnvironment, target string) (*build.Rule, string, error) {
+	label := labels.ParseRelative(target, env.Pkg)
+	if label.Repository == "" && label.Package == env.Pkg {
+		if rule := FindRuleByName(env.File, label.Target); rule != nil {
+			return rule, env.Pkg, nil
+		}
+		return nil, "", fmt.Errorf("could not find rule '%s'", target)
+	}
+	buildFile, _, pkg, name := interpretLabel(opts.cache, opts.RootDir, label.Format())
+	data, _, err := file.ReadFile(buildFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read %s: %v", buildFile, err)
+	}
+	f, err := build.ParseBuild(buildFile, data)
+	if err != nil {
+		return nil, "", err
+	}
+	rule := FindRuleByName(f, name)
+	if rule == nil {
+		return nil, "", fmt.Errorf("could not find rule '%s'", target)
+	}
+	return rule, pkg, nil
+}
+
+func cmdUseRepoAdd(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return cmdImplUseRepo(opts, env, "use_repo_add")
+}
+
+func cmdUseRepoRemove(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return cmdImplUseRepo(opts, env, "use_repo_remove")
+}
+
+// extensionProxies returns the proxies of the extension usage given by the first arguments of
+// the command, either `[dev] <extension .bzl file> <extension name>` or
+// `<use_extension variable name>`, and the rest of the arguments.
+func extensionProxies(env CmdEnvironment, mode string) (proxies, rest []string, err error) {
+	dev := false
+	args := env.Args
+	if env.Args[0] == "dev" && isExtensionLabel(env.Args[1]) {
+		dev = true
+		args = env.Args[1:]
+	}
+
+	if isExtensionLabel(args[0]) {
+		extBzlFile := args[0]
+		extName := args[1]
+
+		proxies = bzlmod.Proxies(env.File, extBzlFile, extName, dev)
+		if len(proxies) == 0 {
+			return nil, nil, fmt.Errorf("%s: no use_extension assignment found for extension %q defined in %q", mode, extName, extBzlFile)
+		}
+		return proxies, args[2:], nil
+	}
+	proxy := args[0]
+
+	proxies = bzlmod.AllProxies(env.File, proxy)
+	if len(proxies) == 0 {
+		return nil, nil, fmt.Errorf("%s: no use_extension assignment to variable %q found", mode, proxy)
+	}
+	return proxies, args[1:], nil
+}
+
+func cmdImplUseRepo(opts *Options, env CmdEnvironment, mode string) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("%s: only applies to MODULE.bazel files", mode)
+	}
+
+	proxies, repos, err := extensionProxies(env, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	useRepos := bzlmod.UseRepos(env.File, proxies)
+	if len(useRepos) == 0 {
+		var newUseRepo *build.CallExpr
+		env.File, newUseRepo = bzlmod.NewUseRepo(env.File, proxies)
+		useRepos = []*build.CallExpr{newUseRepo}
+	}
+
+	if mode == "use_repo_add" {
+		err = bzlmod.AddRepoUsagesInFile(env.File, useRepos, repos...)
+	} else {
+		err = bzlmod.RemoveRepoUsagesInFile(env.File, useRepos, repos...)
+	}
+	if err != nil {
+		// The repos used by spread arguments may be incomplete, the edit is applied regardless.
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(opts.ErrWriter, "%s: warning: %s\n", mode, line)
+		}
+	}
+
+	return env.File, nil
+}
+
+func cmdInjectRepoAdd(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return cmdImplRepoMapping(env, "inject_repo_add", bzlmod.InjectRepo, true)
+}
+
+func cmdInjectRepoRemove(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return cmdImplRepoMapping(env, "inject_repo_remove", bzlmod.InjectRepo, false)
+}
+
+func cmdOverrideRepoAdd(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return cmdImplRepoMapping(env, "override_repo_add", bzlmod.OverrideRepo, true)
+}
+
+func cmdOverrideRepoRemove(opts *Options, env CmdEnvironment) (*build.File, error) {
+	return cmdImplRepoMapping(env, "override_repo_remove", bzlmod.OverrideRepo, false)
+}
+
+// cmdImplRepoMapping adds or removes repository mappings of the inject_repo or override_repo
+// calls, depending on kind, of an extension usage. The calls are created and deleted as needed.
+func cmdImplRepoMapping(env CmdEnvironment, mode, kind string, add bool) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("%s: only applies to MODULE.bazel files", mode)
+	}
+
+	proxies, args, err := extensionProxies(env, mode)
+	if err != nil {
+		return nil, err
+	}
+	var mappings []bzlmod.RepoMapping
+	var names []string
+	for _, arg := range args {
+		m, err := bzlmod.ParseRepoMapping(arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", mode, err)
+		}
+		mappings = append(mappings, m)
+		names = append(names, m.Name)
+	}
+
+	calls := bzlmod.RepoMappingCalls(env.File, kind, proxies)
+	if !add {
+		if !bzlmod.RemoveRepoMappings(env.File, calls, names...) {
+			return nil, nil
+		}
+		return env.File, nil
+	}
+	if len(calls) == 0 {
+		calls = []*build.CallExpr{bzlmod.NewRepoMappingCall(env.File, kind, proxies)}
+	}
+	if !bzlmod.AddRepoMappings(calls, mappings...) {
+		return nil, nil
+	}
+	return env.File, nil
+}
+
+func cmdUseRepoSync(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("use_repo_sync: only applies to MODULE.bazel files")
+	}
+	data, err := os.ReadFile(env.Args[0])
+	if err != nil {
+		return nil, fmt.Errorf("use_repo_sync: %v", err)
+	}
+	metadata, err := bzlmod.ParseExtensionMetadata(data)
+	if err != nil {
+		return nil, fmt.Errorf("use_repo_sync: parsing %s: %v", env.Args[0], err)
+	}
+	for _, m := range metadata {
+		env.File, err = bzlmod.SyncRepoUsages(env.File, m)
+		if err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(opts.ErrWriter, "use_repo_sync: warning: %s\n", line)
+			}
+		}
+	}
+	return env.File, nil
+}
+
+func cmdIsolateExtension(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("isolate_extension: only applies to MODULE.bazel files")
+	}
+	proxy, newProxy, repos := env.Args[0], env.Args[1], env.Args[2:]
+	f, _, err := bzlmod.IsolateUsage(env.File, proxy, newProxy, bzlmod.Tags(env.File, []string{proxy}), repos...)
+	if f == nil {
+		return nil, fmt.Errorf("isolate_extension: %v", err)
+	}
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(opts.ErrWriter, "isolate_extension: warning: %s\n", line)
+		}
+	}
+	return f, nil
+}
+
+func cmdUnisolateExtension(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("unisolate_extension: only applies to MODULE.bazel files")
+	}
+	f, err := bzlmod.UnisolateUsage(env.File, env.Args[0])
+	if f == nil {
+		return nil, fmt.Errorf("unisolate_extension: %v", err)
+	}
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(opts.ErrWriter, "unisolate_extension: warning: %s\n", line)
+		}
+	}
+	return f, nil
+}
+
+func cmdSetModuleVersion(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("set_module_version: only applies to MODULE.bazel files")
+	}
+	if err := bzlmod.SetModuleVersion(env.File, env.Args[0]); err != nil {
+		return nil, fmt.Errorf("set_module_version: %v", err)
+	}
+	return env.File, nil
+}
+
+func cmdSetCompatibilityLevel(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("set_compatibility_level: only applies to MODULE.bazel files")
+	}
+	level, err := strconv.Atoi(env.Args[0])
+	if err != nil {
+		return nil, fmt.Errorf("set_compatibility_level: invalid level %q", env.Args[0])
+	}
+	if err := bzlmod.SetCompatibilityLevel(env.File, level); err != nil {
+		return nil, fmt.Errorf("set_compatibility_level: %v", err)
+	}
+	return env.File, nil
+}
+
+func cmdSetMaxCompatibilityLevel(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("set_max_compatibility_level: only applies to MODULE.bazel files")
+	}
+	level, err := strconv.Atoi(env.Args[1])
+	if err != nil {
+		return nil, fmt.Errorf("set_max_compatibility_level: invalid level %q", env.Args[1])
+	}
+	if err := bzlmod.SetMaxCompatibilityLevel(env.File, env.Args[0], level); err != nil {
+		return nil, fmt.Errorf("set_max_compatibility_level: %v", err)
+	}
+	return env.File, nil
+}
+
+func cmdBazelCompatibilityAdd(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("bazel_compatibility_add: only applies to MODULE.bazel files")
+	}
+	if err := bzlmod.AddBazelCompatibility(env.File, env.Args...); err != nil {
+		return nil, fmt.Errorf("bazel_compatibility_add: %v", err)
+	}
+	return env.File, nil
+}
+
+func cmdBazelCompatibilityRemove(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if env.File.Type != build.TypeModule {
+		return nil, fmt.Errorf("bazel_compatibility_remove: only applies to MODULE.bazel files")
+	}
+	if err := bzlmod.RemoveBazelCompatibility(env.File, env.Args...); err != nil {
+		return nil, fmt.Errorf("bazel_compatibility_remove: %v", err)
+	}
 	return env.File, nil
 }
 
@@ -898,6 +1785,9 @@ func isExtensionLabel(arg string) bool {
 }
 
 func cmdFix(opts *Options, env CmdEnvironment) (*build.File, error) {
+	if err := CheckFixes(env.Args); err != nil {
+		return nil, fmt.Errorf("fix: %v", err)
+	}
 	// Fix the whole file
 	if env.Rule.Kind() == "package" {
 		return FixFile(env.File, env.Pkg, env.Args), nil
@@ -906,6 +1796,27 @@ func cmdFix(opts *Options, env CmdEnvironment) (*build.File, error) {
 	return FixRule(env.File, env.Pkg, env.Rule, env.Args), nil
 }
 
+func cmdNormalizeLabels(opts *Options, env CmdEnvironment) (*build.File, error) {
+	form := LabelFormShort
+	if len(env.Args) > 0 {
+		form = env.Args[0]
+	}
+	switch form {
+	case LabelFormShort:
+	case LabelFormLong:
+		if ShortenLabelsFlag {
+			// The formatter would shorten labels like //foo:foo again.
+			return nil, fmt.Errorf("normalize_labels: the %q form requires -shorten_labels=false", form)
+		}
+	default:
+		return nil, fmt.Errorf("normalize_labels: unknown label form %q, expected %q or %q", form, LabelFormShort, LabelFormLong)
+	}
+	if !NormalizeLabels(env.Rule, env.Pkg, form) {
+		return nil, nil
+	}
+	return env.File, nil
+}
+
 // CommandInfo provides a command function and info on incoming arguments.
 type CommandInfo struct {
 	Fn       func(*Options, CmdEnvironment) (*build.File, error)
@@ -918,44 +1829,191 @@ type CommandInfo struct {
 // AllCommands associates the command names with their function and number
 // of arguments.
 var AllCommands = map[string]CommandInfo{
-	"add":                   {cmdAdd, true, 2, -1, "<attr> <value(s)>"},
-	"new_load":              {cmdNewLoad, false, 1, -1, "<path> <[to=]from(s)>"},
-	"replace_load":          {cmdReplaceLoad, false, 1, -1, "<path> <[to=]symbol(s)>"},
-	"substitute_load":       {cmdSubstituteLoad, false, 2, 2, "<old_regexp> <new_template>"},
-	"comment":               {cmdComment, true, 1, 3, "<attr>? <value>? <comment>"},
-	"print_comment":         {cmdPrintComment, true, 0, 2, "<attr>? <value>?"},
-	"delete":                {cmdDelete, true, 0, 0, ""},
-	"fix":                   {cmdFix, true, 0, -1, "<fix(es)>?"},
-	"move":                  {cmdMove, true, 3, -1, "<old_attr> <new_attr> <value(s)>"},
-	"new":                   {cmdNew, false, 2, 4, "<rule_kind> <rule_name> [(before|after) <relative_rule_name>]"},
-	"print":                 {cmdPrint, true, 0, -1, "<attribute(s)>"},
-	"remove":                {cmdRemove, true, 1, -1, "<attr> <value(s)>"},
-	"remove_comment":        {cmdRemoveComment, true, 0, 2, "<attr>? <value>?"},
-	"remove_if_equal":       {cmdRemoveIfEqual, true, 2, 2, "<attr> <value>"},
-	"rename":                {cmdRename, true, 2, 2, "<old_attr> <new_attr>"},
-	"replace":               {cmdReplace, true, 3, 3, "<attr> <old_value> <new_value>"},
-	"substitute":            {cmdSubstitute, true, 3, 3, "<attr> <old_regexp> <new_template>"},
-	"set":                   {cmdSet, true, 1, -1, "<attr> <value(s)>"},
-	"set_if_absent":         {cmdSetIfAbsent, true, 1, -1, "<attr> <value(s)>"},
-	"set_select":            {cmdSetSelect, true, 1, -1, "<attr> <key_1> <value_1> <key_n> <value_n>"},
-	"copy":                  {cmdCopy, true, 2, 2, "<attr> <from_rule>"},
-	"copy_no_overwrite":     {cmdCopyNoOverwrite, true, 2, 2, "<attr> <from_rule>"},
-	"dict_add":              {cmdDictAdd, true, 2, -1, "<attr> <(key:value)(s)>"},
-	"dict_set":              {cmdDictSet, true, 2, -1, "<attr> <(key:value)(s)>"},
-	"dict_remove":           {cmdDictRemove, true, 2, -1, "<attr> <key(s)>"},
-	"dict_replace_if_equal": {cmdDictReplaceIfEqual, true, 4, 4, "<attr> <key> <old_value> <new_value>"},
-	"dict_list_add":         {cmdDictListAdd, true, 3, -1, "<attr> <key> <value(s)>"},
-	"use_repo_add":          {cmdUseRepoAdd, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <repo(s)>"},
-	"use_repo_remove":       {cmdUseRepoRemove, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <repo(s)>"},
-	"format":                {cmdFormat, false, 0, 0, ""},
+	"add":                         {cmdAdd, true, 2, -1, "<attr> <value(s)>"},
+	"new_load":                    {cmdNewLoad, false, 1, -1, "<path> <[to=]from(s)>"},
+	"replace_load":                {cmdReplaceLoad, false, 1, -1, "<path> <[to=]symbol(s)>"},
+	"substitute_load":             {cmdSubstituteLoad, false, 2, 2, "<old_regexp> <new_template>"},
+	"comment":                     {cmdComment, true, 1, 3, "<attr>? <value>? <comment>"},
+	"print_comment":               {cmdPrintComment, true, 0, 2, "<attr>? <value>?"},
+	"print_blame":                 {cmdPrintBlame, true, 1, 1, "<attr>"},
+	"print_meta":                  {cmdPrintMeta, true, 0, 1, "<key>?"},
+	"set_meta":                    {cmdSetMeta, true, 2, 2, "<key> <value>"},
+	"remove_meta":                 {cmdRemoveMeta, true, 1, 1, "<key>"},
+	"delete":                      {cmdDelete, true, 0, 0, ""},
+	"fix":                         {cmdFix, true, 0, -1, "<fix(es)>?"},
+	"normalize_labels":            {cmdNormalizeLabels, true, 0, 1, "(short|long)?"},
+	"move":                        {cmdMove, true, 3, -1, "<old_attr> <new_attr> <value(s)>"},
+	"new":                         {cmdNew, false, 2, 4, "<rule_kind> <rule_name> [(before|after) <relative_rule_name>]"},
+	"new_from_template":           {cmdNewFromTemplate, false, 2, -1, "<template> <name> [<placeholder>=<value>...]"},
+	"make_alias":                  {cmdMakeAlias, false, 2, 3, "<old_label> <new_label> <deprecation>?"},
+	"add_package_to_group":        {cmdAddPackageToGroup, false, 2, -1, "<group_label> <package(s)|group_label(s)>"},
+	"remove_package_from_group":   {cmdRemovePackageFromGroup, false, 2, -1, "<group_label> <package(s)|group_label(s)>"},
+	"print":                       {cmdPrint, true, 0, -1, "<attribute(s)>"},
+	"exists":                      {cmdQuery, true, 0, 0, ""},
+	"count":                       {cmdQuery, true, 0, 0, ""},
+	"attr_equals":                 {cmdQuery, true, 1, -1, "<attr> <value(s)>"},
+	"remove":                      {cmdRemove, true, 1, -1, "<attr> <value(s)>"},
+	"remove_comment":              {cmdRemoveComment, true, 0, 2, "<attr>? <value>?"},
+	"remove_if_equal":             {cmdRemoveIfEqual, true, 2, 2, "<attr> <value>"},
+	"rename":                      {cmdRename, true, 2, 2, "<old_attr> <new_attr>"},
+	"replace":                     {cmdReplace, true, 3, 3, "<attr> <old_value> <new_value>"},
+	"substitute":                  {cmdSubstitute, true, 3, 3, "<attr> <old_regexp> <new_template>"},
+	"set":                         {cmdSet, true, 1, -1, "<attr> <value(s)>"},
+	"set_if_absent":               {cmdSetIfAbsent, true, 1, -1, "<attr> <value(s)>"},
+	"set_select":                  {cmdSetSelect, true, 1, -1, "<attr> <key_1> <value_1> <key_n> <value_n>"},
+	"set_default":                 {cmdSetDefault, false, 2, -1, "<function> <param> <value(s)>"},
+	"set_constant":                {cmdSetConstant, false, 1, -1, "<variable> <value(s)>"},
+	"copy":                        {cmdCopy, true, 2, 2, "<attr> <from_rule>"},
+	"copy_no_overwrite":           {cmdCopyNoOverwrite, true, 2, 2, "<attr> <from_rule>"},
+	"union_attr":                  {cmdUnionAttr, true, 2, 3, "<attr> <list>|<other_attr>|<from_rule> <other_attr>"},
+	"intersect_attr":              {cmdIntersectAttr, true, 2, 3, "<attr> <list>|<other_attr>|<from_rule> <other_attr>"},
+	"subtract_attr":               {cmdSubtractAttr, true, 2, 3, "<attr> <list>|<other_attr>|<from_rule> <other_attr>"},
+	"dict_add":                    {cmdDictAdd, true, 2, -1, "<attr> <(key:value)(s)>"},
+	"dict_set":                    {cmdDictSet, true, 2, -1, "<attr> <(key:value)(s)>"},
+	"dict_remove":                 {cmdDictRemove, true, 2, -1, "<attr> <key(s)>"},
+	"dict_replace_if_equal":       {cmdDictReplaceIfEqual, true, 4, 4, "<attr> <key> <old_value> <new_value>"},
+	"dict_rename_key":             {cmdDictRenameKey, true, 3, 3, "<attr> <old_key> <new_key>"},
+	"dict_list_add":               {cmdDictListAdd, true, 3, -1, "<attr> <key> <value(s)>"},
+	"use_repo_add":                {cmdUseRepoAdd, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <repo(s)>"},
+	"use_repo_remove":             {cmdUseRepoRemove, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <repo(s)>"},
+	"use_repo_sync":               {cmdUseRepoSync, false, 1, 1, "<extension metadata .json file>"},
+	"isolate_extension":           {cmdIsolateExtension, false, 2, -1, "<use_extension variable name> <new variable name> <repo(s)>"},
+	"unisolate_extension":         {cmdUnisolateExtension, false, 1, 1, "<use_extension variable name>"},
+	"inject_repo_add":             {cmdInjectRepoAdd, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <[name=]repo(s)>"},
+	"inject_repo_remove":          {cmdInjectRepoRemove, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <name(s)>"},
+	"override_repo_add":           {cmdOverrideRepoAdd, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <[name=]repo(s)>"},
+	"override_repo_remove":        {cmdOverrideRepoRemove, false, 2, -1, "([dev] <extension .bzl file> <extension name>|<use_extension variable name>) <name(s)>"},
+	"set_module_version":          {cmdSetModuleVersion, false, 1, 1, "<version>"},
+	"set_compatibility_level":     {cmdSetCompatibilityLevel, false, 1, 1, "<level>"},
+	"set_max_compatibility_level": {cmdSetMaxCompatibilityLevel, false, 2, 2, "<module> <level>"},
+	"bazel_compatibility_add":     {cmdBazelCompatibilityAdd, false, 1, -1, "<constraint(s)>"},
+	"bazel_compatibility_remove":  {cmdBazelCompatibilityRemove, false, 1, -1, "<constraint(s)>"},
+	"format":                      {cmdFormat, false, 0, 0, ""},
+	"bazelrc_add":                 {cmdBazelrc, false, 2, -1, "<command[:config]> <flag(s)>"},
+	"bazelrc_remove":              {cmdBazelrc, false, 2, -1, "<command[:config]> <flag(s)>"},
 }
 
 var readonlyCommands = map[string]bool{
 	"print":         true,
+	"print_blame":   true,
 	"print_comment": true,
+	"print_meta":    true,
+	"exists":        true,
+	"count":         true,
+	"attr_equals":   true,
+}
+
+// frozenDirective is the comment that protects a rule or one of its attributes from the
+// commands modifying them, unless Options.OverrideFrozen is set.
+const frozenDirective = "buildozer: frozen"
+
+// isFrozen reports whether x has a comment containing the frozen directive, on the lines
+// before it or at the end of its line.
+func isFrozen(x build.Expr) bool {
+	comments := x.Comment()
+	for _, list := range [][]build.Comment{comments.Before, comments.Suffix} {
+		for _, com := range list {
+			if strings.Contains(strings.ToLower(com.Token), frozenDirective) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// modifiedAttrs returns the attributes of a rule modified by a command, or nil if the command
+// doesn't target specific attributes.
+func modifiedAttrs(tokens []string) []string {
+	args := tokens[1:]
+	switch tokens[0] {
+	case "move", "rename":
+		return args[:2]
+	case "comment":
+		// The comment is the last argument, it's added to the rule if it's the only one.
+		if len(args) > 1 {
+			return args[:1]
+		}
+	case "remove_comment":
+		if len(args) > 0 {
+			return args[:1]
+		}
+	case "add", "remove", "remove_if_equal", "replace", "substitute", "set", "set_if_absent",
+		"set_select", "copy", "copy_no_overwrite", "union_attr", "intersect_attr", "subtract_attr",
+		"dict_add", "dict_set", "dict_remove", "dict_replace_if_equal", "dict_rename_key",
+		"dict_list_add":
+		return args[:1]
+	}
+	return nil
+}
+
+// frozenReason returns why a command must not modify a rule: "rule" if the rule is frozen,
+// "attribute <name>" if one of the attributes it modifies is frozen, or "" if it can be run.
+func frozenReason(r *build.Rule, tokens []string) string {
+	if readonlyCommands[tokens[0]] {
+		return ""
+	}
+	if isFrozen(r.Call) {
+		return "rule"
+	}
+	for _, attr := range modifiedAttrs(tokens) {
+		if as := r.AttrDefn(attr); as != nil && (isFrozen(as) || isFrozen(as.RHS)) {
+			return "attribute " + attr
+		}
+	}
+	return ""
+}
+
+// inGeneratedRegion reports whether a rule starts within one of the generated regions of its file,
+// see build.GeneratedRegions. Buildozer doesn't modify such rules unless Options.Force is set.
+func inGeneratedRegion(regions []build.GeneratedRegion, r *build.Rule) bool {
+	start, _ := r.Call.Span()
+	return build.InGeneratedRegion(regions, start.Line)
+}
+
+// DefaultGeneratedMarkers are the comments that mark a file as generated by a tool, buildozer
+// refuses to modify such files unless Options.Force is set.
+var DefaultGeneratedMarkers = []string{"DO NOT EDIT", "generated by gazelle", "@generated"}
+
+// generatedMarker returns the first of the markers found in the comment lines at the top of the
+// file, before the first statement, or an empty string if the file isn't generated.
+func generatedMarker(data []byte, markers []string) string {
+	if markers == nil {
+		markers = DefaultGeneratedMarkers
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		for _, marker := range markers {
+			if marker != "" && strings.Contains(line, marker) {
+				return marker
+			}
+		}
+	}
+	return ""
+}
+
+// modifiesFile returns true if some of the commands for a file aren't read-only.
+func modifiesFile(commandsForFile commandsForFile) bool {
+	for _, cft := range commandsForFile.commands {
+		for _, command := range cft.commands {
+			if !readonlyCommands[command.tokens[0]] {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func expandTargets(f *build.File, rule string) ([]*build.Rule, error) {
+	if f.Type == build.TypeBzl && rule == "__pkg__" {
+		// .bzl files have no package declaration, the label refers to the file itself.
+		return nil, nil
+	}
 	if r := FindRuleByName(f, rule); r != nil {
 		return []*build.Rule{r}, nil
 	} else if r := FindExportedFile(f, rule); r != nil {
@@ -973,12 +2031,41 @@ func expandTargets(f *build.File, rule string) ([]*build.Rule, error) {
 				return []*build.Rule{r}, nil
 			}
 		} else {
-			return f.Rules(kind), nil
+			return rulesOfKind(f, kind), nil
 		}
 	}
 	return nil, fmt.Errorf("rule '%s' not found", rule)
 }
 
+// rulesOfKind returns the rules of the given kind in the file. In MODULE.bazel files, extension
+// tags also match "<extension>.<tag>" regardless of the name of the proxy they're called on, so
+// "%maven.install" finds the tags of `mvn = use_extension(..., "maven")`.
+func rulesOfKind(f *build.File, kind string) []*build.Rule {
+	if f.Type != build.TypeModule || !strings.Contains(kind, ".") {
+		return f.Rules(kind)
+	}
+	extensions := bzlmod.ExtensionNames(f)
+	var rules []*build.Rule
+	for _, r := range f.Rules("") {
+		if r.Kind() == kind {
+			rules = append(rules, r)
+			continue
+		}
+		dot, ok := r.Call.X.(*build.DotExpr)
+		if !ok {
+			continue
+		}
+		proxy, ok := dot.X.(*build.Ident)
+		if !ok {
+			continue
+		}
+		if ext, ok := extensions[proxy.Name]; ok && ext+"."+dot.Name == kind {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
 func filterRules(opts *Options, rules []*build.Rule) (result []*build.Rule) {
 	if len(opts.FilterRuleTypes) == 0 {
 		return rules
@@ -1114,6 +2201,69 @@ type rewriteResult struct {
 	errs     []error
 	modified bool
 	records  []*apipb.Output_Record
+	// generated is the generated-file marker found in the file if it was skipped.
+	generated string
+	// skipped are the messages about the commands skipped on frozen rules and attributes, and on
+	// the rules within generated regions.
+	skipped []string
+	// queries is the outcome of the query commands.
+	queries queryResult
+	// counts are the outcomes of the targets, only set with Options.DetailedExitCodes.
+	counts targetCounts
+	// patch is the unified diff of the changes with Options.GitRev.
+	patch []byte
+}
+
+// targetCounts counts the targets of the commands by outcome.
+type targetCounts struct {
+	changed   int // the commands changed the file
+	unchanged int // the target was found but the commands didn't change anything
+	notFound  int // the target or its file doesn't exist, or no rule matched it
+}
+
+func (c *targetCounts) merge(other targetCounts) {
+	c.changed += other.changed
+	c.unchanged += other.unchanged
+	c.notFound += other.notFound
+}
+
+// hasPerRuleCommands checks whether some of the commands apply to each rule of their target.
+func hasPerRuleCommands(commands []command) bool {
+	for _, cmd := range commands {
+		if AllCommands[cmd.tokens[0]].PerRule {
+			return true
+		}
+	}
+	return false
+}
+
+// targetSnapshot returns the parts of a file the commands of a target may change: nothing if
+// they're all read-only, the formatted rules of the target and the number of statements if they
+// all apply to each rule, and the whole formatted file otherwise. Only the commands applying to the
+// file pay for formatting it.
+func targetSnapshot(f *build.File, commands []command, rules []*build.Rule) []byte {
+	perRule := true
+	readonly := true
+	for _, cmd := range commands {
+		perRule = perRule && AllCommands[cmd.tokens[0]].PerRule
+		readonly = readonly && readonlyCommands[cmd.tokens[0]]
+	}
+	switch {
+	case readonly:
+		return []byte{}
+	case !perRule:
+		return build.Format(f)
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d\n", len(f.Stmt))
+	for _, r := range rules {
+		b.WriteString(build.FormatString(r.Call))
+		for _, c := range r.Call.Comments.Suffix {
+			b.WriteString(c.Token)
+		}
+		b.WriteString("\n")
+	}
+	return b.Bytes()
 }
 
 // getGlobalVariables returns the global variable assignments in the provided list of expressions.
@@ -1166,6 +2316,25 @@ func RegisterBuildifier(b Buildifier) {
 	buildifierRegistered = true
 }
 
+// readBuildFile reads the file of a group of commands, a BUILD file given by the path of the file
+// or of its package directory followed by a slash, or any other file. It returns the path of the
+// file that was read.
+func readBuildFile(name string) (string, []byte, os.FileInfo, error) {
+	for _, suffix := range BuildFileNames {
+		if strings.HasSuffix(name, "/"+suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	for _, suffix := range BuildFileNames {
+		if data, fi, err := file.ReadFile(name + suffix); err == nil {
+			return name + suffix, data, fi, nil
+		}
+	}
+	data, fi, err := file.ReadFile(name)
+	return name, data, fi, err
+}
+
 // rewrite parses the BUILD file for the given file, transforms the AST,
 // and write the changes back in the file (or on stdout).
 func rewrite(opts *Options, commandsForFile commandsForFile) *rewriteResult {
@@ -1177,36 +2346,37 @@ func rewrite(opts *Options, commandsForFile commandsForFile) *rewriteResult {
 	if name == stdinPackageName { // read on stdin
 		data, err = io.ReadAll(os.Stdin)
 		if err != nil {
-			return &rewriteResult{file: name, errs: []error{err}}
+			return &rewriteResult{file: name, errs: []error{fileError(ErrorFileNotFound, name, err)}}
 		}
 	} else {
 		origName := name
-		for _, suffix := range BuildFileNames {
-			if strings.HasSuffix(name, "/"+suffix) {
-				name = strings.TrimSuffix(name, suffix)
-				break
-			}
-		}
-		for _, suffix := range BuildFileNames {
-			name = name + suffix
-			data, fi, err = file.ReadFile(name)
-			if err == nil {
-				break
-			}
-			name = strings.TrimSuffix(name, suffix)
-		}
-		if err != nil {
-			data, fi, err = file.ReadFile(name)
+		if opts.git != nil {
+			name, data, err = opts.git.readBuildFile(name)
+		} else {
+			name, data, fi, err = readBuildFile(name)
 		}
 		if err != nil {
+			if queries, ok := missingFileQueries(commandsForFile.commands); ok {
+				return &rewriteResult{file: origName, queries: queries}
+			}
 			err = errors.New("file not found or not readable")
-			return &rewriteResult{file: origName, errs: []error{err}}
+			return &rewriteResult{file: origName, errs: []error{fileError(ErrorFileNotFound, origName, err)}, counts: targetCounts{notFound: len(commandsForFile.commands)}}
 		}
 	}
 
+	if !opts.Force && modifiesFile(commandsForFile) {
+		if marker := generatedMarker(data, opts.GeneratedMarkers); marker != "" {
+			return &rewriteResult{file: name, generated: marker}
+		}
+	}
+
+	if build.IsBazelrcFile(name) {
+		return rewriteBazelrc(opts, name, fi, data, commandsForFile)
+	}
+
 	f, err := build.Parse(name, data)
 	if err != nil {
-		return &rewriteResult{file: name, errs: []error{err}}
+		return &rewriteResult{file: name, errs: []error{fileError(ErrorParse, name, err)}}
 	}
 	if f.Type == build.TypeDefault {
 		// Buildozer is unable to infer the file type, fall back to BUILD by default.
@@ -1219,9 +2389,12 @@ func rewrite(opts *Options, commandsForFile commandsForFile) *rewriteResult {
 		vars = getGlobalVariables(f.Stmt)
 	}
 	var errs []error
+	var skipped []string
+	var queries queryResult
+	var counts targetCounts
 	changed := false
 	for _, cft := range commandsForFile.commands {
-		_, _, absPkg, rule := InterpretLabelForWorkspaceLocation(opts.RootDir, cft.target)
+		_, _, absPkg, rule := interpretLabel(opts.cache, opts.RootDir, cft.target)
 		if label := labels.Parse(cft.target); label.Package == stdinPackageName {
 			// Special-case: This is already absolute
 			absPkg = stdinPackageName
@@ -1232,32 +2405,78 @@ func rewrite(opts *Options, commandsForFile commandsForFile) *rewriteResult {
 		}
 
 		targets, err := expandTargets(f, rule)
+		if err != nil && onlyQueries(cft.commands) {
+			queries.merge(evalQueries(cft.commands, nil, absPkg))
+			continue
+		}
 		if err != nil {
-			cerr := commandError(cft.commands, cft.target, err)
+			cerr := targetError(ErrorTargetNotFound, name, cft.commands, cft.target, nil, err)
 			errs = append(errs, cerr)
+			if opts.DetailedExitCodes {
+				counts.notFound++
+			}
 			if !opts.KeepGoing {
-				return &rewriteResult{file: name, errs: errs, records: records}
+				return &rewriteResult{file: name, errs: errs, records: records, skipped: skipped, counts: counts}
 			}
 		}
 		targets = filterRules(opts, targets)
+		queries.merge(evalQueries(cft.commands, targets, absPkg))
 
-		newf, err := executeCommandsInFile(opts, f, cft, targets, &records, vars, absPkg, &errs)
+		// Most commands return the file even if they didn't change it, the outcome of the
+		// target is found by comparing what they may change before and after them.
+		var before []byte
+		if opts.DetailedExitCodes && err == nil {
+			before = targetSnapshot(f, cft.commands, targets)
+		}
+		newf, err := executeCommandsInFile(opts, f, cft, targets, &records, vars, absPkg, &errs, &skipped)
 		if err != nil {
-			return &rewriteResult{file: name, errs: []error{err}, records: records}
+			return &rewriteResult{file: name, errs: []error{err}, records: records, skipped: skipped, counts: counts}
 		}
 		if newf != nil {
 			changed = true
 			f = newf
 		}
+		if before != nil {
+			switch {
+			case len(targets) == 0 && hasPerRuleCommands(cft.commands):
+				counts.notFound++
+			case !bytes.Equal(before, targetSnapshot(f, cft.commands, targets)):
+				counts.changed++
+			default:
+				counts.unchanged++
+			}
+		}
 	}
 	if !changed {
-		return &rewriteResult{file: name, errs: errs, records: records}
+		counts.unchanged += counts.changed
+		counts.changed = 0
+		return &rewriteResult{file: name, errs: errs, records: records, skipped: skipped, queries: queries, counts: counts}
 	}
 	ndata, err := cleanAndBuildify(opts, f)
 	if err != nil {
-		return &rewriteResult{file: name, errs: []error{fmt.Errorf("running buildifier: %v", err)}, records: records}
+		return &rewriteResult{file: name, errs: []error{fileError(ErrorUnknown, name, fmt.Errorf("running buildifier: %v", err))}, records: records, skipped: skipped}
+	}
+	if opts.MinimalDiff {
+		// The original file is parsed again because the commands modified f in place.
+		if orig, err := build.Parse(name, data); err == nil {
+			orig.Type, orig.WorkspaceRoot, orig.Pkg, orig.Label = f.Type, f.WorkspaceRoot, f.Pkg, f.Label
+			ndata = minimalDiff(orig, data, ndata)
+		}
 	}
+	result := writeResult(opts, name, fi, data, ndata, errs, records)
+	result.skipped = skipped
+	result.queries = queries
+	if !result.modified && bytes.Equal(data, ndata) {
+		// The changes were undone by the formatting.
+		counts.unchanged += counts.changed
+		counts.changed = 0
+	}
+	result.counts = counts
+	return result
+}
 
+// writeResult writes the new contents of a modified file, or prints them if requested.
+func writeResult(opts *Options, name string, fi os.FileInfo, data, ndata []byte, errs []error, records []*apipb.Output_Record) *rewriteResult {
 	if opts.Stdout || name == stdinPackageName {
 		opts.OutWriter.Write(ndata)
 		return &rewriteResult{file: name, errs: errs, records: records}
@@ -1267,12 +2486,20 @@ func rewrite(opts *Options, commandsForFile commandsForFile) *rewriteResult {
 		return &rewriteResult{file: name, errs: errs, records: records}
 	}
 
+	if opts.git != nil {
+		patch, err := opts.git.patch(name, data, ndata)
+		if err != nil {
+			return &rewriteResult{file: name, errs: []error{fileError(ErrorWrite, name, err)}, records: records}
+		}
+		return &rewriteResult{file: name, errs: errs, modified: true, records: records, patch: patch}
+	}
+
 	if err := EditFile(fi, name); err != nil {
-		return &rewriteResult{file: name, errs: []error{err}, records: records}
+		return &rewriteResult{file: name, errs: []error{fileError(ErrorWrite, name, err)}, records: records}
 	}
 
 	if err := file.WriteFile(name, ndata); err != nil {
-		return &rewriteResult{file: name, errs: []error{err}, records: records}
+		return &rewriteResult{file: name, errs: []error{fileError(ErrorWrite, name, err)}, records: records}
 	}
 
 	return &rewriteResult{file: name, errs: errs, modified: true, records: records}
@@ -1288,7 +2515,12 @@ func executeCommandsInFile(
 	vars map[string]*build.AssignExpr,
 	absPkg string,
 	errs *[]error,
+	skipped *[]string,
 ) (*build.File, error) {
+	var regions []build.GeneratedRegion
+	if !opts.Force {
+		regions = build.GeneratedRegions(f)
+	}
 	changed := false
 	for _, cmd := range cft.commands {
 		cmdInfo := AllCommands[cmd.tokens[0]]
@@ -1299,13 +2531,27 @@ func executeCommandsInFile(
 			cmdTargets = []*build.Rule{nil}
 		}
 		for _, r := range cmdTargets {
+			if r != nil && !opts.OverrideFrozen {
+				if reason := frozenReason(r, cmd.tokens); reason != "" {
+					if skipped != nil {
+						*skipped = append(*skipped, fmt.Sprintf("//%s:%s: '%s' skipped (frozen %s), use -override_frozen to edit it", absPkg, r.Name(), strings.Join(cmd.tokens, " "), reason))
+					}
+					continue
+				}
+			}
+			if r != nil && !readonlyCommands[cmd.tokens[0]] && inGeneratedRegion(regions, r) {
+				if skipped != nil {
+					*skipped = append(*skipped, fmt.Sprintf("//%s:%s: '%s' skipped (in a generated region), use -force to edit it", absPkg, r.Name(), strings.Join(cmd.tokens, " ")))
+				}
+				continue
+			}
 			record := &apipb.Output_Record{}
 			newf, err := cmdInfo.Fn(opts, CmdEnvironment{f, r, vars, absPkg, cmd.tokens[1:], record})
 			if len(record.Fields) != 0 {
 				*records = append(*records, record)
 			}
 			if err != nil {
-				cerr := commandError([]command{cmd}, cft.target, err)
+				cerr := targetError(ErrorCommand, f.Path, []command{cmd}, cft.target, r, err)
 				if opts.KeepGoing {
 					*errs = append(*errs, cerr)
 				} else {
@@ -1337,9 +2583,9 @@ var EditFile = func(fi os.FileInfo, name string) error {
 }
 
 // Given a target, whose package may contain a trailing "/...", returns all
-// existing BUILD file paths which match the package.
-func targetExpressionToBuildFiles(rootDir string, target string, respectBazelignore bool) []string {
-	file, _, _, _ := InterpretLabelForWorkspaceLocation(rootDir, target)
+// existing BUILD file paths which match the package, in the Git revision if git isn't nil.
+func targetExpressionToBuildFiles(cache *workspaceCache, git *gitRev, rootDir string, target string, respectBazelignore bool) []string {
+	file, _, _, _ := interpretLabel(cache, rootDir, target)
 	if rootDir == "" {
 		var err error
 		if file, err = filepath.Abs(file); err != nil {
@@ -1355,7 +2601,10 @@ func targetExpressionToBuildFiles(rootDir string, target string, respectBazelign
 
 	var ignoredPrefixes []string
 	if respectBazelignore {
-		ignoredPrefixes = getIgnoredPrefixes(rootDir)
+		ignoredPrefixes = cache.getIgnoredPrefixes(rootDir)
+	}
+	if git != nil {
+		return git.findBuildFiles(strings.TrimSuffix(file, suffix), ignoredPrefixes)
 	}
 	return findBuildFiles(strings.TrimSuffix(file, suffix), ignoredPrefixes)
 }
@@ -1458,6 +2707,13 @@ func appendCommands(opts *Options, commandMap map[string][]commandsForTarget, ar
 	if err != nil {
 		return err
 	}
+	appendCommandsForTargets(opts, commandMap, commands, targets)
+	return nil
+}
+
+// appendCommandsForTargets adds the given parsed commands to be applied to each of the given
+// targets via the commandMap.
+func appendCommandsForTargets(opts *Options, commandMap map[string][]commandsForTarget, commands []command, targets []string) {
 	for _, target := range targets {
 		for _, buildFileName := range BuildFileNames {
 			if strings.HasSuffix(target, filepath.FromSlash("/"+buildFileName)) {
@@ -1470,14 +2726,31 @@ func appendCommands(opts *Options, commandMap map[string][]commandsForTarget, ar
 		if label := labels.Parse(target); label.Package == stdinPackageName {
 			buildFiles = []string{stdinPackageName}
 		} else {
-			buildFiles = targetExpressionToBuildFiles(opts.RootDir, target, opts.RespectBazelignore)
+			buildFiles = targetExpressionToBuildFiles(opts.cache, opts.git, opts.RootDir, target, opts.RespectBazelignore)
 		}
 
 		for _, file := range buildFiles {
-			commandMap[file] = append(commandMap[file], commandsForTarget{target, commands})
+			fileCommands := commands
+			if file != stdinPackageName {
+				// The package groups of other packages are edited in their BUILD file.
+				_, _, pkg, _ := interpretLabel(opts.cache, opts.RootDir, target)
+				if strings.HasSuffix(pkg, "...") {
+					_, pkg, _ = wspace.SplitFilePath(file)
+				}
+				fileCommands = nil
+				for _, cmd := range commands {
+					if redirected, groupTarget, ok := redirectToPackageGroup(cmd, pkg); ok {
+						appendCommandsForTargets(opts, commandMap, []command{redirected}, []string{groupTarget})
+					} else {
+						fileCommands = append(fileCommands, cmd)
+					}
+				}
+			}
+			if len(fileCommands) > 0 || len(commands) == 0 {
+				commandMap[file] = append(commandMap[file], commandsForTarget{target, fileCommands})
+			}
 		}
 	}
-	return nil
 }
 
 func appendCommandsFromFiles(opts *Options, commandsByFile map[string][]commandsForTarget, labels []string) error {
@@ -1557,6 +2830,37 @@ func printRecord(writer io.Writer, record *apipb.Output_Record) {
 	fmt.Fprint(writer, strings.Join(line, " ")+"\n")
 }
 
+// rewriteAll applies the commands to their files concurrently and returns the non-nil results.
+func rewriteAll(opts *Options, commandsByFile map[string][]commandsForTarget) []*rewriteResult {
+	numFiles := len(commandsByFile)
+	if opts.Parallelism > 0 {
+		runtime.GOMAXPROCS(opts.Parallelism)
+	}
+	results := make(chan *rewriteResult, numFiles)
+	data := make(chan commandsForFile)
+
+	for i := 0; i < opts.NumIO; i++ {
+		go func(results chan *rewriteResult, data chan commandsForFile) {
+			for commandsForFile := range data {
+				results <- rewrite(opts, commandsForFile)
+			}
+		}(results, data)
+	}
+
+	for file, commands := range commandsByFile {
+		data <- commandsForFile{file, commands}
+	}
+	close(data)
+
+	var fileResults []*rewriteResult
+	for i := 0; i < numFiles; i++ {
+		if result := <-results; result != nil {
+			fileResults = append(fileResults, result)
+		}
+	}
+	return fileResults
+}
+
 // Buildozer loops over all arguments on the command line fixing BUILD files.
 func Buildozer(opts *Options, args []string) int {
 	if opts.OutWriter == nil {
@@ -1565,6 +2869,29 @@ func Buildozer(opts *Options, args []string) int {
 	if opts.ErrWriter == nil {
 		opts.ErrWriter = os.Stderr
 	}
+	// Files may change between invocations, so every invocation uses a fresh cache.
+	invocationOpts := *opts
+	invocationOpts.cache = newWorkspaceCache()
+	opts = &invocationOpts
+	if opts.GitRev != "" {
+		git, err := newGitRev(opts.RootDir, opts.GitRev)
+		if err != nil {
+			fmt.Fprintf(opts.ErrWriter, "error: %s\n", err)
+			return 1
+		}
+		opts.git = git
+	}
+	if opts.PlanFile != "" {
+		return runPlan(opts, args)
+	}
+	for _, file := range opts.ForFiles {
+		owners, err := FindFileOwners(opts.RootDir, file)
+		if err != nil {
+			fmt.Fprintf(opts.ErrWriter, "error: %s\n", err)
+			return 1
+		}
+		args = append(args, owners...)
+	}
 	commandsByFile := make(map[string][]commandsForTarget)
 	if len(opts.CommandsFiles) > 0 {
 		if err := appendCommandsFromFiles(opts, commandsByFile, args); err != nil {
@@ -1581,41 +2908,49 @@ func Buildozer(opts *Options, args []string) int {
 		}
 	}
 
-	numFiles := len(commandsByFile)
-	if opts.Parallelism > 0 {
-		runtime.GOMAXPROCS(opts.Parallelism)
-	}
-	results := make(chan *rewriteResult, numFiles)
-	data := make(chan commandsForFile)
-
 	if opts.NumIO < 1 {
 		fmt.Fprintf(opts.ErrWriter, "NumIO must be at least 1; got %d (are you using `NewOpts`?)\n", opts.NumIO)
 		return 1
 	}
-	for i := 0; i < opts.NumIO; i++ {
-		go func(results chan *rewriteResult, data chan commandsForFile) {
-			for commandsForFile := range data {
-				results <- rewrite(opts, commandsForFile)
+	if opts.DeleteReferences != "" {
+		if err := checkDeleteReferences(opts, commandsByFile); err != nil {
+			fmt.Fprintf(opts.ErrWriter, "error: %s\n", err)
+			if errors.Is(err, errReferencedRules) {
+				return 2
 			}
-		}(results, data)
-	}
-
-	for file, commands := range commandsByFile {
-		data <- commandsForFile{file, commands}
+			return 1
+		}
 	}
-	close(data)
+	shardFiles(opts, commandsByFile)
 	records := []*apipb.Output_Record{}
-	var hasErrors bool
+	var errs []error
+	var generatedFiles []string
 	var fileModified bool
-	for i := 0; i < numFiles; i++ {
-		fileResults := <-results
-		if fileResults == nil {
+	var queries queryResult
+	var counts targetCounts
+	var patches []*rewriteResult
+	for _, fileResults := range rewriteAll(opts, commandsByFile) {
+		if fileResults.generated != "" {
+			generatedFiles = append(generatedFiles, fileResults.file)
+			if !opts.Quiet {
+				fmt.Fprintf(opts.ErrWriter, "skipped generated file %s (contains %q), use -force to edit it\n", fileResults.file, fileResults.generated)
+			}
 			continue
 		}
-		hasErrors = hasErrors || len(fileResults.errs) > 0
+		errs = append(errs, fileResults.errs...)
 		fileModified = fileModified || fileResults.modified
+		queries.merge(fileResults.queries)
+		counts.merge(fileResults.counts)
+		if !opts.Quiet {
+			for _, msg := range fileResults.skipped {
+				fmt.Fprintf(opts.ErrWriter, "%s: %s\n", fileResults.file, msg)
+			}
+		}
 		for _, err := range fileResults.errs {
 			fmt.Fprintf(opts.ErrWriter, "%s: %s\n", fileResults.file, err)
+			if e, ok := err.(*Error); ok && opts.ErrorHandler != nil {
+				opts.ErrorHandler(e)
+			}
 		}
 		if fileResults.modified && !opts.Quiet {
 			fmt.Fprintf(opts.ErrWriter, "fixed %s\n", fileResults.file)
@@ -1623,17 +2958,31 @@ func Buildozer(opts *Options, args []string) int {
 		if fileResults.records != nil {
 			records = append(records, fileResults.records...)
 		}
+		if fileResults.patch != nil {
+			patches = append(patches, fileResults)
+		}
+	}
+	// The files are processed concurrently, sort the patches to make the output deterministic.
+	sort.Slice(patches, func(i, j int) bool { return patches[i].file < patches[j].file })
+	for _, result := range patches {
+		opts.OutWriter.Write(result.patch)
+	}
+
+	if queries.counted {
+		records = append(records, &apipb.Output_Record{Fields: []*apipb.Output_Record_Field{
+			{Value: &apipb.Output_Record_Field_Number{Number: int32(queries.count)}},
+		}})
 	}
 
 	if opts.IsPrintingProto {
-		data, err := proto.Marshal(&apipb.Output{Records: records})
+		data, err := proto.Marshal(&apipb.Output{Records: records, Errors: errorsProto(errs), GeneratedFiles: generatedFiles})
 		if err != nil {
 			log.Fatal("marshaling error: ", err)
 		}
 		fmt.Fprintf(opts.OutWriter, "%s", data)
 	} else if opts.IsPrintingJSON {
 		marshaler := jsonpb.Marshaler{}
-		if err := marshaler.Marshal(opts.OutWriter, &apipb.Output{Records: records}); err != nil {
+		if err := marshaler.Marshal(opts.OutWriter, &apipb.Output{Records: records, Errors: errorsProto(errs), GeneratedFiles: generatedFiles}); err != nil {
 			log.Fatal("json marshaling error: ", err)
 		}
 		fmt.Fprintln(opts.OutWriter)
@@ -1643,9 +2992,23 @@ func Buildozer(opts *Options, args []string) int {
 		}
 	}
 
-	if hasErrors {
+	if opts.DetailedExitCodes {
+		if !opts.Quiet {
+			fmt.Fprintf(opts.ErrWriter, "targets: %d changed, %d unchanged, %d not found\n", counts.changed, counts.unchanged, counts.notFound)
+		}
+		if !onlyNotFoundErrors(errs) {
+			return 2
+		}
+		if counts.notFound > 0 {
+			return 5
+		}
+	}
+	if len(errs) > 0 {
 		return 2
 	}
+	if queries.answeredNo() {
+		return 4
+	}
 	if fileModified || opts.Stdout {
 		return 0
 	}
@@ -1667,6 +3030,17 @@ func Buildozer(opts *Options, args []string) int {
 	return 0
 }
 
+// onlyNotFoundErrors checks whether all the errors are about files or targets that don't exist.
+func onlyNotFoundErrors(errs []error) bool {
+	for _, err := range errs {
+		e, ok := err.(*Error)
+		if !ok || (e.Kind != ErrorFileNotFound && e.Kind != ErrorTargetNotFound) {
+			return false
+		}
+	}
+	return true
+}
+
 // ExecuteCommandsOnInlineFile executes the given commands on the given file content.
 // Returns the new file content after applying the commands.
 func ExecuteCommandsOnInlineFile(fileContent []byte, commands []string) ([]byte, error) {
@@ -1677,7 +3051,7 @@ func ExecuteCommandsOnInlineFile(fileContent []byte, commands []string) ([]byte,
 	}
 	f, err := build.Parse(*filename, fileContent)
 	if err != nil {
-		return nil, err
+		return nil, fileError(ErrorParse, *filename, err)
 	}
 	if f.Type == build.TypeDefault {
 		// Buildozer is unable to infer the file type, fall back to BUILD by default.
@@ -1686,7 +3060,7 @@ func ExecuteCommandsOnInlineFile(fileContent []byte, commands []string) ([]byte,
 	for _, cft := range commandsByTargetName {
 		rules, err := expandTargets(f, cft.target)
 		if err != nil {
-			return nil, err
+			return nil, &Error{Kind: ErrorTargetNotFound, File: *filename, Target: cft.target, Err: err}
 		}
 		newf, err := executeCommandsInFile(
 			&opts,
@@ -1700,6 +3074,8 @@ func ExecuteCommandsOnInlineFile(fileContent []byte, commands []string) ([]byte,
 			f.Pkg,
 			// Errors-list is ignored since opts.keepGoing is always false.
 			nil,
+			// Frozen rules and attributes and generated rules are skipped without a message.
+			nil,
 		)
 		if err != nil {
 			return nil, err
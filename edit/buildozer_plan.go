@@ -0,0 +1,313 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	apipb "github.com/bazelbuild/buildtools/api_proto"
+	"github.com/bazelbuild/buildtools/wspace"
+)
+
+// PlanEntry is a single step of a plan file passed with --plan. Unlike the lines of a commands
+// file, the arguments are not split on whitespace, so they don't need escaping.
+type PlanEntry struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// Targets the command is applied to. "*" stands for the targets given on the command line.
+	Targets []string `json:"targets"`
+	// Comment describes the step, it's copied to the report.
+	Comment string `json:"comment,omitempty"`
+	// Capture is the name of a variable the output of the command (e.g. of print) is stored in.
+	// The args and targets of the following entries can refer to it as ${NAME}, see
+	// expandPlanVariables.
+	Capture string `json:"capture,omitempty"`
+
+	// Per-entry overrides of the command line options.
+	KeepGoing     *bool    `json:"keep_going,omitempty"`
+	Types         []string `json:"types,omitempty"`
+	EditVariables *bool    `json:"edit_variables,omitempty"`
+}
+
+// PlanEntryReport describes the outcome of a single plan entry.
+type PlanEntryReport struct {
+	Index          int      `json:"index"`
+	Command        string   `json:"command"`
+	Args           []string `json:"args,omitempty"`
+	Comment        string   `json:"comment,omitempty"`
+	Status         string   `json:"status"` // one of "modified", "unchanged", "failed" or "skipped"
+	ModifiedFiles  []string `json:"modified_files,omitempty"`
+	GeneratedFiles []string `json:"generated_files,omitempty"`
+	Output         []string `json:"output,omitempty"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// PlanReport is written to the standard output after a plan has been executed.
+type PlanReport struct {
+	Entries []PlanEntryReport `json:"entries"`
+}
+
+// ParsePlan reads a list of plan entries in JSON format and checks that the commands exist and
+// have a valid number of arguments.
+func ParsePlan(r io.Reader) ([]PlanEntry, error) {
+	var entries []PlanEntry
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("invalid plan: %v", err)
+	}
+	for i, entry := range entries {
+		cmd, ok := AllCommands[entry.Command]
+		if !ok {
+			return nil, fmt.Errorf("plan entry %d: unknown command %q", i, entry.Command)
+		}
+		if !validArgCount(cmd, len(entry.Args)) {
+			return nil, fmt.Errorf("plan entry %d: wrong number of arguments for command '%s', usage: %s %s", i, entry.Command, entry.Command, cmd.Template)
+		}
+		if len(entry.Targets) == 0 {
+			return nil, fmt.Errorf("plan entry %d: no targets", i)
+		}
+		if entry.Capture != "" && !planVariableName.MatchString(entry.Capture) {
+			return nil, fmt.Errorf("plan entry %d: invalid variable name %q", i, entry.Capture)
+		}
+	}
+	return entries, nil
+}
+
+// validArgCount reports whether a command accepts the given number of arguments.
+func validArgCount(cmd CommandInfo, count int) bool {
+	return count >= cmd.MinArg && (cmd.MaxArg == -1 || count <= cmd.MaxArg)
+}
+
+// planVariableName matches the names of the variables of a plan.
+var planVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// planVariableRef matches the references to variables in the args and targets of plan entries.
+var planVariableRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandPlanVariables replaces the references to the variables captured by the previous entries
+// of a plan in an argument or a target. An argument that consists of a single reference expands
+// to one argument per captured value, e.g. to pass the deps of a rule to another command, the
+// references within a longer argument are replaced with the values separated by spaces. The
+// references to variables that haven't been captured are left unchanged, since ${...} may also
+// be a part of e.g. a genrule command.
+func expandPlanVariables(arg string, variables map[string][]string) []string {
+	if m := planVariableRef.FindStringSubmatch(arg); m != nil && m[0] == arg {
+		if values, ok := variables[m[1]]; ok {
+			return values
+		}
+		return []string{arg}
+	}
+	return []string{planVariableRef.ReplaceAllStringFunc(arg, func(ref string) string {
+		values, ok := variables[planVariableRef.FindStringSubmatch(ref)[1]]
+		if !ok {
+			return ref
+		}
+		return strings.Join(values, " ")
+	})}
+}
+
+// recordValues returns the values of the fields of an output record, as captured by a plan entry.
+// The relative labels (e.g. ":foo") are made absolute with the package of the rule they were
+// printed from, so that they can be used in other packages. Missing values are skipped.
+func recordValues(record *apipb.Output_Record, pkg string) []string {
+	absolute := func(value string) string {
+		if strings.HasPrefix(value, ":") {
+			return "//" + pkg + value
+		}
+		return value
+	}
+	var values []string
+	for _, field := range record.Fields {
+		switch value := field.Value.(type) {
+		case *apipb.Output_Record_Field_Text:
+			values = append(values, absolute(value.Text))
+		case *apipb.Output_Record_Field_Number:
+			values = append(values, strconv.Itoa(int(value.Number)))
+		case *apipb.Output_Record_Field_List:
+			for _, s := range value.List.Strings {
+				values = append(values, absolute(s))
+			}
+		}
+	}
+	return values
+}
+
+// runPlan executes the entries of opts.PlanFile one after another and writes a PlanReport.
+func runPlan(opts *Options, args []string) int {
+	if opts.Stdout {
+		fmt.Fprintf(opts.ErrWriter, "error: --plan cannot be combined with --stdout\n")
+		return 1
+	}
+	if opts.GitRev != "" {
+		// The entries would have to see the changes of the previous ones in the patches.
+		fmt.Fprintf(opts.ErrWriter, "error: --plan cannot be combined with --git_rev\n")
+		return 1
+	}
+	if opts.NumIO < 1 {
+		fmt.Fprintf(opts.ErrWriter, "NumIO must be at least 1; got %d (are you using `NewOpts`?)\n", opts.NumIO)
+		return 1
+	}
+
+	if ext := filepath.Ext(opts.PlanFile); ext == ".yaml" || ext == ".yml" {
+		// Parsing YAML would need a new dependency, and JSON plans are easy to generate from it.
+		fmt.Fprintf(opts.ErrWriter, "error: YAML plans are not supported, convert %s to JSON, e.g. with `yq -o=json`\n", opts.PlanFile)
+		return 1
+	}
+
+	var reader io.Reader = os.Stdin
+	if opts.PlanFile != stdinPackageName {
+		f, err := os.Open(opts.PlanFile)
+		if err != nil {
+			fmt.Fprintf(opts.ErrWriter, "error: %s\n", err)
+			return 1
+		}
+		defer f.Close()
+		reader = f
+	}
+	entries, err := ParsePlan(reader)
+	if err != nil {
+		fmt.Fprintf(opts.ErrWriter, "error: %s\n", err)
+		return 1
+	}
+
+	report := PlanReport{Entries: []PlanEntryReport{}}
+	variables := make(map[string][]string)
+	var hasErrors, fileModified, nonReadonlyCommands, stop bool
+	for i, entry := range entries {
+		entryReport := PlanEntryReport{
+			Index:   i,
+			Command: entry.Command,
+			Args:    entry.Args,
+			Comment: entry.Comment,
+		}
+		if stop {
+			entryReport.Status = "skipped"
+			report.Entries = append(report.Entries, entryReport)
+			continue
+		}
+		entryOpts := planEntryOptions(opts, entry)
+
+		var targets []string
+		for _, target := range entry.Targets {
+			if target == "*" {
+				targets = append(targets, args...)
+			} else {
+				targets = append(targets, expandPlanVariables(target, variables)...)
+			}
+		}
+		var cmdArgs []string
+		for _, arg := range entry.Args {
+			cmdArgs = append(cmdArgs, expandPlanVariables(arg, variables)...)
+		}
+		entryReport.Args = cmdArgs
+		if cmd := AllCommands[entry.Command]; !validArgCount(cmd, len(cmdArgs)) {
+			entryReport.Status = "failed"
+			entryReport.Errors = []string{fmt.Sprintf("wrong number of arguments for command '%s' after expanding the variables, usage: %s %s", entry.Command, entry.Command, cmd.Template)}
+			hasErrors = true
+			stop = !entryOpts.KeepGoing
+			report.Entries = append(report.Entries, entryReport)
+			continue
+		}
+		cmd := command{append([]string{entry.Command}, cmdArgs...)}
+		commandsByFile := make(map[string][]commandsForTarget)
+		appendCommandsForTargets(entryOpts, commandsByFile, []command{cmd}, targets)
+
+		var output bytes.Buffer
+		var captured []string
+		for _, result := range rewriteAll(entryOpts, commandsByFile) {
+			for _, err := range result.errs {
+				entryReport.Errors = append(entryReport.Errors, fmt.Sprintf("%s: %s", result.file, err))
+			}
+			if result.generated != "" {
+				entryReport.GeneratedFiles = append(entryReport.GeneratedFiles, result.file)
+			}
+			if result.modified {
+				entryReport.ModifiedFiles = append(entryReport.ModifiedFiles, result.file)
+			}
+			_, pkg, _ := wspace.SplitFilePath(result.file)
+			for _, record := range result.records {
+				printRecord(&output, record)
+				captured = append(captured, recordValues(record, pkg)...)
+			}
+		}
+		if entry.Capture != "" {
+			variables[entry.Capture] = captured
+		}
+		sort.Strings(entryReport.ModifiedFiles)
+		sort.Strings(entryReport.GeneratedFiles)
+		sort.Strings(entryReport.Errors)
+		if output.Len() > 0 {
+			entryReport.Output = strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+		}
+
+		switch {
+		case len(entryReport.Errors) > 0:
+			entryReport.Status = "failed"
+			hasErrors = true
+			stop = !entryOpts.KeepGoing
+		case len(entryReport.ModifiedFiles) > 0:
+			entryReport.Status = "modified"
+			fileModified = true
+		default:
+			entryReport.Status = "unchanged"
+		}
+		if !readonlyCommands[entry.Command] {
+			nonReadonlyCommands = true
+		}
+		report.Entries = append(report.Entries, entryReport)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(opts.ErrWriter, "error: %s\n", err)
+		return 1
+	}
+	fmt.Fprintf(opts.OutWriter, "%s\n", data)
+
+	if hasErrors {
+		return 2
+	}
+	if fileModified || !nonReadonlyCommands {
+		return 0
+	}
+	return 3
+}
+
+// planEntryOptions returns a copy of the options with the overrides of the plan entry applied.
+func planEntryOptions(opts *Options, entry PlanEntry) *Options {
+	entryOpts := *opts
+	if entry.KeepGoing != nil {
+		entryOpts.KeepGoing = *entry.KeepGoing
+	}
+	if entry.Types != nil {
+		entryOpts.FilterRuleTypes = entry.Types
+	}
+	if entry.EditVariables != nil {
+		entryOpts.EditVariables = *entry.EditVariables
+	}
+	return &entryOpts
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePlan(t *testing.T) {
+	entries, err := ParsePlan(strings.NewReader(`[
+  {"command": "add", "args": ["tags", "no remote"], "targets": ["//pkg:a"], "comment": "c", "keep_going": true},
+  {"command": "print", "args": ["name"], "targets": ["*"], "types": ["cc_test"]}
+]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keepGoing := true
+	want := []PlanEntry{
+		{Command: "add", Args: []string{"tags", "no remote"}, Targets: []string{"//pkg:a"}, Comment: "c", KeepGoing: &keepGoing},
+		{Command: "print", Args: []string{"name"}, Targets: []string{"*"}, Types: []string{"cc_test"}},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParsePlan() = %+v, want %+v", entries, want)
+	}
+
+	for _, tc := range []struct {
+		plan string
+		err  string
+	}{
+		{`{}`, "invalid plan"},
+		{`[{"command": "add", "args": ["x"], "targets": ["//a"], "unknown": 1}]`, "invalid plan"},
+		{`[{"command": "frobnicate", "targets": ["//a"]}]`, `unknown command "frobnicate"`},
+		{`[{"command": "add", "args": ["deps"], "targets": ["//a"]}]`, "wrong number of arguments for command 'add'"},
+		{`[{"command": "delete"}]`, "plan entry 0: no targets"},
+		{`[{"command": "print", "args": ["deps"], "targets": ["//a"], "capture": "1x"}]`, `plan entry 0: invalid variable name "1x"`},
+	} {
+		_, err := ParsePlan(strings.NewReader(tc.plan))
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("ParsePlan(%s) error = %v, want %q", tc.plan, err, tc.err)
+		}
+	}
+}
+
+func TestRunPlan(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmp, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	build := `cc_library(name = "lib")
+
+cc_test(name = "test")
+`
+	if err := os.WriteFile(filepath.Join(tmp, "pkg", "BUILD"), []byte(build), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plan := `[
+  {"command": "add", "args": ["tags", "no remote"], "targets": ["//pkg:all"], "types": ["cc_test"], "comment": "Tag tests"},
+  {"command": "print", "args": ["name", "tags"], "targets": ["*"]},
+  {"command": "set", "args": ["testonly", "True"], "targets": ["//pkg:missing"]},
+  {"command": "delete", "targets": ["//pkg:lib"]}
+]`
+	planFile := filepath.Join(tmp, "plan.json")
+	if err := os.WriteFile(planFile, []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.PlanFile = planFile
+	opts.OutWriter = &stdout
+	opts.ErrWriter = &stderr
+	if ret := Buildozer(opts, []string{"//pkg:test"}); ret != 2 {
+		t.Errorf("Buildozer() = %d, want 2; stderr:\n%s", ret, stderr.String())
+	}
+
+	var report PlanReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("invalid report %q: %v", stdout.String(), err)
+	}
+	buildFile := filepath.Join(tmp, "pkg", "BUILD")
+	want := []PlanEntryReport{
+		{Index: 0, Command: "add", Args: []string{"tags", "no remote"}, Comment: "Tag tests", Status: "modified", ModifiedFiles: []string{buildFile}},
+		{Index: 1, Command: "print", Args: []string{"name", "tags"}, Status: "unchanged", Output: []string{"test [no remote]"}},
+		{Index: 2, Command: "set", Args: []string{"testonly", "True"}, Status: "failed", Errors: []string{buildFile + ": error while executing commands [{[set testonly True]}] on target //pkg:missing: rule 'missing' not found"}},
+		{Index: 3, Command: "delete", Status: "skipped"},
+	}
+	if !reflect.DeepEqual(report.Entries, want) {
+		t.Errorf("report = %+v\nwant %+v", report.Entries, want)
+	}
+
+	got, err := os.ReadFile(buildFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBuild := `cc_library(name = "lib")
+
+cc_test(
+    name = "test",
+    tags = ["no remote"],
+)
+`
+	if string(got) != wantBuild {
+		t.Errorf("BUILD = %q, want %q", got, wantBuild)
+	}
+}
+
+func TestRunPlanYAML(t *testing.T) {
+	var stderr bytes.Buffer
+	opts := NewOpts()
+	opts.PlanFile = filepath.Join(t.TempDir(), "plan.yaml")
+	opts.ErrWriter = &stderr
+	if ret := Buildozer(opts, nil); ret != 1 || !strings.Contains(stderr.String(), "YAML plans are not supported") {
+		t.Errorf("Buildozer() with a YAML plan = %d, stderr %q, want 1 and an unsupported format error", ret, stderr.String())
+	}
+}
+
+func TestExpandPlanVariables(t *testing.T) {
+	variables := map[string][]string{
+		"DEPS":  {"//a:b", "//c"},
+		"EMPTY": nil,
+	}
+	for _, tc := range []struct {
+		arg  string
+		want []string
+	}{
+		{"deps", []string{"deps"}},
+		{"${DEPS}", []string{"//a:b", "//c"}},
+		{"${EMPTY}", nil},
+		{"deps: ${DEPS}", []string{"deps: //a:b //c"}},
+		{"${UNKNOWN}", []string{"${UNKNOWN}"}},
+		{"$(location ${DEPS}) ${OTHER}", []string{"$(location //a:b //c) ${OTHER}"}},
+	} {
+		if got := expandPlanVariables(tc.arg, variables); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("expandPlanVariables(%q) = %q, want %q", tc.arg, got, tc.want)
+		}
+	}
+}
+
+func TestRunPlanCapture(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		"WORKSPACE": "",
+		"a/BUILD": `cc_library(
+    name = "x",
+    deps = [
+        ":z",
+        "//c",
+    ],
+)
+`,
+		"b/BUILD": `cc_library(name = "y")
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plan := `[
+  {"command": "print", "args": ["deps"], "targets": ["//a:x"], "capture": "DEPS"},
+  {"command": "add", "args": ["deps", "${DEPS}"], "targets": ["//b:y"]},
+  {"command": "print", "args": ["srcs"], "targets": ["//b:y"], "capture": "SRCS"},
+  {"command": "add", "args": ["srcs", "${SRCS}"], "targets": ["//b:y"]}
+]`
+	planFile := filepath.Join(tmp, "plan.json")
+	if err := os.WriteFile(planFile, []byte(plan), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.PlanFile = planFile
+	opts.OutWriter = &stdout
+	opts.ErrWriter = &stderr
+	if ret := Buildozer(opts, nil); ret != 2 {
+		t.Errorf("Buildozer() = %d, want 2; stderr:\n%s", ret, stderr.String())
+	}
+
+	var report PlanReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("invalid report %q: %v", stdout.String(), err)
+	}
+	want := []PlanEntryReport{
+		{Index: 0, Command: "print", Args: []string{"deps"}, Status: "unchanged", Output: []string{"[:z //c]"}},
+		{Index: 1, Command: "add", Args: []string{"deps", "//a:z", "//c"}, Status: "modified", ModifiedFiles: []string{filepath.Join(tmp, "b", "BUILD")}},
+		{Index: 2, Command: "print", Args: []string{"srcs"}, Status: "unchanged", Output: []string{"(missing)"}},
+		{Index: 3, Command: "add", Args: []string{"srcs"}, Status: "failed", Errors: []string{"wrong number of arguments for command 'add' after expanding the variables, usage: add <attr> <value(s)>"}},
+	}
+	if !reflect.DeepEqual(report.Entries, want) {
+		t.Errorf("report = %+v\nwant %+v", report.Entries, want)
+	}
+
+	got, err := os.ReadFile(filepath.Join(tmp, "b", "BUILD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBuild := `cc_library(
+    name = "y",
+    deps = [
+        "//a:z",
+        "//c",
+    ],
+)
+`
+	if string(got) != wantBuild {
+		t.Errorf("BUILD = %q, want %q", got, wantBuild)
+	}
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package edit
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -215,7 +217,7 @@ func runTestTargetExpressionToBuildFiles(t *testing.T, buildFileName string) {
 			defer os.Chdir(cwd)
 		}
 
-		buildFiles := targetExpressionToBuildFiles(tc.rootDir, tc.target, true)
+		buildFiles := targetExpressionToBuildFiles(nil, nil, tc.rootDir, tc.target, true)
 		expectedBuildFilesMap := make(map[string]bool)
 		buildFilesMap := make(map[string]bool)
 		for _, buildFile := range buildFiles {
@@ -723,6 +725,61 @@ func TestCmdDictOperations(t *testing.T) {
 	}
 }
 
+func TestCmdDictRenameKeyAndReplaceIfEqual(t *testing.T) {
+	file, err := build.Parse("BUILD", []byte(`rule(
+    name = "rule_name",
+    dict_attr = {
+        # The mode.
+        "old_mode": "fast",
+        "level": "1",
+        "both": "x",
+        "both_renamed": "y",
+    },
+)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule := file.RuleNamed("rule_name")
+	run := func(fun func(*Options, CmdEnvironment) (*build.File, error), args ...string) error {
+		_, err := fun(NewOpts(), CmdEnvironment{File: file, Rule: rule, Args: args})
+		return err
+	}
+
+	// The commands are idempotent, running them twice gives the same result.
+	for i := 0; i < 2; i++ {
+		if err := run(cmdDictRenameKey, "dict_attr", "old_mode", "mode"); err != nil {
+			t.Errorf("dict_rename_key returned err: %s", err)
+		}
+		if err := run(cmdDictReplaceIfEqual, "dict_attr", "level", "1", "2"); err != nil {
+			t.Errorf("dict_replace_if_equal returned err: %s", err)
+		}
+		if err := run(cmdDictReplaceIfEqual, "dict_attr", "missing", "1", "2"); err != nil {
+			t.Errorf("dict_replace_if_equal with a missing key returned err: %s", err)
+		}
+	}
+	if err := run(cmdDictReplaceIfEqual, "dict_attr", "mode", "slow", "medium"); err != nil {
+		t.Errorf("dict_replace_if_equal returned err: %s", err)
+	}
+	if err := run(cmdDictRenameKey, "dict_attr", "both", "both_renamed"); err == nil {
+		t.Errorf("dict_rename_key to an existing key succeeded, want error")
+	}
+
+	want := `rule(
+    name = "rule_name",
+    dict_attr = {
+        # The mode.
+        "mode": "fast",
+        "level": "2",
+        "both": "x",
+        "both_renamed": "y",
+    },
+)
+`
+	if diff := cmp.Diff(want, string(build.Format(file))); diff != "" {
+		t.Errorf("dict operations returned diff -want +got %v", diff)
+	}
+}
+
 func TestCmdSetSelect(t *testing.T) {
 	for i, tc := range []struct {
 		name      string
@@ -814,6 +871,108 @@ func TestCmdSetSelect(t *testing.T) {
 	}
 }
 
+var setDefaultTests = []struct {
+	args     []string
+	input    string
+	expected string
+	wantErr  string
+}{
+	{
+		args:     []string{"my_macro", "size", "large"},
+		input:    `def my_macro(name, size = "small", **kwargs): pass`,
+		expected: `def my_macro(name, size = "large", **kwargs): pass`,
+	},
+	{
+		args:     []string{"my_macro", "deps", "//foo", "//bar"},
+		input:    `def my_macro(name, deps = []): pass`,
+		expected: `def my_macro(name, deps = ["//foo", "//bar"]): pass`,
+	},
+	{
+		args:     []string{"my_macro", "shards", "4"},
+		input:    `def my_macro(name, shards: int = 1): pass`,
+		expected: `def my_macro(name, shards: int = 4): pass`,
+	},
+	{
+		args:    []string{"my_macro", "name", "foo"},
+		input:   `def my_macro(name, size = "small"): pass`,
+		wantErr: "parameter 'name' of function 'my_macro' has no default value",
+	},
+	{
+		args:    []string{"my_macro", "timeout", "long"},
+		input:   `def my_macro(name, size = "small"): pass`,
+		wantErr: "parameter 'timeout' not found in function 'my_macro'",
+	},
+	{
+		args:    []string{"other_macro", "size", "large"},
+		input:   `def my_macro(name, size = "small"): pass`,
+		wantErr: "function 'other_macro' not found",
+	},
+}
+
+func TestCmdSetDefault(t *testing.T) {
+	for i, tt := range setDefaultTests {
+		bzl, err := build.ParseBzl("defs.bzl", []byte(tt.input))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		env := CmdEnvironment{
+			File: bzl,
+			Args: tt.args,
+		}
+		bzl, err = cmdSetDefault(NewOpts(), env)
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("cmdSetDefault(%d): got error %v, expected %q", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("cmdSetDefault(%d): got error %v", i, err)
+			continue
+		}
+		expectedBzl, err := build.ParseBzl("defs.bzl", []byte(tt.expected))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		got := strings.TrimSpace(string(build.Format(bzl)))
+		expected := strings.TrimSpace(string(build.Format(expectedBzl)))
+		if got != expected {
+			t.Errorf("cmdSetDefault(%d):\ngot:\n%s\nexpected:\n%s", i, got, expected)
+		}
+	}
+}
+
+func TestCmdSetConstant(t *testing.T) {
+	bzl, err := build.ParseBzl("defs.bzl", []byte(`TIMEOUT = "short"
+
+SIZES = ["small"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := CmdEnvironment{
+		File: bzl,
+		Args: []string{"TIMEOUT", `"long"`},
+	}
+	if _, err := cmdSetConstant(NewOpts(), env); err != nil {
+		t.Fatal(err)
+	}
+	env.Args = []string{"UNKNOWN", "1"}
+	if _, err := cmdSetConstant(NewOpts(), env); err == nil || err.Error() != "variable 'UNKNOWN' not found" {
+		t.Errorf("cmdSetConstant(UNKNOWN): got error %v", err)
+	}
+	got := string(build.Format(bzl))
+	expected := `TIMEOUT = "long"
+
+SIZES = ["small"]
+`
+	if got != expected {
+		t.Errorf("cmdSetConstant:\ngot:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
 func TestExecuteCommandsOnInlineFile(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -1193,3 +1352,632 @@ func TestSplitOnNonEscaped(t *testing.T) {
 		})
 	}
 }
+
+func TestKindInRepositoryFiles(t *testing.T) {
+	tmp := t.TempDir()
+	workspace := `http_archive(
+    name = "foo",
+    urls = ["https://foo"],
+)
+
+http_archive(name = "bar")
+
+git_repository(name = "baz")
+`
+	module := `module(name = "m")
+
+mvn = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+mvn.install(artifacts = ["a:b:1"])
+mvn.install(
+    name = "other",
+    artifacts = [],
+)
+
+bazel_dep(name = "rules_go", version = "0.1")
+`
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), []byte(workspace), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "MODULE.bazel"), []byte(module), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"print label", "//WORKSPACE:%http_archive"}, "//:foo\n//:bar\n"},
+		{[]string{"print name", "//WORKSPACE:%git_repository"}, "baz\n"},
+		{[]string{"print name version", "//MODULE.bazel:%bazel_dep"}, "rules_go 0.1\n"},
+		{[]string{"print artifacts", "//MODULE.bazel:%maven.install"}, "[a:b:1]\n[]\n"},
+		{[]string{"print artifacts", "//MODULE.bazel:%mvn.install"}, "[a:b:1]\n[]\n"},
+	} {
+		var out bytes.Buffer
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.OutWriter = &out
+		opts.ErrWriter = io.Discard
+		if ret := Buildozer(opts, tc.args); ret != 0 {
+			t.Errorf("Buildozer(%q) = %d, want 0", tc.args, ret)
+		}
+		if got := out.String(); got != tc.want {
+			t.Errorf("Buildozer(%q) printed %q, want %q", tc.args, got, tc.want)
+		}
+	}
+
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.Quiet = true
+	if ret := Buildozer(opts, []string{"set version \"0.2\"", "//MODULE.bazel:%bazel_dep"}); ret != 0 {
+		t.Fatalf("Buildozer(set version) = %d, want 0", ret)
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "MODULE.bazel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `bazel_dep(name = "rules_go", version = "0.2")`; !strings.Contains(string(content), want) {
+		t.Errorf("MODULE.bazel after set = %s, want it to contain %s", content, want)
+	}
+}
+
+func TestSetOperationsAcrossPackages(t *testing.T) {
+	tmp := t.TempDir()
+	files := map[string]string{
+		"WORKSPACE": "",
+		"other/BUILD": `cc_library(
+    name = "target",
+    deps = [":x", "//pkg:a"],
+    copts = ["-O2"],
+)
+`,
+		"pkg/BUILD": `cc_library(
+    name = "lib",
+    deps = [":a", ":b"],
+    copts = ["-O2", "-g"],
+)
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tmp, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.Quiet = true
+	for _, args := range [][]string{
+		{"union_attr deps //other:target deps", "//pkg:lib"},
+		{"intersect_attr copts //other:target copts", "//pkg:lib"},
+	} {
+		if ret := Buildozer(opts, args); ret != 0 {
+			t.Fatalf("Buildozer(%q) = %d, want 0", args, ret)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(tmp, "pkg", "BUILD"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `cc_library(
+    name = "lib",
+    copts = ["-O2"],
+    deps = [
+        ":a",
+        ":b",
+        "//other:x",
+    ],
+)
+`
+	if diff := cmp.Diff(want, string(content)); diff != "" {
+		t.Errorf("pkg/BUILD (-want +got):\n%s", diff)
+	}
+}
+
+func TestWorkspaceCache(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ".bazelignore"), []byte("out\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache := newWorkspaceCache()
+	if root, _ := cache.findWorkspaceRoot(tmp); root != tmp {
+		t.Errorf("findWorkspaceRoot(%q) = %q, want %q", tmp, root, tmp)
+	}
+	if got := cache.getIgnoredPrefixes(tmp); !reflect.DeepEqual(got, []string{"out"}) {
+		t.Errorf("getIgnoredPrefixes(%q) = %q, want [out]", tmp, got)
+	}
+
+	// The results are memoized, later changes to the files are not seen.
+	if err := os.Remove(filepath.Join(tmp, "WORKSPACE")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(tmp, ".bazelignore")); err != nil {
+		t.Fatal(err)
+	}
+	if root, _ := cache.findWorkspaceRoot(tmp); root != tmp {
+		t.Errorf("findWorkspaceRoot(%q) = %q, want the memoized %q", tmp, root, tmp)
+	}
+	if got := cache.getIgnoredPrefixes(tmp); !reflect.DeepEqual(got, []string{"out"}) {
+		t.Errorf("getIgnoredPrefixes(%q) = %q, want the memoized [out]", tmp, got)
+	}
+
+	// A nil cache doesn't memoize anything.
+	var noCache *workspaceCache
+	if got := noCache.getIgnoredPrefixes(tmp); len(got) != 0 {
+		t.Errorf("getIgnoredPrefixes(%q) = %q, want []", tmp, got)
+	}
+}
+
+func TestGeneratedFiles(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	buildFile := filepath.Join(tmp, "BUILD")
+	build := `# Code generated by Gazelle. DO NOT EDIT.
+
+go_library(name = "lib")
+`
+	if err := os.WriteFile(buildFile, []byte(build), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.OutWriter = &out
+	opts.ErrWriter = &errOut
+	opts.IsPrintingJSON = true
+	if ret := Buildozer(opts, []string{"set testonly True", "//:lib"}); ret != 3 {
+		t.Errorf("Buildozer(set) = %d, want 3", ret)
+	}
+	if want := `"generatedFiles":["` + buildFile + `"]`; !strings.Contains(out.String(), want) {
+		t.Errorf("Buildozer(set) output = %q, want it to contain %q", out.String(), want)
+	}
+	if want := `contains "DO NOT EDIT"`; !strings.Contains(errOut.String(), want) {
+		t.Errorf("Buildozer(set) error output = %q, want it to contain %q", errOut.String(), want)
+	}
+	if content, err := os.ReadFile(buildFile); err != nil || string(content) != build {
+		t.Errorf("BUILD = %q, %v, want it unchanged", content, err)
+	}
+
+	// Read-only commands are allowed on generated files.
+	opts = NewOpts()
+	opts.RootDir = tmp
+	out.Reset()
+	opts.OutWriter = &out
+	opts.ErrWriter = io.Discard
+	if ret := Buildozer(opts, []string{"print name", "//:lib"}); ret != 0 || out.String() != "lib\n" {
+		t.Errorf("Buildozer(print) = %d, %q, want 0, %q", ret, out.String(), "lib\n")
+	}
+
+	// Custom markers replace the default ones.
+	opts.GeneratedMarkers = []string{"@generated"}
+	opts.Quiet = true
+	if ret := Buildozer(opts, []string{"set testonly True", "//:lib"}); ret != 0 {
+		t.Errorf("Buildozer(set) with custom markers = %d, want 0", ret)
+	}
+
+	opts.GeneratedMarkers = nil
+	opts.Force = true
+	if ret := Buildozer(opts, []string{"set visibility //visibility:public", "//:lib"}); ret != 0 {
+		t.Errorf("Buildozer(set) with Force = %d, want 0", ret)
+	}
+	content, err := os.ReadFile(buildFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `visibility = ["//visibility:public"]`; !strings.Contains(string(content), want) {
+		t.Errorf("BUILD = %s, want it to contain %s", content, want)
+	}
+}
+
+func TestGeneratedMarker(t *testing.T) {
+	for _, tc := range []struct {
+		data string
+		want string
+	}{
+		{"# Code generated by Gazelle. DO NOT EDIT.\n\ngo_library(name = \"lib\")\n", "DO NOT EDIT"},
+		{"# Copyright 2026\n\n# @generated\ngo_library(name = \"lib\")\n", "@generated"},
+		// Only the leading comments are checked, case-sensitively.
+		{"go_library(name = \"lib\")\n\n# DO NOT EDIT the list below by hand.\n", ""},
+		{"# Please do not edit the generated files.\n", ""},
+		{"", ""},
+	} {
+		if got := generatedMarker([]byte(tc.data), nil); got != tc.want {
+			t.Errorf("generatedMarker(%q) = %q, want %q", tc.data, got, tc.want)
+		}
+	}
+}
+
+func BenchmarkBuildozerCommandsFile(b *testing.B) {
+	const numPackages, numCommands = 100, 10000
+	tmp := b.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "MODULE.bazel"), []byte(`module(name = "bench")`), 0644); err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < numPackages; i++ {
+		dir := filepath.Join(tmp, fmt.Sprintf("pkg%d", i))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "BUILD"), []byte(`cc_library(name = "lib")`), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	var commands strings.Builder
+	for i := 0; i < numCommands; i++ {
+		fmt.Fprintf(&commands, "add deps :dep%d|//pkg%d:lib\n", i/numPackages, i%numPackages)
+	}
+	commandsFile := filepath.Join(tmp, "commands.txt")
+	if err := os.WriteFile(commandsFile, []byte(commands.String()), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.CommandsFiles = []string{commandsFile}
+	opts.Quiet = true
+	opts.OutWriter = io.Discard
+	opts.ErrWriter = io.Discard
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// The first iteration modifies the files, the following ones don't change them.
+		if ret := Buildozer(opts, nil); ret != 0 && ret != 3 {
+			b.Fatalf("Buildozer() = %d", ret)
+		}
+	}
+}
+
+func TestMinimalDiff(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	buildFile := filepath.Join(tmp, "BUILD")
+	build := `# Not formatted.
+cc_library(name="a", srcs=["a.cc"])
+cc_library(name="b",
+    srcs=["b.cc"])  # b
+
+
+
+cc_library(name="c", srcs=["c.cc"])
+`
+	for _, tc := range []struct {
+		commands []string
+		want     string
+	}{
+		{
+			commands: []string{"set testonly True", "//:b"},
+			want: `# Not formatted.
+cc_library(name="a", srcs=["a.cc"])
+
+cc_library(
+    name = "b",
+    testonly = True,
+    srcs = ["b.cc"],
+)  # b
+
+cc_library(name="c", srcs=["c.cc"])
+`,
+		},
+		{
+			commands: []string{"add deps :x", "//:a", "//:c"},
+			want: `# Not formatted.
+cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [":x"],
+)
+
+cc_library(name="b",
+    srcs=["b.cc"])  # b
+
+cc_library(
+    name = "c",
+    srcs = ["c.cc"],
+    deps = [":x"],
+)
+`,
+		},
+		{
+			commands: []string{"delete", "//:b"},
+			want: `# Not formatted.
+cc_library(name="a", srcs=["a.cc"])
+
+cc_library(name="c", srcs=["c.cc"])
+`,
+		},
+		{
+			commands: []string{"new cc_test t after a", "//:__pkg__"},
+			want: `# Not formatted.
+cc_library(name="a", srcs=["a.cc"])
+
+cc_test(name = "t")
+
+cc_library(name="b",
+    srcs=["b.cc"])  # b
+
+
+
+cc_library(name="c", srcs=["c.cc"])
+`,
+		},
+	} {
+		if err := os.WriteFile(buildFile, []byte(build), 0644); err != nil {
+			t.Fatal(err)
+		}
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.MinimalDiff = true
+		opts.OutWriter = io.Discard
+		opts.ErrWriter = io.Discard
+		if ret := Buildozer(opts, tc.commands); ret != 0 {
+			t.Errorf("Buildozer(%q) = %d, want 0", tc.commands, ret)
+		}
+		content, err := os.ReadFile(buildFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != tc.want {
+			t.Errorf("Buildozer(%q) with MinimalDiff:\n%s\nwant:\n%s", tc.commands, content, tc.want)
+		}
+	}
+}
+
+func TestFrozen(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	buildFile := filepath.Join(tmp, "BUILD")
+	build := `cc_library(
+    name = "a",
+    # buildozer: frozen
+    copts = ["-O3"],
+    deps = [":x"],  # Buildozer: frozen
+    srcs = ["a.cc"],
+)
+
+# buildozer: frozen
+cc_library(
+    name = "b",
+    copts = ["-O3"],
+)
+`
+	for _, tc := range []struct {
+		commands       []string
+		overrideFrozen bool
+		want           string
+		wantErr        string
+	}{
+		{
+			commands: []string{"set copts -O2", "add srcs b.cc", "rename deps implementation_deps", "comment srcs Sources.", "//:a"},
+			want: `cc_library(
+    name = "a",
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],  # Sources.
+    # buildozer: frozen
+    copts = ["-O3"],
+    deps = [":x"],  # Buildozer: frozen
+)
+
+# buildozer: frozen
+cc_library(
+    name = "b",
+    copts = ["-O3"],
+)
+`,
+			wantErr: "//:a: 'set copts -O2' skipped (frozen attribute copts), use -override_frozen to edit it\n" +
+				"//:a: 'rename deps implementation_deps' skipped (frozen attribute deps), use -override_frozen to edit it\n",
+		},
+		{
+			commands: []string{"set testonly True", "//:*"},
+			want: `cc_library(
+    name = "a",
+    testonly = True,
+    srcs = ["a.cc"],
+    # buildozer: frozen
+    copts = ["-O3"],
+    deps = [":x"],  # Buildozer: frozen
+)
+
+# buildozer: frozen
+cc_library(
+    name = "b",
+    copts = ["-O3"],
+)
+`,
+			wantErr: "//:b: 'set testonly True' skipped (frozen rule), use -override_frozen to edit it\n",
+		},
+		{
+			commands:       []string{"delete", "//:b"},
+			overrideFrozen: true,
+			want: `cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    # buildozer: frozen
+    copts = ["-O3"],
+    deps = [":x"],  # Buildozer: frozen
+)
+`,
+		},
+	} {
+		if err := os.WriteFile(buildFile, []byte(build), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var stderr bytes.Buffer
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.OverrideFrozen = tc.overrideFrozen
+		opts.OutWriter = io.Discard
+		opts.ErrWriter = &stderr
+		if ret := Buildozer(opts, tc.commands); ret != 0 {
+			t.Errorf("Buildozer(%q) = %d, want 0", tc.commands, ret)
+		}
+		content, err := os.ReadFile(buildFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != tc.want {
+			t.Errorf("Buildozer(%q):\n%s\nwant:\n%s", tc.commands, content, tc.want)
+		}
+		wantErr := strings.ReplaceAll(tc.wantErr, "//:", buildFile+": //:") + "fixed " + buildFile + "\n"
+		if got := stderr.String(); got != wantErr {
+			t.Errorf("Buildozer(%q) stderr:\n%s\nwant:\n%s", tc.commands, got, wantErr)
+		}
+	}
+}
+
+func TestGeneratedRegion(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	buildFile := filepath.Join(tmp, "BUILD")
+	build := `cc_library(name = "a")
+
+# buildifier: begin-generated
+cc_library(name = "b")
+# buildifier: end-generated
+`
+	for _, tc := range []struct {
+		commands []string
+		force    bool
+		want     string
+		wantErr  string
+	}{
+		{
+			commands: []string{"set testonly True", "//:*"},
+			want: `cc_library(
+    name = "a",
+    testonly = True,
+)
+
+# buildifier: begin-generated
+cc_library(name = "b")
+# buildifier: end-generated
+`,
+			wantErr: "//:b: 'set testonly True' skipped (in a generated region), use -force to edit it\n",
+		},
+		{
+			commands: []string{"set testonly True", "//:b"},
+			force:    true,
+			want: `cc_library(name = "a")
+
+# buildifier: begin-generated
+cc_library(
+    name = "b",
+    testonly = True,
+)
+# buildifier: end-generated
+`,
+		},
+	} {
+		if err := os.WriteFile(buildFile, []byte(build), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var stderr bytes.Buffer
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.Force = tc.force
+		opts.OutWriter = io.Discard
+		opts.ErrWriter = &stderr
+		if ret := Buildozer(opts, tc.commands); ret != 0 {
+			t.Errorf("Buildozer(%q) = %d, want 0", tc.commands, ret)
+		}
+		content, err := os.ReadFile(buildFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != tc.want {
+			t.Errorf("Buildozer(%q):\n%s\nwant:\n%s", tc.commands, content, tc.want)
+		}
+		wantErr := strings.ReplaceAll(tc.wantErr, "//:", buildFile+": //:") + "fixed " + buildFile + "\n"
+		if got := stderr.String(); got != wantErr {
+			t.Errorf("Buildozer(%q) stderr:\n%s\nwant:\n%s", tc.commands, got, wantErr)
+		}
+	}
+}
+
+func TestQueryCommands(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	build := `cc_library(
+    name = "a",
+    srcs = ["a.cc"],
+    deps = [":b"],
+)
+
+cc_library(name = "b")
+
+cc_test(name = "t")
+`
+	if err := os.WriteFile(filepath.Join(tmp, "BUILD"), []byte(build), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		commands []string
+		want     int
+		wantOut  string
+	}{
+		{[]string{"exists", "//:a", "//:t"}, 0, ""},
+		{[]string{"exists", "//:a", "//:missing"}, 4, ""},
+		{[]string{"exists", "//missing:a"}, 4, ""},
+		{[]string{"count", "//:%cc_library"}, 0, "2\n"},
+		{[]string{"count", "//:%java_library"}, 4, "0\n"},
+		{[]string{"attr_equals kind cc_library", "//:a", "//:b"}, 0, ""},
+		{[]string{"attr_equals deps //:b", "//:a"}, 0, ""},
+		{[]string{"attr_equals srcs a.cc b.cc", "//:a"}, 4, ""},
+		{[]string{"attr_equals srcs a.cc", "//:*"}, 4, ""},
+	} {
+		var stdout bytes.Buffer
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.OutWriter = &stdout
+		opts.ErrWriter = io.Discard
+		if ret := Buildozer(opts, tc.commands); ret != tc.want {
+			t.Errorf("Buildozer(%q) = %d, want %d", tc.commands, ret, tc.want)
+		}
+		if got := stdout.String(); got != tc.wantOut {
+			t.Errorf("Buildozer(%q) printed %q, want %q", tc.commands, got, tc.wantOut)
+		}
+	}
+}
+
+func TestParseMetaComment(t *testing.T) {
+	for _, tc := range []struct {
+		token  string
+		want   []metaPair
+		wantOK bool
+		format string
+	}{
+		{"# Hello", nil, false, ""},
+		{"# meta:", nil, true, "# meta:"},
+		{"#meta: owner=foo ticket=BUG-123", []metaPair{{key: "owner", value: "foo"}, {key: "ticket", value: "BUG-123"}}, true, "# meta: owner=foo ticket=BUG-123"},
+		{`# meta: note="some \"text\"" empty= flag`, []metaPair{{key: "note", value: `some "text"`}, {key: "empty"}, {key: "flag", bare: true}}, true, `# meta: note="some \"text\"" empty="" flag`},
+		{`# meta: broken="unterminated`, []metaPair{{key: "broken", value: `"unterminated`}}, true, `# meta: broken="\"unterminated"`},
+		{`# meta: lines="a\nb" ctrl="\x00\t" path=C:\dir`, []metaPair{{key: "lines", value: "a\nb"}, {key: "ctrl", value: "\x00\t"}, {key: "path", value: `C:\dir`}}, true, `# meta: lines="a\nb" ctrl="\x00\t" path=C:\dir`},
+	} {
+		got, ok := parseMetaComment(tc.token)
+		if !reflect.DeepEqual(got, tc.want) || ok != tc.wantOK {
+			t.Errorf("parseMetaComment(%q) = %v, %v, want %v, %v", tc.token, got, ok, tc.want, tc.wantOK)
+			continue
+		}
+		if ok {
+			if format := formatMetaComment(got); format != tc.format {
+				t.Errorf("formatMetaComment(parseMetaComment(%q)) = %q, want %q", tc.token, format, tc.format)
+			}
+		}
+	}
+}
@@ -18,7 +18,13 @@ limitations under the License.
 package bzlmod
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"path"
+	"sort"
+	"strings"
 
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/labels"
@@ -67,14 +73,34 @@ func AllProxies(f *build.File, proxy string) []string {
 	return nil
 }
 
+// ExtensionNames returns a map from the names of all extension proxies in the given file to the
+// names of the extensions they refer to, e.g. {"mvn": "maven"} for
+// `mvn = use_extension("@rules_jvm_external//:extensions.bzl", "maven")`.
+func ExtensionNames(f *build.File) map[string]string {
+	names := make(map[string]string)
+	for _, stmt := range f.Stmt {
+		proxy, _, name, _, _ := parseUseExtension(stmt)
+		if proxy != "" {
+			names[proxy] = name
+		}
+	}
+	return names
+}
+
 // UseRepos returns the use_repo calls that use the given proxies.
 func UseRepos(f *build.File, proxies []string) []*build.CallExpr {
+	return proxyCalls(f, "use_repo", proxies)
+}
+
+// proxyCalls returns the top-level calls of the given function whose first argument is one of
+// the given proxies, e.g. use_repo(ext, ...).
+func proxyCalls(f *build.File, function string, proxies []string) []*build.CallExpr {
 	proxiesSet := make(map[string]struct{})
 	for _, p := range proxies {
 		proxiesSet[p] = struct{}{}
 	}
 
-	var useRepos []*build.CallExpr
+	var calls []*build.CallExpr
 	for _, stmt := range f.Stmt {
 		if _, ok := stmt.(*build.CallExpr); !ok {
 			continue
@@ -83,7 +109,7 @@ func UseRepos(f *build.File, proxies []string) []*build.CallExpr {
 		if _, ok := call.X.(*build.Ident); !ok {
 			continue
 		}
-		if call.X.(*build.Ident).Name != "use_repo" || len(call.List) < 1 {
+		if call.X.(*build.Ident).Name != function || len(call.List) < 1 {
 			continue
 		}
 		proxy, ok := call.List[0].(*build.Ident)
@@ -93,10 +119,10 @@ func UseRepos(f *build.File, proxies []string) []*build.CallExpr {
 		if _, ok := proxiesSet[proxy.Name]; !ok {
 			continue
 		}
-		useRepos = append(useRepos, call)
+		calls = append(calls, call)
 	}
 
-	return useRepos
+	return calls
 }
 
 // NewUseRepo inserts and returns a new use_repo call after the last usage of any of the given
@@ -118,46 +144,205 @@ func NewUseRepo(f *build.File, proxies []string) (*build.File, *build.CallExpr)
 	return &build.File{Path: f.Path, Comments: f.Comments, Stmt: stmt, Type: build.TypeModule}, useRepo
 }
 
+// A RepoSpread is a `*list` or `**dict` argument of a use_repo call.
+type RepoSpread struct {
+	Call *build.CallExpr
+	Arg  *build.UnaryExpr
+	// Value is the list or dict literal the argument expands to, or nil if it isn't a literal
+	// or a top-level variable of the file assigned to one.
+	Value build.Expr
+	// Variable is the name of the top-level variable the argument refers to, if any.
+	Variable string
+}
+
+// Repos returns the repositories used by the spread, identified via their names as exported by
+// the module extension (i.e. the values in the case of a dict).
+func (s *RepoSpread) Repos() []string {
+	var repos []string
+	switch value := s.Value.(type) {
+	case *build.ListExpr:
+		for _, elem := range value.List {
+			if str, ok := elem.(*build.StringExpr); ok {
+				repos = append(repos, str.Value)
+			}
+		}
+	case *build.DictExpr:
+		for _, kv := range value.List {
+			if str, ok := kv.Value.(*build.StringExpr); ok {
+				repos = append(repos, str.Value)
+			}
+		}
+	}
+	return repos
+}
+
+// A SpreadError reports a `*list` or `**dict` argument of a use_repo call that couldn't be
+// taken into account, so the repositories it uses may have been added twice or not removed.
+type SpreadError struct {
+	Spread *RepoSpread
+	Reason string
+}
+
+func (e *SpreadError) Error() string {
+	start, _ := e.Spread.Arg.Span()
+	return fmt.Sprintf("use_repo argument %s at line %d %s", build.FormatString(e.Spread.Arg), start.Line, e.Reason)
+}
+
+// RepoSpreads returns the `*list` and `**dict` arguments of the given use_repo calls, resolving
+// them to list and dict literals assigned to top-level variables of the file where possible.
+// If f is nil, only the literals given directly as arguments are resolved.
+func RepoSpreads(f *build.File, useRepos []*build.CallExpr) []*RepoSpread {
+	constants := make(map[string]build.Expr)
+	assignments := make(map[string]int)
+	var stmts []build.Expr
+	if f != nil {
+		stmts = f.Stmt
+	}
+	for _, stmt := range stmts {
+		assign, ok := stmt.(*build.AssignExpr)
+		if !ok || assign.Op != "=" {
+			continue
+		}
+		if ident, ok := assign.LHS.(*build.Ident); ok {
+			constants[ident.Name] = assign.RHS
+			assignments[ident.Name]++
+		}
+	}
+
+	var spreads []*RepoSpread
+	for _, useRepo := range useRepos {
+		for _, arg := range useRepo.List {
+			unary, ok := arg.(*build.UnaryExpr)
+			if !ok || (unary.Op != "*" && unary.Op != "**") {
+				continue
+			}
+			spread := &RepoSpread{Call: useRepo, Arg: unary}
+			value := unary.X
+			if ident, ok := value.(*build.Ident); ok {
+				spread.Variable = ident.Name
+				if assignments[ident.Name] == 1 {
+					value = constants[ident.Name]
+				}
+			}
+			if isRepoLiteral(value, unary.Op) {
+				spread.Value = value
+			}
+			spreads = append(spreads, spread)
+		}
+	}
+	return spreads
+}
+
+// isRepoLiteral checks whether expr is a literal that can be spread into use_repo arguments with
+// the given operator: a list of strings for "*", or a dict of strings for "**".
+func isRepoLiteral(expr build.Expr, op string) bool {
+	switch expr := expr.(type) {
+	case *build.ListExpr:
+		if op != "*" {
+			return false
+		}
+		for _, elem := range expr.List {
+			if _, ok := elem.(*build.StringExpr); !ok {
+				return false
+			}
+		}
+		return true
+	case *build.DictExpr:
+		if op != "**" {
+			return false
+		}
+		for _, kv := range expr.List {
+			if _, ok := kv.Key.(*build.StringExpr); !ok {
+				return false
+			}
+			if _, ok := kv.Value.(*build.StringExpr); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// unresolvedSpreadErrors returns errors for the spreads that couldn't be resolved.
+func unresolvedSpreadErrors(spreads []*RepoSpread) []error {
+	var errs []error
+	for _, spread := range spreads {
+		if spread.Value == nil {
+			errs = append(errs, &SpreadError{spread, "is not a list or dict of strings defined in the file"})
+		}
+	}
+	return errs
+}
+
 // AddRepoUsages adds the given repos to the given use_repo calls without introducing duplicate
 // arguments.
 // useRepos must not be empty.
 // Keyword arguments are preserved but adding them is currently not supported.
+// The arguments of the edited use_repo call are sorted as by SortUseRepos, so that the file is
+// canonical even if it isn't formatted afterwards.
+// Use AddRepoUsagesInFile to take the `*list` and `**dict` arguments referring to variables of
+// the file into account.
 func AddRepoUsages(useRepos []*build.CallExpr, repos ...string) {
+	AddRepoUsagesInFile(nil, useRepos, repos...)
+}
+
+// AddRepoUsagesInFile is like AddRepoUsages for use_repo calls of the file f, also taking into
+// account the repos used via `*list` and `**dict` arguments that resolve to literals in the file.
+// The returned error consists of a SpreadError for each spread argument that couldn't be
+// resolved, the repos are added regardless.
+func AddRepoUsagesInFile(f *build.File, useRepos []*build.CallExpr, repos ...string) error {
 	if len(repos) == 0 {
-		return
+		return nil
 	}
 	if len(useRepos) == 0 {
 		panic("useRepos must not be empty")
 	}
 
-	seen := make(map[string]struct{})
-	for _, useRepo := range useRepos {
-		if len(useRepo.List) == 0 {
-			// Invalid use_repo call, skip.
-			continue
-		}
-		for _, arg := range useRepo.List[1:] {
-			seen[repoFromUseRepoArg(arg)] = struct{}{}
-		}
-	}
-
+	seen, spreads := usedRepos(f, useRepos)
 	lastUseRepo := getLastUseRepo(useRepos)
+	added := false
 	for _, repo := range repos {
 		if _, ok := seen[repo]; ok {
 			continue
 		}
-		// Sorting of use_repo arguments is handled by Buildify.
 		// TODO: Add a keyword argument instead if repo is of the form "key=value".
 		lastUseRepo.List = append(lastUseRepo.List, &build.StringExpr{Value: repo})
+		added = true
+	}
+	if added {
+		SortUseRepos(lastUseRepo)
+	}
+	return errors.Join(unresolvedSpreadErrors(spreads)...)
+}
+
+// SortUseRepos puts the arguments of the given use_repo calls in the order buildifier would:
+// the module extension proxy first, then the repository names passed as positional arguments
+// sorted and deduplicated, then the keyword arguments sorted by key. Unlike build.Format, it
+// doesn't change the formatting of the rest of the file.
+func SortUseRepos(useRepos ...*build.CallExpr) {
+	for _, useRepo := range useRepos {
+		build.SortUseRepoArgs(useRepo)
 	}
 }
 
 // RemoveRepoUsages removes the given repos from the given use_repo calls.
 // Repositories are identified via their names as exported by the module extension (i.e. the value
 // rather than the key in the case of keyword arguments).
+// Use RemoveRepoUsagesInFile to also remove the repos used via `*list` and `**dict` arguments
+// referring to variables of the file.
 func RemoveRepoUsages(useRepos []*build.CallExpr, repos ...string) {
+	RemoveRepoUsagesInFile(nil, useRepos, repos...)
+}
+
+// RemoveRepoUsagesInFile is like RemoveRepoUsages for use_repo calls of the file f. Repos used via
+// `*list` and `**dict` arguments are also removed from the list or dict literal the argument
+// refers to, unless the variable holding it is also used elsewhere in the file.
+// The returned error consists of a SpreadError for each spread argument that couldn't be resolved
+// or edited.
+func RemoveRepoUsagesInFile(f *build.File, useRepos []*build.CallExpr, repos ...string) error {
 	if len(useRepos) == 0 || len(repos) == 0 {
-		return
+		return nil
 	}
 
 	toRemove := make(map[string]struct{})
@@ -180,6 +365,244 @@ func RemoveRepoUsages(useRepos []*build.CallExpr, repos ...string) {
 		}
 		useRepo.List = append(useRepo.List[:1], args...)
 	}
+
+	spreads := RepoSpreads(f, useRepos)
+	errs := unresolvedSpreadErrors(spreads)
+	spreadUses := make(map[string]int)
+	for _, spread := range spreads {
+		if spread.Variable != "" {
+			spreadUses[spread.Variable]++
+		}
+	}
+	for _, spread := range spreads {
+		if spread.Value == nil || !containsAny(spread.Repos(), toRemove) {
+			continue
+		}
+		if spread.Variable != "" && variableUses(f, spread.Variable) > spreadUses[spread.Variable] {
+			errs = append(errs, &SpreadError{spread, fmt.Sprintf("refers to %s, which is also used elsewhere", spread.Variable)})
+			continue
+		}
+		switch value := spread.Value.(type) {
+		case *build.ListExpr:
+			var list []build.Expr
+			for _, elem := range value.List {
+				if _, remove := toRemove[elem.(*build.StringExpr).Value]; !remove {
+					list = append(list, elem)
+				}
+			}
+			value.List = list
+		case *build.DictExpr:
+			var list []*build.KeyValueExpr
+			for _, kv := range value.List {
+				if _, remove := toRemove[kv.Value.(*build.StringExpr).Value]; !remove {
+					list = append(list, kv)
+				}
+			}
+			value.List = list
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// usedRepos returns the repos used by the given use_repo calls, including the ones used via
+// `*list` and `**dict` arguments that resolve to literals in the file, and the spread arguments.
+func usedRepos(f *build.File, useRepos []*build.CallExpr) (map[string]struct{}, []*RepoSpread) {
+	seen := make(map[string]struct{})
+	for _, useRepo := range useRepos {
+		if len(useRepo.List) == 0 {
+			// Invalid use_repo call, skip.
+			continue
+		}
+		for _, arg := range useRepo.List[1:] {
+			if repo := repoFromUseRepoArg(arg); repo != "" {
+				seen[repo] = struct{}{}
+			}
+		}
+	}
+	spreads := RepoSpreads(f, useRepos)
+	for _, spread := range spreads {
+		for _, repo := range spread.Repos() {
+			seen[repo] = struct{}{}
+		}
+	}
+	return seen, spreads
+}
+
+// ExtensionMetadata describes the repos a module extension reports as direct dependencies of the
+// root module via `module_ctx.extension_metadata`, as printed by Bazel when the use_repo calls
+// of the root module don't match them.
+type ExtensionMetadata struct {
+	// ExtensionBzlFile is the label of the .bzl file defining the extension.
+	ExtensionBzlFile string `json:"extension_bzl_file"`
+	// ExtensionName is the name of the extension.
+	ExtensionName string `json:"extension_name"`
+	// RootModuleDirectDeps are the repos the regular usages of the extension should import,
+	// nil if the extension doesn't report them.
+	RootModuleDirectDeps *DirectDeps `json:"root_module_direct_deps"`
+	// RootModuleDirectDevDeps are the repos the dev_dependency usages of the extension should
+	// import, nil if the extension doesn't report them.
+	RootModuleDirectDevDeps *DirectDeps `json:"root_module_direct_dev_deps"`
+}
+
+// DirectDeps are the direct dependencies of the root module reported by a module extension,
+// either a list of repos or the special value "all".
+type DirectDeps struct {
+	Repos []string // the reported repos, empty if All is set
+	All   bool     // all the repos generated by the extension, which buildozer doesn't know
+}
+
+// UnmarshalJSON parses a JSON list of repo names or the string "all".
+func (d *DirectDeps) UnmarshalJSON(data []byte) error {
+	var all string
+	if err := json.Unmarshal(data, &all); err == nil {
+		if all != "all" {
+			return fmt.Errorf(`direct deps must be a list of repos or "all", got %q`, all)
+		}
+		d.All = true
+		return nil
+	}
+	return json.Unmarshal(data, &d.Repos)
+}
+
+func (d *DirectDeps) isAll() bool {
+	return d != nil && d.All
+}
+
+func (d *DirectDeps) isEmpty() bool {
+	return d == nil || !d.All && len(d.Repos) == 0
+}
+
+// ParseExtensionMetadata parses a JSON object or a JSON list of objects describing the
+// metadata of module extensions.
+func ParseExtensionMetadata(data []byte) ([]*ExtensionMetadata, error) {
+	data = bytes.TrimSpace(data)
+	var metadata []*ExtensionMetadata
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			return nil, err
+		}
+	} else {
+		m := &ExtensionMetadata{}
+		if err := json.Unmarshal(data, m); err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, m)
+	}
+	for _, m := range metadata {
+		if m.ExtensionBzlFile == "" || m.ExtensionName == "" {
+			return nil, fmt.Errorf("extension metadata must specify extension_bzl_file and extension_name")
+		}
+		// As in Bazel, "all" can only be used for one of the lists and the lists are disjoint,
+		// so the other one must be empty.
+		deps, devDeps := m.RootModuleDirectDeps, m.RootModuleDirectDevDeps
+		if deps.isAll() && !devDeps.isEmpty() || devDeps.isAll() && !deps.isEmpty() {
+			return nil, fmt.Errorf(`extension metadata of %q: if one of root_module_direct_deps and root_module_direct_dev_deps is "all", the other must be empty`, m.ExtensionName)
+		}
+	}
+	return metadata, nil
+}
+
+// SyncRepoUsages updates the use_repo calls of the non-isolated usages of a module extension
+// to import exactly the repos reported by its metadata, adding a use_repo call if necessary.
+// Regular and dev_dependency usages are handled separately, a nil list of repos in the metadata
+// leaves the corresponding usages untouched, and so does "all" since the repos generated by the
+// extension aren't known.
+// The returned error reports the spread arguments that couldn't be taken into account and the
+// reported repos that couldn't be imported because the extension isn't used, the other edits
+// are applied regardless.
+func SyncRepoUsages(f *build.File, metadata *ExtensionMetadata) (*build.File, error) {
+	var errs []error
+	seenErrs := make(map[string]bool)
+	// AddRepoUsages and RemoveRepoUsages both report the unresolved spread arguments.
+	addErrs := func(err error) {
+		joined, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			return
+		}
+		for _, e := range joined.Unwrap() {
+			if !seenErrs[e.Error()] {
+				seenErrs[e.Error()] = true
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	for _, dev := range []bool{false, true} {
+		deps := metadata.RootModuleDirectDeps
+		if dev {
+			deps = metadata.RootModuleDirectDevDeps
+		}
+		if deps == nil || deps.All {
+			continue
+		}
+		want := deps.Repos
+		proxies := Proxies(f, metadata.ExtensionBzlFile, metadata.ExtensionName, dev)
+		if len(proxies) == 0 {
+			if len(want) > 0 {
+				kind := "regular"
+				if dev {
+					kind = "dev_dependency"
+				}
+				errs = append(errs, fmt.Errorf("no %s usage of extension %q defined in %q to import %s",
+					kind, metadata.ExtensionName, metadata.ExtensionBzlFile, strings.Join(want, ", ")))
+			}
+			continue
+		}
+
+		useRepos := UseRepos(f, proxies)
+		current, _ := usedRepos(f, useRepos)
+		wantSet := make(map[string]struct{})
+		var add []string
+		for _, repo := range want {
+			wantSet[repo] = struct{}{}
+			if _, ok := current[repo]; !ok {
+				add = append(add, repo)
+			}
+		}
+		var remove []string
+		for repo := range current {
+			if _, ok := wantSet[repo]; !ok {
+				remove = append(remove, repo)
+			}
+		}
+		sort.Strings(remove)
+
+		if len(add) > 0 && len(useRepos) == 0 {
+			var newUseRepo *build.CallExpr
+			f, newUseRepo = NewUseRepo(f, proxies)
+			useRepos = []*build.CallExpr{newUseRepo}
+		}
+		addErrs(RemoveRepoUsagesInFile(f, useRepos, remove...))
+		addErrs(AddRepoUsagesInFile(f, useRepos, add...))
+	}
+	return f, errors.Join(errs...)
+}
+
+func containsAny(repos []string, set map[string]struct{}) bool {
+	for _, repo := range repos {
+		if _, ok := set[repo]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// variableUses returns the number of times the given top-level variable is read in the file.
+func variableUses(f *build.File, name string) int {
+	uses := 0
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		ident, ok := expr.(*build.Ident)
+		if !ok || ident.Name != name {
+			return
+		}
+		if len(stack) > 0 {
+			if assign, ok := stack[len(stack)-1].(*build.AssignExpr); ok && assign.LHS == expr {
+				return
+			}
+		}
+		uses++
+	})
+	return uses
 }
 
 func getLastUseRepo(useRepos []*build.CallExpr) *build.CallExpr {
@@ -17,6 +17,8 @@ limitations under the License.
 package bzlmod
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"testing"
@@ -468,6 +470,70 @@ use_repo(prox, "repo3", "repo4")
 	}
 }
 
+func TestSortUseRepos(t *testing.T) {
+	for i, tc := range []struct {
+		content         string
+		repos           []string
+		expectedContent string
+	}{
+		{
+			`use_repo(prox, "b", "c", "a", "b", z = "z", y = "y")`,
+			nil,
+			`use_repo(prox, "a", "b", "c", y = "y", z = "z")
+`,
+		},
+		{
+			`use_repo(prox, "repo2",   "repo4")`,
+			[]string{"repo3", "repo1"},
+			`use_repo(prox, "repo1", "repo2", "repo3", "repo4")
+`,
+		},
+		{
+			`use_repo(
+    prox,
+    # do not sort
+    "repo2",
+    "repo1",
+)`,
+			[]string{"repo0"},
+			`use_repo(
+    prox,
+    # do not sort
+    "repo2",
+    "repo1",
+    "repo0",
+)
+`,
+		},
+		{
+			`# buildifier: leave-alone
+use_repo(prox, "b", "a")`,
+			nil,
+			`# buildifier: leave-alone
+use_repo(prox, "b", "a")
+`,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			f, err := build.ParseModule("MODULE.bazel", []byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			useRepos := UseRepos(f, []string{"prox"})
+			if tc.repos == nil {
+				SortUseRepos(useRepos...)
+			} else {
+				AddRepoUsages(useRepos, tc.repos...)
+			}
+			// The file isn't rewritten by the formatter, the arguments must already be sorted.
+			actualContent := string(build.FormatWithoutRewriting(f))
+			if actualContent != tc.expectedContent {
+				t.Errorf("want:\n%q\ngot:\n%q\n", tc.expectedContent, actualContent)
+			}
+		})
+	}
+}
+
 func TestRemoveRepoUsages(t *testing.T) {
 	for i, tc := range []struct {
 		content         string
@@ -547,3 +613,228 @@ use_repo(prox)
 		})
 	}
 }
+
+func TestRepoUsagesWithSpreads(t *testing.T) {
+	for i, tc := range []struct {
+		content         string
+		add             bool
+		repos           []string
+		expectedContent string
+		expectedErr     string
+	}{
+		{
+			`REPOS = ["repo1", "repo2"]
+
+use_repo(prox, *REPOS)`,
+			true,
+			[]string{"repo2", "repo3"},
+			`REPOS = [
+    "repo1",
+    "repo2",
+]
+
+use_repo(prox, "repo3", *REPOS)
+`,
+			"",
+		},
+		{
+			`REPOS = {"my_repo": "repo1"}
+
+use_repo(prox, **REPOS)`,
+			true,
+			[]string{"repo1"},
+			`REPOS = {"my_repo": "repo1"}
+
+use_repo(prox, **REPOS)
+`,
+			"",
+		},
+		{
+			`load(":repos.bzl", "REPOS")
+
+use_repo(prox, *REPOS)`,
+			true,
+			[]string{"repo1"},
+			`load(":repos.bzl", "REPOS")
+
+use_repo(prox, "repo1", *REPOS)
+`,
+			`use_repo argument *REPOS at line 3 is not a list or dict of strings defined in the file`,
+		},
+		{
+			`REPOS = ["repo1", "repo2"]
+
+MAPPED = {"my_repo": "repo3"}
+
+use_repo(prox, "repo4", *REPOS, **MAPPED)`,
+			false,
+			[]string{"repo1", "repo3", "repo4"},
+			`REPOS = ["repo2"]
+
+MAPPED = {}
+
+use_repo(prox, *REPOS, **MAPPED)
+`,
+			"",
+		},
+		{
+			`REPOS = ["repo1", "repo2"]
+
+ALL = REPOS
+
+use_repo(prox, *REPOS)`,
+			false,
+			[]string{"repo1"},
+			`REPOS = [
+    "repo1",
+    "repo2",
+]
+
+ALL = REPOS
+
+use_repo(prox, *REPOS)
+`,
+			`use_repo argument *REPOS at line 5 refers to REPOS, which is also used elsewhere`,
+		},
+		{
+			`use_repo(prox, *["repo1", "repo2"], **get_repos())`,
+			false,
+			[]string{"repo1"},
+			`use_repo(prox, *["repo2"], **get_repos())
+`,
+			`use_repo argument **get_repos() at line 1 is not a list or dict of strings defined in the file`,
+		},
+	} {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			f, err := build.ParseModule("MODULE.bazel", []byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			useRepos := UseRepos(f, []string{"prox"})
+			if tc.add {
+				err = AddRepoUsagesInFile(f, useRepos, tc.repos...)
+			} else {
+				err = RemoveRepoUsagesInFile(f, useRepos, tc.repos...)
+			}
+			if actualErr := fmt.Sprint(err); err != nil && actualErr != tc.expectedErr || err == nil && tc.expectedErr != "" {
+				t.Errorf("want error %q, got %q", tc.expectedErr, actualErr)
+			}
+			var spreadErr *SpreadError
+			if err != nil && !errors.As(err, &spreadErr) {
+				t.Errorf("want a SpreadError, got %T", err)
+			}
+			actualContent := string(build.Format(f))
+			if actualContent != tc.expectedContent {
+				t.Errorf("want:\n%q\ngot:\n%q\n", tc.expectedContent, actualContent)
+			}
+		})
+	}
+}
+
+func TestSyncRepoUsages(t *testing.T) {
+	metadata, err := ParseExtensionMetadata([]byte(`[{
+  "extension_bzl_file": "@gazelle//:extensions.bzl",
+  "extension_name": "go_deps",
+  "root_module_direct_deps": ["com_example_bar", "com_example_foo", "org_example_new"],
+  "root_module_direct_dev_deps": ["org_example_test"]
+}, {
+  "extension_bzl_file": "//:extensions.bzl",
+  "extension_name": "my_ext",
+  "root_module_direct_deps": ["repo1"]
+}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metadata) != 2 || metadata[1].RootModuleDirectDevDeps != nil || metadata[1].RootModuleDirectDeps.All {
+		t.Fatalf("ParseExtensionMetadata() = %+v", metadata)
+	}
+
+	f, err := build.ParseModule("MODULE.bazel", []byte(`module(name = "my_module")
+
+go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")
+go_deps.from_file(go_mod = "//:go.mod")
+use_repo(go_deps, "com_example_foo", "com_example_unused", bar = "com_example_bar")
+
+go_dev_deps = use_extension("@gazelle//:extensions.bzl", "go_deps", dev_dependency = True)
+go_dev_deps.module(path = "example.org/test")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err = SyncRepoUsages(f, metadata[0])
+	if err != nil {
+		t.Errorf("SyncRepoUsages() = %v, want no error", err)
+	}
+	f, err = SyncRepoUsages(f, metadata[1])
+	if want := `no regular usage of extension "my_ext" defined in "//:extensions.bzl" to import repo1`; fmt.Sprint(err) != want {
+		t.Errorf("SyncRepoUsages() = %v, want %q", err, want)
+	}
+
+	want := `module(name = "my_module")
+
+go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")
+go_deps.from_file(go_mod = "//:go.mod")
+use_repo(go_deps, "com_example_foo", "org_example_new", bar = "com_example_bar")
+
+go_dev_deps = use_extension("@gazelle//:extensions.bzl", "go_deps", dev_dependency = True)
+go_dev_deps.module(path = "example.org/test")
+use_repo(go_dev_deps, "org_example_test")
+`
+	if got := string(build.Format(f)); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	if _, err := ParseExtensionMetadata([]byte(`{"extension_name": "go_deps"}`)); err == nil {
+		t.Error("ParseExtensionMetadata() without extension_bzl_file succeeded, want an error")
+	}
+}
+
+func TestSyncRepoUsagesAll(t *testing.T) {
+	metadata, err := ParseExtensionMetadata([]byte(`{
+  "extension_bzl_file": "//:extensions.bzl",
+  "extension_name": "my_ext",
+  "root_module_direct_deps": "all",
+  "root_module_direct_dev_deps": []
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := metadata[0]; !m.RootModuleDirectDeps.All || m.RootModuleDirectDevDeps == nil || m.RootModuleDirectDevDeps.All {
+		t.Fatalf("ParseExtensionMetadata() = %+v", m)
+	}
+
+	f, err := build.ParseModule("MODULE.bazel", []byte(`my_ext = use_extension("//:extensions.bzl", "my_ext")
+use_repo(my_ext, "repo1")
+
+my_dev_ext = use_extension("//:extensions.bzl", "my_ext", dev_dependency = True)
+use_repo(my_dev_ext, "repo2")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The regular usages are left untouched, the repos generated by the extension aren't known.
+	f, err = SyncRepoUsages(f, metadata[0])
+	if err != nil {
+		t.Errorf("SyncRepoUsages() = %v, want no error", err)
+	}
+	want := `my_ext = use_extension("//:extensions.bzl", "my_ext")
+use_repo(my_ext, "repo1")
+
+my_dev_ext = use_extension("//:extensions.bzl", "my_ext", dev_dependency = True)
+use_repo(my_dev_ext)
+`
+	if got := string(build.Format(f)); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+
+	for _, data := range []string{
+		`{"extension_bzl_file": "//:extensions.bzl", "extension_name": "my_ext", "root_module_direct_deps": "all", "root_module_direct_dev_deps": "all"}`,
+		`{"extension_bzl_file": "//:extensions.bzl", "extension_name": "my_ext", "root_module_direct_deps": ["repo1"], "root_module_direct_dev_deps": "all"}`,
+		`{"extension_bzl_file": "//:extensions.bzl", "extension_name": "my_ext", "root_module_direct_deps": "some"}`,
+	} {
+		if _, err := ParseExtensionMetadata([]byte(data)); err == nil {
+			t.Errorf("ParseExtensionMetadata(%s) succeeded, want an error", data)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bzlmod
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// versionPattern matches module versions accepted by Bazel, see
+// https://bazel.build/external/module#version_format
+var versionPattern = regexp.MustCompile(`(?i)^(?:\d+|[a-z]\w*)(?:\.(?:\d+|[a-z]\w*))*` +
+	`(?:-(?:\d+|[a-z][\w-]*)(?:\.(?:\d+|[a-z][\w-]*))*)?` +
+	`(?:\+[\w.-]*)?$`)
+
+// bazelCompatibilityPattern matches the constraints accepted by the bazel_compatibility attribute
+// of module().
+var bazelCompatibilityPattern = regexp.MustCompile(`^(?:>=|<=|>|<|-)\d+\.\d+\.\d+$`)
+
+// ValidateVersion returns an error if the given string is not a valid module version. The empty
+// string is a valid version.
+func ValidateVersion(version string) error {
+	if version != "" && !versionPattern.MatchString(version) {
+		return fmt.Errorf("invalid module version %q", version)
+	}
+	return nil
+}
+
+// ValidateBazelCompatibility returns an error if the given string is not a valid
+// bazel_compatibility constraint, e.g. ">=7.0.0" or "-7.1.0".
+func ValidateBazelCompatibility(constraint string) error {
+	if !bazelCompatibilityPattern.MatchString(constraint) {
+		return fmt.Errorf("invalid bazel_compatibility constraint %q, expected one of '>', '<', '>=', '<=' or '-' followed by a version of the form X.Y.Z", constraint)
+	}
+	return nil
+}
+
+// moduleRule returns the module() call of the given MODULE.bazel file.
+func moduleRule(f *build.File) (*build.Rule, error) {
+	modules := f.Rules("module")
+	if len(modules) == 0 {
+		return nil, fmt.Errorf("no module() call found")
+	}
+	return modules[0], nil
+}
+
+// bazelDepRule returns the bazel_dep call for the given module.
+func bazelDepRule(f *build.File, moduleName string) (*build.Rule, error) {
+	for _, dep := range f.Rules("bazel_dep") {
+		if dep.AttrString("name") == moduleName {
+			return dep, nil
+		}
+	}
+	return nil, fmt.Errorf("no bazel_dep found for module %q", moduleName)
+}
+
+// intAttr returns the value of an integer attribute, or def if the attribute is not set.
+func intAttr(r *build.Rule, key string, def int) (int, error) {
+	if r.Attr(key) == nil {
+		return def, nil
+	}
+	value, err := r.AttrInt(key)
+	if err != nil {
+		return 0, fmt.Errorf("%s of %s() is not an integer literal", key, r.Kind())
+	}
+	return value, nil
+}
+
+// ModuleVersion returns the version of the module defined in the given MODULE.bazel file.
+func ModuleVersion(f *build.File) (string, error) {
+	module, err := moduleRule(f)
+	if err != nil {
+		return "", err
+	}
+	return module.AttrString("version"), nil
+}
+
+// SetModuleVersion sets the version of the module defined in the given MODULE.bazel file.
+func SetModuleVersion(f *build.File, version string) error {
+	if err := ValidateVersion(version); err != nil {
+		return err
+	}
+	module, err := moduleRule(f)
+	if err != nil {
+		return err
+	}
+	module.SetAttr("version", &build.StringExpr{Value: version})
+	return nil
+}
+
+// CompatibilityLevel returns the compatibility level of the module defined in the given
+// MODULE.bazel file. Bazel uses 0 if the attribute is not set.
+func CompatibilityLevel(f *build.File) (int, error) {
+	module, err := moduleRule(f)
+	if err != nil {
+		return 0, err
+	}
+	return intAttr(module, "compatibility_level", 0)
+}
+
+// SetCompatibilityLevel sets the compatibility level of the module defined in the given
+// MODULE.bazel file.
+func SetCompatibilityLevel(f *build.File, level int) error {
+	if level < 0 {
+		return fmt.Errorf("invalid compatibility_level %d, must not be negative", level)
+	}
+	module, err := moduleRule(f)
+	if err != nil {
+		return err
+	}
+	module.SetAttrInt("compatibility_level", level)
+	return nil
+}
+
+// MaxCompatibilityLevel returns the max_compatibility_level of the bazel_dep on the given module.
+// Bazel uses -1 (no maximum) if the attribute is not set.
+func MaxCompatibilityLevel(f *build.File, moduleName string) (int, error) {
+	dep, err := bazelDepRule(f, moduleName)
+	if err != nil {
+		return 0, err
+	}
+	return intAttr(dep, "max_compatibility_level", -1)
+}
+
+// SetMaxCompatibilityLevel sets the max_compatibility_level of the bazel_dep on the given module.
+// A negative level removes the attribute.
+func SetMaxCompatibilityLevel(f *build.File, moduleName string, level int) error {
+	dep, err := bazelDepRule(f, moduleName)
+	if err != nil {
+		return err
+	}
+	if level < 0 {
+		dep.DelAttr("max_compatibility_level")
+		return nil
+	}
+	dep.SetAttrInt("max_compatibility_level", level)
+	return nil
+}
+
+// BazelCompatibility returns the bazel_compatibility constraints of the module defined in the
+// given MODULE.bazel file.
+func BazelCompatibility(f *build.File) ([]string, error) {
+	module, err := moduleRule(f)
+	if err != nil {
+		return nil, err
+	}
+	return module.AttrStrings("bazel_compatibility"), nil
+}
+
+// SetBazelCompatibility replaces the bazel_compatibility constraints of the module defined in the
+// given MODULE.bazel file. An empty list removes the attribute.
+func SetBazelCompatibility(f *build.File, constraints []string) error {
+	for _, c := range constraints {
+		if err := ValidateBazelCompatibility(c); err != nil {
+			return err
+		}
+	}
+	module, err := moduleRule(f)
+	if err != nil {
+		return err
+	}
+	if len(constraints) == 0 {
+		module.DelAttr("bazel_compatibility")
+		return nil
+	}
+	var list []build.Expr
+	for _, c := range constraints {
+		list = append(list, &build.StringExpr{Value: c})
+	}
+	module.SetAttr("bazel_compatibility", &build.ListExpr{List: list})
+	return nil
+}
+
+// AddBazelCompatibility adds the given constraints to the bazel_compatibility attribute of the
+// module defined in the given MODULE.bazel file, without introducing duplicates.
+func AddBazelCompatibility(f *build.File, constraints ...string) error {
+	current, err := BazelCompatibility(f)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, c := range current {
+		seen[c] = true
+	}
+	for _, c := range constraints {
+		if !seen[c] {
+			seen[c] = true
+			current = append(current, c)
+		}
+	}
+	return SetBazelCompatibility(f, current)
+}
+
+// RemoveBazelCompatibility removes the given constraints from the bazel_compatibility attribute
+// of the module defined in the given MODULE.bazel file.
+func RemoveBazelCompatibility(f *build.File, constraints ...string) error {
+	current, err := BazelCompatibility(f)
+	if err != nil {
+		return err
+	}
+	toRemove := make(map[string]bool)
+	for _, c := range constraints {
+		toRemove[c] = true
+	}
+	var remaining []string
+	for _, c := range current {
+		if !toRemove[c] {
+			remaining = append(remaining, c)
+		}
+	}
+	return SetBazelCompatibility(f, remaining)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bzlmod
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestValidateVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version string
+		valid   bool
+	}{
+		{"", true},
+		{"1.2.3", true},
+		{"1.2.3-rc.1", true},
+		{"1.2.3+build.5", true},
+		{"2024.01.01.bcr.1", true},
+		{"1..2", false},
+		{".1", false},
+		{"1.2 3", false},
+		{"1.2-", false},
+	} {
+		if err := ValidateVersion(tc.version); (err == nil) != tc.valid {
+			t.Errorf("ValidateVersion(%q) = %v, want valid: %v", tc.version, err, tc.valid)
+		}
+	}
+}
+
+func TestValidateBazelCompatibility(t *testing.T) {
+	for _, tc := range []struct {
+		constraint string
+		valid      bool
+	}{
+		{">=7.0.0", true},
+		{"<8.0.0", true},
+		{"-7.1.2", true},
+		{"7.0.0", false},
+		{">=7.0", false},
+		{"=>7.0.0", false},
+	} {
+		if err := ValidateBazelCompatibility(tc.constraint); (err == nil) != tc.valid {
+			t.Errorf("ValidateBazelCompatibility(%q) = %v, want valid: %v", tc.constraint, err, tc.valid)
+		}
+	}
+}
+
+func TestModuleCompatibility(t *testing.T) {
+	f, err := build.ParseModule("MODULE.bazel", []byte(`module(
+    name = "foo",
+    version = "1.0.0",
+    bazel_compatibility = [">=6.0.0"],
+)
+
+bazel_dep(name = "bar", version = "1.0", max_compatibility_level = 3)
+bazel_dep(name = "baz", version = "2.0")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version, err := ModuleVersion(f); err != nil || version != "1.0.0" {
+		t.Errorf("ModuleVersion() = %q, %v, want \"1.0.0\"", version, err)
+	}
+	if level, err := CompatibilityLevel(f); err != nil || level != 0 {
+		t.Errorf("CompatibilityLevel() = %d, %v, want 0", level, err)
+	}
+	if level, err := MaxCompatibilityLevel(f, "bar"); err != nil || level != 3 {
+		t.Errorf("MaxCompatibilityLevel(bar) = %d, %v, want 3", level, err)
+	}
+	if level, err := MaxCompatibilityLevel(f, "baz"); err != nil || level != -1 {
+		t.Errorf("MaxCompatibilityLevel(baz) = %d, %v, want -1", level, err)
+	}
+	if _, err := MaxCompatibilityLevel(f, "unknown"); err == nil {
+		t.Errorf("MaxCompatibilityLevel(unknown) succeeded, want error")
+	}
+
+	if err := SetModuleVersion(f, "not a version"); err == nil {
+		t.Errorf("SetModuleVersion() with an invalid version succeeded, want error")
+	}
+	if err := SetModuleVersion(f, "2.0.0"); err != nil {
+		t.Error(err)
+	}
+	if err := SetCompatibilityLevel(f, 2); err != nil {
+		t.Error(err)
+	}
+	if err := SetMaxCompatibilityLevel(f, "bar", -1); err != nil {
+		t.Error(err)
+	}
+	if err := SetMaxCompatibilityLevel(f, "baz", 1); err != nil {
+		t.Error(err)
+	}
+	if err := AddBazelCompatibility(f, ">=6.0.0", "<9.0.0"); err != nil {
+		t.Error(err)
+	}
+	if err := AddBazelCompatibility(f, "9"); err == nil {
+		t.Errorf("AddBazelCompatibility() with an invalid constraint succeeded, want error")
+	}
+	if err := RemoveBazelCompatibility(f, ">=6.0.0"); err != nil {
+		t.Error(err)
+	}
+	if constraints, err := BazelCompatibility(f); err != nil || !reflect.DeepEqual(constraints, []string{"<9.0.0"}) {
+		t.Errorf("BazelCompatibility() = %q, %v, want [\"<9.0.0\"]", constraints, err)
+	}
+
+	want := `module(
+    name = "foo",
+    version = "2.0.0",
+    bazel_compatibility = ["<9.0.0"],
+    compatibility_level = 2,
+)
+
+bazel_dep(name = "bar", version = "1.0")
+bazel_dep(name = "baz", version = "2.0", max_compatibility_level = 1)
+`
+	if got := string(build.Format(f)); got != want {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestNoModule(t *testing.T) {
+	f, err := build.ParseModule("MODULE.bazel", []byte(`bazel_dep(name = "bar", version = "1.0")`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetModuleVersion(f, "1.0"); err == nil {
+		t.Errorf("SetModuleVersion() without module() succeeded, want error")
+	}
+	if err := SetCompatibilityLevel(f, 1); err == nil {
+		t.Errorf("SetCompatibilityLevel() without module() succeeded, want error")
+	}
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bzlmod
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// Tags returns the top-level tag calls of the given proxies, e.g. ext.tag(...), in order.
+func Tags(f *build.File, proxies []string) []*build.CallExpr {
+	proxiesSet := make(map[string]bool)
+	for _, p := range proxies {
+		proxiesSet[p] = true
+	}
+	var tags []*build.CallExpr
+	for _, stmt := range f.Stmt {
+		if proxy := parseTag(stmt); proxy != "" && proxiesSet[proxy] {
+			tags = append(tags, stmt.(*build.CallExpr))
+		}
+	}
+	return tags
+}
+
+// IsolateUsage moves the given tags and repos of a non-isolated usage of a module extension,
+// identified by one of its proxies, to a new usage of the extension with `isolate = True`
+// assigned to newProxy, which must not be used in the file yet. An empty newProxy is replaced
+// with a name derived from proxy, e.g. "maven_isolated". The tags must be top-level tag calls of
+// the usage, see Tags. The repos are removed from the use_repo calls of the usage and imported by
+// a new use_repo call of the isolated one.
+// The isolated usage is inserted before the first moved tag, or after the last statement of the
+// usage if there's none. If proxy isn't used anymore, e.g. because all its tags were moved, its
+// use_extension call is turned into the isolated one instead.
+// It returns the updated file and the new proxy. The returned error consists of a SpreadError
+// for each spread argument of the use_repo calls that couldn't be edited, the file is updated
+// regardless; the file is nil for the other errors.
+func IsolateUsage(f *build.File, proxy, newProxy string, tags []*build.CallExpr, repos ...string) (*build.File, string, error) {
+	index := useExtensionIndex(f, proxy)
+	if index == -1 {
+		return nil, "", fmt.Errorf("no use_extension assignment to variable %q found", proxy)
+	}
+	_, bzlFile, name, dev, isolate := parseUseExtension(f.Stmt[index])
+	if isolate {
+		return nil, "", fmt.Errorf("the extension usage of %q is already isolated", proxy)
+	}
+	if newProxy == "" {
+		newProxy = unusedName(f, proxy+"_isolated")
+	} else if variableDefined(f, newProxy) {
+		return nil, "", fmt.Errorf("variable %q is already used", newProxy)
+	}
+	proxies := AllProxies(f, proxy)
+	usageTags := make(map[*build.CallExpr]bool)
+	for _, tag := range Tags(f, proxies) {
+		usageTags[tag] = true
+	}
+	for _, tag := range tags {
+		if !usageTags[tag] {
+			return nil, "", fmt.Errorf("%s is not a tag of the extension usage of %q", build.FormatString(tag.X), proxy)
+		}
+	}
+
+	var err error
+	if len(repos) > 0 {
+		useRepos := UseRepos(f, proxies)
+		err = RemoveRepoUsagesInFile(f, useRepos, repos...)
+		removeEmptyCalls(f, useRepos)
+	}
+	moved := make(map[*build.CallExpr]bool)
+	for _, tag := range tags {
+		tag.X.(*build.DotExpr).X = &build.Ident{Name: newProxy}
+		moved[tag] = true
+	}
+
+	args := []build.Expr{&build.StringExpr{Value: bzlFile}, &build.StringExpr{Value: name}}
+	if dev {
+		args = append(args, keywordArg("dev_dependency", "True"))
+	}
+	args = append(args, keywordArg("isolate", "True"))
+	useExtension := &build.AssignExpr{
+		LHS: &build.Ident{Name: newProxy},
+		Op:  "=",
+		RHS: &build.CallExpr{X: &build.Ident{Name: "use_extension"}, List: args},
+	}
+
+	// The statements may have moved if use_repo calls were removed.
+	index = useExtensionIndex(f, proxy)
+	if variableUses(f, proxy) == 0 {
+		useExtension.Comments = *f.Stmt[index].Comment()
+		f.Stmt[index] = useExtension
+	} else {
+		insert := -1
+		for i, stmt := range f.Stmt {
+			if call, ok := stmt.(*build.CallExpr); ok && moved[call] {
+				insert = i
+				break
+			}
+		}
+		if insert == -1 {
+			insert, _ = lastProxyUsage(f, proxies)
+			insert++
+		}
+		f.Stmt = append(f.Stmt[:insert], append([]build.Expr{useExtension}, f.Stmt[insert:]...)...)
+	}
+
+	if len(repos) > 0 {
+		var useRepo *build.CallExpr
+		f, useRepo = NewUseRepo(f, []string{newProxy})
+		err = errors.Join(err, AddRepoUsagesInFile(f, []*build.CallExpr{useRepo}, repos...))
+	}
+	return f, newProxy, err
+}
+
+// UnisolateUsage turns the isolated usage of a module extension assigned to the given proxy
+// into a regular one by removing its `isolate` argument. If the module already has a regular
+// usage of the extension with the same dev_dependency value, the repos imported by both are
+// only kept in the use_repo calls of the existing usage, and if the latter is defined before the
+// isolated one, the tags and the other calls using proxy are moved to its first proxy and the
+// use_extension call of proxy is removed.
+// The returned error consists of a SpreadError for each spread argument of the use_repo calls
+// that couldn't be edited, the file is updated regardless; the file is nil for the other errors.
+func UnisolateUsage(f *build.File, proxy string) (*build.File, error) {
+	index := useExtensionIndex(f, proxy)
+	if index == -1 {
+		return nil, fmt.Errorf("no use_extension assignment to variable %q found", proxy)
+	}
+	_, bzlFile, name, dev, isolate := parseUseExtension(f.Stmt[index])
+	if !isolate {
+		return nil, fmt.Errorf("the extension usage of %q is not isolated", proxy)
+	}
+	call := f.Stmt[index].(*build.AssignExpr).RHS.(*build.CallExpr)
+	var args []build.Expr
+	for _, arg := range call.List {
+		if kwarg, ok := arg.(*build.AssignExpr); ok {
+			if ident, ok := kwarg.LHS.(*build.Ident); ok && ident.Name == "isolate" {
+				continue
+			}
+		}
+		args = append(args, arg)
+	}
+	call.List = args
+
+	var shared []string
+	for _, p := range Proxies(f, bzlFile, name, dev) {
+		if p != proxy {
+			shared = append(shared, p)
+		}
+	}
+	if len(shared) == 0 {
+		return f, nil
+	}
+
+	// Importing the same repo twice is an error.
+	sharedRepos, _ := usedRepos(f, UseRepos(f, shared))
+	useRepos := UseRepos(f, []string{proxy})
+	repos, _ := usedRepos(f, useRepos)
+	var duplicates []string
+	for repo := range repos {
+		if _, ok := sharedRepos[repo]; ok {
+			duplicates = append(duplicates, repo)
+		}
+	}
+	sort.Strings(duplicates)
+	var errs []error
+	if err := RemoveRepoUsagesInFile(f, useRepos, duplicates...); err != nil {
+		errs = append(errs, err)
+	}
+	removeEmptyCalls(f, useRepos)
+
+	// The tags of the first proxy must follow its definition.
+	target := ""
+	for _, stmt := range f.Stmt {
+		p, _, _, _, _ := parseUseExtension(stmt)
+		if p == proxy {
+			break
+		}
+		if p != "" && containsString(shared, p) {
+			target = p
+			break
+		}
+	}
+	if target == "" {
+		return f, errors.Join(errs...)
+	}
+
+	index = useExtensionIndex(f, proxy)
+	removed := f.Stmt[index]
+	f.Stmt = append(f.Stmt[:index], f.Stmt[index+1:]...)
+	if index < len(f.Stmt) {
+		next := f.Stmt[index].Comment()
+		next.Before = append(removed.Comment().Before, next.Before...)
+	}
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		ident, ok := expr.(*build.Ident)
+		if !ok || ident.Name != proxy {
+			return
+		}
+		// Keep the keyword arguments, e.g. use_repo(ext, proxy = "repo").
+		if len(stack) > 0 {
+			if assign, ok := stack[len(stack)-1].(*build.AssignExpr); ok && assign.LHS == expr {
+				return
+			}
+		}
+		ident.Name = target
+	})
+	return f, errors.Join(errs...)
+}
+
+// useExtensionIndex returns the index of the use_extension assignment to the given proxy, or -1.
+func useExtensionIndex(f *build.File, proxy string) int {
+	for i, stmt := range f.Stmt {
+		if p, _, _, _, _ := parseUseExtension(stmt); p == proxy {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeEmptyCalls removes the given calls from the file if their only argument is the proxy.
+func removeEmptyCalls(f *build.File, calls []*build.CallExpr) {
+	empty := make(map[*build.CallExpr]bool)
+	for _, call := range calls {
+		empty[call] = len(call.List) == 1
+	}
+	var stmts []build.Expr
+	for _, stmt := range f.Stmt {
+		if call, ok := stmt.(*build.CallExpr); ok && empty[call] {
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	f.Stmt = stmts
+}
+
+// variableDefined checks whether the given name is used anywhere in the file.
+func variableDefined(f *build.File, name string) bool {
+	found := false
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		if ident, ok := expr.(*build.Ident); ok && ident.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// unusedName returns the given name, with a numeric suffix if it's already used in the file.
+func unusedName(f *build.File, name string) string {
+	candidate := name
+	for i := 2; variableDefined(f, candidate); i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	return candidate
+}
+
+func keywordArg(name, value string) *build.AssignExpr {
+	return &build.AssignExpr{
+		LHS: &build.Ident{Name: name},
+		Op:  "=",
+		RHS: &build.Ident{Name: value},
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
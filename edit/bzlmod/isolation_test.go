@@ -0,0 +1,262 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bzlmod
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestIsolateUsage(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		content   string
+		proxy     string
+		newProxy  string
+		tags      []int // indices of the tags of the usage to move
+		repos     []string
+		want      string
+		wantProxy string
+		wantErr   bool
+	}{
+		{
+			name: "whole usage",
+			content: `# The maven usage.
+maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+use_repo(maven, "foo")
+`,
+			proxy: "maven",
+			tags:  []int{0},
+			repos: []string{"foo"},
+			want: `# The maven usage.
+maven_isolated = use_extension("@rules_jvm_external//:extensions.bzl", "maven", isolate = True)
+maven_isolated.install(name = "foo")
+use_repo(maven_isolated, "foo")
+`,
+			wantProxy: "maven_isolated",
+		},
+		{
+			name: "some tags",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", dev_dependency = True)
+maven.install(name = "foo")
+maven.install(name = "bar")
+maven.artifact(
+    name = "bar",
+    artifact = "baz",
+)
+use_repo(maven, "bar", "foo")
+`,
+			proxy:    "maven",
+			newProxy: "bar_maven",
+			tags:     []int{1, 2},
+			repos:    []string{"bar"},
+			want: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", dev_dependency = True)
+maven.install(name = "foo")
+
+bar_maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", dev_dependency = True, isolate = True)
+bar_maven.install(name = "bar")
+bar_maven.artifact(
+    name = "bar",
+    artifact = "baz",
+)
+use_repo(bar_maven, "bar")
+
+use_repo(maven, "foo")
+`,
+			wantProxy: "bar_maven",
+		},
+		{
+			name: "repos of another proxy",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+other = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+use_repo(other, "foo")
+maven_isolated = 1
+`,
+			proxy: "maven",
+			repos: []string{"foo"},
+			want: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+
+other = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+
+maven_isolated_2 = use_extension("@rules_jvm_external//:extensions.bzl", "maven", isolate = True)
+use_repo(maven_isolated_2, "foo")
+
+maven_isolated = 1
+`,
+			wantProxy: "maven_isolated_2",
+		},
+		{
+			name: "already isolated",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", isolate = True)
+`,
+			proxy:   "maven",
+			wantErr: true,
+		},
+		{
+			name: "used name",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+other = use_extension("@rules_jvm_external//:extensions.bzl", "other")
+`,
+			proxy:    "maven",
+			newProxy: "other",
+			wantErr:  true,
+		},
+		{
+			name:    "no usage",
+			content: ``,
+			proxy:   "maven",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := build.ParseModule("MODULE.bazel", []byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			usageTags := Tags(f, AllProxies(f, tc.proxy))
+			var tags []*build.CallExpr
+			for _, i := range tc.tags {
+				tags = append(tags, usageTags[i])
+			}
+			f, proxy, err := IsolateUsage(f, tc.proxy, tc.newProxy, tags, tc.repos...)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("IsolateUsage() = %q, want error", proxy)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("IsolateUsage() error = %v", err)
+			}
+			if proxy != tc.wantProxy {
+				t.Errorf("IsolateUsage() proxy = %q, want %q", proxy, tc.wantProxy)
+			}
+			if got := string(build.FormatWithoutRewriting(f)); got != tc.want {
+				t.Errorf("IsolateUsage() =\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsolateUsageOtherTag(t *testing.T) {
+	f, err := build.ParseModule("MODULE.bazel", []byte(`maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")
+go_deps.from_file(go_mod = "//:go.mod")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := IsolateUsage(f, "maven", "", Tags(f, []string{"go_deps"})); err == nil {
+		t.Errorf("IsolateUsage() with a tag of another extension succeeded, want error")
+	}
+}
+
+func TestUnisolateUsage(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		proxy   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "only usage",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", dev_dependency = True, isolate = True)
+maven.install(name = "foo")
+use_repo(maven, "foo")
+`,
+			proxy: "maven",
+			want: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", dev_dependency = True)
+maven.install(name = "foo")
+use_repo(maven, "foo")
+`,
+		},
+		{
+			name: "merged",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+use_repo(maven, "foo")
+
+# Isolated bar.
+bar_maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", isolate = True)
+bar_maven.install(name = "bar")
+use_repo(bar_maven, "bar", "foo")
+override_repo(bar_maven, "baz")
+`,
+			proxy: "bar_maven",
+			want: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+use_repo(maven, "foo")
+
+# Isolated bar.
+maven.install(name = "bar")
+use_repo(maven, "bar")
+override_repo(maven, "baz")
+`,
+		},
+		{
+			name: "shared usage defined later",
+			content: `bar_maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven", isolate = True)
+bar_maven.install(name = "bar")
+use_repo(bar_maven, "foo")
+
+maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+use_repo(maven, "foo")
+`,
+			proxy: "bar_maven",
+			want: `bar_maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+bar_maven.install(name = "bar")
+
+maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(name = "foo")
+use_repo(maven, "foo")
+`,
+		},
+		{
+			name: "not isolated",
+			content: `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+`,
+			proxy:   "maven",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := build.ParseModule("MODULE.bazel", []byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err = UnisolateUsage(f, tc.proxy)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("UnisolateUsage() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UnisolateUsage() error = %v", err)
+			}
+			if got := string(build.FormatWithoutRewriting(f)); got != tc.want {
+				t.Errorf("UnisolateUsage() =\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
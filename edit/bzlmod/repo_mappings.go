@@ -0,0 +1,204 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bzlmod
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// The functions of MODULE.bazel files that map repositories visible to the module to the
+// repositories of a module extension: inject_repo makes them visible to the extension,
+// override_repo replaces the repositories the extension defines.
+const (
+	InjectRepo   = "inject_repo"
+	OverrideRepo = "override_repo"
+)
+
+// A RepoMapping is an argument of an inject_repo or override_repo call. Name is the name of the
+// repository as seen by the module extension and Repo the apparent name of the repository
+// visible to the module, e.g. {"foo", "foo"} for `inject_repo(ext, "foo")` and
+// {"bar", "my_bar"} for `override_repo(ext, bar = "my_bar")`.
+type RepoMapping struct {
+	Name string
+	Repo string
+}
+
+// ParseRepoMapping parses a repository mapping given on the command line, either as a single
+// repository name for positional arguments, or as name=repo for keyword arguments.
+func ParseRepoMapping(arg string) (RepoMapping, error) {
+	name, repo, isKeyword := strings.Cut(arg, "=")
+	if !isKeyword {
+		repo = name
+	}
+	if name == "" || repo == "" {
+		return RepoMapping{}, fmt.Errorf("invalid repository mapping %q, want <repo> or <name>=<repo>", arg)
+	}
+	return RepoMapping{Name: name, Repo: repo}, nil
+}
+
+// arg returns the argument of an inject_repo or override_repo call for the mapping.
+func (m RepoMapping) arg() build.Expr {
+	if m.Name == m.Repo {
+		return &build.StringExpr{Value: m.Repo}
+	}
+	return &build.AssignExpr{
+		LHS: &build.Ident{Name: m.Name},
+		Op:  "=",
+		RHS: &build.StringExpr{Value: m.Repo},
+	}
+}
+
+// repoMappingFromArg returns the mapping of an argument of an inject_repo or override_repo call,
+// ok is false if it isn't a string literal or a keyword argument with a string literal value.
+func repoMappingFromArg(arg build.Expr) (m RepoMapping, ok bool) {
+	switch arg := arg.(type) {
+	case *build.StringExpr:
+		return RepoMapping{Name: arg.Value, Repo: arg.Value}, true
+	case *build.AssignExpr:
+		name, ok := arg.LHS.(*build.Ident)
+		if !ok {
+			return RepoMapping{}, false
+		}
+		repo, ok := arg.RHS.(*build.StringExpr)
+		if !ok {
+			return RepoMapping{}, false
+		}
+		return RepoMapping{Name: name.Name, Repo: repo.Value}, true
+	}
+	return RepoMapping{}, false
+}
+
+// RepoMappingCalls returns the inject_repo or override_repo calls, depending on kind, of the
+// given proxies.
+func RepoMappingCalls(f *build.File, kind string, proxies []string) []*build.CallExpr {
+	return proxyCalls(f, kind, proxies)
+}
+
+// RepoMappings returns the repository mappings of the given inject_repo or override_repo calls,
+// in order. The arguments that aren't literals are skipped.
+func RepoMappings(calls []*build.CallExpr) []RepoMapping {
+	var mappings []RepoMapping
+	for _, call := range calls {
+		if len(call.List) == 0 {
+			continue
+		}
+		for _, arg := range call.List[1:] {
+			if m, ok := repoMappingFromArg(arg); ok {
+				mappings = append(mappings, m)
+			}
+		}
+	}
+	return mappings
+}
+
+// NewRepoMappingCall inserts and returns a new inject_repo or override_repo call, depending on
+// kind, after the last usage of any of the given proxies, see NewUseRepo. It returns nil if the
+// file doesn't use any of the proxies.
+func NewRepoMappingCall(f *build.File, kind string, proxies []string) *build.CallExpr {
+	lastUsage, proxy := lastProxyUsage(f, proxies)
+	if lastUsage == -1 {
+		return nil
+	}
+	call := &build.CallExpr{
+		X:    &build.Ident{Name: kind},
+		List: []build.Expr{&build.Ident{Name: proxy}},
+	}
+	f.Stmt = append(f.Stmt[:lastUsage+1], append([]build.Expr{call}, f.Stmt[lastUsage+1:]...)...)
+	return call
+}
+
+// AddRepoMappings adds the given mappings to the given inject_repo or override_repo calls of the
+// same extension usage. A mapping of a name the calls already map is replaced in place, the other
+// ones are added to the last call, whose arguments are then deduplicated and sorted like the ones
+// of use_repo, see SortUseRepos. calls must not be empty. It returns false if the calls already
+// had all the mappings.
+func AddRepoMappings(calls []*build.CallExpr, mappings ...RepoMapping) bool {
+	if len(calls) == 0 {
+		panic("calls must not be empty")
+	}
+	changed := false
+	lastCall := getLastUseRepo(calls)
+	for _, m := range mappings {
+		found := false
+		for _, call := range calls {
+			for i, arg := range call.List {
+				if i == 0 {
+					continue
+				}
+				existing, ok := repoMappingFromArg(arg)
+				if !ok || existing.Name != m.Name {
+					continue
+				}
+				found = true
+				if existing.Repo != m.Repo {
+					call.List[i] = m.arg()
+					changed = true
+				}
+			}
+		}
+		if !found {
+			lastCall.List = append(lastCall.List, m.arg())
+			changed = true
+		}
+	}
+	if changed {
+		SortUseRepos(lastCall)
+	}
+	return changed
+}
+
+// RemoveRepoMappings removes the mappings of the given names, as seen by the module extension,
+// from the given inject_repo or override_repo calls, and removes the calls that don't have any
+// mappings left from the file. It returns false if none of the names were mapped.
+func RemoveRepoMappings(f *build.File, calls []*build.CallExpr, names ...string) bool {
+	toRemove := make(map[string]bool)
+	for _, name := range names {
+		toRemove[name] = true
+	}
+	removed := false
+	empty := make(map[*build.CallExpr]bool)
+	for _, call := range calls {
+		if len(call.List) == 0 {
+			continue
+		}
+		args := call.List[:1]
+		for _, arg := range call.List[1:] {
+			if m, ok := repoMappingFromArg(arg); ok && toRemove[m.Name] {
+				removed = true
+				continue
+			}
+			args = append(args, arg)
+		}
+		call.List = args
+		empty[call] = len(args) == 1
+	}
+	if !removed {
+		return false
+	}
+	var stmts []build.Expr
+	for _, stmt := range f.Stmt {
+		if call, ok := stmt.(*build.CallExpr); ok && empty[call] {
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	f.Stmt = stmts
+	return true
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bzlmod
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestParseRepoMapping(t *testing.T) {
+	for _, tc := range []struct {
+		arg     string
+		want    RepoMapping
+		wantErr bool
+	}{
+		{arg: "foo", want: RepoMapping{"foo", "foo"}},
+		{arg: "bar=my_bar", want: RepoMapping{"bar", "my_bar"}},
+		{arg: "=my_bar", wantErr: true},
+		{arg: "bar=", wantErr: true},
+	} {
+		got, err := ParseRepoMapping(tc.arg)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseRepoMapping(%q) error = %v, want error: %v", tc.arg, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Errorf("ParseRepoMapping(%q) = %v, want %v", tc.arg, got, tc.want)
+		}
+	}
+}
+
+func TestRepoMappings(t *testing.T) {
+	f, err := build.ParseModule("MODULE.bazel", []byte(`foo = use_extension("//:foo.bzl", "foo")
+foo.tag()
+inject_repo(foo, "a", b = "my_b")
+
+bar = use_extension("//:bar.bzl", "bar")
+inject_repo(bar, "c")
+override_repo(bar, "d")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := RepoMappingCalls(f, InjectRepo, []string{"foo"})
+	want := []RepoMapping{{"a", "a"}, {"b", "my_b"}}
+	if got := RepoMappings(calls); !reflect.DeepEqual(got, want) {
+		t.Errorf("RepoMappings(inject_repo(foo)) = %v, want %v", got, want)
+	}
+
+	if AddRepoMappings(calls, RepoMapping{"a", "a"}, RepoMapping{"b", "my_b"}) {
+		t.Errorf("AddRepoMappings() of existing mappings = true, want false")
+	}
+	if !AddRepoMappings(calls, RepoMapping{"e", "e"}, RepoMapping{"b", "other_b"}, RepoMapping{"e", "e"}) {
+		t.Errorf("AddRepoMappings() = false, want true")
+	}
+
+	overrides := RepoMappingCalls(f, OverrideRepo, []string{"bar"})
+	if RemoveRepoMappings(f, overrides, "a") {
+		t.Errorf("RemoveRepoMappings() of a missing name = true, want false")
+	}
+	if !RemoveRepoMappings(f, overrides, "d") {
+		t.Errorf("RemoveRepoMappings() = false, want true")
+	}
+
+	baz := &build.AssignExpr{
+		LHS: &build.Ident{Name: "baz"},
+		Op:  "=",
+		RHS: &build.CallExpr{
+			X:    &build.Ident{Name: "use_extension"},
+			List: []build.Expr{&build.StringExpr{Value: "//:baz.bzl"}, &build.StringExpr{Value: "baz"}},
+		},
+	}
+	f.Stmt = append(f.Stmt, baz)
+	call := NewRepoMappingCall(f, OverrideRepo, []string{"baz"})
+	AddRepoMappings([]*build.CallExpr{call}, RepoMapping{"f", "my_f"})
+
+	want2 := `foo = use_extension("//:foo.bzl", "foo")
+foo.tag()
+inject_repo(foo, "a", "e", b = "other_b")
+
+bar = use_extension("//:bar.bzl", "bar")
+inject_repo(bar, "c")
+
+baz = use_extension("//:baz.bzl", "baz")
+override_repo(baz, f = "my_f")
+`
+	if got := string(build.Format(f)); got != want2 {
+		t.Errorf("edited file =\n%s\nwant:\n%s", got, want2)
+	}
+}
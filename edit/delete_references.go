@@ -0,0 +1,298 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Deletion safety: the references to the rules removed by the delete command, which would be
+// left dangling.
+
+package edit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	buildpb "github.com/bazelbuild/buildtools/build_proto"
+	"github.com/bazelbuild/buildtools/file"
+	"github.com/bazelbuild/buildtools/labels"
+	"github.com/bazelbuild/buildtools/tables"
+	"github.com/bazelbuild/buildtools/wspace"
+	"github.com/golang/protobuf/proto"
+)
+
+// The policies for the references to the rules removed by the delete command, see
+// Options.DeleteReferences.
+const (
+	// DeleteReferencesFail reports the references and doesn't run any command.
+	DeleteReferencesFail = "fail"
+	// DeleteReferencesWarn reports the references and deletes the rules anyway.
+	DeleteReferencesWarn = "warn"
+	// DeleteReferencesRemove also removes the references from the attributes of the rules.
+	DeleteReferencesRemove = "remove"
+)
+
+// errReferencedRules is returned by checkDeleteReferences if rules to delete are referenced with
+// the DeleteReferencesFail policy.
+var errReferencedRules = errors.New("the rules to delete are referenced by other rules, see -delete_references")
+
+// ValidateDeleteReferences checks the policy given to -delete_references.
+func ValidateDeleteReferences(policy string) error {
+	switch policy {
+	case "", DeleteReferencesFail, DeleteReferencesWarn, DeleteReferencesRemove:
+		return nil
+	}
+	return fmt.Errorf("unknown policy %q, want %s, %s or %s", policy, DeleteReferencesFail, DeleteReferencesWarn, DeleteReferencesRemove)
+}
+
+// A ruleReference is an attribute of a rule referring to a rule about to be deleted.
+type ruleReference struct {
+	file    string       // the BUILD file of the referring rule
+	from    labels.Label // the referring rule
+	attr    string       // the referring attribute
+	deleted labels.Label // the rule about to be deleted
+	// single is true if the attribute holds a single label, rather than a list.
+	single bool
+}
+
+func (r ruleReference) String() string {
+	return fmt.Sprintf("%s: %s references the deleted rule %s in %s", r.file, r.from.Format(), r.deleted.Format(), r.attr)
+}
+
+// deletedRules returns the labels of the rules the delete commands remove, without modifying
+// any file. The labels are relative to the main repository.
+func deletedRules(opts *Options, commandsByFile map[string][]commandsForTarget) map[labels.Label]bool {
+	deleted := make(map[labels.Label]bool)
+	for name, commands := range commandsByFile {
+		if name == stdinPackageName {
+			continue
+		}
+		var deleting []commandsForTarget
+		for _, cft := range commands {
+			for _, cmd := range cft.commands {
+				if cmd.tokens[0] == "delete" {
+					deleting = append(deleting, cft)
+					break
+				}
+			}
+		}
+		if len(deleting) == 0 {
+			continue
+		}
+		var data []byte
+		var err error
+		if opts.git != nil {
+			name, data, err = opts.git.readBuildFile(name)
+		} else {
+			name, data, _, err = readBuildFile(name)
+		}
+		if err != nil {
+			continue
+		}
+		f, err := build.ParseBuild(name, data)
+		if err != nil {
+			continue
+		}
+		_, pkg, _ := wspace.SplitFilePath(name)
+		for _, cft := range deleting {
+			_, _, _, rule := interpretLabel(opts.cache, opts.RootDir, cft.target)
+			targets, err := expandTargets(f, rule)
+			if err != nil {
+				continue
+			}
+			for _, r := range filterRules(opts, targets) {
+				deleted[labels.Label{Package: pkg, Target: r.Name()}] = true
+			}
+		}
+	}
+	return deleted
+}
+
+// workspaceReferences returns the references to the deleted rules in the BUILD files of the
+// workspace. The labels are compared after resolving them in the package of the referring rule,
+// the attributes of the deleted rules themselves are skipped.
+func workspaceReferences(opts *Options, deleted map[labels.Label]bool) []ruleReference {
+	rootDir := opts.RootDir
+	if rootDir == "" {
+		rootDir, _ = os.Getwd()
+	}
+	root, _ := opts.cache.findWorkspaceRoot(rootDir)
+	if root == "" {
+		return nil
+	}
+	var ignoredPrefixes []string
+	if opts.RespectBazelignore {
+		ignoredPrefixes = opts.cache.getIgnoredPrefixes(root)
+	}
+
+	buildFiles, readFile := findBuildFiles(root, ignoredPrefixes), file.ReadFile
+	if opts.git != nil {
+		buildFiles = opts.git.findBuildFiles(root, ignoredPrefixes)
+		readFile = func(name string) ([]byte, os.FileInfo, error) {
+			data, err := opts.git.readFile(name)
+			return data, nil, err
+		}
+	}
+
+	var refs []ruleReference
+	for _, name := range buildFiles {
+		data, _, err := readFile(name)
+		if err != nil {
+			continue
+		}
+		f, err := build.ParseBuild(name, data)
+		if err != nil {
+			continue
+		}
+		_, pkg, _ := wspace.SplitFilePath(name)
+		for _, r := range f.Rules("") {
+			from := labels.Label{Package: pkg, Target: r.Name()}
+			if r.Name() == "" || deleted[from] {
+				continue
+			}
+			for _, attr := range r.AttrKeys() {
+				if attr == "name" {
+					continue
+				}
+				value := r.Attr(attr)
+				_, single := value.(*build.StringExpr)
+				build.Walk(value, func(x build.Expr, stk []build.Expr) {
+					str, ok := x.(*build.StringExpr)
+					if !ok || !isLabelLike(str.Value, attr) {
+						return
+					}
+					if label := labels.ParseRelative(str.Value, pkg); deleted[label] {
+						refs = append(refs, ruleReference{name, from, attr, label, single})
+					}
+				})
+			}
+		}
+	}
+	return refs
+}
+
+// isLabelLike reports whether a string of an attribute may be a label of a rule, rather than a
+// file name or arbitrary text. The bare names of rules of the same package, e.g. "foo" in deps,
+// are only recognized in the attributes of tables.IsLabelArg.
+func isLabelLike(s, attr string) bool {
+	if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "//") || strings.HasPrefix(s, "@//") || strings.HasPrefix(s, "@@//") {
+		return true
+	}
+	return tables.IsLabelArg[attr] && s != "" && !strings.HasPrefix(s, "@") && !strings.ContainsAny(s, " $()")
+}
+
+// queryReferences returns the references to the deleted rules in the attributes of the rules of
+// a QueryResult proto, the output of `bazel query --output=proto`, e.g. of
+// `rdeps(//..., <targets>, 1)`. The referring rules found by the query include the ones
+// instantiated by macros.
+func queryReferences(queryFile string, deleted map[labels.Label]bool) ([]ruleReference, error) {
+	data, err := os.ReadFile(queryFile)
+	if err != nil {
+		return nil, err
+	}
+	var result buildpb.QueryResult
+	if err := proto.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", queryFile, err)
+	}
+	var refs []ruleReference
+	for _, target := range result.GetTarget() {
+		rule := target.GetRule()
+		if rule == nil {
+			continue
+		}
+		from := labels.Parse(rule.GetName())
+		if deleted[from] {
+			continue
+		}
+		// The location is <path>:<line>:<column>.
+		location := rule.GetLocation()
+		for i := 0; i < 2; i++ {
+			if j := strings.LastIndex(location, ":"); j >= 0 {
+				location = location[:j]
+			}
+		}
+		for _, attr := range rule.GetAttribute() {
+			values := attr.GetStringListValue()
+			single := attr.StringValue != nil
+			if single {
+				values = []string{attr.GetStringValue()}
+			}
+			for _, value := range values {
+				if !isLabelLike(value, attr.GetName()) {
+					continue
+				}
+				if label := labels.Parse(value); deleted[label] {
+					refs = append(refs, ruleReference{location, from, attr.GetName(), label, single})
+				}
+			}
+		}
+	}
+	return refs, nil
+}
+
+// checkDeleteReferences looks for the references to the rules the delete commands remove,
+// according to opts.DeleteReferences: it reports them, and with DeleteReferencesRemove adds
+// commands removing them from the referring attributes to commandsByFile. It returns
+// errReferencedRules if the rules are referenced with DeleteReferencesFail.
+func checkDeleteReferences(opts *Options, commandsByFile map[string][]commandsForTarget) error {
+	deleted := deletedRules(opts, commandsByFile)
+	if len(deleted) == 0 {
+		return nil
+	}
+	var refs []ruleReference
+	if opts.ReferencesQuery != "" {
+		var err error
+		if refs, err = queryReferences(opts.ReferencesQuery, deleted); err != nil {
+			return err
+		}
+	} else {
+		refs = workspaceReferences(opts, deleted)
+	}
+	// A list may reference a rule several times, e.g. in the branches of a select.
+	seen := make(map[ruleReference]bool)
+	var unique []ruleReference
+	for _, ref := range refs {
+		if !seen[ref] {
+			seen[ref] = true
+			unique = append(unique, ref)
+		}
+	}
+	refs = unique
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].from.Format() < refs[j].from.Format()
+	})
+
+	switch opts.DeleteReferences {
+	case DeleteReferencesFail, DeleteReferencesWarn:
+		for _, ref := range refs {
+			fmt.Fprintf(opts.ErrWriter, "%s\n", ref)
+		}
+		if len(refs) > 0 && opts.DeleteReferences == DeleteReferencesFail {
+			return errReferencedRules
+		}
+	case DeleteReferencesRemove:
+		for _, ref := range refs {
+			name := "remove"
+			if ref.single {
+				name = "remove_if_equal"
+			}
+			cmd := command{tokens: []string{name, ref.attr, ref.deleted.Format()}}
+			appendCommandsForTargets(opts, commandsByFile, []command{cmd}, []string{ref.from.Format()})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	buildpb "github.com/bazelbuild/buildtools/build_proto"
+	"github.com/golang/protobuf/proto"
+)
+
+func setUpDeleteReferences(t *testing.T) string {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		"WORKSPACE": "",
+		"a/BUILD": `cc_library(name = "x")
+
+cc_library(
+    name = "y",
+    deps = [":x"],
+)
+
+cc_library(
+    name = "z",
+    deps = ["x"],
+)
+`,
+		"b/BUILD": `cc_binary(
+    name = "bin",
+    deps = [
+        "//a:x",
+        ":other",
+    ] + select({
+        ":cond": ["//a:x"],
+        "//conditions:default": [],
+    }),
+)
+
+alias(
+    name = "al",
+    actual = "//a:x",
+)
+`,
+	} {
+		path := filepath.Join(tmp, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmp
+}
+
+func TestDeleteReferences(t *testing.T) {
+	for _, tc := range []struct {
+		policy  string
+		want    int
+		wantErr []string
+		wantA   string
+		wantB   string
+	}{
+		{
+			policy: DeleteReferencesFail,
+			want:   2,
+			wantErr: []string{
+				"a/BUILD: //a:y references the deleted rule //a:x in deps",
+				"a/BUILD: //a:z references the deleted rule //a:x in deps",
+				"b/BUILD: //b:al references the deleted rule //a:x in actual",
+				"b/BUILD: //b:bin references the deleted rule //a:x in deps",
+			},
+			wantA: `cc_library(name = "x")`,
+			wantB: `"//a:x"`,
+		},
+		{
+			policy:  DeleteReferencesWarn,
+			wantErr: []string{"b/BUILD: //b:bin references the deleted rule //a:x in deps"},
+			wantA: `cc_library(
+    name = "y",
+    deps = [":x"],
+)
+`,
+			wantB: `"//a:x"`,
+		},
+		{
+			policy: DeleteReferencesRemove,
+			wantA: `cc_library(name = "y")
+
+cc_library(name = "z")
+`,
+			wantB: `cc_binary(
+    name = "bin",
+    deps = [":other"],
+)
+
+alias(name = "al")
+`,
+		},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			tmp := setUpDeleteReferences(t)
+			var stderr bytes.Buffer
+			opts := NewOpts()
+			opts.RootDir = tmp
+			opts.DeleteReferences = tc.policy
+			opts.OutWriter = io.Discard
+			opts.ErrWriter = &stderr
+			if ret := Buildozer(opts, []string{"delete", "//a:x"}); ret != tc.want {
+				t.Errorf("Buildozer(delete) = %d, want %d", ret, tc.want)
+			}
+			for _, want := range tc.wantErr {
+				if !strings.Contains(stderr.String(), tmp+"/"+want) {
+					t.Errorf("Buildozer(delete) stderr:\n%s\nwant it to contain %q", stderr.String(), want)
+				}
+			}
+			for file, want := range map[string]string{"a/BUILD": tc.wantA, "b/BUILD": tc.wantB} {
+				content, err := os.ReadFile(filepath.Join(tmp, file))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(content), want) {
+					t.Errorf("%s after Buildozer(delete):\n%s\nwant it to contain:\n%s", file, content, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteReferencesQuery(t *testing.T) {
+	tmp := setUpDeleteReferences(t)
+	result := &buildpb.QueryResult{Target: []*buildpb.Target{
+		{
+			Type: buildpb.Target_RULE.Enum(),
+			Rule: &buildpb.Rule{
+				Name:      proto.String("//c:generated"),
+				RuleClass: proto.String("java_library"),
+				Location:  proto.String("/ws/c/BUILD:3:10"),
+				Attribute: []*buildpb.Attribute{
+					{
+						Name:            proto.String("deps"),
+						Type:            buildpb.Attribute_LABEL_LIST.Enum(),
+						StringListValue: []string{"//a:x", "//a:y"},
+					},
+					{
+						Name:            proto.String("srcs"),
+						Type:            buildpb.Attribute_LABEL_LIST.Enum(),
+						StringListValue: []string{"//c:A.java"},
+					},
+				},
+			},
+		},
+		{
+			Type: buildpb.Target_RULE.Enum(),
+			Rule: &buildpb.Rule{
+				Name:      proto.String("//a:x"),
+				RuleClass: proto.String("cc_library"),
+				Attribute: []*buildpb.Attribute{
+					{
+						Name:        proto.String("actual"),
+						Type:        buildpb.Attribute_LABEL.Enum(),
+						StringValue: proto.String("//a:x"),
+					},
+				},
+			},
+		},
+	}}
+	data, err := proto.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryFile := filepath.Join(tmp, "query.pb")
+	if err := os.WriteFile(queryFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.DeleteReferences = DeleteReferencesFail
+	opts.ReferencesQuery = queryFile
+	opts.OutWriter = io.Discard
+	opts.ErrWriter = &stderr
+	if ret := Buildozer(opts, []string{"delete", "//a:x"}); ret != 2 {
+		t.Errorf("Buildozer(delete) = %d, want 2", ret)
+	}
+	want := "/ws/c/BUILD: //c:generated references the deleted rule //a:x in deps\n" +
+		"error: the rules to delete are referenced by other rules, see -delete_references\n"
+	if got := stderr.String(); got != want {
+		t.Errorf("Buildozer(delete) stderr:\n%s\nwant:\n%s", got, want)
+	}
+}
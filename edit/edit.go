@@ -36,19 +36,71 @@ var (
 	ShortenLabelsFlag = true
 	// DeleteWithComments if true a list attribute will be be deleted in ListDelete, even if there is a comment attached to it
 	DeleteWithComments = true
+	// FoldRuleNamesFlag if true lets FindRuleByName match rules whose name is a static string
+	// expression, e.g. name = PREFIX + "_lib" where PREFIX is a top-level string constant
+	FoldRuleNamesFlag = false
 )
 
 // InterpretLabelForWorkspaceLocation returns the name of the BUILD file to
 // edit, the full package name, and the rule. It takes a workspace-rooted
 // directory to use.
 func InterpretLabelForWorkspaceLocation(root, target string) (buildFile, repo, pkg, rule string) {
+	return interpretLabel(nil, root, target)
+}
+
+// findBuildFile returns the path of the BUILD file of the package in the given directory, or
+// the path of a new BUILD file if there's none.
+func findBuildFile(cache *workspaceCache, dir string) string {
+	for _, buildFileName := range BuildFileNames {
+		buildFile := filepath.Join(dir, buildFileName)
+		if cache.isRegularFile(buildFile) {
+			return buildFile
+		}
+	}
+	return filepath.Join(dir, "BUILD")
+}
+
+// canonicalRepoDir returns the directory of the repository with the given canonical name, if
+// it's the main repository or the repository of a module overridden with local_path_override
+// in the MODULE.bazel file of the workspace.
+func canonicalRepoDir(cache *workspaceCache, rootDir, canonicalName string) (string, bool) {
+	module, ok := wspace.CanonicalRepoModule(canonicalName)
+	if !ok {
+		return "", false
+	}
+	if module == "" {
+		return rootDir, true
+	}
+	dirs, err := cache.findModuleRepoDirs(rootDir)
+	if err != nil {
+		return "", false
+	}
+	dir, ok := dirs[module]
+	return dir, ok
+}
+
+// interpretLabel implements InterpretLabelForWorkspaceLocation, memoizing the workspace lookups
+// in the cache if it's not nil.
+func interpretLabel(cache *workspaceCache, root, target string) (buildFile, repo, pkg, rule string) {
 	label := labels.Parse(target)
 	repo = label.Repository
 	pkg = label.Package
 	rule = label.Target
-	rootDir, relativePath := wspace.FindWorkspaceRoot(root)
+	rootDir, relativePath := cache.findWorkspaceRoot(root)
+	if strings.HasPrefix(target, "@@") && strings.Contains(target, "//") {
+		// A label with a canonical repository name, e.g. from the output of `bazel query`.
+		if dir, ok := canonicalRepoDir(cache, rootDir, repo); ok {
+			if dir == rootDir {
+				// The main repository, interpret the label as an absolute label.
+				target = "//" + strings.SplitN(target, "//", 2)[1]
+				repo = ""
+			} else {
+				return findBuildFile(cache, filepath.Join(dir, filepath.FromSlash(pkg))), repo, pkg, rule
+			}
+		}
+	}
 	if repo != "" {
-		files, err := wspace.FindRepoBuildFiles(rootDir)
+		files, err := cache.findRepoBuildFiles(rootDir)
 		if err == nil {
 			if buildFile, ok := files[repo]; ok {
 				return buildFile, repo, pkg, rule
@@ -57,42 +109,41 @@ func InterpretLabelForWorkspaceLocation(root, target string) (buildFile, repo, p
 		return "", repo, pkg, rule
 	}
 
-	defaultBuildFileName := "BUILD"
+	if !strings.Contains(target, ":") && strings.HasSuffix(target, ".bzl") {
+		// A .bzl file without a target name refers to the file itself, e.g.
+		// "path/to/defs.bzl" is the same as "path/to/defs.bzl:__pkg__".
+		if pkg == "" {
+			pkg = rule
+		}
+		rule = "__pkg__"
+	}
+
 	if strings.HasPrefix(target, "//") {
 		pkgPath := filepath.Join(rootDir, filepath.FromSlash(pkg))
-		if wspace.IsRegularFile(pkgPath) {
+		if cache.isRegularFile(pkgPath) {
 			// allow operation on other files like WORKSPACE
 			buildFile = pkgPath
 			pkg = path.Dir(pkg)
-			return
-		}
-		for _, buildFileName := range BuildFileNames {
-			buildFile = filepath.Join(pkgPath, buildFileName)
-			if wspace.IsRegularFile(buildFile) {
-				return
+			if pkg == "." {
+				// Files at the workspace root, such as MODULE.bazel, belong to the root package.
+				pkg = ""
 			}
+			return
 		}
-		buildFile = filepath.Join(pkgPath, defaultBuildFileName)
+		buildFile = findBuildFile(cache, pkgPath)
 		return
 	}
-	if wspace.IsRegularFile(filepath.FromSlash(pkg)) {
+	if cache.isRegularFile(filepath.FromSlash(pkg)) {
 		// allow operation on other files like WORKSPACE
 		buildFile = pkg
 		pkg = filepath.Join(relativePath, filepath.FromSlash(path.Dir(pkg)))
+		if pkg == "." {
+			pkg = ""
+		}
 		return
 	}
 
-	found := false
-	for _, buildFileName := range BuildFileNames {
-		buildFile = filepath.Join(pkg, buildFileName)
-		if wspace.IsRegularFile(buildFile) {
-			found = true
-			break
-		}
-	}
-	if !found {
-		buildFile = filepath.Join(pkg, defaultBuildFileName)
-	}
+	buildFile = findBuildFile(cache, pkg)
 
 	pkg = filepath.Join(relativePath, filepath.FromSlash(pkg))
 	return
@@ -114,14 +165,15 @@ func InterpretLabelWithRepo(target string) (buildFile string, repo string, pkg s
 
 // ExprToRule returns a Rule from an Expr.
 // The boolean is false iff the Expr is not a function call, or does not have
-// the expected kind.
+// the expected kind. The kind of a call of a dot expression contains dots, e.g.
+// "maven.install" for the install tags of a module extension proxy named maven
+// (see build.CallKind).
 func ExprToRule(expr build.Expr, kind string) (*build.Rule, bool) {
 	call, ok := expr.(*build.CallExpr)
 	if !ok {
 		return nil, false
 	}
-	k, ok := call.X.(*build.Ident)
-	if !ok || k.Name != kind {
+	if k := build.CallKind(call); k == "" || k != kind {
 		return nil, false
 	}
 	return &build.Rule{Call: call, ImplicitName: ""}, true
@@ -248,12 +300,7 @@ func InsertAfter(i int, stmt []build.Expr, expr build.Expr) []build.Expr {
 func IndexOfLast(stmt []build.Expr, Kind string) int {
 	lastIndex := -1
 	for i, s := range stmt {
-		sAsCallExpr, ok := s.(*build.CallExpr)
-		if !ok {
-			continue
-		}
-		literal, ok := sAsCallExpr.X.(*build.Ident)
-		if ok && literal.Name == Kind {
+		if _, ok := ExprToRule(s, Kind); ok {
 			lastIndex = i
 		}
 	}
@@ -262,7 +309,7 @@ func IndexOfLast(stmt []build.Expr, Kind string) int {
 
 // InsertAfterLastOfSameKind inserts an expression after the last expression of the same kind.
 func InsertAfterLastOfSameKind(stmt []build.Expr, expr *build.CallExpr) []build.Expr {
-	index := IndexOfLast(stmt, expr.X.(*build.Ident).Name)
+	index := IndexOfLast(stmt, build.CallKind(expr))
 	if index == -1 {
 		return InsertAtEnd(stmt, expr)
 	}
@@ -302,7 +349,14 @@ func IndexOfRuleByName(f *build.File, name string) (int, *build.Rule) {
 		}
 	}
 
+	var constants map[string]string
+	if FoldRuleNamesFlag {
+		constants = make(map[string]string)
+	}
 	for i, stmt := range f.Stmt {
+		if constants != nil {
+			recordStringConstant(stmt, constants)
+		}
 		call, ok := stmt.(*build.CallExpr)
 		if !ok {
 			continue
@@ -312,6 +366,11 @@ func IndexOfRuleByName(f *build.File, name string) (int, *build.Rule) {
 		if r.Name() == name || start.Line == linenum {
 			return i, r
 		}
+		if constants != nil && r.Name() == "" {
+			if folded, ok := FoldString(r.Attr("name"), constants); ok && folded == name {
+				return i, r
+			}
+		}
 
 		// Allow for precisely targeting the package declaration. This
 		// helps adding new load() and license() rules
@@ -324,6 +383,61 @@ func IndexOfRuleByName(f *build.File, name string) (int, *build.Rule) {
 	return -1, nil
 }
 
+// recordStringConstant updates constants with the value of a top-level
+// assignment of a static string expression. Assignments that can't be
+// evaluated shadow any previously known value.
+func recordStringConstant(stmt build.Expr, constants map[string]string) {
+	as, ok := stmt.(*build.AssignExpr)
+	if !ok {
+		return
+	}
+	lhs, ok := as.LHS.(*build.Ident)
+	if !ok {
+		return
+	}
+	value, ok := FoldString(as.RHS, constants)
+	if ok && as.Op == "=" {
+		constants[lhs.Name] = value
+	} else if ok && as.Op == "+=" {
+		if prev, found := constants[lhs.Name]; found {
+			constants[lhs.Name] = prev + value
+		} else {
+			delete(constants, lhs.Name)
+		}
+	} else {
+		delete(constants, lhs.Name)
+	}
+}
+
+// FoldString evaluates a static string expression built from string literals,
+// identifiers bound in constants and the + operator, e.g. PREFIX + "_lib".
+// It returns false if the expression can't be evaluated statically.
+func FoldString(expr build.Expr, constants map[string]string) (string, bool) {
+	switch expr := expr.(type) {
+	case *build.StringExpr:
+		return expr.Value, true
+	case *build.Ident:
+		value, ok := constants[expr.Name]
+		return value, ok
+	case *build.ParenExpr:
+		return FoldString(expr.X, constants)
+	case *build.BinaryExpr:
+		if expr.Op != "+" {
+			return "", false
+		}
+		x, ok := FoldString(expr.X, constants)
+		if !ok {
+			return "", false
+		}
+		y, ok := FoldString(expr.Y, constants)
+		if !ok {
+			return "", false
+		}
+		return x + y, true
+	}
+	return "", false
+}
+
 // FindExportedFile returns the first exports_files call which contains the
 // file 'name', or nil if not found
 func FindExportedFile(f *build.File, name string) *build.Rule {
@@ -374,13 +488,7 @@ func DeleteRuleByName(f *build.File, name string) *build.File {
 func DeleteRuleByKind(f *build.File, kind string) *build.File {
 	var all []build.Expr
 	for _, stmt := range f.Stmt {
-		call, ok := stmt.(*build.CallExpr)
-		if !ok {
-			all = append(all, stmt)
-			continue
-		}
-		k, ok := call.X.(*build.Ident)
-		if !ok || k.Name != kind {
+		if _, ok := ExprToRule(stmt, kind); !ok {
 			all = append(all, stmt)
 		}
 	}
@@ -689,12 +797,18 @@ func SelectDelete(e build.Expr, item, pkg string, deleted **build.StringExpr) {
 // the deleted StringExpr at the address pointed by the last parameter
 func RemoveFromList(li *build.ListExpr, item, pkg string, deleted **build.StringExpr) {
 	var all []build.Expr
-	for _, elem := range li.List {
+	for i, elem := range li.List {
 		if str, ok := elem.(*build.StringExpr); ok {
 			if labels.Equal(str.Value, item, pkg) && (DeleteWithComments || !hasComments(str)) {
 				if deleted != nil {
 					*deleted = str
 				}
+				// A "keep sorted" or "do not sort" comment applies to the whole block, keep it
+				// above the next element of the block.
+				if build.BlockDirective(str) != "" && i+1 < len(li.List) && len(li.List[i+1].Comment().Before) == 0 {
+					li.List[i+1].Comment().Before = str.Before
+					str.Before = nil
+				}
 
 				continue
 			}
@@ -732,6 +846,20 @@ func ListAttributeDelete(rule *build.Rule, attr, item, pkg string) *build.String
 	return deleted
 }
 
+// ListAttributeDeleteWithVariables is like ListAttributeDelete, but it also deletes the item
+// from the definitions of the global variables the attribute consists of (e.g. COMMON_DEPS in
+// deps = COMMON_DEPS + [":foo"]). vars maps the variable names to their assignments.
+func ListAttributeDeleteWithVariables(rule *build.Rule, attr, item, pkg string, vars *map[string]*build.AssignExpr) *build.StringExpr {
+	variables := referencedVariables(rule.Attr(attr), vars)
+	deleted := ListAttributeDelete(rule, attr, item, pkg)
+	for _, as := range variables {
+		if d := ListDelete(as.RHS, item, pkg); deleted == nil {
+			deleted = d
+		}
+	}
+	return deleted
+}
+
 // ListReplace replaces old with value in all lists in e and returns a Boolean
 // to indicate whether the replacement was successful.
 func ListReplace(e build.Expr, old, value, pkg string) bool {
@@ -804,20 +932,57 @@ func isExprLessThan(x1, x2 build.Expr) bool {
 	return false
 }
 
+// sortedInsert inserts the item in a list, before the first element greater than it. Blocks
+// of elements starting with a "do not sort" comment are skipped, if the item is greater than
+// all the other elements it's added to the end of the last block that can be sorted.
 func sortedInsert(list []build.Expr, item build.Expr) []build.Expr {
-	i := 0
-	for ; i < len(list); i++ {
-		if isExprLessThan(item, list[i]) {
+	// fixed reports whether each element is in a "do not sort" block.
+	fixed := make([]bool, len(list))
+	for i, elem := range list {
+		if i == 0 || len(elem.Comment().Before) > 0 {
+			fixed[i] = build.BlockDirective(elem) == "do not sort"
+		} else {
+			fixed[i] = fixed[i-1]
+		}
+	}
+
+	i := len(list)
+	for j := range list {
+		if !fixed[j] && isExprLessThan(item, list[j]) {
+			i = j
 			break
 		}
 	}
+	if i == len(list) {
+		if end := lastSortableBlockEnd(fixed); end >= 0 {
+			i = end
+		}
+	}
 	res := make([]build.Expr, 0, len(list)+1)
 	res = append(res, list[:i]...)
 	res = append(res, item)
 	res = append(res, list[i:]...)
+	if i < len(list) && i > 0 && fixed[i-1] && !fixed[i] {
+		// The item would be appended to the preceding "do not sort" block, move the comments
+		// starting the next block above it instead.
+		com := list[i].Comment()
+		item.Comment().Before = append(com.Before, item.Comment().Before...)
+		com.Before = nil
+	}
 	return res
 }
 
+// lastSortableBlockEnd returns the index following the last element that isn't in a
+// "do not sort" block, or -1 if there's none.
+func lastSortableBlockEnd(fixed []bool) int {
+	for i := len(fixed) - 1; i >= 0; i-- {
+		if !fixed[i] {
+			return i + 1
+		}
+	}
+	return -1
+}
+
 // attributeMustNotBeSorted returns true if the list in the attribute cannot be
 // sorted. For some attributes, it makes sense to try to do a sorted insert
 // (e.g. deps), even when buildifier will not sort it for conservative reasons.
@@ -842,6 +1007,26 @@ func getVariable(expr build.Expr, vars *map[string]*build.AssignExpr) (varAssign
 	return nil
 }
 
+// referencedVariables returns the assignments of the global variables an attribute value
+// consists of, either directly (deps = COMMON_DEPS) or in a concatenation
+// (deps = COMMON_DEPS + [":foo"]). Variables referenced by the variables are not followed.
+func referencedVariables(expr build.Expr, vars *map[string]*build.AssignExpr) []*build.AssignExpr {
+	if vars == nil {
+		return nil
+	}
+	switch expr := expr.(type) {
+	case *build.Ident:
+		if varAssignment := (*vars)[expr.Name]; varAssignment != nil {
+			return []*build.AssignExpr{varAssignment}
+		}
+	case *build.BinaryExpr:
+		if expr.Op == "+" {
+			return append(referencedVariables(expr.X, vars), referencedVariables(expr.Y, vars)...)
+		}
+	}
+	return nil
+}
+
 // AddValueToList adds a value to a list. If the expression is
 // not a list, a list with a single element is appended to the original
 // expression.
@@ -877,6 +1062,14 @@ func AddValueToList(oldList build.Expr, pkg string, item build.Expr, sorted bool
 func AddValueToListAttribute(r *build.Rule, name string, pkg string, item build.Expr, vars *map[string]*build.AssignExpr) {
 	old := r.Attr(name)
 	sorted := !attributeMustNotBeSorted(r.Kind(), name)
+	if str, ok := item.(*build.StringExpr); ok {
+		for _, as := range referencedVariables(old, vars) {
+			if ListFind(as.RHS, str.Value, pkg) != nil {
+				// The value is already in one of the variables.
+				return
+			}
+		}
+	}
 	if varAssignment := getVariable(old, vars); varAssignment != nil {
 		varAssignment.RHS = AddValueToList(varAssignment.RHS, pkg, item, sorted)
 	} else {
@@ -975,15 +1168,11 @@ func RenameAttribute(r *build.Rule, oldName, newName string) error {
 }
 
 // EditFunction is a wrapper around build.Edit. The callback is called only on
-// functions 'name'.
+// functions 'name', which may contain dots (see ExprToRule).
 func EditFunction(v build.Expr, name string, f func(x *build.CallExpr, stk []build.Expr) build.Expr) build.Expr {
 	return build.Edit(v, func(expr build.Expr, stk []build.Expr) build.Expr {
 		call, ok := expr.(*build.CallExpr)
-		if !ok {
-			return nil
-		}
-		fct, ok := call.X.(*build.Ident)
-		if !ok || fct.Name != name {
+		if !ok || name == "" || build.CallKind(call) != name {
 			return nil
 		}
 		return f(call, stk)
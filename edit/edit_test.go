@@ -134,6 +134,61 @@ func TestSplitOnSpaces(t *testing.T) {
 	}
 }
 
+func TestExprToRule(t *testing.T) {
+	f, err := build.ParseModule("MODULE.bazel", []byte(`maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(artifacts = ["junit:junit:4.13.2"])
+bazel_dep(name = "rules_go", version = "0.50.1")
+maven.artifact(artifact = "guava")
+maven.install(name = "other")
+deps()[0]()
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		kind string
+		want []int
+	}{
+		{"maven.install", []int{1, 4}},
+		{"bazel_dep", []int{2}},
+		{"maven", nil},
+		{"", nil},
+	} {
+		var got []int
+		for i, stmt := range f.Stmt {
+			if rule, ok := ExprToRule(stmt, tt.kind); ok {
+				got = append(got, i)
+				if rule.Kind() != tt.kind {
+					t.Errorf("ExprToRule(%s, %q).Kind() = %q", build.FormatString(stmt), tt.kind, rule.Kind())
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ExprToRule(%q) matches the statements %v, want %v", tt.kind, got, tt.want)
+		}
+	}
+
+	if i := IndexOfLast(f.Stmt, "maven.install"); i != 4 {
+		t.Errorf("IndexOfLast(maven.install) = %d, want 4", i)
+	}
+	tag := &build.CallExpr{X: build.KindExpr("maven.artifact", build.Position{})}
+	if stmts := InsertAfterLastOfSameKind(f.Stmt, tag); stmts[4] != tag {
+		t.Errorf("InsertAfterLastOfSameKind(maven.artifact) didn't insert the tag after the other one")
+	}
+	f = DeleteRuleByKind(f, "maven.install")
+	want := `maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+
+bazel_dep(name = "rules_go", version = "0.50.1")
+
+maven.artifact(artifact = "guava")
+
+deps()[0]()
+`
+	if got := string(build.FormatWithoutRewriting(f)); got != want {
+		t.Errorf("DeleteRuleByKind(maven.install) =\n%s\nwant:\n%s", got, want)
+	}
+}
+
 func TestInsertLoad(t *testing.T) {
 	tests := []struct{ input, expected string }{
 		{``, `load("location", "symbol")`},
@@ -234,6 +289,169 @@ func TestAddValueToListAttribute(t *testing.T) {
 	}
 }
 
+func TestAddValueToListAttributeBlocks(t *testing.T) {
+	tests := []struct{ input, item, expected string }{
+		{`rule(
+    name = "rule",
+    attr = [
+        ":b",
+        # do not sort
+        ":z",
+        ":y",
+        # keep sorted
+        ":m",
+        ":p",
+    ],
+)
+`, ":x", `rule(
+    name = "rule",
+    attr = [
+        ":b",
+        # do not sort
+        ":z",
+        ":y",
+        # keep sorted
+        ":m",
+        ":p",
+        ":x",
+    ],
+)
+`},
+		{`rule(
+    name = "rule",
+    attr = [
+        # do not sort
+        ":z",
+        ":y",
+        # keep sorted
+        ":m",
+    ],
+)
+`, ":a", `rule(
+    name = "rule",
+    attr = [
+        # do not sort
+        ":z",
+        ":y",
+        # keep sorted
+        ":a",
+        ":m",
+    ],
+)
+`},
+		{`rule(
+    name = "rule",
+    attr = [
+        ":b",
+        # do not sort
+        ":z",
+        ":y",
+    ],
+)
+`, ":x", `rule(
+    name = "rule",
+    attr = [
+        ":b",
+        ":x",
+        # do not sort
+        ":z",
+        ":y",
+    ],
+)
+`},
+	}
+
+	for _, tst := range tests {
+		bld, err := build.Parse("BUILD", []byte(tst.input))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		rule := bld.RuleAt(1)
+		AddValueToListAttribute(rule, "attr", "", &build.StringExpr{Value: tst.item}, nil)
+		if got := string(build.FormatWithoutRewriting(bld)); got != tst.expected {
+			t.Errorf("AddValueToListAttribute(%s, %s): got %s, expected %s", tst.input, tst.item, got, tst.expected)
+		}
+	}
+}
+
+func TestListAttributeDeleteBlocks(t *testing.T) {
+	input := `rule(
+    name = "rule",
+    attr = [
+        ":b",
+        # keep sorted
+        ":m",
+        ":p",
+    ],
+)
+`
+	expected := `rule(
+    name = "rule",
+    attr = [
+        ":b",
+        # keep sorted
+        ":p",
+    ],
+)
+`
+	bld, err := build.Parse("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleted := ListAttributeDelete(bld.RuleAt(1), "attr", ":m", "")
+	if deleted == nil || len(deleted.Before) > 0 {
+		t.Errorf("ListAttributeDelete(:m) = %v, want the element without comments", deleted)
+	}
+	if got := string(build.FormatWithoutRewriting(bld)); got != expected {
+		t.Errorf("ListAttributeDelete(:m): got %s, expected %s", got, expected)
+	}
+}
+
+func TestListAttributeDeleteWithVariables(t *testing.T) {
+	tests := []struct {
+		input, item, expected string
+		deleted               bool
+	}{
+		{`COMMON = [":a", ":b"]
+rule(name = "rule", attr = COMMON)`, ":a", `COMMON = [":b"]
+rule(name = "rule", attr = COMMON)`, true},
+		{`COMMON = [":a", ":b"]
+OTHER = [":c"]
+rule(name = "rule", attr = COMMON + [":d"] + OTHER)`, ":c", `COMMON = [":a", ":b"]
+OTHER = []
+rule(name = "rule", attr = COMMON + [":d"] + OTHER)`, true},
+		{`COMMON = [":a", ":b"]
+rule(name = "rule", attr = COMMON + [":a"])`, ":a", `COMMON = [":b"]
+rule(name = "rule", attr = COMMON + [])`, true},
+		{`COMMON = [":a", ":b"]
+rule(name = "rule", attr = other(COMMON))`, ":a", `COMMON = [":a", ":b"]
+rule(name = "rule", attr = other(COMMON))`, false},
+	}
+
+	for _, tst := range tests {
+		bld, err := build.Parse("BUILD", []byte(tst.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vars := getGlobalVariables(bld.Stmt)
+		deleted := ListAttributeDeleteWithVariables(bld.Rules("rule")[0], "attr", tst.item, "", &vars)
+		if (deleted != nil) != tst.deleted {
+			t.Errorf("ListAttributeDeleteWithVariables(%s) = %v, want deleted: %v", tst.input, deleted, tst.deleted)
+		}
+		got := strings.TrimSpace(string(build.FormatWithoutRewriting(bld)))
+
+		wantBld, err := build.Parse("BUILD", []byte(tst.expected))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := strings.TrimSpace(string(build.FormatWithoutRewriting(wantBld)))
+		if got != want {
+			t.Errorf("ListAttributeDeleteWithVariables(%s): got %s, expected %s", tst.input, got, want)
+		}
+	}
+}
+
 func TestSelectListsIntersection(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -682,6 +900,60 @@ func TestDictionaryDelete(t *testing.T) {
 	}
 }
 
+func TestFindRuleByNameFoldRuleNames(t *testing.T) {
+	defer func(old bool) { FoldRuleNamesFlag = old }(FoldRuleNamesFlag)
+
+	input := `PREFIX = "foo"
+SUFFIX = "_" + "lib"
+
+cc_library(name = PREFIX + SUFFIX)
+
+cc_test(name = (PREFIX + "_test"))
+
+cc_binary(name = UNKNOWN + "_bin")
+
+PREFIX = "bar"
+
+cc_library(name = PREFIX + SUFFIX)
+
+PREFIX += "_baz"
+
+cc_library(name = PREFIX)
+
+cc_library(name = "plain")
+`
+	tests := []struct {
+		name     string
+		fold     bool
+		wantLine int
+	}{
+		{"foo_lib", true, 4},
+		{"foo_test", true, 6},
+		{"bar_lib", true, 12},
+		{"bar_baz", true, 16},
+		{"plain", true, 18},
+		{"_bin", true, -1},
+		{"foo_lib", false, -1},
+		{"plain", false, 18},
+	}
+
+	f, err := build.ParseBuild("BUILD", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tst := range tests {
+		FoldRuleNamesFlag = tst.fold
+		gotLine := -1
+		if r := FindRuleByName(f, tst.name); r != nil {
+			start, _ := r.Call.Span()
+			gotLine = start.Line
+		}
+		if gotLine != tst.wantLine {
+			t.Errorf("FindRuleByName(%q) with FoldRuleNamesFlag=%v: got rule at line %d, want %d", tst.name, tst.fold, gotLine, tst.wantLine)
+		}
+	}
+}
+
 func TestPackageDeclaration(t *testing.T) {
 	tests := []struct{ input, expected string }{
 		{``, `package(attr = "val")`},
@@ -747,6 +1019,25 @@ func runTestInterpretLabelForWorkspaceLocation(t *testing.T, buildFileName strin
 	if err := os.WriteFile(filepath.Join(tmp, "a", "b", buildFileName), nil, 0755); err != nil {
 		t.Fatal(err)
 	}
+	if err := os.WriteFile(filepath.Join(tmp, "a", "defs.bzl"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+	module := `module(name = "main")
+
+local_path_override(
+    module_name = "lib",
+    path = "third_party/lib",
+)
+`
+	if err := os.WriteFile(filepath.Join(tmp, "MODULE.bazel"), []byte(module), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmp, "third_party", "lib", "c"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, "third_party", "lib", "c", buildFileName), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
 
 	for _, tc := range []testCase{
 		{tmp, "//", filepath.Join(tmp, buildFileName), "", "."},
@@ -754,6 +1045,18 @@ func runTestInterpretLabelForWorkspaceLocation(t *testing.T, buildFileName strin
 		{tmp, "//a:a", filepath.Join(tmp, "a", buildFileName), "a", "a"},
 		{tmp, "//a/b", filepath.Join(tmp, "a", "b", buildFileName), "a/b", "b"},
 		{tmp, "//a/b:b", filepath.Join(tmp, "a", "b", buildFileName), "a/b", "b"},
+		{tmp, "//a/defs.bzl", filepath.Join(tmp, "a", "defs.bzl"), "a", "__pkg__"},
+		{tmp, "//a/defs.bzl:__pkg__", filepath.Join(tmp, "a", "defs.bzl"), "a", "__pkg__"},
+		{tmp, "//WORKSPACE:%http_archive", filepath.Join(tmp, "WORKSPACE"), "", "%http_archive"},
+		// Canonical repository names
+		{tmp, "@@//a:a", filepath.Join(tmp, "a", buildFileName), "a", "a"},
+		{tmp, "@@_main//a/b", filepath.Join(tmp, "a", "b", buildFileName), "a/b", "b"},
+		{tmp, "@@main~//a/defs.bzl:__pkg__", filepath.Join(tmp, "a", "defs.bzl"), "a", "__pkg__"},
+		{tmp, "@@main+//a:a", filepath.Join(tmp, "a", buildFileName), "a", "a"},
+		{tmp, "@@lib~1.0//c:c", filepath.Join(tmp, "third_party", "lib", "c", buildFileName), "c", "c"},
+		{tmp, "@@lib+//c:c", filepath.Join(tmp, "third_party", "lib", "c", buildFileName), "c", "c"},
+		{tmp, "@@rules_go~0.50.1//go:def.bzl", "", "go", "def.bzl"},
+		{tmp, "@@lib++ext+repo//:x", "", "", "x"},
 	} {
 		buildFile, _, pkg, rule := InterpretLabelForWorkspaceLocation(tc.inputRoot, tc.inputTarget)
 		if buildFile != tc.expectedBuildFile || pkg != tc.expectedPkg || rule != tc.expectedRule {
@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Errors reported by buildozer.
+
+package edit
+
+import (
+	"errors"
+	"strings"
+
+	apipb "github.com/bazelbuild/buildtools/api_proto"
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// ErrorKind classifies the errors of buildozer, e.g. to decide whether retrying makes sense.
+type ErrorKind int
+
+// The kinds of errors, they match the values of apipb.Output_Error_Kind.
+const (
+	ErrorUnknown        ErrorKind = iota
+	ErrorFileNotFound             // the file doesn't exist or can't be read
+	ErrorParse                    // the file has syntax errors
+	ErrorTargetNotFound           // the target wasn't found in the file
+	ErrorCommand                  // a command failed on the target
+	ErrorWrite                    // the modified file couldn't be written
+)
+
+// String returns the name of the kind as used in the JSON output.
+func (k ErrorKind) String() string {
+	return apipb.Output_Error_Kind(k).String()
+}
+
+// An Error is an error of buildozer about a file, or about commands executed on a target of
+// the file. The errors reported by Buildozer and ExecuteCommandsOnInlineFile have this type.
+type Error struct {
+	Kind ErrorKind
+	// File is the path of the BUILD file.
+	File string
+	// Target and Commands are the target and the commands (each one with its arguments)
+	// executed on it, empty for errors about the whole file. The commands may be unknown.
+	Target   string
+	Commands [][]string
+	// Pos is the position the error refers to, e.g. of a syntax error or of the rule a command
+	// failed on, the zero value if unknown.
+	Pos build.Position
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the message of the underlying error, prefixed with the commands and the
+// target if the commands are known.
+func (e *Error) Error() string {
+	if len(e.Commands) == 0 {
+		return e.Err.Error()
+	}
+	commands := make([]command, len(e.Commands))
+	for i, tokens := range e.Commands {
+		commands[i] = command{tokens}
+	}
+	return commandError(commands, e.Target, e.Err).Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Proto returns the error as reported in the proto and JSON output.
+func (e *Error) Proto() *apipb.Output_Error {
+	var commands []string
+	for _, tokens := range e.Commands {
+		commands = append(commands, strings.Join(tokens, " "))
+	}
+	return &apipb.Output_Error{
+		Kind:     apipb.Output_Error_Kind(e.Kind),
+		File:     e.File,
+		Target:   e.Target,
+		Commands: commands,
+		Line:     int32(e.Pos.Line),
+		Column:   int32(e.Pos.LineRune),
+		Message:  e.Err.Error(),
+	}
+}
+
+// fileError returns an error about a whole file.
+func fileError(kind ErrorKind, file string, err error) *Error {
+	e := &Error{Kind: kind, File: file, Err: err}
+	var parseError build.ParseError
+	if errors.As(err, &parseError) {
+		e.Pos = parseError.Pos
+	}
+	return e
+}
+
+// targetError returns an error of commands executed on a target, at the position of the rule
+// the commands failed on if it's known.
+func targetError(kind ErrorKind, file string, commands []command, target string, r *build.Rule, err error) *Error {
+	e := &Error{Kind: kind, File: file, Target: target, Err: err}
+	for _, cmd := range commands {
+		e.Commands = append(e.Commands, cmd.tokens)
+	}
+	if r != nil {
+		e.Pos, _ = r.Call.Span()
+	}
+	return e
+}
+
+// errorsProto returns the errors that have the type *Error as reported in the proto and JSON
+// output.
+func errorsProto(errs []error) []*apipb.Output_Error {
+	var result []*apipb.Output_Error
+	for _, err := range errs {
+		var e *Error
+		if errors.As(err, &e) {
+			result = append(result, e.Proto())
+		}
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	apipb "github.com/bazelbuild/buildtools/api_proto"
+	"github.com/golang/protobuf/jsonpb"
+)
+
+func TestBuildozerErrors(t *testing.T) {
+	tmp := t.TempDir()
+	for name, content := range map[string]string{
+		"WORKSPACE": "",
+		"pkg/BUILD": "cc_library(name = \"lib\")\n\ncc_test(name = \"test\")\n",
+		"bad/BUILD": "cc_library(\n    name = \"lib\"\n    srcs = [],\n)\n",
+	} {
+		path := filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) ([]*Error, *apipb.Output) {
+		var stdout, stderr bytes.Buffer
+		var errs []*Error
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.KeepGoing = true
+		opts.IsPrintingJSON = true
+		opts.OutWriter = &stdout
+		opts.ErrWriter = &stderr
+		opts.ErrorHandler = func(err *Error) { errs = append(errs, err) }
+		if ret := Buildozer(opts, args); ret != 2 {
+			t.Errorf("Buildozer(%q) = %d, want 2; stderr:\n%s", args, ret, stderr.String())
+		}
+		var output apipb.Output
+		if err := jsonpb.Unmarshal(&stdout, &output); err != nil {
+			t.Fatalf("Buildozer(%q) printed invalid JSON: %v", args, err)
+		}
+		sort.Slice(errs, func(i, j int) bool { return errs[i].File < errs[j].File })
+		sort.Slice(output.Errors, func(i, j int) bool { return output.Errors[i].File < output.Errors[j].File })
+		return errs, &output
+	}
+
+	errs, output := run("set testonly True", "//pkg:missing", "//bad:lib", "//nonexistent:lib")
+	for i, want := range []struct {
+		kind   ErrorKind
+		file   string
+		target string
+		line   int
+	}{
+		{ErrorParse, filepath.Join(tmp, "bad", "BUILD"), "", 3},
+		{ErrorFileNotFound, filepath.Join(tmp, "nonexistent", "BUILD"), "", 0},
+		{ErrorTargetNotFound, filepath.Join(tmp, "pkg", "BUILD"), "//pkg:missing", 0},
+	} {
+		if i >= len(errs) || i >= len(output.Errors) {
+			t.Fatalf("got errors %v and %v, want 3", errs, output.Errors)
+		}
+		if got := errs[i]; got.Kind != want.kind || got.File != want.file || got.Target != want.target || got.Pos.Line != want.line {
+			t.Errorf("error %d = %+v, want kind %v, file %q, target %q, line %d", i, got, want.kind, want.file, want.target, want.line)
+		}
+		if got := output.Errors[i]; got.Kind != apipb.Output_Error_Kind(want.kind) || got.File != want.file || got.Target != want.target || int(got.Line) != want.line {
+			t.Errorf("JSON error %d = %+v, want kind %v, file %q, target %q, line %d", i, got, want.kind, want.file, want.target, want.line)
+		}
+	}
+	if got, want := output.Errors[2].Commands, []string{"set testonly True"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("JSON error commands = %q, want %q", got, want)
+	}
+
+	errs, output = run("copy srcs missing", "//pkg:test")
+	if len(errs) != 1 || len(output.Errors) != 1 {
+		t.Fatalf("got errors %v and %v, want 1", errs, output.Errors)
+	}
+	if got := errs[0]; got.Kind != ErrorCommand || got.Target != "//pkg:test" || got.Pos.Line != 3 {
+		t.Errorf("error = %+v, want a command error on //pkg:test at line 3", got)
+	}
+	want := "error while executing commands [{[copy srcs missing]}] on target //pkg:test: could not find rule 'missing'"
+	if got := errs[0].Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := output.Errors[0]; got.Kind != apipb.Output_Error_COMMAND || got.Message != "could not find rule 'missing'" {
+		t.Errorf("JSON error = %+v, want a command error with the message of the command", got)
+	}
+}
+
+func TestErrorKindString(t *testing.T) {
+	for kind, want := range map[ErrorKind]string{
+		ErrorUnknown:        "UNKNOWN",
+		ErrorFileNotFound:   "FILE_NOT_FOUND",
+		ErrorParse:          "PARSE",
+		ErrorTargetNotFound: "TARGET_NOT_FOUND",
+		ErrorCommand:        "COMMAND",
+		ErrorWrite:          "WRITE",
+	} {
+		if got := kind.String(); got != want {
+			t.Errorf("ErrorKind(%d).String() = %q, want %q", int(kind), got, want)
+		}
+	}
+}
@@ -19,6 +19,8 @@ limitations under the License.
 package edit
 
 import (
+	"bytes"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -100,6 +102,110 @@ func shortenLabels(_ *build.File, r *build.Rule, pkg string) bool {
 	return fixed
 }
 
+// Label forms supported by NormalizeLabel.
+const (
+	// LabelFormShort is the canonical form recommended by the style guide: labels of the same
+	// package are relative and redundant target names are omitted, e.g. ":x" and "//foo".
+	LabelFormShort = "short"
+	// LabelFormLong spells out the package and the target name of all labels, e.g. "//pkg:x"
+	// and "//foo:foo".
+	LabelFormLong = "long"
+)
+
+// NormalizeLabel rewrites a label of the package pkg to the given form. Strings that don't look
+// like labels (e.g. file names), package wildcards and canonical repository names are returned
+// unchanged.
+func NormalizeLabel(label, pkg, form string) string {
+	if !strings.HasPrefix(label, "//") && !strings.HasPrefix(label, "@") && !strings.HasPrefix(label, ":") {
+		return label
+	}
+	if strings.HasPrefix(label, "@@") || strings.Contains(label, "...") || strings.Contains(label, "$(") {
+		return label
+	}
+	l := labels.ParseRelative(label, pkg)
+	if form == LabelFormShort {
+		return l.FormatRelative(pkg)
+	}
+	long := "//" + l.Package + ":" + l.Target
+	if l.Repository != "" {
+		long = "@" + l.Repository + long
+	}
+	return long
+}
+
+// HasKeepComment reports whether an expression is marked with a `# keep` comment, which asks
+// tools to leave it alone.
+func HasKeepComment(e build.Expr) bool {
+	comments := e.Comment()
+	for _, c := range append(comments.Before, comments.Suffix...) {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Token, "#"))
+		if text == "keep" || strings.HasPrefix(text, "keep:") {
+			return true
+		}
+	}
+	return false
+}
+
+// labelStrings returns the string literals of a label attribute value, including the ones
+// in list concatenations and select() branches.
+func labelStrings(e build.Expr) []*build.StringExpr {
+	switch e := e.(type) {
+	case *build.StringExpr:
+		return []*build.StringExpr{e}
+	case *build.ListExpr:
+		var strs []*build.StringExpr
+		for _, elem := range e.List {
+			if !HasKeepComment(elem) {
+				strs = append(strs, labelStrings(elem)...)
+			}
+		}
+		return strs
+	case *build.BinaryExpr:
+		if e.Op == "+" {
+			return append(labelStrings(e.X), labelStrings(e.Y)...)
+		}
+	case *build.CallExpr:
+		if ident, ok := e.X.(*build.Ident); ok && ident.Name == "select" && len(e.List) > 0 {
+			if dict, ok := e.List[0].(*build.DictExpr); ok {
+				var strs []*build.StringExpr
+				for _, kv := range dict.List {
+					if !HasKeepComment(kv) {
+						strs = append(strs, labelStrings(kv.Key)...)
+						strs = append(strs, labelStrings(kv.Value)...)
+					}
+				}
+				return strs
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeLabels rewrites the labels in the label attributes of a rule to the given form (see
+// NormalizeLabel). Attributes and values marked with a `# keep` comment are left alone.
+// Returns whether the rule has been changed.
+func NormalizeLabels(r *build.Rule, pkg, form string) bool {
+	fixed := false
+	for _, attr := range r.AttrKeys() {
+		if !ContainsLabels(r.Kind(), attr) {
+			continue
+		}
+		if as := r.AttrDefn(attr); as == nil || HasKeepComment(as) {
+			continue
+		}
+		for _, str := range labelStrings(r.Attr(attr)) {
+			if HasKeepComment(str) {
+				continue
+			}
+			if normalized := NormalizeLabel(str.Value, pkg, form); normalized != str.Value {
+				str.Value = normalized
+				fixed = true
+			}
+		}
+	}
+	return fixed
+}
+
 // removeVisibility removes useless visibility attributes.
 func removeVisibility(f *build.File, r *build.Rule, pkg string) bool {
 	// If no default_visibility is given, it is implicitly private.
@@ -471,6 +577,28 @@ func moveLicenses(f *build.File) bool {
 	return moveToPackage(f, "licenses")
 }
 
+// applyRewrites runs the named buildifier rewrites, see build.RewritePasses, on the file and
+// reports whether they changed it. They're the ones buildifier applies when formatting, so that
+// Buildozer can run them on the files it doesn't otherwise modify.
+func applyRewrites(f *build.File, passes ...string) bool {
+	before := build.FormatWithoutRewriting(f)
+	w := build.NewRewriter()
+	w.RewriteSet = passes
+	w.Rewrite(f)
+	return !bytes.Equal(before, build.FormatWithoutRewriting(f))
+}
+
+// sortLists sorts the sortable lists of a rule (srcs, deps, etc.) like buildifier does.
+func sortLists(f *build.File, r *build.Rule, _ string) bool {
+	return applyRewrites(&build.File{Type: f.Type, Stmt: []build.Expr{r.Call}}, "listsort")
+}
+
+// sortLoads merges the load statements of the same module, and sorts them and their symbols
+// like buildifier does.
+func sortLoads(f *build.File) bool {
+	return applyRewrites(f, "sameOriginLoad", "sortLoadStatements", "loadsort")
+}
+
 // AllRuleFixes is a list of all Buildozer fixes that can be applied on a rule.
 var AllRuleFixes = []struct {
 	Name    string
@@ -497,6 +625,8 @@ var AllRuleFixes = []struct {
 		"All varref('foo') should be replaced with '$foo'"},
 	{"mergeLiteralLists", mergeLiteralLists,
 		"Remove useless list concatenation"},
+	{"sortLists", sortLists,
+		"Sort the lists of the attributes buildifier sorts"},
 }
 
 // FileLevelFixes is a list of all Buildozer fixes that apply on the whole file.
@@ -513,6 +643,37 @@ var FileLevelFixes = []struct {
 		"Remove unused symbols from load statements"},
 	{"moveLicenses", moveLicenses,
 		"Move licenses to the package function"},
+	{"sortLoads", sortLoads,
+		"Merge and sort load statements and their symbols"},
+}
+
+// namedOnlyFixes are the fixes that only run when they're named, not by a fix command without
+// fixes: they reorder lists and load statements, which is noisy in the files the other fixes
+// touch.
+var namedOnlyFixes = map[string]bool{
+	"sortLists": true,
+	"sortLoads": true,
+}
+
+// CheckFixes returns an error if any of the fixes is neither in AllRuleFixes nor in
+// FileLevelFixes.
+func CheckFixes(fixes []string) error {
+	known := make(map[string]bool)
+	var names []string
+	for _, fix := range AllRuleFixes {
+		known[fix.Name] = true
+		names = append(names, fix.Name)
+	}
+	for _, fix := range FileLevelFixes {
+		known[fix.Name] = true
+		names = append(names, fix.Name)
+	}
+	for _, fix := range fixes {
+		if !known[fix] {
+			return fmt.Errorf("unknown fix %q, the available fixes are: %s", fix, strings.Join(names, ", "))
+		}
+	}
+	return nil
 }
 
 // FixRule aims to fix errors in BUILD files, remove deprecated features, and
@@ -524,7 +685,7 @@ func FixRule(f *build.File, pkg string, rule *build.Rule, fixes []string) *build
 	}
 	fixed := false
 	for _, fix := range AllRuleFixes {
-		if len(fixes) == 0 || fixesAsMap[fix.Name] {
+		if len(fixes) == 0 && !namedOnlyFixes[fix.Name] || fixesAsMap[fix.Name] {
 			fixed = fix.Fn(f, rule, pkg) || fixed
 		}
 	}
@@ -549,7 +710,7 @@ func FixFile(f *build.File, pkg string, fixes []string) *build.File {
 		}
 	}
 	for _, fix := range FileLevelFixes {
-		if len(fixes) == 0 || fixesAsMap[fix.Name] {
+		if len(fixes) == 0 && !namedOnlyFixes[fix.Name] || fixesAsMap[fix.Name] {
 			fixed = fix.Fn(f) || fixed
 		}
 	}
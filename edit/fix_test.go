@@ -126,3 +126,196 @@ x()
 		})
 	}
 }
+
+func TestFixFileNamedFixes(t *testing.T) {
+	tests := []struct {
+		name  string
+		fixes []string
+		input string
+		want  string // empty if the fixes don't change the file
+	}{
+		{
+			name:  "sort lists",
+			fixes: []string{"sortLists"},
+			input: `load(":b.bzl", "b")
+load(":a.bzl", "a")
+
+cc_library(
+    name = "x",
+    srcs = [
+        "b.cc",
+        "a.cc",
+    ],
+)
+`,
+			want: `load(":b.bzl", "b")
+load(":a.bzl", "a")
+
+cc_library(
+    name = "x",
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],
+)
+`,
+		},
+		{
+			name:  "sort loads",
+			fixes: []string{"sortLoads"},
+			input: `load(":b.bzl", "b")
+load(":a.bzl", "z")
+load(":a.bzl", "a")
+
+cc_library(
+    name = "x",
+    srcs = [
+        "b.cc",
+        "a.cc",
+    ],
+)
+`,
+			want: `load(":a.bzl", "a", "z")
+load(":b.bzl", "b")
+
+cc_library(
+    name = "x",
+    srcs = [
+        "b.cc",
+        "a.cc",
+    ],
+)
+`,
+		},
+		{
+			name: "not run without named fixes",
+			input: `load(":b.bzl", "b")
+load(":a.bzl", "a")
+
+a(
+    name = "x",
+    srcs = [
+        "b.cc",
+        "a.cc",
+    ],
+)
+
+b(name = "y")
+`,
+		},
+		{
+			name:  "already sorted",
+			fixes: []string{"sortLists", "sortLoads"},
+			input: `load(":a.bzl", "a")
+
+cc_library(
+    name = "x",
+    srcs = [
+        "a.cc",
+        "b.cc",
+    ],
+)
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bld, err := build.Parse("BUILD", []byte(tc.input))
+			if err != nil {
+				t.Fatalf("Failed to parse %s; %v", tc.input, err)
+			}
+			res := FixFile(bld, "pkg", tc.fixes)
+			if tc.want == "" {
+				if res != nil {
+					t.Errorf("FixFile(%q) changed the file:\n%s", tc.fixes, build.FormatWithoutRewriting(res))
+				}
+				return
+			}
+			if res == nil {
+				t.Fatalf("FixFile(%q) didn't change the file", tc.fixes)
+			}
+			if diff := cmp.Diff(tc.want, string(build.FormatWithoutRewriting(res))); diff != "" {
+				t.Errorf("%s: (-want +got): %s", tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestCheckFixes(t *testing.T) {
+	if err := CheckFixes([]string{"unusedLoads", "sortLists"}); err != nil {
+		t.Errorf("CheckFixes() = %v, want nil", err)
+	}
+	if err := CheckFixes([]string{"sortList"}); err == nil || !strings.Contains(err.Error(), `unknown fix "sortList"`) {
+		t.Errorf("CheckFixes() = %v, want an unknown fix error", err)
+	}
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	input := `cc_library(
+    name = "lib",
+    srcs = ["lib.cc"],
+    deps = [
+        ":a",
+        "//pkg:b",
+        "//foo:foo",
+        "//foo:bar",  # keep
+        "@repo//:repo",
+        "@other//x",
+    ] + select({
+        "//conditions:default": ["//pkg:c"],
+    }),
+    data = ["//pkg:d"],  # keep
+    visibility = ["//foo/..."],
+)
+`
+	for _, tc := range []struct {
+		form, want string
+	}{
+		{LabelFormShort, `cc_library(
+    name = "lib",
+    srcs = ["lib.cc"],
+    deps = [
+        ":a",
+        ":b",
+        "//foo",
+        "//foo:bar",  # keep
+        "@repo",
+        "@other//x",
+    ] + select({
+        "//conditions:default": [":c"],
+    }),
+    data = ["//pkg:d"],  # keep
+    visibility = ["//foo/..."],
+)
+`},
+		{LabelFormLong, `cc_library(
+    name = "lib",
+    srcs = ["lib.cc"],
+    deps = [
+        "//pkg:a",
+        "//pkg:b",
+        "//foo:foo",
+        "//foo:bar",  # keep
+        "@repo//:repo",
+        "@other//x:x",
+    ] + select({
+        "//conditions:default": ["//pkg:c"],
+    }),
+    data = ["//pkg:d"],  # keep
+    visibility = ["//foo/..."],
+)
+`},
+	} {
+		f, err := build.ParseBuild("pkg/BUILD", []byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !NormalizeLabels(f.Rules("cc_library")[0], "pkg", tc.form) {
+			t.Errorf("NormalizeLabels(%q) = false, want true", tc.form)
+		}
+		if got := string(build.FormatWithoutRewriting(f)); got != tc.want {
+			t.Errorf("NormalizeLabels(%q):\n%s", tc.form, cmp.Diff(tc.want, got))
+		}
+	}
+}
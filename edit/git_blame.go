@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Annotating the lines of a file with the commits that last changed them, for the print_blame
+// command
+
+package edit
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A blameCommit is a commit reported by `git blame`.
+type blameCommit struct {
+	hash   string
+	author string
+	date   string // the author date, e.g. 2026-01-31
+}
+
+// String returns the abbreviated hash of the commit followed by its date and author.
+func (c *blameCommit) String() string {
+	hash := c.hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	return fmt.Sprintf("%s %s %s", hash, c.date, c.author)
+}
+
+// gitBlame returns the commits that last changed the lines first to last (1-based, inclusive)
+// of a file, indexed by line number. The file is read from the given commit, or from the
+// working tree including the uncommitted changes if commit is empty.
+func gitBlame(name, commit string, first, last int) (map[int]*blameCommit, error) {
+	args := []string{"blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", first, last)}
+	if commit != "" {
+		args = append(args, commit)
+	}
+	args = append(args, "--", filepath.Base(name))
+	out, err := runGit(filepath.Dir(name), args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseBlame(string(out))
+}
+
+// parseBlame parses the output of `git blame --porcelain`. Each line of the file is preceded by
+// a header "<hash> <original line> <final line> [<number of lines>]", followed by the
+// information about the commit the first time it appears.
+func parseBlame(out string) (map[int]*blameCommit, error) {
+	commits := make(map[string]*blameCommit)
+	lines := make(map[int]*blameCommit)
+	var commit *blameCommit
+	var timestamp, tz string
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" || strings.HasPrefix(line, "\t") {
+			// The content of the line ends the information about the commit.
+			if commit != nil && commit.date == "" && timestamp != "" {
+				commit.date = blameDate(timestamp, tz)
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		if fields := strings.Fields(line); len(fields) >= 3 && isCommitHash(fields[0]) {
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected git blame output %q", line)
+			}
+			if commits[fields[0]] == nil {
+				commits[fields[0]] = &blameCommit{hash: fields[0]}
+				timestamp, tz = "", ""
+			}
+			commit = commits[fields[0]]
+			lines[n] = commit
+			continue
+		}
+		if commit == nil {
+			return nil, fmt.Errorf("unexpected git blame output %q", line)
+		}
+		switch key {
+		case "author":
+			commit.author = value
+		case "author-time":
+			timestamp = value
+		case "author-tz":
+			tz = value
+		}
+	}
+	return lines, nil
+}
+
+// isCommitHash reports whether s is a full SHA-1 or SHA-256 commit hash.
+func isCommitHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// blameDate formats a Unix timestamp as a date in the time zone of the author, e.g. +0100.
+func blameDate(timestamp, tz string) string {
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return timestamp
+	}
+	t := time.Unix(secs, 0).UTC()
+	if zone, err := time.Parse("-0700", tz); err == nil {
+		t = t.In(zone.Location())
+	}
+	return t.Format("2006-01-02")
+}
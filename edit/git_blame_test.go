@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPrintBlame(t *testing.T) {
+	tmp, git, write := newGitRepo(t)
+	hash := func() string {
+		t.Helper()
+		out, err := runGit(tmp, "rev-parse", "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out[:12])
+	}
+
+	write("MODULE.bazel", "")
+	write("BUILD", `go_library(
+    name = "lib",
+    deps = [
+        ":a",
+        ":b",
+    ],
+)
+`)
+	git("add", "-A")
+	git("-c", "user.name=Alice Smith", "commit", "-q", "-m", "base", "--date=2026-01-02T10:00:00+0000")
+	base := hash()
+	write("BUILD", `go_library(
+    name = "lib",
+    deps = [
+        ":a",
+        ":b",
+        ":c",
+    ] + select({
+        ":linux": [":linux_only"],
+        "//conditions:default": [],
+    }),
+    srcs = ["lib.go"],
+)
+`)
+	git("add", "-A")
+	git("-c", "user.name=Bob", "commit", "-q", "-m", "more deps", "--date=2026-03-04T23:30:00-0500")
+	more := hash()
+	// An uncommitted change.
+	write("BUILD", `go_library(
+    name = "lib",
+    deps = [
+        ":a",
+        ":b",
+        ":c",
+        ":d",
+    ],
+    srcs = ["lib.go"],
+)
+`)
+
+	var out, stderr bytes.Buffer
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.OutWriter = &out
+	opts.ErrWriter = &stderr
+	opts.GitRev = "HEAD"
+	if ret := Buildozer(opts, []string{"print_blame deps", "//:lib"}); ret != 0 {
+		t.Fatalf("Buildozer() = %d, want 0, stderr:\n%s", ret, stderr.String())
+	}
+	want := `:a ` + base + ` 2026-01-02 Alice Smith
+:b ` + base + ` 2026-01-02 Alice Smith
+:c ` + more + ` 2026-03-04 Bob
+:linux_only ` + more + ` 2026-03-04 Bob
+`
+	if out.String() != want {
+		t.Errorf("Buildozer(print_blame) with GitRev output =\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// The working tree, with the elements added by the previous commands.
+	out.Reset()
+	opts.GitRev = ""
+	if ret := Buildozer(opts, []string{"add deps :e", "print_blame deps", "//:lib"}); ret != 0 {
+		t.Fatalf("Buildozer() = %d, want 0, stderr:\n%s", ret, stderr.String())
+	}
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) != 6 || lines[2] != ":c "+more+" 2026-03-04 Bob" ||
+		!strings.HasPrefix(lines[3], ":d 000000000000 ") || lines[4] != ":e (unknown)" {
+		t.Errorf("Buildozer(print_blame) output =\n%s", out.String())
+	}
+
+	opts.ErrWriter = io.Discard
+	if ret := Buildozer(opts, []string{"print_blame name", "//:lib"}); ret != 2 {
+		t.Errorf("Buildozer(print_blame) on a string = %d, want 2", ret)
+	}
+}
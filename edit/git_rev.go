@@ -0,0 +1,191 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Reading the files of a Git revision for the -git_rev flag
+
+package edit
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bazelbuild/buildtools/differ"
+)
+
+// gitRev reads the files of a revision of a Git repository instead of the ones of the working
+// tree, see Options.GitRev. The paths given to its methods are paths of the working tree.
+type gitRev struct {
+	commit string // the commit the revision resolved to when the invocation started
+	root   string // the top-level directory of the working tree
+
+	once  sync.Once
+	files map[string]bool // the files of the commit, relative to root
+	err   error
+}
+
+// runGit runs a git command in dir and returns its standard output.
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+// newGitRev resolves a revision of the Git repository containing dir, e.g. a commit hash, a
+// branch or HEAD.
+func newGitRev(dir, rev string) (*gitRev, error) {
+	if dir == "" {
+		dir = "."
+	}
+	out, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.FromSlash(strings.TrimSpace(string(out)))
+	out, err = runGit(dir, "rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q", rev)
+	}
+	return &gitRev{commit: strings.TrimSpace(string(out)), root: root}, nil
+}
+
+// relPath returns the path of a file of the working tree relative to its top-level directory,
+// with forward slashes.
+func (g *gitRev) relPath(name string) (string, error) {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", err
+	}
+	// Git reports the top-level directory with the symbolic links resolved.
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(dir, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(g.root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the Git repository %s", name, g.root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// listFiles returns the files of the commit, it only runs `git ls-tree` once.
+func (g *gitRev) listFiles() (map[string]bool, error) {
+	g.once.Do(func() {
+		out, err := runGit(g.root, "ls-tree", "-r", "-z", "--name-only", "--full-tree", g.commit)
+		if err != nil {
+			g.err = err
+			return
+		}
+		g.files = make(map[string]bool)
+		for _, file := range strings.Split(string(out), "\x00") {
+			if file != "" {
+				g.files[file] = true
+			}
+		}
+	})
+	return g.files, g.err
+}
+
+// readFile returns the contents of a file in the commit.
+func (g *gitRev) readFile(name string) ([]byte, error) {
+	rel, err := g.relPath(name)
+	if err != nil {
+		return nil, err
+	}
+	files, err := g.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	if !files[rel] {
+		return nil, fmt.Errorf("%s doesn't exist in %s: %w", rel, g.commit, os.ErrNotExist)
+	}
+	return runGit(g.root, "cat-file", "blob", g.commit+":"+rel)
+}
+
+// readBuildFile is like the readBuildFile function, for the files of the commit.
+func (g *gitRev) readBuildFile(name string) (string, []byte, error) {
+	for _, suffix := range BuildFileNames {
+		if strings.HasSuffix(name, "/"+suffix) {
+			name = strings.TrimSuffix(name, suffix)
+			break
+		}
+	}
+	for _, suffix := range BuildFileNames {
+		if data, err := g.readFile(name + suffix); err == nil {
+			return name + suffix, data, nil
+		}
+	}
+	data, err := g.readFile(name)
+	return name, data, err
+}
+
+// findBuildFiles is like the findBuildFiles function, for the BUILD files of the commit.
+func (g *gitRev) findBuildFiles(rootDir string, ignoredPrefixes []string) []string {
+	dir, err := g.relPath(rootDir)
+	if err != nil {
+		return nil
+	}
+	files, err := g.listFiles()
+	if err != nil {
+		return nil
+	}
+	var buildFiles []string
+	for file := range files {
+		if dir != "." && !strings.HasPrefix(file, dir+"/") {
+			continue
+		}
+		fullPath := filepath.Join(rootDir, filepath.FromSlash(strings.TrimPrefix(file, dir+"/")))
+		if shouldIgnorePath(fullPath, rootDir, ignoredPrefixes) {
+			continue
+		}
+		for _, buildFileName := range BuildFileNames {
+			if path.Base(file) == buildFileName {
+				buildFiles = append(buildFiles, fullPath)
+			}
+		}
+	}
+	sort.Strings(buildFiles)
+	return buildFiles
+}
+
+// patch returns the changes made to a file of the commit as a unified diff, with the path of
+// the file relative to the top-level directory like in the output of `git diff`, so that it can
+// be applied with `git apply`.
+func (g *gitRev) patch(name string, data, ndata []byte) ([]byte, error) {
+	rel, err := g.relPath(name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := differ.WriteUnified(&buf, rel, string(data), string(ndata), false); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
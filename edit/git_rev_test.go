@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newGitRepo creates a Git repository in a temporary directory and returns the directory,
+// a function running git commands in it, and one writing its files.
+func newGitRepo(t *testing.T) (string, func(args ...string), func(name, content string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't available")
+	}
+	tmp := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if _, err := runGit(tmp, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(tmp, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	return tmp, git, write
+}
+
+func TestGitRev(t *testing.T) {
+	tmp, git, write := newGitRepo(t)
+	write("MODULE.bazel", "")
+	write("BUILD", `go_library(name = "lib")
+`)
+	write("pkg/BUILD.bazel", `go_library(
+    name = "lib",
+    srcs = ["lib.go"],
+)
+`)
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+
+	// Uncommitted changes of the working tree, ignored with GitRev.
+	dirty := `go_library(name = "lib", testonly = True)
+`
+	write("BUILD", dirty)
+	write("untracked/BUILD", `go_library(name = "lib")
+`)
+
+	var out bytes.Buffer
+	opts := NewOpts()
+	opts.RootDir = tmp
+	opts.OutWriter = &out
+	opts.ErrWriter = io.Discard
+	opts.GitRev = "HEAD"
+	if ret := Buildozer(opts, []string{"add deps //base", "//...:lib"}); ret != 0 {
+		t.Fatalf("Buildozer() = %d, want 0", ret)
+	}
+	want := `--- a/BUILD
++++ b/BUILD
+@@ -1 +1,4 @@
+-go_library(name = "lib")
++go_library(
++    name = "lib",
++    deps = ["//base"],
++)
+--- a/pkg/BUILD.bazel
++++ b/pkg/BUILD.bazel
+@@ -1,4 +1,5 @@
+ go_library(
+     name = "lib",
+     srcs = ["lib.go"],
++    deps = ["//base"],
+ )
+`
+	if out.String() != want {
+		t.Errorf("Buildozer() output =\n%s\nwant:\n%s", out.String(), want)
+	}
+	if content, err := os.ReadFile(filepath.Join(tmp, "BUILD")); err != nil || string(content) != dirty {
+		t.Errorf("BUILD = %q, %v, want it unchanged", content, err)
+	}
+
+	// The patch applies to the revision.
+	if err := os.WriteFile(filepath.Join(tmp, "fix.patch"), out.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("apply", "--cached", "fix.patch")
+
+	// Nothing to change.
+	out.Reset()
+	if ret := Buildozer(opts, []string{"remove deps //base", "//:lib"}); ret != 3 || out.Len() != 0 {
+		t.Errorf("Buildozer(remove) = %d, %q, want 3 and no output", ret, out.String())
+	}
+
+	// The entries of a plan would have to see the changes of the previous ones.
+	opts.PlanFile = filepath.Join(tmp, "plan.json")
+	write("plan.json", `[{"command": "add", "args": ["deps", "//base"], "targets": ["//:lib"]}]`)
+	if ret := Buildozer(opts, nil); ret != 1 || out.Len() != 0 {
+		t.Errorf("Buildozer() with a plan = %d, %q, want 1 and no output", ret, out.String())
+	}
+	opts.PlanFile = ""
+
+	opts.GitRev = "missing"
+	if ret := Buildozer(opts, []string{"add deps //base", "//:lib"}); ret != 1 {
+		t.Errorf("Buildozer() with an unknown revision = %d, want 1", ret)
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// An InsertionStrategy decides where a new rule is inserted into a file.
+type InsertionStrategy interface {
+	// InsertionIndex returns the index of the statement of f after which the call should be
+	// inserted, or -1 to insert it at the beginning of the file.
+	InsertionIndex(f *build.File, call *build.CallExpr) int
+}
+
+// InsertRule inserts a rule into the file at the place chosen by the strategy.
+// A nil strategy means SameKindInsertion.
+func InsertRule(f *build.File, call *build.CallExpr, strategy InsertionStrategy) {
+	if strategy == nil {
+		strategy = SameKindInsertion{}
+	}
+	f.Stmt = InsertAfter(strategy.InsertionIndex(f, call), f.Stmt, call)
+}
+
+// InsertionStrategies lists the strategies that can be selected by name, e.g. with the
+// -new_rule_placement flag of buildozer.
+var InsertionStrategies = map[string]InsertionStrategy{
+	"kind":          SameKindInsertion{},
+	"group_by_kind": GroupByKindInsertion{},
+	"alphabetical":  AlphabeticalInsertion{},
+	"after_loads":   AfterLoadsInsertion{},
+	"end":           AppendInsertion{},
+}
+
+// InsertionStrategyByName returns the strategy with the given name from InsertionStrategies.
+func InsertionStrategyByName(name string) (InsertionStrategy, error) {
+	if strategy, ok := InsertionStrategies[name]; ok {
+		return strategy, nil
+	}
+	var names []string
+	for name := range InsertionStrategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown insertion strategy %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// indexedRule is a rule together with its index in the list of statements.
+type indexedRule struct {
+	index int
+	rule  *build.Rule
+}
+
+// topLevelRules returns the rules called at the top level of the file.
+func topLevelRules(f *build.File) []indexedRule {
+	var rules []indexedRule
+	for i, stmt := range f.Stmt {
+		if call, ok := stmt.(*build.CallExpr); ok {
+			rules = append(rules, indexedRule{i, f.Rule(call)})
+		}
+	}
+	return rules
+}
+
+// AppendInsertion inserts new rules at the end of the file, before trailing comments.
+type AppendInsertion struct{}
+
+// InsertionIndex implements InsertionStrategy.
+func (AppendInsertion) InsertionIndex(f *build.File, call *build.CallExpr) int {
+	i := len(f.Stmt) - 1
+	for ; i >= 0; i-- {
+		if _, ok := f.Stmt[i].(*build.CommentBlock); !ok {
+			break
+		}
+	}
+	return i
+}
+
+// SameKindInsertion inserts new rules after the last rule of the same kind, or at the end of
+// the file if there's none. This is the default strategy.
+type SameKindInsertion struct{}
+
+// InsertionIndex implements InsertionStrategy.
+func (SameKindInsertion) InsertionIndex(f *build.File, call *build.CallExpr) int {
+	kind := f.Rule(call).Kind()
+	index := -1
+	for _, r := range topLevelRules(f) {
+		if r.rule.Kind() == kind {
+			index = r.index
+		}
+	}
+	if index == -1 {
+		return AppendInsertion{}.InsertionIndex(f, call)
+	}
+	return index
+}
+
+// GroupByKindInsertion inserts new rules after the last rule of the same kind. If there's none,
+// the new rule starts a new group before the first rule whose kind sorts after its own, so that
+// files whose rules are grouped by kind in alphabetical order stay that way.
+type GroupByKindInsertion struct{}
+
+// InsertionIndex implements InsertionStrategy.
+func (GroupByKindInsertion) InsertionIndex(f *build.File, call *build.CallExpr) int {
+	kind := f.Rule(call).Kind()
+	rules := topLevelRules(f)
+	for _, r := range rules {
+		if r.rule.Kind() == kind {
+			return SameKindInsertion{}.InsertionIndex(f, call)
+		}
+	}
+	for _, r := range rules {
+		if r.rule.Name() != "" && r.rule.Kind() > kind {
+			return r.index - 1
+		}
+	}
+	return AppendInsertion{}.InsertionIndex(f, call)
+}
+
+// AlphabeticalInsertion inserts new rules before the first rule of the same kind whose name sorts
+// after the new name, or after the last rule of the same kind. If there are no rules of the same
+// kind, all named rules are considered.
+type AlphabeticalInsertion struct{}
+
+// InsertionIndex implements InsertionStrategy.
+func (AlphabeticalInsertion) InsertionIndex(f *build.File, call *build.CallExpr) int {
+	rule := f.Rule(call)
+	var candidates, named []indexedRule
+	for _, r := range topLevelRules(f) {
+		if r.rule.Name() == "" {
+			continue
+		}
+		named = append(named, r)
+		if r.rule.Kind() == rule.Kind() {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = named
+	}
+	if len(candidates) == 0 {
+		return AppendInsertion{}.InsertionIndex(f, call)
+	}
+	for _, r := range candidates {
+		if r.rule.Name() > rule.Name() {
+			return r.index - 1
+		}
+	}
+	return candidates[len(candidates)-1].index
+}
+
+// AfterLoadsInsertion inserts new rules after the load statements and the package declaration,
+// or after the leading comments and docstring if there are none.
+type AfterLoadsInsertion struct{}
+
+// InsertionIndex implements InsertionStrategy.
+func (AfterLoadsInsertion) InsertionIndex(f *build.File, call *build.CallExpr) int {
+	index := -1
+	for i, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.LoadStmt:
+			index = i
+		case *build.CallExpr:
+			if f.Rule(stmt).Kind() == "package" {
+				index = i
+			}
+		}
+	}
+	if index >= 0 {
+		return index
+	}
+	for i, stmt := range f.Stmt {
+		switch stmt.(type) {
+		case *build.CommentBlock, *build.StringExpr:
+			index = i
+			continue
+		}
+		break
+	}
+	return index
+}
+
+// RelativeInsertion inserts new rules right before or after the rule with the given name.
+// If there's no such rule, Fallback (or SameKindInsertion if it's nil) is used.
+type RelativeInsertion struct {
+	Name     string
+	Before   bool
+	Fallback InsertionStrategy
+}
+
+// InsertionIndex implements InsertionStrategy.
+func (s RelativeInsertion) InsertionIndex(f *build.File, call *build.CallExpr) int {
+	index, _ := IndexOfRuleByName(f, s.Name)
+	if index == -1 {
+		fallback := s.Fallback
+		if fallback == nil {
+			fallback = SameKindInsertion{}
+		}
+		return fallback.InsertionIndex(f, call)
+	}
+	if s.Before {
+		return index - 1
+	}
+	return index
+}
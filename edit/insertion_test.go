@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestInsertionStrategies(t *testing.T) {
+	input := `"""Docstring."""
+
+load(":defs.bzl", "my_rule")
+
+package(default_visibility = ["//visibility:public"])
+
+cc_library(name = "b")
+
+cc_library(name = "d")
+
+py_library(name = "c")
+
+# trailing comment
+`
+	for _, tc := range []struct {
+		strategy InsertionStrategy
+		kind     string
+		name     string
+		want     []string // names (or kinds of unnamed calls) of the top-level calls after insertion
+	}{
+		{SameKindInsertion{}, "cc_library", "a", []string{"package", "b", "d", "a", "c"}},
+		{SameKindInsertion{}, "go_library", "a", []string{"package", "b", "d", "c", "a"}},
+		{AppendInsertion{}, "cc_library", "a", []string{"package", "b", "d", "c", "a"}},
+		{GroupByKindInsertion{}, "cc_library", "a", []string{"package", "b", "d", "a", "c"}},
+		{GroupByKindInsertion{}, "go_library", "a", []string{"package", "b", "d", "a", "c"}},
+		{GroupByKindInsertion{}, "aa_library", "a", []string{"package", "a", "b", "d", "c"}},
+		{GroupByKindInsertion{}, "sh_library", "a", []string{"package", "b", "d", "c", "a"}},
+		{AlphabeticalInsertion{}, "cc_library", "a", []string{"package", "a", "b", "d", "c"}},
+		{AlphabeticalInsertion{}, "cc_library", "c", []string{"package", "b", "c", "d", "c"}},
+		{AlphabeticalInsertion{}, "cc_library", "e", []string{"package", "b", "d", "e", "c"}},
+		{AlphabeticalInsertion{}, "go_library", "cc", []string{"package", "b", "cc", "d", "c"}},
+		{AfterLoadsInsertion{}, "cc_library", "a", []string{"package", "a", "b", "d", "c"}},
+		{RelativeInsertion{Name: "d", Before: true}, "sh_library", "a", []string{"package", "b", "a", "d", "c"}},
+		{RelativeInsertion{Name: "d"}, "sh_library", "a", []string{"package", "b", "d", "a", "c"}},
+		{RelativeInsertion{Name: "x", Fallback: AfterLoadsInsertion{}}, "sh_library", "a", []string{"package", "a", "b", "d", "c"}},
+	} {
+		f, err := build.ParseBuild("BUILD", []byte(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		call := &build.CallExpr{X: &build.Ident{Name: tc.kind}}
+		(&build.Rule{Call: call}).SetAttr("name", &build.StringExpr{Value: tc.name})
+		InsertRule(f, call, tc.strategy)
+
+		var got []string
+		for _, stmt := range f.Stmt {
+			if call, ok := stmt.(*build.CallExpr); ok {
+				r := f.Rule(call)
+				if r.Name() != "" {
+					got = append(got, r.Name())
+				} else {
+					got = append(got, r.Kind())
+				}
+			}
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%T inserting %s %q: got %q, want %q", tc.strategy, tc.kind, tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%T inserting %s %q: got %q, want %q", tc.strategy, tc.kind, tc.name, got, tc.want)
+				break
+			}
+		}
+		if _, ok := f.Stmt[len(f.Stmt)-1].(*build.CommentBlock); !ok {
+			t.Errorf("%T inserting %s %q: the trailing comment should stay at the end", tc.strategy, tc.kind, tc.name)
+		}
+	}
+}
+
+func TestAfterLoadsInsertionWithoutLoads(t *testing.T) {
+	f, err := build.ParseBuild("BUILD", []byte(`# Copyright header
+
+"""Docstring."""
+
+cc_library(name = "b")
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := &build.CallExpr{X: &build.Ident{Name: "cc_library"}}
+	if got := (AfterLoadsInsertion{}).InsertionIndex(f, call); got != 1 {
+		t.Errorf("InsertionIndex() = %d, want 1", got)
+	}
+}
+
+func TestInsertionStrategyByName(t *testing.T) {
+	if _, err := InsertionStrategyByName("alphabetical"); err != nil {
+		t.Error(err)
+	}
+	if _, err := InsertionStrategyByName("random"); err == nil {
+		t.Error("InsertionStrategyByName(random) succeeded, want error")
+	}
+}
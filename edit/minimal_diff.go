@@ -0,0 +1,143 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// A stmtRange is the byte range of a top-level statement, including its comments.
+type stmtRange struct {
+	start, end int
+}
+
+// stmtRanges parses a file and returns the byte ranges of its top-level statements.
+func stmtRanges(name string, data []byte) ([]stmtRange, error) {
+	f, err := build.Parse(name, data)
+	if err != nil {
+		return nil, err
+	}
+	return fileStmtRanges(f), nil
+}
+
+// fileStmtRanges returns the byte ranges of the top-level statements of a parsed file.
+func fileStmtRanges(f *build.File) []stmtRange {
+	ranges := make([]stmtRange, len(f.Stmt))
+	for i, stmt := range f.Stmt {
+		start, end := stmt.Span()
+		r := stmtRange{start.Byte, end.Byte}
+		comments := stmt.Comment()
+		for _, c := range comments.Before {
+			if c.Start.Byte < r.start {
+				r.start = c.Start.Byte
+			}
+		}
+		for _, c := range comments.Suffix {
+			if e := c.Start.Byte + len(c.Token); e > r.end {
+				r.end = e
+			}
+		}
+		ranges[i] = r
+	}
+	return ranges
+}
+
+// minimalDiff returns the contents of a modified file that only differ from the original
+// contents by the top-level statements changed by the commands. orig is the original file
+// parsed from data, and ndata is the formatted modified file. The other statements are kept as
+// they are, even if they aren't formatted, so that editing a large generated file produces a
+// small diff. ndata is returned if the statements of the original file can't be matched with
+// the formatted ones, e.g. because the formatter reordered them.
+func minimalDiff(orig *build.File, data, ndata []byte) []byte {
+	name := orig.Path
+	origRanges := fileStmtRanges(orig)
+	// The original file is only formatted to find the statements that didn't change, it
+	// doesn't need the clean-ups and the second pass of Buildify.
+	odata := build.Format(orig)
+	formatted, err := stmtRanges(name, odata)
+	if err != nil || len(formatted) != len(origRanges) {
+		return ndata
+	}
+	modified, err := stmtRanges(name, ndata)
+	if err != nil {
+		return ndata
+	}
+	text := func(data []byte, r stmtRange) []byte { return data[r.start:r.end] }
+	same := func(i, j int) bool {
+		return bytes.Equal(text(odata, formatted[i]), text(ndata, modified[j]))
+	}
+
+	// The statements before prefix and after the last suffix statements are unchanged.
+	prefix := 0
+	for prefix < len(origRanges) && prefix < len(modified) && same(prefix, prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(origRanges)-prefix && suffix < len(modified)-prefix && same(len(origRanges)-1-suffix, len(modified)-1-suffix) {
+		suffix++
+	}
+
+	// Runs of changed statements, [oldStart, oldEnd) in the original file are replaced with
+	// [newStart, newEnd) in the modified file. If no statement was added or removed, each
+	// run of consecutive changed statements is replaced separately.
+	type run struct{ oldStart, oldEnd, newStart, newEnd int }
+	var runs []run
+	if len(origRanges) == len(modified) {
+		for i := prefix; i < len(origRanges)-suffix; i++ {
+			if same(i, i) {
+				continue
+			}
+			if n := len(runs); n > 0 && runs[n-1].oldEnd == i {
+				runs[n-1].oldEnd++
+				runs[n-1].newEnd++
+			} else {
+				runs = append(runs, run{i, i + 1, i, i + 1})
+			}
+		}
+	} else {
+		runs = []run{{prefix, len(origRanges) - suffix, prefix, len(modified) - suffix}}
+	}
+	if len(runs) == 0 {
+		return data
+	}
+
+	// A run is replaced together with the whitespace around it, from the end of the previous
+	// statement to the beginning of the next one.
+	bounds := func(data []byte, ranges []stmtRange, start, end int) (int, int) {
+		from, to := 0, len(data)
+		if start > 0 {
+			from = ranges[start-1].end
+		}
+		if end < len(ranges) {
+			to = ranges[end].start
+		}
+		return from, to
+	}
+	var out bytes.Buffer
+	last := 0
+	for _, r := range runs {
+		oldFrom, oldTo := bounds(data, origRanges, r.oldStart, r.oldEnd)
+		newFrom, newTo := bounds(ndata, modified, r.newStart, r.newEnd)
+		out.Write(data[last:oldFrom])
+		out.Write(ndata[newFrom:newTo])
+		last = oldTo
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
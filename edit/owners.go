@@ -0,0 +1,207 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Finding the rules that own a source file.
+
+package edit
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/labels"
+	"github.com/bazelbuild/buildtools/wspace"
+)
+
+// SourceAttributes are the attributes FindFileOwners looks for source files in.
+var SourceAttributes = []string{"srcs", "hdrs", "textual_hdrs"}
+
+// FindFileOwners returns the labels of the rules whose source attributes include the given
+// file, either explicitly or with a glob. The file path is relative to the working directory,
+// the rules are looked up in the package of the file, i.e. in the BUILD file of the closest
+// directory above it that has one. rootDir is used to find the workspace root like for labels.
+func FindFileOwners(rootDir, filename string) ([]string, error) {
+	root, _ := wspace.FindWorkspaceRoot(rootDir)
+	if root == "" {
+		return nil, fmt.Errorf("%s: workspace root not found", filename)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%s is not in the workspace %s", filename, root)
+	}
+
+	// Look for the package of the file.
+	dir := filepath.ToSlash(filepath.Dir(rel))
+	for {
+		if dir == "." {
+			dir = ""
+		}
+		buildFile := packageBuildFile(filepath.Join(root, filepath.FromSlash(dir)))
+		if buildFile != "" {
+			return findOwnersInPackage(buildFile, dir, strings.TrimPrefix(filepath.ToSlash(rel), dir+"/"))
+		}
+		if dir == "" {
+			return nil, fmt.Errorf("no package found for %s", filename)
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// packageBuildFile returns the path of the BUILD file of a directory, or "" if it's not a package.
+func packageBuildFile(dir string) string {
+	for _, name := range BuildFileNames {
+		buildFile := filepath.Join(dir, name)
+		if info, err := os.Stat(buildFile); err == nil && info.Mode().IsRegular() {
+			return buildFile
+		}
+	}
+	return ""
+}
+
+// findOwnersInPackage returns the labels of the rules of a BUILD file that own the file with
+// the given path relative to the package.
+func findOwnersInPackage(buildFile, pkg, name string) ([]string, error) {
+	data, err := os.ReadFile(buildFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := build.ParseBuild(buildFile, data)
+	if err != nil {
+		return nil, err
+	}
+	var owners []string
+	for _, r := range f.Rules("") {
+		if r.Name() == "" {
+			continue
+		}
+		for _, attr := range SourceAttributes {
+			if exprContainsFile(r.Attr(attr), pkg, name) {
+				owners = append(owners, "//"+pkg+":"+r.Name())
+				break
+			}
+		}
+	}
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("no rule in //%s has %s in %s", pkg, name, strings.Join(SourceAttributes, ", "))
+	}
+	return owners, nil
+}
+
+// exprContainsFile reports whether the value of an attribute includes the file. Lists,
+// concatenations, selects and globs are taken into account.
+func exprContainsFile(e build.Expr, pkg, name string) bool {
+	switch e := e.(type) {
+	case *build.StringExpr:
+		return labels.Equal(e.Value, name, pkg)
+	case *build.ListExpr:
+		for _, elem := range e.List {
+			if exprContainsFile(elem, pkg, name) {
+				return true
+			}
+		}
+	case *build.BinaryExpr:
+		return e.Op == "+" && (exprContainsFile(e.X, pkg, name) || exprContainsFile(e.Y, pkg, name))
+	case *build.CallExpr:
+		ident, ok := e.X.(*build.Ident)
+		if !ok {
+			return false
+		}
+		switch {
+		case ident.Name == "select" && len(e.List) == 1:
+			if dict, ok := e.List[0].(*build.DictExpr); ok {
+				for _, kv := range dict.List {
+					if exprContainsFile(kv.Value, pkg, name) {
+						return true
+					}
+				}
+			}
+		case ident.Name == "glob":
+			return globContainsFile(e, name)
+		}
+	}
+	return false
+}
+
+// globContainsFile reports whether the file matches the include patterns of a glob call and
+// none of its exclude patterns.
+func globContainsFile(call *build.CallExpr, name string) bool {
+	var include, exclude build.Expr
+	for i, arg := range call.List {
+		if as, ok := arg.(*build.AssignExpr); ok {
+			if key, ok := as.LHS.(*build.Ident); ok {
+				switch key.Name {
+				case "include":
+					include = as.RHS
+				case "exclude":
+					exclude = as.RHS
+				}
+			}
+			continue
+		}
+		switch i {
+		case 0:
+			include = arg
+		case 1:
+			exclude = arg
+		}
+	}
+	return globListMatches(include, name) && !globListMatches(exclude, name)
+}
+
+// globListMatches reports whether the file matches any pattern of a list of glob patterns.
+func globListMatches(e build.Expr, name string) bool {
+	list, ok := e.(*build.ListExpr)
+	if !ok {
+		return false
+	}
+	for _, elem := range list.List {
+		if str, ok := elem.(*build.StringExpr); ok && globMatch(strings.Split(str.Value, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// globMatch reports whether the path segments match the segments of a glob pattern, where
+// "**" matches any number of segments.
+func globMatch(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if globMatch(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return globMatch(pattern[1:], segments[1:])
+}
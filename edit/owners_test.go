@@ -0,0 +1,111 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindFileOwners(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"MODULE.bazel": "",
+		"pkg/BUILD": `
+go_library(
+    name = "lib",
+    srcs = ["a.go"] + select({
+        "//conditions:default": ["b.go"],
+    }),
+)
+
+go_test(
+    name = "test",
+    srcs = glob(
+        ["**/*.go"],
+        exclude = ["a.go"],
+    ),
+)
+
+cc_library(
+    name = "headers",
+    hdrs = glob(include = ["sub/*.h"]),
+)
+`,
+		"pkg/a.go":             "",
+		"pkg/b.go":             "",
+		"pkg/sub/c.go":         "",
+		"pkg/sub/d.h":          "",
+		"pkg/sub/e.txt":        "",
+		"pkg/other/BUILD":      "",
+		"pkg/other/f.go":       "",
+		"outside/no_package.c": "",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		file    string
+		want    []string
+		wantErr bool
+	}{
+		{file: "pkg/a.go", want: []string{"//pkg:lib"}},
+		{file: "pkg/b.go", want: []string{"//pkg:lib", "//pkg:test"}},
+		{file: "pkg/sub/c.go", want: []string{"//pkg:test"}},
+		{file: "pkg/sub/d.h", want: []string{"//pkg:headers"}},
+		{file: "pkg/sub/e.txt", wantErr: true},
+		{file: "pkg/other/f.go", wantErr: true},
+		{file: "outside/no_package.c", wantErr: true},
+	} {
+		got, err := FindFileOwners(root, filepath.Join(root, filepath.FromSlash(tc.file)))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("FindFileOwners(%q) error = %v, want error: %v", tc.file, err, tc.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("FindFileOwners(%q) = %v, want %v", tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "a.go", true},
+		{"*.go", "sub/a.go", false},
+		{"**/*.go", "a.go", true},
+		{"**/*.go", "sub/dir/a.go", true},
+		{"sub/**", "sub/dir/a.go", true},
+		{"sub/**", "other/a.go", false},
+		{"a?.cc", "ab.cc", true},
+	} {
+		if got := globMatch(strings.Split(tc.pattern, "/"), strings.Split(tc.name, "/")); got != tc.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/labels"
+)
+
+// packageGroupAttr returns the attribute of a package_group a member belongs to: the labels of
+// other package groups (e.g. ":other" or "//foo:group") go to its includes, and the package
+// specifications (e.g. "//foo", "//foo/...", "-//foo/bar" or "public") to its packages. The
+// labels are shortened relative to pkg.
+func packageGroupAttr(member, pkg string) (attr, value string, err error) {
+	switch {
+	case strings.Contains(member, ":"):
+		return "includes", ShortenLabel(labels.ParseRelative(member, pkg).Format(), pkg), nil
+	case member == "public" || member == "private",
+		strings.HasPrefix(strings.TrimPrefix(member, "-"), "//"),
+		strings.HasPrefix(strings.TrimPrefix(member, "-"), "@"):
+		return "packages", member, nil
+	}
+	return "", "", fmt.Errorf("invalid package specification %q, want e.g. //foo, //foo/... or a package group label", member)
+}
+
+// packageGroupCommands are the commands editing the package_group given as their first argument.
+var packageGroupCommands = map[string]bool{
+	"add_package_to_group":      true,
+	"remove_package_from_group": true,
+}
+
+// redirectToPackageGroup rewrites a command of packageGroupCommands run on the package pkg whose
+// group is in another package of the main repository, so that it runs on the package of the
+// group instead: the group and the members relative to pkg are made absolute. It returns the
+// rewritten command and the target to run it on (e.g. "//tools:__pkg__"), or false if cmd
+// isn't such a command.
+func redirectToPackageGroup(cmd command, pkg string) (command, string, bool) {
+	if len(cmd.tokens) < 2 || !packageGroupCommands[cmd.tokens[0]] {
+		return cmd, "", false
+	}
+	group := labels.ParseRelative(cmd.tokens[1], pkg)
+	if group.Repository != "" || group.Package == pkg {
+		return cmd, "", false
+	}
+	tokens := []string{cmd.tokens[0], group.Format()}
+	for _, member := range cmd.tokens[2:] {
+		if strings.Contains(member, ":") {
+			member = labels.ParseRelative(member, pkg).Format()
+		}
+		tokens = append(tokens, member)
+	}
+	target := labels.Label{Package: group.Package, Target: "__pkg__"}
+	return command{tokens}, target.Format(), true
+}
+
+// findPackageGroup returns the package_group with the given name, or nil if the file doesn't
+// have it. It returns an error if the name is used by a rule of another kind.
+func findPackageGroup(f *build.File, name string) (*build.Rule, error) {
+	r := FindRuleByName(f, name)
+	if r != nil && r.Kind() != "package_group" {
+		return nil, fmt.Errorf("'%s' is a %s, not a package_group", name, r.Kind())
+	}
+	return r, nil
+}
+
+// AddToPackageGroup adds members to the package_group with the given name in the BUILD file of
+// the package pkg, creating it with the given placement if the file doesn't have it. The
+// package specifications are added to its packages, and the labels of other package groups to
+// its includes, see packageGroupAttr. The members it already has are skipped.
+func AddToPackageGroup(f *build.File, pkg, name string, members []string, placement InsertionStrategy) error {
+	r, err := findPackageGroup(f, name)
+	if err != nil {
+		return err
+	}
+	type value struct{ attr, value string }
+	var values []value
+	for _, member := range members {
+		attr, v, err := packageGroupAttr(member, pkg)
+		if err != nil {
+			return err
+		}
+		values = append(values, value{attr, v})
+	}
+	if r == nil {
+		call := &build.CallExpr{X: &build.Ident{Name: "package_group"}}
+		r = &build.Rule{Call: call}
+		r.SetAttr("name", &build.StringExpr{Value: name})
+		InsertRule(f, call, placement)
+	}
+	for _, v := range values {
+		AddValueToListAttribute(r, v.attr, pkg, &build.StringExpr{Value: v.value}, nil)
+	}
+	return nil
+}
+
+// RemoveFromPackageGroup removes members from the package_group with the given name in the BUILD
+// file of the package pkg, see AddToPackageGroup. The packages and includes attributes are
+// deleted if they become empty, but the group itself is kept. It returns false if the group
+// didn't have any of the members.
+func RemoveFromPackageGroup(f *build.File, pkg, name string, members []string) (bool, error) {
+	r, err := findPackageGroup(f, name)
+	if err != nil {
+		return false, err
+	}
+	if r == nil {
+		return false, fmt.Errorf("package_group '%s' not found", name)
+	}
+	removed := false
+	for _, member := range members {
+		attr, v, err := packageGroupAttr(member, pkg)
+		if err != nil {
+			return false, err
+		}
+		if ListAttributeDelete(r, attr, v, pkg) != nil {
+			removed = true
+		}
+	}
+	return removed, nil
+}
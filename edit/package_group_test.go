@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestPackageGroup(t *testing.T) {
+	f, err := build.ParseBuild("tools/BUILD", []byte(`cc_library(name = "lib")`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AddToPackageGroup(f, "tools", "friends", []string{"//b/...", "//a", "//tools:admins"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// Adding existing members is a no-op.
+	if err := AddToPackageGroup(f, "tools", "friends", []string{"//a", ":admins"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `cc_library(name = "lib")
+
+package_group(
+    name = "friends",
+    includes = [":admins"],
+    packages = [
+        "//a",
+        "//b/...",
+    ],
+)
+`
+	if got := string(build.Format(f)); got != want {
+		t.Errorf("AddToPackageGroup() =\n%s\nwant:\n%s", got, want)
+	}
+
+	removed, err := RemoveFromPackageGroup(f, "tools", "friends", []string{"//tools:admins", "//c"})
+	if err != nil || !removed {
+		t.Errorf("RemoveFromPackageGroup() = %t, %v, want true, nil", removed, err)
+	}
+	if removed, err := RemoveFromPackageGroup(f, "tools", "friends", []string{"//c"}); err != nil || removed {
+		t.Errorf("RemoveFromPackageGroup() of a missing member = %t, %v, want false, nil", removed, err)
+	}
+	if _, err := RemoveFromPackageGroup(f, "tools", "lib", []string{"//a"}); err == nil {
+		t.Errorf("RemoveFromPackageGroup() of a cc_library returned no error")
+	}
+	if err := AddToPackageGroup(f, "tools", "friends", []string{"a/b"}, nil); err == nil {
+		t.Errorf("AddToPackageGroup() of an invalid package returned no error")
+	}
+}
+
+func TestRedirectToPackageGroup(t *testing.T) {
+	for _, tc := range []struct {
+		tokens     []string
+		wantTokens []string
+		wantTarget string
+	}{
+		{
+			[]string{"add_package_to_group", "//tools:friends", "//new/pkg/...", ":group", "-//a"},
+			[]string{"add_package_to_group", "//tools:friends", "//new/pkg/...", "//pkg:group", "-//a"},
+			"//tools:__pkg__",
+		},
+		{
+			[]string{"remove_package_from_group", "//:friends", "//a"},
+			[]string{"remove_package_from_group", "//:friends", "//a"},
+			"//:__pkg__",
+		},
+		{[]string{"add_package_to_group", "friends", ":group"}, nil, ""},
+		{[]string{"add_package_to_group", "//pkg:friends", ":group"}, nil, ""},
+		{[]string{"add_package_to_group", "@repo//tools:friends", ":group"}, nil, ""},
+		{[]string{"add", "//tools:friends", ":group"}, nil, ""},
+	} {
+		got, target, ok := redirectToPackageGroup(command{tc.tokens}, "pkg")
+		if ok != (tc.wantTokens != nil) || target != tc.wantTarget ||
+			ok && strings.Join(got.tokens, " ") != strings.Join(tc.wantTokens, " ") {
+			t.Errorf("redirectToPackageGroup(%q) = %q, %q, %t, want %q, %q", tc.tokens, got.tokens, target, ok, tc.wantTokens, tc.wantTarget)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A Shard selects a part of the files processed by Buildozer, so that the same commands can
+// be run on a large workspace by several workers in parallel, each of them processing a
+// different shard. Files are assigned to shards by a hash of their path relative to the
+// workspace root, so the assignment doesn't depend on where the workspace is checked out nor
+// on the other files. The zero value selects all files.
+type Shard struct {
+	Index int // the 1-based index of the shard, between 1 and Count
+	Count int // the total number of shards
+}
+
+// ParseShard parses a shard in the K/N form, e.g. "2/4" for the second of four shards.
+func ParseShard(s string) (Shard, error) {
+	k, n, ok := strings.Cut(s, "/")
+	index, err1 := strconv.Atoi(k)
+	count, err2 := strconv.Atoi(n)
+	if !ok || err1 != nil || err2 != nil {
+		return Shard{}, fmt.Errorf("invalid shard %q, want K/N, e.g. 1/4", s)
+	}
+	if count < 1 || index < 1 || index > count {
+		return Shard{}, fmt.Errorf("invalid shard %q, K must be between 1 and N", s)
+	}
+	return Shard{Index: index, Count: count}, nil
+}
+
+// String returns the shard in the K/N form.
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Contains returns true if the file belongs to the shard. path is the slash-separated path
+// of the file relative to the workspace root.
+func (s Shard) Contains(path string) bool {
+	if s.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return int(h.Sum32()%uint32(s.Count)) == s.Index-1
+}
+
+// shardFiles removes the files which don't belong to opts.Shard from commandsByFile.
+func shardFiles(opts *Options, commandsByFile map[string][]commandsForTarget) {
+	if opts.Shard.Count <= 1 {
+		return
+	}
+	root, _ := opts.cache.findWorkspaceRoot(opts.RootDir)
+	if root != "" {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+	}
+	for file := range commandsByFile {
+		path := file
+		if abs, err := filepath.Abs(file); root != "" && file != stdinPackageName && err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		if !opts.Shard.Contains(filepath.ToSlash(path)) {
+			delete(commandsByFile, file)
+		}
+	}
+}
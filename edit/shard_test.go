@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	for _, tc := range []struct {
+		input   string
+		want    Shard
+		wantErr bool
+	}{
+		{input: "1/1", want: Shard{1, 1}},
+		{input: "2/4", want: Shard{2, 4}},
+		{input: "4/4", want: Shard{4, 4}},
+		{input: "0/4", wantErr: true},
+		{input: "5/4", wantErr: true},
+		{input: "1/0", wantErr: true},
+		{input: "1", wantErr: true},
+		{input: "a/b", wantErr: true},
+	} {
+		got, err := ParseShard(tc.input)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ParseShard(%q) error = %v, want error: %t", tc.input, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseShard(%q) = %v, want %v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestShard(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmp, "WORKSPACE"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var all []string
+	for i := 0; i < 20; i++ {
+		pkg := fmt.Sprintf("pkg%d", i)
+		if err := os.MkdirAll(filepath.Join(tmp, pkg), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, pkg, "BUILD"), []byte(`cc_library(name = "lib")`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, fmt.Sprintf("//%s:lib", pkg))
+	}
+
+	var got []string
+	for i := 1; i <= 3; i++ {
+		var stdout bytes.Buffer
+		opts := NewOpts()
+		opts.RootDir = tmp
+		opts.Shard = Shard{Index: i, Count: 3}
+		opts.OutWriter = &stdout
+		opts.ErrWriter = io.Discard
+		if ret := Buildozer(opts, []string{"print label", "//...:*"}); ret != 0 {
+			t.Errorf("Buildozer() with shard %v = %d, want 0", opts.Shard, ret)
+		}
+		labels := strings.Fields(stdout.String())
+		// The assignment only depends on the path relative to the workspace root.
+		var want []string
+		for _, label := range all {
+			if opts.Shard.Contains(strings.TrimSuffix(strings.TrimPrefix(label, "//"), ":lib") + "/BUILD") {
+				want = append(want, label)
+			}
+		}
+		sort.Strings(labels)
+		sort.Strings(want)
+		if strings.Join(labels, " ") != strings.Join(want, " ") {
+			t.Errorf("shard %v contains %q, want %q", opts.Shard, labels, want)
+		}
+		got = append(got, labels...)
+	}
+	// Each file belongs to exactly one shard.
+	sort.Strings(got)
+	sort.Strings(all)
+	if strings.Join(got, " ") != strings.Join(all, " ") {
+		t.Errorf("shards contain %q, want %q", got, all)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Rule templates for the new_from_template command
+
+package edit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// RuleTemplate describes the targets created by the new_from_template command. The strings of
+// the name pattern and of the attribute values may contain placeholders, e.g. "{name}_test",
+// see expandTemplate.
+type RuleTemplate struct {
+	Kind string `json:"kind"`
+	// Name is the pattern of the name of the target, "{name}" if empty.
+	Name string `json:"name,omitempty"`
+	// Attrs are the attributes of the target. The JSON strings, numbers, booleans, nulls, lists
+	// and objects are turned into the corresponding Starlark values.
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+	// Loads maps the files to load to the symbols needed by the target, e.g. the kind. A symbol
+	// may be given in the to=from form, like in the arguments of new_load.
+	Loads map[string][]string `json:"loads,omitempty"`
+}
+
+// ParseRuleTemplates reads rule templates in JSON format, an object mapping the names of the
+// templates to their definitions.
+func ParseRuleTemplates(r io.Reader) (map[string]*RuleTemplate, error) {
+	var templates map[string]*RuleTemplate
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(&templates); err != nil {
+		return nil, fmt.Errorf("invalid templates: %v", err)
+	}
+	for name, t := range templates {
+		if t == nil || t.Kind == "" {
+			return nil, fmt.Errorf("template %q: no kind", name)
+		}
+		for attr, value := range t.Attrs {
+			if attr == "name" {
+				return nil, fmt.Errorf("template %q: the name is set by the \"name\" pattern, not by an attribute", name)
+			}
+			if _, err := templateValue(value, nil); err != nil {
+				return nil, fmt.Errorf("template %q: attribute %q: %v", name, attr, err)
+			}
+		}
+	}
+	return templates, nil
+}
+
+// ReadRuleTemplates reads the rule templates of a JSON file, see ParseRuleTemplates.
+func ReadRuleTemplates(file string) (map[string]*RuleTemplate, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRuleTemplates(f)
+}
+
+// templatePlaceholder matches the placeholders of the rule templates. The ones preceded by a
+// dollar sign, e.g. ${HOME} in a genrule command, are left unchanged.
+var templatePlaceholder = regexp.MustCompile(`\$?\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandTemplate replaces the placeholders of a string of a rule template with their values.
+// The values are nil when the templates are only being validated.
+func expandTemplate(s string, values map[string]string) (string, error) {
+	var err error
+	expanded := templatePlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		if strings.HasPrefix(m, "$") || values == nil {
+			return m
+		}
+		key := m[1 : len(m)-1]
+		value, ok := values[key]
+		if !ok && err == nil {
+			err = fmt.Errorf("no value for the placeholder %s", m)
+		}
+		return value
+	})
+	return expanded, err
+}
+
+// templateValue converts a JSON value of a rule template to a Starlark expression, expanding
+// the placeholders of its strings.
+func templateValue(value interface{}, values map[string]string) (build.Expr, error) {
+	switch v := value.(type) {
+	case nil:
+		return &build.Ident{Name: "None"}, nil
+	case bool:
+		if v {
+			return &build.Ident{Name: "True"}, nil
+		}
+		return &build.Ident{Name: "False"}, nil
+	case json.Number:
+		return &build.LiteralExpr{Token: v.String()}, nil
+	case string:
+		s, err := expandTemplate(v, values)
+		if err != nil {
+			return nil, err
+		}
+		return &build.StringExpr{Value: s}, nil
+	case []interface{}:
+		list := &build.ListExpr{}
+		for _, x := range v {
+			expr, err := templateValue(x, values)
+			if err != nil {
+				return nil, err
+			}
+			list.List = append(list.List, expr)
+		}
+		return list, nil
+	case map[string]interface{}:
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		dict := &build.DictExpr{}
+		for _, key := range keys {
+			k, err := expandTemplate(key, values)
+			if err != nil {
+				return nil, err
+			}
+			expr, err := templateValue(v[key], values)
+			if err != nil {
+				return nil, err
+			}
+			dict.List = append(dict.List, &build.KeyValueExpr{Key: &build.StringExpr{Value: k}, Value: expr})
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+}
+
+// templateValues returns the values of the placeholders of a rule template: {name}, the name
+// given to new_from_template, {package} and {package_name}, the path and the last component of
+// the package, and the ones given as key=value arguments.
+func templateValues(name, pkg string, args []string) (map[string]string, error) {
+	values := map[string]string{
+		"name":         name,
+		"package":      pkg,
+		"package_name": path.Base(pkg),
+	}
+	if pkg == "" {
+		values["package_name"] = ""
+	}
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || !planVariableName.MatchString(kv[0]) {
+			return nil, fmt.Errorf("invalid placeholder value '%s', expected key=value", arg)
+		}
+		values[kv[0]] = kv[1]
+	}
+	return values, nil
+}
+
+// NewRuleFromTemplate adds the target described by a rule template to the file, with the loads
+// it needs, and returns it.
+func NewRuleFromTemplate(f *build.File, t *RuleTemplate, values map[string]string, strategy InsertionStrategy) (*build.Rule, error) {
+	pattern := t.Name
+	if pattern == "" {
+		pattern = "{name}"
+	}
+	name, err := expandTemplate(pattern, values)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the name of the new rule is empty")
+	}
+	if FindRuleByName(f, name) != nil {
+		return nil, fmt.Errorf("rule '%s' already exists", name)
+	}
+
+	call := &build.CallExpr{X: &build.Ident{Name: t.Kind}}
+	rule := &build.Rule{Call: call, ImplicitName: ""}
+	rule.SetAttr("name", &build.StringExpr{Value: name})
+	var attrs []string
+	for attr := range t.Attrs {
+		attrs = append(attrs, attr)
+	}
+	sort.Strings(attrs)
+	for _, attr := range attrs {
+		value, err := templateValue(t.Attrs[attr], values)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %v", attr, err)
+		}
+		rule.SetAttr(attr, value)
+	}
+
+	var modules []string
+	for module := range t.Loads {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	for _, module := range modules {
+		from, to := splitLoadArgs(append([]string{}, t.Loads[module]...))
+		f.Stmt = InsertLoad(f.Stmt, module, from, to)
+	}
+	InsertRule(f, call, strategy)
+	return rule, nil
+}
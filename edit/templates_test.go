@@ -0,0 +1,162 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+const testTemplates = `{
+  "go_binary_tmpl": {
+    "kind": "go_binary",
+    "attrs": {
+      "srcs": ["{name}.go"],
+      "embed": [":{package_name}_lib"],
+      "pure": "on",
+      "visibility": ["//visibility:public"]
+    },
+    "loads": {"@io_bazel_rules_go//go:def.bzl": ["go_binary"]}
+  },
+  "sh_test_tmpl": {
+    "kind": "sh_test",
+    "name": "{name}_test",
+    "attrs": {
+      "srcs": ["{name}_test.sh"],
+      "args": ["${HOME}", "{mode}"],
+      "env": {"MODE": "{mode}"},
+      "shard_count": 2,
+      "flaky": false,
+      "size": null
+    }
+  }
+}`
+
+func TestNewRuleFromTemplate(t *testing.T) {
+	templates, err := ParseRuleTemplates(strings.NewReader(testTemplates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name     string
+		input    string
+		template string
+		args     []string
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "with loads",
+			input: `load("@io_bazel_rules_go//go:def.bzl", "go_library")
+
+go_library(name = "tools_lib")
+`,
+			template: "go_binary_tmpl",
+			args:     []string{"my_tool"},
+			want: `load("@io_bazel_rules_go//go:def.bzl", "go_binary", "go_library")
+
+go_library(name = "tools_lib")
+
+go_binary(
+    name = "my_tool",
+    srcs = ["my_tool.go"],
+    embed = [":tools_lib"],
+    pure = "on",
+    visibility = ["//visibility:public"],
+)
+`,
+		},
+		{
+			name:     "placeholders",
+			template: "sh_test_tmpl",
+			args:     []string{"check", "mode=fast"},
+			want: `sh_test(
+    name = "check_test",
+    size = None,
+    srcs = ["check_test.sh"],
+    args = [
+        "${HOME}",
+        "fast",
+    ],
+    env = {"MODE": "fast"},
+    flaky = False,
+    shard_count = 2,
+)
+`,
+		},
+		{
+			name:     "missing placeholder",
+			template: "sh_test_tmpl",
+			args:     []string{"check"},
+			wantErr:  `attribute "args": no value for the placeholder {mode}`,
+		},
+		{
+			name:     "existing rule",
+			input:    `go_binary(name = "my_tool")`,
+			template: "go_binary_tmpl",
+			args:     []string{"my_tool"},
+			wantErr:  "rule 'my_tool' already exists",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := build.ParseBuild("tools/BUILD", []byte(tc.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			values, err := templateValues(tc.args[0], "tools", tc.args[1:])
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = NewRuleFromTemplate(f, templates[tc.template], values, nil)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Errorf("NewRuleFromTemplate() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewRuleFromTemplate() error = %v", err)
+			}
+			if got := string(build.Format(f)); got != tc.want {
+				t.Errorf("NewRuleFromTemplate() =\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRuleTemplatesErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"t": {"attrs": {"srcs": []}}}`,
+		`{"t": {"kind": "cc_library", "attrs": {"name": "foo"}}}`,
+		`{"t": {"kind": "cc_library", "deps": []}}`,
+		`[]`,
+	} {
+		if _, err := ParseRuleTemplates(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseRuleTemplates(%s) succeeded, want error", input)
+		}
+	}
+}
+
+func TestTemplateValuesErrors(t *testing.T) {
+	for _, arg := range []string{"mode", "=fast", "a-b=c"} {
+		if _, err := templateValues("foo", "pkg", []string{arg}); err == nil {
+			t.Errorf("templateValues(%q) succeeded, want error", arg)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package edit
+
+import (
+	"sync"
+
+	"github.com/bazelbuild/buildtools/wspace"
+)
+
+// workspaceCache memoizes the filesystem lookups done to resolve targets: the workspace root,
+// the BUILD files of WORKSPACE repositories, the .bazelignore prefixes and the existence of
+// BUILD files. Without it they are repeated for every command and file, which dominates the run
+// time of large command files. Buildozer never creates files, so the results stay valid for a
+// single invocation; a nil cache performs the lookups without memoizing them.
+type workspaceCache struct {
+	mu              sync.Mutex
+	workspaceRoots  map[string][2]string
+	repoBuildFiles  map[string]repoBuildFilesResult
+	moduleRepoDirs  map[string]repoBuildFilesResult
+	ignoredPrefixes map[string][]string
+	regularFiles    map[string]bool
+}
+
+type repoBuildFilesResult struct {
+	files map[string]string
+	err   error
+}
+
+func newWorkspaceCache() *workspaceCache {
+	return &workspaceCache{
+		workspaceRoots:  make(map[string][2]string),
+		repoBuildFiles:  make(map[string]repoBuildFilesResult),
+		moduleRepoDirs:  make(map[string]repoBuildFilesResult),
+		ignoredPrefixes: make(map[string][]string),
+		regularFiles:    make(map[string]bool),
+	}
+}
+
+// findWorkspaceRoot is a memoized version of wspace.FindWorkspaceRoot.
+func (c *workspaceCache) findWorkspaceRoot(rootDir string) (root string, rest string) {
+	if c == nil {
+		return wspace.FindWorkspaceRoot(rootDir)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if result, ok := c.workspaceRoots[rootDir]; ok {
+		return result[0], result[1]
+	}
+	root, rest = wspace.FindWorkspaceRoot(rootDir)
+	c.workspaceRoots[rootDir] = [2]string{root, rest}
+	return root, rest
+}
+
+// findRepoBuildFiles is a memoized version of wspace.FindRepoBuildFiles.
+func (c *workspaceCache) findRepoBuildFiles(root string) (map[string]string, error) {
+	if c == nil {
+		return wspace.FindRepoBuildFiles(root)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if result, ok := c.repoBuildFiles[root]; ok {
+		return result.files, result.err
+	}
+	files, err := wspace.FindRepoBuildFiles(root)
+	c.repoBuildFiles[root] = repoBuildFilesResult{files, err}
+	return files, err
+}
+
+// findModuleRepoDirs is a memoized version of wspace.FindModuleRepoDirs.
+func (c *workspaceCache) findModuleRepoDirs(root string) (map[string]string, error) {
+	if c == nil {
+		return wspace.FindModuleRepoDirs(root)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if result, ok := c.moduleRepoDirs[root]; ok {
+		return result.files, result.err
+	}
+	dirs, err := wspace.FindModuleRepoDirs(root)
+	c.moduleRepoDirs[root] = repoBuildFilesResult{dirs, err}
+	return dirs, err
+}
+
+// getIgnoredPrefixes is a memoized version of getIgnoredPrefixes.
+func (c *workspaceCache) getIgnoredPrefixes(rootDir string) []string {
+	if c == nil {
+		return getIgnoredPrefixes(rootDir)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prefixes, ok := c.ignoredPrefixes[rootDir]; ok {
+		return prefixes
+	}
+	prefixes := getIgnoredPrefixes(rootDir)
+	c.ignoredPrefixes[rootDir] = prefixes
+	return prefixes
+}
+
+// isRegularFile is a memoized version of wspace.IsRegularFile.
+func (c *workspaceCache) isRegularFile(path string) bool {
+	if c == nil {
+		return wspace.IsRegularFile(path)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if isRegular, ok := c.regularFiles[path]; ok {
+		return isRegular
+	}
+	isRegular := wspace.IsRegularFile(path)
+	c.regularFiles[path] = isRegular
+	return isRegular
+}
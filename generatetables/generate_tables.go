@@ -15,7 +15,8 @@ limitations under the License.
 */
 
 // generateTables is a tool that generates a go file from the Build language proto file.
-// It generates a Go map to find the type of an attribute.
+// It generates a Go map to find the type of an attribute, and another one to find the
+// attributes of a rule.
 
 package main
 
@@ -25,6 +26,7 @@ import (
 	"log"
 	"os"
 	"sort"
+	"strings"
 
 	buildpb "github.com/bazelbuild/buildtools/build_proto"
 	"github.com/golang/protobuf/proto"
@@ -107,6 +109,23 @@ func generateTable(rules []*buildpb.RuleDefinition) map[string]buildpb.Attribute
 	return types
 }
 
+// generateRuleAttributes returns a map that associates the sorted list of its public attributes
+// (i.e. the ones that can be set in BUILD files) with each rule found in Bazel.
+func generateRuleAttributes(rules []*buildpb.RuleDefinition) map[string][]string {
+	attributes := make(map[string][]string)
+	for _, r := range rules {
+		names := []string{"name"} // the name argument is missing from the proto file
+		for _, attr := range r.Attribute {
+			if name := attr.GetName(); name != "name" && !strings.HasPrefix(name, "$") && !strings.HasPrefix(name, ":") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		attributes[r.GetName()] = names
+	}
+	return attributes
+}
+
 func main() {
 	flag.Parse()
 	if *inputPath == "" {
@@ -143,4 +162,19 @@ var TypeOf = map[string]buildpb.Attribute_Discriminator{
 		fmt.Fprintf(f, "	\"%s\":	buildpb.Attribute_%s,\n", attr, types[attr])
 	}
 	fmt.Fprintf(f, "}\n")
+
+	attributes := generateRuleAttributes(lang.Rule)
+	rules := make([]string, 0, len(attributes))
+	for rule := range attributes {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	fmt.Fprintf(f, `
+var AttributesOf = map[string][]string{
+`)
+	for _, rule := range rules {
+		fmt.Fprintf(f, "	\"%s\":	{\"%s\"},\n", rule, strings.Join(attributes[rule], "\", \""))
+	}
+	fmt.Fprintf(f, "}\n")
 }
@@ -28,6 +28,7 @@ type Label struct {
 	Repository string // Repository of the target, can be empty if the target belongs to the current repository
 	Package    string // Package of a target, can be empty for top packages
 	Target     string // Name of the target, should be always non-empty
+	Canonical  bool   // Whether Repository is a canonical name, formatted with the "@@" prefix
 }
 
 // Format returns a string representation of a label. It's always absolute but
@@ -36,6 +37,9 @@ type Label struct {
 func (l Label) Format() string {
 	b := new(bytes.Buffer)
 	if l.Repository != "" {
+		if l.Canonical {
+			b.WriteString("@")
+		}
 		b.WriteString("@")
 		b.WriteString(l.Repository)
 	}
@@ -70,7 +74,7 @@ func Parse(target string) Label {
 		parts := strings.SplitN(target, "/", 2)
 		if len(parts) == 1 {
 			// "@foo" -> @foo//:foo
-			return Label{target, "", target}
+			return Label{Repository: target, Target: target}
 		}
 		label.Repository = parts[0]
 		target = "/" + parts[1]
@@ -100,9 +104,32 @@ func ParseRelative(input, pkg string) Label {
 	return Parse(input)
 }
 
+// Resolve parses a label `raw` the way Bazel does in the package `currentPkg`:
+// relative labels (":foo", "foo" or "foo/bar.cc") belong to currentPkg, and
+// apparent repository names ("@foo//...") are translated to canonical names
+// with repoMapping, which maps the apparent names visible from the current
+// repository to canonical names (e.g. the output of `bazel mod
+// dump_repo_mapping`). Canonical names ("@@foo+//...") and apparent names
+// missing from the mapping are kept as they are, only the former are marked
+// as Canonical. The main repository ("//foo", "@//foo" or "@@//foo") has an
+// empty Repository, unless the mapping maps the empty name to another one.
+func Resolve(raw, currentPkg string, repoMapping map[string]string) Label {
+	label := ParseRelative(raw, currentPkg)
+	if strings.HasPrefix(raw, "@@") {
+		label.Canonical = label.Repository != ""
+		return label
+	}
+	if canonical, ok := repoMapping[label.Repository]; ok {
+		label.Repository = canonical
+		label.Canonical = canonical != ""
+	}
+	return label
+}
+
 // Shorten rewrites labels to use the canonical form (the form
 // recommended by build-style).
 // "//foo/bar:bar" => "//foo/bar", or ":bar" if the label belongs to pkg
+// Labels with canonical repository names ("@@foo//...") keep their "@@" prefix.
 func Shorten(input, pkg string) string {
 	if !strings.HasPrefix(input, "//") && !strings.HasPrefix(input, "@") {
 		// It doesn't look like a long label, so we preserve it.
@@ -110,6 +137,9 @@ func Shorten(input, pkg string) string {
 		return input
 	}
 	label := Parse(input)
+	if strings.HasPrefix(input, "@@") && label.Repository != "" {
+		return "@" + label.Format()
+	}
 	return label.FormatRelative(pkg)
 }
 
@@ -76,6 +76,13 @@ var shortenLabelTests = []struct {
 	{"something else", "", "something else"},
 	{"/path/to/file", "path/to", "/path/to/file"},
 	{"\"//baz\"", "", "\"//baz\""},
+	{"@@//devtools/buildozer:rule", "devtools/buildozer", ":rule"},
+	{"@@//devtools/buildozer:buildozer", "", "//devtools/buildozer"},
+	{"@@r+//devtools/buildozer:buildozer", "devtools/buildozer", "@@r+//devtools/buildozer"},
+	{"@@r+//devtools:rule", "", "@@r+//devtools:rule"},
+	{"@@foo+//:foo+", "", "@@foo+"},
+	{"@@foo+//:foo", "", "@@foo+//:foo"},
+	{"foo/bar.cc", "devtools", "foo/bar.cc"},
 }
 
 func TestShortenLabel(t *testing.T) {
@@ -88,6 +95,81 @@ func TestShortenLabel(t *testing.T) {
 	}
 }
 
+var resolveTests = []struct {
+	in   string
+	pkg  string
+	want Label
+}{
+	// Relative labels
+	{":rule", "devtools/buildozer", Label{"", "devtools/buildozer", "rule", false}},
+	{"rule", "devtools/buildozer", Label{"", "devtools/buildozer", "rule", false}},
+	{"sub/file.cc", "devtools", Label{"", "devtools", "sub/file.cc", false}},
+	{":rule", "", Label{"", "", "rule", false}},
+	// Labels of the main repository
+	{"//devtools/buildozer:rule", "base", Label{"", "devtools/buildozer", "rule", false}},
+	{"//devtools/buildozer", "base", Label{"", "devtools/buildozer", "buildozer", false}},
+	{"//:rule", "base", Label{"", "", "rule", false}},
+	{"@//devtools:rule", "base", Label{"", "devtools", "rule", false}},
+	{"@@//devtools:rule", "base", Label{"", "devtools", "rule", false}},
+	// Apparent repository names
+	{"@foo//devtools:rule", "base", Label{"foo+", "devtools", "rule", true}},
+	{"@foo//devtools", "base", Label{"foo+", "devtools", "devtools", true}},
+	{"@foo//:rule", "base", Label{"foo+", "", "rule", true}},
+	{"@foo", "base", Label{"foo+", "", "foo", true}},
+	{"@bar//devtools:rule", "base", Label{"rules_bar+", "devtools", "rule", true}},
+	{"@unknown//devtools:rule", "base", Label{"unknown", "devtools", "rule", false}},
+	// Canonical repository names
+	{"@@foo+//devtools:rule", "base", Label{"foo+", "devtools", "rule", true}},
+	{"@@foo//devtools:rule", "base", Label{"foo", "devtools", "rule", true}},
+	{"@@bar//devtools:rule", "base", Label{"bar", "devtools", "rule", true}},
+}
+
+func TestResolve(t *testing.T) {
+	repoMapping := map[string]string{
+		"foo": "foo+",
+		"bar": "rules_bar+",
+	}
+	for i, tt := range resolveTests {
+		if got := Resolve(tt.in, tt.pkg, repoMapping); got != tt.want {
+			t.Errorf("%d. Resolve(%q, %q) => %+v, want %+v", i, tt.in, tt.pkg, got, tt.want)
+		}
+	}
+
+	// Without a mapping, the apparent names are kept.
+	if got, want := Resolve("@foo//devtools:rule", "", nil), (Label{"foo", "devtools", "rule", false}); got != want {
+		t.Errorf("Resolve(@foo//devtools:rule) without mapping => %+v, want %+v", got, want)
+	}
+	// The mapping of the empty name applies to the labels of the main repository.
+	if got, want := Resolve("//devtools:rule", "", map[string]string{"": "_main"}), (Label{"_main", "devtools", "rule", true}); got != want {
+		t.Errorf("Resolve(//devtools:rule) with main mapping => %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	repoMapping := map[string]string{"foo": "foo+"}
+	for _, tt := range []struct {
+		in   string
+		want string
+	}{
+		{"@foo//devtools:rule", "@@foo+//devtools:rule"},
+		{"@foo", "@@foo+//:foo"},
+		{"@@foo+//devtools", "@@foo+//devtools"},
+		{"@@bar//devtools:rule", "@@bar//devtools:rule"},
+		{"@unknown//devtools:rule", "@unknown//devtools:rule"},
+		{"@@//devtools:rule", "//devtools:rule"},
+		{":rule", "//devtools:rule"},
+	} {
+		label := Resolve(tt.in, "devtools", repoMapping)
+		if got := label.Format(); got != tt.want {
+			t.Errorf("Resolve(%q).Format() => %q, want %q", tt.in, got, tt.want)
+		}
+		// The formatted label resolves to the same label.
+		if got := Resolve(label.Format(), "", repoMapping); got != label {
+			t.Errorf("Resolve(%q) => %+v, want %+v", label.Format(), got, label)
+		}
+	}
+}
+
 var labelsEqualTests = []struct {
 	label1   string
 	label2   string
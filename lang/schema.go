@@ -0,0 +1,60 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lang
+
+import (
+	"bytes"
+	"os"
+	"sort"
+	"strings"
+
+	buildpb "github.com/bazelbuild/buildtools/build_proto"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+)
+
+// ReadAttributes reads the output of `bazel info build-language` (a binary BuildLanguage
+// proto, or its JSON form if the file name ends with ".json") and returns the public
+// attributes of each rule, in the same form as AttributesOf.
+func ReadAttributes(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	lang := &buildpb.BuildLanguage{}
+	if strings.HasSuffix(path, ".json") {
+		err = jsonpb.Unmarshal(bytes.NewReader(data), lang)
+	} else {
+		err = proto.Unmarshal(data, lang)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := make(map[string][]string)
+	for _, r := range lang.Rule {
+		names := []string{"name"}
+		for _, attr := range r.Attribute {
+			if name := attr.GetName(); name != "name" && !strings.HasPrefix(name, "$") && !strings.HasPrefix(name, ":") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		attributes[r.GetName()] = names
+	}
+	return attributes, nil
+}
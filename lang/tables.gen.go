@@ -441,3 +441,84 @@ var TypeOf = map[string]buildpb.Attribute_Discriminator{
 	"xlint":	buildpb.Attribute_STRING_LIST,
 	"zipalign":	buildpb.Attribute_LABEL,
 }
+
+var AttributesOf = map[string][]string{
+	"aar_import":	{"aar", "applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "exports", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "srcjar", "tags", "testonly", "transitive_configs", "visibility"},
+	"action_listener":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "extra_actions", "features", "generator_function", "generator_location", "generator_name", "licenses", "mnemonics", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"alias":	{"actual", "applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"android_binary":	{"applicable_licenses", "application_resources", "assets", "assets_dir", "compatible_with", "crunch_png", "custom_package", "debug_key", "debug_signing_keys", "debug_signing_lineage_file", "densities", "deprecation", "deps", "dex_shards", "dexopts", "distribs", "enable_data_binding", "exec_compatible_with", "exec_properties", "feature_flags", "features", "generator_function", "generator_location", "generator_name", "incremental_dexing", "inline_constants", "instruments", "javacopts", "licenses", "main_dex_list", "main_dex_list_opts", "main_dex_proguard_specs", "manifest", "manifest_values", "multidex", "name", "nocompress_extensions", "plugins", "proguard_apply_dictionary", "proguard_apply_mapping", "proguard_generate_mapping", "proguard_specs", "resource_configuration_filters", "resource_files", "restricted_to", "shrink_resources", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"android_device":	{"applicable_licenses", "cache", "compatible_with", "default_properties", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "horizontal_resolution", "licenses", "name", "platform_apks", "pregenerate_oat_files_for_tests", "ram", "restricted_to", "screen_density", "system_image", "tags", "testonly", "transitive_configs", "vertical_resolution", "visibility", "vm_heap"},
+	"android_device_script_fixture":	{"applicable_licenses", "cmd", "compatible_with", "daemon", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "script", "strict_exit", "support_apks", "tags", "testonly", "transitive_configs", "visibility"},
+	"android_host_service_fixture":	{"applicable_licenses", "compatible_with", "daemon", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "executable", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "provides_test_args", "restricted_to", "service_names", "support_apks", "tags", "testonly", "transitive_configs", "visibility"},
+	"android_instrumentation_test":	{"applicable_licenses", "args", "compatible_with", "data", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "fixtures", "flaky", "generator_function", "generator_location", "generator_name", "licenses", "local", "name", "restricted_to", "shard_count", "size", "support_apks", "tags", "target_device", "test_app", "testonly", "timeout", "toolchains", "transitive_configs", "visibility"},
+	"android_library":	{"alwayslink", "applicable_licenses", "assets", "assets_dir", "compatible_with", "custom_package", "data", "deprecation", "deps", "distribs", "enable_data_binding", "exec_compatible_with", "exec_properties", "exported_plugins", "exports", "exports_manifest", "features", "generator_function", "generator_location", "generator_name", "idl_import_root", "idl_parcelables", "idl_preprocessed", "idl_srcs", "inline_constants", "javacopts", "licenses", "manifest", "name", "neverlink", "plugins", "proguard_specs", "resource_files", "restricted_to", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"android_local_test":	{"applicable_licenses", "args", "compatible_with", "custom_package", "data", "densities", "deprecation", "deps", "exec_compatible_with", "exec_properties", "feature_flags", "features", "flaky", "generator_function", "generator_location", "generator_name", "javacopts", "jvm_flags", "licenses", "local", "manifest", "manifest_values", "name", "nocompress_extensions", "plugins", "resource_configuration_filters", "resource_jars", "resource_strip_prefix", "restricted_to", "runtime_deps", "shard_count", "size", "srcs", "stamp", "tags", "test_class", "testonly", "timeout", "toolchains", "transitive_configs", "visibility"},
+	"android_ndk_repository":	{"api_level", "generator_function", "generator_location", "generator_name", "name", "path", "repo_mapping"},
+	"android_sdk":	{"aapt", "aapt2", "adb", "aidl", "aidl_lib", "android_jar", "annotations_jar", "apkbuilder", "apksigner", "applicable_licenses", "build_tools_version", "compatible_with", "deprecation", "distribs", "dx", "features", "framework_aidl", "generator_function", "generator_location", "generator_name", "licenses", "main_dex_classes", "main_dex_list_creator", "name", "proguard", "restricted_to", "shrinked_android_jar", "source_properties", "tags", "testonly", "transitive_configs", "visibility", "zipalign"},
+	"android_sdk_repository":	{"api_level", "build_tools_version", "generator_function", "generator_location", "generator_name", "name", "path", "repo_mapping"},
+	"android_tools_defaults_jar":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"apple_binary":	{"applicable_licenses", "binary_type", "bundle_loader", "compatible_with", "data", "deprecation", "deps", "distribs", "dylibs", "exec_compatible_with", "exec_properties", "extension_safe", "feature_flags", "features", "generator_function", "generator_location", "generator_name", "licenses", "linkopts", "minimum_os_version", "name", "platform_type", "restricted_to", "sdk_dylibs", "sdk_frameworks", "stamp", "tags", "testonly", "transitive_configs", "visibility", "weak_sdk_frameworks"},
+	"apple_cc_toolchain":	{"all_files", "applicable_licenses", "ar_files", "as_files", "compatible_with", "compiler", "compiler_files", "compiler_files_without_includes", "coverage_files", "cpu", "deprecation", "distribs", "dwp_files", "dynamic_runtime_lib", "features", "generator_function", "generator_location", "generator_name", "libc_top", "licenses", "linker_files", "module_map", "name", "objcopy_files", "output_licenses", "restricted_to", "static_runtime_lib", "strip_files", "supports_header_parsing", "supports_param_files", "tags", "testonly", "toolchain_config", "toolchain_identifier", "transitive_configs", "visibility"},
+	"apple_static_library":	{"applicable_licenses", "avoid_deps", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "feature_flags", "features", "generator_function", "generator_location", "generator_name", "licenses", "linkopts", "minimum_os_version", "name", "platform_type", "restricted_to", "sdk_dylibs", "sdk_frameworks", "tags", "testonly", "transitive_configs", "visibility", "weak_sdk_frameworks"},
+	"available_xcodes":	{"applicable_licenses", "default", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "transitive_configs", "versions", "visibility"},
+	"bind":	{"actual", "applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"cc_binary":	{"additional_linker_inputs", "applicable_licenses", "args", "compatible_with", "copts", "data", "defines", "deprecation", "deps", "distribs", "dynamic_deps", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "includes", "licenses", "linkopts", "linkshared", "linkstatic", "local_defines", "malloc", "name", "nocopts", "output_licenses", "reexport_deps", "restricted_to", "srcs", "stamp", "tags", "testonly", "toolchains", "transitive_configs", "visibility", "win_def_file"},
+	"cc_host_toolchain_alias":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"cc_import":	{"alwayslink", "applicable_licenses", "compatible_with", "data", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "hdrs", "interface_library", "licenses", "name", "restricted_to", "shared_library", "static_library", "system_provided", "tags", "testonly", "transitive_configs", "visibility"},
+	"cc_libc_top_alias":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"cc_library":	{"alwayslink", "applicable_licenses", "compatible_with", "copts", "data", "defines", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "hdrs", "include_prefix", "includes", "licenses", "linkopts", "linkstamp", "linkstatic", "local_defines", "name", "nocopts", "reexport_deps", "restricted_to", "srcs", "strip_include_prefix", "tags", "testonly", "textual_hdrs", "toolchains", "transitive_configs", "visibility", "win_def_file"},
+	"cc_proto_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"cc_test":	{"additional_linker_inputs", "applicable_licenses", "args", "compatible_with", "copts", "data", "defines", "deprecation", "deps", "distribs", "dynamic_deps", "exec_compatible_with", "exec_properties", "features", "flaky", "generator_function", "generator_location", "generator_name", "includes", "licenses", "linkopts", "linkstatic", "local", "local_defines", "malloc", "name", "nocopts", "reexport_deps", "restricted_to", "shard_count", "size", "srcs", "stamp", "tags", "testonly", "timeout", "toolchains", "transitive_configs", "visibility", "win_def_file"},
+	"cc_toolchain":	{"all_files", "applicable_licenses", "ar_files", "as_files", "compatible_with", "compiler", "compiler_files", "compiler_files_without_includes", "coverage_files", "cpu", "deprecation", "distribs", "dwp_files", "dynamic_runtime_lib", "features", "generator_function", "generator_location", "generator_name", "libc_top", "licenses", "linker_files", "module_map", "name", "objcopy_files", "output_licenses", "restricted_to", "static_runtime_lib", "strip_files", "supports_header_parsing", "supports_param_files", "tags", "testonly", "toolchain_config", "toolchain_identifier", "transitive_configs", "visibility"},
+	"cc_toolchain_alias":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"cc_toolchain_suite":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "tags", "testonly", "toolchains", "transitive_configs", "visibility"},
+	"config_feature_flag":	{"allowed_values", "applicable_licenses", "default_value", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "visibility"},
+	"config_setting":	{"applicable_licenses", "constraint_values", "define_values", "deprecation", "distribs", "features", "flag_values", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "transitive_configs", "values", "visibility"},
+	"constraint_setting":	{"applicable_licenses", "default_constraint_value", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "transitive_configs", "visibility"},
+	"constraint_value":	{"applicable_licenses", "constraint_setting", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "transitive_configs", "visibility"},
+	"environment":	{"applicable_licenses", "deprecation", "distribs", "features", "fulfills", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "transitive_configs", "visibility"},
+	"extra_action":	{"applicable_licenses", "cmd", "compatible_with", "data", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "out_templates", "requires_action_output", "restricted_to", "tags", "testonly", "toolchains", "tools", "transitive_configs", "visibility"},
+	"fdo_prefetch_hints":	{"absolute_path_profile", "applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "profile", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"fdo_profile":	{"absolute_path_profile", "applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "profile", "proto_profile", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"filegroup":	{"applicable_licenses", "compatible_with", "data", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "output_group", "output_licenses", "path", "restricted_to", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"genquery":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "expression", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "opts", "restricted_to", "scope", "strict", "tags", "testonly", "transitive_configs", "visibility"},
+	"genrule":	{"applicable_licenses", "cmd", "cmd_bash", "cmd_bat", "cmd_ps", "compatible_with", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "exec_tools", "executable", "features", "generator_function", "generator_location", "generator_name", "heuristic_label_expansion", "licenses", "local", "message", "name", "output_licenses", "output_to_bindir", "outs", "restricted_to", "srcs", "stamp", "tags", "testonly", "toolchains", "tools", "transitive_configs", "visibility"},
+	"j2objc_library":	{"applicable_licenses", "compatible_with", "deprecation", "deps", "distribs", "entry_classes", "features", "generator_function", "generator_location", "generator_name", "jre_deps", "licenses", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_binary":	{"applicable_licenses", "args", "classpath_resources", "compatible_with", "create_executable", "data", "deploy_env", "deploy_manifest_lines", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "javacopts", "jvm_flags", "launcher", "licenses", "main_class", "name", "output_licenses", "plugins", "resource_jars", "resource_strip_prefix", "resources", "restricted_to", "runtime_deps", "srcs", "stamp", "tags", "testonly", "toolchains", "transitive_configs", "use_testrunner", "visibility"},
+	"java_import":	{"applicable_licenses", "compatible_with", "constraints", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "exports", "features", "generator_function", "generator_location", "generator_name", "jars", "licenses", "name", "neverlink", "proguard_specs", "restricted_to", "runtime_deps", "srcjar", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "exported_plugins", "exports", "features", "generator_function", "generator_location", "generator_name", "javacopts", "licenses", "name", "neverlink", "plugins", "proguard_specs", "resource_jars", "resource_strip_prefix", "resources", "restricted_to", "runtime_deps", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_lite_proto_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "strict_deps", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_package_configuration":	{"applicable_licenses", "compatible_with", "data", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "javacopts", "licenses", "name", "output_licenses", "packages", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_plugin":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generates_api", "generator_function", "generator_location", "generator_name", "javacopts", "licenses", "name", "neverlink", "output_licenses", "plugins", "processor_class", "proguard_specs", "resource_jars", "resource_strip_prefix", "resources", "restricted_to", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_proto_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "strict_deps", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_runtime":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "java", "java_home", "licenses", "name", "output_licenses", "restricted_to", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_runtime_alias":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"java_test":	{"applicable_licenses", "args", "classpath_resources", "compatible_with", "create_executable", "data", "deploy_manifest_lines", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "flaky", "generator_function", "generator_location", "generator_name", "javacopts", "jvm_flags", "launcher", "licenses", "local", "main_class", "name", "plugins", "resource_jars", "resource_strip_prefix", "resources", "restricted_to", "runtime_deps", "shard_count", "size", "srcs", "stamp", "tags", "test_class", "testonly", "timeout", "toolchains", "transitive_configs", "use_testrunner", "visibility"},
+	"java_toolchain":	{"applicable_licenses", "bootclasspath", "compatible_javacopts", "compatible_with", "deprecation", "distribs", "extclasspath", "features", "forcibly_disable_header_compilation", "genclass", "generator_function", "generator_location", "generator_name", "header_compiler", "header_compiler_builtin_processors", "header_compiler_direct", "ijar", "jacocorunner", "javabuilder", "javabuilder_jvm_opts", "javac", "javac_supports_multiplex_workers", "javac_supports_workers", "javacopts", "jvm_opts", "licenses", "misc", "name", "oneversion", "oneversion_whitelist", "output_licenses", "package_configuration", "reduced_classpath_incompatible_processors", "reduced_classpath_incompatible_targets", "resourcejar", "restricted_to", "singlejar", "source_version", "tags", "target_version", "testonly", "timezone_data", "tools", "transitive_configs", "turbine_incompatible_processors", "turbine_jvm_opts", "visibility", "xlint"},
+	"java_toolchain_alias":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"label_flag":	{"applicable_licenses", "build_setting_default", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"label_setting":	{"applicable_licenses", "build_setting_default", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"local_config_platform":	{"generator_function", "generator_location", "generator_name", "name", "repo_mapping"},
+	"local_repository":	{"generator_function", "generator_location", "generator_name", "name", "path", "repo_mapping"},
+	"new_local_repository":	{"build_file", "build_file_content", "generator_function", "generator_location", "generator_name", "name", "path", "repo_mapping", "workspace_file", "workspace_file_content"},
+	"ninja_build":	{"applicable_licenses", "compatible_with", "deprecation", "deps_mapping", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "ninja_graph", "output_groups", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"ninja_graph":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "main", "name", "ninja_srcs", "output_root", "output_root_inputs", "output_root_symlinks", "restricted_to", "tags", "testonly", "transitive_configs", "visibility", "working_directory"},
+	"objc_import":	{"alwayslink", "applicable_licenses", "archives", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "hdrs", "includes", "licenses", "name", "restricted_to", "sdk_dylibs", "sdk_frameworks", "sdk_includes", "tags", "testonly", "textual_hdrs", "transitive_configs", "visibility", "weak_sdk_frameworks"},
+	"objc_library":	{"alwayslink", "applicable_licenses", "compatible_with", "copts", "data", "defines", "deprecation", "deps", "distribs", "enable_modules", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "hdrs", "includes", "licenses", "module_map", "module_name", "name", "non_arc_srcs", "pch", "restricted_to", "runtime_deps", "sdk_dylibs", "sdk_frameworks", "sdk_includes", "srcs", "tags", "testonly", "textual_hdrs", "toolchains", "transitive_configs", "visibility", "weak_sdk_frameworks"},
+	"platform":	{"applicable_licenses", "constraint_values", "cpu_constraints", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "host_platform", "licenses", "name", "os_constraints", "parents", "remote_execution_properties", "tags", "target_platform", "testonly", "transitive_configs", "visibility"},
+	"proto_lang_toolchain":	{"applicable_licenses", "blacklisted_protos", "command_line", "compatible_with", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "plugin", "restricted_to", "runtime", "tags", "testonly", "transitive_configs", "visibility"},
+	"proto_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "exports", "features", "generator_function", "generator_location", "generator_name", "import_prefix", "licenses", "name", "restricted_to", "srcs", "strip_import_prefix", "tags", "testonly", "transitive_configs", "visibility"},
+	"py_binary":	{"applicable_licenses", "args", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "imports", "legacy_create_init", "licenses", "main", "name", "output_licenses", "python_version", "restricted_to", "srcs", "srcs_version", "stamp", "tags", "testonly", "toolchains", "transitive_configs", "visibility"},
+	"py_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "imports", "licenses", "name", "restricted_to", "srcs", "srcs_version", "tags", "testonly", "transitive_configs", "visibility"},
+	"py_runtime":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "files", "generator_function", "generator_location", "generator_name", "interpreter", "interpreter_path", "licenses", "name", "output_licenses", "python_version", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"py_test":	{"applicable_licenses", "args", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "flaky", "generator_function", "generator_location", "generator_name", "imports", "legacy_create_init", "licenses", "local", "main", "name", "python_version", "restricted_to", "shard_count", "size", "srcs", "srcs_version", "stamp", "tags", "testonly", "timeout", "toolchains", "transitive_configs", "visibility"},
+	"sh_binary":	{"applicable_licenses", "args", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "output_licenses", "restricted_to", "srcs", "tags", "testonly", "toolchains", "transitive_configs", "visibility"},
+	"sh_library":	{"applicable_licenses", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "srcs", "tags", "testonly", "transitive_configs", "visibility"},
+	"sh_test":	{"applicable_licenses", "args", "compatible_with", "data", "deprecation", "deps", "distribs", "exec_compatible_with", "exec_properties", "features", "flaky", "generator_function", "generator_location", "generator_name", "licenses", "local", "name", "restricted_to", "shard_count", "size", "srcs", "tags", "testonly", "timeout", "toolchains", "transitive_configs", "visibility"},
+	"test_suite":	{"applicable_licenses", "compatible_with", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "restricted_to", "tags", "testonly", "tests", "transitive_configs", "visibility"},
+	"toolchain":	{"applicable_licenses", "deprecation", "distribs", "exec_compatible_with", "exec_properties", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "target_compatible_with", "testonly", "toolchain", "toolchain_type", "transitive_configs", "visibility"},
+	"toolchain_type":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"xcode_config":	{"applicable_licenses", "default", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "local_versions", "name", "remote_versions", "tags", "testonly", "transitive_configs", "versions", "visibility"},
+	"xcode_config_alias":	{"applicable_licenses", "compatible_with", "deprecation", "features", "generator_function", "generator_location", "generator_name", "name", "restricted_to", "tags", "testonly", "transitive_configs", "visibility"},
+	"xcode_version":	{"aliases", "applicable_licenses", "default_ios_sdk_version", "default_macos_sdk_version", "default_tvos_sdk_version", "default_watchos_sdk_version", "deprecation", "distribs", "features", "generator_function", "generator_location", "generator_name", "licenses", "name", "tags", "testonly", "transitive_configs", "version", "visibility"},
+}
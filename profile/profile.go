@@ -0,0 +1,174 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package profile collects pprof profiles, execution traces and per-phase timings of a run of
+// buildifier or buildozer, so that they can be attached to performance reports.
+package profile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// Kinds lists the supported values of the -profile flag.
+var Kinds = []string{"cpu", "mem", "trace"}
+
+// Validate checks that kind is one of Kinds or empty (profiling disabled).
+func Validate(kind string) error {
+	if kind == "" {
+		return nil
+	}
+	for _, k := range Kinds {
+		if k == kind {
+			return nil
+		}
+	}
+	return fmt.Errorf("unrecognized profile kind %q; valid kinds are cpu, mem, trace", kind)
+}
+
+// DefaultOutput returns the file a profile of the given kind is written to if no output path is
+// given, e.g. "buildifier.cpu.pprof" or "buildifier.trace".
+func DefaultOutput(tool, kind string) string {
+	if kind == "trace" {
+		return tool + ".trace"
+	}
+	return tool + "." + kind + ".pprof"
+}
+
+// Start starts collecting a profile of the given kind, which is written to path once the
+// returned function is called. An empty kind disables profiling.
+func Start(kind, path string) (stop func() error, err error) {
+	if err := Validate(kind); err != nil {
+		return nil, err
+	}
+	if kind == "" {
+		return func() error { return nil }, nil
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "cpu":
+		if err := pprof.StartCPUProfile(out); err != nil {
+			out.Close()
+			return nil, err
+		}
+		return func() error {
+			pprof.StopCPUProfile()
+			return out.Close()
+		}, nil
+	case "trace":
+		if err := trace.Start(out); err != nil {
+			out.Close()
+			return nil, err
+		}
+		return func() error {
+			trace.Stop()
+			return out.Close()
+		}, nil
+	default: // "mem"
+		return func() error {
+			// Collect the garbage to get up-to-date statistics of the allocations.
+			runtime.GC()
+			if err := pprof.Lookup("allocs").WriteTo(out, 0); err != nil {
+				out.Close()
+				return err
+			}
+			return out.Close()
+		}, nil
+	}
+}
+
+// Stats accumulates the time spent in the phases of a run, such as parsing or printing.
+// A nil *Stats discards all timings, so callers don't need to check whether stats are enabled.
+type Stats struct {
+	mu        sync.Mutex
+	start     time.Time
+	files     int
+	phases    []string
+	durations map[string]time.Duration
+}
+
+// NewStats returns an empty Stats, the total time is measured from now on.
+func NewStats() *Stats {
+	return &Stats{
+		start:     time.Now(),
+		durations: make(map[string]time.Duration),
+	}
+}
+
+// Time starts timing the given phase and returns a function that stops it, e.g.
+//
+//	defer stats.Time("parse")()
+func (s *Stats) Time(phase string) func() {
+	if s == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		s.Add(phase, time.Since(start))
+	}
+}
+
+// Add adds d to the time spent in the given phase.
+func (s *Stats) Add(phase string, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.durations[phase]; !ok {
+		s.phases = append(s.phases, phase)
+	}
+	s.durations[phase] += d
+}
+
+// AddFile counts a processed file.
+func (s *Stats) AddFile() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files++
+}
+
+// Write prints a summary of the number of processed files, the total time and the time spent in
+// each phase, in the order the phases were first timed.
+func (s *Stats) Write(w io.Writer, tool string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := time.Since(s.start)
+	fmt.Fprintf(w, "%s: processed %d files in %v\n", tool, s.files, total.Round(time.Microsecond))
+	for _, phase := range s.phases {
+		d := s.durations[phase]
+		percent := 0.0
+		if total > 0 {
+			percent = 100 * float64(d) / float64(total)
+		}
+		fmt.Fprintf(w, "  %-8s %12v %5.1f%%\n", phase, d.Round(time.Microsecond), percent)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	tmp := t.TempDir()
+	for _, kind := range Kinds {
+		path := filepath.Join(tmp, DefaultOutput("tool", kind))
+		stop, err := Start(kind, path)
+		if err != nil {
+			t.Fatalf("Start(%q) failed: %v", kind, err)
+		}
+		if err := stop(); err != nil {
+			t.Fatalf("stopping the %s profile failed: %v", kind, err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Start(%q) didn't write a profile to %s", kind, path)
+		}
+	}
+
+	if _, err := Start("heap", filepath.Join(tmp, "heap")); err == nil {
+		t.Errorf("Start(\"heap\") succeeded, want an error")
+	}
+	stop, err := Start("", "")
+	if err != nil {
+		t.Fatalf("Start(\"\") failed: %v", err)
+	}
+	if err := stop(); err != nil {
+		t.Errorf("stopping a disabled profile failed: %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	stats := NewStats()
+	stats.AddFile()
+	stats.AddFile()
+	stats.Add("parse", 2*time.Millisecond)
+	stats.Add("print", time.Millisecond)
+	stats.Add("parse", 3*time.Millisecond)
+
+	var out bytes.Buffer
+	stats.Write(&out, "buildifier")
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Write() printed %q, want 3 lines", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "buildifier: processed 2 files in ") {
+		t.Errorf("Write() printed the summary %q", lines[0])
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "parse" || fields[1] != "5ms" {
+		t.Errorf("Write() printed the parse phase as %q", lines[1])
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "print" || fields[1] != "1ms" {
+		t.Errorf("Write() printed the print phase as %q", lines[2])
+	}
+
+	// A nil Stats discards the timings.
+	var noStats *Stats
+	noStats.Time("parse")()
+	noStats.AddFile()
+	out.Reset()
+	noStats.Write(&out, "buildifier")
+	if out.Len() != 0 {
+		t.Errorf("Write() on nil Stats printed %q", out.String())
+	}
+}
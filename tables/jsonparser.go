@@ -18,7 +18,9 @@ package tables
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
 )
 
 type Definitions struct {
@@ -31,6 +33,14 @@ type Definitions struct {
 	NamePriority                    map[string]int
 	StripLabelLeadingSlashes        bool
 	ShortenAbsoluteLabelsToRelative bool
+	CanonicalLoadLabelForm          string
+	CanonicalLoadRepoName           string
+	CanonicalReexports              map[string]bool
+	IsOrderSensitiveListArg         map[string]bool
+	IsFlagListArg                   map[string]bool
+	SortableFlagListArg             map[string]bool
+	JoinableFlags                   map[string]bool
+	PassThroughFlags                map[string]bool
 }
 
 // ParseJSONDefinitions reads and parses JSON table definitions from file.
@@ -49,15 +59,173 @@ func ParseJSONDefinitions(file string) (Definitions, error) {
 // ParseAndUpdateJSONDefinitions reads definitions from file and merges or
 // overrides the values in memory.
 func ParseAndUpdateJSONDefinitions(file string, merge bool) error {
-	definitions, err := ParseJSONDefinitions(file)
-	if err != nil {
-		return err
+	_, err := ParseAndUpdateJSONDefinitionFiles([]string{file}, merge)
+	return err
+}
+
+// ParseAndUpdateJSONDefinitionFiles reads definitions from several files, e.g. an
+// organization-wide one followed by a repository-specific one, and merges or overrides the
+// values in memory with their combination. The files are merged in order: the entries of the
+// maps are added, the entries with the same key and the non-empty strings of the later files
+// replace the ones of the earlier files, and the booleans are true if any file sets them. The
+// entries replaced with different values are returned as conflicts.
+func ParseAndUpdateJSONDefinitionFiles(files []string, merge bool) ([]Conflict, error) {
+	var combined Definitions
+	m := definitionsMerger{origins: make(map[string]string)}
+	for _, file := range files {
+		definitions, err := ParseJSONDefinitions(file)
+		if err != nil {
+			return nil, err
+		}
+		switch definitions.CanonicalLoadLabelForm {
+		case "", "absolute", "repo":
+		default:
+			return nil, fmt.Errorf("%s: invalid CanonicalLoadLabelForm %q, expected \"absolute\" or \"repo\"", file, definitions.CanonicalLoadLabelForm)
+		}
+		m.merge(&combined, definitions, file)
+	}
+	updateDefinitions(combined, merge)
+	return m.conflicts, nil
+}
+
+// updateDefinitions merges or overrides the values in memory with definitions.
+func updateDefinitions(definitions Definitions, merge bool) {
+	if definitions.CanonicalLoadLabelForm != "" {
+		CanonicalLoadLabelForm = definitions.CanonicalLoadLabelForm
+	}
+	if definitions.CanonicalLoadRepoName != "" {
+		CanonicalLoadRepoName = definitions.CanonicalLoadRepoName
+	}
+	if definitions.CanonicalReexports != nil {
+		if !merge {
+			CanonicalReexports = make(map[string]bool)
+		}
+		for k, v := range definitions.CanonicalReexports {
+			CanonicalReexports[k] = v
+		}
+	}
+	if definitions.IsOrderSensitiveListArg != nil {
+		if !merge {
+			IsOrderSensitiveListArg = make(map[string]bool)
+		}
+		for k, v := range definitions.IsOrderSensitiveListArg {
+			IsOrderSensitiveListArg[k] = v
+		}
 	}
+	updateMap(&IsFlagListArg, definitions.IsFlagListArg, merge)
+	updateMap(&SortableFlagListArg, definitions.SortableFlagListArg, merge)
+	updateMap(&JoinableFlags, definitions.JoinableFlags, merge)
+	updateMap(&PassThroughFlags, definitions.PassThroughFlags, merge)
 
 	if merge {
 		MergeTables(definitions.IsLabelArg, definitions.LabelDenylist, definitions.IsListArg, definitions.IsSortableListArg, definitions.SortableDenylist, definitions.SortableAllowlist, definitions.NamePriority, definitions.StripLabelLeadingSlashes, definitions.ShortenAbsoluteLabelsToRelative)
 	} else {
 		OverrideTables(definitions.IsLabelArg, definitions.LabelDenylist, definitions.IsListArg, definitions.IsSortableListArg, definitions.SortableDenylist, definitions.SortableAllowlist, definitions.NamePriority, definitions.StripLabelLeadingSlashes, definitions.ShortenAbsoluteLabelsToRelative)
 	}
-	return nil
+}
+
+// updateMap merges or overrides a table with the entries of a definitions file, if it sets it.
+func updateMap(table *map[string]bool, definitions map[string]bool, merge bool) {
+	if definitions == nil {
+		return
+	}
+	if !merge {
+		*table = make(map[string]bool)
+	}
+	for k, v := range definitions {
+		(*table)[k] = v
+	}
+}
+
+// A Conflict is a table entry set to different values by two of the files merged by
+// ParseAndUpdateJSONDefinitionFiles. The value of the later file wins.
+type Conflict struct {
+	Table string // e.g. "IsSortableListArg"
+	Key   string // the key of the entry, empty for the string values
+	File  string // the file whose value wins
+	Value string
+	// PreviousFile is the earlier file whose value is replaced.
+	PreviousFile  string
+	PreviousValue string
+}
+
+func (c Conflict) String() string {
+	entry := c.Table
+	if c.Key != "" {
+		entry = fmt.Sprintf("%s[%q]", c.Table, c.Key)
+	}
+	return fmt.Sprintf("%s: %s = %s overrides %s from %s", c.File, entry, c.Value, c.PreviousValue, c.PreviousFile)
+}
+
+// definitionsMerger merges the definitions of several files, remembering which file each entry
+// comes from to report the conflicts.
+type definitionsMerger struct {
+	origins   map[string]string // "table[key]" -> file
+	conflicts []Conflict
+}
+
+func (m *definitionsMerger) merge(dst *Definitions, src Definitions, file string) {
+	mergeMap(m, "IsLabelArg", &dst.IsLabelArg, src.IsLabelArg, file)
+	mergeMap(m, "LabelDenylist", &dst.LabelDenylist, src.LabelDenylist, file)
+	mergeMap(m, "IsListArg", &dst.IsListArg, src.IsListArg, file)
+	mergeMap(m, "IsSortableListArg", &dst.IsSortableListArg, src.IsSortableListArg, file)
+	mergeMap(m, "SortableDenylist", &dst.SortableDenylist, src.SortableDenylist, file)
+	mergeMap(m, "SortableAllowlist", &dst.SortableAllowlist, src.SortableAllowlist, file)
+	mergeMap(m, "NamePriority", &dst.NamePriority, src.NamePriority, file)
+	mergeMap(m, "CanonicalReexports", &dst.CanonicalReexports, src.CanonicalReexports, file)
+	mergeMap(m, "IsOrderSensitiveListArg", &dst.IsOrderSensitiveListArg, src.IsOrderSensitiveListArg, file)
+	mergeMap(m, "IsFlagListArg", &dst.IsFlagListArg, src.IsFlagListArg, file)
+	mergeMap(m, "SortableFlagListArg", &dst.SortableFlagListArg, src.SortableFlagListArg, file)
+	mergeMap(m, "JoinableFlags", &dst.JoinableFlags, src.JoinableFlags, file)
+	mergeMap(m, "PassThroughFlags", &dst.PassThroughFlags, src.PassThroughFlags, file)
+	m.mergeString("CanonicalLoadLabelForm", &dst.CanonicalLoadLabelForm, src.CanonicalLoadLabelForm, file)
+	m.mergeString("CanonicalLoadRepoName", &dst.CanonicalLoadRepoName, src.CanonicalLoadRepoName, file)
+	dst.StripLabelLeadingSlashes = dst.StripLabelLeadingSlashes || src.StripLabelLeadingSlashes
+	dst.ShortenAbsoluteLabelsToRelative = dst.ShortenAbsoluteLabelsToRelative || src.ShortenAbsoluteLabelsToRelative
+}
+
+// record remembers that file sets an entry, and reports a conflict if an earlier file set it
+// to a different value.
+func (m *definitionsMerger) record(table, key, file, value, previousValue string, exists bool) {
+	id := table + "[" + key + "]"
+	if exists && value != previousValue {
+		m.conflicts = append(m.conflicts, Conflict{
+			Table:         table,
+			Key:           key,
+			File:          file,
+			Value:         value,
+			PreviousFile:  m.origins[id],
+			PreviousValue: previousValue,
+		})
+	}
+	m.origins[id] = file
+}
+
+func (m *definitionsMerger) mergeString(table string, dst *string, src, file string) {
+	if src == "" {
+		return
+	}
+	m.record(table, "", file, src, *dst, *dst != "")
+	*dst = src
+}
+
+func mergeMap[V bool | int](m *definitionsMerger, table string, dst *map[string]V, src map[string]V, file string) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]V)
+	}
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	// Sorted to report the conflicts in a deterministic order.
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := src[k]
+		previous, exists := (*dst)[k]
+		m.record(table, k, file, fmt.Sprint(v), fmt.Sprint(previous), exists)
+		(*dst)[k] = v
+	}
 }
@@ -18,6 +18,7 @@ package tables
 
 import (
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -42,3 +43,53 @@ func TestParseJSONDefinitions(t *testing.T) {
 		t.Errorf("ParseJSONDefinitions(simple_tables.json) = %v; want %v", definitions, expected)
 	}
 }
+
+func TestParseAndUpdateJSONDefinitionFiles(t *testing.T) {
+	dir := t.TempDir()
+	org := filepath.Join(dir, "org.json")
+	repo := filepath.Join(dir, "repo.json")
+	if err := os.WriteFile(org, []byte(`{
+  "IsSortableListArg": {"srcs": true, "deps": true},
+  "NamePriority": {"name": -1},
+  "CanonicalLoadLabelForm": "absolute"
+}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(repo, []byte(`{
+  "IsSortableListArg": {"srcs": false, "deps": true, "data": true},
+  "StripLabelLeadingSlashes": true,
+  "CanonicalLoadLabelForm": "repo"
+}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(sortable map[string]bool, priority map[string]int, form string, strip bool) {
+		IsSortableListArg, NamePriority, CanonicalLoadLabelForm, StripLabelLeadingSlashes = sortable, priority, form, strip
+	}(IsSortableListArg, NamePriority, CanonicalLoadLabelForm, StripLabelLeadingSlashes)
+
+	conflicts, err := ParseAndUpdateJSONDefinitionFiles([]string{org, repo}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"srcs": false, "deps": true, "data": true}; !reflect.DeepEqual(IsSortableListArg, want) {
+		t.Errorf("IsSortableListArg = %v, want %v", IsSortableListArg, want)
+	}
+	if want := map[string]int{"name": -1}; !reflect.DeepEqual(NamePriority, want) {
+		t.Errorf("NamePriority = %v, want %v", NamePriority, want)
+	}
+	if CanonicalLoadLabelForm != "repo" || !StripLabelLeadingSlashes {
+		t.Errorf("CanonicalLoadLabelForm = %q, StripLabelLeadingSlashes = %t, want \"repo\", true", CanonicalLoadLabelForm, StripLabelLeadingSlashes)
+	}
+
+	var got []string
+	for _, c := range conflicts {
+		got = append(got, c.String())
+	}
+	want := []string{
+		repo + `: IsSortableListArg["srcs"] = false overrides true from ` + org,
+		repo + `: CanonicalLoadLabelForm = repo overrides absolute from ` + org,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("conflicts = %q, want %q", got, want)
+	}
+}
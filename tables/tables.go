@@ -168,6 +168,60 @@ var SortableDenylist = map[string]bool{
 // (format: "rule_name.attribute_name").
 var SortableAllowlist = map[string]bool{}
 
+// IsOrderSensitiveListArg contains the named arguments to a rule call whose values are lists of
+// flags where the order matters, typically because the later flags override the earlier ones.
+// The select-order warning checks the concatenations of lists and selects in them.
+var IsOrderSensitiveListArg = map[string]bool{
+	"conlyopts":   true,
+	"copts":       true,
+	"cxxopts":     true,
+	"gc_goopts":   true,
+	"gc_linkopts": true,
+	"javacopts":   true,
+	"linkopts":    true,
+	"rustc_flags": true,
+}
+
+// IsFlagListArg contains the named arguments to a rule call whose values are lists of compiler
+// or linker flags, which the opt-in flaglists rewrite normalizes: one flag per element, the
+// values of the JoinableFlags attached to them, and the repeated flags removed, except the
+// libraries of linkopts.
+var IsFlagListArg = map[string]bool{
+	"conlyopts": true,
+	"copts":     true,
+	"cxxopts":   true,
+	"linkopts":  true,
+}
+
+// SortableFlagListArg contains the flag lists of IsFlagListArg whose order doesn't matter, which
+// the flaglists rewrite also sorts.
+var SortableFlagListArg = map[string]bool{}
+
+// JoinableFlags contains the flags whose value can be given either in the same argument or in
+// the next one, e.g. "-DFOO" or "-D", "FOO". The flaglists rewrite attaches their values.
+var JoinableFlags = map[string]bool{
+	"-D": true,
+	"-I": true,
+	"-L": true,
+	"-U": true,
+	"-l": true,
+}
+
+// PassThroughFlags contains the flags passing an option through to another tool, either in the
+// next element, e.g. "-Xlinker", "-rpath", or after a comma, e.g. "-Wl,-rpath". The flaglists
+// rewrite keeps the options together with them whatever they look like, and with the values of
+// the options passed through separately, e.g. "-Xlinker", "-rpath", "-Xlinker", "/path".
+var PassThroughFlags = map[string]bool{
+	"-Wa,":           true,
+	"-Wl,":           true,
+	"-Wp,":           true,
+	"-Xassembler":    true,
+	"-Xclang":        true,
+	"-Xlinker":       true,
+	"-Xpreprocessor": true,
+	"-mllvm":         true,
+}
+
 // NamePriority maps an argument name to its sorting priority.
 //
 // NOTE(bazel-team): These are the old buildifier rules. It is likely that this table
@@ -212,6 +266,40 @@ var StripLabelLeadingSlashes = false
 
 var ShortenAbsoluteLabelsToRelative = false
 
+// NativeModuleFunctions lists the functions that are global in BUILD files but only available
+// through the native module in .bzl files, mapped to the Bazel version that added them to the
+// native module, empty if they have always been there. The native rules that moved to Starlark
+// rule sets (e.g. cc_library) aren't listed, the native-* warnings load them instead.
+var NativeModuleFunctions = map[string]string{
+	"alias":                  "",
+	"config_setting":         "",
+	"constraint_setting":     "",
+	"constraint_value":       "",
+	"environment":            "",
+	"existing_rule":          "",
+	"existing_rules":         "",
+	"exports_files":          "",
+	"filegroup":              "",
+	"genquery":               "",
+	"genrule":                "",
+	"glob":                   "",
+	"label_flag":             "",
+	"label_setting":          "",
+	"module_name":            "6.0",
+	"module_version":         "6.0",
+	"package_group":          "",
+	"package_name":           "",
+	"package_relative_label": "7.0",
+	"platform":               "",
+	"repo_name":              "7.1",
+	"repository_name":        "",
+	"starlark_doc_extract":   "7.0",
+	"subpackages":            "6.0",
+	"test_suite":             "",
+	"toolchain":              "",
+	"toolchain_type":         "",
+}
+
 // AndroidNativeRules lists all Android rules that are being migrated from Native to Starlark.
 var AndroidNativeRules = []string{
 	"aar_import",
@@ -265,6 +353,20 @@ var IsModuleOverride = map[string]bool{
 	"single_version_override":   true,
 }
 
+// CanonicalLoadLabelForm is the form of load labels of the current repository enforced by the
+// canonical-load-label warning: "absolute" for //pkg:file.bzl or "repo" for @repo//pkg:file.bzl.
+var CanonicalLoadLabelForm = "absolute"
+
+// CanonicalLoadRepoName is the name of the current repository. It's used to recognize loads from
+// the current repository and to build labels in the "repo" form. If it's empty, the name of the
+// module declared in MODULE.bazel is used.
+var CanonicalLoadRepoName = ""
+
+// CanonicalReexports lists the .bzl files (as absolute labels, e.g. "//tools/build_defs:defs.bzl")
+// that are the public entry points for the symbols they re-export. The reexported-load warning
+// doesn't report loads from them and suggests them instead of the files defining the symbols.
+var CanonicalReexports = map[string]bool{}
+
 // OverrideTables allows a user of the build package to override the special-case rules. The user-provided tables replace the built-in tables.
 func OverrideTables(labelArg, denylist, listArg, sortableListArg, sortDenylist, sortAllowlist map[string]bool, namePriority map[string]int, stripLabelLeadingSlashes, shortenAbsoluteLabelsToRelative bool) {
 	IsLabelArg = labelArg
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Analysis of the aliases symbols are loaded under across a file tree
+
+package warn
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/labels"
+)
+
+// LoadLocation is a load statement that loads a symbol under an alias.
+type LoadLocation struct {
+	File string // display path of the file
+	Line int
+}
+
+// LoadAlias is a name a symbol is loaded under, with the load statements using it.
+type LoadAlias struct {
+	Name      string
+	Locations []LoadLocation
+}
+
+// LoadAliasReport describes a symbol that is loaded under several different aliases.
+type LoadAliasReport struct {
+	Module    string // label of the loaded file, relative labels are resolved against the packages
+	Symbol    string // name of the symbol in the loaded file
+	Canonical string // suggested alias, the most used one
+	// Aliases are sorted by the number of load statements using them, the most used first.
+	Aliases []*LoadAlias
+}
+
+// FindLoadAliases reports the symbols that the BUILD files load under different aliases,
+// e.g. `load("//tools:defs.bzl", "my_rule")` in one file and
+// `load("//tools:defs.bzl", my_rule2 = "my_rule")` in another one. Other file types are
+// ignored, .bzl files often load symbols under private aliases on purpose. The reports are
+// sorted by module and symbol.
+func FindLoadAliases(files []*build.File) []*LoadAliasReport {
+	type symbol struct{ module, name string }
+	aliases := make(map[symbol]map[string]*LoadAlias)
+	for _, f := range files {
+		if f.Type != build.TypeBuild {
+			continue
+		}
+		for _, stmt := range f.Stmt {
+			load, ok := stmt.(*build.LoadStmt)
+			if !ok {
+				continue
+			}
+			module := labels.ParseRelative(load.Module.Value, f.Pkg).Format()
+			for i, from := range load.From {
+				key := symbol{module, from.Name}
+				if aliases[key] == nil {
+					aliases[key] = make(map[string]*LoadAlias)
+				}
+				name := load.To[i].Name
+				alias := aliases[key][name]
+				if alias == nil {
+					alias = &LoadAlias{Name: name}
+					aliases[key][name] = alias
+				}
+				start, _ := load.To[i].Span()
+				alias.Locations = append(alias.Locations, LoadLocation{f.DisplayPath(), start.Line})
+			}
+		}
+	}
+
+	var reports []*LoadAliasReport
+	for key, byName := range aliases {
+		if len(byName) < 2 {
+			continue
+		}
+		report := &LoadAliasReport{Module: key.module, Symbol: key.name}
+		for _, alias := range byName {
+			report.Aliases = append(report.Aliases, alias)
+		}
+		sort.Slice(report.Aliases, func(i, j int) bool {
+			a, b := report.Aliases[i], report.Aliases[j]
+			if len(a.Locations) != len(b.Locations) {
+				return len(a.Locations) > len(b.Locations)
+			}
+			// On a tie prefer the original name of the symbol.
+			if (a.Name == key.name) != (b.Name == key.name) {
+				return a.Name == key.name
+			}
+			return a.Name < b.Name
+		})
+		report.Canonical = report.Aliases[0].Name
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Module != reports[j].Module {
+			return reports[i].Module < reports[j].Module
+		}
+		return reports[i].Symbol < reports[j].Symbol
+	})
+	return reports
+}
+
+// PrintLoadAliases writes the reports grouped by symbol, with the places that load the
+// symbol under each alias.
+func PrintLoadAliases(w io.Writer, reports []*LoadAliasReport) {
+	for _, report := range reports {
+		fmt.Fprintf(w, "%q from %q is loaded under %d aliases, consider using %q everywhere:\n",
+			report.Symbol, report.Module, len(report.Aliases), report.Canonical)
+		for _, alias := range report.Aliases {
+			fmt.Fprintf(w, "  %s:\n", alias.Name)
+			for _, loc := range alias.Locations {
+				fmt.Fprintf(w, "    %s:%d\n", loc.File, loc.Line)
+			}
+		}
+	}
+}
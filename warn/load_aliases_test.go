@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestFindLoadAliases(t *testing.T) {
+	sources := []struct {
+		path, pkg, content string
+	}{
+		{"a/BUILD", "a", `
+load("//tools:defs.bzl", "my_rule", lib = "my_library")
+load(":defs.bzl", "local")
+`},
+		{"b/BUILD", "b", `
+load("//tools:defs.bzl", r = "my_rule", "my_library")
+load("//a:defs.bzl", local2 = "local")
+`},
+		{"c/BUILD.bazel", "c", `
+load("@//tools:defs.bzl", rule = "my_rule")
+load("//tools:defs.bzl", "my_library")
+`},
+		{"d/BUILD", "d", `
+load("//tools:defs.bzl", r = "my_rule")
+`},
+		// Private aliases in .bzl files are ignored.
+		{"e/defs.bzl", "e", `
+load("//tools:defs.bzl", _my_rule = "my_rule")
+`},
+	}
+	var files []*build.File
+	for _, src := range sources {
+		f, err := build.Parse(src.path, []byte(src.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Pkg = src.pkg
+		files = append(files, f)
+	}
+
+	var out bytes.Buffer
+	PrintLoadAliases(&out, FindLoadAliases(files))
+	want := `"local" from "//a:defs.bzl" is loaded under 2 aliases, consider using "local" everywhere:
+  local:
+    a/BUILD:3
+  local2:
+    b/BUILD:3
+"my_library" from "//tools:defs.bzl" is loaded under 2 aliases, consider using "my_library" everywhere:
+  my_library:
+    b/BUILD:2
+    c/BUILD.bazel:3
+  lib:
+    a/BUILD:2
+"my_rule" from "//tools:defs.bzl" is loaded under 3 aliases, consider using "r" everywhere:
+  r:
+    b/BUILD:2
+    d/BUILD:2
+  my_rule:
+    a/BUILD:2
+  rule:
+    c/BUILD.bazel:2
+`
+	if got := out.String(); got != want {
+		t.Errorf("FindLoadAliases() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -17,6 +17,12 @@ limitations under the License.
 package warn
 
 import (
+	"bytes"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
 	"github.com/bazelbuild/buildtools/build"
 )
 
@@ -25,6 +31,53 @@ import (
 type FileReader struct {
 	cache    map[string]*build.File
 	readFile func(string) ([]byte, error)
+	fsys     fs.FS
+	// fileCache keeps the parsed files across FileReaders, see NewFileReaderWithCache.
+	fileCache *FileCache
+	root      string
+}
+
+// A FileCache keeps the Starlark files parsed by FileReaders across runs, e.g. in a long-lived
+// process that lints the files of a repository repeatedly. The files are read on each run and
+// only parsed again if their contents have changed.
+type FileCache struct {
+	mu    sync.Mutex
+	files map[fileCacheKey]cachedFile
+}
+
+type fileCacheKey struct {
+	root     string
+	filename string
+}
+
+type cachedFile struct {
+	contents []byte
+	file     *build.File
+}
+
+// NewFileCache returns an empty FileCache.
+func NewFileCache() *FileCache {
+	return &FileCache{files: make(map[fileCacheKey]cachedFile)}
+}
+
+// parse returns the parsed file of the given contents, from the cache if the file had the same
+// contents the last time it was parsed.
+func (c *FileCache) parse(root, filename string, contents []byte) (*build.File, error) {
+	key := fileCacheKey{root, filename}
+	c.mu.Lock()
+	cached, ok := c.files[key]
+	c.mu.Unlock()
+	if ok && bytes.Equal(cached.contents, contents) {
+		return cached.file, nil
+	}
+	file, err := build.Parse(filename, contents)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.files[key] = cachedFile{contents, file}
+	c.mu.Unlock()
+	return file, nil
 }
 
 // NewFileReader creates and initializes a FileReader instance with a
@@ -38,15 +91,39 @@ func NewFileReader(readFile func(string) ([]byte, error)) *FileReader {
 	}
 }
 
+// NewFileReaderWithFS is like NewFileReader, but the FileReader can also check whether the
+// source files exist in fsys, the file system of the repository rooted at the workspace root,
+// e.g. os.DirFS(workspaceRoot).
+func NewFileReaderWithFS(readFile func(string) ([]byte, error), fsys fs.FS) *FileReader {
+	fr := NewFileReader(readFile)
+	fr.fsys = fsys
+	return fr
+}
+
+// NewFileReaderWithCache is like NewFileReaderWithFS, but the parsed files are also kept in
+// cache and reused by the other FileReaders of the same cache. root is the workspace root the
+// paths given to readFile are relative to, it tells apart the files of different repositories.
+func NewFileReaderWithCache(readFile func(string) ([]byte, error), fsys fs.FS, cache *FileCache, root string) *FileReader {
+	fr := NewFileReaderWithFS(readFile, fsys)
+	fr.fileCache = cache
+	fr.root = root
+	return fr
+}
+
 // retrieveFile reads a Starlark file using only the readFile method
-// (without using the cache).
+// (without using the cache of the FileReader).
 func (fr *FileReader) retrieveFile(filename string) *build.File {
 	contents, err := fr.readFile(filename)
 	if err != nil {
 		return nil
 	}
 
-	file, err := build.Parse(filename, contents)
+	var file *build.File
+	if fr.fileCache != nil {
+		file, err = fr.fileCache.parse(fr.root, filename, contents)
+	} else {
+		file, err = build.Parse(filename, contents)
+	}
 	if err != nil {
 		return nil
 	}
@@ -75,3 +152,95 @@ func (fr *FileReader) GetFile(pkg, label string) *build.File {
 	fr.cache[filename] = file
 	return file
 }
+
+// ReadSourceFile returns the contents of a file of a package of the repository, or nil if it
+// can't be read.
+func (fr *FileReader) ReadSourceFile(pkg, name string) []byte {
+	if fr == nil {
+		return nil
+	}
+	contents, err := fr.readFile(path.Join(pkg, name))
+	if err != nil {
+		return nil
+	}
+	return contents
+}
+
+// FileExists reports whether a file or a directory exists in a package of the repository. The
+// second result is false if it can't be checked, e.g. because the FileReader has no file system.
+func (fr *FileReader) FileExists(pkg, name string) (exists, ok bool) {
+	if fr == nil || fr.fsys == nil {
+		return false, false
+	}
+	filename := path.Join(pkg, name)
+	if !fs.ValidPath(filename) {
+		return false, false
+	}
+	_, err := fs.Stat(fr.fsys, filename)
+	return err == nil, true
+}
+
+// IsFile reports whether a file that isn't a directory exists in a package of the repository.
+// The second result is false if it can't be checked, e.g. because the FileReader has no file
+// system.
+func (fr *FileReader) IsFile(pkg, name string) (isFile, ok bool) {
+	if fr == nil || fr.fsys == nil {
+		return false, false
+	}
+	filename := path.Join(pkg, name)
+	if !fs.ValidPath(filename) {
+		return false, false
+	}
+	info, err := fs.Stat(fr.fsys, filename)
+	return err == nil && !info.IsDir(), true
+}
+
+// PackageFiles returns the names of the files and directories in the directory of a package,
+// or nil if they can't be listed.
+func (fr *FileReader) PackageFiles(pkg string) []string {
+	if fr == nil || fr.fsys == nil {
+		return nil
+	}
+	dir := pkg
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := fs.ReadDir(fr.fsys, dir)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	return names
+}
+
+// StarlarkFiles returns the paths of the BUILD, .bzl and MODULE.bazel files of the repository,
+// or nil if they can't be listed, e.g. because the FileReader has no file system. The hidden
+// directories and the bazel-* output directories are skipped.
+func (fr *FileReader) StarlarkFiles() []string {
+	if fr == nil || fr.fsys == nil {
+		return nil
+	}
+	files := []string{}
+	fs.WalkDir(fr.fsys, ".", func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if filename != "." && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "bazel-")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case name == "BUILD", name == "BUILD.bazel", name == "MODULE.bazel",
+			strings.HasSuffix(name, ".bzl"), strings.HasSuffix(name, ".MODULE.bazel"):
+			files = append(files, filename)
+		}
+		return nil
+	})
+	return files
+}
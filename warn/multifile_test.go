@@ -0,0 +1,54 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFileCache(t *testing.T) {
+	files := map[string]string{"pkg/defs.bzl": "a = 1\n"}
+	readFile := func(filename string) ([]byte, error) {
+		if contents, ok := files[filename]; ok {
+			return []byte(contents), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	cache := NewFileCache()
+
+	first := NewFileReaderWithCache(readFile, nil, cache, "/ws").GetFile("pkg", "defs.bzl")
+	if first == nil {
+		t.Fatal("GetFile() = nil, want the parsed file")
+	}
+	if got := NewFileReaderWithCache(readFile, nil, cache, "/ws").GetFile("pkg", "defs.bzl"); got != first {
+		t.Errorf("GetFile() of an unchanged file reparsed it, want the cached file")
+	}
+	if got := NewFileReaderWithCache(readFile, nil, cache, "/other").GetFile("pkg", "defs.bzl"); got == first {
+		t.Errorf("GetFile() in another workspace returned the cached file, want it to be parsed")
+	}
+
+	files["pkg/defs.bzl"] = "a = 1\nb = 2\n"
+	got := NewFileReaderWithCache(readFile, nil, cache, "/ws").GetFile("pkg", "defs.bzl")
+	if got == first || got == nil || len(got.Stmt) != 2 {
+		t.Errorf("GetFile() of a changed file = %v, want it to be parsed again", got)
+	}
+
+	if got := NewFileReaderWithCache(readFile, nil, cache, "/ws").GetFile("pkg", "missing.bzl"); got != nil {
+		t.Errorf("GetFile() of a missing file = %v, want nil", got)
+	}
+}
@@ -40,6 +40,7 @@ const (
 	String
 	List
 	Float
+	Label
 )
 
 func (t Type) String() string {
@@ -56,6 +57,7 @@ func (t Type) String() string {
 		"string",
 		"list",
 		"float",
+		"Label",
 	}[t]
 }
 
@@ -105,7 +107,7 @@ func DetectTypes(f *build.File) map[build.Expr]Type {
 				switch ident.Name {
 				case "bool":
 					nodeType = Bool
-				case "int":
+				case "int", "len":
 					nodeType = Int
 				case "float":
 					nodeType = Float
@@ -117,10 +119,15 @@ func DetectTypes(f *build.File) map[build.Expr]Type {
 					nodeType = Dict
 				case "list":
 					nodeType = List
+				case "Label":
+					nodeType = Label
 				}
 			} else if dot, ok := (node.X).(*build.DotExpr); ok {
 				if result[dot.X] == CtxActions && dot.Name == "args" {
 					nodeType = CtxActionsArgs
+				} else if dot.Name == "package_relative_label" {
+					// `native.package_relative_label(x)`
+					nodeType = Label
 				}
 			}
 		case *build.ParenExpr:
@@ -149,6 +156,9 @@ func DetectTypes(f *build.File) map[build.Expr]Type {
 		case *build.DotExpr:
 			if result[node.X] == Ctx && node.Name == "actions" {
 				nodeType = CtxActions
+			} else if node.Name == "label" {
+				// `ctx.label` or the label of a target, e.g. `ctx.attr.dep.label`
+				nodeType = Label
 			}
 		case *build.BinaryExpr:
 			switch node.Op {
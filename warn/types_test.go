@@ -65,6 +65,7 @@ b = True
 b2 = bool("hello")
 i = 3
 i2 = int(1.2)
+i3 = len(s)
 f = 1.2
 f2 = float(3)
 s = "string"
@@ -75,12 +76,16 @@ d2 = {foo: bar}
 d3 = dict(**foo)
 d4 = {k: v for k, v in foo}
 dep = depset(items=[s, d])
+l = Label("//foo:bar")
+l2 = native.package_relative_label(":bar")
+l3 = ctx.attr.dep.label
 foo = bar
 `, `
 b = bool:<True>
 b2 = bool:<bool(string:<"hello">)>
 i = int:<3>
 i2 = int:<int(float:<1.2>)>
+i3 = int:<len(s)>
 f = float:<1.2>
 f2 = float:<float(int:<3>)>
 s = string:<"string">
@@ -94,6 +99,9 @@ dep = depset:<depset(items = list:<[
     string:<s>,
     dict:<d>,
 ]>)>
+l = Label:<Label(string:<"//foo:bar">)>
+l2 = Label:<native.package_relative_label(string:<":bar">)>
+l3 = Label:<ctx.attr.dep.label>
 foo = bar
 `)
 }
@@ -22,6 +22,7 @@ import (
 	"log"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/edit"
@@ -48,6 +49,9 @@ type LinterFinding struct {
 	Message     string
 	URL         string
 	Replacement []LinterReplacement
+	// Metrics are the measurements of the metrics warnings, e.g. the number of targets of a
+	// BUILD file, reported along with the message for dashboards.
+	Metrics map[string]int
 }
 
 // LinterReplacement is a low-level object returned by single fixer functions.
@@ -67,6 +71,11 @@ type Finding struct {
 	Actionable  bool
 	AutoFixable bool
 	Replacement *Replacement
+	// Generated is true if the finding is within a generated region of the file, see
+	// build.GeneratedRegions. The automatic fixes aren't applied in generated regions.
+	Generated bool
+	// Metrics are the measurements the finding is based on, see LinterFinding.Metrics.
+	Metrics map[string]int
 }
 
 // A Replacement is a suggested fix. Text between Start and End should be replaced with Content.
@@ -120,13 +129,17 @@ var RuleWarningMap = map[string]func(call *build.CallExpr, pkg string) *LinterFi
 var FileWarningMap = map[string]func(f *build.File) []*LinterFinding{
 	"attr-applicable_licenses":  attrApplicableLicensesWarning,
 	"attr-cfg":                  attrConfigurationWarning,
+	"attr-doc":                  attrDocWarning,
 	"attr-license":              attrLicenseWarning,
 	"attr-licenses":             attrLicensesWarning,
 	"attr-non-empty":            attrNonEmptyWarning,
 	"attr-output-default":       attrOutputDefaultWarning,
 	"attr-single-file":          attrSingleFileWarning,
+	"broad-exports":             broadExportsWarning,
 	"build-args-kwargs":         argsKwargsInBuildFilesWarning,
 	"bzl-visibility":            bzlVisibilityWarning,
+	"chained-comparison":        chainedComparisonWarning,
+	"config-setting":            configSettingWarning,
 	"confusing-name":            confusingNameWarning,
 	"constant-glob":             constantGlobWarning,
 	"ctx-actions":               ctxActionsWarning,
@@ -136,35 +149,62 @@ var FileWarningMap = map[string]func(f *build.File) []*LinterFinding{
 	"depset-union":              depsetUnionWarning,
 	"dict-method-named-arg":     dictMethodNamedArgWarning,
 	"dict-concatenation":        dictionaryConcatenationWarning,
+	"dict-iteration-order":      dictIterationOrderWarning,
+	"disallowed-statement":      disallowedStatementWarning,
 	"duplicated-name":           duplicatedNameWarning,
+	"exported-symbols":          exportedSymbolsWarning,
+	"fail-message":              failMessageWarning,
 	"filetype":                  fileTypeWarning,
 	"function-docstring":        functionDocstringWarning,
 	"function-docstring-header": functionDocstringHeaderWarning,
 	"function-docstring-args":   functionDocstringArgsWarning,
 	"function-docstring-return": functionDocstringReturnWarning,
+	"function-length":           functionLengthWarning,
+	"glob-context":              globContextWarning,
+	"glob-patterns":             globPatternsWarning,
 	"integer-division":          integerDivisionWarning,
 	"keyword-positional-params": keywordPositionalParametersWarning,
+	"label-string-comparison":   labelStringComparisonWarning,
+	"legacy-dict-method":        legacyDictMethodWarning,
+	"legacy-octal":              legacyOctalWarning,
+	"line-continuation":         lineContinuationWarning,
 	"list-append":               listAppendWarning,
 	"load":                      unusedLoadWarning,
+	"misplaced-attribute":       misplacedAttributeWarning,
+	"missing-comma":             missingCommaWarning,
+	"missing-native":            missingNativeWarning,
+	"mixed-type-comparison":     mixedTypeComparisonWarning,
 	"module-docstring":          moduleDocstringWarning,
+	"module-order":              moduleOrderWarning,
+	"module-version":            moduleVersionWarning,
 	"name-conventions":          nameConventionsWarning,
 	"native-build":              nativeInBuildFilesWarning,
 	"native-package":            nativePackageWarning,
+	"negative-repetition":       negativeRepetitionWarning,
 	"no-effect":                 noEffectWarning,
 	"output-group":              outputGroupWarning,
 	"overly-nested-depset":      overlyNestedDepsetWarning,
+	"oversized-build-file":      oversizedBuildFileWarning,
 	"package-name":              packageNameWarning,
+	"package-metadata":          packageMetadataWarning,
 	"package-on-top":            packageOnTopWarning,
+	"platform-constraints":      platformConstraintsWarning,
 	"print":                     printWarning,
 	"provider-params":           providerParamsWarning,
 	"redefined-variable":        redefinedVariableWarning,
+	"redundant-attr-label":      redundantAttrLabelWarning,
 	"repository-name":           repositoryNameWarning,
+	"rule-doc":                  ruleDocWarning,
 	"rule-impl-return":          ruleImplReturnWarning,
 	"return-value":              missingReturnValueWarning,
+	"select-default":            selectDefaultWarning,
+	"select-order":              selectOrderWarning,
 	"skylark-comment":           skylarkCommentWarning,
 	"skylark-docstring":         skylarkDocstringWarning,
+	"stale-keep":                staleKeepWarning,
 	"string-iteration":          stringIterationWarning,
 	"uninitialized":             uninitializedVariableWarning,
+	"unknown-attribute":         unknownAttributeWarning,
 	"unreachable":               unreachableStatementWarning,
 	"unsorted-dict-items":       unsortedDictItemsWarning,
 	"unused-variable":           unusedVariableWarning,
@@ -172,9 +212,15 @@ var FileWarningMap = map[string]func(f *build.File) []*LinterFinding{
 
 // MultiFileWarningMap lists the warnings that run on the whole file, but may use other files.
 var MultiFileWarningMap = map[string]func(f *build.File, fileReader *FileReader) []*LinterFinding{
+	"alias-chain":                        aliasChainWarning,
+	"canonical-load-label":               canonicalLoadLabelWarning,
 	"deprecated-function":                deprecatedFunctionWarning,
 	"git-repository":                     nativeGitRepositoryWarning,
 	"http-archive":                       nativeHTTPArchiveWarning,
+	"macro-depth":                        macroDepthWarning,
+	"macro-kwargs-forwarding":            macroKwargsForwardingWarning,
+	"missing-source-file":                missingSourceFileWarning,
+	"module-extension-tag":               moduleExtensionTagWarning,
 	"native-android":                     nativeAndroidRulesWarning,
 	"native-cc-binary":                   NativeCcRulesWarning("cc_binary"),
 	"native-cc-import":                   NativeCcRulesWarning("cc_import"),
@@ -217,13 +263,51 @@ var MultiFileWarningMap = map[string]func(f *build.File, fileReader *FileReader)
 	"native-sh-binary":                   NativeShellRulesWarning("sh_binary"),
 	"native-sh-library":                  NativeShellRulesWarning("sh_library"),
 	"native-sh-test":                     NativeShellRulesWarning("sh_test"),
+	"reexported-load":                    reexportedLoadWarning,
+	"source-root-layout":                 sourceRootLayoutWarning,
+	"test-suite-membership":              testSuiteMembershipWarning,
 	"unnamed-macro":                      unnamedMacroWarning,
+	"unresolved-load":                    unresolvedLoadWarning,
+	"unused-bazel-dep":                   unusedBazelDepWarning,
 }
 
 // nonDefaultWarnings contains warnings that are enabled by default because they're not applicable
 // for all files and cause too much diff noise when applied.
 var nonDefaultWarnings = map[string]bool{
-	"unsorted-dict-items": true, // dict items should be sorted
+	"alias-chain":             true, // reads the BUILD files of other packages, targets created by macros may be reported as missing
+	"attr-doc":                true, // many attributes are self-explanatory, documenting all of them is a per-repository choice
+	"broad-exports":           true, // existing exports are often relied upon by other packages
+	"canonical-load-label":    true, // the canonical form is a per-repository choice
+	"chained-comparison":      true, // Python 2 cleanup, see PythonCleanupWarnings
+	"dict-iteration-order":    true, // only relevant for files evaluated by legacy Starlark interpreters
+	"exported-symbols":        true, // large public APIs are often kept for backward compatibility
+	"function-length":         true, // the statement limit depends on the repository's coding style
+	"glob-context":            true, // relies on heuristics about the functions the file defines
+	"glob-patterns":           true, // rewrites pattern lists that generated files may keep in their own order
+	"label-string-comparison": true, // relies on heuristic type detection, some string comparisons are only used in messages
+	"legacy-dict-method":      true, // Python 2 cleanup, see PythonCleanupWarnings
+	"legacy-octal":            true, // Python 2 cleanup, see PythonCleanupWarnings
+	"line-continuation":       true, // Python 2 cleanup, see PythonCleanupWarnings
+	"macro-depth":             true, // loads the .bzl files of other packages, layered macros are often deliberate
+	"macro-kwargs-forwarding": true, // helper targets are often deliberately not testonly
+	"misplaced-attribute":     true, // the fix deletes attributes that tools reading the BUILD files, e.g. test runners, may still use
+	"missing-source-file":     true, // files generated by macros are reported as missing
+	"module-order":            true, // moves statements, which causes too much diff noise in existing files
+	"oversized-build-file":    true, // splitting packages changes the labels other packages depend on
+	"package-metadata":        true, // only applicable if PackageMetadataRoots is configured
+	"platform-constraints":    true, // the constraints every platform must set are a per-repository choice
+	"redundant-attr-label":    true, // custom rules with the same kind prefix may use the attributes differently
+	"reexported-load":         true, // re-exports are sometimes the intended public entry point
+	"rule-doc":                true, // only rule sets published with Stardoc need the docs, see DocCoverage
+	"select-default":          true, // many selects are deliberately exhaustive
+	"select-order":            true, // some flags are deliberately appended after configurable ones
+	"source-root-layout":      true, // only applicable if SourceRoots is configured
+	"stale-keep":              true, // needs the used deps of the targets, see UsedDeps
+	"test-suite-membership":   true, // manual tests are often deliberately excluded from test suites
+	"unknown-attribute":       true, // the bundled schema of native rules may be outdated
+	"unresolved-load":         true, // only applicable if the workspace root is known, and may be slow in large workspaces
+	"unsorted-dict-items":     true, // dict items should be sorted
+	"unused-bazel-dep":        true, // repositories referenced by tools other than BUILD and .bzl files are reported
 }
 
 // fileWarningWrapper is a wrapper that converts a file warning function to a generic function.
@@ -273,21 +357,25 @@ func ruleWarningWrapper(ruleWarning func(call *build.CallExpr, pkg string) *Lint
 }
 
 // runWarningsFunction runs a linter/fixer function over a file and applies the fixes conditionally
-func runWarningsFunction(category string, f *build.File, fct func(f *build.File, pkg string, fileReader *FileReader) []*LinterFinding, formatted *[]byte, mode LintMode, fileReader *FileReader) []*Finding {
+func runWarningsFunction(category string, f *build.File, fct func(f *build.File, pkg string, fileReader *FileReader) []*LinterFinding, formatted *[]byte, mode LintMode, fileReader *FileReader, regions []build.GeneratedRegion) []*Finding {
 	findings := []*Finding{}
 	for _, w := range fct(f, f.Pkg, fileReader) {
 		if !DisabledWarning(f, w.Start.Line, category) {
 			finding := makeFinding(f, w.Start, w.End, category, w.URL, w.Message, true, len(w.Replacement) > 0, nil)
+			finding.Generated = build.InGeneratedRegion(regions, w.Start.Line)
+			finding.Metrics = w.Metrics
 			if len(w.Replacement) > 0 {
 				// An automatic fix exists
-				switch mode {
-				case ModeFix:
+				switch {
+				case mode == ModeFix && finding.Generated:
+					// Keep the finding, the generated code is overwritten by its generator
+				case mode == ModeFix:
 					// Apply the fix and discard the finding
 					for _, r := range w.Replacement {
 						*r.Old = r.New
 					}
 					finding = nil
-				case ModeSuggest:
+				case mode == ModeSuggest:
 					// Apply the fix, calculate the diff and roll back the fix
 					newContents := formatWithFix(f, &w.Replacement)
 
@@ -360,13 +448,14 @@ func FileWarnings(f *build.File, enabledWarnings []string, formatted *[]byte, mo
 		formatted = &contents
 	}
 
+	regions := build.GeneratedRegions(f)
 	for _, warn := range warnings {
 		if fct, ok := FileWarningMap[warn]; ok {
-			findings = append(findings, runWarningsFunction(warn, f, fileWarningWrapper(fct), formatted, mode, fileReader)...)
+			findings = append(findings, runWarningsFunction(warn, f, fileWarningWrapper(fct), formatted, mode, fileReader, regions)...)
 		} else if fct, ok := MultiFileWarningMap[warn]; ok {
-			findings = append(findings, runWarningsFunction(warn, f, multiFileWarningWrapper(fct), formatted, mode, fileReader)...)
+			findings = append(findings, runWarningsFunction(warn, f, multiFileWarningWrapper(fct), formatted, mode, fileReader, regions)...)
 		} else if fct, ok := RuleWarningMap[warn]; ok {
-			findings = append(findings, runWarningsFunction(warn, f, ruleWarningWrapper(fct), formatted, mode, fileReader)...)
+			findings = append(findings, runWarningsFunction(warn, f, ruleWarningWrapper(fct), formatted, mode, fileReader, regions)...)
 		} else {
 			log.Printf("unexpected warning %q", warn)
 			os.Exit(1)
@@ -464,3 +553,169 @@ func collectDefaultWarnings() []string {
 
 // DefaultWarnings is the list of all warnings that should be used inside google3
 var DefaultWarnings = collectDefaultWarnings()
+
+// warningGroups lists the warnings of each group that can be enabled or disabled as a whole,
+// e.g. with `--warnings=+performance,-style`. A warning may belong to several groups. The
+// native-* warnings are added to the migration group by collectWarningGroups.
+var warningGroups = map[string][]string{
+	"bzlmod": {
+		"canonical-load-label",
+		"git-repository",
+		"http-archive",
+		"label-string-comparison",
+		"module-extension-tag",
+		"module-order",
+		"module-version",
+		"unused-bazel-dep",
+	},
+	"correctness": {
+		"alias-chain",
+		"broad-exports",
+		"build-args-kwargs",
+		"bzl-visibility",
+		"config-setting",
+		"constant-glob",
+		"dict-iteration-order",
+		"disallowed-statement",
+		"duplicated-name",
+		"glob-context",
+		"keyword-positional-params",
+		"macro-kwargs-forwarding",
+		"misplaced-attribute",
+		"missing-comma",
+		"missing-native",
+		"missing-source-file",
+		"mixed-type-comparison",
+		"module-extension-tag",
+		"module-version",
+		"negative-repetition",
+		"no-effect",
+		"package-on-top",
+		"platform-constraints",
+		"redefined-variable",
+		"redundant-attr-label",
+		"return-value",
+		"rule-impl-return",
+		"select-default",
+		"select-order",
+		"source-root-layout",
+		"test-suite-membership",
+		"uninitialized",
+		"unknown-attribute",
+		"unnamed-macro",
+		"unreachable",
+		"unresolved-load",
+	},
+	"migration": {
+		"attr-applicable_licenses",
+		"attr-cfg",
+		"attr-license",
+		"attr-licenses",
+		"attr-non-empty",
+		"attr-output-default",
+		"attr-single-file",
+		"chained-comparison",
+		"ctx-actions",
+		"ctx-args",
+		"deprecated-function",
+		"depset-items",
+		"dict-method-named-arg",
+		"filetype",
+		"integer-division",
+		"legacy-dict-method",
+		"legacy-octal",
+		"line-continuation",
+		"output-group",
+		"package-metadata",
+		"package-name",
+		"repository-name",
+		"skylark-comment",
+		"skylark-docstring",
+		"string-iteration",
+	},
+	"performance": {
+		"depset-iteration",
+		"depset-union",
+		"dict-concatenation",
+		"list-append",
+		"overly-nested-depset",
+	},
+	"style": {
+		"attr-doc",
+		"canonical-load-label",
+		"confusing-name",
+		"exported-symbols",
+		"fail-message",
+		"function-docstring",
+		"function-docstring-args",
+		"function-docstring-header",
+		"function-docstring-return",
+		"function-length",
+		"glob-patterns",
+		"load",
+		"macro-depth",
+		"module-docstring",
+		"module-order",
+		"name-conventions",
+		"oversized-build-file",
+		"positional-args",
+		"print",
+		"provider-params",
+		"reexported-load",
+		"rule-doc",
+		"stale-keep",
+		"unsorted-dict-items",
+		"unused-variable",
+	},
+}
+
+func collectWarningGroups() map[string][]string {
+	groups := make(map[string][]string)
+	for group, warnings := range warningGroups {
+		groups[group] = append([]string{}, warnings...)
+	}
+	for _, warning := range AllWarnings {
+		if strings.HasPrefix(warning, "native-") {
+			groups["migration"] = append(groups["migration"], warning)
+		}
+	}
+	for _, warnings := range groups {
+		sort.Strings(warnings)
+	}
+	return groups
+}
+
+// WarningGroups maps the names of the warning groups (bzlmod, correctness, migration,
+// performance and style) to the sorted lists of their warnings.
+var WarningGroups = collectWarningGroups()
+
+// RenamedWarnings maps the former names of the warnings that were renamed or split to the
+// warnings replacing them. The former names are still accepted by the -warnings flag, and
+// `buildifier -migrate_config` replaces them in the configuration files.
+var RenamedWarnings = map[string][]string{
+	"native-cc": {
+		"native-cc-binary",
+		"native-cc-import",
+		"native-cc-library",
+		"native-cc-objc-import",
+		"native-cc-objc-library",
+		"native-cc-shared-library",
+		"native-cc-test",
+		"native-cc-toolchain",
+		"native-cc-toolchain-suite",
+		"native-cc-fdo-prefetch-hints",
+		"native-cc-fdo-profile",
+		"native-cc-memprof-profile",
+		"native-cc-propeller-optimize",
+	},
+	"native-java": {
+		"native-java-binary",
+		"native-java-import",
+		"native-java-library",
+		"native-java-plugin",
+		"native-java-test",
+		"native-java-package-config",
+		"native-java-runtime",
+		"native-java-toolchain",
+	},
+}
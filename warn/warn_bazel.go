@@ -20,9 +20,18 @@ package warn
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/bzlenv"
+	"github.com/bazelbuild/buildtools/edit"
+	"github.com/bazelbuild/buildtools/labels"
+	"github.com/bazelbuild/buildtools/lang"
+	"github.com/bazelbuild/buildtools/tables"
 )
 
 var functionsWithPositionalArguments = map[string]bool{
@@ -95,6 +104,427 @@ func constantGlobWarning(f *build.File) []*LinterFinding {
 	return findings
 }
 
+// globArgs returns the include and exclude pattern lists of a glob call, or nil for patterns that
+// are missing or not given as a list literal.
+func globArgs(call *build.CallExpr) (include, exclude *build.ListExpr) {
+	for i, arg := range call.List {
+		if assign, ok := arg.(*build.AssignExpr); ok {
+			key, ok := assign.LHS.(*build.Ident)
+			if !ok {
+				continue
+			}
+			switch key.Name {
+			case "include":
+				include, _ = assign.RHS.(*build.ListExpr)
+			case "exclude":
+				exclude, _ = assign.RHS.(*build.ListExpr)
+			}
+		} else if i == 0 {
+			include, _ = arg.(*build.ListExpr)
+		}
+	}
+	return include, exclude
+}
+
+// globPatterns returns the values of the patterns in the list, or false if any of them is not a
+// string literal.
+func globPatterns(list *build.ListExpr) ([]string, bool) {
+	var patterns []string
+	for _, expr := range list.List {
+		str, ok := expr.(*build.StringExpr)
+		if !ok {
+			return nil, false
+		}
+		patterns = append(patterns, str.Value)
+	}
+	return patterns, true
+}
+
+// normalizeGlobPattern collapses consecutive "**" segments of a glob pattern, they are equivalent
+// to a single one.
+func normalizeGlobPattern(pattern string) string {
+	var segments []string
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment == "**" && len(segments) > 0 && segments[len(segments)-1] == "**" {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// globSegmentsIntersect reports whether some path segment is matched by both segment patterns.
+func globSegmentsIntersect(a, b string) bool {
+	switch {
+	case a == "" || b == "":
+		return strings.Trim(a, "*") == "" && strings.Trim(b, "*") == ""
+	case a[0] == '*':
+		// The wildcard either matches nothing or consumes the first character of b.
+		return globSegmentsIntersect(a[1:], b) || globSegmentsIntersect(a, b[1:])
+	case b[0] == '*':
+		return globSegmentsIntersect(a, b[1:]) || globSegmentsIntersect(a[1:], b)
+	default:
+		return a[0] == b[0] && globSegmentsIntersect(a[1:], b[1:])
+	}
+}
+
+// globPatternsIntersect reports whether some path is matched by both glob patterns.
+func globPatternsIntersect(a, b []string) bool {
+	switch {
+	case len(a) == 0 || len(b) == 0:
+		return (len(a) == 0 || a[0] == "**" && globPatternsIntersect(a[1:], b)) &&
+			(len(b) == 0 || b[0] == "**" && globPatternsIntersect(a, b[1:]))
+	case a[0] == "**":
+		return globPatternsIntersect(a[1:], b) || globPatternsIntersect(a, b[1:])
+	case b[0] == "**":
+		return globPatternsIntersect(a, b[1:]) || globPatternsIntersect(a[1:], b)
+	default:
+		return globSegmentsIntersect(a[0], b[0]) && globPatternsIntersect(a[1:], b[1:])
+	}
+}
+
+// globSegmentContains reports whether every path segment matched by inner is also matched by outer.
+// The check is conservative: wildcards of inner can only be matched by wildcards of outer.
+func globSegmentContains(outer, inner string) bool {
+	switch {
+	case outer == "":
+		return inner == ""
+	case outer[0] == '*':
+		return globSegmentContains(outer[1:], inner) || inner != "" && globSegmentContains(outer, inner[1:])
+	default:
+		return inner != "" && inner[0] != '*' && outer[0] == inner[0] && globSegmentContains(outer[1:], inner[1:])
+	}
+}
+
+// globPatternContains reports whether every path matched by inner is also matched by outer.
+func globPatternContains(outer, inner []string) bool {
+	switch {
+	case len(outer) == 0:
+		return len(inner) == 0
+	case outer[0] == "**":
+		return globPatternContains(outer[1:], inner) || len(inner) > 0 && globPatternContains(outer, inner[1:])
+	default:
+		return len(inner) > 0 && inner[0] != "**" && globSegmentContains(outer[0], inner[0]) &&
+			globPatternContains(outer[1:], inner[1:])
+	}
+}
+
+// globPatternListWarning checks a list of glob patterns for invalid, duplicate, redundant and
+// unsorted patterns.
+func globPatternListWarning(list *build.ListExpr, listExpr *build.Expr) []*LinterFinding {
+	if _, ok := globPatterns(list); !ok {
+		return nil
+	}
+	var findings, fixable []*LinterFinding
+
+	// The fix normalizes, deduplicates and sorts the patterns.
+	fixed := *list
+	fixed.List = nil
+	seen := make(map[string]bool)
+	for _, expr := range list.List {
+		str := expr.(*build.StringExpr)
+		for _, segment := range strings.Split(str.Value, "/") {
+			if segment != "**" && strings.Contains(segment, "**") {
+				findings = append(findings, makeLinterFinding(str,
+					fmt.Sprintf(`Glob pattern %q is invalid, "**" must be a whole path segment.`, str.Value)))
+				break
+			}
+		}
+		normalized := normalizeGlobPattern(str.Value)
+		if seen[normalized] {
+			fixable = append(fixable, makeLinterFinding(str,
+				fmt.Sprintf(`Glob pattern %q is listed more than once.`, str.Value)))
+			continue
+		}
+		seen[normalized] = true
+		if normalized != str.Value {
+			fixable = append(fixable, makeLinterFinding(str,
+				fmt.Sprintf(`Glob pattern %q has consecutive "**" segments, use %q instead.`, str.Value, normalized)))
+			newStr := *str
+			newStr.Value = normalized
+			newStr.Token = ""
+			str = &newStr
+		}
+		fixed.List = append(fixed.List, str)
+	}
+	less := func(i, j int) bool {
+		return fixed.List[i].(*build.StringExpr).Value < fixed.List[j].(*build.StringExpr).Value
+	}
+	if !sort.SliceIsSorted(fixed.List, less) {
+		fixable = append(fixable, makeLinterFinding(list, "Glob patterns should be sorted."))
+		sort.SliceStable(fixed.List, less)
+	}
+	for _, finding := range fixable {
+		finding.Replacement = []LinterReplacement{{listExpr, &fixed}}
+	}
+	findings = append(findings, fixable...)
+
+	// Redundant patterns are reported but not removed: removing them doesn't change the matched
+	// files, but they may be listed on purpose.
+	for _, innerExpr := range fixed.List {
+		inner := innerExpr.(*build.StringExpr).Value
+		for _, outerExpr := range fixed.List {
+			outer := outerExpr.(*build.StringExpr).Value
+			if inner != outer && globPatternContains(strings.Split(outer, "/"), strings.Split(inner, "/")) {
+				findings = append(findings, makeLinterFinding(innerExpr,
+					fmt.Sprintf(`Glob pattern %q is already matched by %q.`, inner, outer)))
+				break
+			}
+		}
+	}
+	return findings
+}
+
+func globPatternsWarning(f *build.File) []*LinterFinding {
+	switch f.Type {
+	case build.TypeBuild, build.TypeWorkspace, build.TypeBzl:
+	default:
+		// Not applicable
+		return nil
+	}
+
+	var findings []*LinterFinding
+	build.WalkPointers(f, func(expr *build.Expr, stack []build.Expr) {
+		call, ok := (*expr).(*build.CallExpr)
+		if !ok {
+			return
+		}
+		if ident, ok := call.X.(*build.Ident); !ok || ident.Name != "glob" {
+			return
+		}
+		include, exclude := globArgs(call)
+		for i := range call.List {
+			arg := &call.List[i]
+			if assign, ok := (*arg).(*build.AssignExpr); ok {
+				arg = &assign.RHS
+			}
+			if list, ok := (*arg).(*build.ListExpr); ok && (list == include || list == exclude) {
+				findings = append(findings, globPatternListWarning(list, arg)...)
+			}
+		}
+
+		// Exclude patterns that don't intersect with any include pattern have no effect.
+		if include == nil || exclude == nil {
+			return
+		}
+		includePatterns, ok := globPatterns(include)
+		if !ok {
+			return
+		}
+		for _, expr := range exclude.List {
+			str, ok := expr.(*build.StringExpr)
+			if !ok {
+				continue
+			}
+			matches := false
+			for _, pattern := range includePatterns {
+				if globPatternsIntersect(strings.Split(pattern, "/"), strings.Split(str.Value, "/")) {
+					matches = true
+					break
+				}
+			}
+			if !matches {
+				findings = append(findings, makeLinterFinding(str,
+					fmt.Sprintf(`Exclude pattern %q can't match any file matched by the include patterns.`, str.Value)))
+			}
+		}
+	})
+	return findings
+}
+
+// A globContext describes the functions of a .bzl file that run outside of the evaluation of
+// BUILD files, where glob() isn't available.
+type globContext struct {
+	description string
+	guidance    string
+}
+
+// globContexts maps the functions that take an implementation function to the context it runs in.
+var globContexts = map[string]globContext{
+	"rule": {"a rule implementation",
+		"Declare the files in a label_list attribute with allow_files = True and use ctx.files instead."},
+	"aspect": {"an aspect implementation",
+		"Use the files of the attributes of the rule the aspect is applied to (ctx.rule.files) instead."},
+	"repository_rule": {"a repository rule implementation",
+		"List the files with repository_ctx.path(...).readdir(), or use glob() in the BUILD files the repository rule creates."},
+	"module_extension": {"a module extension implementation",
+		"List the files with module_ctx.path(...).readdir(), or use glob() in the BUILD files of the repositories the extension creates."},
+}
+
+// workspaceGlobContext is the context of the macros that instantiate repository rules, which are
+// called from WORKSPACE files.
+var workspaceGlobContext = globContext{"a WORKSPACE macro",
+	"Use glob() in the BUILD file of the repository instead, e.g. in its build_file_content."}
+
+// isGlobCall reports whether the expression is a call to glob() or native.glob().
+func isGlobCall(expr build.Expr) bool {
+	call, ok := expr.(*build.CallExpr)
+	if !ok {
+		return false
+	}
+	switch x := call.X.(type) {
+	case *build.Ident:
+		return x.Name == "glob"
+	case *build.DotExpr:
+		ident, ok := x.X.(*build.Ident)
+		return ok && ident.Name == "native" && x.Name == "glob"
+	}
+	return false
+}
+
+// calledFunctions returns the names of the functions called with plain identifiers in a function.
+func calledFunctions(def *build.DefStmt) []string {
+	var names []string
+	build.Walk(def, func(expr build.Expr, stack []build.Expr) {
+		if call, ok := expr.(*build.CallExpr); ok {
+			if ident, ok := call.X.(*build.Ident); ok {
+				names = append(names, ident.Name)
+			}
+		}
+	})
+	return names
+}
+
+func globContextWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	defs := make(map[string]*build.DefStmt)
+	for _, stmt := range f.Stmt {
+		if def, ok := stmt.(*build.DefStmt); ok {
+			defs[def.Name] = def
+		}
+	}
+
+	// The repository rules defined in the file or loaded from @bazel_tools, the macros that
+	// call them are WORKSPACE macros.
+	repositoryRules := make(map[string]bool)
+	for _, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.LoadStmt:
+			// e.g. http_archive, git_repository or maybe
+			if strings.HasPrefix(strings.TrimLeft(stmt.Module.Value, "@"), "bazel_tools//tools/build_defs/repo:") {
+				for _, to := range stmt.To {
+					repositoryRules[to.Name] = true
+				}
+			}
+		case *build.AssignExpr:
+			if _, ok := isFunctionCall(stmt.RHS, "repository_rule"); !ok {
+				continue
+			}
+			if lhs, ok := stmt.LHS.(*build.Ident); ok {
+				repositoryRules[lhs.Name] = true
+			}
+		}
+	}
+
+	// The functions that can't call glob(), with their context and the function that makes them
+	// run in it, if they're called from it rather than being the implementation themselves.
+	type reason struct {
+		context globContext
+		root    string
+	}
+	reasons := make(map[string]reason)
+	var queue []string
+	addRoot := func(name string, context globContext) {
+		if _, ok := defs[name]; !ok {
+			return
+		}
+		if _, ok := reasons[name]; ok {
+			return
+		}
+		reasons[name] = reason{context, name}
+		queue = append(queue, name)
+	}
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		call, ok := expr.(*build.CallExpr)
+		if !ok {
+			return
+		}
+		ident, ok := call.X.(*build.Ident)
+		if !ok {
+			return
+		}
+		context, ok := globContexts[ident.Name]
+		if !ok {
+			return
+		}
+		var impl build.Expr
+		if _, _, param := getParam(call.List, "implementation"); param != nil {
+			impl = param.RHS
+		} else if len(call.List) > 0 {
+			impl = call.List[0]
+		}
+		if name, ok := impl.(*build.Ident); ok {
+			addRoot(name.Name, context)
+		}
+	})
+	for _, stmt := range f.Stmt {
+		def, ok := stmt.(*build.DefStmt)
+		if !ok {
+			continue
+		}
+		build.Walk(def, func(expr build.Expr, stack []build.Expr) {
+			call, ok := expr.(*build.CallExpr)
+			if !ok {
+				return
+			}
+			switch x := call.X.(type) {
+			case *build.Ident:
+				if repositoryRules[x.Name] {
+					addRoot(def.Name, workspaceGlobContext)
+				}
+			case *build.DotExpr:
+				if ident, ok := x.X.(*build.Ident); ok && ident.Name == "native" &&
+					(x.Name == "bind" || strings.HasSuffix(x.Name, "_repository")) {
+					addRoot(def.Name, workspaceGlobContext)
+				}
+			}
+		})
+	}
+	// Propagate the contexts to the functions defined in the file that are called from them.
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, callee := range calledFunctions(defs[name]) {
+			if _, ok := defs[callee]; !ok {
+				continue
+			}
+			if _, ok := reasons[callee]; ok {
+				continue
+			}
+			reasons[callee] = reasons[name]
+			queue = append(queue, callee)
+		}
+	}
+
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		def, ok := stmt.(*build.DefStmt)
+		if !ok {
+			continue
+		}
+		r, ok := reasons[def.Name]
+		if !ok {
+			continue
+		}
+		via := ""
+		if r.root != def.Name {
+			via = fmt.Sprintf(" (it's called from %q)", r.root)
+		}
+		build.Walk(def, func(expr build.Expr, stack []build.Expr) {
+			if !isGlobCall(expr) {
+				return
+			}
+			findings = append(findings, makeLinterFinding(expr, fmt.Sprintf(
+				`glob() is only available while BUILD files are evaluated, but %q runs as %s%s. %s`,
+				def.Name, r.context.description, via, r.context.guidance)))
+		})
+	}
+	return findings
+}
+
 func nativeInBuildFilesWarning(f *build.File) []*LinterFinding {
 	if f.Type != build.TypeBuild {
 		return nil
@@ -120,6 +550,85 @@ func nativeInBuildFilesWarning(f *build.File) []*LinterFinding {
 	return findings
 }
 
+// BazelVersion is the version of Bazel the files are used with, e.g. 7.4.0, which decides the
+// functions the native module provides. An empty version means the latest one.
+var BazelVersion string
+
+// bazelVersionAtLeast reports whether BazelVersion is the given version or a later one.
+func bazelVersionAtLeast(version string) bool {
+	if BazelVersion == "" || version == "" {
+		return true
+	}
+	parse := func(v string) []int {
+		var numbers []int
+		for _, part := range strings.Split(v, ".") {
+			// Pre-release suffixes are ignored, e.g. 8.0.0rc1 is 8.0.0.
+			n, err := strconv.Atoi(strings.TrimRight(part, "abcdefghijklmnopqrstuvwxyz-"))
+			if err != nil {
+				break
+			}
+			numbers = append(numbers, n)
+		}
+		return numbers
+	}
+	have, want := parse(BazelVersion), parse(version)
+	for i, w := range want {
+		h := 0
+		if i < len(have) {
+			h = have[i]
+		}
+		if h != w {
+			return h > w
+		}
+	}
+	return true
+}
+
+func missingNativeWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	var walk func(expr *build.Expr, env *bzlenv.Environment)
+	walk = func(expr *build.Expr, env *bzlenv.Environment) {
+		defer bzlenv.WalkOnceWithEnvironment(*expr, env, walk)
+
+		call, ok := (*expr).(*build.CallExpr)
+		if !ok {
+			return
+		}
+		ident, ok := call.X.(*build.Ident)
+		if !ok || env.Get(ident.Name) != nil {
+			return
+		}
+		since, ok := tables.NativeModuleFunctions[ident.Name]
+		if !ok || !bazelVersionAtLeast(since) {
+			return
+		}
+		newCall := *call
+		newCall.X = &build.DotExpr{X: &build.Ident{Name: "native"}, Name: ident.Name}
+		findings = append(findings, makeLinterFinding(ident,
+			fmt.Sprintf(`Function %q is only global in BUILD files, use "native.%s" in .bzl files.`, ident.Name, ident.Name),
+			LinterReplacement{expr, &newCall}))
+	}
+	var expr build.Expr = f
+	walk(&expr, bzlenv.NewEnvironment())
+	return findings
+}
+
+func disallowedStatementWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	for _, err := range build.Validate(f) {
+		findings = append(findings, &LinterFinding{
+			Start:   err.Start,
+			End:     err.End,
+			Message: err.Message,
+		})
+	}
+	return findings
+}
+
 func nativePackageWarning(f *build.File) []*LinterFinding {
 	if f.Type != build.TypeBzl {
 		return nil
@@ -222,12 +731,61 @@ func argsKwargsInBuildFilesWarning(f *build.File) []*LinterFinding {
 	return findings
 }
 
+// RemovePrints makes the print warning remove the print() calls used as statements when the
+// warnings are fixed. They're only reported by default, since they may be intended.
+var RemovePrints = false
+
+// printStatementFixes returns the replacements removing the print() calls used as statements.
+// A block that only consists of print() calls keeps a pass statement, with their comments.
+func printStatementFixes(f *build.File) map[*build.CallExpr]LinterReplacement {
+	fixes := make(map[*build.CallExpr]LinterReplacement)
+	fixBlock := func(stmts []build.Expr, needsStatement bool) {
+		last := -1
+		var comments []build.Comment
+		for i, stmt := range stmts {
+			if call, ok := isFunctionCall(stmt, "print"); ok {
+				fixes[call] = LinterReplacement{&stmts[i], nil}
+				comments = append(comments, call.Comments.Before...)
+				last = i
+			} else if stmt != nil {
+				needsStatement = false
+			}
+		}
+		if needsStatement && last != -1 {
+			call := stmts[last].(*build.CallExpr)
+			start, _ := call.Span()
+			pass := &build.BranchStmt{Token: "pass", TokenPos: start, Comments: build.Comments{Before: comments}}
+			fixes[call] = LinterReplacement{&stmts[last], pass}
+		}
+	}
+	build.WalkStatements(f, func(expr build.Expr, stack []build.Expr) error {
+		switch expr := expr.(type) {
+		case *build.File:
+			// An empty file is valid.
+			fixBlock(expr.Stmt, false)
+		case *build.DefStmt:
+			fixBlock(expr.Body, true)
+		case *build.ForStmt:
+			fixBlock(expr.Body, true)
+		case *build.IfStmt:
+			fixBlock(expr.True, true)
+			fixBlock(expr.False, true)
+		}
+		return nil
+	})
+	return fixes
+}
+
 func printWarning(f *build.File) []*LinterFinding {
 	if f.Type == build.TypeDefault {
 		// Only applicable to Bazel files
 		return nil
 	}
 
+	var fixes map[*build.CallExpr]LinterReplacement
+	if RemovePrints {
+		fixes = printStatementFixes(f)
+	}
 	findings := []*LinterFinding{}
 	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
 		call, ok := expr.(*build.CallExpr)
@@ -238,8 +796,1547 @@ func printWarning(f *build.File) []*LinterFinding {
 		if !ok || ident.Name != "print" {
 			return
 		}
-		findings = append(findings,
-			makeLinterFinding(expr, `"print()" is a debug function and shouldn't be submitted.`))
+		finding := makeLinterFinding(expr, `"print()" is a debug function and shouldn't be submitted.`)
+		if fix, ok := fixes[call]; ok {
+			finding.Replacement = []LinterReplacement{fix}
+		}
+		findings = append(findings, finding)
+	})
+	return findings
+}
+
+// failMessage returns the message of a fail() call, or nil if it has none. The second result is
+// false if the message can't be checked, e.g. for fail(*args) or if it consists of several
+// positional arguments.
+func failMessage(call *build.CallExpr) (build.Expr, bool) {
+	var msg build.Expr
+	positional := 0
+	for _, arg := range call.List {
+		switch arg := arg.(type) {
+		case *build.AssignExpr:
+			if ident, ok := arg.LHS.(*build.Ident); ok && ident.Name == "msg" {
+				msg = arg.RHS
+			}
+		case *build.UnaryExpr:
+			if arg.Op == "*" || arg.Op == "**" {
+				return nil, false
+			}
+			msg = arg
+			positional++
+		default:
+			msg = arg
+			positional++
+		}
+	}
+	return msg, positional <= 1
+}
+
+// isNonStringLiteral checks whether an expression is a literal value other than a string.
+func isNonStringLiteral(expr build.Expr) bool {
+	switch expr := expr.(type) {
+	case *build.LiteralExpr, *build.ListExpr, *build.DictExpr, *build.TupleExpr, *build.Comprehension:
+		return true
+	case *build.Ident:
+		return expr.Name == "None" || expr.Name == "True" || expr.Name == "False"
+	}
+	return false
+}
+
+func failMessageWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		call, ok := isFunctionCall(expr, "fail")
+		if !ok {
+			return
+		}
+		msg, ok := failMessage(call)
+		if !ok {
+			return
+		}
+		switch {
+		case msg == nil:
+			findings = append(findings, makeLinterFinding(call,
+				`The "fail()" call has no message, explain what went wrong and how to fix it.`))
+		case isNonStringLiteral(msg):
+			findings = append(findings, makeLinterFinding(msg,
+				fmt.Sprintf(`The message of the "fail()" call is %s instead of a string explaining what went wrong.`, build.FormatString(msg))))
+		default:
+			if str, ok := msg.(*build.StringExpr); ok && strings.TrimSpace(str.Value) == "" {
+				findings = append(findings, makeLinterFinding(msg,
+					`The message of the "fail()" call is empty, explain what went wrong and how to fix it.`))
+			}
+		}
+	})
+	return findings
+}
+
+// PackageMetadataRoots lists the package roots (e.g. "third_party") under which every BUILD file
+// is expected to declare license metadata, see packageMetadataWarning.
+var PackageMetadataRoots []string
+
+// packageMetadataLoad is the module that provides the license() rule used by the autofix.
+const packageMetadataLoad = "@rules_license//rules:license.bzl"
+
+// isUnderRoots checks whether a package is located under one of the roots.
+func isUnderRoots(pkg string, roots []string) bool {
+	for _, root := range roots {
+		root = strings.Trim(strings.TrimPrefix(root, "//"), "/")
+		if root == "" || pkg == root || strings.HasPrefix(pkg, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnderPackageMetadataRoots checks whether a package is located under one of PackageMetadataRoots.
+func isUnderPackageMetadataRoots(pkg string) bool {
+	return isUnderRoots(pkg, PackageMetadataRoots)
+}
+
+func packageMetadataWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild || !isUnderPackageMetadataRoots(f.Pkg) {
+		return nil
+	}
+
+	// New statements are inserted after the package declaration or, if there's none,
+	// after the leading comments, docstring and load statements.
+	pkgIndex := -1
+	insertIndex := 0
+	inHeader := true
+	for i, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.CommentBlock, *build.LoadStmt, *build.StringExpr:
+			if inHeader {
+				insertIndex = i + 1
+			}
+			continue
+		case *build.CallExpr:
+			rule := f.Rule(stmt)
+			if rule.Kind() == "license" {
+				return nil
+			}
+			if rule.Kind() == "package" && pkgIndex < 0 {
+				if rule.Attr("default_applicable_licenses") != nil || rule.Attr("default_package_metadata") != nil {
+					return nil
+				}
+				pkgIndex = i
+				insertIndex = i + 1
+			}
+		}
+		inHeader = false
+	}
+
+	// Insert placeholders for the new statements, they're replaced by the fix.
+	newPackage := &build.CallExpr{X: &build.Ident{Name: "package"}}
+	if pkgIndex >= 0 {
+		newPackage = f.Stmt[pkgIndex].Copy().(*build.CallExpr)
+		newPackage.List = append([]build.Expr{}, newPackage.List...)
+	}
+	newPackage.List = append(newPackage.List, &build.AssignExpr{
+		LHS: &build.Ident{Name: "default_applicable_licenses"},
+		Op:  "=",
+		RHS: &build.ListExpr{List: []build.Expr{&build.StringExpr{Value: ":license"}}},
 	})
+	license := &build.CallExpr{
+		X: &build.Ident{Name: "license"},
+		List: []build.Expr{
+			&build.AssignExpr{LHS: &build.Ident{Name: "name"}, Op: "=", RHS: &build.StringExpr{Value: "license"}},
+			&build.AssignExpr{LHS: &build.Ident{Name: "license_kinds"}, Op: "=", RHS: &build.ListExpr{}},
+			&build.AssignExpr{LHS: &build.Ident{Name: "license_text"}, Op: "=", RHS: &build.StringExpr{Value: "LICENSE"}},
+		},
+		ForceMultiLine: true,
+	}
+
+	placeholders := 1
+	if pkgIndex < 0 {
+		placeholders = 2
+	}
+	stmts := append([]build.Expr{}, f.Stmt[:insertIndex]...)
+	stmts = append(stmts, make([]build.Expr, placeholders)...)
+	stmts = append(stmts, f.Stmt[insertIndex:]...)
+	f.Stmt = stmts
+
+	loadReplacement := insertLoad(f, packageMetadataLoad, []string{"license"})
+	if loadReplacement != nil && *loadReplacement.Old == nil {
+		// A new load statement has been inserted before the placeholders
+		insertIndex++
+		if pkgIndex >= 0 {
+			pkgIndex++
+		}
+	}
+
+	var replacements []LinterReplacement
+	if loadReplacement != nil {
+		replacements = append(replacements, *loadReplacement)
+	}
+	if pkgIndex >= 0 {
+		replacements = append(replacements,
+			LinterReplacement{&f.Stmt[pkgIndex], newPackage},
+			LinterReplacement{&f.Stmt[insertIndex], license})
+	} else {
+		replacements = append(replacements,
+			LinterReplacement{&f.Stmt[insertIndex], newPackage},
+			LinterReplacement{&f.Stmt[insertIndex+1], license})
+	}
+
+	message := fmt.Sprintf(`Packages under %q should declare license metadata, either with `+
+		`"package(default_applicable_licenses = ...)" or with a "license()" target.`, strings.Join(PackageMetadataRoots, ", "))
+	finding := &LinterFinding{
+		Start:       build.Position{Line: 1, LineRune: 1},
+		End:         build.Position{Line: 1, LineRune: 1},
+		Message:     message,
+		Replacement: replacements,
+	}
+	if pkgIndex >= 0 {
+		finding.Start, finding.End = f.Stmt[pkgIndex].Span()
+	}
+	return []*LinterFinding{finding}
+}
+
+// ExportsVisibilityRoots lists the package roots (e.g. "third_party") under which exports_files()
+// calls must restrict the visibility of the exported files, see broadExportsWarning.
+var ExportsVisibilityRoots []string
+
+func broadExportsWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	for _, rule := range f.Rules("exports_files") {
+		srcs := rule.Attr("srcs")
+		if srcs == nil && len(rule.Call.List) > 0 {
+			if _, ok := rule.Call.List[0].(*build.AssignExpr); !ok {
+				srcs = rule.Call.List[0]
+			}
+		}
+		switch srcs := srcs.(type) {
+		case *build.CallExpr:
+			if ident, ok := srcs.X.(*build.Ident); ok && ident.Name == "glob" {
+				findings = append(findings, makeLinterFinding(srcs,
+					`"exports_files(glob(...))" exports every matching file, including the files added later. List the exported files explicitly.`))
+			}
+		case *build.ListExpr:
+			for _, src := range srcs.List {
+				str, ok := src.(*build.StringExpr)
+				if !ok {
+					continue
+				}
+				base := path.Base(str.Value)
+				switch {
+				case strings.ContainsAny(str.Value, "*?["):
+					findings = append(findings, makeLinterFinding(str,
+						fmt.Sprintf(`%q looks like a glob pattern but "exports_files()" doesn't expand patterns. List the exported files explicitly.`, str.Value)))
+				case base == "BUILD" || base == "BUILD.bazel":
+					findings = append(findings, makeLinterFinding(str,
+						fmt.Sprintf(`The BUILD file %q shouldn't be exported, other packages shouldn't depend on the definition of the package.`, str.Value)))
+				case strings.HasSuffix(base, ".bzl"):
+					findings = append(findings, makeLinterFinding(str,
+						fmt.Sprintf(`The .bzl file %q doesn't need to be exported to be loaded. Use a "bzl_library" target to make it available to other rules.`, str.Value)))
+				}
+			}
+		}
+
+		if rule.Attr("visibility") == nil && isUnderRoots(f.Pkg, ExportsVisibilityRoots) {
+			findings = append(findings, makeLinterFinding(rule.Call,
+				fmt.Sprintf(`"exports_files()" without a visibility makes the files public, packages under %q should restrict the visibility of the exported files.`, strings.Join(ExportsVisibilityRoots, ", "))))
+		}
+	}
+	return findings
+}
+
+// SelectDefaultFix selects the autofix of the select-default warning: "empty" adds a
+// "//conditions:default" branch with an empty value of the type of the other branches, "error"
+// adds a no_match_error argument instead.
+var SelectDefaultFix = "empty"
+
+// exhaustiveSelectDirective marks the selects that are intentionally exhaustive.
+const exhaustiveSelectDirective = "@exhaustive-select"
+
+// emptyValue returns an empty value of the type of the given values, or nil if their type isn't
+// a list, a dict or a string.
+func emptyValue(values []build.Expr) build.Expr {
+	kind := ""
+	for _, value := range values {
+		var k string
+		switch value.(type) {
+		case *build.ListExpr:
+			k = "list"
+		case *build.DictExpr:
+			k = "dict"
+		case *build.StringExpr:
+			k = "string"
+		default:
+			return nil
+		}
+		if kind != "" && k != kind {
+			return nil
+		}
+		kind = k
+	}
+	switch kind {
+	case "list":
+		return &build.ListExpr{}
+	case "dict":
+		return &build.DictExpr{}
+	case "string":
+		return &build.StringExpr{}
+	}
+	return nil
+}
+
+func selectDefaultWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	build.WalkPointers(f, func(expr *build.Expr, stack []build.Expr) {
+		call, ok := (*expr).(*build.CallExpr)
+		if !ok || len(call.List) == 0 {
+			return
+		}
+		if ident, ok := call.X.(*build.Ident); !ok || ident.Name != "select" {
+			return
+		}
+		dict, ok := call.List[0].(*build.DictExpr)
+		if !ok {
+			return
+		}
+		var values []build.Expr
+		for _, item := range dict.List {
+			if key, ok := item.Key.(*build.StringExpr); ok && key.Value == "//conditions:default" {
+				return
+			}
+			values = append(values, item.Value)
+		}
+		for _, arg := range call.List[1:] {
+			if assign, ok := arg.(*build.AssignExpr); ok {
+				if lhs, ok := assign.LHS.(*build.Ident); ok && lhs.Name == "no_match_error" {
+					return
+				}
+			}
+		}
+		for _, e := range append([]build.Expr{call, dict}, stack...) {
+			if edit.ContainsComments(e, exhaustiveSelectDirective) {
+				return
+			}
+		}
+
+		var replacements []LinterReplacement
+		switch SelectDefaultFix {
+		case "empty":
+			if value := emptyValue(values); value != nil {
+				newDict := *dict
+				newDict.List = append(append([]*build.KeyValueExpr{}, dict.List...), &build.KeyValueExpr{
+					Key:   &build.StringExpr{Value: "//conditions:default"},
+					Value: value,
+				})
+				replacements = append(replacements, LinterReplacement{&call.List[0], &newDict})
+			}
+		case "error":
+			newCall := *call
+			newCall.List = append(append([]build.Expr{}, call.List...), &build.AssignExpr{
+				LHS: &build.Ident{Name: "no_match_error"},
+				Op:  "=",
+				RHS: &build.StringExpr{Value: "None of the conditions of this select() matches the configuration."},
+			})
+			replacements = append(replacements, LinterReplacement{expr, &newCall})
+		}
+		findings = append(findings, makeLinterFinding(call,
+			`The select() has no "//conditions:default" branch, it fails the analysis for the configurations `+
+				`none of its conditions match, e.g. on new platforms. Add a default branch, a "no_match_error" `+
+				`argument, or a "`+exhaustiveSelectDirective+`" comment if the select() is intentionally exhaustive.`,
+			replacements...))
+	})
+	return findings
+}
+
+// concatenationOperands returns the operands of a concatenation, e.g. `a`, `b` and `c` for
+// `a + b + c`.
+func concatenationOperands(expr build.Expr) []build.Expr {
+	if bin, ok := expr.(*build.BinaryExpr); ok && bin.Op == "+" {
+		return append(concatenationOperands(bin.X), concatenationOperands(bin.Y)...)
+	}
+	return []build.Expr{expr}
+}
+
+// selectConditions returns the conditions of a select() other than the default one, or nil
+// if expr isn't a select().
+func selectConditions(expr build.Expr) []string {
+	call, ok := expr.(*build.CallExpr)
+	if !ok || len(call.List) == 0 {
+		return nil
+	}
+	if ident, ok := call.X.(*build.Ident); !ok || ident.Name != "select" {
+		return nil
+	}
+	dict, ok := call.List[0].(*build.DictExpr)
+	if !ok {
+		return nil
+	}
+	conditions := []string{}
+	for _, item := range dict.List {
+		if key, ok := item.Key.(*build.StringExpr); ok && key.Value != "//conditions:default" {
+			conditions = append(conditions, key.Value)
+		}
+	}
+	return conditions
+}
+
+func selectOrderWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		call, ok := expr.(*build.CallExpr)
+		if !ok {
+			return
+		}
+		for _, arg := range call.List {
+			assign, ok := arg.(*build.AssignExpr)
+			if !ok {
+				continue
+			}
+			key, ok := assign.LHS.(*build.Ident)
+			if !ok || !tables.IsOrderSensitiveListArg[key.Name] {
+				continue
+			}
+			operands := concatenationOperands(assign.RHS)
+			var seen [][]string
+			for i, operand := range operands {
+				conditions := selectConditions(operand)
+				if conditions == nil {
+					continue
+				}
+				for _, next := range operands[i+1:] {
+					if list, ok := next.(*build.ListExpr); ok && len(list.List) > 0 {
+						findings = append(findings, makeLinterFinding(operand, fmt.Sprintf(
+							`The flags of the select() in %q come before the constant flags concatenated after it, `+
+								`which override them. Move the select() after the constant flags.`, key.Name)))
+						break
+					}
+				}
+			overlap:
+				for _, condition := range conditions {
+					for _, previous := range seen {
+						for _, c := range previous {
+							if labels.Equal(c, condition, f.Pkg) {
+								findings = append(findings, makeLinterFinding(operand, fmt.Sprintf(
+									`The condition %q is used by several select()s in %q, the order of their flags `+
+										`depends on the order of the select()s. Merge them into a single select().`,
+									condition, key.Name)))
+								break overlap
+							}
+						}
+					}
+				}
+				seen = append(seen, conditions)
+			}
+		}
+	})
+	return findings
+}
+
+// redundantAttrs lists the pairs of attributes of the rule kinds with the given prefixes that
+// shouldn't list the same label: the label is redundant in the drop attribute, because the keep
+// attribute already provides everything it's needed for.
+var redundantAttrs = []struct {
+	kindPrefixes []string
+	keep, drop   string
+}{
+	{[]string{"cc_", "objc_"}, "hdrs", "srcs"},
+	{[]string{"java_", "android_", "kt_jvm_"}, "deps", "runtime_deps"},
+	{[]string{"py_", "sh_"}, "srcs", "data"},
+}
+
+// unconditionalLabels returns the string literals of an attribute value that don't depend on
+// the configuration or on the file system, i.e. aren't within a select or a glob call.
+func unconditionalLabels(value build.Expr) []string {
+	var values []string
+	build.Walk(value, func(x build.Expr, stk []build.Expr) {
+		str, ok := x.(*build.StringExpr)
+		if !ok {
+			return
+		}
+		for _, parent := range stk {
+			if _, ok := parent.(*build.CallExpr); ok {
+				return
+			}
+		}
+		values = append(values, str.Value)
+	})
+	return values
+}
+
+func redundantAttrLabelWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	for _, r := range f.Rules("") {
+		for _, pair := range redundantAttrs {
+			matches := false
+			for _, prefix := range pair.kindPrefixes {
+				if strings.HasPrefix(r.Kind(), prefix) {
+					matches = true
+				}
+			}
+			defn := r.AttrDefn(pair.drop)
+			if !matches || defn == nil || r.Attr(pair.keep) == nil {
+				continue
+			}
+			kept := unconditionalLabels(r.Attr(pair.keep))
+
+			// Each list of the dropped attribute (e.g. the branches of a select) is fixed separately.
+			fixList := func(slot *build.Expr) {
+				list, ok := (*slot).(*build.ListExpr)
+				if !ok {
+					return
+				}
+				var redundant []string
+				newList := *list
+				newList.List = nil
+				for _, item := range list.List {
+					if str, ok := item.(*build.StringExpr); ok {
+						isKept := false
+						for _, label := range kept {
+							if labels.Equal(str.Value, label, f.Pkg) {
+								isKept = true
+							}
+						}
+						if isKept {
+							redundant = append(redundant, fmt.Sprintf("%q", str.Value))
+							continue
+						}
+					}
+					newList.List = append(newList.List, item)
+				}
+				if len(redundant) == 0 {
+					return
+				}
+				message := fmt.Sprintf("The labels %s of %q are already listed in %q of %q, remove them from %q.",
+					strings.Join(redundant, ", "), pair.drop, pair.keep, r.Name(), pair.drop)
+				if len(redundant) == 1 {
+					message = fmt.Sprintf("The label %s of %q is already listed in %q of %q, remove it from %q.",
+						redundant[0], pair.drop, pair.keep, r.Name(), pair.drop)
+				}
+				findings = append(findings, makeLinterFinding(list, message, LinterReplacement{slot, &newList}))
+			}
+			fixList(&defn.RHS)
+			build.WalkPointers(defn.RHS, func(x *build.Expr, stk []build.Expr) {
+				if len(stk) == 0 {
+					return // the value itself, already fixed
+				}
+				for _, parent := range stk {
+					if call, ok := parent.(*build.CallExpr); ok {
+						if ident, ok := call.X.(*build.Ident); !ok || ident.Name != "select" {
+							return // e.g. the patterns of a glob
+						}
+					}
+				}
+				fixList(x)
+			})
+		}
+	}
+	return findings
+}
+
+// staticTags returns the tags of a rule if they're a list of string literals.
+func staticTags(r *build.Rule) (tags []string, ok bool) {
+	expr := r.Attr("tags")
+	if expr == nil {
+		return nil, true
+	}
+	list, ok := expr.(*build.ListExpr)
+	if !ok {
+		return nil, false
+	}
+	for _, item := range list.List {
+		str, ok := item.(*build.StringExpr)
+		if !ok {
+			return nil, false
+		}
+		tags = append(tags, str.Value)
+	}
+	return tags, true
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasExplicitTests checks whether a test_suite lists its tests. An empty or missing "tests"
+// attribute means all the tests of the package that aren't tagged "manual".
+func hasExplicitTests(suite *build.Rule) bool {
+	expr := suite.Attr("tests")
+	if expr == nil {
+		return false
+	}
+	if list, ok := expr.(*build.ListExpr); ok {
+		return len(list.List) > 0
+	}
+	return true
+}
+
+// testFilterTags returns the tags of a test that the tags of a test_suite are matched against,
+// like Bazel does: its tags, its size ("medium" by default) and "flaky" if it's flaky. Returns
+// false if they can't be determined statically.
+func testFilterTags(r *build.Rule) (tags []string, ok bool) {
+	tags, ok = staticTags(r)
+	if !ok {
+		return nil, false
+	}
+	size := "medium"
+	if expr := r.Attr("size"); expr != nil {
+		str, ok := expr.(*build.StringExpr)
+		if !ok {
+			return nil, false
+		}
+		size = str.Value
+	}
+	tags = append(tags, size)
+	if expr := r.Attr("flaky"); expr != nil {
+		ident, ok := expr.(*build.Ident)
+		if !ok || ident.Name != "True" && ident.Name != "False" {
+			return nil, false
+		}
+		if ident.Name == "True" {
+			tags = append(tags, "flaky")
+		}
+	}
+	return tags, true
+}
+
+// matchesTestSuiteTags checks whether a test with the given filter tags (see testFilterTags) is
+// selected by the tags of a test_suite: it must have all the positive tags, optionally prefixed
+// with "+", and none of the negative ones, prefixed with "-". The "manual" tag is ignored.
+func matchesTestSuiteTags(suiteTags, tags []string) bool {
+	for _, tag := range suiteTags {
+		if tag == "manual" {
+			continue
+		}
+		if strings.HasPrefix(tag, "-") {
+			if hasTag(tags, tag[1:]) {
+				return false
+			}
+		} else if !hasTag(tags, strings.TrimPrefix(tag, "+")) {
+			return false
+		}
+	}
+	return true
+}
+
+// implicitTestSuiteTests returns the tests of the file that would be selected by a test_suite
+// without explicit tests if they weren't tagged "manual" (manual), and whether any test is
+// actually selected (nonEmpty). Tests with unknown tags are assumed to be selected.
+func implicitTestSuiteTests(f *build.File, suite *build.Rule) (manual []*build.Rule, nonEmpty bool) {
+	suiteTags, ok := staticTags(suite)
+	if !ok {
+		return nil, true
+	}
+	for _, r := range f.Rules("") {
+		if !strings.HasSuffix(r.Kind(), "_test") {
+			continue
+		}
+		tags, ok := testFilterTags(r)
+		if !ok {
+			nonEmpty = true
+			continue
+		}
+		if !matchesTestSuiteTags(suiteTags, tags) {
+			continue
+		}
+		if hasTag(tags, "manual") {
+			manual = append(manual, r)
+		} else {
+			nonEmpty = true
+		}
+	}
+	return manual, nonEmpty
+}
+
+// getBuildFile reads the BUILD file of a package.
+func getBuildFile(fileReader *FileReader, pkg string) *build.File {
+	if fileReader == nil {
+		return nil
+	}
+	for _, name := range []string{"BUILD.bazel", "BUILD"} {
+		if f := fileReader.GetFile(pkg, name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func testSuiteMembershipWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	reportedTests := make(map[*build.Rule]bool)
+	for _, suite := range f.Rules("test_suite") {
+		if !hasExplicitTests(suite) {
+			manual, nonEmpty := implicitTestSuiteTests(f, suite)
+			for _, test := range manual {
+				if reportedTests[test] {
+					continue
+				}
+				reportedTests[test] = true
+				findings = append(findings, makeLinterFinding(test.Call,
+					fmt.Sprintf(`The test ":%s" is tagged "manual" and is therefore silently excluded from the test_suite ":%s" which doesn't list its tests explicitly.`, test.Name(), suite.Name())))
+			}
+			if !nonEmpty {
+				findings = append(findings, makeLinterFinding(suite.Call,
+					fmt.Sprintf(`The test_suite ":%s" doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual", so it's empty.`, suite.Name())))
+			}
+			continue
+		}
+
+		// Check that the referenced test suites aren't empty.
+		list, ok := suite.Attr("tests").(*build.ListExpr)
+		if !ok {
+			continue
+		}
+		for _, item := range list.List {
+			str, ok := item.(*build.StringExpr)
+			if !ok {
+				continue
+			}
+			label := labels.ParseRelative(str.Value, f.Pkg)
+			if label.Repository != "" {
+				continue
+			}
+			buildFile := f
+			if label.Package != f.Pkg {
+				if buildFile = getBuildFile(fileReader, label.Package); buildFile == nil {
+					continue
+				}
+			}
+			for _, other := range buildFile.Rules("test_suite") {
+				if other.Name() != label.Target || hasExplicitTests(other) {
+					continue
+				}
+				if _, nonEmpty := implicitTestSuiteTests(buildFile, other); !nonEmpty {
+					findings = append(findings, makeLinterFinding(str,
+						fmt.Sprintf(`The test_suite %q is empty because it doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual".`, str.Value)))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// sourceFileAttributes are the attributes whose labels of the same package are checked by
+// missingSourceFileWarning.
+var sourceFileAttributes = []string{"srcs", "hdrs", "data"}
+
+// generatedFiles returns the names of the rules of a BUILD file and of the files declared as
+// their outputs, i.e. the targets of the package that aren't source files.
+func generatedFiles(f *build.File) map[string]bool {
+	generated := make(map[string]bool)
+	for _, r := range f.Rules("") {
+		if name := r.Name(); name != "" {
+			generated[name] = true
+		}
+		for _, key := range r.AttrKeys() {
+			if key != "out" && key != "outs" && !strings.HasSuffix(key, "_out") && !strings.HasSuffix(key, "_outs") {
+				continue
+			}
+			build.Walk(r.Attr(key), func(x build.Expr, _ []build.Expr) {
+				if str, ok := x.(*build.StringExpr); ok {
+					generated[str.Value] = true
+				}
+			})
+		}
+	}
+	return generated
+}
+
+// sourceStrings returns the string literals of an attribute value that refer to files, i.e.
+// the ones that aren't conditions of a select or patterns of a glob.
+func sourceStrings(expr build.Expr) []*build.StringExpr {
+	var strs []*build.StringExpr
+	build.Walk(expr, func(x build.Expr, stk []build.Expr) {
+		str, ok := x.(*build.StringExpr)
+		if !ok {
+			return
+		}
+		if len(stk) > 0 {
+			if kv, ok := stk[len(stk)-1].(*build.KeyValueExpr); ok && kv.Key == x {
+				return // a condition of a select
+			}
+		}
+		for _, parent := range stk {
+			if call, ok := parent.(*build.CallExpr); ok {
+				if ident, ok := call.X.(*build.Ident); ok && ident.Name == "glob" {
+					return
+				}
+			}
+		}
+		strs = append(strs, str)
+	})
+	return strs
+}
+
+func missingSourceFileWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+	if _, ok := fileReader.FileExists(f.Pkg, "."); !ok {
+		return nil
+	}
+
+	generated := generatedFiles(f)
+	var files []string
+	var findings []*LinterFinding
+	for _, r := range f.Rules("") {
+		for _, attr := range sourceFileAttributes {
+			for _, str := range sourceStrings(r.Attr(attr)) {
+				if strings.Contains(str.Value, "$") {
+					continue
+				}
+				label := labels.ParseRelative(str.Value, f.Pkg)
+				if label.Repository != "" || label.Package != f.Pkg || generated[label.Target] {
+					continue
+				}
+				if exists, _ := fileReader.FileExists(f.Pkg, label.Target); exists {
+					continue
+				}
+
+				message := fmt.Sprintf("The file %q in %q of %q doesn't exist in the package and isn't generated by a rule of this BUILD file.", label.Target, attr, r.Name())
+				if files == nil {
+					files = fileReader.PackageFiles(f.Pkg)
+				}
+				best, bestDistance := "", 3 // only suggest files differing by at most 2 edits
+				for _, file := range files {
+					if d := editDistance(label.Target, file); d < bestDistance {
+						best, bestDistance = file, d
+					}
+				}
+				if best != "" {
+					message += fmt.Sprintf(" Did you mean %q?", best)
+				}
+				findings = append(findings, makeLinterFinding(str, message))
+			}
+		}
+	}
+	return findings
+}
+
+// SourceRoots lists the source roots (e.g. "src/main/java" or "go=example.com/repo") the packages
+// are checked against by sourceRootLayoutWarning. A root can be followed by "=" and the import
+// path of its packages, used as the prefix of the Go import paths and of the proto import paths.
+var SourceRoots []string
+
+// sourceRoot returns the innermost source root containing a package, with its import path
+// prefix, and the path of the package relative to it. ok is false if the package isn't under
+// any of SourceRoots.
+func sourceRoot(pkg string) (root, prefix, rel string, ok bool) {
+	for _, entry := range SourceRoots {
+		r, p, _ := strings.Cut(entry, "=")
+		r = strings.Trim(strings.TrimPrefix(r, "//"), "/")
+		if !isUnderRoots(pkg, []string{r}) || ok && len(r) <= len(root) {
+			continue
+		}
+		root, prefix, ok = r, strings.Trim(p, "/"), true
+	}
+	if ok {
+		rel = strings.TrimPrefix(strings.TrimPrefix(pkg, root), "/")
+	}
+	return root, prefix, rel, ok
+}
+
+// javaPackageRE matches the package declaration of a Java file.
+var javaPackageRE = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+
+func sourceRootLayoutWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+	root, prefix, rel, ok := sourceRoot(f.Pkg)
+	if !ok {
+		return nil
+	}
+	rootName := root
+	if rootName == "" {
+		rootName = "//"
+	}
+
+	var findings []*LinterFinding
+	for _, r := range f.Rules("") {
+		kind := r.Kind()
+		switch {
+		case strings.HasPrefix(kind, "go_"):
+			// The Go import path is the location of the package under the root, after the prefix.
+			str, ok := r.Attr("importpath").(*build.StringExpr)
+			if !ok {
+				continue
+			}
+			if prefix != "" {
+				if want := path.Join(prefix, rel); str.Value != want {
+					findings = append(findings, makeLinterFinding(str, fmt.Sprintf(
+						`The importpath %q of %q doesn't match the location of the package under the source root %q, expected %q.`,
+						str.Value, r.Name(), rootName, want)))
+				}
+			} else if rel != "" && str.Value != rel && !strings.HasSuffix(str.Value, "/"+rel) {
+				findings = append(findings, makeLinterFinding(str, fmt.Sprintf(
+					`The importpath %q of %q doesn't match the location of the package under the source root %q, expected it to end with %q.`,
+					str.Value, r.Name(), rootName, rel)))
+			}
+
+		case strings.HasPrefix(kind, "java_") || strings.HasPrefix(kind, "android_"):
+			// The Java package of a source file is its directory under the root.
+			for _, str := range sourceStrings(r.Attr("srcs")) {
+				if !strings.HasSuffix(str.Value, ".java") {
+					continue
+				}
+				label := labels.ParseRelative(str.Value, f.Pkg)
+				if label.Repository != "" || label.Package != f.Pkg {
+					continue
+				}
+				m := javaPackageRE.FindSubmatch(fileReader.ReadSourceFile(f.Pkg, label.Target))
+				if m == nil {
+					continue
+				}
+				want := strings.ReplaceAll(path.Dir(path.Join(rel, label.Target)), "/", ".")
+				if want == "." {
+					want = ""
+				}
+				if got := string(m[1]); got != want {
+					findings = append(findings, makeLinterFinding(str, fmt.Sprintf(
+						`The Java package %q declared in %q doesn't match the location of the file under the source root %q, expected %q.`,
+						got, label.Target, rootName, want)))
+				}
+			}
+
+		case kind == "proto_library":
+			// The import path of the protos is their location, after strip_import_prefix is removed
+			// from it and import_prefix is added to it, which must be their location under the root.
+			strip, _ := r.Attr("strip_import_prefix").(*build.StringExpr)
+			importPrefix, _ := r.Attr("import_prefix").(*build.StringExpr)
+			if strip == nil && importPrefix == nil {
+				continue
+			}
+			got := f.Pkg
+			if strip != nil {
+				base := path.Join(f.Pkg, strip.Value)
+				if strings.HasPrefix(strip.Value, "/") {
+					base = strings.Trim(strip.Value, "/")
+				}
+				if !isUnderRoots(f.Pkg, []string{base}) {
+					continue
+				}
+				got = strings.TrimPrefix(strings.TrimPrefix(f.Pkg, base), "/")
+			}
+			if importPrefix != nil {
+				got = path.Join(strings.Trim(importPrefix.Value, "/"), got)
+			}
+			if want := path.Join(prefix, rel); got != want {
+				node := build.Expr(strip)
+				if strip == nil {
+					node = importPrefix
+				}
+				findings = append(findings, makeLinterFinding(node, fmt.Sprintf(
+					`The protos of %q are imported from %q, which doesn't match the location of the package under the source root %q, expected %q.`,
+					r.Name(), got, rootName, want)))
+			}
+		}
+	}
+	return findings
+}
+
+// currentRepoName returns the name of the current repository as configured in the tables or
+// declared in MODULE.bazel, or an empty string if it's unknown.
+func currentRepoName(fileReader *FileReader) string {
+	if tables.CanonicalLoadRepoName != "" || fileReader == nil {
+		return tables.CanonicalLoadRepoName
+	}
+	module := fileReader.GetFile("", "MODULE.bazel")
+	if module == nil {
+		return ""
+	}
+	for _, r := range module.Rules("module") {
+		return r.AttrString("name")
+	}
+	return ""
+}
+
+func canonicalLoadLabelWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	var findings []*LinterFinding
+	repo := currentRepoName(fileReader)
+	for i, stmt := range f.Stmt {
+		load, ok := stmt.(*build.LoadStmt)
+		if !ok || load.Module == nil {
+			continue
+		}
+		module := load.Module.Value
+		var label labels.Label
+		switch {
+		case strings.HasPrefix(module, "@@"):
+			// Canonical repository names are never written by hand.
+			continue
+		case strings.HasPrefix(module, "@"):
+			label = labels.Parse(module)
+			if label.Repository != "" && label.Repository != repo {
+				// A load from another repository
+				continue
+			}
+		case strings.HasPrefix(module, "//"):
+			label = labels.Parse(module)
+		default:
+			if f.WorkspaceRoot == "" {
+				// The package of the file is unknown, relative labels can't be resolved.
+				continue
+			}
+			label = labels.ParseRelative(module, f.Pkg)
+		}
+
+		label.Repository = ""
+		if tables.CanonicalLoadLabelForm == "repo" {
+			if repo == "" && strings.HasPrefix(module, "//") {
+				// The repository name is unknown, keep the label as is.
+				continue
+			}
+			label.Repository = repo
+		}
+		canonical := label.Format()
+		if canonical == module {
+			continue
+		}
+
+		newModule := *load.Module
+		newModule.Value = canonical
+		newModule.Token = ""
+		newLoad := *load
+		newLoad.Module = &newModule
+		findings = append(findings, makeLinterFinding(load.Module,
+			fmt.Sprintf("Load labels of the current repository should be written in the canonical form %q.", canonical),
+			LinterReplacement{&f.Stmt[i], &newLoad}))
+	}
+	return findings
+}
+
+// reexportStep is a symbol of a .bzl file.
+type reexportStep struct {
+	label labels.Label
+	name  string
+}
+
+// reexportSource checks whether the symbol is defined in the file as a re-export, i.e. by a
+// top-level assignment `name = alias` where alias is loaded from another file, and returns the
+// symbol it re-exports.
+func reexportSource(f *build.File, pkg, name string) (reexportStep, bool) {
+	alias := ""
+	for _, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.AssignExpr:
+			lhs, ok := stmt.LHS.(*build.Ident)
+			if !ok || lhs.Name != name {
+				continue
+			}
+			rhs, ok := stmt.RHS.(*build.Ident)
+			if !ok || alias != "" {
+				// Not a plain re-export, or the symbol is reassigned.
+				return reexportStep{}, false
+			}
+			alias = rhs.Name
+		case *build.DefStmt:
+			if stmt.Name == name {
+				return reexportStep{}, false
+			}
+		}
+	}
+	if alias == "" {
+		return reexportStep{}, false
+	}
+	for _, stmt := range f.Stmt {
+		load, ok := stmt.(*build.LoadStmt)
+		if !ok || load.Module == nil {
+			continue
+		}
+		for i, to := range load.To {
+			if to.Name == alias {
+				return reexportStep{labels.ParseRelative(load.Module.Value, pkg), load.From[i].Name}, true
+			}
+		}
+	}
+	return reexportStep{}, false
+}
+
+// followReexports follows the chain of re-exports of a symbol loaded from a .bzl file of the
+// current repository. It returns the symbols the chain passes through after the loaded one, the
+// last of them is where the symbol is defined. Files that can't be read end the chain.
+func followReexports(fileReader *FileReader, symbol reexportStep) []reexportStep {
+	var chain []reexportStep
+	visited := map[reexportStep]bool{symbol: true}
+	for symbol.label.Repository == "" {
+		f := fileReader.GetFile(symbol.label.Package, symbol.label.Target)
+		if f == nil {
+			break
+		}
+		next, ok := reexportSource(f, symbol.label.Package, symbol.name)
+		if !ok {
+			break
+		}
+		if visited[next] {
+			// A cycle, Bazel will fail to load the files anyway.
+			return nil
+		}
+		visited[next] = true
+		chain = append(chain, next)
+		symbol = next
+	}
+	return chain
+}
+
+func reexportedLoadWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if fileReader == nil {
+		return nil
+	}
+	switch f.Type {
+	case build.TypeBuild, build.TypeBzl:
+	default:
+		return nil
+	}
+
+	type loadFix struct {
+		index    int
+		stmts    []build.Expr
+		findings []*LinterFinding
+	}
+	var fixes []loadFix
+	extra := 0
+	repo := currentRepoName(fileReader)
+	for i, stmt := range f.Stmt {
+		load, ok := stmt.(*build.LoadStmt)
+		if !ok || load.Module == nil {
+			continue
+		}
+		module := load.Module.Value
+		label := labels.ParseRelative(module, f.Pkg)
+		if label.Repository != "" && label.Repository != repo {
+			continue
+		}
+		label.Repository = ""
+		if tables.CanonicalReexports[label.Format()] {
+			continue
+		}
+
+		fix := loadFix{index: i}
+		var kept []int
+		var targets []string
+		moved := make(map[string][]int)
+		newNames := make(map[int]string)
+		for j, from := range load.From {
+			chain := followReexports(fileReader, reexportStep{label, from.Name})
+			if len(chain) == 0 {
+				kept = append(kept, j)
+				continue
+			}
+			target := chain[len(chain)-1]
+			for _, step := range chain {
+				if tables.CanonicalReexports[step.label.Format()] {
+					target = step
+					break
+				}
+			}
+			targetModule := target.label.Format()
+			if !strings.HasPrefix(module, "//") && !strings.HasPrefix(module, "@") {
+				targetModule = target.label.FormatRelative(f.Pkg)
+			}
+			if _, ok := moved[targetModule]; !ok {
+				targets = append(targets, targetModule)
+			}
+			moved[targetModule] = append(moved[targetModule], j)
+			newNames[j] = target.name
+			fix.findings = append(fix.findings, makeLinterFinding(from,
+				fmt.Sprintf(`Symbol %q is re-exported by %q from %q, load it from there instead.`, from.Name, module, targetModule)))
+		}
+		if len(fix.findings) == 0 {
+			continue
+		}
+
+		if len(kept) > 0 {
+			newLoad := *load
+			newLoad.From, newLoad.To = nil, nil
+			for _, j := range kept {
+				newLoad.From = append(newLoad.From, load.From[j])
+				newLoad.To = append(newLoad.To, load.To[j])
+			}
+			fix.stmts = append(fix.stmts, &newLoad)
+		}
+		for _, targetModule := range targets {
+			var from, to []string
+			for _, j := range moved[targetModule] {
+				from = append(from, newNames[j])
+				to = append(to, load.To[j].Name)
+			}
+			newLoad := edit.NewLoad(targetModule, from, to)
+			if len(fix.stmts) == 0 {
+				// The first new load replaces the original one and keeps its comments.
+				newLoad.Comments = load.Comments
+			}
+			fix.stmts = append(fix.stmts, newLoad)
+		}
+		extra += len(fix.stmts) - 1
+		fixes = append(fixes, fix)
+	}
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	// The additional load statements replace nil placeholders inserted after the original ones.
+	if extra > 0 {
+		stmts := make([]build.Expr, 0, len(f.Stmt)+extra)
+		k := 0
+		for i, stmt := range f.Stmt {
+			stmts = append(stmts, stmt)
+			if k < len(fixes) && fixes[k].index == i {
+				fixes[k].index = len(stmts) - 1
+				for range fixes[k].stmts[1:] {
+					stmts = append(stmts, nil)
+				}
+				k++
+			}
+		}
+		f.Stmt = stmts
+	}
+
+	var findings []*LinterFinding
+	for _, fix := range fixes {
+		var replacements []LinterReplacement
+		for j, stmt := range fix.stmts {
+			replacements = append(replacements, LinterReplacement{&f.Stmt[fix.index+j], stmt})
+		}
+		for _, finding := range fix.findings {
+			finding.Replacement = replacements
+		}
+		findings = append(findings, fix.findings...)
+	}
+	return findings
+}
+
+// RuleAttributes lists the attributes of the native rules checked by the unknown-attribute
+// warning. It can be replaced with a schema read by lang.ReadAttributes.
+var RuleAttributes = lang.AttributesOf
+
+// commonAttributes can be set on all rules, even if the schema doesn't list them because it
+// predates them.
+var commonAttributes = map[string]bool{
+	"aspect_hints":               true,
+	"exec_group_compatible_with": true,
+	"package_metadata":           true,
+	"target_compatible_with":     true,
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// shadowedRuleNames returns the symbols loaded or defined in a file, calls to them aren't
+// native rules even if they have the name of one.
+func shadowedRuleNames(f *build.File) map[string]bool {
+	shadowed := make(map[string]bool)
+	for _, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.LoadStmt:
+			for _, to := range stmt.To {
+				shadowed[to.Name] = true
+			}
+		case *build.DefStmt:
+			shadowed[stmt.Name] = true
+		}
+	}
+	return shadowed
+}
+
+func unknownAttributeWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	// Loaded and locally defined symbols may be macros or Starlark rules with a different schema.
+	shadowed := shadowedRuleNames(f)
+
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		call, ok := stmt.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		ident, ok := call.X.(*build.Ident)
+		if !ok || shadowed[ident.Name] {
+			continue
+		}
+		attributes, ok := RuleAttributes[ident.Name]
+		if !ok {
+			continue
+		}
+		known := make(map[string]bool)
+		for _, attr := range attributes {
+			known[attr] = true
+		}
+		misplaced := misplacedAttributes(ident.Name)
+		for _, arg := range call.List {
+			as, ok := arg.(*build.AssignExpr)
+			if !ok {
+				continue
+			}
+			key, ok := as.LHS.(*build.Ident)
+			if !ok || known[key.Name] || commonAttributes[key.Name] {
+				continue
+			}
+			if _, ok := misplaced[key.Name]; ok {
+				// Reported by the misplaced-attribute warning.
+				continue
+			}
+			message := fmt.Sprintf("Rule %q has no attribute %q.", ident.Name, key.Name)
+			best, bestDistance := "", 3 // only suggest attributes differing by at most 2 edits
+			for _, attr := range attributes {
+				if d := editDistance(key.Name, attr); d < bestDistance {
+					best, bestDistance = attr, d
+				}
+			}
+			if best != "" {
+				message += fmt.Sprintf(" Did you mean %q?", best)
+			}
+			findings = append(findings, makeLinterFinding(key, message))
+		}
+	}
+	return findings
+}
+
+// misplacedAttributes returns the attributes that a native rule doesn't define but the binary or
+// test rules of the same language do, e.g. `stamp` for `cc_library`, mapped to the kinds that
+// define them.
+func misplacedAttributes(kind string) map[string][]string {
+	i := strings.LastIndex(kind, "_")
+	if i < 0 {
+		return nil
+	}
+	known := make(map[string]bool)
+	for _, attr := range RuleAttributes[kind] {
+		known[attr] = true
+	}
+	misplaced := make(map[string][]string)
+	for _, other := range []string{kind[:i] + "_binary", kind[:i] + "_test"} {
+		if other == kind {
+			continue
+		}
+		for _, attr := range RuleAttributes[other] {
+			if !known[attr] && !commonAttributes[attr] {
+				misplaced[attr] = append(misplaced[attr], other)
+			}
+		}
+	}
+	return misplaced
+}
+
+func misplacedAttributeWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	shadowed := shadowedRuleNames(f)
+
+	var findings []*LinterFinding
+	for i, stmt := range f.Stmt {
+		call, ok := stmt.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		ident, ok := call.X.(*build.Ident)
+		if !ok || shadowed[ident.Name] {
+			continue
+		}
+		misplaced := misplacedAttributes(ident.Name)
+		if len(misplaced) == 0 {
+			continue
+		}
+		// A single fix removes all the misplaced attributes of the rule.
+		newCall := *call
+		newCall.List = nil
+		var callFindings []*LinterFinding
+		for _, arg := range call.List {
+			if as, ok := arg.(*build.AssignExpr); ok {
+				if key, ok := as.LHS.(*build.Ident); ok {
+					if kinds, ok := misplaced[key.Name]; ok {
+						message := fmt.Sprintf("Attribute %q has no effect on %q, it's only meaningful on %s rules.",
+							key.Name, ident.Name, strings.Join(kinds, " and "))
+						callFindings = append(callFindings, makeLinterFinding(key, message))
+						continue
+					}
+				}
+			}
+			newCall.List = append(newCall.List, arg)
+		}
+		for _, finding := range callFindings {
+			finding.Replacement = []LinterReplacement{{&f.Stmt[i], &newCall}}
+		}
+		findings = append(findings, callFindings...)
+	}
+	return findings
+}
+
+// MaxAliasDepth is the number of aliases a chain of aliases may consist of before the
+// alias-chain warning reports the first one.
+var MaxAliasDepth = 2
+
+// findRuleNamed returns the rule of a BUILD file with the given name, or nil.
+func findRuleNamed(f *build.File, name string) *build.Rule {
+	for _, r := range f.Rules("") {
+		if r.Name() == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// mayDefineTarget reports whether a target that isn't a rule of a BUILD file may still be defined
+// by it: as an output of a rule, or as an implicit output or a target created by a macro, whose
+// names usually start with the name of the rule (e.g. foo_deploy.jar or libfoo.so for foo).
+func mayDefineTarget(f *build.File, name string) bool {
+	if generatedFiles(f)[name] {
+		return true
+	}
+	for _, r := range f.Rules("") {
+		if n := r.Name(); n != "" && (strings.HasPrefix(name, n) || strings.HasPrefix(name, "lib"+n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasActual returns the label an alias points at, or ok = false if it isn't an alias with a
+// literal label of the main repository as actual.
+func aliasActual(r *build.Rule, pkg string) (label labels.Label, ok bool) {
+	if r.Kind() != "alias" {
+		return label, false
+	}
+	actual, ok := r.Attr("actual").(*build.StringExpr)
+	if !ok || strings.Contains(actual.Value, "$") {
+		return label, false
+	}
+	label = labels.ParseRelative(actual.Value, pkg)
+	return label, label.Repository == "" && label.Target != ""
+}
+
+func aliasChainWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	// buildFile returns the BUILD file of a package, f itself for its own package.
+	buildFile := func(pkg string) *build.File {
+		if pkg == f.Pkg {
+			return f
+		}
+		return getBuildFile(fileReader, pkg)
+	}
+	format := func(label labels.Label) string {
+		return label.FormatRelative(f.Pkg)
+	}
+
+	var findings []*LinterFinding
+	for _, r := range f.Rules("alias") {
+		name := r.Name()
+		actual, ok := aliasActual(r, f.Pkg)
+		if name == "" || !ok {
+			continue
+		}
+		self := labels.Label{Package: f.Pkg, Target: name}
+		if isFile, _ := fileReader.IsFile(f.Pkg, name); isFile {
+			// A directory, e.g. of a subpackage, isn't shadowed.
+			findings = append(findings, makeLinterFinding(r.Call,
+				fmt.Sprintf(`The alias %q has the name of a file of the package, which it shadows: %q refers to the alias instead of the file.`, name, ":"+name)))
+		}
+		if actual == self {
+			findings = append(findings, makeLinterFinding(r.Attr("actual"),
+				fmt.Sprintf(`The alias %q points at itself.`, name)))
+			continue
+		}
+
+		// Follow the chain of aliases.
+		chain := []labels.Label{self}
+		visited := map[labels.Label]bool{self: true}
+		target := actual
+		for {
+			chain = append(chain, target)
+			file := buildFile(target.Package)
+			if file == nil {
+				// The package can't be read, the chain ends there.
+				break
+			}
+			rule := findRuleNamed(file, target.Target)
+			if rule == nil {
+				if len(chain) != 2 || mayDefineTarget(file, target.Target) {
+					// The missing targets of the other aliases of the chain are reported on them.
+					break
+				}
+				if exists, ok := fileReader.FileExists(target.Package, target.Target); !ok || exists {
+					break
+				}
+				findings = append(findings, makeLinterFinding(r.Attr("actual"),
+					fmt.Sprintf(`The alias %q points at %q, which doesn't exist.`, name, format(target))))
+				break
+			}
+			next, ok := aliasActual(rule, target.Package)
+			if !ok {
+				break
+			}
+			visited[target] = true
+			if visited[next] {
+				var names []string
+				for _, label := range append(chain, next) {
+					names = append(names, format(label))
+				}
+				findings = append(findings, makeLinterFinding(r.Attr("actual"),
+					fmt.Sprintf(`The chain of aliases of %q has a cycle: %s.`, name, strings.Join(names, " -> "))))
+				chain = nil
+				break
+			}
+			target = next
+		}
+
+		// The last label of the chain isn't an alias.
+		if depth := len(chain) - 1; depth > MaxAliasDepth {
+			var names []string
+			for _, label := range chain {
+				names = append(names, format(label))
+			}
+			findings = append(findings, makeLinterFinding(r.Attr("actual"),
+				fmt.Sprintf(`The alias %q resolves to %q through a chain of %d aliases (%s), more than %d. Point it directly at the target.`,
+					name, format(chain[len(chain)-1]), depth, strings.Join(names, " -> "), MaxAliasDepth)))
+		}
+	}
 	return findings
 }
@@ -193,3 +193,83 @@ func overlyNestedDepsetWarning(f *build.File) []*LinterFinding {
 	})
 	return findings
 }
+
+// stringifiedLabel returns the label converted to a string by expr, e.g. `ctx.label` for
+// `str(ctx.label)`, or nil if expr isn't such a conversion.
+func stringifiedLabel(expr build.Expr, types map[build.Expr]Type) build.Expr {
+	call, ok := expr.(*build.CallExpr)
+	if !ok || len(call.List) != 1 {
+		return nil
+	}
+	ident, ok := call.X.(*build.Ident)
+	if !ok || (ident.Name != "str" && ident.Name != "repr") {
+		return nil
+	}
+	if types[call.List[0]] != Label {
+		return nil
+	}
+	return call.List[0]
+}
+
+// isRepositoryName returns true if expr is the repository name of a label, e.g.
+// `ctx.label.workspace_name`.
+func isRepositoryName(expr build.Expr, types map[build.Expr]Type) bool {
+	dot, ok := expr.(*build.DotExpr)
+	if !ok || (dot.Name != "workspace_name" && dot.Name != "repo_name") {
+		return false
+	}
+	return types[dot.X] == Label
+}
+
+func labelStringComparisonWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+	var findings []*LinterFinding
+	types := DetectTypes(f)
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		switch expr := expr.(type) {
+		case *build.BinaryExpr:
+			switch expr.Op {
+			case "==", "!=", "in", "not in":
+			default:
+				return
+			}
+			if stringifiedLabel(expr.X, types) != nil || stringifiedLabel(expr.Y, types) != nil {
+				findings = append(findings, makeLinterFinding(expr,
+					`Labels compared as strings don't match when their repositories are spelled differently, e.g. by their canonical names under Bzlmod. Compare Label objects instead, e.g. "label == Label(\"//foo:bar\")".`))
+				return
+			}
+			for _, side := range []build.Expr{expr.X, expr.Y} {
+				// Comparing with the empty string checks for the main repository, which is fine.
+				if str, ok := side.(*build.StringExpr); ok && str.Value == "" {
+					return
+				}
+			}
+			if isRepositoryName(expr.X, types) || isRepositoryName(expr.Y, types) {
+				findings = append(findings, makeLinterFinding(expr,
+					`Repository names are the canonical names under Bzlmod (e.g. "rules_foo+" instead of "rules_foo"), don't compare them with strings. Compare them with the repository name of a Label instead, e.g. "label.repo_name == Label(\"@rules_foo\").repo_name".`))
+			}
+		case *build.CallExpr:
+			// `str(label).startswith("@rules_foo")`
+			dot, ok := expr.X.(*build.DotExpr)
+			if !ok || (dot.Name != "startswith" && dot.Name != "endswith") || stringifiedLabel(dot.X, types) == nil {
+				return
+			}
+			findings = append(findings, makeLinterFinding(expr,
+				`Labels matched as strings don't match when their repositories are spelled differently, e.g. by their canonical names under Bzlmod. Use the attributes of Label objects instead, e.g. "label.package".`))
+		case *build.KeyValueExpr:
+			// A dict item, in a dict or a dict comprehension
+			if stringifiedLabel(expr.Key, types) != nil {
+				findings = append(findings, makeLinterFinding(expr.Key,
+					`Labels converted to strings are ambiguous dict keys, use the Label objects as keys instead.`))
+			}
+		case *build.IndexExpr:
+			if stringifiedLabel(expr.Y, types) != nil {
+				findings = append(findings, makeLinterFinding(expr.Y,
+					`Labels converted to strings are ambiguous dict keys, use the Label objects as keys instead.`))
+			}
+		}
+	})
+	return findings
+}
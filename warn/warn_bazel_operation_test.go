@@ -192,3 +192,42 @@ for y in z:
 		},
 		scopeEverywhere)
 }
+
+func TestLabelStringComparison(t *testing.T) {
+	checkFindings(t, "label-string-comparison", `
+def _impl(ctx):
+    if str(ctx.label) == "@rules_foo//:bar":
+        pass
+    if "@rules_foo//:bar" != repr(ctx.attr.dep.label):
+        pass
+    lbl = Label("//foo:bar")
+    if str(lbl) in ALLOWED:
+        pass
+    if str(ctx.label).startswith("@rules_foo"):
+        pass
+    if ctx.label.workspace_name == "rules_foo":
+        pass
+    deps = {str(dep.label): dep for dep in ctx.attr.deps}
+    seen[str(ctx.label)] = True
+
+    # Ok
+    if ctx.label == Label("@rules_foo//:bar"):
+        pass
+    if ctx.label.workspace_name == "":
+        pass
+    if str(foo) == "bar":
+        pass
+    print("Building %s" % str(ctx.label))
+    names = {ctx.label.name: True}
+`,
+		[]string{
+			`:2: Labels compared as strings don't match`,
+			`:4: Labels compared as strings don't match`,
+			`:7: Labels compared as strings don't match`,
+			`:9: Labels matched as strings don't match`,
+			`:11: Repository names are the canonical names under Bzlmod`,
+			`:13: Labels converted to strings are ambiguous dict keys`,
+			`:14: Labels converted to strings are ambiguous dict keys`,
+		},
+		scopeBzl)
+}
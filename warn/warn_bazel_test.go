@@ -16,7 +16,14 @@ limitations under the License.
 
 package warn
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/tables"
+)
 
 func TestConstantGlob(t *testing.T) {
 	checkFindings(t, "constant-glob", `
@@ -42,6 +49,38 @@ cc_library(srcs = glob(exclude = ["bar.cc"], include = ["*.cc"]))`,
 		scopeBuild|scopeBzl|scopeWorkspace)
 }
 
+func TestGlobPatterns(t *testing.T) {
+	checkFindingsAndFix(t, "glob-patterns", `
+cc_library(srcs = glob(["*.h", "*.cc", "*.h"]))
+cc_library(srcs = glob(include = ["a/**/**/*.cc", "b/*.cc"]))
+cc_library(srcs = glob(["*.cc"], exclude = ["b.cc", "a.cc"]))
+cc_library(srcs = glob(["*.cc", "**/*.cc"]))
+cc_library(srcs = glob(["src/*.cc"], exclude = ["test/*.cc", "src/**/*_test.cc"]))
+cc_library(srcs = glob(["a**/*.cc"]))
+cc_library(srcs = glob(["*.cc", PATTERN, "*.cc"]))
+cc_library(srcs = glob(["*.cc", "*.h"], exclude = ["*_test.cc"]))
+`, `
+cc_library(srcs = glob(["*.cc", "*.h"]))
+cc_library(srcs = glob(include = ["a/**/*.cc", "b/*.cc"]))
+cc_library(srcs = glob(["*.cc"], exclude = ["a.cc", "b.cc"]))
+cc_library(srcs = glob(["**/*.cc", "*.cc"]))
+cc_library(srcs = glob(["src/*.cc"], exclude = ["src/**/*_test.cc", "test/*.cc"]))
+cc_library(srcs = glob(["a**/*.cc"]))
+cc_library(srcs = glob(["*.cc", PATTERN, "*.cc"]))
+cc_library(srcs = glob(["*.cc", "*.h"], exclude = ["*_test.cc"]))
+`, []string{
+		`:1: Glob pattern "*.h" is listed more than once.`,
+		`:1: Glob patterns should be sorted.`,
+		`:2: Glob pattern "a/**/**/*.cc" has consecutive "**" segments, use "a/**/*.cc" instead.`,
+		`:3: Glob patterns should be sorted.`,
+		`:4: Glob patterns should be sorted.`,
+		`:4: Glob pattern "*.cc" is already matched by "**/*.cc".`,
+		`:5: Glob patterns should be sorted.`,
+		`:5: Exclude pattern "test/*.cc" can't match any file matched by the include patterns.`,
+		`:6: Glob pattern "a**/*.cc" is invalid, "**" must be a whole path segment.`,
+	}, scopeBuild|scopeBzl|scopeWorkspace)
+}
+
 func TestNativeInBuildFiles(t *testing.T) {
 	checkFindingsAndFix(t, "native-build", `
 native.package("foo")
@@ -57,6 +96,60 @@ cc_library(name = "lib")
 	}, scopeBuild)
 }
 
+func TestMissingNative(t *testing.T) {
+	checkFindingsAndFix(t, "missing-native", `
+load(":defs.bzl", "genrule")
+
+def my_macro(name, filegroup = None):
+    native.alias(name = name + "_alias", actual = name)
+    genrule(name = name + "_gen")
+    filegroup(name = name + "_fg")
+    test_suite(
+        name = name,
+        tests = glob(["*_test.sh"]) + [package_relative_label(":foo")],
+    )
+
+def glob(patterns):
+    return patterns
+`, `
+load(":defs.bzl", "genrule")
+
+def my_macro(name, filegroup = None):
+    native.alias(name = name + "_alias", actual = name)
+    genrule(name = name + "_gen")
+    filegroup(name = name + "_fg")
+    native.test_suite(
+        name = name,
+        tests = glob(["*_test.sh"]) + [native.package_relative_label(":foo")],
+    )
+
+def glob(patterns):
+    return patterns
+`, []string{
+		`:7: Function "test_suite" is only global in BUILD files, use "native.test_suite" in .bzl files.`,
+		`:9: Function "package_relative_label" is only global in BUILD files, use "native.package_relative_label" in .bzl files.`,
+	}, scopeBzl)
+
+	// package_relative_label was added to the native module by Bazel 7.0.
+	defer func(old string) { BazelVersion = old }(BazelVersion)
+	BazelVersion = "6.5.0"
+	checkFindings(t, "missing-native", `
+def my_macro(name):
+    return [package_relative_label(name), native.package_name()]
+`, []string{}, scopeBzl)
+	BazelVersion = "7.0.0rc2"
+	checkFindingsAndFix(t, "missing-native", `
+def my_macro(name):
+    return [package_relative_label(name), package_name()]
+`, `
+def my_macro(name):
+    return [native.package_relative_label(name), native.package_name()]
+`, []string{
+		`:2: Function "package_relative_label" is only global in BUILD files, use "native.package_relative_label" in .bzl files.`,
+		`:2: Function "package_name" is only global in BUILD files, use "native.package_name" in .bzl files.`,
+	}, scopeBzl)
+}
+
 func TestNativePackage(t *testing.T) {
 	checkFindings(t, "native-package", `
 native.package("foo")
@@ -154,3 +247,1102 @@ def f(x):
 		},
 		scopeBazel)
 }
+
+func TestPrintWarningRemovePrints(t *testing.T) {
+	defer func(old bool) { RemovePrints = old }(RemovePrints)
+	RemovePrints = true
+
+	checkFindingsAndFix(t, "print", `
+print("top-level")
+
+def f(x):
+    # Debugging.
+    print(x)
+    g(x) or print("not g")
+    for y in x:
+        print(y)
+    if x:
+        print("a")
+        print("b")
+    else:
+        # Only a print.
+        print("c")
+`, `
+def f(x):
+    g(x) or print("not g")
+    for y in x:
+        pass
+    if x:
+        pass
+    else:
+        # Only a print.
+        pass
+`,
+		[]string{
+			`:1: "print()" is a debug function and shouldn't be submitted.`,
+			`:5: "print()" is a debug function and shouldn't be submitted.`,
+			`:6: "print()" is a debug function and shouldn't be submitted.`,
+			`:8: "print()" is a debug function and shouldn't be submitted.`,
+			`:10: "print()" is a debug function and shouldn't be submitted.`,
+			`:11: "print()" is a debug function and shouldn't be submitted.`,
+			`:14: "print()" is a debug function and shouldn't be submitted.`,
+		},
+		scopeBazel)
+}
+
+func TestFailMessageWarning(t *testing.T) {
+	checkFindings(t, "fail-message", `
+def f(x):
+    if x == 1:
+        fail()
+    if x == 2:
+        fail("")
+    if x == 3:
+        fail(msg = "  ")
+    if x == 4:
+        fail(None)
+    if x == 5:
+        fail(attr = "deps")
+    fail([x])
+`,
+		[]string{
+			`:3: The "fail()" call has no message, explain what went wrong and how to fix it.`,
+			`:5: The message of the "fail()" call is empty, explain what went wrong and how to fix it.`,
+			`:7: The message of the "fail()" call is empty, explain what went wrong and how to fix it.`,
+			`:9: The message of the "fail()" call is None instead of a string explaining what went wrong.`,
+			`:11: The "fail()" call has no message, explain what went wrong and how to fix it.`,
+			`:12: The message of the "fail()" call is [x] instead of a string explaining what went wrong.`,
+		},
+		scopeBzl)
+
+	checkFindings(t, "fail-message", `
+def f(x, msg, *args):
+    fail("x must be positive, got %d" % x)
+    fail(msg)
+    fail(msg = msg, attr = "deps")
+    fail(*args)
+    fail("", x)
+`,
+		[]string{},
+		scopeBzl)
+}
+
+func TestPackageMetadata(t *testing.T) {
+	defer func(old []string) { PackageMetadataRoots = old }(PackageMetadataRoots)
+
+	// Not configured
+	PackageMetadataRoots = nil
+	checkFindings(t, "package-metadata", `
+cc_library(name = "lib")
+`, []string{}, scopeBuild)
+
+	// The test package is not under the root
+	PackageMetadataRoots = []string{"third_party"}
+	checkFindings(t, "package-metadata", `
+cc_library(name = "lib")
+`, []string{}, scopeBuild)
+
+	PackageMetadataRoots = []string{"//test/"}
+	checkFindings(t, "package-metadata", `
+package(default_applicable_licenses = [":license"])
+
+cc_library(name = "lib")
+`, []string{}, scopeBuild)
+
+	checkFindings(t, "package-metadata", `
+package(default_package_metadata = ["//:metadata"])
+`, []string{}, scopeBuild)
+
+	checkFindings(t, "package-metadata", `
+load("@rules_license//rules:license.bzl", "license")
+
+license(
+    name = "license",
+    license_kinds = ["@rules_license//licenses/spdx:MIT"],
+)
+`, []string{}, scopeBuild)
+
+	checkFindingsAndFix(t, "package-metadata", `
+"""Docstring."""
+
+load(":foo.bzl", "foo")
+
+foo(name = "foo")
+`, `
+"""Docstring."""
+
+load("@rules_license//rules:license.bzl", "license")
+load(":foo.bzl", "foo")
+
+package(default_applicable_licenses = [":license"])
+
+license(
+    name = "license",
+    license_kinds = [],
+    license_text = "LICENSE",
+)
+
+foo(name = "foo")
+`, []string{
+		`:1: Packages under "//test/" should declare license metadata`,
+	}, scopeBuild)
+
+	checkFindingsAndFix(t, "package-metadata", `
+load("@rules_license//rules:license.bzl", "license")
+
+package(default_visibility = ["//visibility:public"])
+
+cc_library(name = "lib")
+`, `
+load("@rules_license//rules:license.bzl", "license")
+
+package(
+    default_applicable_licenses = [":license"],
+    default_visibility = ["//visibility:public"],
+)
+
+license(
+    name = "license",
+    license_kinds = [],
+    license_text = "LICENSE",
+)
+
+cc_library(name = "lib")
+`, []string{
+		`:3: Packages under "//test/" should declare license metadata`,
+	}, scopeBuild)
+}
+
+func TestBroadExports(t *testing.T) {
+	defer func(old []string) { ExportsVisibilityRoots = old }(ExportsVisibilityRoots)
+	ExportsVisibilityRoots = nil
+
+	checkFindings(t, "broad-exports", `
+exports_files(["foo.txt", "bar.txt"])
+
+exports_files(
+    srcs = ["baz.txt"],
+    visibility = ["//visibility:private"],
+)
+`, []string{}, scopeBuild)
+
+	checkFindings(t, "broad-exports", `
+exports_files(glob(["*.txt"]))
+
+exports_files(["*", "data/file?.txt", "ok.txt"])
+
+exports_files([
+    "BUILD",
+    "sub/BUILD.bazel",
+    "defs.bzl",
+])
+`, []string{
+		`:1: "exports_files(glob(...))" exports every matching file, including the files added later.`,
+		`:3: "*" looks like a glob pattern but "exports_files()" doesn't expand patterns.`,
+		`:3: "data/file?.txt" looks like a glob pattern but "exports_files()" doesn't expand patterns.`,
+		`:6: The BUILD file "BUILD" shouldn't be exported`,
+		`:7: The BUILD file "sub/BUILD.bazel" shouldn't be exported`,
+		`:8: The .bzl file "defs.bzl" doesn't need to be exported to be loaded.`,
+	}, scopeBuild)
+
+	// The test package is not under the root
+	ExportsVisibilityRoots = []string{"third_party"}
+	checkFindings(t, "broad-exports", `
+exports_files(["foo.txt"])
+`, []string{}, scopeBuild)
+
+	ExportsVisibilityRoots = []string{"//test"}
+	checkFindings(t, "broad-exports", `
+exports_files(["foo.txt"])
+
+exports_files(
+    ["bar.txt"],
+    visibility = ["//visibility:public"],
+)
+`, []string{
+		`:1: "exports_files()" without a visibility makes the files public, packages under "//test" should restrict the visibility of the exported files.`,
+	}, scopeBuild)
+}
+
+func TestSelectDefault(t *testing.T) {
+	defer func(old string) { SelectDefaultFix = old }(SelectDefaultFix)
+	SelectDefaultFix = "empty"
+
+	checkFindings(t, "select-default", `
+cc_library(
+    name = "lib",
+    srcs = select({
+        ":linux": ["linux.cc"],
+        "//conditions:default": [],
+    }),
+    copts = select(
+        {":linux": ["-DLINUX"]},
+        no_match_error = "Unsupported platform",
+    ),
+    # @exhaustive-select
+    deps = select({
+        ":linux": [":linux_deps"],
+        ":macos": [":macos_deps"],
+    }),
+    data = select(CONDITIONS),
+)
+`, []string{}, scopeEverywhere)
+
+	checkFindingsAndFix(t, "select-default", `
+cc_library(
+    name = "lib",
+    srcs = ["lib.cc"] + select({
+        ":linux": ["linux.cc"],
+        ":macos": ["macos.cc"],
+    }),
+    defines = select({
+        ":linux": {"OS": "linux"},
+    }),
+    linkstatic = select({
+        ":linux": True,
+    }),
+)
+`, `
+cc_library(
+    name = "lib",
+    srcs = ["lib.cc"] + select({
+        ":linux": ["linux.cc"],
+        ":macos": ["macos.cc"],
+        "//conditions:default": [],
+    }),
+    defines = select({
+        ":linux": {"OS": "linux"},
+        "//conditions:default": {},
+    }),
+    linkstatic = select({
+        ":linux": True,
+    }),
+)
+`, []string{
+		`:3: The select() has no "//conditions:default" branch`,
+		`:7: The select() has no "//conditions:default" branch`,
+		`:10: The select() has no "//conditions:default" branch`,
+	}, scopeEverywhere)
+
+	SelectDefaultFix = "error"
+	checkFindingsAndFix(t, "select-default", `
+def macro(name):
+    native.cc_library(
+        name = name,
+        srcs = select({
+            ":linux": ["linux.cc"],
+        }),
+    )
+`, `
+def macro(name):
+    native.cc_library(
+        name = name,
+        srcs = select({
+            ":linux": ["linux.cc"],
+        }, no_match_error = "None of the conditions of this select() matches the configuration."),
+    )
+`, []string{
+		`:4: The select() has no "//conditions:default" branch`,
+	}, scopeEverywhere)
+}
+
+func TestSelectOrder(t *testing.T) {
+	checkFindings(t, "select-order", `
+cc_library(
+    name = "a",
+    copts = select({
+        ":debug": ["-O0"],
+        "//conditions:default": [],
+    }) + ["-O2"],
+    linkopts = select({":opt": ["-s"]}) + select({"//test/package:opt": ["-Wl,-O1"]}) + select({":dbg": ["-g"]}),
+)
+
+cc_library(
+    name = "b",
+    copts = ["-O2"] + select({
+        ":debug": ["-O0"],
+        "//conditions:default": [],
+    }) + [],
+    srcs = select({":a": ["a.cc"]}) + ["b.cc"],
+    linkopts = select({":opt": ["-s"]}) + select({"//conditions:default": []}) + select({"//conditions:default": []}),
+)
+`,
+		[]string{
+			`:3: The flags of the select() in "copts" come before the constant flags concatenated after it`,
+			`:7: The condition "//test/package:opt" is used by several select()s in "linkopts"`,
+		},
+		scopeEverywhere)
+}
+
+func TestGlobContext(t *testing.T) {
+	checkFindings(t, "glob-context", `
+load("@bazel_tools//tools/build_defs/repo:http.bzl", "http_archive")
+
+def _srcs():
+    return native.glob(["*.cc"])
+
+def _impl(ctx):
+    srcs = _srcs()
+
+my_rule = rule(implementation = _impl)
+
+def _repo_impl(repository_ctx):
+    glob(["*"])
+
+my_repo = repository_rule(_repo_impl)
+
+def _ext_impl(module_ctx):
+    pass
+
+my_ext = module_extension(implementation = _ext_impl)
+
+def my_deps():
+    http_archive(name = "foo", build_file_content = str(native.glob(["*.BUILD"])))
+    my_repo(name = "bar")
+
+def my_macro(name):
+    native.cc_library(name = name, srcs = _srcs() + native.glob(["*.h"]))
+`,
+		[]string{
+			`:4: glob() is only available while BUILD files are evaluated, but "_srcs" runs as a rule implementation (it's called from "_impl"). Declare the files in a label_list attribute with allow_files = True and use ctx.files instead.`,
+			`:12: glob() is only available while BUILD files are evaluated, but "_repo_impl" runs as a repository rule implementation. List the files with repository_ctx.path(...).readdir(), or use glob() in the BUILD files the repository rule creates.`,
+			`:22: glob() is only available while BUILD files are evaluated, but "my_deps" runs as a WORKSPACE macro. Use glob() in the BUILD file of the repository instead, e.g. in its build_file_content.`,
+		},
+		scopeBzl)
+}
+
+func TestRedundantAttrLabel(t *testing.T) {
+	checkFindingsAndFix(t, "redundant-attr-label", `
+cc_library(
+    name = "lib",
+    srcs = [
+        "lib.cc",
+        "lib.h",
+        "//test/package:util.h",
+    ] + select({
+        ":arm": ["arm.h"],
+        "//conditions:default": glob(["lib.h"]),
+    }),
+    hdrs = ["lib.h", "util.h", "arm.h"],
+)
+
+cc_library(
+    name = "conditional",
+    srcs = ["a.h"],
+    hdrs = select({":x": ["a.h"]}),
+)
+
+java_library(
+    name = "java",
+    deps = [":a", ":b"],
+    runtime_deps = [":a"],
+)
+
+py_binary(
+    name = "py",
+    srcs = ["main.py"],
+    data = ["//test/package:main.py", "data.txt"],
+)
+
+go_library(
+    name = "go",
+    srcs = ["a.go"],
+    data = ["a.go"],
+)
+`, `
+cc_library(
+    name = "lib",
+    srcs = ["lib.cc"] + select({
+        ":arm": [],
+        "//conditions:default": glob(["lib.h"]),
+    }),
+    hdrs = ["lib.h", "util.h", "arm.h"],
+)
+
+cc_library(
+    name = "conditional",
+    srcs = ["a.h"],
+    hdrs = select({":x": ["a.h"]}),
+)
+
+java_library(
+    name = "java",
+    deps = [":a", ":b"],
+    runtime_deps = [],
+)
+
+py_binary(
+    name = "py",
+    srcs = ["main.py"],
+    data = ["data.txt"],
+)
+
+go_library(
+    name = "go",
+    srcs = ["a.go"],
+    data = ["a.go"],
+)
+`, []string{
+		`:3: The labels "lib.h", "//test/package:util.h" of "srcs" are already listed in "hdrs" of "lib", remove them from "srcs".`,
+		`:8: The label "arm.h" of "srcs" is already listed in "hdrs" of "lib", remove it from "srcs".`,
+		`:23: The label ":a" of "runtime_deps" is already listed in "deps" of "java", remove it from "runtime_deps".`,
+		`:29: The label "//test/package:main.py" of "data" is already listed in "srcs" of "py", remove it from "data".`,
+	}, scopeBuild)
+}
+
+func TestMissingSourceFile(t *testing.T) {
+	testFileReader = NewFileReaderWithFS(func(string) ([]byte, error) {
+		return nil, fmt.Errorf("file not found")
+	}, fstest.MapFS{
+		"test/package/lib.cc":           {},
+		"test/package/lib.h":            {},
+		"test/package/testdata/input":   {},
+		"test/package/sub/BUILD.bazel":  {},
+		"other/package/missing_here.cc": {},
+	})
+	defer func() { testFileReader = nil }()
+
+	checkFindings(t, "missing-source-file", `
+genrule(
+    name = "gen",
+    outs = ["gen.cc"],
+)
+
+cc_library(
+    name = "lib",
+    srcs = [
+        "lib.cc",
+        "gen.cc",
+        ":gen",
+        "//test/package:lib.h",
+        "lib.c",
+        "missing_here.cc",
+        "//other/package:missing_here.cc",
+        "@repo//:file.cc",
+    ] + glob(["*.inc"]) + select({
+        ":arm": ["arm.cc"],
+        "//conditions:default": [],
+    }),
+    hdrs = ["lib.h", "$(location :gen)"],
+    data = ["testdata", "testdata/input", "testdata/output"],
+    copts = ["missing.txt"],
+)
+`, []string{
+		`:13: The file "lib.c" in "srcs" of "lib" doesn't exist in the package and isn't generated by a rule of this BUILD file. Did you mean "lib.cc"?`,
+		`:14: The file "missing_here.cc" in "srcs" of "lib" doesn't exist in the package and isn't generated by a rule of this BUILD file.`,
+		`:18: The file "arm.cc" in "srcs" of "lib" doesn't exist in the package and isn't generated by a rule of this BUILD file.`,
+		`:22: The file "testdata/output" in "data" of "lib" doesn't exist in the package and isn't generated by a rule of this BUILD file.`,
+	}, scopeBuild)
+
+	// A single file instead of a list.
+	checkFindings(t, "missing-source-file", `
+cc_library(
+    name = "lib",
+    srcs = "lib.c",
+)
+`, []string{
+		`:3: The file "lib.c" in "srcs" of "lib" doesn't exist in the package and isn't generated by a rule of this BUILD file. Did you mean "lib.cc"?`,
+	}, scopeBuild)
+
+	// The files can't be checked without a file system.
+	testFileReader = nil
+	checkFindings(t, "missing-source-file", `
+cc_library(
+    name = "lib",
+    srcs = ["lib.c"],
+)
+`, []string{}, scopeBuild)
+}
+
+func TestSourceRootLayout(t *testing.T) {
+	defer setUpFileReader(map[string]string{
+		"test/package/Ok.java":      "// Copyright.\npackage package;\n\nclass Ok {}\n",
+		"test/package/sub/Bad.java": "package package;\n",
+		"test/package/Default.java": "class Default {}\n",
+	})()
+	defer func(old []string) { SourceRoots = old }(SourceRoots)
+
+	build := `
+go_library(
+    name = "go_ok",
+    importpath = "example.com/repo/package",
+)
+
+go_library(
+    name = "go_bad",
+    importpath = "example.com/other/package",
+)
+
+java_library(
+    name = "java",
+    srcs = [
+        "Ok.java",
+        "sub/Bad.java",
+        "Default.java",
+        "Missing.java",
+    ] + glob(["*.java"]),
+)
+
+proto_library(
+    name = "proto_ok",
+    strip_import_prefix = "/test",
+    import_prefix = "example.com/repo",
+)
+
+proto_library(
+    name = "proto_bad",
+    strip_import_prefix = "/test/package",
+)
+
+proto_library(
+    name = "proto_default",
+    srcs = ["a.proto"],
+)
+`
+	SourceRoots = nil
+	checkFindings(t, "source-root-layout", build, []string{}, scopeBuild)
+
+	SourceRoots = []string{"other", "//test/=example.com/repo/"}
+	checkFindings(t, "source-root-layout", build, []string{
+		`:8: The importpath "example.com/other/package" of "go_bad" doesn't match the location of the package under the source root "test", expected "example.com/repo/package".`,
+		`:15: The Java package "package" declared in "sub/Bad.java" doesn't match the location of the file under the source root "test", expected "package.sub".`,
+		`:29: The protos of "proto_bad" are imported from "", which doesn't match the location of the package under the source root "test", expected "example.com/repo/package".`,
+	}, scopeBuild)
+
+	// Without an import path, the Go import paths must end with the location of the package.
+	// A single source file may be given instead of a list.
+	SourceRoots = []string{"test"}
+	checkFindings(t, "source-root-layout", `
+go_library(
+    name = "go_ok",
+    importpath = "example.com/other/package",
+)
+
+go_library(
+    name = "go_bad",
+    importpath = "example.com/pkg",
+)
+
+java_library(
+    name = "java",
+    srcs = "sub/Bad.java",
+)
+`, []string{
+		`:8: The importpath "example.com/pkg" of "go_bad" doesn't match the location of the package under the source root "test", expected it to end with "package".`,
+		`:13: The Java package "package" declared in "sub/Bad.java" doesn't match the location of the file under the source root "test", expected "package.sub".`,
+	}, scopeBuild)
+
+	// The innermost root is used.
+	SourceRoots = []string{"test", "test/package"}
+	checkFindings(t, "source-root-layout", `
+java_library(
+    name = "java",
+    srcs = ["Ok.java"],
+)
+`, []string{
+		`:3: The Java package "package" declared in "Ok.java" doesn't match the location of the file under the source root "test/package", expected "".`,
+	}, scopeBuild)
+}
+
+func TestTestSuiteMembership(t *testing.T) {
+	defer setUpFileReader(map[string]string{
+		"other/BUILD": `
+test_suite(name = "empty")
+
+test_suite(
+    name = "explicit",
+    tests = [":manual_test"],
+)
+
+sh_test(
+    name = "manual_test",
+    tags = ["manual"],
+)
+`,
+	})()
+
+	checkFindings(t, "test-suite-membership", `
+test_suite(name = "all")
+
+cc_test(name = "a_test")
+
+cc_test(
+    name = "b_test",
+    tags = ["manual"],
+)
+
+cc_test(
+    name = "c_test",
+    tags = TAGS,
+)
+`, []string{
+		`5: The test ":b_test" is tagged "manual" and is therefore silently excluded from the test_suite ":all" which doesn't list its tests explicitly.`,
+	}, scopeBuild)
+
+	checkFindings(t, "test-suite-membership", `
+test_suite(
+    name = "small",
+    tags = ["small", "-flaky"],
+)
+
+test_suite(
+    name = "explicit",
+    tests = [
+        ":small",
+        ":b_test",
+        "//other:empty",
+        "//other:explicit",
+        "//missing:suite",
+        "@repo//other:empty",
+    ],
+)
+
+cc_test(
+    name = "a_test",
+    tags = ["small", "flaky"],
+)
+
+cc_test(
+    name = "b_test",
+    tags = ["manual", "small"],
+)
+
+cc_test(
+    name = "c_test",
+    tags = ["large"],
+)
+`, []string{
+		`1: The test_suite ":small" doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual", so it's empty.`,
+		`9: The test_suite ":small" is empty because it doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual".`,
+		`11: The test_suite "//other:empty" is empty because it doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual".`,
+		`23: The test ":b_test" is tagged "manual" and is therefore silently excluded from the test_suite ":small" which doesn't list its tests explicitly.`,
+	}, scopeBuild)
+
+	// The size and flakiness of the tests are matched like tags, positive tags may start with "+".
+	checkFindings(t, "test-suite-membership", `
+test_suite(
+    name = "small",
+    tags = ["+small"],
+)
+
+test_suite(
+    name = "medium",
+    tags = ["medium", "-flaky"],
+)
+
+test_suite(
+    name = "large",
+    tags = ["large"],
+)
+
+cc_test(
+    name = "a_test",
+    size = "small",
+)
+
+cc_test(
+    name = "b_test",
+    flaky = True,
+)
+
+cc_test(
+    name = "c_test",
+    size = "large",
+    tags = ["manual"],
+)
+`, []string{
+		`6: The test_suite ":medium" doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual", so it's empty.`,
+		`11: The test_suite ":large" doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual", so it's empty.`,
+		`26: The test ":c_test" is tagged "manual" and is therefore silently excluded from the test_suite ":large" which doesn't list its tests explicitly.`,
+	}, scopeBuild)
+
+	checkFindings(t, "test-suite-membership", `
+test_suite(
+    name = "all",
+    tests = [],
+)
+`, []string{
+		`1: The test_suite ":all" doesn't list its tests explicitly and doesn't match any test that isn't tagged "manual", so it's empty.`,
+	}, scopeBuild)
+}
+
+func TestCanonicalLoadLabel(t *testing.T) {
+	defer setUpFileReader(map[string]string{
+		"MODULE.bazel": `module(name = "my_repo")`,
+	})()
+
+	checkFindingsAndFix(t, "canonical-load-label", `
+load(":defs.bzl", "a")
+load("//foo:defs.bzl", "b")
+load("@my_repo//foo:other.bzl", "c")
+load("@//bar:defs.bzl", "d")
+load("@rules_cc//cc:defs.bzl", "e")
+`, `
+load("//test/package:defs.bzl", "a")
+load("//foo:defs.bzl", "b")
+load("//foo:other.bzl", "c")
+load("//bar:defs.bzl", "d")
+load("@rules_cc//cc:defs.bzl", "e")
+`,
+		[]string{
+			`:1: Load labels of the current repository should be written in the canonical form "//test/package:defs.bzl".`,
+			`:3: Load labels of the current repository should be written in the canonical form "//foo:other.bzl".`,
+			`:4: Load labels of the current repository should be written in the canonical form "//bar:defs.bzl".`,
+		},
+		scopeEverywhere)
+
+	defer func(form string) { tables.CanonicalLoadLabelForm = form }(tables.CanonicalLoadLabelForm)
+	tables.CanonicalLoadLabelForm = "repo"
+
+	checkFindingsAndFix(t, "canonical-load-label", `
+load(":defs.bzl", "a")
+load("//foo:defs.bzl", "b")
+load("@my_repo//foo:other.bzl", "c")
+load("@rules_cc//cc:defs.bzl", "e")
+`, `
+load("@my_repo//test/package:defs.bzl", "a")
+load("@my_repo//foo:defs.bzl", "b")
+load("@my_repo//foo:other.bzl", "c")
+load("@rules_cc//cc:defs.bzl", "e")
+`,
+		[]string{
+			`:1: Load labels of the current repository should be written in the canonical form "@my_repo//test/package:defs.bzl".`,
+			`:2: Load labels of the current repository should be written in the canonical form "@my_repo//foo:defs.bzl".`,
+		},
+		scopeEverywhere)
+
+	defer func(name string) { tables.CanonicalLoadRepoName = name }(tables.CanonicalLoadRepoName)
+	tables.CanonicalLoadRepoName = "other_name"
+
+	checkFindingsAndFix(t, "canonical-load-label", `
+load("@my_repo//foo:other.bzl", "c")
+load("@other_name//foo:other.bzl", "d")
+`, `
+load("@my_repo//foo:other.bzl", "c")
+load("@other_name//foo:other.bzl", "d")
+`,
+		[]string{},
+		scopeEverywhere)
+}
+
+func TestReexportedLoad(t *testing.T) {
+	defer setUpFileReader(map[string]string{
+		"test/package/defs.bzl": `
+load("//lib:public.bzl", _foo = "foo", _bar = "bar")
+load(":impl.bzl", _baz = "baz")
+
+foo = _foo
+bar = _bar
+baz = _baz
+
+def local():
+    pass
+`,
+		"test/package/impl.bzl": `
+def baz():
+    pass
+`,
+		"lib/public.bzl": `
+load("//lib/private:foo.bzl", _foo = "foo_impl")
+
+foo = _foo
+
+def bar():
+    pass
+`,
+		"lib/private/foo.bzl": `
+def foo_impl():
+    pass
+`,
+	})()
+
+	checkFindingsAndFix(t, "reexported-load", `
+# Comment
+load(":defs.bzl", "foo", "local", my_baz = "baz")
+load("//lib:public.bzl", "bar")
+load("@rules_cc//cc:defs.bzl", "cc_library")
+`, `
+# Comment
+load(":defs.bzl", "local")
+load("//lib/private:foo.bzl", foo = "foo_impl")
+load(":impl.bzl", my_baz = "baz")
+load("//lib:public.bzl", "bar")
+load("@rules_cc//cc:defs.bzl", "cc_library")
+`,
+		[]string{
+			`:2: Symbol "foo" is re-exported by ":defs.bzl" from "//lib/private:foo.bzl", load it from there instead.`,
+			`:2: Symbol "baz" is re-exported by ":defs.bzl" from ":impl.bzl", load it from there instead.`,
+		},
+		scopeBuild|scopeBzl)
+
+	defer func(reexports map[string]bool) { tables.CanonicalReexports = reexports }(tables.CanonicalReexports)
+	tables.CanonicalReexports = map[string]bool{"//lib:public.bzl": true}
+
+	checkFindingsAndFix(t, "reexported-load", `
+load("//test/package:defs.bzl", "bar", "foo")
+load("//lib:public.bzl", "foo")
+`, `
+load("//lib:public.bzl", "bar", "foo")
+load("//lib:public.bzl", "foo")
+`,
+		[]string{
+			`:1: Symbol "bar" is re-exported by "//test/package:defs.bzl" from "//lib:public.bzl", load it from there instead.`,
+			`:1: Symbol "foo" is re-exported by "//test/package:defs.bzl" from "//lib:public.bzl", load it from there instead.`,
+		},
+		scopeBuild|scopeBzl)
+}
+
+func TestDisallowedStatement(t *testing.T) {
+	repo := `
+load("//:defs.bzl", "VISIBILITY")
+
+repo(default_visibility = ["//visibility:public"])
+
+ignore_directories(["node_modules"])
+
+repo(default_package_metadata = ["//:license"])
+
+package(default_visibility = ["//visibility:public"])
+
+def foo():
+    pass
+`
+	compareFindings(t, "disallowed-statement", repo, []string{
+		":1: Load statements are not allowed in REPO.bazel files.",
+		":7: repo() can be called only once per REPO.bazel file.",
+		`:9: "package" can't be called in REPO.bazel files, only ignore_directories(), repo() are allowed.`,
+		":11: Functions can't be defined in REPO.bazel files.",
+	}, build.TypeRepo, build.TypeRepo)
+
+	vendor := `
+ignore("@rules_foo")
+
+pin("@rules_bar")
+
+for repo in ["@a", "@b"]:
+    pin(repo)
+
+vendor("@rules_baz")
+`
+	compareFindings(t, "disallowed-statement", vendor, []string{
+		":5: Control flow statements are not allowed in VENDOR.bazel files.",
+		`:8: "vendor" can't be called in VENDOR.bazel files, only ignore(), pin() are allowed.`,
+	}, build.TypeVendor, build.TypeVendor)
+
+	buildFile := `
+load(":defs.bzl", "my_macro")
+
+def foo():
+    pass
+
+if True:
+    my_macro(name = "a")
+
+[my_macro(name = n) for n in ["b", "c"]]
+`
+	compareFindings(t, "disallowed-statement", buildFile, []string{
+		":3: Functions can't be defined in BUILD files.",
+		":6: Control flow statements are not allowed in BUILD files.",
+	}, build.TypeBuild, build.TypeBuild)
+
+	module := `
+module(name = "foo")
+
+load("//:defs.bzl", "VERSION")
+
+bazel_dep(name = "rules_go", version = "0.50.1")
+
+go_sdk = use_extension("@rules_go//go:extensions.bzl", "go_sdk")
+go_sdk.download(version = "1.23.0")
+use_repo(go_sdk, "go_toolchains")
+
+http_archive = use_repo_rule("@bazel_tools//tools/build_defs/repo:http.bzl", "http_archive")
+http_archive(name = "bar")
+
+module(name = "bar")
+cc_library(name = "baz")
+`
+	compareFindings(t, "disallowed-statement", module, []string{
+		":3: Load statements are not allowed in MODULE.bazel files.",
+		":14: module() can be called only once per MODULE.bazel file.",
+		`:15: "cc_library" can't be called in MODULE.bazel files, only archive_override(), bazel_dep(), flag_alias(), git_override(), include(), inject_repo(), local_path_override(), module(), multiple_version_override(), override_repo(), register_execution_platforms(), register_toolchains(), single_version_override(), use_extension(), use_repo(), use_repo_rule() are allowed.`,
+	}, build.TypeModule, build.TypeModule)
+
+	// Other files are not restricted
+	for _, fileType := range []build.FileType{build.TypeBzl, build.TypeWorkspace, build.TypeDefault} {
+		compareFindings(t, "disallowed-statement", repo, []string{}, fileType, fileType)
+	}
+}
+
+func TestUnknownAttribute(t *testing.T) {
+	checkFindings(t, "unknown-attribute", `
+load(":defs.bzl", "java_library")
+
+filegroup(
+    name = "files",
+    srcs = ["a.txt"],
+    deps = [":lib"],
+    target_compatible_with = ["@platforms//os:linux"],
+)
+
+cc_library(
+    name = "lib",
+    dep = [":other"],
+    visability = ["//visibility:public"],
+    **kwargs
+)
+
+genrule(
+    name = "gen",
+    outs = ["out.txt"],
+    cmd = "touch $@",
+    visability = ["//visibility:public"],
+)
+
+java_library(
+    name = "java",
+    unknown = True,
+)
+
+my_macro(
+    name = "macro",
+    unknown = True,
+)
+`,
+		[]string{
+			`:6: Rule "filegroup" has no attribute "deps".`,
+			`:12: Rule "cc_library" has no attribute "dep". Did you mean "deps"?`,
+			`:13: Rule "cc_library" has no attribute "visability". Did you mean "visibility"?`,
+			`:21: Rule "genrule" has no attribute "visability". Did you mean "visibility"?`,
+		},
+		scopeBuild)
+}
+
+func TestMisplacedAttribute(t *testing.T) {
+	checkFindingsAndFix(t, "misplaced-attribute", `
+load(":defs.bzl", "java_library")
+
+cc_library(
+    name = "lib",
+    srcs = ["lib.cc"],
+    stamp = 1,
+    linkstatic = True,
+    shard_count = 4,
+)
+
+cc_binary(
+    name = "bin",
+    stamp = 1,
+    flaky = True,
+)
+
+cc_test(
+    name = "test",
+    shard_count = 4,
+)
+
+java_library(
+    name = "java",
+    stamp = 1,
+)
+
+filegroup(
+    name = "files",
+    stamp = 1,
+)
+`, `
+load(":defs.bzl", "java_library")
+
+cc_library(
+    name = "lib",
+    srcs = ["lib.cc"],
+    linkstatic = True,
+)
+
+cc_binary(
+    name = "bin",
+    stamp = 1,
+)
+
+cc_test(
+    name = "test",
+    shard_count = 4,
+)
+
+java_library(
+    name = "java",
+    stamp = 1,
+)
+
+filegroup(
+    name = "files",
+    stamp = 1,
+)
+`,
+		[]string{
+			`:6: Attribute "stamp" has no effect on "cc_library", it's only meaningful on cc_binary and cc_test rules.`,
+			`:8: Attribute "shard_count" has no effect on "cc_library", it's only meaningful on cc_test rules.`,
+			`:14: Attribute "flaky" has no effect on "cc_binary", it's only meaningful on cc_test rules.`,
+		},
+		scopeBuild)
+}
+
+func TestAliasChain(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test/package/config.json": {},
+		"test/package/data.txt":    {},
+		"test/package/api/BUILD":   {Data: []byte(`cc_library(name = "api")`)},
+		"test/package/docs/a.md":   {},
+		"other/BUILD.bazel": {Data: []byte(`
+alias(name = "b", actual = "//chain:c")
+alias(name = "loop1", actual = ":loop2")
+alias(name = "loop2", actual = "//test/package:loop")
+cc_library(name = "real")
+`)},
+		"chain/BUILD": {Data: []byte(`alias(name = "c", actual = "//other:real")`)},
+	}
+	testFileReader = NewFileReaderWithFS(func(filename string) ([]byte, error) {
+		if file, ok := fsys[filename]; ok {
+			return file.Data, nil
+		}
+		return nil, fmt.Errorf("file not found")
+	}, fsys)
+	defer func() { testFileReader = nil }()
+
+	input := `
+alias(name = "self", actual = ":self")
+alias(name = "a", actual = "//other:b")
+alias(name = "short", actual = "//chain:c")
+alias(name = "missing", actual = ":nothing")
+alias(name = "data", actual = "data.txt")
+alias(name = "gen_alias", actual = ":gen_out.txt")
+alias(name = "implicit", actual = ":lib_deploy.jar")
+alias(name = "loop", actual = "//other:loop1")
+alias(name = "config.json", actual = ":lib")
+alias(name = "ext", actual = "@repo//:x")
+alias(name = "unknown_pkg", actual = "//unknown:x")
+alias(name = "selected", actual = select({"//conditions:default": ":self"}))
+alias(name = "api", actual = "//test/package/api")
+alias(name = "docs", actual = "//other:real")
+
+genrule(name = "gen", outs = ["gen_out.txt"])
+
+java_binary(name = "lib")
+`
+	checkFindings(t, "alias-chain", input, []string{
+		`:1: The alias "self" points at itself.`,
+		`:2: The alias "a" resolves to "//other:real" through a chain of 3 aliases (:a -> //other:b -> //chain:c -> //other:real), more than 2. Point it directly at the target.`,
+		`:4: The alias "missing" points at ":nothing", which doesn't exist.`,
+		`:8: The chain of aliases of "loop" has a cycle: :loop -> //other:loop1 -> //other:loop2 -> :loop.`,
+		`:9: The alias "config.json" has the name of a file of the package, which it shadows: ":config.json" refers to the alias instead of the file.`,
+	}, scopeBuild)
+
+	defer func(old int) { MaxAliasDepth = old }(MaxAliasDepth)
+	MaxAliasDepth = 1
+	checkFindings(t, "alias-chain", `
+alias(name = "short", actual = "//chain:c")
+alias(name = "direct", actual = "//other:real")
+`, []string{
+		`:1: The alias "short" resolves to "//other:real" through a chain of 2 aliases (:short -> //chain:c -> //other:real), more than 1. Point it directly at the target.`,
+	}, scopeBuild)
+}
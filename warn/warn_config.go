@@ -0,0 +1,200 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about the definitions of configuration settings and platforms
+
+package warn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/labels"
+)
+
+// AllowedDefines lists the names that config_setting rules may match with --define, the
+// config-setting warning reports the other ones. Any name is allowed if it's empty.
+var AllowedDefines []string
+
+// PlatformConstraints lists the constraint settings (e.g. "@platforms//os") that every platform
+// without parents must have a constraint value for, see the platform-constraints warning.
+var PlatformConstraints = []string{"@platforms//os", "@platforms//cpu"}
+
+// configSettingConditions are the attributes of config_setting that a configuration must match.
+var configSettingConditions = []string{"values", "flag_values", "define_values", "constraint_values"}
+
+// defineNames returns the names of the --define flags matched by a config_setting, with the
+// expressions they're defined by.
+func defineNames(r *build.Rule) map[string]*build.StringExpr {
+	names := make(map[string]*build.StringExpr)
+	if values, ok := r.Attr("values").(*build.DictExpr); ok {
+		for _, kv := range values.List {
+			key, ok := kv.Key.(*build.StringExpr)
+			value, ok2 := kv.Value.(*build.StringExpr)
+			if ok && ok2 && key.Value == "define" {
+				names[strings.SplitN(value.Value, "=", 2)[0]] = value
+			}
+		}
+	}
+	if values, ok := r.Attr("define_values").(*build.DictExpr); ok {
+		for _, kv := range values.List {
+			if key, ok := kv.Key.(*build.StringExpr); ok {
+				names[key.Value] = key
+			}
+		}
+	}
+	return names
+}
+
+// constraintSetting returns the constraint setting of a constraint value of the platforms
+// repository, whose packages each define a single setting (e.g. @platforms//os), or an empty
+// label if it isn't known.
+func constraintSetting(value string) labels.Label {
+	label := labels.Parse(value)
+	if label.Repository != "platforms" {
+		return labels.Label{}
+	}
+	return labels.Label{Repository: label.Repository, Package: label.Package}
+}
+
+func configSettingWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+	shadowed := shadowedRuleNames(f)
+	if shadowed["config_setting"] {
+		return nil
+	}
+	allowed := make(map[string]bool)
+	for _, name := range AllowedDefines {
+		allowed[name] = true
+	}
+
+	var findings []*LinterFinding
+	for _, r := range f.Rules("config_setting") {
+		// The conditions given by expressions other than literals can't be checked.
+		literal, empty, conditions := true, false, 0
+		for _, attr := range configSettingConditions {
+			switch value := r.Attr(attr).(type) {
+			case nil:
+			case *build.DictExpr:
+				if len(value.List) == 0 {
+					findings = append(findings, makeLinterFinding(r.AttrDefn(attr),
+						fmt.Sprintf("The attribute %q of the config_setting %q is empty.", attr, r.Name())))
+					empty = true
+				}
+				conditions += len(value.List)
+			case *build.ListExpr:
+				if len(value.List) == 0 {
+					findings = append(findings, makeLinterFinding(r.AttrDefn(attr),
+						fmt.Sprintf("The attribute %q of the config_setting %q is empty.", attr, r.Name())))
+					empty = true
+				}
+				conditions += len(value.List)
+			default:
+				literal = false
+			}
+		}
+		if literal && !empty && conditions == 0 {
+			findings = append(findings, makeLinterFinding(r.Call,
+				fmt.Sprintf(`The config_setting %q has no conditions, set at least one of "values", "flag_values", "define_values" or "constraint_values".`, r.Name())))
+		}
+
+		if values, ok := r.Attr("constraint_values").(*build.ListExpr); ok {
+			settings := make(map[labels.Label]*build.StringExpr)
+			for _, x := range values.List {
+				value, ok := x.(*build.StringExpr)
+				if !ok {
+					continue
+				}
+				setting := constraintSetting(value.Value)
+				if setting == (labels.Label{}) {
+					continue
+				}
+				if other, ok := settings[setting]; ok && labels.Parse(other.Value) != labels.Parse(value.Value) {
+					findings = append(findings, makeLinterFinding(value,
+						fmt.Sprintf("The constraint values %q and %q of the config_setting %q belong to the same constraint setting, the config_setting never matches.",
+							other.Value, value.Value, r.Name())))
+					continue
+				}
+				settings[setting] = value
+			}
+		}
+
+		if len(allowed) > 0 {
+			defines := defineNames(r)
+			var names []string
+			for name := range defines {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if !allowed[name] {
+					findings = append(findings, makeLinterFinding(defines[name],
+						fmt.Sprintf("The config_setting %q matches --define %s, which isn't one of the allowed defines (%s).",
+							r.Name(), name, strings.Join(AllowedDefines, ", "))))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func platformConstraintsWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild || len(PlatformConstraints) == 0 {
+		return nil
+	}
+	if shadowedRuleNames(f)["platform"] {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	for _, r := range f.Rules("platform") {
+		// Platforms with parents inherit their constraint values.
+		if r.Attr("parents") != nil {
+			continue
+		}
+		var values []build.Expr
+		switch attr := r.Attr("constraint_values").(type) {
+		case nil:
+		case *build.ListExpr:
+			values = attr.List
+		default:
+			continue
+		}
+		settings := make(map[labels.Label]bool)
+		for _, x := range values {
+			if value, ok := x.(*build.StringExpr); ok {
+				label := labels.ParseRelative(value.Value, f.Pkg)
+				settings[labels.Label{Repository: label.Repository, Package: label.Package}] = true
+			}
+		}
+		var missing []string
+		for _, required := range PlatformConstraints {
+			label := labels.Parse(required)
+			if !settings[labels.Label{Repository: label.Repository, Package: label.Package}] {
+				missing = append(missing, required)
+			}
+		}
+		if len(missing) > 0 {
+			findings = append(findings, makeLinterFinding(r.Call,
+				fmt.Sprintf("The platform %q has no constraint value for %s.", r.Name(), strings.Join(missing, ", "))))
+		}
+	}
+	return findings
+}
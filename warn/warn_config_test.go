@@ -0,0 +1,124 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import "testing"
+
+func TestConfigSetting(t *testing.T) {
+	defer func(old []string) { AllowedDefines = old }(AllowedDefines)
+
+	input := `
+config_setting(
+    name = "linux",
+    constraint_values = ["@platforms//os:linux"],
+)
+
+config_setting(
+    name = "empty",
+    values = {},
+)
+
+config_setting(
+    name = "none",
+)
+
+config_setting(
+    name = "computed",
+    values = COMPUTED_VALUES,
+)
+
+config_setting(
+    name = "contradictory",
+    constraint_values = [
+        "@platforms//os:linux",
+        "@platforms//cpu:x86_64",
+        "@platforms//os:macos",
+        "//constraints:a",
+        "//constraints:b",
+    ],
+)
+
+config_setting(
+    name = "defines",
+    values = {"define": "with_gpu=true"},
+    define_values = {
+        "with_tpu": "true",
+        "with_cpu": "true",
+    },
+)
+`
+	AllowedDefines = nil
+	checkFindings(t, "config-setting", input, []string{
+		`:8: The attribute "values" of the config_setting "empty" is empty.`,
+		`:11: The config_setting "none" has no conditions, set at least one of "values", "flag_values", "define_values" or "constraint_values".`,
+		`:25: The constraint values "@platforms//os:linux" and "@platforms//os:macos" of the config_setting "contradictory" belong to the same constraint setting, the config_setting never matches.`,
+	}, scopeBuild)
+
+	AllowedDefines = []string{"with_cpu", "with_gpu"}
+	checkFindings(t, "config-setting", input, []string{
+		`:8: The attribute "values" of the config_setting "empty" is empty.`,
+		`:11: The config_setting "none" has no conditions, set at least one of "values", "flag_values", "define_values" or "constraint_values".`,
+		`:25: The constraint values "@platforms//os:linux" and "@platforms//os:macos" of the config_setting "contradictory" belong to the same constraint setting, the config_setting never matches.`,
+		`:35: The config_setting "defines" matches --define with_tpu, which isn't one of the allowed defines (with_cpu, with_gpu).`,
+	}, scopeBuild)
+}
+
+func TestPlatformConstraints(t *testing.T) {
+	defer func(old []string) { PlatformConstraints = old }(PlatformConstraints)
+
+	input := `
+platform(
+    name = "linux_x86_64",
+    constraint_values = [
+        "@platforms//os:linux",
+        "@platforms//cpu:x86_64",
+        ":gpu",
+    ],
+)
+
+platform(
+    name = "linux",
+    constraint_values = ["@platforms//os:linux"],
+)
+
+platform(
+    name = "empty",
+)
+
+platform(
+    name = "child",
+    parents = [":linux_x86_64"],
+    constraint_values = [":gpu"],
+)
+
+platform(
+    name = "computed",
+    constraint_values = LINUX_CONSTRAINTS,
+)
+`
+	checkFindings(t, "platform-constraints", input, []string{
+		`:10: The platform "linux" has no constraint value for @platforms//cpu.`,
+		`:15: The platform "empty" has no constraint value for @platforms//os, @platforms//cpu.`,
+	}, scopeBuild)
+
+	PlatformConstraints = []string{"@platforms//os", "//constraints/gpu"}
+	checkFindings(t, "platform-constraints", input, []string{
+		`:1: The platform "linux_x86_64" has no constraint value for //constraints/gpu.`,
+		`:10: The platform "linux" has no constraint value for //constraints/gpu.`,
+		`:15: The platform "empty" has no constraint value for @platforms//os, //constraints/gpu.`,
+	}, scopeBuild)
+}
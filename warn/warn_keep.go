@@ -0,0 +1,164 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about `# keep` comments that are no longer needed
+
+package warn
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/edit"
+	"github.com/bazelbuild/buildtools/labels"
+)
+
+// UsedDeps maps the labels of targets to the labels of the dependencies they actually use,
+// e.g. according to their .jdeps files or to `bazel query`. It's used by the stale-keep
+// warning and can be read by ReadUsedDeps.
+var UsedDeps map[string][]string
+
+// ReadUsedDeps reads a JSON file that maps the labels of targets to the lists of labels of
+// the dependencies they use, e.g. {"//pkg:lib": ["//base", "@maven//:guava"]}.
+func ReadUsedDeps(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var usedDeps map[string][]string
+	if err := json.Unmarshal(data, &usedDeps); err != nil {
+		return nil, err
+	}
+	return usedDeps, nil
+}
+
+// usedDepsOf returns the set of the normalized labels of the dependencies used by a target,
+// or nil if there's no data about the target. The target may be listed with its long or its
+// shortened label.
+func usedDepsOf(target labels.Label) map[labels.Label]bool {
+	deps, ok := UsedDeps[target.Format()]
+	if !ok {
+		deps, ok = UsedDeps["//"+target.Package+":"+target.Target]
+	}
+	if !ok {
+		return nil
+	}
+	used := make(map[labels.Label]bool)
+	for _, dep := range deps {
+		used[labels.Parse(dep)] = true
+	}
+	return used
+}
+
+// withoutKeepComment returns a copy of a string literal without its `# keep` comments.
+func withoutKeepComment(str *build.StringExpr) *build.StringExpr {
+	newStr := *str
+	newStr.Comments = build.Comments{
+		Before: removeKeepComments(str.Comments.Before),
+		Suffix: removeKeepComments(str.Comments.Suffix),
+		After:  str.Comments.After,
+	}
+	return &newStr
+}
+
+// removeKeepComments returns the comments that aren't `# keep` comments.
+func removeKeepComments(comments []build.Comment) []build.Comment {
+	var kept []build.Comment
+	for _, c := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Token, "#"))
+		if text != "keep" && !strings.HasPrefix(text, "keep:") {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// walkListElements calls fn for every element of the lists of an attribute value, including
+// the ones in list concatenations and select() branches.
+func walkListElements(e build.Expr, fn func(elem *build.Expr)) {
+	switch e := e.(type) {
+	case *build.ListExpr:
+		for i := range e.List {
+			fn(&e.List[i])
+		}
+	case *build.BinaryExpr:
+		if e.Op == "+" {
+			walkListElements(e.X, fn)
+			walkListElements(e.Y, fn)
+		}
+	case *build.CallExpr:
+		if ident, ok := e.X.(*build.Ident); ok && ident.Name == "select" && len(e.List) == 1 {
+			if dict, ok := e.List[0].(*build.DictExpr); ok {
+				for _, kv := range dict.List {
+					walkListElements(kv.Value, fn)
+				}
+			}
+		}
+	}
+}
+
+func staleKeepWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild {
+		return nil
+	}
+
+	shadowed := shadowedRuleNames(f)
+	var findings []*LinterFinding
+	for _, r := range f.Rules("") {
+		// `# keep` on an attribute the rule doesn't have.
+		if attributes, ok := RuleAttributes[r.Kind()]; ok && !shadowed[r.Kind()] {
+			known := make(map[string]bool)
+			for _, attr := range attributes {
+				known[attr] = true
+			}
+			for _, arg := range r.Call.List {
+				as, ok := arg.(*build.AssignExpr)
+				if !ok || !edit.HasKeepComment(as) {
+					continue
+				}
+				if key, ok := as.LHS.(*build.Ident); ok && !known[key.Name] && !commonAttributes[key.Name] {
+					findings = append(findings, makeLinterFinding(as,
+						fmt.Sprintf("The \"# keep\" comment is on the attribute %q that rule %q doesn't have.", key.Name, r.Kind())))
+				}
+			}
+		}
+
+		// `# keep` on a dependency the target is known to use.
+		if r.Name() == "" {
+			continue
+		}
+		target := labels.Label{Package: f.Pkg, Target: r.Name()}
+		used := usedDepsOf(target)
+		if used == nil {
+			continue
+		}
+		for _, attr := range r.AttrKeys() {
+			walkListElements(r.Attr(attr), func(elem *build.Expr) {
+				str, ok := (*elem).(*build.StringExpr)
+				if !ok || !edit.HasKeepComment(str) || !used[labels.ParseRelative(str.Value, f.Pkg)] {
+					return
+				}
+				findings = append(findings, makeLinterFinding(str,
+					fmt.Sprintf("The \"# keep\" comment on %q is no longer needed, %q uses it.", str.Value, target.Format()),
+					LinterReplacement{elem, withoutKeepComment(str)}))
+			})
+		}
+	}
+	return findings
+}
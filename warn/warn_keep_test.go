@@ -0,0 +1,158 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStaleKeepUsedDeps(t *testing.T) {
+	defer func(old map[string][]string) { UsedDeps = old }(UsedDeps)
+
+	// No data about the used deps
+	UsedDeps = nil
+	checkFindings(t, "stale-keep", `
+java_library(
+    name = "lib",
+    deps = [
+        "//base",  # keep
+    ],
+)
+`, []string{}, scopeBuild)
+
+	UsedDeps = map[string][]string{
+		"//test/package:lib": {"//base", "//test/package:util", "@maven//:guava"},
+		"//test/package":     {"//base"},
+	}
+	checkFindingsAndFix(t, "stale-keep", `
+java_library(
+    name = "lib",
+    deps = [
+        "//base:base",  # keep
+        ":util",  # keep: used by reflection
+        # keep
+        "@maven//:guava",
+        "//unused",  # keep
+        "//other",
+    ] + select({
+        "//conditions:default": ["//base"],  # keep
+    }),
+    runtime_deps = [
+        "//base",  # keep
+    ],
+)
+
+java_library(
+    name = "other",
+    deps = [
+        "//base",  # keep
+    ],
+)
+
+java_library(
+    name = "package",
+    deps = [
+        "//base",  # keep
+    ],
+)
+`, `
+java_library(
+    name = "lib",
+    deps = [
+        "//base:base",
+        ":util",
+        "@maven//:guava",
+        "//unused",  # keep
+        "//other",
+    ] + select({
+        "//conditions:default": ["//base"],  # keep
+    }),
+    runtime_deps = [
+        "//base",
+    ],
+)
+
+java_library(
+    name = "other",
+    deps = [
+        "//base",  # keep
+    ],
+)
+
+java_library(
+    name = "package",
+    deps = [
+        "//base",
+    ],
+)
+`, []string{
+		`:4: The "# keep" comment on "//base:base" is no longer needed, "//test/package:lib" uses it.`,
+		`:5: The "# keep" comment on ":util" is no longer needed, "//test/package:lib" uses it.`,
+		`:7: The "# keep" comment on "@maven//:guava" is no longer needed, "//test/package:lib" uses it.`,
+		`:14: The "# keep" comment on "//base" is no longer needed, "//test/package:lib" uses it.`,
+		`:28: The "# keep" comment on "//base" is no longer needed, "//test/package" uses it.`,
+	}, scopeBuild)
+}
+
+func TestStaleKeepUnknownAttribute(t *testing.T) {
+	checkFindings(t, "stale-keep", `
+load(":defs.bzl", "my_library")
+
+cc_library(
+    name = "lib",
+    srcs = ["a.cc"],  # keep
+    copt = ["-O2"],  # keep
+    # keep
+    visability = ["//visibility:public"],
+    target_compatible_with = ["@platforms//os:linux"],  # keep
+    nocopts = "-Wall",
+)
+
+my_library(
+    name = "macro",
+    unknown = True,  # keep
+)
+`, []string{
+		`:6: The "# keep" comment is on the attribute "copt" that rule "cc_library" doesn't have.`,
+		`:8: The "# keep" comment is on the attribute "visability" that rule "cc_library" doesn't have.`,
+	}, scopeBuild)
+}
+
+func TestReadUsedDeps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "used_deps.json")
+	if err := os.WriteFile(path, []byte(`{"//pkg:lib": ["//base", "@maven//:guava"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	usedDeps, err := ReadUsedDeps(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"//pkg:lib": {"//base", "@maven//:guava"}}
+	if !reflect.DeepEqual(usedDeps, want) {
+		t.Errorf("ReadUsedDeps() = %v, want %v", usedDeps, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`["//base"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadUsedDeps(path); err == nil {
+		t.Errorf("ReadUsedDeps() with a list succeeded, want an error")
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about the files loaded by the load statements
+
+package warn
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/labels"
+	"github.com/bazelbuild/buildtools/wspace"
+)
+
+// VendorDir is the directory of the vendored external repositories relative to the workspace
+// root, e.g. created by `bazel vendor --vendor_dir=vendor`, in which the unresolved-load warning
+// looks for the files loaded from the other repositories.
+var VendorDir string
+
+// workspacePath cleans a path of a local repository relative to the workspace root. ok is false
+// if the path is outside of the workspace.
+func workspacePath(p string) (dir string, ok bool) {
+	if p == "" {
+		return "", false
+	}
+	p = path.Clean(p)
+	if p == "." {
+		return "", true
+	}
+	return p, fs.ValidPath(p)
+}
+
+// repoDir returns the directory of a repository relative to the workspace root: the workspace
+// root itself for the main repository, the path of a local_path_override, local_repository or
+// new_local_repository, or the directory of the repository in VendorDir. ok is false if the
+// repository isn't available in the workspace, e.g. because it's fetched by Bazel.
+func repoDir(repo string, fileReader *FileReader) (dir string, ok bool) {
+	if repo == "" {
+		return "", true
+	}
+	moduleName := ""
+	if module := fileReader.GetFile("", "MODULE.bazel"); module != nil {
+		for _, r := range module.Rules("module") {
+			if repo == r.AttrString("name") || repo == r.AttrString("repo_name") {
+				return "", true
+			}
+		}
+		for _, r := range module.Rules("bazel_dep") {
+			apparent := r.AttrString("repo_name")
+			if apparent == "" {
+				apparent = r.AttrString("name")
+			}
+			if apparent == repo {
+				moduleName = r.AttrString("name")
+			}
+		}
+		for _, r := range module.Rules("local_path_override") {
+			if moduleName != "" && r.AttrString("module_name") == moduleName {
+				return workspacePath(r.AttrString("path"))
+			}
+		}
+	}
+	for _, name := range []string{"WORKSPACE", "WORKSPACE.bazel"} {
+		workspace := fileReader.GetFile("", name)
+		if workspace == nil {
+			continue
+		}
+		for _, r := range workspace.Rules("") {
+			switch r.Kind() {
+			case "workspace":
+				if r.AttrString("name") == repo {
+					return "", true
+				}
+			case "local_repository", "new_local_repository":
+				if r.Name() == repo {
+					return workspacePath(r.AttrString("path"))
+				}
+			}
+		}
+	}
+	if VendorDir != "" {
+		// The directories of the vendored repositories have canonical names, e.g. rules_go+.
+		for _, entry := range fileReader.PackageFiles(VendorDir) {
+			module, ok := wspace.CanonicalRepoModule(entry)
+			if entry == repo || ok && moduleName != "" && module == moduleName {
+				return path.Join(VendorDir, entry), true
+			}
+		}
+	}
+	return "", false
+}
+
+// filePackage returns the package of a file of the main repository, i.e. its innermost
+// directory containing a BUILD file.
+func filePackage(filename string, fileReader *FileReader) string {
+	for dir := path.Dir(filename); dir != "."; dir = path.Dir(dir) {
+		for _, buildFile := range []string{"BUILD", "BUILD.bazel"} {
+			if exists, _ := fileReader.FileExists(dir, buildFile); exists {
+				return dir
+			}
+		}
+	}
+	return ""
+}
+
+// loadSuggestion returns the label of an existing .bzl file similar to the missing one loaded
+// with the given label from the repository in dir, or an empty string: a file of the same package
+// whose name differs by at most 2 edits or, for the main repository, a file with the same name in
+// another package, e.g. if the file has been moved.
+func loadSuggestion(label labels.Label, dir string, fileReader *FileReader) string {
+	pkgDir := path.Join(dir, label.Package)
+	targetDir, name := path.Split(label.Target)
+	best, bestDistance := "", 3 // only suggest files differing by at most 2 edits
+	for _, file := range fileReader.PackageFiles(path.Join(pkgDir, targetDir)) {
+		if !strings.HasSuffix(file, ".bzl") {
+			continue
+		}
+		if d := editDistance(name, file); d < bestDistance {
+			best, bestDistance = file, d
+		}
+	}
+	if best != "" {
+		label.Target = targetDir + best
+		return label.Format()
+	}
+	if label.Repository != "" {
+		return ""
+	}
+
+	missing := path.Join(pkgDir, label.Target)
+	best, bestDistance = "", -1
+	for _, file := range fileReader.StarlarkFiles() {
+		if path.Base(file) != name || VendorDir != "" && isUnderRoots(file, []string{VendorDir}) {
+			continue
+		}
+		if d := editDistance(missing, file); bestDistance == -1 || d < bestDistance {
+			best, bestDistance = file, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	pkg := filePackage(best, fileReader)
+	return labels.Label{Package: pkg, Target: strings.TrimPrefix(strings.TrimPrefix(best, pkg), "/")}.Format()
+}
+
+func unresolvedLoadWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if _, ok := fileReader.FileExists("", "."); !ok {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		load, ok := stmt.(*build.LoadStmt)
+		if !ok || load.Module == nil {
+			continue
+		}
+		module := load.Module.Value
+		if strings.HasPrefix(module, "@@") {
+			// The canonical repository names depend on the Bazel version.
+			continue
+		}
+		if !strings.HasPrefix(module, "@") && !strings.HasPrefix(module, "//") && f.WorkspaceRoot == "" {
+			// The package of the file is unknown, relative labels can't be resolved.
+			continue
+		}
+		label := labels.ParseRelative(module, f.Pkg)
+		dir, ok := repoDir(label.Repository, fileReader)
+		if !ok {
+			continue
+		}
+		if exists, _ := fileReader.FileExists(path.Join(dir, label.Package), label.Target); exists {
+			continue
+		}
+
+		message := fmt.Sprintf("The loaded file %q doesn't exist.", module)
+		if suggestion := loadSuggestion(label, dir, fileReader); suggestion != "" {
+			if !strings.HasPrefix(module, "@") && !strings.HasPrefix(module, "//") {
+				suggestion = labels.Parse(suggestion).FormatRelative(f.Pkg)
+			}
+			message += fmt.Sprintf(" Did you mean %q?", suggestion)
+		}
+		findings = append(findings, makeLinterFinding(load.Module, message))
+	}
+	return findings
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestUnresolvedLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"MODULE.bazel": {Data: []byte(`
+module(name = "my_module")
+
+bazel_dep(name = "local_mod", repo_name = "local")
+bazel_dep(name = "rules_go")
+
+local_path_override(
+    module_name = "local_mod",
+    path = "third_party/local",
+)
+`)},
+		"WORKSPACE": {Data: []byte(`
+local_repository(
+    name = "ws_local",
+    path = "ws",
+)
+`)},
+		"test/package/BUILD":                {},
+		"test/package/defs.bzl":             {},
+		"build/BUILD":                       {},
+		"build/rules/moved.bzl":             {},
+		"third_party/local/pkg/lib.bzl":     {},
+		"vendor/rules_go+/go/def.bzl":       {},
+		"vendor/rules_go+/go/private/x.bzl": {},
+		"ws/BUILD":                          {},
+	}
+	testFileReader = NewFileReaderWithFS(func(filename string) ([]byte, error) {
+		return fs.ReadFile(fsys, filename)
+	}, fsys)
+	defer func() { testFileReader = nil }()
+	defer func(old string) { VendorDir = old }(VendorDir)
+	VendorDir = "vendor"
+
+	checkFindings(t, "unresolved-load", `
+load(":defs.bzl", "a")
+load("//test/package:defs.bzl", "b")
+load("@my_module//test/package:defs.bzl", "c")
+load(":def.bzl", "d")
+load("//tools:moved.bzl", "e")
+load("@local//pkg:lib.bzl", "f")
+load("@local//pkg:libs.bzl", "g")
+load("@rules_go//go:def.bzl", "h")
+load("@rules_go//go:missing.bzl", "i")
+load("@ws_local//:defs.bzl", "j")
+load("@fetched//:defs.bzl", "k")
+load("@@rules_go+//go:missing.bzl", "l")
+`, []string{
+		`:4: The loaded file ":def.bzl" doesn't exist. Did you mean ":defs.bzl"?`,
+		`:5: The loaded file "//tools:moved.bzl" doesn't exist. Did you mean "//build:rules/moved.bzl"?`,
+		`:7: The loaded file "@local//pkg:libs.bzl" doesn't exist. Did you mean "@local//pkg:lib.bzl"?`,
+		`:9: The loaded file "@rules_go//go:missing.bzl" doesn't exist.`,
+		`:10: The loaded file "@ws_local//:defs.bzl" doesn't exist.`,
+	}, scopeEverywhere)
+
+	// The vendored repositories aren't checked without VendorDir.
+	VendorDir = ""
+	checkFindings(t, "unresolved-load", `
+load("@rules_go//go:missing.bzl", "i")
+`, []string{}, scopeEverywhere)
+
+	// The files can't be checked without a file system.
+	testFileReader = nil
+	checkFindings(t, "unresolved-load", `
+load(":def.bzl", "d")
+`, []string{}, scopeEverywhere)
+}
@@ -330,3 +330,181 @@ This is important for tooling and automation.
 
 	return findings
 }
+
+// forwardedAttributes are the attributes of all rules that callers of a macro expect to be
+// passed on to the targets it creates.
+var forwardedAttributes = []string{"testonly", "visibility", "tags"}
+
+// kwargsParam returns the name of the **kwargs parameter of a function, or "" if it has none.
+func kwargsParam(def *build.DefStmt) string {
+	for _, param := range def.Params {
+		if name, op := build.GetParamName(param); op == "**" {
+			return name
+		}
+	}
+	return ""
+}
+
+// passesKwargs reports whether a call has a **kwargs argument.
+func passesKwargs(call *build.CallExpr) bool {
+	for _, arg := range call.List {
+		if unary, ok := arg.(*build.UnaryExpr); ok && unary.Op == "**" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKeywordArgument reports whether a call has a keyword argument with the given name.
+func hasKeywordArgument(call *build.CallExpr, name string) bool {
+	for _, arg := range call.List {
+		if as, ok := arg.(*build.AssignExpr); ok {
+			if ident, ok := as.LHS.(*build.Ident); ok && ident.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kwargsUsage returns the keys a function reads from its **kwargs parameter with constant
+// strings, e.g. `kwargs.get("visibility")`, `kwargs.pop("tags", [])`, `kwargs["testonly"]` or
+// `"tags" in kwargs`. If the parameter is used in any other way (e.g. passed to a helper
+// function), opaque is true and the keys are unknown.
+func kwargsUsage(def *build.DefStmt, kwargs string) (keys map[string]bool, opaque bool) {
+	keys = make(map[string]bool)
+	for _, stmt := range def.Body {
+		build.Walk(stmt, func(expr build.Expr, stack []build.Expr) {
+			if ident, ok := expr.(*build.Ident); !ok || ident.Name != kwargs || len(stack) == 0 {
+				return
+			}
+			switch parent := stack[len(stack)-1].(type) {
+			case *build.DotExpr:
+				if len(stack) >= 2 {
+					if call, ok := stack[len(stack)-2].(*build.CallExpr); ok && call.X == parent && len(call.List) > 0 {
+						if key, ok := call.List[0].(*build.StringExpr); ok {
+							switch parent.Name {
+							case "get", "pop", "setdefault":
+								keys[key.Value] = true
+								return
+							}
+						}
+					}
+				}
+			case *build.IndexExpr:
+				if key, ok := parent.Y.(*build.StringExpr); ok && parent.X == expr {
+					keys[key.Value] = true
+					return
+				}
+			case *build.BinaryExpr:
+				if key, ok := parent.X.(*build.StringExpr); ok && (parent.Op == "in" || parent.Op == "not in") {
+					keys[key.Value] = true
+					return
+				}
+			case *build.UnaryExpr:
+				if parent.Op == "**" && len(stack) >= 2 {
+					if _, ok := stack[len(stack)-2].(*build.CallExpr); ok {
+						// Forwarded to a call, the calls of rules and macros are checked separately.
+						return
+					}
+				}
+			}
+			opaque = true
+		})
+	}
+	return keys, opaque
+}
+
+// calleeName returns the name a call is analyzed under by getFunCalls, or "" if it's not analyzed.
+func calleeName(call *build.CallExpr) string {
+	switch x := call.X.(type) {
+	case *build.Ident:
+		return x.Name
+	case *build.DotExpr:
+		if ident, ok := x.X.(*build.Ident); ok && ident.Name == "native" {
+			return "native." + x.Name
+		}
+	}
+	return ""
+}
+
+func macroKwargsForwardingWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	macroAnalyzer := newMacroAnalyzer(fileReader)
+	fileData := analyzeFile(f)
+	macroAnalyzer.files[f.Pkg+":"+f.Label] = fileData
+
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		def, ok := stmt.(*build.DefStmt)
+		if !ok {
+			continue
+		}
+		kwargs := kwargsParam(def)
+		if kwargs == "" {
+			continue
+		}
+
+		// The calls of rules and macros, depending on whether they receive **kwargs.
+		var forwarding, other []*build.CallExpr
+		funCalls := fileData.functions[def.Name]
+		build.Walk(def, func(expr build.Expr, stack []build.Expr) {
+			call, ok := expr.(*build.CallExpr)
+			if !ok {
+				return
+			}
+			fc, ok := funCalls[calleeName(call)]
+			if !ok || !macroAnalyzer.IsMacro(fc.function).isMacro {
+				return
+			}
+			if passesKwargs(call) {
+				forwarding = append(forwarding, call)
+			} else {
+				other = append(other, call)
+			}
+		})
+
+		if len(forwarding) > 0 {
+			// The targets created next to the main one should be testonly if the main one is.
+			for _, call := range other {
+				if hasKeywordArgument(call, "testonly") {
+					continue
+				}
+				findings = append(findings, makeLinterFinding(call.X, fmt.Sprintf(
+					`The macro %q forwards **%s to some of the rules it instantiates but not to %q, which doesn't set "testonly". `+
+						`If the macro is called with testonly = True, the targets may fail to depend on each other. `+
+						`Pass testonly = %s.get("testonly") to it explicitly.`,
+					def.Name, kwargs, calleeName(call), kwargs)))
+			}
+			continue
+		}
+		if len(other) == 0 {
+			// Not a macro.
+			continue
+		}
+
+		keys, opaque := kwargsUsage(def, kwargs)
+		if opaque {
+			continue
+		}
+		var dropped []string
+		for _, attr := range forwardedAttributes {
+			if !keys[attr] {
+				dropped = append(dropped, fmt.Sprintf("%q", attr))
+			}
+		}
+		if len(dropped) == 0 {
+			continue
+		}
+		finding := makeLinterFinding(def, fmt.Sprintf(
+			`The macro %q accepts **%s but doesn't pass it to the rules it instantiates, so the %s attributes of its callers are silently dropped. `+
+				`Forward them with "**%s" or handle them explicitly.`,
+			def.Name, kwargs, strings.Join(dropped, ", "), kwargs))
+		finding.End = def.ColonPos
+		findings = append(findings, finding)
+	}
+	return findings
+}
@@ -435,3 +435,53 @@ def macro(name: string = "default"):
 		[]string{},
 		scopeBzl)
 }
+
+func TestMacroKwargsForwarding(t *testing.T) {
+	defer setUpFileReader(map[string]string{
+		"test/package/rules.bzl": `
+my_rule = rule()
+`,
+	})()
+
+	checkFindings(t, "macro-kwargs-forwarding", `
+load(":rules.bzl", "my_rule")
+
+def dropped(name, **kwargs):
+    my_rule(name = name)
+
+def partially_handled(name, **attrs):
+    my_rule(
+        name = name,
+        visibility = attrs.get("visibility"),
+        tags = attrs.pop("tags", []),
+    )
+
+def handled(name, **kwargs):
+    my_rule(
+        name = name,
+        visibility = kwargs.get("visibility"),
+        tags = kwargs["tags"] if "tags" in kwargs else [],
+        testonly = kwargs.get("testonly"),
+    )
+
+def opaque(name, **kwargs):
+    my_rule(name = name, **_helper(kwargs))
+
+def forwarded(name, **kwargs):
+    native.genrule(name = name + "_gen")
+    native.filegroup(name = name + "_files", testonly = kwargs.get("testonly"))
+    my_rule(name = name, **kwargs)
+
+def not_a_macro(**kwargs):
+    return _helper(kwargs)
+
+def _helper(kwargs):
+    return kwargs
+`,
+		[]string{
+			`:3: The macro "dropped" accepts **kwargs but doesn't pass it to the rules it instantiates, so the "testonly", "visibility", "tags" attributes of its callers are silently dropped.`,
+			`:6: The macro "partially_handled" accepts **attrs but doesn't pass it to the rules it instantiates, so the "testonly" attributes of its callers are silently dropped.`,
+			`:25: The macro "forwarded" forwards **kwargs to some of the rules it instantiates but not to "native.genrule", which doesn't set "testonly".`,
+		},
+		scopeBzl)
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about the size and the complexity of BUILD and .bzl files
+
+package warn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// MaxFunctionStatements is the number of statements a function may contain before the
+// function-length warning reports it.
+var MaxFunctionStatements = 50
+
+// MaxExportedSymbols is the number of public symbols a .bzl file may export before the
+// exported-symbols warning reports it.
+var MaxExportedSymbols = 40
+
+// MaxMacroDepth is the number of nested macro layers a macro may consist of before the
+// macro-depth warning reports it.
+var MaxMacroDepth = 3
+
+// MaxBuildTargets is the number of targets a BUILD file may define before the
+// oversized-build-file warning reports it.
+var MaxBuildTargets = 500
+
+// MaxBuildFileBytes is the size of a BUILD file, in bytes, before the
+// oversized-build-file warning reports it.
+var MaxBuildFileBytes = 200000
+
+// MaxSelectBranches is the number of select() branches a BUILD file may contain in total before
+// the oversized-build-file warning reports it.
+var MaxSelectBranches = 100
+
+// splitSuggestions is the number of subdirectories suggested as new packages by the
+// oversized-build-file warning.
+const splitSuggestions = 3
+
+// countStatements returns the number of statements in a block, including the nested ones.
+func countStatements(stmts []build.Expr) int {
+	count := 0
+	for _, stmt := range stmts {
+		count++
+		switch stmt := stmt.(type) {
+		case *build.DefStmt:
+			count += countStatements(stmt.Body)
+		case *build.ForStmt:
+			count += countStatements(stmt.Body)
+		case *build.IfStmt:
+			count += countStatements(stmt.True)
+			count += countStatements(stmt.False)
+		}
+	}
+	return count
+}
+
+func functionLengthWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		def, ok := stmt.(*build.DefStmt)
+		if !ok {
+			continue
+		}
+		body := def.Body
+		if _, ok := getDocstring(body); ok {
+			body = body[1:]
+		}
+		if n := countStatements(body); n > MaxFunctionStatements {
+			finding := makeLinterFinding(def, fmt.Sprintf(
+				"The function %q has %d statements (the limit is %d), consider splitting it into smaller functions.",
+				def.Name, n, MaxFunctionStatements))
+			finding.End = def.ColonPos
+			finding.Metrics = map[string]int{"statements": n}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// exportedSymbols returns the top-level bindings of a .bzl file that can be loaded from other
+// files, in the order of their definitions.
+func exportedSymbols(f *build.File) []*build.Ident {
+	var symbols []*build.Ident
+	seen := make(map[string]bool)
+	add := func(ident *build.Ident) {
+		if strings.HasPrefix(ident.Name, "_") || seen[ident.Name] {
+			return
+		}
+		seen[ident.Name] = true
+		symbols = append(symbols, ident)
+	}
+	for _, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.DefStmt:
+			add(&build.Ident{Name: stmt.Name, NamePos: stmt.StartPos})
+		case *build.AssignExpr:
+			for _, ident := range bzlLValues(stmt.LHS) {
+				add(ident)
+			}
+		}
+	}
+	return symbols
+}
+
+// bzlLValues returns the identifiers an assignment binds, including the ones in tuple unpacking.
+func bzlLValues(lhs build.Expr) []*build.Ident {
+	switch lhs := lhs.(type) {
+	case *build.Ident:
+		return []*build.Ident{lhs}
+	case *build.TupleExpr:
+		var idents []*build.Ident
+		for _, elem := range lhs.List {
+			idents = append(idents, bzlLValues(elem)...)
+		}
+		return idents
+	case *build.ListExpr:
+		var idents []*build.Ident
+		for _, elem := range lhs.List {
+			idents = append(idents, bzlLValues(elem)...)
+		}
+		return idents
+	}
+	return nil
+}
+
+func exportedSymbolsWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+	symbols := exportedSymbols(f)
+	if len(symbols) <= MaxExportedSymbols {
+		return nil
+	}
+	// Report the first symbol over the limit, that's where the file should be split.
+	finding := makeLinterFinding(symbols[MaxExportedSymbols], fmt.Sprintf(
+		"The file exports %d public symbols (the limit is %d), consider splitting it or making some of them private.",
+		len(symbols), MaxExportedSymbols))
+	finding.Metrics = map[string]int{"symbols": len(symbols)}
+	return []*LinterFinding{finding}
+}
+
+// macroDepth returns the deepest chain of nested macros starting with the given function, its
+// length is the nesting depth. The chain is empty for rules, native functions and ordinary
+// functions that are not macros.
+func (ma macroAnalyzer) macroDepth(fn function, depths map[function][]string) []string {
+	if chain, ok := depths[fn]; ok {
+		return chain
+	}
+	// Recursive calls don't add to the depth.
+	depths[fn] = nil
+
+	var chain []string
+	if fn.filename != nativeModule {
+		fileData := ma.getFileData(fn.pkg, fn.filename)
+		if alias, ok := fileData.aliases[fn.name]; ok {
+			chain = ma.macroDepth(alias, depths)
+		} else if funCalls, ok := fileData.functions[fn.name]; ok && ma.IsMacro(fn).isMacro {
+			var deepest []string
+			for _, name := range sortedKeys(funCalls) {
+				fc := funCalls[name]
+				if !ma.IsMacro(fc.function).isMacro {
+					continue
+				}
+				if sub := ma.macroDepth(fc.function, depths); len(sub) > len(deepest) {
+					deepest = sub
+				}
+			}
+			chain = append([]string{fn.name}, deepest...)
+		}
+	}
+	depths[fn] = chain
+	return chain
+}
+
+// sortedKeys returns the keys of a map in alphabetical order.
+func sortedKeys[V any](m map[string]V) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func macroDepthWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	macroAnalyzer := newMacroAnalyzer(fileReader)
+	macroAnalyzer.files[f.Pkg+":"+f.Label] = analyzeFile(f)
+	depths := make(map[function][]string)
+
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		def, ok := stmt.(*build.DefStmt)
+		if !ok {
+			continue
+		}
+		chain := macroAnalyzer.macroDepth(function{f.Pkg, f.Label, def.Name}, depths)
+		if len(chain) <= MaxMacroDepth {
+			continue
+		}
+		finding := makeLinterFinding(def, fmt.Sprintf(
+			"The macro %q has a nesting depth of %d (the limit is %d): %s. Deeply nested macros are hard to debug, consider calling the rules more directly.",
+			def.Name, len(chain), MaxMacroDepth, strings.Join(chain, " -> ")))
+		finding.End = def.ColonPos
+		finding.Metrics = map[string]int{"depth": len(chain)}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// selectBranches returns the total number of branches of the select() calls in a file.
+func selectBranches(f *build.File) int {
+	branches := 0
+	build.Walk(f, func(x build.Expr, stk []build.Expr) {
+		call, ok := x.(*build.CallExpr)
+		if !ok || len(call.List) == 0 {
+			return
+		}
+		if ident, ok := call.X.(*build.Ident); !ok || ident.Name != "select" {
+			return
+		}
+		if dict, ok := call.List[0].(*build.DictExpr); ok {
+			branches += len(dict.List)
+		}
+	})
+	return branches
+}
+
+// sourceAttrs are the attributes whose files determine the subdirectory a target belongs to.
+var sourceAttrs = []string{"srcs", "hdrs", "textual_hdrs", "data", "resources"}
+
+// sourceSubdirectory returns the subdirectory of the package that contains all the source files
+// of a target, or an empty string if the target has no source files in subdirectories or they're
+// spread over several ones.
+func sourceSubdirectory(r *build.Rule) string {
+	var srcs []string
+	for _, attr := range sourceAttrs {
+		if value := r.Attr(attr); value != nil {
+			// Include the files of the select() branches and of concatenations.
+			build.Walk(value, func(x build.Expr, stk []build.Expr) {
+				if str, ok := x.(*build.StringExpr); ok {
+					srcs = append(srcs, str.Value)
+				}
+			})
+		}
+	}
+	subdir := ""
+	for _, src := range srcs {
+		if strings.HasPrefix(src, ":") || strings.HasPrefix(src, "//") || strings.HasPrefix(src, "@") {
+			continue
+		}
+		dir, _, ok := strings.Cut(src, "/")
+		if !ok || (subdir != "" && dir != subdir) {
+			return ""
+		}
+		subdir = dir
+	}
+	return subdir
+}
+
+// packageSplits returns the subdirectories whose targets could be moved to their own packages,
+// with the number of targets, the ones with the most targets first.
+func packageSplits(rules []*build.Rule) []string {
+	counts := make(map[string]int)
+	for _, r := range rules {
+		if subdir := sourceSubdirectory(r); subdir != "" {
+			counts[subdir]++
+		}
+	}
+	var subdirs []string
+	for subdir := range counts {
+		subdirs = append(subdirs, subdir)
+	}
+	sort.Slice(subdirs, func(i, j int) bool {
+		if counts[subdirs[i]] != counts[subdirs[j]] {
+			return counts[subdirs[i]] > counts[subdirs[j]]
+		}
+		return subdirs[i] < subdirs[j]
+	})
+	if len(subdirs) > splitSuggestions {
+		subdirs = subdirs[:splitSuggestions]
+	}
+	var splits []string
+	for _, subdir := range subdirs {
+		noun := "targets"
+		if counts[subdir] == 1 {
+			noun = "target"
+		}
+		splits = append(splits, fmt.Sprintf("%s (%d %s)", subdir, counts[subdir], noun))
+	}
+	return splits
+}
+
+func oversizedBuildFileWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBuild || len(f.Stmt) == 0 {
+		return nil
+	}
+	var targets []*build.Rule
+	for _, r := range f.Rules("") {
+		if r.ExplicitName() != "" {
+			targets = append(targets, r)
+		}
+	}
+	// The size of the file up to the end of its last statement.
+	_, end := f.Span()
+	bytes := end.Byte
+	branches := selectBranches(f)
+
+	var exceeded []string
+	if len(targets) > MaxBuildTargets {
+		exceeded = append(exceeded, fmt.Sprintf("%d targets (the limit is %d)", len(targets), MaxBuildTargets))
+	}
+	if bytes > MaxBuildFileBytes {
+		exceeded = append(exceeded, fmt.Sprintf("%d bytes (the limit is %d)", bytes, MaxBuildFileBytes))
+	}
+	if branches > MaxSelectBranches {
+		exceeded = append(exceeded, fmt.Sprintf("%d select branches (the limit is %d)", branches, MaxSelectBranches))
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("The BUILD file has %s, consider splitting the package.", strings.Join(exceeded, ", "))
+	if splits := packageSplits(targets); len(splits) > 0 {
+		msg += fmt.Sprintf(" The targets whose sources are all in a subdirectory could be moved to a package there, e.g. %s.", strings.Join(splits, ", "))
+	}
+	// The finding is about the whole file, report it on the first statement.
+	start, _ := f.Stmt[0].Span()
+	finding := makeLinterFinding(f.Stmt[0], msg)
+	finding.End = build.Position{
+		Line:     start.Line,
+		LineRune: start.LineRune + 1,
+		Byte:     start.Byte + 1,
+	}
+	finding.Metrics = map[string]int{
+		"targets":         len(targets),
+		"bytes":           bytes,
+		"select_branches": branches,
+	}
+	return []*LinterFinding{finding}
+}
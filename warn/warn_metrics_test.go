@@ -0,0 +1,218 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+func TestFunctionLength(t *testing.T) {
+	defer func(n int) { MaxFunctionStatements = n }(MaxFunctionStatements)
+	MaxFunctionStatements = 4
+
+	checkFindings(t, "function-length", `
+def short():
+    """Docstring."""
+    a = 1
+    b = 2
+    c = 3
+    return a + b + c
+
+def long(x):
+    x = sorted(x)
+    for y in x:
+        if y:
+            print(y)
+        else:
+            fail(y)
+
+def _nested():
+    def inner():
+        pass
+    a = 1
+    b = 2
+    c = 3
+`,
+		[]string{
+			`:8: The function "long" has 5 statements (the limit is 4), consider splitting it into smaller functions.`,
+			`:16: The function "_nested" has 5 statements (the limit is 4), consider splitting it into smaller functions.`,
+		},
+		scopeBzl)
+}
+
+func TestExportedSymbols(t *testing.T) {
+	defer func(n int) { MaxExportedSymbols = n }(MaxExportedSymbols)
+	MaxExportedSymbols = 3
+
+	checkFindings(t, "exported-symbols", `
+load(":a.bzl", "loaded")
+
+A = 1
+_PRIVATE = 2
+B, (C, _D) = 3, (4, 5)
+
+def _helper():
+    pass
+
+def public():
+    pass
+
+A = 6
+`,
+		[]string{
+			`:10: The file exports 4 public symbols (the limit is 3), consider splitting it or making some of them private.`,
+		},
+		scopeBzl)
+
+	checkFindings(t, "exported-symbols", `
+A = 1
+B = 2
+
+def c():
+    pass
+`,
+		[]string{},
+		scopeBzl)
+}
+
+func TestMacroDepth(t *testing.T) {
+	defer func(n int) { MaxMacroDepth = n }(MaxMacroDepth)
+	MaxMacroDepth = 2
+
+	defer setUpFileReader(map[string]string{
+		"test/package/inner.bzl": `
+my_rule = rule()
+
+def inner_macro(name):
+    my_rule(name = name)
+
+def helper():
+    return 1
+`,
+	})()
+
+	checkFindings(t, "macro-depth", `
+load(":inner.bzl", "helper", "inner_macro")
+
+def middle(name):
+    inner_macro(name = name)
+    helper()
+
+def outer(name):
+    middle(name = name)
+    native.cc_library(name = name + "_lib")
+
+outer_alias = outer
+
+def top(name):
+    outer_alias(name = name)
+
+def recursive(name):
+    recursive(name = name)
+    native.filegroup(name = name)
+
+def not_a_macro():
+    helper()
+`,
+		[]string{
+			`:7: The macro "outer" has a nesting depth of 3 (the limit is 2): outer -> middle -> inner_macro.`,
+			`:13: The macro "top" has a nesting depth of 4 (the limit is 2): top -> outer -> middle -> inner_macro.`,
+		},
+		scopeBzl)
+}
+
+func TestOversizedBuildFile(t *testing.T) {
+	defer func(targets, bytes, branches int) {
+		MaxBuildTargets, MaxBuildFileBytes, MaxSelectBranches = targets, bytes, branches
+	}(MaxBuildTargets, MaxBuildFileBytes, MaxSelectBranches)
+	MaxBuildTargets = 3
+	MaxSelectBranches = 2
+
+	checkFindings(t, "oversized-build-file", `
+cc_library(
+    name = "a",
+    srcs = ["a/a.cc"],
+    hdrs = ["a/a.h"],
+)
+
+cc_library(
+    name = "b",
+    srcs = ["b/b.cc"] + select({
+        ":x": ["b/x.cc"],
+        "//conditions:default": [],
+    }),
+)
+
+cc_library(
+    name = "c",
+    srcs = ["a/c.cc", "b/c.cc"],
+)
+
+cc_test(
+    name = "a_test",
+    srcs = ["a/a_test.cc"],
+    data = [":b"],
+)
+
+exports_files(["a/a.txt"])
+`,
+		[]string{
+			`:1: The BUILD file has 4 targets (the limit is 3), consider splitting the package. The targets whose sources are all in a subdirectory could be moved to a package there, e.g. a (2 targets), b (1 target).`,
+		},
+		scopeBuild)
+
+	MaxBuildTargets = 10
+	MaxBuildFileBytes = 10
+	checkFindings(t, "oversized-build-file", `
+cc_library(
+    name = "a",
+    srcs = select({
+        ":x": ["x.cc"],
+        ":y": ["y.cc"],
+        "//conditions:default": [],
+    }),
+)
+`,
+		[]string{
+			`:1: The BUILD file has 141 bytes (the limit is 10), 3 select branches (the limit is 2), consider splitting the package.`,
+		},
+		scopeBuild)
+}
+
+func TestOversizedBuildFileMetrics(t *testing.T) {
+	defer func(n int) { MaxBuildTargets = n }(MaxBuildTargets)
+	MaxBuildTargets = 1
+
+	f, err := build.ParseBuild("BUILD", []byte(`cc_library(name = "a")
+
+cc_library(name = "b", srcs = select({":x": ["b.cc"]}))
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings := oversizedBuildFileWarning(f)
+	if len(findings) != 1 {
+		t.Fatalf("oversizedBuildFileWarning() returned %d findings, want 1", len(findings))
+	}
+	want := map[string]int{"targets": 2, "bytes": 79, "select_branches": 1}
+	if got := findings[0].Metrics; !reflect.DeepEqual(got, want) {
+		t.Errorf("oversizedBuildFileWarning() metrics = %v, want %v", got, want)
+	}
+}
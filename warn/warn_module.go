@@ -0,0 +1,629 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about the structure of MODULE.bazel files
+
+package warn
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/edit"
+	"github.com/bazelbuild/buildtools/edit/bzlmod"
+	"github.com/bazelbuild/buildtools/labels"
+	"github.com/bazelbuild/buildtools/tables"
+)
+
+// The groups of MODULE.bazel statements in their canonical order.
+const (
+	moduleGroupModule = iota
+	moduleGroupBazelDep
+	moduleGroupDevBazelDep
+	moduleGroupOverride
+	moduleGroupRegistration
+	moduleGroupExtension
+	// Statements that can't be moved, e.g. include() calls or assignments of variables used
+	// by other statements. The statements between two of them are grouped separately.
+	moduleGroupBarrier
+)
+
+// moduleStmt is a top-level statement of a MODULE.bazel file, together with the standalone
+// comment blocks preceding it.
+type moduleStmt struct {
+	stmts []build.Expr
+	group int
+	// name is the module name of a bazel_dep, used to sort them.
+	name string
+	// proxy is the extension proxy (or repo rule proxy) a statement defines or uses.
+	proxy string
+}
+
+// classifyModuleStmt returns the group of a MODULE.bazel statement, and the module name of
+// bazel_deps or the proxy extension usages refer to.
+func classifyModuleStmt(stmt build.Expr) (group int, name, proxy string) {
+	switch stmt := stmt.(type) {
+	case *build.AssignExpr:
+		lhs, ok := stmt.LHS.(*build.Ident)
+		if !ok {
+			break
+		}
+		if call, ok := stmt.RHS.(*build.CallExpr); ok {
+			if fn, ok := call.X.(*build.Ident); ok && (fn.Name == "use_extension" || fn.Name == "use_repo_rule") {
+				return moduleGroupExtension, "", lhs.Name
+			}
+		}
+	case *build.CallExpr:
+		switch fn := stmt.X.(type) {
+		case *build.Ident:
+			switch {
+			case fn.Name == "module":
+				return moduleGroupModule, "", ""
+			case fn.Name == "bazel_dep":
+				rule := &build.Rule{Call: stmt}
+				if dev, ok := rule.Attr("dev_dependency").(*build.Ident); ok && dev.Name == "True" {
+					return moduleGroupDevBazelDep, rule.AttrString("name"), ""
+				}
+				return moduleGroupBazelDep, rule.AttrString("name"), ""
+			case tables.IsModuleOverride[fn.Name]:
+				return moduleGroupOverride, "", ""
+			case fn.Name == "register_toolchains" || fn.Name == "register_execution_platforms":
+				return moduleGroupRegistration, "", ""
+			case fn.Name == "use_repo" && len(stmt.List) > 0:
+				if proxy, ok := stmt.List[0].(*build.Ident); ok {
+					return moduleGroupExtension, "", proxy.Name
+				}
+			}
+		case *build.DotExpr:
+			// A tag of a module extension, or a call of a repo rule proxy.
+			if proxy, ok := fn.X.(*build.Ident); ok {
+				return moduleGroupExtension, "", proxy.Name
+			}
+		}
+	}
+	return moduleGroupBarrier, "", ""
+}
+
+// sortModuleStmts returns the statements of a part of a MODULE.bazel file between two
+// barriers in their canonical order: module() first, then the bazel_deps sorted by name (the
+// dev dependencies after the other ones), the overrides, the toolchain registrations and the
+// extension usages, each one followed by its tags and use_repo() calls.
+func sortModuleStmts(stmts []*moduleStmt) []*moduleStmt {
+	// Gather the tags and the use_repo() calls of each extension usage.
+	var extensions [][]*moduleStmt
+	extensionIndex := make(map[string]int)
+	var sorted []*moduleStmt
+	for _, stmt := range stmts {
+		if stmt.group != moduleGroupExtension {
+			sorted = append(sorted, stmt)
+			continue
+		}
+		i, ok := extensionIndex[stmt.proxy]
+		if !ok {
+			// The proxy may be defined before the last barrier.
+			i = len(extensions)
+			extensionIndex[stmt.proxy] = i
+			extensions = append(extensions, nil)
+		}
+		extensions[i] = append(extensions[i], stmt)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].group != sorted[j].group {
+			return sorted[i].group < sorted[j].group
+		}
+		return sorted[i].name < sorted[j].name
+	})
+	for _, extension := range extensions {
+		sorted = append(sorted, extension...)
+	}
+	return sorted
+}
+
+func moduleOrderWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeModule {
+		return nil
+	}
+
+	var order []build.Expr
+	var part []*moduleStmt
+	var comments []build.Expr
+	flush := func() {
+		for _, stmt := range sortModuleStmts(part) {
+			order = append(order, stmt.stmts...)
+		}
+		part = nil
+	}
+	for _, stmt := range f.Stmt {
+		if _, ok := stmt.(*build.CommentBlock); ok {
+			// Standalone comments are moved together with the next statement.
+			comments = append(comments, stmt)
+			continue
+		}
+		group, name, proxy := classifyModuleStmt(stmt)
+		if group == moduleGroupBarrier {
+			flush()
+			order = append(append(order, comments...), stmt)
+		} else {
+			part = append(part, &moduleStmt{append(comments, stmt), group, name, proxy})
+		}
+		comments = nil
+	}
+	flush()
+	order = append(order, comments...)
+
+	var finding *LinterFinding
+	for i, stmt := range order {
+		if f.Stmt[i] == stmt {
+			continue
+		}
+		if finding == nil {
+			finding = makeLinterFinding(f.Stmt[i], `The statements of the MODULE.bazel file are not in the canonical order: module() first, then bazel_dep() sorted by name (dev dependencies last), overrides, toolchain registrations and extension usages followed by their tags and use_repo() calls.`)
+		}
+		finding.Replacement = append(finding.Replacement, LinterReplacement{&f.Stmt[i], stmt})
+	}
+	if finding == nil {
+		return nil
+	}
+	return []*LinterFinding{finding}
+}
+
+// ToolchainModules lists the modules that are only bazel_deps for the toolchains they register
+// (e.g. toolchains_llvm), which the unused-bazel-dep warning doesn't report.
+var ToolchainModules []string
+
+// referencedRepos adds the repositories of the labels used in a file to repos.
+func referencedRepos(f *build.File, repos map[string]bool) {
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		if str, ok := expr.(*build.StringExpr); ok && strings.HasPrefix(str.Value, "@") {
+			repos[labels.Parse(str.Value).Repository] = true
+		}
+	})
+}
+
+func unusedBazelDepWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeModule {
+		return nil
+	}
+
+	type bazelDep struct {
+		call         *build.CallExpr
+		module, repo string
+	}
+	toolchainModules := make(map[string]bool)
+	for _, module := range ToolchainModules {
+		toolchainModules[module] = true
+	}
+	var deps []bazelDep
+	for _, stmt := range f.Stmt {
+		call, ok := stmt.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		if fn, ok := call.X.(*build.Ident); !ok || fn.Name != "bazel_dep" || edit.HasKeepComment(call) {
+			continue
+		}
+		rule := &build.Rule{Call: call}
+		module := rule.AttrString("name")
+		if module == "" || toolchainModules[module] {
+			continue
+		}
+		repo := module
+		if attr := rule.Attr("repo_name"); attr != nil {
+			str, ok := attr.(*build.StringExpr)
+			if !ok {
+				// repo_name = None, the module isn't visible as a repository.
+				continue
+			}
+			repo = str.Value
+		}
+		deps = append(deps, bazelDep{call, module, repo})
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+
+	files := fileReader.StarlarkFiles()
+	if files == nil {
+		// The files of the repository can't be listed.
+		return nil
+	}
+	repos := make(map[string]bool)
+	referencedRepos(f, repos)
+	for _, filename := range files {
+		pkg, label := path.Split(filename)
+		if file := fileReader.GetFile(strings.TrimSuffix(pkg, "/"), label); file != nil {
+			referencedRepos(file, repos)
+		}
+	}
+
+	var findings []*LinterFinding
+	for _, dep := range deps {
+		if repos[dep.repo] {
+			continue
+		}
+		findings = append(findings, makeLinterFinding(dep.call, fmt.Sprintf(
+			`The repository "@%s" of the bazel_dep %q isn't referenced by any BUILD, .bzl or MODULE.bazel file of the repository, remove the unused dependency or mark it with a "# keep" comment.`,
+			dep.repo, dep.module)))
+	}
+	return findings
+}
+
+// moduleVersionCalls are the functions of MODULE.bazel files with a module version attribute,
+// with the attribute holding the name of the module.
+var moduleVersionCalls = map[string]string{
+	"module":                  "name",
+	"bazel_dep":               "name",
+	"single_version_override": "module_name",
+}
+
+// nonRegistryOverrides are the overrides that fetch a module from elsewhere than a registry, in
+// which case its bazel_dep doesn't need a version.
+var nonRegistryOverrides = map[string]bool{
+	"archive_override":    true,
+	"git_override":        true,
+	"local_path_override": true,
+}
+
+func moduleVersionWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeModule {
+		return nil
+	}
+
+	overridden := make(map[string]bool)
+	for _, r := range f.Rules("") {
+		if nonRegistryOverrides[r.Kind()] || r.Kind() == "single_version_override" {
+			overridden[r.AttrString("module_name")] = true
+		}
+	}
+
+	var findings []*LinterFinding
+	for i, stmt := range f.Stmt {
+		call, ok := stmt.(*build.CallExpr)
+		if !ok {
+			continue
+		}
+		r := &build.Rule{Call: call}
+		nameAttr, ok := moduleVersionCalls[r.Kind()]
+		if !ok {
+			continue
+		}
+		module := r.AttrString(nameAttr)
+		what := fmt.Sprintf("%s(%q)", r.Kind(), module)
+		if r.Kind() == "module" {
+			what = "module()"
+		}
+
+		attr := r.AttrDefn("version")
+		if attr == nil {
+			if r.Kind() == "bazel_dep" && !overridden[module] {
+				findings = append(findings, makeLinterFinding(call,
+					fmt.Sprintf("The %s has no version, which is only meant for the modules with a non-registry override such as local_path_override.", what)))
+			}
+			continue
+		}
+		str, ok := attr.RHS.(*build.StringExpr)
+		if !ok {
+			continue
+		}
+		version := str.Value
+
+		switch trimmed := strings.TrimSpace(version); {
+		case version == "":
+			if r.Kind() == "bazel_dep" {
+				if !overridden[module] {
+					findings = append(findings, makeLinterFinding(str,
+						fmt.Sprintf("The version of %s is empty, which is only meant for the modules with a non-registry override such as local_path_override.", what)))
+				}
+				continue
+			}
+			// An empty version is the default, the attribute can be removed.
+			newCall := *call
+			newCall.List = nil
+			for _, arg := range call.List {
+				if arg != attr {
+					newCall.List = append(newCall.List, arg)
+				}
+			}
+			findings = append(findings, makeLinterFinding(str,
+				fmt.Sprintf("The version of %s is empty, which is the default.", what),
+				LinterReplacement{&f.Stmt[i], &newCall}))
+		case trimmed != version && trimmed != "" && bzlmod.ValidateVersion(trimmed) == nil:
+			findings = append(findings, makeLinterFinding(str,
+				fmt.Sprintf("The version %q of %s has leading or trailing whitespace.", version, what),
+				LinterReplacement{&attr.RHS, &build.StringExpr{Value: trimmed}}))
+		case bzlmod.ValidateVersion(version) != nil:
+			findings = append(findings, makeLinterFinding(str,
+				fmt.Sprintf(`The version %q of %s is invalid, module versions consist of dot-separated numbers or identifiers optionally followed by a "-" prerelease and a "+" build suffix, e.g. "1.2.3" or "2.0.0-rc.1".`, version, what)))
+		case len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && version[1] >= '0' && version[1] <= '9':
+			findings = append(findings, makeLinterFinding(str,
+				fmt.Sprintf(`The version %q of %s starts with "v", module versions don't, use %q.`, version, what, version[1:]),
+				LinterReplacement{&attr.RHS, &build.StringExpr{Value: version[1:]}}))
+		}
+	}
+	return findings
+}
+
+// A tagClassSchema lists the attributes of a tag class of a module extension.
+type tagClassSchema struct {
+	// attrs maps the names of the attributes to the functions defining them, e.g. string_list,
+	// or to an empty string if unknown.
+	attrs map[string]string
+	// complete is false if some attributes are unknown, e.g. because they come from a function
+	// call, in which case the attributes of the tags aren't checked.
+	complete bool
+}
+
+// tagClassSchemas returns the tag classes of a module extension defined in a .bzl file, or nil
+// if the extension or its tag classes can't be found.
+func tagClassSchemas(f *build.File, extension string) map[string]*tagClassSchema {
+	defs, globals := documentedDefinitions(f)
+	for _, d := range defs {
+		if d.kind != "module_extension" || d.name != extension {
+			continue
+		}
+		schemas := make(map[string]*tagClassSchema)
+		_, _, param := getParam(d.call.List, "tag_classes")
+		if param == nil {
+			return schemas
+		}
+		dict, ok := resolveGlobal(param.RHS, globals).(*build.DictExpr)
+		if !ok {
+			return nil
+		}
+		for _, kv := range dict.List {
+			key, ok := kv.Key.(*build.StringExpr)
+			if !ok {
+				return nil
+			}
+			schema := &tagClassSchema{attrs: make(map[string]string)}
+			schemas[key.Value] = schema
+			call, ok := isFunctionCall(resolveGlobal(kv.Value, globals), "tag_class")
+			if !ok {
+				continue
+			}
+			attrs := definitionAttrs(&documentedDefinition{call: call, kind: "tag_class"})
+			if attrs == nil {
+				schema.complete = true
+				continue
+			}
+			entries, complete := attrEntries(attrs, globals)
+			schema.complete = complete
+			for _, entry := range entries {
+				name, ok := entry.Key.(*build.StringExpr)
+				if !ok {
+					schema.complete = false
+					continue
+				}
+				schema.attrs[name.Value] = attrType(entry.Value)
+			}
+		}
+		return schemas
+	}
+	return nil
+}
+
+// An attrValueType is the type of literal values accepted by an attribute of a tag class.
+type attrValueType struct {
+	kind string
+	// elem is the kind of the elements of a list, or of the values of a dict.
+	elem string
+}
+
+// attrValueTypes maps the functions defining attributes to the types of their values. The keys
+// of the dicts are strings (labels or strings).
+var attrValueTypes = map[string]attrValueType{
+	"bool":                    {kind: "bool"},
+	"int":                     {kind: "int"},
+	"int_list":                {kind: "list", elem: "int"},
+	"label":                   {kind: "string"},
+	"label_keyed_string_dict": {kind: "dict", elem: "string"},
+	"label_list":              {kind: "list", elem: "string"},
+	"string":                  {kind: "string"},
+	"string_dict":             {kind: "dict", elem: "string"},
+	"string_keyed_label_dict": {kind: "dict", elem: "string"},
+	"string_list":             {kind: "list", elem: "string"},
+	"string_list_dict":        {kind: "dict", elem: "list"},
+}
+
+// literalKinds describes the kinds of literal values, with their plurals.
+var literalKinds = map[string][2]string{
+	"bool":   {"a boolean", "booleans"},
+	"dict":   {"a dict", "dicts"},
+	"int":    {"an integer", "integers"},
+	"list":   {"a list", "lists"},
+	"string": {"a string", "strings"},
+}
+
+// literalKind returns the kind of a literal value, or an empty string if the expression isn't
+// a literal, e.g. a variable or None.
+func literalKind(expr build.Expr) string {
+	switch expr := expr.(type) {
+	case *build.StringExpr:
+		return "string"
+	case *build.LiteralExpr:
+		if _, err := strconv.ParseInt(expr.Token, 0, 64); err == nil {
+			return "int"
+		}
+	case *build.UnaryExpr:
+		if expr.Op == "-" || expr.Op == "+" {
+			if literalKind(expr.X) == "int" {
+				return "int"
+			}
+		}
+	case *build.Ident:
+		if expr.Name == "True" || expr.Name == "False" {
+			return "bool"
+		}
+	case *build.ListExpr:
+		return "list"
+	case *build.DictExpr:
+		return "dict"
+	}
+	return ""
+}
+
+// checkAttrValue returns the part of the value of an attribute whose kind doesn't match its
+// type, and a description of what it is, or nil if the value is compatible or unknown.
+func checkAttrValue(value build.Expr, t attrValueType) (build.Expr, string) {
+	kind := literalKind(value)
+	if kind == "" {
+		return nil, ""
+	}
+	if kind != t.kind {
+		return value, literalKinds[kind][0]
+	}
+	var elems []build.Expr
+	switch value := value.(type) {
+	case *build.ListExpr:
+		elems = value.List
+	case *build.DictExpr:
+		for _, kv := range value.List {
+			if kind := literalKind(kv.Key); kind != "" && kind != "string" {
+				return kv.Key, fmt.Sprintf("a dict with %s key", literalKinds[kind][0])
+			}
+			elems = append(elems, kv.Value)
+		}
+	}
+	for _, elem := range elems {
+		if kind := literalKind(elem); kind != "" && kind != t.elem {
+			return elem, fmt.Sprintf("%s containing %s", literalKinds[t.kind][0], literalKinds[kind][0])
+		}
+	}
+	return nil, ""
+}
+
+// describeAttrValueType returns the description of a type, e.g. "a list of strings".
+func describeAttrValueType(t attrValueType) string {
+	switch t.kind {
+	case "list":
+		return "a list of " + literalKinds[t.elem][1]
+	case "dict":
+		return "a dict of strings to " + literalKinds[t.elem][1]
+	}
+	return literalKinds[t.kind][0]
+}
+
+func moduleExtensionTagWarning(f *build.File, fileReader *FileReader) []*LinterFinding {
+	if f.Type != build.TypeModule || fileReader == nil {
+		return nil
+	}
+
+	// The name of the repository of the module, which the labels of its files may use.
+	moduleRepo := ""
+	for _, r := range f.Rules("module") {
+		moduleRepo = r.AttrString("name")
+		if repoName := r.AttrString("repo_name"); repoName != "" {
+			moduleRepo = repoName
+		}
+	}
+
+	type extensionUsage struct {
+		label, name string
+		schemas     map[string]*tagClassSchema
+	}
+	usages := make(map[string]*extensionUsage)
+	var findings []*LinterFinding
+	for _, stmt := range f.Stmt {
+		switch stmt := stmt.(type) {
+		case *build.AssignExpr:
+			// An extension usage, e.g. deps = use_extension("//:extensions.bzl", "deps").
+			lhs, ok := stmt.LHS.(*build.Ident)
+			if !ok {
+				continue
+			}
+			call, ok := stmt.RHS.(*build.CallExpr)
+			if !ok || len(call.List) < 2 {
+				delete(usages, lhs.Name)
+				continue
+			}
+			fn, ok := call.X.(*build.Ident)
+			bzl, okBzl := call.List[0].(*build.StringExpr)
+			name, okName := call.List[1].(*build.StringExpr)
+			if !ok || fn.Name != "use_extension" || !okBzl || !okName {
+				delete(usages, lhs.Name)
+				continue
+			}
+			label := labels.ParseRelative(bzl.Value, f.Pkg)
+			if label.Repository != "" && label.Repository != moduleRepo {
+				// The extensions of the other modules can't be read.
+				delete(usages, lhs.Name)
+				continue
+			}
+			var schemas map[string]*tagClassSchema
+			if file := fileReader.GetFile(label.Package, label.Target); file != nil {
+				schemas = tagClassSchemas(file, name.Value)
+			}
+			usages[lhs.Name] = &extensionUsage{bzl.Value, name.Value, schemas}
+		case *build.CallExpr:
+			// A tag, e.g. deps.install(version = "1.0").
+			dot, ok := stmt.X.(*build.DotExpr)
+			if !ok {
+				continue
+			}
+			proxy, ok := dot.X.(*build.Ident)
+			if !ok || usages[proxy.Name] == nil || usages[proxy.Name].schemas == nil {
+				continue
+			}
+			usage := usages[proxy.Name]
+			schema, ok := usage.schemas[dot.Name]
+			if !ok {
+				msg := fmt.Sprintf("The module extension %q of %q has no tag class %q", usage.name, usage.label, dot.Name)
+				if len(usage.schemas) > 0 {
+					msg += ", the tag classes are: " + strings.Join(sortedKeys(usage.schemas), ", ")
+				}
+				findings = append(findings, makeLinterFinding(stmt, msg+"."))
+				continue
+			}
+			if !schema.complete {
+				continue
+			}
+			for _, arg := range stmt.List {
+				assign, ok := arg.(*build.AssignExpr)
+				if !ok {
+					continue
+				}
+				key, ok := assign.LHS.(*build.Ident)
+				if !ok {
+					continue
+				}
+				typ, ok := schema.attrs[key.Name]
+				if !ok {
+					msg := fmt.Sprintf("The tag class %q of the module extension %q has no attribute %q", dot.Name, usage.name, key.Name)
+					if len(schema.attrs) > 0 {
+						msg += ", its attributes are: " + strings.Join(sortedKeys(schema.attrs), ", ")
+					}
+					findings = append(findings, makeLinterFinding(assign, msg+"."))
+					continue
+				}
+				t, ok := attrValueTypes[typ]
+				if !ok {
+					continue
+				}
+				if expr, got := checkAttrValue(assign.RHS, t); expr != nil {
+					findings = append(findings, makeLinterFinding(expr, fmt.Sprintf(
+						"The attribute %q of the tag class %q expects %s (attr.%s), got %s.",
+						key.Name, dot.Name, describeAttrValueType(t), typ, got)))
+				}
+			}
+		}
+	}
+	return findings
+}
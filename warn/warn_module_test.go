@@ -0,0 +1,281 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+const moduleOrderMessage = `The statements of the MODULE.bazel file are not in the canonical order: module() first, then bazel_dep() sorted by name (dev dependencies last), overrides, toolchain registrations and extension usages followed by their tags and use_repo() calls.`
+
+func TestModuleOrder(t *testing.T) {
+	checkFindingsAndFix(t, "module-order", `
+bazel_dep(name = "rules_go", version = "0.50.1")
+
+# Used by the tests.
+bazel_dep(name = "rules_testing", version = "0.6.0", dev_dependency = True)
+
+go_sdk = use_extension("@rules_go//go:extensions.bzl", "go_sdk")
+
+single_version_override(module_name = "rules_go", patches = ["//:go.patch"])
+
+module(name = "my_module")
+
+bazel_dep(name = "gazelle", version = "0.39.1")
+
+maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+go_sdk.download(version = "1.23.0")
+maven.install(artifacts = ["com.google.guava:guava:33.0.0-jre"])
+use_repo(maven, "maven")
+register_toolchains("//toolchains:all")
+use_repo(go_sdk, "go_toolchains")
+`, `
+module(name = "my_module")
+
+bazel_dep(name = "gazelle", version = "0.39.1")
+bazel_dep(name = "rules_go", version = "0.50.1")
+
+# Used by the tests.
+bazel_dep(name = "rules_testing", version = "0.6.0", dev_dependency = True)
+
+single_version_override(module_name = "rules_go", patches = ["//:go.patch"])
+
+register_toolchains("//toolchains:all")
+
+go_sdk = use_extension("@rules_go//go:extensions.bzl", "go_sdk")
+go_sdk.download(version = "1.23.0")
+use_repo(go_sdk, "go_toolchains")
+
+maven = use_extension("@rules_jvm_external//:extensions.bzl", "maven")
+maven.install(artifacts = ["com.google.guava:guava:33.0.0-jre"])
+use_repo(maven, "maven")
+`, []string{
+		`:1: ` + moduleOrderMessage,
+	}, scopeModule)
+
+	checkFindings(t, "module-order", `
+module(name = "my_module")
+
+bazel_dep(name = "bazel_skylib", version = "1.7.1")
+bazel_dep(name = "rules_go", version = "0.50.1")
+bazel_dep(name = "rules_testing", version = "0.6.0", dev_dependency = True)
+
+go_sdk = use_extension("@rules_go//go:extensions.bzl", "go_sdk")
+go_sdk.download(version = "1.23.0")
+use_repo(go_sdk, "go_toolchains")
+`, []string{}, scopeModule)
+}
+
+func TestModuleOrderBarriers(t *testing.T) {
+	checkFindingsAndFix(t, "module-order", `
+VERSION = "1.0"
+
+bazel_dep(name = "rules_go", version = "0.50.1")
+bazel_dep(name = "gazelle", version = "0.39.1")
+
+include("//deps:go.MODULE.bazel")
+
+go_sdk.download(version = "1.23.0")
+
+# Standalone comment about the next dependency.
+
+bazel_dep(name = "bazel_skylib", version = "1.7.1")
+`, `
+VERSION = "1.0"
+
+bazel_dep(name = "gazelle", version = "0.39.1")
+bazel_dep(name = "rules_go", version = "0.50.1")
+
+include("//deps:go.MODULE.bazel")
+
+# Standalone comment about the next dependency.
+
+bazel_dep(name = "bazel_skylib", version = "1.7.1")
+
+go_sdk.download(version = "1.23.0")
+`, []string{
+		`:3: ` + moduleOrderMessage,
+	}, scopeModule)
+}
+
+func TestUnusedBazelDep(t *testing.T) {
+	fsys := fstest.MapFS{
+		"BUILD.bazel":            {Data: []byte(`cc_library(name = "a", deps = ["@abseil-cpp//absl/strings", "@com_google_protobuf"])`)},
+		"tools/defs.bzl":         {Data: []byte(`load("@rules_python//python:defs.bzl", "py_library")`)},
+		"tools/BUILD":            {Data: []byte(`alias(name = "cc", actual = "@rules_cc//cc:toolchain")`)},
+		"deps/go.MODULE.bazel":   {Data: []byte(`go_deps = use_extension("@gazelle//:extensions.bzl", "go_deps")`)},
+		"bazel-out/gen/BUILD":    {Data: []byte(`alias(name = "a", actual = "@output//:a")`)},
+		".git/hooks/hook.bzl":    {Data: []byte(`x = "@hidden"`)},
+		"docs/README.md":         {Data: []byte(`@googletest//:gtest`)},
+		"invalid/BUILD":          {Data: []byte(`cc_library(`)},
+		"tools/unused/extra.txt": {},
+	}
+	testFileReader = NewFileReaderWithFS(func(filename string) ([]byte, error) {
+		if file, ok := fsys[filename]; ok {
+			return file.Data, nil
+		}
+		return nil, fmt.Errorf("file not found")
+	}, fsys)
+	defer func() { testFileReader = nil }()
+	defer func(old []string) { ToolchainModules = old }(ToolchainModules)
+	ToolchainModules = []string{"toolchains_llvm"}
+
+	checkFindings(t, "unused-bazel-dep", `
+bazel_dep(name = "abseil-cpp", version = "20240722.0")
+bazel_dep(name = "gazelle", version = "0.39.1")
+bazel_dep(name = "googletest", version = "1.15.2", dev_dependency = True)
+bazel_dep(name = "output", version = "1.0")
+bazel_dep(name = "hidden", version = "1.0")
+bazel_dep(name = "platforms", version = "0.0.10", repo_name = None)
+bazel_dep(name = "protobuf", version = "28.2", repo_name = "com_google_protobuf")
+bazel_dep(name = "rules_cc", version = "0.0.9", repo_name = "my_rules_cc")
+bazel_dep(name = "rules_go", version = "0.50.1")
+bazel_dep(name = "rules_jvm_external", version = "6.0")  # keep
+bazel_dep(name = "rules_python", version = "0.36.0")
+bazel_dep(name = "toolchains_llvm", version = "1.2.0")
+
+go_sdk = use_extension("@rules_go//go:extensions.bzl", "go_sdk")
+`, []string{
+		`:3: The repository "@googletest" of the bazel_dep "googletest" isn't referenced by any BUILD, .bzl or MODULE.bazel file of the repository, remove the unused dependency or mark it with a "# keep" comment.`,
+		`:4: The repository "@output" of the bazel_dep "output" isn't referenced by any BUILD, .bzl or MODULE.bazel file of the repository, remove the unused dependency or mark it with a "# keep" comment.`,
+		`:5: The repository "@hidden" of the bazel_dep "hidden" isn't referenced by any BUILD, .bzl or MODULE.bazel file of the repository, remove the unused dependency or mark it with a "# keep" comment.`,
+		`:8: The repository "@my_rules_cc" of the bazel_dep "rules_cc" isn't referenced by any BUILD, .bzl or MODULE.bazel file of the repository, remove the unused dependency or mark it with a "# keep" comment.`,
+	}, scopeModule)
+
+	// The files of the repository can't be listed without a file system.
+	defer setUpFileReader(map[string]string{})()
+	checkFindings(t, "unused-bazel-dep", `
+bazel_dep(name = "googletest", version = "1.15.2")
+`, []string{}, scopeModule)
+}
+
+func TestModuleVersion(t *testing.T) {
+	checkFindingsAndFix(t, "module-version", `
+module(name = "my_module", version = "")
+
+bazel_dep(name = "rules_go", version = "v0.50.1")
+bazel_dep(name = "gazelle", version = " 0.39.1 ")
+bazel_dep(name = "rules_cc", version = "0.0.9")
+bazel_dep(name = "bazel_skylib", version = "1..2")
+bazel_dep(name = "protobuf", version = "29.0 rc2")
+bazel_dep(name = "rules_java")
+bazel_dep(name = "rules_python", version = "")
+
+single_version_override(module_name = "rules_cc", version = "")
+`, `
+module(name = "my_module")
+
+bazel_dep(name = "rules_go", version = "0.50.1")
+bazel_dep(name = "gazelle", version = "0.39.1")
+bazel_dep(name = "rules_cc", version = "0.0.9")
+bazel_dep(name = "bazel_skylib", version = "1..2")
+bazel_dep(name = "protobuf", version = "29.0 rc2")
+bazel_dep(name = "rules_java")
+bazel_dep(name = "rules_python", version = "")
+
+single_version_override(module_name = "rules_cc")
+`, []string{
+		`:1: The version of module() is empty, which is the default.`,
+		`:3: The version "v0.50.1" of bazel_dep("rules_go") starts with "v", module versions don't, use "0.50.1".`,
+		`:4: The version " 0.39.1 " of bazel_dep("gazelle") has leading or trailing whitespace.`,
+		`:6: The version "1..2" of bazel_dep("bazel_skylib") is invalid`,
+		`:7: The version "29.0 rc2" of bazel_dep("protobuf") is invalid`,
+		`:8: The bazel_dep("rules_java") has no version`,
+		`:9: The version of bazel_dep("rules_python") is empty`,
+		`:11: The version of single_version_override("rules_cc") is empty, which is the default.`,
+	}, scopeModule)
+
+	checkFindings(t, "module-version", `
+module(name = "my_module", version = VERSION)
+
+bazel_dep(name = "rules_java")
+bazel_dep(name = "rules_python", version = "")
+bazel_dep(name = "rules_go", version = "0.50.1-rc.1+build.2")
+
+local_path_override(module_name = "rules_java", path = "../rules_java")
+git_override(module_name = "rules_python", remote = "https://github.com/bazelbuild/rules_python")
+`, []string{}, scopeModule)
+}
+
+func TestModuleExtensionTag(t *testing.T) {
+	defer setUpFileReader(map[string]string{
+		"tools/extensions.bzl": `
+_INSTALL_ATTRS = {
+    "version": attr.string(),
+    "urls": attr.string_list(),
+}
+
+_install = tag_class(attrs = _INSTALL_ATTRS | {"patches": attr.label_list()})
+
+deps = module_extension(
+    implementation = _deps_impl,
+    tag_classes = {
+        "install": _install,
+        "toolchain": tag_class({
+            "name": attr.string(),
+            "env": attr.string_dict(),
+            "verbose": attr.bool(),
+            "jobs": attr.int(),
+        }),
+        "computed": tag_class(attrs = _computed_attrs()),
+        "nothing": tag_class(),
+    },
+)
+
+no_tags = module_extension(implementation = _impl)
+
+other = module_extension(implementation = _impl, tag_classes = _TAG_CLASSES)
+`,
+	})()
+
+	checkFindings(t, "module-extension-tag", `
+module(name = "my_module")
+
+deps = use_extension("//tools:extensions.bzl", "deps")
+deps.install(version = "1.0", urls = ["https://example.com"], patches = ["//:fix.patch"])
+deps.instal(version = "1.0")
+deps.install(verison = "1.0", urls = "https://example.com")
+deps.toolchain(name = "tc", env = {"A": 1}, verbose = 1, jobs = -4)
+deps.toolchain(name = NAME, env = ENV, verbose = True, jobs = "4")
+deps.computed(anything = 1)
+deps.nothing(name = "x")
+use_repo(deps, "dep")
+
+no_tags = use_extension("@my_module//tools:extensions.bzl", "no_tags")
+no_tags.install()
+
+other = use_extension("//tools:extensions.bzl", "other")
+other.anything(x = 1)
+
+missing = use_extension("//tools:missing.bzl", "missing")
+missing.anything(x = 1)
+
+remote = use_extension("@rules_foo//:extensions.bzl", "deps")
+remote.anything(x = 1)
+`, []string{
+		`:5: The module extension "deps" of "//tools:extensions.bzl" has no tag class "instal", the tag classes are: computed, install, nothing, toolchain.`,
+		`:6: The tag class "install" of the module extension "deps" has no attribute "verison", its attributes are: patches, urls, version.`,
+		`:6: The attribute "urls" of the tag class "install" expects a list of strings (attr.string_list), got a string.`,
+		`:7: The attribute "env" of the tag class "toolchain" expects a dict of strings to strings (attr.string_dict), got a dict containing an integer.`,
+		`:7: The attribute "verbose" of the tag class "toolchain" expects a boolean (attr.bool), got an integer.`,
+		`:8: The attribute "jobs" of the tag class "toolchain" expects an integer (attr.int), got a string.`,
+		`:10: The tag class "nothing" of the module extension "deps" has no attribute "name".`,
+		`:14: The module extension "no_tags" of "@my_module//tools:extensions.bzl" has no tag class "install".`,
+	}, scopeModule)
+}
@@ -19,7 +19,11 @@ limitations under the License.
 package warn
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/bzlenv"
 )
 
 func dictionaryConcatenationWarning(f *build.File) []*LinterFinding {
@@ -174,6 +178,176 @@ func integerDivisionWarning(f *build.File) []*LinterFinding {
 	return findings
 }
 
+// isNegativeInt reports whether an expression is a negative integer literal, e.g. `-1`.
+func isNegativeInt(expr build.Expr) bool {
+	unary, ok := expr.(*build.UnaryExpr)
+	if !ok || unary.Op != "-" {
+		return false
+	}
+	literal, ok := unary.X.(*build.LiteralExpr)
+	return ok && intRegexp.MatchString(literal.Token)
+}
+
+func negativeRepetitionWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+
+	types := DetectTypes(f)
+	check := func(expr, sequence, count build.Expr) bool {
+		t := types[sequence]
+		if (t != String && t != List) || !isNegativeInt(count) {
+			return false
+		}
+		findings = append(findings, makeLinterFinding(expr,
+			fmt.Sprintf("Repeating a %s a negative number of times yields an empty %s.", t, t)))
+		return true
+	}
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		switch expr := expr.(type) {
+		case *build.BinaryExpr:
+			if expr.Op == "*" && !check(expr, expr.X, expr.Y) {
+				check(expr, expr.Y, expr.X)
+			}
+		case *build.AssignExpr:
+			if expr.Op == "*=" {
+				check(expr, expr.LHS, expr.RHS)
+			}
+		}
+	})
+	return findings
+}
+
+func mixedTypeComparisonWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+
+	isNumber := func(t Type) bool {
+		return t == Int || t == Float
+	}
+	types := DetectTypes(f)
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		binary, ok := expr.(*build.BinaryExpr)
+		if !ok {
+			return
+		}
+		switch binary.Op {
+		case "<", "<=", ">", ">=":
+		default:
+			return
+		}
+		x, y := types[binary.X], types[binary.Y]
+		if x == Unknown || y == Unknown || x == y || (isNumber(x) && isNumber(y)) {
+			return
+		}
+		findings = append(findings, makeLinterFinding(binary,
+			fmt.Sprintf("Values of types %s and %s can't be ordered, the %q comparison fails at runtime.", x, y, binary.Op)))
+	})
+	return findings
+}
+
+// dictKeysSorted reports whether the keys of a dict literal are in the order legacy Starlark
+// interpreters iterate them. Dicts with keys other than string or integer literals, or with keys
+// of both kinds, are considered sorted.
+func dictKeysSorted(dict *build.DictExpr) bool {
+	var strs []string
+	var ints []int64
+	for _, kv := range dict.List {
+		switch key := kv.Key.(type) {
+		case *build.StringExpr:
+			strs = append(strs, key.Value)
+		case *build.LiteralExpr:
+			n, err := strconv.ParseInt(key.Token, 0, 64)
+			if err != nil {
+				return true
+			}
+			ints = append(ints, n)
+		default:
+			return true
+		}
+	}
+	if len(strs) > 0 && len(ints) > 0 {
+		return true
+	}
+	for i := 1; i < len(strs); i++ {
+		if strs[i] < strs[i-1] {
+			return false
+		}
+	}
+	for i := 1; i < len(ints); i++ {
+		if ints[i] < ints[i-1] {
+			return false
+		}
+	}
+	return true
+}
+
+func dictIterationOrderWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+
+	// The dict literals the variables are assigned, and the dict literals the identifiers refer to.
+	variables := make(map[int]*build.DictExpr)
+	literals := make(map[*build.Ident]*build.DictExpr)
+	var walk func(e *build.Expr, env *bzlenv.Environment)
+	walk = func(e *build.Expr, env *bzlenv.Environment) {
+		switch node := (*e).(type) {
+		case *build.AssignExpr:
+			if ident, ok := node.LHS.(*build.Ident); ok {
+				if binding := env.Get(ident.Name); binding != nil {
+					dict, _ := node.RHS.(*build.DictExpr)
+					if node.Op != "=" {
+						dict = nil
+					}
+					variables[binding.ID] = dict
+				}
+			}
+		case *build.Ident:
+			if binding := env.Get(node.Name); binding != nil && variables[binding.ID] != nil {
+				literals[node] = variables[binding.ID]
+			}
+		}
+		walkOnce(*e, env, walk)
+	}
+	var expr build.Expr = f
+	walk(&expr, bzlenv.NewEnvironment())
+
+	check := func(iterated build.Expr) {
+		if call, ok := iterated.(*build.CallExpr); ok && len(call.List) == 0 {
+			if dot, ok := call.X.(*build.DotExpr); ok {
+				switch dot.Name {
+				case "keys", "values", "items":
+					iterated = dot.X
+				}
+			}
+		}
+		var dict *build.DictExpr
+		switch iterated := iterated.(type) {
+		case *build.DictExpr:
+			dict = iterated
+		case *build.Ident:
+			dict = literals[iterated]
+		}
+		if dict == nil || dictKeysSorted(dict) {
+			return
+		}
+		findings = append(findings, makeLinterFinding(iterated,
+			"The keys of the dict aren't sorted. Dicts are iterated in insertion order, but legacy Starlark interpreters iterate them in sorted order. Sort the keys, or use sorted() if the order of the iteration matters."))
+	}
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		switch expr := expr.(type) {
+		case *build.ForStmt:
+			check(expr.X)
+		case *build.ForClause:
+			check(expr.X)
+		case *build.CallExpr:
+			if ident, ok := expr.X.(*build.Ident); ok && len(expr.List) == 1 {
+				switch ident.Name {
+				case "list", "enumerate", "tuple":
+					check(expr.List[0])
+				}
+			}
+		}
+	})
+	return findings
+}
+
 func listAppendWarning(f *build.File) []*LinterFinding {
 	var findings []*LinterFinding
 
@@ -203,3 +377,26 @@ func listAppendWarning(f *build.File) []*LinterFinding {
 	})
 	return findings
 }
+
+func missingCommaWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		list, ok := expr.(*build.ListExpr)
+		if !ok {
+			return
+		}
+		for i, item := range list.List {
+			str, ok := item.(*build.StringExpr)
+			if !ok || !str.MissingComma {
+				continue
+			}
+			newStr := *str
+			newStr.MissingComma = false
+			findings = append(findings, makeLinterFinding(str,
+				"Adjacent string literals in a list, a comma is probably missing before this item. Starlark doesn't concatenate them implicitly.",
+				LinterReplacement{&list.List[i], &newStr}))
+		}
+	})
+	return findings
+}
@@ -16,7 +16,11 @@ limitations under the License.
 
 package warn
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/bazelbuild/buildtools/build"
+)
 
 func TestIntegerDivision(t *testing.T) {
 	checkFindingsAndFix(t, "integer-division", `
@@ -29,6 +33,7 @@ e = a / b
 f = a / c
 g = c / a
 h = c / d
+i = len(e) / 2
 
 a /= b
 a /= c
@@ -44,6 +49,7 @@ e = a // b
 f = a / c
 g = c / a
 h = c / d
+i = len(e) // 2
 
 a //= b
 a /= c
@@ -52,7 +58,8 @@ c /= d
 `,
 		[]string{
 			":6: The \"/\" operator for integer division is deprecated in favor of \"//\".",
-			":11: The \"/=\" operator for integer division is deprecated in favor of \"//=\".",
+			":10: The \"/\" operator for integer division is deprecated in favor of \"//\".",
+			":12: The \"/=\" operator for integer division is deprecated in favor of \"//=\".",
 		},
 		scopeEverywhere)
 }
@@ -168,3 +175,116 @@ d.pop("a", default = "b") # warning
 		},
 		scopeEverywhere)
 }
+
+func TestNegativeRepetition(t *testing.T) {
+	checkFindings(t, "negative-repetition", `
+s = "-"
+l = [1]
+
+a = s * -1
+b = -10 * "="
+c = l * -2
+d = s * 2
+e = foo * -1
+f = "x" * - 0x1
+s *= -3
+`,
+		[]string{
+			`:4: Repeating a string a negative number of times yields an empty string.`,
+			`:5: Repeating a string a negative number of times yields an empty string.`,
+			`:6: Repeating a list a negative number of times yields an empty list.`,
+			`:9: Repeating a string a negative number of times yields an empty string.`,
+			`:10: Repeating a string a negative number of times yields an empty string.`,
+		},
+		scopeEverywhere)
+}
+
+func TestMixedTypeComparison(t *testing.T) {
+	checkFindings(t, "mixed-type-comparison", `
+n = len(srcs)
+
+a = n > "1"
+b = 1 <= 2.5
+c = "a" < "b"
+d = foo < 1
+e = [1] >= (1,)
+f = None < 1
+g = True > 0
+h = n == "1"
+`,
+		[]string{
+			`:3: Values of types int and string can't be ordered, the ">" comparison fails at runtime.`,
+			`:8: Values of types none and int can't be ordered, the "<" comparison fails at runtime.`,
+			`:9: Values of types bool and int can't be ordered, the ">" comparison fails at runtime.`,
+		},
+		scopeEverywhere)
+}
+
+func TestDictIterationOrder(t *testing.T) {
+	checkFindings(t, "dict-iteration-order", `
+SORTED = {"a": 1, "b": 2}
+UNSORTED = {"b": 1, "a": 2}
+NUMBERS = {2: "two", 10: "ten"}
+MIXED = {"b": 1, 1: "a"}
+
+def f(x):
+    for k in SORTED:
+        pass
+    for k in UNSORTED:
+        pass
+    for k, v in UNSORTED.items():
+        pass
+    l = [v for v in UNSORTED.values()]
+    l = [k for k in NUMBERS]
+    l = [k for k in MIXED]
+    l = list({"y": 1, "x": 2})
+    l = sorted(UNSORTED)
+    for k in x:
+        pass
+
+def g(UNSORTED):
+    for k in UNSORTED:
+        pass
+
+def h():
+    d = {"y": 1, "x": 2}
+    d = dict(x)
+    for k in d:
+        pass
+`,
+		[]string{
+			`:9: The keys of the dict aren't sorted.`,
+			`:11: The keys of the dict aren't sorted.`,
+			`:13: The keys of the dict aren't sorted.`,
+			`:16: The keys of the dict aren't sorted.`,
+		},
+		scopeEverywhere)
+}
+
+func TestMissingComma(t *testing.T) {
+	build.AllowMissingCommas = true
+	defer func() { build.AllowMissingCommas = false }()
+	checkFindingsAndFix(t, "missing-comma", `
+x = [
+    "a"
+    "b",
+    "c",
+]
+y = ["d" "e" "f"]
+z = "g" + "h"
+`, `
+x = [
+    "a",
+    "b",
+    "c",
+]
+y = ["d", "e", "f"]
+z = "g" + "h"
+`,
+		[]string{
+			`:3: Adjacent string literals in a list, a comma is probably missing before this item.`,
+			`:6: Adjacent string literals in a list, a comma is probably missing before this item.`,
+			`:6: Adjacent string literals in a list, a comma is probably missing before this item.`,
+		},
+		scopeEverywhere)
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about Python 2 remnants in legacy Starlark files
+
+package warn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// PythonCleanupWarnings are the warnings about Python 2 remnants, the group enabled by
+// the -py_cleanup flag of buildifier.
+var PythonCleanupWarnings = []string{
+	"chained-comparison",
+	"legacy-dict-method",
+	"legacy-octal",
+	"line-continuation",
+}
+
+// comparisonOperators are the operators that Python chains and Starlark doesn't.
+var comparisonOperators = map[string]bool{
+	"<":      true,
+	">":      true,
+	"<=":     true,
+	">=":     true,
+	"==":     true,
+	"!=":     true,
+	"in":     true,
+	"not in": true,
+}
+
+func chainedComparisonWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	build.WalkPointers(f, func(e *build.Expr, stack []build.Expr) {
+		outer, ok := (*e).(*build.BinaryExpr)
+		if !ok || !comparisonOperators[outer.Op] {
+			return
+		}
+		// Explicitly parenthesized comparisons are ParenExpr nodes and are not reported.
+		inner, ok := outer.X.(*build.BinaryExpr)
+		if !ok || !comparisonOperators[inner.Op] {
+			return
+		}
+		message := fmt.Sprintf(`Chained comparisons are not supported, "a %s b %s c" means "(a %s b) %s c" rather than "a %s b and b %s c".`,
+			inner.Op, outer.Op, inner.Op, outer.Op, inner.Op, outer.Op)
+
+		// The middle operand is evaluated twice after the fix, only do that for simple ones.
+		var middle build.Expr
+		switch y := inner.Y.(type) {
+		case *build.Ident:
+			middle = &build.Ident{Name: y.Name}
+		case *build.LiteralExpr:
+			middle = &build.LiteralExpr{Token: y.Token}
+		case *build.StringExpr:
+			middle = &build.StringExpr{Value: y.Value}
+		default:
+			findings = append(findings, makeLinterFinding(outer, message))
+			return
+		}
+		newExpr := &build.BinaryExpr{
+			X:  inner,
+			Op: "and",
+			Y: &build.BinaryExpr{
+				X:  middle,
+				Op: outer.Op,
+				Y:  outer.Y,
+			},
+		}
+		findings = append(findings, makeLinterFinding(outer, message, LinterReplacement{e, newExpr}))
+	})
+	return findings
+}
+
+// legacyDictMethods maps the Python 2 dict methods to their Starlark replacements.
+var legacyDictMethods = map[string]string{
+	"iteritems":  "items",
+	"iterkeys":   "keys",
+	"itervalues": "values",
+}
+
+func legacyDictMethodWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	build.WalkPointers(f, func(e *build.Expr, stack []build.Expr) {
+		call, ok := (*e).(*build.CallExpr)
+		if !ok {
+			return
+		}
+		dot, ok := call.X.(*build.DotExpr)
+		if !ok {
+			return
+		}
+		if name, ok := legacyDictMethods[dot.Name]; ok && len(call.List) == 0 {
+			newDot := *dot
+			newDot.Name = name
+			newCall := *call
+			newCall.X = &newDot
+			findings = append(findings, makeLinterFinding(call,
+				fmt.Sprintf(`The method ".%s()" doesn't exist in Starlark, use ".%s()" instead.`, dot.Name, name),
+				LinterReplacement{e, &newCall}))
+			return
+		}
+		if dot.Name != "has_key" || len(call.List) != 1 {
+			return
+		}
+		message := `The method ".has_key()" doesn't exist in Starlark, use the "in" operator instead.`
+		if _, ok := call.List[0].(*build.AssignExpr); ok {
+			findings = append(findings, makeLinterFinding(call, message))
+			return
+		}
+		newExpr := &build.BinaryExpr{
+			X:  call.List[0],
+			Op: "in",
+			Y:  dot.X,
+		}
+		findings = append(findings, makeLinterFinding(call, message, LinterReplacement{e, newExpr}))
+	})
+	return findings
+}
+
+func legacyOctalWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	build.WalkPointers(f, func(e *build.Expr, stack []build.Expr) {
+		lit, ok := (*e).(*build.LiteralExpr)
+		if !ok {
+			return
+		}
+		if len(lit.Token) < 2 || lit.Token[0] != '0' || strings.TrimLeft(lit.Token[1:], "01234567") != "" {
+			// Not an octal literal, e.g. 09 or 01.5 can't be fixed by adding the prefix.
+			return
+		}
+		newLit := *lit
+		newLit.Token = "0o" + lit.Token[1:]
+		findings = append(findings, makeLinterFinding(lit,
+			fmt.Sprintf(`Octal literals need the "0o" prefix in Starlark, use %s instead of %s.`, newLit.Token, lit.Token),
+			LinterReplacement{e, &newLit}))
+	})
+	return findings
+}
+
+// insideBrackets reports whether an expression is printed inside brackets, where line breaks
+// don't need backslashes.
+func insideBrackets(stack []build.Expr, expr build.Expr) bool {
+	for i, parent := range stack {
+		switch parent := parent.(type) {
+		case *build.CallExpr, *build.ListExpr, *build.DictExpr, *build.SetExpr, *build.ParenExpr,
+			*build.Comprehension, *build.IndexExpr, *build.SliceExpr, *build.LoadStmt:
+			return true
+		case *build.TupleExpr:
+			if !parent.NoBrackets {
+				return true
+			}
+		case *build.DefStmt:
+			child := expr
+			if i+1 < len(stack) {
+				child = stack[i+1]
+			}
+			for _, param := range parent.Params {
+				if param == child {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// joinLines returns a copy of an expression without the line breaks after its operators, and
+// whether there were any.
+func joinLines(expr build.Expr) (build.Expr, bool) {
+	switch expr := expr.(type) {
+	case *build.BinaryExpr:
+		x, xChanged := joinLines(expr.X)
+		y, yChanged := joinLines(expr.Y)
+		if !expr.LineBreak && !xChanged && !yChanged {
+			return expr, false
+		}
+		newExpr := *expr
+		newExpr.X, newExpr.Y, newExpr.LineBreak = x, y, false
+		return &newExpr, true
+	case *build.AssignExpr:
+		rhs, changed := joinLines(expr.RHS)
+		if !expr.LineBreak && !changed {
+			return expr, false
+		}
+		newExpr := *expr
+		newExpr.RHS, newExpr.LineBreak = rhs, false
+		return &newExpr, true
+	case *build.UnaryExpr:
+		x, changed := joinLines(expr.X)
+		if !changed {
+			return expr, false
+		}
+		newExpr := *expr
+		newExpr.X = x
+		return &newExpr, true
+	}
+	return expr, false
+}
+
+func lineContinuationWarning(f *build.File) []*LinterFinding {
+	var findings []*LinterFinding
+	build.WalkPointers(f, func(e *build.Expr, stack []build.Expr) {
+		switch (*e).(type) {
+		case *build.BinaryExpr, *build.AssignExpr:
+		default:
+			return
+		}
+		if len(stack) > 0 {
+			// Only check the outermost operator, joinLines handles the nested ones.
+			switch stack[len(stack)-1].(type) {
+			case *build.BinaryExpr, *build.AssignExpr, *build.UnaryExpr:
+				return
+			}
+		}
+		// Outside brackets a line break after an operator is only possible with a backslash,
+		// the printer keeps it.
+		if insideBrackets(stack, *e) {
+			return
+		}
+		if newExpr, changed := joinLines(*e); changed {
+			findings = append(findings, makeLinterFinding(*e,
+				"Avoid backslash line continuations, use parentheses to split long expressions instead.",
+				LinterReplacement{e, newExpr}))
+		}
+	})
+	return findings
+}
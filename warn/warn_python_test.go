@@ -0,0 +1,123 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import "testing"
+
+func TestChainedComparison(t *testing.T) {
+	checkFindingsAndFix(t, "chained-comparison", `
+a = 0 <= x < 10
+b = x == y == "foo"
+c = f(x) < g(x) < 3
+d = (0 <= x) < 10
+e = 0 <= x and x < 10
+`, `
+a = 0 <= x and x < 10
+b = x == y and y == "foo"
+c = f(x) < g(x) < 3
+d = (0 <= x) < 10
+e = 0 <= x and x < 10
+`,
+		[]string{
+			`:1: Chained comparisons are not supported, "a <= b < c" means "(a <= b) < c" rather than "a <= b and b < c".`,
+			`:2: Chained comparisons are not supported, "a == b == c" means "(a == b) == c" rather than "a == b and b == c".`,
+			`:3: Chained comparisons are not supported, "a < b < c" means "(a < b) < c" rather than "a < b and b < c".`,
+		},
+		scopeEverywhere)
+}
+
+func TestLegacyDictMethod(t *testing.T) {
+	checkFindingsAndFix(t, "legacy-dict-method", `
+for k, v in d.iteritems():
+    if d.has_key(k):
+        print(d.iterkeys(), d.itervalues())
+x = d.items()
+y = d.iteritems(1)
+z = d.has_key(key = 1)
+`, `
+for k, v in d.items():
+    if k in d:
+        print(d.keys(), d.values())
+x = d.items()
+y = d.iteritems(1)
+z = d.has_key(key = 1)
+`,
+		[]string{
+			`:1: The method ".iteritems()" doesn't exist in Starlark, use ".items()" instead.`,
+			`:2: The method ".has_key()" doesn't exist in Starlark, use the "in" operator instead.`,
+			`:3: The method ".iterkeys()" doesn't exist in Starlark, use ".keys()" instead.`,
+			`:3: The method ".itervalues()" doesn't exist in Starlark, use ".values()" instead.`,
+			`:6: The method ".has_key()" doesn't exist in Starlark, use the "in" operator instead.`,
+		},
+		scopeEverywhere)
+}
+
+func TestLegacyOctal(t *testing.T) {
+	checkFindingsAndFix(t, "legacy-octal", `
+a = 0755
+b = 0o644
+c = 0
+d = 0x1F
+e = 10
+f = 09
+g = 0.5
+`, `
+a = 0o755
+b = 0o644
+c = 0
+d = 0x1F
+e = 10
+f = 09
+g = 0.5
+`,
+		[]string{
+			`:1: Octal literals need the "0o" prefix in Starlark, use 0o755 instead of 0755.`,
+		},
+		scopeEverywhere)
+}
+
+func TestLineContinuation(t *testing.T) {
+	checkFindingsAndFix(t, "line-continuation", `
+x = 1 + \
+    2 + \
+    3
+if a and \
+   not b:
+    y = [
+        1 +
+        2,
+    ]
+z = \
+    foo(1 +
+        2)
+`, `
+x = 1 + 2 + 3
+if a and not b:
+    y = [
+        1 +
+        2,
+    ]
+z = foo(1 +
+        2)
+`,
+		[]string{
+			`:1: Avoid backslash line continuations, use parentheses to split long expressions instead.`,
+			`:4: Avoid backslash line continuations, use parentheses to split long expressions instead.`,
+			`:10: Avoid backslash line continuations, use parentheses to split long expressions instead.`,
+		},
+		scopeEverywhere)
+}
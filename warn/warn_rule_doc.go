@@ -0,0 +1,252 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Warnings about the documentation of rules, repository rules, module extensions and their attributes
+
+package warn
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/buildtools/build"
+)
+
+// DocCoverage is the set of definitions on which the rule-doc and attr-doc warnings require a
+// documentation: "public" for the rules, repository rules and module extensions assigned to
+// public names, the tag classes of the public module extensions, and their attributes whose
+// names don't start with an underscore, or "all" for all of them.
+var DocCoverage = "public"
+
+// documentedKinds maps the functions defining the symbols documented by Stardoc to the
+// descriptions of the symbols.
+var documentedKinds = map[string]string{
+	"module_extension": "module extension",
+	"repository_rule":  "repository rule",
+	"rule":             "rule",
+	"tag_class":        "tag class",
+}
+
+// A documentedDefinition is a call defining a symbol documented by Stardoc.
+type documentedDefinition struct {
+	call   *build.CallExpr
+	kind   string // the function called, e.g. rule
+	name   string // the name of the symbol, or of the tag for a tag class, empty if unknown
+	public bool
+}
+
+// description returns the kind of the symbol followed by its name, e.g. `rule "go_binary"`.
+func (d *documentedDefinition) description() string {
+	if d.name == "" {
+		return documentedKinds[d.kind]
+	}
+	return fmt.Sprintf("%s %q", documentedKinds[d.kind], d.name)
+}
+
+// resolveGlobal returns the value of a global variable assigned once at the top level of the
+// file, or the expression itself if it isn't such a variable.
+func resolveGlobal(expr build.Expr, globals map[string]build.Expr) build.Expr {
+	if ident, ok := expr.(*build.Ident); ok {
+		if value, ok := globals[ident.Name]; ok {
+			return value
+		}
+	}
+	return expr
+}
+
+// documentedDefinitions returns the calls of a file defining symbols documented by Stardoc.
+func documentedDefinitions(f *build.File) (defs []*documentedDefinition, globals map[string]build.Expr) {
+	byCall := make(map[*build.CallExpr]*documentedDefinition)
+	build.Walk(f, func(expr build.Expr, stack []build.Expr) {
+		call, ok := expr.(*build.CallExpr)
+		if !ok {
+			return
+		}
+		if ident, ok := call.X.(*build.Ident); ok && documentedKinds[ident.Name] != "" {
+			d := &documentedDefinition{call: call, kind: ident.Name}
+			defs = append(defs, d)
+			byCall[call] = d
+		}
+	})
+
+	globals = make(map[string]build.Expr)
+	assignments := make(map[string]int)
+	for _, stmt := range f.Stmt {
+		assign, ok := stmt.(*build.AssignExpr)
+		if !ok {
+			continue
+		}
+		lhs, ok := assign.LHS.(*build.Ident)
+		if !ok {
+			continue
+		}
+		globals[lhs.Name] = assign.RHS
+		assignments[lhs.Name]++
+		if call, ok := assign.RHS.(*build.CallExpr); ok && byCall[call] != nil {
+			byCall[call].name = lhs.Name
+			byCall[call].public = !strings.HasPrefix(lhs.Name, "_")
+		}
+	}
+	for name, n := range assignments {
+		if n > 1 {
+			// The value depends on where the variable is used.
+			delete(globals, name)
+		}
+	}
+
+	// The tag classes are documented as part of their module extensions.
+	for _, d := range defs {
+		if d.kind != "module_extension" {
+			continue
+		}
+		_, _, param := getParam(d.call.List, "tag_classes")
+		if param == nil {
+			continue
+		}
+		dict, ok := resolveGlobal(param.RHS, globals).(*build.DictExpr)
+		if !ok {
+			continue
+		}
+		for _, kv := range dict.List {
+			call, ok := resolveGlobal(kv.Value, globals).(*build.CallExpr)
+			if !ok || byCall[call] == nil || byCall[call].kind != "tag_class" {
+				continue
+			}
+			if key, ok := kv.Key.(*build.StringExpr); ok {
+				byCall[call].name = key.Value
+			}
+			byCall[call].public = byCall[call].public || d.public
+		}
+	}
+	return defs, globals
+}
+
+// hasDoc reports whether a call has a non-empty doc parameter. A doc that isn't a string
+// literal, e.g. a variable, is assumed to be non-empty.
+func hasDoc(call *build.CallExpr) bool {
+	_, _, param := getParam(call.List, "doc")
+	if param == nil {
+		return false
+	}
+	if str, ok := param.RHS.(*build.StringExpr); ok {
+		return strings.TrimSpace(str.Value) != ""
+	}
+	return true
+}
+
+// isDocCovered reports whether a definition must be documented according to DocCoverage.
+func isDocCovered(d *documentedDefinition) bool {
+	return DocCoverage == "all" || d.public
+}
+
+// attrEntries returns the entries of a dict of attributes, possibly assigned to a global
+// variable or merged from several dicts with the | or + operators. The second result is false
+// if some of the entries are unknown, e.g. because they come from a function call.
+func attrEntries(expr build.Expr, globals map[string]build.Expr) ([]*build.KeyValueExpr, bool) {
+	switch expr := resolveGlobal(expr, globals).(type) {
+	case *build.DictExpr:
+		return expr.List, true
+	case *build.BinaryExpr:
+		if expr.Op == "|" || expr.Op == "+" {
+			x, okX := attrEntries(expr.X, globals)
+			y, okY := attrEntries(expr.Y, globals)
+			return append(x, y...), okX && okY
+		}
+	}
+	return nil, false
+}
+
+// definitionAttrs returns the dict of attributes of a definition, or nil if it has none.
+func definitionAttrs(d *documentedDefinition) build.Expr {
+	if _, _, param := getParam(d.call.List, "attrs"); param != nil {
+		return param.RHS
+	}
+	if d.kind == "tag_class" && len(d.call.List) > 0 {
+		// The attributes are the first positional argument of tag_class.
+		if _, ok := d.call.List[0].(*build.AssignExpr); !ok {
+			return d.call.List[0]
+		}
+	}
+	return nil
+}
+
+// attrType returns the function defining an attribute, e.g. string_list for attr.string_list(),
+// or an empty string if it isn't a call of a function of the attr module.
+func attrType(value build.Expr) string {
+	call, ok := value.(*build.CallExpr)
+	if !ok {
+		return ""
+	}
+	dot, ok := call.X.(*build.DotExpr)
+	if !ok {
+		return ""
+	}
+	if ident, ok := dot.X.(*build.Ident); !ok || ident.Name != "attr" {
+		return ""
+	}
+	return dot.Name
+}
+
+func ruleDocWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	defs, _ := documentedDefinitions(f)
+	for _, d := range defs {
+		if !isDocCovered(d) || hasDoc(d.call) {
+			continue
+		}
+		findings = append(findings, makeLinterFinding(d.call,
+			fmt.Sprintf(`The %s has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`, d.description())))
+	}
+	return findings
+}
+
+func attrDocWarning(f *build.File) []*LinterFinding {
+	if f.Type != build.TypeBzl {
+		return nil
+	}
+
+	var findings []*LinterFinding
+	defs, globals := documentedDefinitions(f)
+	// The dicts of attributes may be shared by several definitions.
+	seen := make(map[*build.CallExpr]bool)
+	for _, d := range defs {
+		if d.kind == "module_extension" || !isDocCovered(d) {
+			continue
+		}
+		entries, _ := attrEntries(definitionAttrs(d), globals)
+		for _, kv := range entries {
+			key, ok := kv.Key.(*build.StringExpr)
+			if !ok || DocCoverage != "all" && strings.HasPrefix(key.Value, "_") {
+				continue
+			}
+			call, ok := kv.Value.(*build.CallExpr)
+			if !ok || seen[call] || attrType(call) == "" {
+				continue
+			}
+			seen[call] = true
+			if hasDoc(call) {
+				continue
+			}
+			findings = append(findings, makeLinterFinding(call,
+				fmt.Sprintf(`The attribute %q of the %s has no documentation. Add a "doc" parameter to attr.%s().`, key.Value, d.description(), attrType(call))))
+		}
+	}
+	return findings
+}
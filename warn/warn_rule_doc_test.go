@@ -0,0 +1,94 @@
+/*
+Copyright 2026 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warn
+
+import "testing"
+
+const ruleDocInput = `
+_ATTRS = {
+    "srcs": attr.label_list(doc = "The sources."),
+    "deps": attr.label_list(),
+    "_tool": attr.label(default = "//tools:tool"),
+}
+
+my_rule = rule(
+    implementation = _impl,
+    attrs = _ATTRS | {"out": attr.output(doc = "")},
+)
+
+documented_rule = rule(
+    implementation = _impl,
+    doc = "A documented rule.",
+    attrs = _ATTRS,
+)
+
+_private_rule = rule(
+    implementation = _impl,
+    attrs = {"name_suffix": attr.string()},
+)
+
+http_thing = repository_rule(
+    implementation = _repo_impl,
+    doc = DOC,
+)
+
+_install = tag_class(attrs = {"version": attr.string()})
+
+deps = module_extension(
+    implementation = _ext_impl,
+    doc = "   ",
+    tag_classes = {
+        "install": _install,
+        "toolchain": tag_class({"name": attr.string(doc = "The name.")}, doc = "A toolchain."),
+    },
+)
+`
+
+func TestRuleDocWarning(t *testing.T) {
+	checkFindings(t, "rule-doc", ruleDocInput, []string{
+		`:7: The rule "my_rule" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+		`:28: The tag class "install" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+		`:30: The module extension "deps" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+	}, scopeBzl)
+
+	defer func(old string) { DocCoverage = old }(DocCoverage)
+	DocCoverage = "all"
+	checkFindings(t, "rule-doc", ruleDocInput, []string{
+		`:7: The rule "my_rule" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+		`:18: The rule "_private_rule" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+		`:28: The tag class "install" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+		`:30: The module extension "deps" has no documentation. Add a "doc" parameter describing it, Stardoc uses it to generate the documentation.`,
+	}, scopeBzl)
+}
+
+func TestAttrDocWarning(t *testing.T) {
+	checkFindings(t, "attr-doc", ruleDocInput, []string{
+		`:3: The attribute "deps" of the rule "my_rule" has no documentation. Add a "doc" parameter to attr.label_list().`,
+		`:9: The attribute "out" of the rule "my_rule" has no documentation. Add a "doc" parameter to attr.output().`,
+		`:28: The attribute "version" of the tag class "install" has no documentation. Add a "doc" parameter to attr.string().`,
+	}, scopeBzl)
+
+	defer func(old string) { DocCoverage = old }(DocCoverage)
+	DocCoverage = "all"
+	checkFindings(t, "attr-doc", ruleDocInput, []string{
+		`:3: The attribute "deps" of the rule "my_rule" has no documentation. Add a "doc" parameter to attr.label_list().`,
+		`:4: The attribute "_tool" of the rule "my_rule" has no documentation. Add a "doc" parameter to attr.label().`,
+		`:9: The attribute "out" of the rule "my_rule" has no documentation. Add a "doc" parameter to attr.output().`,
+		`:20: The attribute "name_suffix" of the rule "_private_rule" has no documentation. Add a "doc" parameter to attr.string().`,
+		`:28: The attribute "version" of the tag class "install" has no documentation. Add a "doc" parameter to attr.string().`,
+	}, scopeBzl)
+}
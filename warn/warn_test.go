@@ -19,6 +19,7 @@ package warn
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -88,6 +89,10 @@ func getFilename(fileType build.FileType) string {
 		return "test_file.bzl"
 	case build.TypeModule:
 		return "MODULE.bazel"
+	case build.TypeRepo:
+		return "REPO.bazel"
+	case build.TypeVendor:
+		return "VENDOR.bazel"
 	default:
 		return "test_file.strlrk"
 	}
@@ -341,6 +346,43 @@ attr.baz("baz", cfg = "data")
 	}
 }
 
+func TestGeneratedRegionFindings(t *testing.T) {
+	contents := `a = 1 / 2
+
+# buildifier: begin-generated
+b = 3 / 4
+# buildifier: end-generated
+
+c = 5 / 6
+`
+	f, err := build.ParseBzl("file.bzl", []byte(contents))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	findings := FileWarnings(f, []string{"integer-division"}, nil, ModeWarn, testFileReader)
+	var generated []bool
+	for _, f := range findings {
+		generated = append(generated, f.Generated)
+	}
+	if want := []bool{false, true, false}; !reflect.DeepEqual(generated, want) {
+		t.Errorf("generated findings = %v, want %v", generated, want)
+	}
+
+	FixWarnings(f, []string{"integer-division"}, false, testFileReader)
+	want := `a = 1 // 2
+
+# buildifier: begin-generated
+b = 3 / 4
+# buildifier: end-generated
+
+c = 5 // 6
+`
+	if got := string(build.Format(f)); got != want {
+		t.Errorf("fixed file = %q, want %q", got, want)
+	}
+}
+
 func TestDisabledWarning(t *testing.T) {
 	contents := `foo()
 
@@ -420,3 +462,20 @@ cc_library(
 		}
 	}
 }
+
+func TestWarningGroups(t *testing.T) {
+	known := make(map[string]bool)
+	for _, warning := range AllWarnings {
+		known[warning] = true
+	}
+	for group, warnings := range WarningGroups {
+		if known[group] {
+			t.Errorf("the group %q has the same name as a warning", group)
+		}
+		for _, warning := range warnings {
+			if !known[warning] {
+				t.Errorf("the group %q contains the unknown warning %q", group, warning)
+			}
+		}
+	}
+}
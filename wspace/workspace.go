@@ -28,6 +28,7 @@ import (
 
 const workspaceFile = "WORKSPACE"
 const buildFile = "BUILD"
+const moduleFile = "MODULE.bazel"
 
 // IsRegularFile returns true if the path refers to a regular file after
 // following symlinks.
@@ -149,6 +150,58 @@ func FindRepoBuildFiles(root string) (map[string]string, error) {
 	return files, nil
 }
 
+// FindModuleRepoDirs returns the directories of the repositories of the modules declared in the
+// MODULE.bazel file of the workspace at root, keyed by module name: the root module itself and
+// the modules overridden with local_path_override. Other module repositories are fetched by
+// Bazel and have no directory in the workspace.
+func FindModuleRepoDirs(root string) (map[string]string, error) {
+	module := filepath.Join(root, moduleFile)
+	data, err := os.ReadFile(module)
+	if err != nil {
+		return nil, err
+	}
+	ast, err := build.ParseModule(module, data)
+	if err != nil {
+		return nil, err
+	}
+	dirs := make(map[string]string)
+	for _, r := range ast.Rules("module") {
+		if name := r.AttrString("name"); name != "" {
+			dirs[name] = root
+		}
+	}
+	for _, r := range ast.Rules("local_path_override") {
+		name, dir := r.AttrString("module_name"), r.AttrString("path")
+		if name == "" || dir == "" {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, filepath.FromSlash(dir))
+		}
+		dirs[name] = dir
+	}
+	return dirs, nil
+}
+
+// CanonicalRepoModule returns the name of the module whose repository has the given canonical
+// name, e.g. "rules_go" for "rules_go~0.50.1", "rules_go~" or "rules_go+", and false for the
+// repositories generated by module extensions ("rules_go~~go_sdk~go_sdk", "rules_go++go_sdk+go_sdk").
+// The canonical name of the main repository is empty or "_main".
+func CanonicalRepoModule(canonicalName string) (string, bool) {
+	if canonicalName == "" || canonicalName == "_main" {
+		return "", true
+	}
+	for _, separator := range []string{"+", "~"} {
+		if parts := strings.Split(canonicalName, separator); len(parts) > 1 {
+			if len(parts) > 2 {
+				return "", false
+			}
+			return parts[0], true
+		}
+	}
+	return "", false
+}
+
 // relPath returns a path for `target` relative to `base`, but an empty string
 // instead of "." if the directories are equivalent, and with forward slashes.
 func relPath(base, target string) (string, error) {
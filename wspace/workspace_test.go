@@ -114,6 +114,60 @@ new_http_archive(
 	}
 }
 
+func TestFindModuleRepoDirs(t *testing.T) {
+	tmp := t.TempDir()
+	module := []byte(`
+module(name = "main")
+
+bazel_dep(name = "a", version = "1.0")
+local_path_override(
+    module_name = "a",
+    path = "third_party/a",
+)
+local_path_override(
+    module_name = "b",
+    path = "/opt/b",
+)
+`)
+	if err := os.WriteFile(filepath.Join(tmp, moduleFile), module, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dirs, err := FindModuleRepoDirs(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"main": tmp,
+		"a":    filepath.Join(tmp, "third_party", "a"),
+		"b":    "/opt/b",
+	}
+	if !reflect.DeepEqual(dirs, expected) {
+		t.Errorf("FindModuleRepoDirs(`%s`) = %q; want %q", module, dirs, expected)
+	}
+}
+
+func TestCanonicalRepoModule(t *testing.T) {
+	for _, tc := range []struct {
+		canonicalName string
+		module        string
+		ok            bool
+	}{
+		{"", "", true},
+		{"_main", "", true},
+		{"rules_go~0.50.1", "rules_go", true},
+		{"rules_go~", "rules_go", true},
+		{"rules_go+", "rules_go", true},
+		{"rules_go+0.50.1", "rules_go", true},
+		{"rules_go~~go_sdk~go_sdk", "", false},
+		{"rules_go++go_sdk+go_sdk", "", false},
+		{"rules_go", "", false},
+	} {
+		if module, ok := CanonicalRepoModule(tc.canonicalName); module != tc.module || ok != tc.ok {
+			t.Errorf("CanonicalRepoModule(%q) = %q, %t; want %q, %t", tc.canonicalName, module, ok, tc.module, tc.ok)
+		}
+	}
+}
+
 func checkSplitFilePathOutput(t *testing.T, name, filename, expectedWorkspaceRoot, expectedPkg, expectedLabel string) {
 	workspaceRoot, pkg, label := SplitFilePath(filename)
 	if workspaceRoot != expectedWorkspaceRoot {